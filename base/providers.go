@@ -2,7 +2,9 @@ package base
 
 import (
 	businessV2 "space/business/V2"
+	"space/business/admins"
 	"space/business/backoffice"
+	"space/business/backtest"
 	bondetf "space/business/bondEtf"
 	bondsdetails "space/business/bondsDetails"
 	"space/business/charges"
@@ -11,10 +13,12 @@ import (
 	"space/business/finvu"
 	"space/business/freshdesk"
 	"space/business/funds"
+	globalsearch "space/business/globalSearch"
 	"space/business/pins"
 	"space/business/pockets"
 	portfolioanalyzer "space/business/portfolioAnalyzer"
 	"space/business/reports"
+	"space/business/screeners"
 	"space/business/scrips"
 	searchscriptv2 "space/business/searchScriptV2"
 	technicalindicators "space/business/technicalIndicators"
@@ -163,7 +167,7 @@ func InitProviders(mongodb db.MongoDatabase, redisCli cache.RedisCache, contract
 	scripsProvider := BuildScripsProvider(mongodb)
 	v3.InitScripsProvider(scripsProvider)
 
-	searchScriptProviderV2 := BuildSearchScriptProviderV2(contractCacheCli, smartCacheCli)
+	searchScriptProviderV2 := BuildSearchScriptProviderV2(contractCacheCli, smartCacheCli, redisCli)
 	v2.InitContractDetailsProvider(searchScriptProviderV2)
 
 	warningProvider := BuildWarningProvider()
@@ -213,6 +217,9 @@ func InitProviders(mongodb db.MongoDatabase, redisCli cache.RedisCache, contract
 	pocketsProvider := BuildPocketsProvider(mongodb, redisCli)
 	v1.InitPocketsProvider(pocketsProvider)
 
+	adminsProvider := BuildAdminsProvider(mongodb)
+	v1.InitAdminsProvider(adminsProvider)
+
 	watchlistProviderV2 := BuildWatchListProviderV2(mongodb, contractCacheCli)
 	v2.InitWatchListProviderV2(watchlistProviderV2)
 
@@ -231,6 +238,15 @@ func InitProviders(mongodb db.MongoDatabase, redisCli cache.RedisCache, contract
 	sipProvider := BuildSipProvider()
 	v1.InitSipProvider(sipProvider)
 
+	screenerProviderV2 := BuildScreenerProvider(mongodb, redisCli)
+	v2.InitScreenerProviderV2(screenerProviderV2)
+
+	backtestProviderV2 := BuildBacktestProvider()
+	v2.InitBacktestProviderV2(backtestProviderV2)
+
+	globalSearchProvider := BuildGlobalSearchProvider(searchScriptProviderV2, executePocketProviderV3, collectionsProvider, ipoProvider, cmotsProviderV2)
+	v3.InitGlobalSearchProvider(globalSearchProvider)
+
 }
 
 func BuildLoginProvider(mongodb db.MongoDatabase, redisCli cache.RedisCache) models.LoginProvider {
@@ -351,6 +367,15 @@ func BuildCmotsProvider(contractCacheCli cache.ContractCache) models.CMOTSProvid
 	return cmots.InitCmotsProvider(pgDB, contractCacheCli)
 }
 
+func BuildScreenerProvider(mongodb db.MongoDatabase, redisCli cache.RedisCache) models.ScreenerProvider {
+	pgDB := db.GetPgObj()
+	return screeners.InitScreenersProvider(mongodb, pgDB, redisCli)
+}
+
+func BuildBacktestProvider() models.BacktestProvider {
+	return backtest.InitBacktestProvider()
+}
+
 func BuildUserDetailsProvider(mongodb db.MongoDatabase) models.UserDetailsProvider {
 	return userdetails.InitUserDetailsProvider(mongodb)
 }
@@ -393,8 +418,8 @@ func BuildBondEtfProvider() models.BondEtfProvider {
 	return bondetf.InitBondEtfObj()
 }
 
-func BuildSearchScriptProviderV2(contractCacheCli cache.ContractCache, smartCacheCli cache.SmartCache) models.ContractDetailsProviderV2 {
-	return searchscriptv2.InitSearchScript(contractCacheCli, smartCacheCli)
+func BuildSearchScriptProviderV2(contractCacheCli cache.ContractCache, smartCacheCli cache.SmartCache, redisCli cache.RedisCache) models.ContractDetailsProviderV2 {
+	return searchscriptv2.InitSearchScript(contractCacheCli, smartCacheCli, redisCli)
 }
 
 func BuildWarningProvider() models.WarningProvider {
@@ -455,6 +480,10 @@ func BuildPinsProvider(mongodb db.MongoDatabase) models.PinsProvider {
 	return pins.InitPins(mongodb)
 }
 
+func BuildAdminsProvider(mongodb db.MongoDatabase) models.AdminsProvider {
+	return admins.InitAdminsProvider(mongodb)
+}
+
 func BuildPocketsProvider(mongodb db.MongoDatabase, redisCli cache.RedisCache) models.PocketsProvider {
 	return pockets.InitExecutePocketV2Provider(mongodb, redisCli)
 }
@@ -479,6 +508,10 @@ func BuildBondsDetailsProvider(mongodb db.MongoDatabase, contractCacheCli cache.
 	return bondsdetails.InitBondsDetailsProvider(mongodb, contractCacheCli)
 }
 
+func BuildGlobalSearchProvider(scripProvider models.ContractDetailsProviderV2, pocketProvider models.ExecutePocketV3, collectionsProvider models.CollectionsProvider, ipoProvider models.IpoProvider, cmotsProvider models.CMOTSProviderV2) models.GlobalSearchProvider {
+	return globalsearch.InitGlobalSearchProvider(scripProvider, pocketProvider, collectionsProvider, ipoProvider, cmotsProvider)
+}
+
 func BuildSipProvider() models.SipProvider {
 	return tradelab.InitSipSerivceProvider()
 }
@@ -0,0 +1,253 @@
+package admins
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/db"
+	"space/dbops"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AdminsObj struct {
+	mongodb db.MongoDatabase
+}
+
+func InitAdminsProvider(mongodb db.MongoDatabase) AdminsObj {
+	defer models.HandlePanic()
+	adminsObj := AdminsObj{mongodb: mongodb}
+	return adminsObj
+}
+
+var CallSaveAdmin = func(admin models.MongoAdmin) error {
+	filter := bson.M{"userId": admin.UserId}
+	_, err := dbops.MongoRepo.ReplaceOne(constants.ADMINCOLLECTION, filter, admin)
+	return err
+}
+
+var CallFetchAllAdmins = func() ([]models.MongoAdmin, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"userId": 1})
+	cursor, err := dbops.MongoRepo.GetMongoCollection(constants.ADMINCOLLECTION).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var admins []models.MongoAdmin
+	if err := cursor.All(ctx, &admins); err != nil {
+		return nil, err
+	}
+	return admins, nil
+}
+
+var callHashAdminPassword = func(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// adminDetails is an admin as the apis and the audit trail show it, without
+// the password hash.
+func adminDetails(admin models.MongoAdmin) models.AdminDetails {
+	return models.AdminDetails{
+		UserId:            admin.UserId,
+		Name:              admin.Name,
+		Role:              admin.Role,
+		Disabled:          admin.Disabled,
+		CreatedBy:         admin.CreatedBy,
+		CreatedAt:         unixOrZero(admin.CreatedAt),
+		UpdatedAt:         unixOrZero(admin.UpdatedAt),
+		PasswordUpdatedAt: unixOrZero(admin.PasswordUpdatedAt),
+	}
+}
+
+func (obj AdminsObj) CreateAdmin(req models.CreateAdminRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	if !ValidAdminRole(req.Role) {
+		return apihelpers.SendErrorResponse(false, constants.InvalidAdminRole, http.StatusBadRequest)
+	}
+
+	_, err := CallFetchAdmin(req.UserId)
+	if err == nil {
+		return apihelpers.SendErrorResponse(false, constants.AdminAlreadyExists, http.StatusBadRequest)
+	}
+	if err.Error() != constants.MongoNoDocError {
+		loggerconfig.Error("CreateAdmin, error finding admin:", err, " userId:", req.UserId, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	hash, err := callHashAdminPassword(req.Password)
+	if err != nil {
+		loggerconfig.Error("CreateAdmin, error hashing password:", err, " userId:", req.UserId, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	now := adminAuditNow()
+	admin := models.MongoAdmin{
+		UserId:            req.UserId,
+		Password:          hash,
+		Name:              req.Name,
+		Role:              req.Role,
+		CreatedBy:         reqH.AdminUserId,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		PasswordUpdatedAt: now,
+	}
+	if err := dbops.MongoRepo.InsertOne(constants.ADMINCOLLECTION, admin); err != nil {
+		loggerconfig.Error("CreateAdmin, mongo error:", err, " userId:", req.UserId, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	resp := adminDetails(admin)
+	RecordAdminMutation(reqH, constants.AdminActionCreate, constants.AdminEntityAdmin, admin.UserId, nil, resp)
+
+	loggerconfig.Info("CreateAdmin Successful, response:", helpers.LogStructAsJSON(resp), " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+	apiRes.Data = resp
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+
+	return http.StatusOK, apiRes
+}
+
+func (obj AdminsObj) UpdateAdmin(req models.UpdateAdminRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	if req.Role != "" && !ValidAdminRole(req.Role) {
+		return apihelpers.SendErrorResponse(false, constants.InvalidAdminRole, http.StatusBadRequest)
+	}
+
+	admin, err := CallFetchAdmin(req.UserId)
+	if err != nil {
+		if err.Error() == constants.MongoNoDocError {
+			return apihelpers.SendErrorResponse(false, constants.AdminDoesNotExists, http.StatusBadRequest)
+		}
+		loggerconfig.Error("UpdateAdmin, error finding admin:", err, " userId:", req.UserId, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	// an admin taking away their own access could leave nobody to restore it
+	if req.UserId == reqH.AdminUserId && ((req.Role != "" && req.Role != admin.Role) || (req.Disabled != nil && *req.Disabled)) {
+		return apihelpers.SendErrorResponse(false, constants.AdminPermissionDenied, http.StatusForbidden)
+	}
+
+	before := adminDetails(admin)
+	now := adminAuditNow()
+	if req.Name != "" {
+		admin.Name = req.Name
+	}
+	if req.Role != "" {
+		admin.Role = req.Role
+	}
+	if req.Disabled != nil {
+		admin.Disabled = *req.Disabled
+	}
+	if req.Password != "" {
+		hash, err := callHashAdminPassword(req.Password)
+		if err != nil {
+			loggerconfig.Error("UpdateAdmin, error hashing password:", err, " userId:", req.UserId, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+			return apihelpers.SendInternalServerError()
+		}
+		admin.Password = hash
+		admin.PasswordUpdatedAt = now
+	}
+	admin.UpdatedAt = now
+
+	if err := CallSaveAdmin(admin); err != nil {
+		loggerconfig.Error("UpdateAdmin, mongo error:", err, " userId:", req.UserId, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	resp := adminDetails(admin)
+	RecordAdminMutation(reqH, constants.AdminActionModify, constants.AdminEntityAdmin, admin.UserId, before, resp)
+
+	loggerconfig.Info("UpdateAdmin Successful, response:", helpers.LogStructAsJSON(resp), " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+	apiRes.Data = resp
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+
+	return http.StatusOK, apiRes
+}
+
+func (obj AdminsObj) FetchAdmins(reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	admins, err := CallFetchAllAdmins()
+	if err != nil {
+		loggerconfig.Error("FetchAdmins, mongo error:", err, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	resp := models.FetchAdminsResponse{Admins: []models.AdminDetails{}}
+	for _, admin := range admins {
+		resp.Admins = append(resp.Admins, adminDetails(admin))
+	}
+
+	apiRes.Data = resp
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+
+	return http.StatusOK, apiRes
+}
+
+func (obj AdminsObj) FetchAdminAudit(req models.FetchAdminAuditRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	filter := bson.M{}
+	if req.AdminUserId != "" {
+		filter["adminUserId"] = req.AdminUserId
+	}
+	if req.Entity != "" {
+		filter["entity"] = req.Entity
+	}
+	if req.EntityId != "" {
+		filter["entityId"] = req.EntityId
+	}
+
+	audit, err := CallFetchAdminAudit(filter)
+	if err != nil {
+		loggerconfig.Error("FetchAdminAudit, mongo error:", err, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	resp := models.FetchAdminAuditResponse{Audit: []models.AdminAuditItem{}}
+	for _, entry := range audit {
+		resp.Audit = append(resp.Audit, models.AdminAuditItem{
+			AuditId:     entry.AuditId,
+			AdminUserId: entry.AdminUserId,
+			AdminRole:   entry.AdminRole,
+			Action:      entry.Action,
+			Entity:      entry.Entity,
+			EntityId:    entry.EntityId,
+			Before:      entry.Before,
+			After:       entry.After,
+			Changes:     entry.Changes,
+			Ip:          entry.Ip,
+			CreatedAt:   entry.CreatedAt.Unix(),
+		})
+	}
+
+	apiRes.Data = resp
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+
+	return http.StatusOK, apiRes
+}
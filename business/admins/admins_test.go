@@ -0,0 +1,199 @@
+package admins
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+)
+
+func TestRoleHasPermission(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission string
+		want       bool
+	}{
+		{constants.AdminRoleSuper, constants.AdminPermFundsWrite, true},
+		{constants.AdminRoleSuper, "", true},
+		{constants.AdminRolePocketEditor, constants.AdminPermPocketsEdit, true},
+		{constants.AdminRolePocketEditor, constants.AdminPermPocketsPublish, false},
+		{constants.AdminRolePocketPublisher, constants.AdminPermPocketsPublish, true},
+		{constants.AdminRoleContentAdmin, constants.AdminPermBlockDealsWrite, true},
+		{constants.AdminRoleContentAdmin, constants.AdminPermPocketsEdit, false},
+		{constants.AdminRoleSupportReadOnly, constants.AdminPermCollectionsRead, true},
+		{constants.AdminRoleSupportReadOnly, constants.AdminPermCollectionsWrite, false},
+		{constants.AdminRoleSupportReadOnly, constants.AdminPermClientWrite, false},
+		{constants.AdminRoleSupportReadOnly, "", false},
+		{"", constants.AdminPermPocketsRead, false},
+		{"owner", constants.AdminPermPocketsRead, false},
+	}
+	for _, tt := range tests {
+		if got := RoleHasPermission(tt.role, tt.permission); got != tt.want {
+			t.Errorf("RoleHasPermission(%q, %q) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestAdminMutations(t *testing.T) {
+	origNow, origFetch, origFetchAll, origSave, origInsert, origHash := adminAuditNow, CallFetchAdmin, CallFetchAllAdmins, CallSaveAdmin, CallInsertAdminAudit, callHashAdminPassword
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		adminAuditNow, CallFetchAdmin, CallFetchAllAdmins, CallSaveAdmin, CallInsertAdminAudit, callHashAdminPassword = origNow, origFetch, origFetchAll, origSave, origInsert, origHash
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+
+	var stored map[string]models.MongoAdmin
+	var audit []models.MongoAdminAudit
+	reset := func() {
+		stored, audit = map[string]models.MongoAdmin{}, nil
+	}
+	adminAuditNow = func() time.Time { return time.Date(2026, 10, 19, 11, 0, 0, 0, constants.LocationKolkata) }
+	CallFetchAdmin = func(userId string) (models.MongoAdmin, error) {
+		admin, ok := stored[userId]
+		if !ok {
+			return admin, errors.New(constants.MongoNoDocError)
+		}
+		return admin, nil
+	}
+	CallSaveAdmin = func(admin models.MongoAdmin) error {
+		stored[admin.UserId] = admin
+		return nil
+	}
+	CallInsertAdminAudit = func(entry models.MongoAdminAudit) error {
+		audit = append(audit, entry)
+		return nil
+	}
+	callHashAdminPassword = func(password string) (string, error) { return "hash-" + password, nil }
+
+	t.Run("record mutation", func(t *testing.T) {
+		reset()
+		reqH := models.ReqHeader{AdminUserId: "editor1", AdminRole: constants.AdminRolePocketEditor, RequestId: "req1", ClientPublicIP: "10.1.2.3"}
+
+		before := models.MongoPockets{PocketId: "p1", PocketName: "Banks", PocketTokens: []models.PocketsMetaData{{Token: "1", Qty: "2"}}}
+		after := before
+		after.PocketName = "Private Banks"
+		after.PocketTokens = []models.PocketsMetaData{{Token: "1", Qty: "3"}}
+		RecordAdminMutation(reqH, constants.AdminActionModify, constants.AdminEntityPocket, "p1", before, &after)
+
+		if len(audit) != 1 {
+			t.Fatalf("audit entries = %d, want 1", len(audit))
+		}
+		entry := audit[0]
+		if entry.AdminUserId != "editor1" || entry.AdminRole != constants.AdminRolePocketEditor || entry.EntityId != "p1" || entry.RequestId != "req1" || entry.Ip != "10.1.2.3" {
+			t.Errorf("audit entry = %+v", entry)
+		}
+		var fields []string
+		for _, change := range entry.Changes {
+			fields = append(fields, change.Field)
+		}
+		if !reflect.DeepEqual(fields, []string{"pocketName", "pocketTokens"}) {
+			t.Errorf("changed fields = %v", fields)
+		}
+		if entry.Changes[0].Before != "Banks" || entry.Changes[0].After != "Private Banks" {
+			t.Errorf("pocketName change = %+v", entry.Changes[0])
+		}
+
+		// a delete keeps the whole entity in before and every field as a change
+		var deleted *models.MongoPockets
+		RecordAdminMutation(reqH, constants.AdminActionDelete, constants.AdminEntityPocket, "p1", after, deleted)
+		entry = audit[1]
+		if entry.After != nil || entry.Before["pocketName"] != "Private Banks" || len(entry.Changes) != len(entry.Before) {
+			t.Errorf("delete audit entry = %+v", entry)
+		}
+	})
+
+	t.Run("update admin", func(t *testing.T) {
+		reset()
+		stored["root"] = models.MongoAdmin{UserId: "root", Role: constants.AdminRoleSuper}
+		stored["editor1"] = models.MongoAdmin{UserId: "editor1", Password: "hash-old", Role: constants.AdminRolePocketEditor}
+		reqH := models.ReqHeader{AdminUserId: "root", AdminRole: constants.AdminRoleSuper}
+
+		code, res := AdminsObj{}.UpdateAdmin(models.UpdateAdminRequest{UserId: "editor1", Role: constants.AdminRolePocketPublisher, Password: "new-password"}, reqH)
+		if code != http.StatusOK || !res.Status {
+			t.Fatalf("UpdateAdmin() = %d %+v", code, res)
+		}
+		if admin := stored["editor1"]; admin.Role != constants.AdminRolePocketPublisher || admin.Password != "hash-new-password" {
+			t.Errorf("stored admin = %+v", admin)
+		}
+		entry := audit[0]
+		var fields []string
+		for _, change := range entry.Changes {
+			fields = append(fields, change.Field)
+		}
+		if !reflect.DeepEqual(fields, []string{"passwordUpdatedAt", "role", "updatedAt"}) {
+			t.Errorf("changed fields = %v", fields)
+		}
+		if _, ok := entry.After["password"]; ok {
+			t.Errorf("password hash written to the audit trail")
+		}
+
+		disable := true
+		tests := []struct {
+			name string
+			req  models.UpdateAdminRequest
+			code int
+			err  string
+		}{
+			{"unknown role", models.UpdateAdminRequest{UserId: "editor1", Role: "owner"}, http.StatusBadRequest, constants.InvalidAdminRole},
+			{"unknown admin", models.UpdateAdminRequest{UserId: "nobody", Role: constants.AdminRoleContentAdmin}, http.StatusBadRequest, constants.AdminDoesNotExists},
+			{"own role", models.UpdateAdminRequest{UserId: "root", Role: constants.AdminRoleContentAdmin}, http.StatusForbidden, constants.AdminPermissionDenied},
+			{"disable self", models.UpdateAdminRequest{UserId: "root", Disabled: &disable}, http.StatusForbidden, constants.AdminPermissionDenied},
+		}
+		for _, tt := range tests {
+			code, res := AdminsObj{}.UpdateAdmin(tt.req, reqH)
+			if code != tt.code || res.ErrorCode != tt.err {
+				t.Errorf("%s: UpdateAdmin() = %d %s, want %d %s", tt.name, code, res.ErrorCode, tt.code, tt.err)
+			}
+		}
+		if len(audit) != 1 {
+			t.Errorf("rejected updates were audited, entries = %d", len(audit))
+		}
+	})
+
+	t.Run("create admin without a role", func(t *testing.T) {
+		reset()
+		code, res := AdminsObj{}.CreateAdmin(models.CreateAdminRequest{UserId: "new1", Password: "secret", Name: "New"}, models.ReqHeader{AdminUserId: "root"})
+		if code != http.StatusBadRequest || res.ErrorCode != constants.InvalidAdminRole || len(stored) != 0 || len(audit) != 0 {
+			t.Errorf("CreateAdmin() = %d %s, stored = %v", code, res.ErrorCode, stored)
+		}
+	})
+
+	t.Run("bootstrap roles", func(t *testing.T) {
+		origSuperUsers := constants.AdminSuperUsers
+		t.Cleanup(func() { constants.AdminSuperUsers = origSuperUsers })
+		constants.AdminSuperUsers = []string{"owner"}
+
+		reset()
+		stored["legacy"] = models.MongoAdmin{UserId: "legacy", Name: "Legacy"}
+		stored["owner"] = models.MongoAdmin{UserId: "owner", Name: "Owner"}
+		stored["editor1"] = models.MongoAdmin{UserId: "editor1", Role: constants.AdminRolePocketEditor}
+		CallFetchAllAdmins = func() ([]models.MongoAdmin, error) {
+			return []models.MongoAdmin{stored["editor1"], stored["legacy"], stored["owner"]}, nil
+		}
+
+		BootstrapAdminRoles()
+		BootstrapAdminRoles()
+
+		// only the configured admins become superAdmin, the rest get the least access
+		if stored["legacy"].Role != constants.AdminRoleSupportReadOnly || stored["owner"].Role != constants.AdminRoleSuper {
+			t.Errorf("legacy role = %q, owner role = %q", stored["legacy"].Role, stored["owner"].Role)
+		}
+		// an admin with a role keeps it
+		if stored["editor1"].Role != constants.AdminRolePocketEditor {
+			t.Errorf("editor1 role = %q", stored["editor1"].Role)
+		}
+		if len(audit) != 2 || audit[0].EntityId != "legacy" || audit[0].AdminUserId != constants.AdminRoleBootstrap {
+			t.Fatalf("audit = %+v", audit)
+		}
+		if change := audit[0].Changes[0]; change.Field != "role" || change.After != constants.AdminRoleSupportReadOnly {
+			t.Errorf("changes = %+v", audit[0].Changes)
+		}
+	})
+}
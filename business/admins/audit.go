@@ -0,0 +1,121 @@
+package admins
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"space/constants"
+	"space/dbops"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var adminAuditNow = helpers.GetCurrentTimeInIST
+
+// The audit collection is insert only, nothing in the service updates or
+// deletes its entries.
+var CallInsertAdminAudit = func(audit models.MongoAdminAudit) error {
+	return dbops.MongoRepo.InsertOne(constants.ADMINAUDITCOLLECTION, audit)
+}
+
+var CallFetchAdminAudit = func(filter bson.M) ([]models.MongoAdminAudit, error) {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.ADMINAUDITCOLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(constants.AdminAuditHistoryLimit)
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var audit []models.MongoAdminAudit
+	if err := cursor.All(ctx, &audit); err != nil {
+		return nil, err
+	}
+	return audit, nil
+}
+
+/*
+RecordAdminMutation adds a change made by the admin of reqH to the audit
+trail, with the entity as it was before and after the change and the fields
+that differ between them. The change has already been made, so a failure to
+record it is alerted on rather than returned.
+*/
+func RecordAdminMutation(reqH models.ReqHeader, action, entity, entityId string, before, after interface{}) {
+	audit := models.MongoAdminAudit{
+		AuditId:     uuid.New().String(),
+		AdminUserId: reqH.AdminUserId,
+		AdminRole:   reqH.AdminRole,
+		Action:      action,
+		Entity:      entity,
+		EntityId:    entityId,
+		RequestId:   reqH.RequestId,
+		Ip:          reqH.ClientPublicIP,
+		CreatedAt:   adminAuditNow(),
+	}
+
+	var err error
+	if audit.Before, err = auditDocument(before); err != nil {
+		loggerconfig.Error("RecordAdminMutation, error encoding before:", err, " entity:", entity, " entityId:", entityId, " requestId:", reqH.RequestId)
+	}
+	if audit.After, err = auditDocument(after); err != nil {
+		loggerconfig.Error("RecordAdminMutation, error encoding after:", err, " entity:", entity, " entityId:", entityId, " requestId:", reqH.RequestId)
+	}
+	audit.Changes = diffAuditDocuments(audit.Before, audit.After)
+
+	if err := CallInsertAdminAudit(audit); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, platform:", reqH.Platform, " RecordAdminMutation, mongo error:", err, " adminUserId:", reqH.AdminUserId, " action:", action, " entity:", entity, " entityId:", entityId, " requestId:", reqH.RequestId)
+	}
+}
+
+// auditDocument flattens an entity into the fields it has in api responses.
+func auditDocument(entity interface{}) (map[string]interface{}, error) {
+	if entity == nil {
+		return nil, nil
+	}
+	if value := reflect.ValueOf(entity); value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil, nil
+	}
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// diffAuditDocuments lists the top level fields whose values differ, sorted
+// by field.
+func diffAuditDocuments(before, after map[string]interface{}) []models.AdminAuditChange {
+	fields := map[string]bool{}
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	changes := []models.AdminAuditChange{}
+	for field := range fields {
+		oldValue, newValue := before[field], after[field]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, models.AdminAuditChange{Field: field, Before: oldValue, After: newValue})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
@@ -0,0 +1,103 @@
+package admins
+
+import (
+	"space/constants"
+	"space/dbops"
+	"space/loggerconfig"
+	"space/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// adminRolePermissions lists what each role may do. superAdmin may do
+// everything and an admin without a known role nothing.
+var adminRolePermissions = map[string][]string{
+	constants.AdminRolePocketEditor: {
+		constants.AdminPermPocketsRead,
+		constants.AdminPermPocketsEdit,
+	},
+	constants.AdminRolePocketPublisher: {
+		constants.AdminPermPocketsRead,
+		constants.AdminPermPocketsEdit,
+		constants.AdminPermPocketsPublish,
+	},
+	constants.AdminRoleContentAdmin: {
+		constants.AdminPermPocketsRead,
+		constants.AdminPermCollectionsRead,
+		constants.AdminPermCollectionsWrite,
+		constants.AdminPermBlockDealsWrite,
+	},
+	constants.AdminRoleSupportReadOnly: {
+		constants.AdminPermPocketsRead,
+		constants.AdminPermCollectionsRead,
+		constants.AdminPermFundsRead,
+		constants.AdminPermClientSupport,
+	},
+}
+
+func ValidAdminRole(role string) bool {
+	_, ok := adminRolePermissions[role]
+	return ok || role == constants.AdminRoleSuper
+}
+
+// RoleHasPermission reports whether a role grants a permission. An empty
+// permission is one no role but superAdmin has.
+func RoleHasPermission(role, permission string) bool {
+	if role == constants.AdminRoleSuper {
+		return true
+	}
+	if permission == "" {
+		return false
+	}
+	for _, granted := range adminRolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+var CallFetchAdmin = func(userId string) (models.MongoAdmin, error) {
+	var admin models.MongoAdmin
+	err := dbops.MongoRepo.FindOne(constants.ADMINCOLLECTION, bson.M{"userId": userId}, &admin)
+	return admin, err
+}
+
+/*
+BootstrapAdminRoles gives a role to the admins saved before roles existed,
+who would otherwise be refused everywhere. They get supportReadOnly, the
+least any role grants, except those named in AdminSuperUsers, who get
+superAdmin so someone can hand out the other roles. It runs at startup and
+only touches admins without a role, so an admin given a role later keeps it.
+Each change goes to the audit trail.
+*/
+func BootstrapAdminRoles() {
+	defer models.HandlePanic()
+
+	admins, err := CallFetchAllAdmins()
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, BootstrapAdminRoles, error fetching admins:", err)
+		return
+	}
+
+	reqH := models.ReqHeader{AdminUserId: constants.AdminRoleBootstrap, RequestId: constants.AdminRoleBootstrap}
+	for _, admin := range admins {
+		if admin.Role != "" {
+			continue
+		}
+		before := adminDetails(admin)
+		admin.Role = constants.AdminRoleSupportReadOnly
+		for _, userId := range constants.AdminSuperUsers {
+			if userId == admin.UserId {
+				admin.Role = constants.AdminRoleSuper
+			}
+		}
+		admin.UpdatedAt = adminAuditNow()
+		if err := CallSaveAdmin(admin); err != nil {
+			loggerconfig.Error("Alert Severity:P1-High, BootstrapAdminRoles, mongo error:", err, " userId:", admin.UserId)
+			continue
+		}
+		RecordAdminMutation(reqH, constants.AdminActionModify, constants.AdminEntityAdmin, admin.UserId, before, adminDetails(admin))
+		loggerconfig.Info("BootstrapAdminRoles, gave ", admin.Role, " to admin without a role, userId:", admin.UserId)
+	}
+}
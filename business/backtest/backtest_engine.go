@@ -0,0 +1,532 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"space/constants"
+	"space/models"
+
+	technicalindicatorsV2 "space/business/technicalIndicatorsV2"
+)
+
+// indicatorSpec describes how an operand name maps onto the technicalIndicatorsV2
+// calculators. lookback is the number of bars before the series is meaningful;
+// some calculators pad the warm-up with zeros rather than NaN.
+type indicatorSpec struct {
+	defaults []int
+	lookback func(params []int) int
+	compute  func(data models.ChartDataResponse, params []int) ([]float64, error)
+}
+
+// errNotEnoughData is returned when the requested range is too short for the
+// strategy, unlike other runBacktest errors it is the caller's to fix.
+var errNotEnoughData = errors.New("not enough data points")
+
+func firstParam(params []int) int { return params[0] }
+
+func candleField(index int) func(models.ChartDataResponse, []int) ([]float64, error) {
+	return func(data models.ChartDataResponse, _ []int) ([]float64, error) {
+		series := make([]float64, len(data.Data.Candles))
+		for i, candle := range data.Data.Candles {
+			if len(candle) <= index {
+				return nil, fmt.Errorf("invalid candle data: %v", candle)
+			}
+			value, ok := toFloat(candle[index])
+			if !ok {
+				return nil, fmt.Errorf("invalid candle value type: %v", candle[index])
+			}
+			series[i] = value
+		}
+		return series, nil
+	}
+}
+
+var indicatorRegistry = map[string]indicatorSpec{
+	"open":   {lookback: func([]int) int { return 0 }, compute: candleField(1)},
+	"high":   {lookback: func([]int) int { return 0 }, compute: candleField(2)},
+	"low":    {lookback: func([]int) int { return 0 }, compute: candleField(3)},
+	"close":  {lookback: func([]int) int { return 0 }, compute: candleField(4)},
+	"volume": {lookback: func([]int) int { return 0 }, compute: candleField(5)},
+	"sma": {defaults: []int{20}, lookback: firstParam, compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+		return technicalindicatorsV2.CalculateSMA(d, p[0])
+	}},
+	"ema": {defaults: []int{20}, lookback: firstParam, compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+		return technicalindicatorsV2.CalculateEMA(d, p[0])
+	}},
+	"hma": {defaults: []int{20}, lookback: firstParam, compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+		return technicalindicatorsV2.CalculateHullMA(d, p[0])
+	}},
+	"vwma": {defaults: []int{20}, lookback: firstParam, compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+		return technicalindicatorsV2.CalculateVWMA(d, p[0])
+	}},
+	"rsi": {defaults: []int{14}, lookback: firstParam, compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+		return technicalindicatorsV2.CalculateRSI(d, p[0])
+	}},
+	"cci": {defaults: []int{20}, lookback: firstParam, compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+		return technicalindicatorsV2.CalculateCCI(d, p[0])
+	}},
+	"adx": {defaults: []int{14}, lookback: func(p []int) int { return 2 * p[0] }, compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+		return technicalindicatorsV2.CalculateADX(d, p[0])
+	}},
+	"momentum": {defaults: []int{10}, lookback: firstParam, compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+		return technicalindicatorsV2.CalculateMomentum(d, p[0])
+	}},
+	"williams_r": {defaults: []int{14}, lookback: firstParam, compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+		return technicalindicatorsV2.CalculateWilliamsR(d, p[0])
+	}},
+	"ao": {lookback: func([]int) int { return 34 }, compute: func(d models.ChartDataResponse, _ []int) ([]float64, error) {
+		return technicalindicatorsV2.CalculateAwesomeOscillator(d)
+	}},
+	"ultimate": {defaults: []int{7, 14, 28}, lookback: func(p []int) int { return p[2] }, compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+		return technicalindicatorsV2.CalculateUltimateOscillator(d, p[0], p[1], p[2])
+	}},
+	"macd":        macdSpec(0),
+	"macd_signal": macdSpec(1),
+	"macd_hist":   macdSpec(2),
+	"stoch_k":     stochasticSpec(0),
+	"stoch_d":     stochasticSpec(1),
+}
+
+func macdSpec(line int) indicatorSpec {
+	return indicatorSpec{
+		defaults: []int{12, 26, 9},
+		lookback: func(p []int) int { return p[1] + p[2] },
+		compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+			macd, signal, histogram, err := technicalindicatorsV2.CalculateMACD(d, p[0], p[1], p[2])
+			return [][]float64{macd, signal, histogram}[line], err
+		},
+	}
+}
+
+func stochasticSpec(line int) indicatorSpec {
+	return indicatorSpec{
+		defaults: []int{14, 3, 3},
+		lookback: func(p []int) int { return p[0] + p[1] + p[2] },
+		compute: func(d models.ChartDataResponse, p []int) ([]float64, error) {
+			k, dLine, err := technicalindicatorsV2.CalculateStochastic(d, p[0], p[1], p[2])
+			return [][]float64{k, dLine}[line], err
+		},
+	}
+}
+
+// validateStrategy checks every operand against the registry and fills in
+// default params so the engine can assume well-formed input.
+func validateStrategy(strategy *models.BacktestStrategy) error {
+	if len(strategy.EntryRules) == 0 {
+		return errors.New("at least one entry rule is required")
+	}
+	if len(strategy.EntryRules) > constants.BacktestMaxRules || len(strategy.ExitRules) > constants.BacktestMaxRules {
+		return fmt.Errorf("at most %d rules are allowed", constants.BacktestMaxRules)
+	}
+	if len(strategy.ExitRules) == 0 && strategy.StopLossPct == 0 && strategy.TargetPct == 0 {
+		return errors.New("an exit rule, stop loss or target is required")
+	}
+	for _, rules := range [][]models.BacktestCondition{strategy.EntryRules, strategy.ExitRules} {
+		for i := range rules {
+			for _, operand := range []*models.BacktestOperand{&rules[i].Left, &rules[i].Right} {
+				if err := normaliseOperand(operand); err != nil {
+					return err
+				}
+			}
+			if rules[i].Left.Indicator == "" {
+				return errors.New("left operand of a rule must be an indicator")
+			}
+		}
+	}
+	return nil
+}
+
+func normaliseOperand(operand *models.BacktestOperand) error {
+	if operand.Indicator == "" {
+		return nil
+	}
+	operand.Indicator = strings.ToLower(strings.TrimSpace(operand.Indicator))
+	spec, ok := indicatorRegistry[operand.Indicator]
+	if !ok {
+		return fmt.Errorf("unknown indicator %q", operand.Indicator)
+	}
+	if len(operand.Params) == 0 {
+		operand.Params = spec.defaults
+	}
+	if len(operand.Params) != len(spec.defaults) {
+		return fmt.Errorf("indicator %q takes %d params", operand.Indicator, len(spec.defaults))
+	}
+	for _, p := range operand.Params {
+		if p <= 0 {
+			return fmt.Errorf("indicator %q params must be positive", operand.Indicator)
+		}
+	}
+	return nil
+}
+
+type backtestBar struct {
+	timestamp string
+	at        time.Time
+	open      float64
+	high      float64
+	low       float64
+	close     float64
+}
+
+type seriesValue struct {
+	values   []float64
+	lookback int
+}
+
+type backtestEngine struct {
+	req    models.RunBacktestReq
+	reqH   models.ReqHeader
+	bars   []backtestBar
+	series map[string]seriesValue
+}
+
+func operandKey(operand models.BacktestOperand) string {
+	return fmt.Sprintf("%s%v", operand.Indicator, operand.Params)
+}
+
+// runBacktest replays a long-only strategy over the candles. Signals are
+// evaluated on bar close and filled at that close; stop loss and target are
+// checked against the high/low of later bars, stop first when both are hit.
+func runBacktest(req models.RunBacktestReq, chartData models.ChartDataResponse, reqH models.ReqHeader) (models.RunBacktestRes, error) {
+	var res models.RunBacktestRes
+
+	bars, err := parseBars(chartData)
+	if err != nil {
+		return res, err
+	}
+	if len(bars) < 2 {
+		return res, errNotEnoughData
+	}
+
+	engine := backtestEngine{req: req, reqH: reqH, bars: bars, series: make(map[string]seriesValue)}
+	for _, rules := range [][]models.BacktestCondition{req.Strategy.EntryRules, req.Strategy.ExitRules} {
+		for _, rule := range rules {
+			for _, operand := range []models.BacktestOperand{rule.Left, rule.Right} {
+				if err := engine.loadSeries(operand, chartData); err != nil {
+					return res, err
+				}
+			}
+		}
+	}
+
+	return engine.run()
+}
+
+func (e *backtestEngine) loadSeries(operand models.BacktestOperand, chartData models.ChartDataResponse) error {
+	if operand.Indicator == "" {
+		return nil
+	}
+	key := operandKey(operand)
+	if _, ok := e.series[key]; ok {
+		return nil
+	}
+	spec := indicatorRegistry[operand.Indicator]
+	if spec.lookback(operand.Params) >= len(e.bars) {
+		return fmt.Errorf("%s: %w", key, errNotEnoughData)
+	}
+	values, err := spec.compute(chartData, operand.Params)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	// right-align series that come back shorter than the candles
+	aligned := make([]float64, len(e.bars))
+	offset := len(e.bars) - len(values)
+	for i := range aligned {
+		if i < offset {
+			aligned[i] = math.NaN()
+		} else {
+			aligned[i] = values[i-offset]
+		}
+	}
+	e.series[key] = seriesValue{values: aligned, lookback: spec.lookback(operand.Params)}
+	return nil
+}
+
+func (e *backtestEngine) value(operand models.BacktestOperand, i int) (float64, bool) {
+	if operand.Indicator == "" {
+		return operand.Value, true
+	}
+	s := e.series[operandKey(operand)]
+	if i < s.lookback || i >= len(s.values) || math.IsNaN(s.values[i]) || math.IsInf(s.values[i], 0) {
+		return 0, false
+	}
+	return s.values[i], true
+}
+
+func (e *backtestEngine) holds(rule models.BacktestCondition, i int) bool {
+	left, okLeft := e.value(rule.Left, i)
+	right, okRight := e.value(rule.Right, i)
+	if !okLeft || !okRight {
+		return false
+	}
+	switch rule.Operator {
+	case ">":
+		return left > right
+	case "<":
+		return left < right
+	case ">=":
+		return left >= right
+	case "<=":
+		return left <= right
+	case constants.BacktestCrossesAbove, constants.BacktestCrossesBelow:
+		if i == 0 {
+			return false
+		}
+		prevLeft, okPrevLeft := e.value(rule.Left, i-1)
+		prevRight, okPrevRight := e.value(rule.Right, i-1)
+		if !okPrevLeft || !okPrevRight {
+			return false
+		}
+		if rule.Operator == constants.BacktestCrossesAbove {
+			return prevLeft <= prevRight && left > right
+		}
+		return prevLeft >= prevRight && left < right
+	}
+	return false
+}
+
+func (e *backtestEngine) allHold(rules []models.BacktestCondition, i int) bool {
+	for _, rule := range rules {
+		if !e.holds(rule, i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *backtestEngine) anyHolds(rules []models.BacktestCondition, i int) bool {
+	for _, rule := range rules {
+		if e.holds(rule, i) {
+			return true
+		}
+	}
+	return false
+}
+
+type openPosition struct {
+	entryIndex int
+	price      float64
+	quantity   int
+	charges    float64
+}
+
+func (e *backtestEngine) run() (models.RunBacktestRes, error) {
+	var res models.RunBacktestRes
+	strategy := e.req.Strategy
+	intraday := e.req.SubSegment == constants.INTRADAY
+	cash := e.req.InitialCapital
+	var position *openPosition
+	var totalCharges float64
+
+	closePosition := func(i int, price float64, reason string) error {
+		exitCharges, err := e.charges(price, position.quantity, constants.SELL)
+		if err != nil {
+			return err
+		}
+		cash += price*float64(position.quantity) - exitCharges
+		totalCharges += exitCharges
+		tradeCharges := position.charges + exitCharges
+		pnl := (price-position.price)*float64(position.quantity) - tradeCharges
+		res.Trades = append(res.Trades, models.BacktestTrade{
+			EntryTime:  e.bars[position.entryIndex].timestamp,
+			EntryPrice: position.price,
+			ExitTime:   e.bars[i].timestamp,
+			ExitPrice:  round2(price),
+			Quantity:   position.quantity,
+			Charges:    round2(tradeCharges),
+			Pnl:        round2(pnl),
+			PnlPct:     round2(pnl / (position.price * float64(position.quantity)) * 100),
+			ExitReason: reason,
+		})
+		position = nil
+		return nil
+	}
+
+	last := len(e.bars) - 1
+	for i, bar := range e.bars {
+		if position != nil && i > position.entryIndex {
+			stopPrice := position.price * (1 - strategy.StopLossPct/100)
+			targetPrice := position.price * (1 + strategy.TargetPct/100)
+			var err error
+			switch {
+			case strategy.StopLossPct > 0 && bar.low <= stopPrice:
+				err = closePosition(i, math.Min(bar.open, stopPrice), constants.BacktestExitStopLoss)
+			case strategy.TargetPct > 0 && bar.high >= targetPrice:
+				err = closePosition(i, math.Max(bar.open, targetPrice), constants.BacktestExitTarget)
+			case e.anyHolds(strategy.ExitRules, i):
+				err = closePosition(i, bar.close, constants.BacktestExitSignal)
+			case intraday && (i == last || !sameSession(bar.at, e.bars[i+1].at)):
+				err = closePosition(i, bar.close, constants.BacktestExitSquareOff)
+			case i == last:
+				err = closePosition(i, bar.close, constants.BacktestExitEndOfData)
+			}
+			if err != nil {
+				return res, err
+			}
+		} else if position == nil && i < last && e.allHold(strategy.EntryRules, i) &&
+			!(intraday && !sameSession(bar.at, e.bars[i+1].at)) {
+			quantity := e.positionSize(bar.close, cash)
+			if quantity > 0 {
+				entryCharges, err := e.charges(bar.close, quantity, constants.BUY)
+				if err != nil {
+					return res, err
+				}
+				if cost := bar.close*float64(quantity) + entryCharges; cost > cash {
+					quantity -= int(math.Ceil((cost - cash) / bar.close))
+					if quantity > 0 {
+						entryCharges, err = e.charges(bar.close, quantity, constants.BUY)
+						if err != nil {
+							return res, err
+						}
+					}
+				}
+				if quantity > 0 {
+					cash -= bar.close*float64(quantity) + entryCharges
+					totalCharges += entryCharges
+					position = &openPosition{entryIndex: i, price: bar.close, quantity: quantity, charges: entryCharges}
+				}
+			}
+		}
+
+		equity := cash
+		if position != nil {
+			equity += bar.close * float64(position.quantity)
+		}
+		res.EquityCurve = append(res.EquityCurve, models.BacktestEquityPoint{Time: bar.timestamp, Equity: round2(equity)})
+	}
+
+	res.Summary = summarise(e.req.InitialCapital, cash, totalCharges, res.Trades, res.EquityCurve, e.bars[0].at, e.bars[last].at)
+	if res.Trades == nil {
+		res.Trades = []models.BacktestTrade{}
+	}
+	return res, nil
+}
+
+func (e *backtestEngine) positionSize(price, cash float64) int {
+	sizing := e.req.Strategy
+	var quantity float64
+	switch sizing.Sizing {
+	case constants.BacktestSizeFixedQty:
+		quantity = sizing.SizingValue
+	case constants.BacktestSizeFixedAmount:
+		quantity = sizing.SizingValue / price
+	case constants.BacktestSizeEquityPct:
+		quantity = cash * math.Min(sizing.SizingValue, 100) / 100 / price
+	}
+	return int(math.Min(math.Floor(quantity), math.Floor(cash/price)))
+}
+
+func (e *backtestEngine) charges(price float64, quantity int, process string) (float64, error) {
+	segment := constants.EQUITY
+	subSegment := e.req.SubSegment
+	if subSegment == "" {
+		subSegment = constants.DELIVERY
+	}
+	code, apiRes := CallBrokerCharges(models.BrokerChargesReq{
+		ClientID:   e.reqH.ClientId,
+		Price:      price,
+		Quantity:   quantity,
+		Segment:    segment,
+		SubSegment: subSegment,
+		Process:    strings.ToUpper(process),
+		Exchange:   e.req.Exchange,
+	}, e.reqH)
+	if code != http.StatusOK {
+		return 0, fmt.Errorf("broker charges failed with status %d", code)
+	}
+	chargesRes, ok := apiRes.Data.(models.BrokerChargesRes)
+	if !ok {
+		return 0, errors.New("broker charges interface parsing error")
+	}
+	return chargesRes.TotalCharge, nil
+}
+
+func summarise(initial, final, totalCharges float64, trades []models.BacktestTrade, curve []models.BacktestEquityPoint, from, to time.Time) models.BacktestSummary {
+	summary := models.BacktestSummary{
+		TotalTrades:    len(trades),
+		InitialCapital: initial,
+		FinalEquity:    round2(final),
+		NetPnl:         round2(final - initial),
+		TotalCharges:   round2(totalCharges),
+		ReturnPct:      round2((final - initial) / initial * 100),
+	}
+	for _, trade := range trades {
+		if trade.Pnl > 0 {
+			summary.WinningTrades++
+		} else {
+			summary.LosingTrades++
+		}
+	}
+	if len(trades) > 0 {
+		summary.WinRate = round2(float64(summary.WinningTrades) / float64(len(trades)) * 100)
+	}
+
+	years := to.Sub(from).Hours() / 24 / 365.25
+	if years > 0 && final > 0 {
+		summary.Cagr = round2((math.Pow(final/initial, 1/years) - 1) * 100)
+	}
+
+	peak := initial
+	for _, point := range curve {
+		peak = math.Max(peak, point.Equity)
+		if peak > 0 {
+			summary.MaxDrawdown = math.Max(summary.MaxDrawdown, (peak-point.Equity)/peak*100)
+		}
+	}
+	summary.MaxDrawdown = round2(summary.MaxDrawdown)
+	return summary
+}
+
+func parseBars(chartData models.ChartDataResponse) ([]backtestBar, error) {
+	bars := make([]backtestBar, 0, len(chartData.Data.Candles))
+	for _, candle := range chartData.Data.Candles {
+		if len(candle) < 5 {
+			return nil, fmt.Errorf("invalid candle data: %v", candle)
+		}
+		timestamp, ok := candle[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid candle timestamp: %v", candle[0])
+		}
+		at, err := time.Parse(constants.CandleTimestampLayout, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		bar := backtestBar{timestamp: timestamp, at: at}
+		for j, field := range []*float64{&bar.open, &bar.high, &bar.low, &bar.close} {
+			value, ok := toFloat(candle[j+1])
+			if !ok {
+				return nil, fmt.Errorf("invalid candle value type: %v", candle[j+1])
+			}
+			*field = value
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+func sameSession(a, b time.Time) bool {
+	a = a.In(technicalindicatorsV2.LocationKolkata)
+	b = b.In(technicalindicatorsV2.LocationKolkata)
+	return a.YearDay() == b.YearDay() && a.Year() == b.Year()
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
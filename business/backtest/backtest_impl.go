@@ -0,0 +1,68 @@
+package backtest
+
+import (
+	"errors"
+	"net/http"
+
+	apihelpers "space/apiHelpers"
+	"space/business/charges"
+	"space/constants"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+
+	technicalindicatorsV2 "space/business/technicalIndicatorsV2"
+)
+
+type BacktestObj struct{}
+
+func InitBacktestProvider() BacktestObj {
+	defer models.HandlePanic()
+	return BacktestObj{}
+}
+
+var CallFetchChartData = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+	return technicalindicatorsV2.GetCachedChartData(req, reqH)
+}
+
+var CallBrokerCharges = func(req models.BrokerChargesReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	return charges.BrokerChargesInternal(req, reqH)
+}
+
+func (obj BacktestObj) RunBacktest(req models.RunBacktestReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	if err := validateStrategy(&req.Strategy); err != nil {
+		loggerconfig.Error("RunBacktest invalid strategy err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidBacktestStrategy, http.StatusBadRequest)
+	}
+
+	chartReq := models.ChartDataReq{
+		Exchange:     req.Exchange,
+		Token:        req.Token,
+		CandleType:   req.CandleType,
+		StartTime:    req.StartTime,
+		EndTime:      req.EndTime,
+		DataDuration: req.DataDuration,
+	}
+	err, chartData := CallFetchChartData(chartReq, reqH)
+	if err != nil {
+		loggerconfig.Error("RunBacktest FetchChartData err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	result, err := runBacktest(req, chartData, reqH)
+	if err != nil {
+		loggerconfig.Error("RunBacktest runBacktest err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		if errors.Is(err, errNotEnoughData) {
+			return apihelpers.SendErrorResponse(false, constants.InvalidBacktestStrategy, http.StatusBadRequest)
+		}
+		return apihelpers.SendInternalServerError()
+	}
+
+	loggerconfig.Info("RunBacktest summary:", helpers.LogStructAsJSON(result.Summary), " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Data = result
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
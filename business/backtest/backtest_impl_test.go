@@ -0,0 +1,245 @@
+package backtest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureCandle(ts string, open, high, low, close float64) []interface{} {
+	return []interface{}{ts, open, high, low, close, float64(1000)}
+}
+
+func dailyCandles(closes ...float64) models.ChartDataResponse {
+	start := time.Date(2024, 1, 1, 9, 15, 0, 0, constants.LocationKolkata)
+	var chartData models.ChartDataResponse
+	for i, c := range closes {
+		ts := start.AddDate(0, 0, i).Format(constants.CandleTimestampLayout)
+		chartData.Data.Candles = append(chartData.Data.Candles, fixtureCandle(ts, c, c, c, c))
+	}
+	return chartData
+}
+
+// flatCharges charges 10 on every order
+func flatCharges(req models.BrokerChargesReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	return http.StatusOK, apihelpers.APIRes{Status: true, Data: models.BrokerChargesRes{Price: req.Price, TotalCharge: 10}}
+}
+
+func closeOperand() models.BacktestOperand {
+	return models.BacktestOperand{Indicator: "close"}
+}
+
+func baseReq(strategy models.BacktestStrategy) models.RunBacktestReq {
+	return models.RunBacktestReq{
+		Exchange:       "NSE",
+		Token:          "11536",
+		CandleType:     "3",
+		StartTime:      "1704067200",
+		EndTime:        "1704931200",
+		InitialCapital: 100000,
+		Strategy:       strategy,
+	}
+}
+
+func TestRunBacktest(t *testing.T) {
+	origCharges, origFetch := CallBrokerCharges, CallFetchChartData
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		CallBrokerCharges, CallFetchChartData = origCharges, origFetch
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+	CallBrokerCharges = flatCharges
+
+	t.Run("crossover", func(t *testing.T) {
+		CallFetchChartData = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+			return nil, dailyCandles(100, 98, 96, 95, 97, 101, 105, 110, 108, 104)
+		}
+
+		sma3 := models.BacktestOperand{Indicator: "SMA", Params: []int{3}}
+		req := baseReq(models.BacktestStrategy{
+			EntryRules:  []models.BacktestCondition{{Left: closeOperand(), Operator: constants.BacktestCrossesAbove, Right: sma3}},
+			ExitRules:   []models.BacktestCondition{{Left: closeOperand(), Operator: constants.BacktestCrossesBelow, Right: sma3}},
+			Sizing:      constants.BacktestSizeFixedQty,
+			SizingValue: 10,
+		})
+
+		code, res := BacktestObj{}.RunBacktest(req, models.ReqHeader{ClientId: "CLIENT1"})
+		assert.Equal(t, http.StatusOK, code)
+		data := res.Data.(models.RunBacktestRes)
+
+		assert.Len(t, data.Trades, 1)
+		trade := data.Trades[0]
+		assert.Equal(t, 97.0, trade.EntryPrice)
+		assert.Equal(t, 104.0, trade.ExitPrice)
+		assert.Equal(t, 10, trade.Quantity)
+		assert.Equal(t, 20.0, trade.Charges)
+		assert.Equal(t, 50.0, trade.Pnl)
+		assert.Equal(t, constants.BacktestExitSignal, trade.ExitReason)
+
+		assert.Equal(t, 100.0, data.Summary.WinRate)
+		assert.Equal(t, 100050.0, data.Summary.FinalEquity)
+		assert.Equal(t, 20.0, data.Summary.TotalCharges)
+		assert.Len(t, data.EquityCurve, 10)
+	})
+
+	t.Run("stop loss", func(t *testing.T) {
+		chartData := dailyCandles(100, 102)
+		chartData.Data.Candles = append(chartData.Data.Candles, fixtureCandle("2024-01-03T09:15:00+0530", 99, 99, 94, 96))
+		CallFetchChartData = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+			return nil, chartData
+		}
+
+		req := baseReq(models.BacktestStrategy{
+			EntryRules:  []models.BacktestCondition{{Left: closeOperand(), Operator: ">", Right: models.BacktestOperand{Value: 0}}},
+			StopLossPct: 5,
+			Sizing:      constants.BacktestSizeEquityPct,
+			SizingValue: 50,
+		})
+		req.InitialCapital = 10000
+
+		code, res := BacktestObj{}.RunBacktest(req, models.ReqHeader{ClientId: "CLIENT1"})
+		assert.Equal(t, http.StatusOK, code)
+		data := res.Data.(models.RunBacktestRes)
+
+		assert.Len(t, data.Trades, 1)
+		assert.Equal(t, 95.0, data.Trades[0].ExitPrice)
+		assert.Equal(t, 50, data.Trades[0].Quantity)
+		assert.Equal(t, -270.0, data.Trades[0].Pnl)
+		assert.Equal(t, constants.BacktestExitStopLoss, data.Trades[0].ExitReason)
+		assert.Equal(t, 0.0, data.Summary.WinRate)
+		assert.Equal(t, 3.57, data.Summary.MaxDrawdown)
+	})
+
+	t.Run("intraday square off", func(t *testing.T) {
+		CallFetchChartData = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+			return nil, models.ChartDataResponse{Data: models.CandleData{Candles: [][]interface{}{
+				fixtureCandle("2024-01-01T10:00:00+0530", 100, 100, 100, 100),
+				fixtureCandle("2024-01-01T15:00:00+0530", 101, 101, 101, 101),
+				fixtureCandle("2024-01-02T10:00:00+0530", 103, 103, 103, 103),
+			}}}
+		}
+
+		req := baseReq(models.BacktestStrategy{
+			EntryRules:  []models.BacktestCondition{{Left: closeOperand(), Operator: ">", Right: models.BacktestOperand{Value: 0}}},
+			TargetPct:   50,
+			Sizing:      constants.BacktestSizeFixedAmount,
+			SizingValue: 1000,
+		})
+		req.SubSegment = constants.INTRADAY
+
+		code, res := BacktestObj{}.RunBacktest(req, models.ReqHeader{ClientId: "CLIENT1"})
+		assert.Equal(t, http.StatusOK, code)
+		data := res.Data.(models.RunBacktestRes)
+		assert.Len(t, data.Trades, 1)
+		assert.Equal(t, constants.BacktestExitSquareOff, data.Trades[0].ExitReason)
+		assert.Equal(t, "2024-01-01T15:00:00+0530", data.Trades[0].ExitTime)
+	})
+}
+
+func TestRunBacktestErrors(t *testing.T) {
+	origCharges, origFetch := CallBrokerCharges, CallFetchChartData
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		CallBrokerCharges, CallFetchChartData = origCharges, origFetch
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+	CallBrokerCharges = flatCharges
+	CallFetchChartData = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+		return nil, dailyCandles(100, 101, 102)
+	}
+
+	t.Run("unknown indicator", func(t *testing.T) {
+		req := baseReq(models.BacktestStrategy{
+			EntryRules:  []models.BacktestCondition{{Left: models.BacktestOperand{Indicator: "foo"}, Operator: ">", Right: models.BacktestOperand{Value: 1}}},
+			TargetPct:   5,
+			Sizing:      constants.BacktestSizeFixedQty,
+			SizingValue: 1,
+		})
+		code, res := BacktestObj{}.RunBacktest(req, models.ReqHeader{})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, constants.InvalidBacktestStrategy, res.ErrorCode)
+	})
+
+	t.Run("wrong param count", func(t *testing.T) {
+		req := baseReq(models.BacktestStrategy{
+			EntryRules:  []models.BacktestCondition{{Left: models.BacktestOperand{Indicator: "macd", Params: []int{12}}, Operator: ">", Right: models.BacktestOperand{Value: 0}}},
+			TargetPct:   5,
+			Sizing:      constants.BacktestSizeFixedQty,
+			SizingValue: 1,
+		})
+		code, res := BacktestObj{}.RunBacktest(req, models.ReqHeader{})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, constants.InvalidBacktestStrategy, res.ErrorCode)
+	})
+
+	t.Run("no way to exit", func(t *testing.T) {
+		req := baseReq(models.BacktestStrategy{
+			EntryRules:  []models.BacktestCondition{{Left: closeOperand(), Operator: ">", Right: models.BacktestOperand{Value: 0}}},
+			Sizing:      constants.BacktestSizeFixedQty,
+			SizingValue: 1,
+		})
+		code, _ := BacktestObj{}.RunBacktest(req, models.ReqHeader{})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("period longer than the range", func(t *testing.T) {
+		req := baseReq(models.BacktestStrategy{
+			EntryRules:  []models.BacktestCondition{{Left: models.BacktestOperand{Indicator: "sma", Params: []int{20}}, Operator: ">", Right: models.BacktestOperand{Value: 0}}},
+			TargetPct:   5,
+			Sizing:      constants.BacktestSizeFixedQty,
+			SizingValue: 1,
+		})
+		code, res := BacktestObj{}.RunBacktest(req, models.ReqHeader{})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, constants.InvalidBacktestStrategy, res.ErrorCode)
+	})
+
+	t.Run("broker charges fail", func(t *testing.T) {
+		CallBrokerCharges = func(req models.BrokerChargesReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return http.StatusInternalServerError, apihelpers.APIRes{}
+		}
+		t.Cleanup(func() { CallBrokerCharges = flatCharges })
+		req := baseReq(models.BacktestStrategy{
+			EntryRules:  []models.BacktestCondition{{Left: closeOperand(), Operator: ">", Right: models.BacktestOperand{Value: 0}}},
+			TargetPct:   5,
+			Sizing:      constants.BacktestSizeFixedQty,
+			SizingValue: 1,
+		})
+		code, _ := BacktestObj{}.RunBacktest(req, models.ReqHeader{})
+		assert.Equal(t, http.StatusInternalServerError, code)
+	})
+
+	t.Run("chart data fails", func(t *testing.T) {
+		CallFetchChartData = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+			return errors.New("tradelab down"), models.ChartDataResponse{}
+		}
+		req := baseReq(models.BacktestStrategy{
+			EntryRules:  []models.BacktestCondition{{Left: closeOperand(), Operator: ">", Right: models.BacktestOperand{Value: 0}}},
+			TargetPct:   5,
+			Sizing:      constants.BacktestSizeFixedQty,
+			SizingValue: 1,
+		})
+		code, _ := BacktestObj{}.RunBacktest(req, models.ReqHeader{})
+		assert.Equal(t, http.StatusInternalServerError, code)
+	})
+}
+
+func TestSummariseCagr(t *testing.T) {
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Duration(2*365.25*24) * time.Hour)
+	summary := summarise(100, 121, 0, nil, nil, from, to)
+	assert.Equal(t, 10.0, summary.Cagr)
+	assert.Equal(t, 21.0, summary.ReturnPct)
+}
@@ -0,0 +1,262 @@
+package v1
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"space/business/admins"
+	"space/constants"
+	"space/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// layouts date1 is accepted in when unixtime is not given, as exchange files
+// and the markets team use several
+var blockDealDateLayouts = []string{"2006-01-02", "02-01-2006", "02/01/2006", "02-Jan-2006", "02 Jan 2006", "2006-01-02T15:04:05", time.RFC3339}
+
+var errBlockDealsFileHeader = errors.New("file has no header row with cocode")
+
+// blockDealValidate is shared by every row, validator caches struct info
+// across calls
+var blockDealValidate = validator.New()
+
+// ValidateBlockDeal trims a deal, fills unixtime from date1 when it is not
+// set and checks every field.
+func (s *blockDealService) ValidateBlockDeal(blockDeal *models.BlockDeal) error {
+	blockDeal.DealType = strings.ToUpper(strings.TrimSpace(blockDeal.DealType))
+	blockDeal.ScripCode = strings.TrimSpace(blockDeal.ScripCode)
+	blockDeal.Date1 = strings.TrimSpace(blockDeal.Date1)
+	blockDeal.ScripName = strings.TrimSpace(blockDeal.ScripName)
+	blockDeal.ClientName = strings.TrimSpace(blockDeal.ClientName)
+	blockDeal.BuySell = strings.ToUpper(strings.TrimSpace(blockDeal.BuySell))
+
+	if blockDeal.UnixTime == 0 && blockDeal.Date1 != "" {
+		for _, layout := range blockDealDateLayouts {
+			if date, err := time.ParseInLocation(layout, blockDeal.Date1, constants.LocationKolkata); err == nil {
+				blockDeal.UnixTime = date.Unix()
+				break
+			}
+		}
+		if blockDeal.UnixTime == 0 {
+			return fmt.Errorf("unrecognised date1 %q", blockDeal.Date1)
+		}
+	}
+	return blockDealValidate.Struct(blockDeal)
+}
+
+/*
+BulkUpsertBlockDeals loads a file of deals, updating a deal that is already
+stored for the same cocode, deal type, unixtime, client and side and inserting
+the rest. Nothing is written when the file or any row is invalid, the errors
+are returned in the response instead, with row 0 for the file itself.
+*/
+func (s *blockDealService) BulkUpsertBlockDeals(c *gin.Context, req models.BulkBlockDealsRequest) (models.BulkBlockDealsResponse, error) {
+	var res models.BulkBlockDealsResponse
+
+	deals, err := parseBlockDealsFile(req.FileName, req.Content)
+	res.Rows = len(deals)
+	if err == nil && len(deals) == 0 {
+		err = errors.New("file has no deals")
+	}
+	if err == nil && len(deals) > constants.BlockDealsBulkMaxRows {
+		err = fmt.Errorf("file has %d deals, at most %d are loaded at once", len(deals), constants.BlockDealsBulkMaxRows)
+	}
+	if err != nil {
+		res.Errors = append(res.Errors, models.BlockDealRowError{Row: 0, Error: err.Error()})
+		return res, nil
+	}
+
+	for i := range deals {
+		if deals[i].err == nil {
+			deals[i].err = s.ValidateBlockDeal(&deals[i].deal)
+		}
+		if deals[i].err != nil {
+			res.Errors = append(res.Errors, models.BlockDealRowError{Row: deals[i].row, Error: deals[i].err.Error()})
+		}
+	}
+	if len(res.Errors) > 0 {
+		return res, nil
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	// the audit trail gets one entry per deal, with the deals it overwrote
+	type blockDealChange struct {
+		deal   models.BlockDeal
+		before []models.BlockDeal
+	}
+	changes := make([]blockDealChange, 0, len(deals))
+
+	for _, row := range deals {
+		deal := row.deal
+		before, err := blockDealsMatching(tx, deal)
+		if err != nil {
+			return res, fmt.Errorf("row %d: %v", row.row, err)
+		}
+		changes = append(changes, blockDealChange{deal: deal, before: before})
+
+		result, err := tx.Exec(
+			"UPDATE blockdeals SET scripcode=$1, serial=$2, date1=$3, scripname=$4, buysell=$5, qtyshares=$6, avgprice=$7 WHERE cocode=$8 AND dealtype=$9 AND unixtime=$10 AND clientname=$11 AND UPPER(LEFT(buysell, 1))=$12",
+			deal.ScripCode, deal.Serial, deal.Date1, deal.ScripName, deal.BuySell, deal.QtyShares, deal.AvgPrice, deal.Cocode, deal.DealType, deal.UnixTime, deal.ClientName, deal.BuySell[:1],
+		)
+		if err != nil {
+			return res, fmt.Errorf("row %d: %v", row.row, err)
+		}
+		if updated, _ := result.RowsAffected(); updated > 0 {
+			res.Updated++
+			continue
+		}
+
+		_, err = tx.Exec(
+			"INSERT INTO blockdeals (cocode, dealtype, scripcode, serial, date1, scripname, clientname, buysell, qtyshares, avgprice, unixtime) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+			deal.Cocode, deal.DealType, deal.ScripCode, deal.Serial, deal.Date1, deal.ScripName, deal.ClientName, deal.BuySell, deal.QtyShares, deal.AvgPrice, deal.UnixTime,
+		)
+		if err != nil {
+			return res, fmt.Errorf("row %d: %v", row.row, err)
+		}
+		res.Inserted++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return res, err
+	}
+
+	reqH := adminReqHeader(c)
+	for _, change := range changes {
+		entityId := strconv.Itoa(change.deal.Cocode)
+		if len(change.before) == 0 {
+			admins.RecordAdminMutation(reqH, constants.AdminActionCreate, constants.AdminEntityBlockDeal, entityId, nil, change.deal)
+			continue
+		}
+		admins.RecordAdminMutation(reqH, constants.AdminActionModify, constants.AdminEntityBlockDeal, entityId, blockDealsAudit{Deals: change.before}, blockDealsAudit{Deals: []models.BlockDeal{change.deal}})
+	}
+	return res, nil
+}
+
+// blockDealsMatching locks and returns the stored deals a deal of a file
+// overwrites, matched the way BulkUpsertBlockDeals updates them.
+func blockDealsMatching(tx *sql.Tx, deal models.BlockDeal) ([]models.BlockDeal, error) {
+	rows, err := tx.Query(
+		"SELECT cocode, dealtype, scripcode, serial, date1, scripname, clientname, buysell, qtyshares, avgprice, unixtime FROM blockdeals WHERE cocode=$1 AND dealtype=$2 AND unixtime=$3 AND clientname=$4 AND UPPER(LEFT(buysell, 1))=$5 FOR UPDATE",
+		deal.Cocode, deal.DealType, deal.UnixTime, deal.ClientName, deal.BuySell[:1],
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanBlockDeals(rows)
+}
+
+type blockDealsFileRow struct {
+	row  int
+	deal models.BlockDeal
+	err  error
+}
+
+// parseBlockDealsFile reads the deals of a csv or json file. Rows are
+// numbered from 1 for the first deal, a row that cannot be read keeps its
+// error for the response.
+func parseBlockDealsFile(fileName string, content []byte) ([]blockDealsFileRow, error) {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(fileName), ".")) {
+	case constants.BlockDealsFormatJson:
+		var deals []models.BlockDeal
+		if err := json.Unmarshal(content, &deals); err != nil {
+			return nil, err
+		}
+		rows := make([]blockDealsFileRow, len(deals))
+		for i, deal := range deals {
+			rows[i] = blockDealsFileRow{row: i + 1, deal: deal}
+		}
+		return rows, nil
+	case constants.BlockDealsFormatCsv:
+		return parseBlockDealsCsv(content)
+	default:
+		return nil, fmt.Errorf("unsupported file type %q", fileName)
+	}
+}
+
+func parseBlockDealsCsv(content []byte) ([]blockDealsFileRow, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errBlockDealsFileHeader
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["cocode"]; !ok {
+		return nil, errBlockDealsFileHeader
+	}
+
+	var rows []blockDealsFileRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		column := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := blockDealsFileRow{row: len(rows) + 1}
+		row.deal = models.BlockDeal{
+			DealType:   column("dealtype"),
+			ScripCode:  column("scripcode"),
+			Date1:      column("date1"),
+			ScripName:  column("scripname"),
+			ClientName: column("clientname"),
+			BuySell:    column("buysell"),
+		}
+		var errs []string
+		parse := func(name string, set func(string) error) {
+			if value := column(name); value != "" {
+				if err := set(value); err != nil {
+					errs = append(errs, fmt.Sprintf("invalid %s %q", name, value))
+				}
+			}
+		}
+		parse("cocode", func(v string) (err error) { row.deal.Cocode, err = strconv.Atoi(v); return })
+		parse("serial", func(v string) (err error) { row.deal.Serial, err = strconv.Atoi(v); return })
+		parse("qtyshares", func(v string) (err error) {
+			row.deal.QtyShares, err = strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64)
+			return
+		})
+		parse("avgprice", func(v string) (err error) {
+			row.deal.AvgPrice, err = strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64)
+			return
+		})
+		parse("unixtime", func(v string) (err error) { row.deal.UnixTime, err = strconv.ParseInt(v, 10, 64); return })
+		if len(errs) > 0 {
+			row.err = errors.New(strings.Join(errs, ", "))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/models"
+)
+
+func TestValidateBlockDeal(t *testing.T) {
+	s := &blockDealService{}
+
+	deal := models.BlockDeal{Cocode: 476, DealType: " block ", ScripCode: "500325", Date1: "17-Oct-2026", ScripName: "RELIANCE", ClientName: " ABC FUND ", BuySell: "buy", QtyShares: 1000, AvgPrice: 2450.5}
+	if err := s.ValidateBlockDeal(&deal); err != nil {
+		t.Fatalf("ValidateBlockDeal() = %v", err)
+	}
+	want := time.Date(2026, 10, 17, 0, 0, 0, 0, constants.LocationKolkata).Unix()
+	if deal.UnixTime != want || deal.DealType != "BLOCK" || deal.BuySell != "BUY" || deal.ClientName != "ABC FUND" {
+		t.Errorf("normalised deal = %+v", deal)
+	}
+
+	invalid := map[string]models.BlockDeal{
+		"no cocode":    {DealType: "BLOCK", ScripCode: "1", Date1: "2026-10-17", ScripName: "X", ClientName: "Y", BuySell: "S", QtyShares: 1, AvgPrice: 1},
+		"bad date":     {Cocode: 1, DealType: "BLOCK", ScripCode: "1", Date1: "17th Oct", ScripName: "X", ClientName: "Y", BuySell: "S", QtyShares: 1, AvgPrice: 1},
+		"bad side":     {Cocode: 1, DealType: "BLOCK", ScripCode: "1", Date1: "2026-10-17", ScripName: "X", ClientName: "Y", BuySell: "HOLD", QtyShares: 1, AvgPrice: 1},
+		"no quantity":  {Cocode: 1, DealType: "BLOCK", ScripCode: "1", Date1: "2026-10-17", ScripName: "X", ClientName: "Y", BuySell: "S", AvgPrice: 1},
+		"no client":    {Cocode: 1, DealType: "BLOCK", ScripCode: "1", Date1: "2026-10-17", ScripName: "X", ClientName: "  ", BuySell: "S", QtyShares: 1, AvgPrice: 1},
+		"no date1":     {Cocode: 1, DealType: "BLOCK", ScripCode: "1", ScripName: "X", ClientName: "Y", BuySell: "S", QtyShares: 1, AvgPrice: 1, UnixTime: want},
+		"negative avg": {Cocode: 1, DealType: "BLOCK", ScripCode: "1", Date1: "2026-10-17", ScripName: "X", ClientName: "Y", BuySell: "S", QtyShares: 1, AvgPrice: -1},
+	}
+	for name, deal := range invalid {
+		if err := s.ValidateBlockDeal(&deal); err == nil {
+			t.Errorf("%s: ValidateBlockDeal() accepted %+v", name, deal)
+		}
+	}
+}
+
+func TestParseBlockDealsFile(t *testing.T) {
+	csvFile := "\ufeffCocode,DealType,ScripCode,Serial,Date1,ScripName,ClientName,BuySell,QtyShares,AvgPrice\n" +
+		"476,BLOCK,500325,1,2026-10-17,RELIANCE,ABC FUND,B,\"1,000\",2450.5\n" +
+		"\n" +
+		"abc,BULK,500180,2,2026-10-17,HDFCBANK,XYZ LLP,S,500,x\n"
+	rows, err := parseBlockDealsFile("deals.CSV", []byte(csvFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+	wantDeal := models.BlockDeal{Cocode: 476, DealType: "BLOCK", ScripCode: "500325", Serial: 1, Date1: "2026-10-17", ScripName: "RELIANCE", ClientName: "ABC FUND", BuySell: "B", QtyShares: 1000, AvgPrice: 2450.5}
+	if rows[0].err != nil || !reflect.DeepEqual(rows[0].deal, wantDeal) {
+		t.Errorf("row 1 = %+v, %v", rows[0].deal, rows[0].err)
+	}
+	if rows[1].row != 2 || rows[1].err == nil || !strings.Contains(rows[1].err.Error(), "cocode") || !strings.Contains(rows[1].err.Error(), "avgprice") {
+		t.Errorf("row 2 = %d, %v", rows[1].row, rows[1].err)
+	}
+
+	rows, err = parseBlockDealsFile("body.json", []byte(`[{"cocode":476,"dealtype":"BLOCK","buysell":"S"}]`))
+	if err != nil || len(rows) != 1 || rows[0].deal.Cocode != 476 || rows[0].deal.BuySell != "S" {
+		t.Errorf("json rows = %+v, %v", rows, err)
+	}
+
+	for name, file := range map[string]string{"deals.csv": "symbol,qty\nRELIANCE,1\n", "deals.xlsx": "", "body.json": "{}"} {
+		if _, err := parseBlockDealsFile(name, []byte(file)); err == nil {
+			t.Errorf("parseBlockDealsFile(%s) accepted %q", name, file)
+		}
+	}
+}
+
+func TestBlockDealsWhere(t *testing.T) {
+
+	where, args, err := blockDealsWhere(models.BlockDealFilter{})
+	if err != nil || where != "" || len(args) != 0 {
+		t.Errorf("blockDealsWhere(empty) = %q %v %v", where, args, err)
+	}
+
+	filter := models.BlockDealFilter{FromDate: "2026-10-01", ToDate: "2026-10-17", Cocode: 476, Client: "50%_fund", BuySell: "sell", MinValue: 1e7}
+	where, args, err = blockDealsWhere(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantWhere := " WHERE unixtime >= $1 AND unixtime < $2 AND cocode = $3 AND clientname ILIKE $4 AND UPPER(LEFT(buysell, 1)) = $5 AND qtyshares * avgprice >= $6"
+	if where != wantWhere {
+		t.Errorf("where = %q", where)
+	}
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, constants.LocationKolkata).Unix()
+	to := time.Date(2026, 10, 18, 0, 0, 0, 0, constants.LocationKolkata).Unix()
+	wantArgs := []interface{}{from, to, 476, `%50\%\_fund%`, "S", 1e7}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+}
@@ -2,7 +2,14 @@ package v1
 
 import (
 	"database/sql"
+	"fmt"
+	"space/business/admins"
+	"space/constants"
+	"space/loggerconfig"
 	"space/models"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,6 +18,12 @@ type blockDealService struct {
 	DB *sql.DB
 }
 
+// blockDealsAudit is how the deals of a cocode are kept in the admin audit
+// trail, as updates and deletes apply to all of them.
+type blockDealsAudit struct {
+	Deals []models.BlockDeal `json:"deals"`
+}
+
 // NewBlockDealService returns a new BlockDealService instance
 func NewBlockDealService(db *sql.DB) models.BlockDealService {
 	return &blockDealService{DB: db}
@@ -22,27 +35,95 @@ func (s *blockDealService) CreateBlockDeal(c *gin.Context, blockDeal models.Bloc
 		"INSERT INTO blockdeals (cocode, dealtype, scripcode, serial, date1, scripname, clientname, buysell, qtyshares, avgprice, unixtime) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
 		blockDeal.Cocode, blockDeal.DealType, blockDeal.ScripCode, blockDeal.Serial, blockDeal.Date1, blockDeal.ScripName, blockDeal.ClientName, blockDeal.BuySell, blockDeal.QtyShares, blockDeal.AvgPrice, blockDeal.UnixTime,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	admins.RecordAdminMutation(adminReqHeader(c), constants.AdminActionCreate, constants.AdminEntityBlockDeal, strconv.Itoa(blockDeal.Cocode), nil, blockDeal)
+	return nil
 }
 
-// Get all BlockDeals
-func (s *blockDealService) GetAllBlockDeals(c *gin.Context) ([]models.BlockDeal, error) {
-	rows, err := s.DB.Query("SELECT cocode, dealtype, scripcode, serial, date1, scripname, clientname, buysell, qtyshares, avgprice, unixtime FROM blockdeals")
+// Get a page of the BlockDeals matching a filter, latest first
+func (s *blockDealService) GetAllBlockDeals(c *gin.Context, filter models.BlockDealFilter) (models.BlockDealsPage, error) {
+	page := models.BlockDealsPage{Deals: []models.BlockDeal{}, Page: filter.Page, PageSize: filter.PageSize}
+	if page.Page < 1 {
+		page.Page = 1
+	}
+	if page.PageSize < 1 || page.PageSize > constants.BlockDealsMaxPageSize {
+		page.PageSize = constants.BlockDealsDefaultPageSize
+	}
+
+	where, args, err := blockDealsWhere(filter)
 	if err != nil {
-		return nil, err
+		return page, err
+	}
+
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM blockdeals"+where, args...).Scan(&page.Total); err != nil {
+		return page, err
+	}
+
+	args = append(args, page.PageSize, (page.Page-1)*page.PageSize)
+	query := fmt.Sprintf("SELECT cocode, dealtype, scripcode, serial, date1, scripname, clientname, buysell, qtyshares, avgprice, unixtime FROM blockdeals%s ORDER BY unixtime DESC, cocode, serial LIMIT $%d OFFSET $%d", where, len(args)-1, len(args))
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return page, err
 	}
 	defer rows.Close()
 
-	var blockDeals []models.BlockDeal
 	for rows.Next() {
 		var blockDeal models.BlockDeal
 		if err := rows.Scan(&blockDeal.Cocode, &blockDeal.DealType, &blockDeal.ScripCode, &blockDeal.Serial, &blockDeal.Date1, &blockDeal.ScripName, &blockDeal.ClientName, &blockDeal.BuySell, &blockDeal.QtyShares, &blockDeal.AvgPrice, &blockDeal.UnixTime); err != nil {
-			return nil, err
+			return page, err
+		}
+		page.Deals = append(page.Deals, blockDeal)
+	}
+
+	return page, rows.Err()
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// blockDealsWhere builds the WHERE clause of a filter with its positional
+// arguments. The side of a deal is matched on its first letter as both B and
+// BUY are stored.
+func blockDealsWhere(filter models.BlockDealFilter) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.FromDate != "" {
+		from, err := time.ParseInLocation("2006-01-02", filter.FromDate, constants.LocationKolkata)
+		if err != nil {
+			return "", nil, err
+		}
+		add("unixtime >= $%d", from.Unix())
+	}
+	if filter.ToDate != "" {
+		to, err := time.ParseInLocation("2006-01-02", filter.ToDate, constants.LocationKolkata)
+		if err != nil {
+			return "", nil, err
 		}
-		blockDeals = append(blockDeals, blockDeal)
+		add("unixtime < $%d", to.AddDate(0, 0, 1).Unix())
+	}
+	if filter.Cocode > 0 {
+		add("cocode = $%d", filter.Cocode)
+	}
+	if client := strings.TrimSpace(filter.Client); client != "" {
+		add("clientname ILIKE $%d", "%"+likeEscaper.Replace(client)+"%")
+	}
+	if filter.BuySell != "" {
+		add("UPPER(LEFT(buysell, 1)) = $%d", strings.ToUpper(filter.BuySell[:1]))
+	}
+	if filter.MinValue > 0 {
+		add("qtyshares * avgprice >= $%d", filter.MinValue)
 	}
 
-	return blockDeals, nil
+	if len(conditions) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
 }
 
 // Get a single BlockDeal by ID
@@ -57,16 +138,71 @@ func (s *blockDealService) GetBlockDealByID(c *gin.Context, id int) (models.Bloc
 }
 
 // Update a BlockDeal
-func (s *blockDealService) UpdateBlockDeal(cocode int, blockDeal models.BlockDeal) error {
-	_, err := s.DB.Exec(
+func (s *blockDealService) UpdateBlockDeal(c *gin.Context, cocode int, blockDeal models.BlockDeal) error {
+	before, err := s.blockDealsByCocode(cocode)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.Exec(
 		"UPDATE blockdeals SET dealtype=$1, scripcode=$2, serial=$3, date1=$4, scripname=$5, clientname=$6, buysell=$7, qtyshares=$8, avgprice=$9, unixtime=$10 WHERE cocode=$11",
 		blockDeal.DealType, blockDeal.ScripCode, blockDeal.Serial, blockDeal.Date1, blockDeal.ScripName, blockDeal.ClientName, blockDeal.BuySell, blockDeal.QtyShares, blockDeal.AvgPrice, blockDeal.UnixTime, cocode,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	after, err := s.blockDealsByCocode(cocode)
+	if err != nil {
+		loggerconfig.Error("UpdateBlockDeal, error reading updated deals:", err, " cocode:", cocode)
+	}
+	admins.RecordAdminMutation(adminReqHeader(c), constants.AdminActionModify, constants.AdminEntityBlockDeal, strconv.Itoa(cocode), before, after)
+	return nil
 }
 
 // Delete a BlockDeal
 func (s *blockDealService) DeleteBlockDeal(c *gin.Context, id int) error {
-	_, err := s.DB.Exec("DELETE FROM blockdeals WHERE cocode = $1", id)
-	return err
+	before, err := s.blockDealsByCocode(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.Exec("DELETE FROM blockdeals WHERE cocode = $1", id)
+	if err != nil {
+		return err
+	}
+	admins.RecordAdminMutation(adminReqHeader(c), constants.AdminActionDelete, constants.AdminEntityBlockDeal, strconv.Itoa(id), before, nil)
+	return nil
+}
+
+func (s *blockDealService) blockDealsByCocode(cocode int) (blockDealsAudit, error) {
+	deals := blockDealsAudit{Deals: []models.BlockDeal{}}
+	rows, err := s.DB.Query("SELECT cocode, dealtype, scripcode, serial, date1, scripname, clientname, buysell, qtyshares, avgprice, unixtime FROM blockdeals WHERE cocode = $1", cocode)
+	if err != nil {
+		return deals, err
+	}
+	defer rows.Close()
+
+	stored, err := scanBlockDeals(rows)
+	deals.Deals = append(deals.Deals, stored...)
+	return deals, err
+}
+
+func scanBlockDeals(rows *sql.Rows) ([]models.BlockDeal, error) {
+	var deals []models.BlockDeal
+	for rows.Next() {
+		var blockDeal models.BlockDeal
+		if err := rows.Scan(&blockDeal.Cocode, &blockDeal.DealType, &blockDeal.ScripCode, &blockDeal.Serial, &blockDeal.Date1, &blockDeal.ScripName, &blockDeal.ClientName, &blockDeal.BuySell, &blockDeal.QtyShares, &blockDeal.AvgPrice, &blockDeal.UnixTime); err != nil {
+			return deals, err
+		}
+		deals = append(deals, blockDeal)
+	}
+	return deals, rows.Err()
+}
+
+// adminReqHeader is the header AdminMiddleware set for the admin making a change.
+func adminReqHeader(c *gin.Context) models.ReqHeader {
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+	return reqH
 }
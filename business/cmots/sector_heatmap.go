@@ -0,0 +1,397 @@
+package cmots
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+
+	technicalindicatorsV2 "space/business/technicalIndicatorsV2"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var sectorHeatmapGroup singleflight.Group
+
+type heatmapSector struct {
+	name      string
+	sectCodes []string
+	companies []models.SectorWiseCompanyDetails
+}
+
+// heatmapQuote is what the heatmap needs from a stock's daily candles.
+type heatmapQuote struct {
+	ltp         float64
+	changePct   float64
+	newHigh     bool
+	newLow      bool
+	hasDma200   bool
+	aboveDma200 bool
+}
+
+var CallFetchHeatmapSectors = func(obj CmotsObj, reqH models.ReqHeader) ([]heatmapSector, error) {
+	code, res := obj.FetchSectorListV2("", reqH)
+	if code != http.StatusOK {
+		return nil, errors.New("FetchSectorListV2 failed")
+	}
+	categories, ok := res.Data.([]map[string]interface{})
+	if !ok {
+		return nil, errors.New("FetchSectorListV2 interface parsing error")
+	}
+
+	sectors := make([]heatmapSector, 0, len(categories))
+	for _, category := range categories {
+		name, _ := category["sectName"].(string)
+		sectCodes, _ := category["sectCode"].([]string)
+		code, res := obj.FetchSectorWiseCompanyV2(models.FetchSectorWiseCompanyReqV2{SectCode: sectCodes}, reqH)
+		if code != http.StatusOK {
+			loggerconfig.Error("FetchSectorHeatmap, FetchSectorWiseCompanyV2 failed for sector:", name, " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+			continue
+		}
+		companies, ok := res.Data.(models.SectorWiseCompanyV2)
+		if !ok {
+			continue
+		}
+		sectors = append(sectors, heatmapSector{name: name, sectCodes: sectCodes, companies: companies.Companies})
+	}
+	return sectors, nil
+}
+
+var CallFetchHeatmapMarketCaps = func(obj CmotsObj) (map[string]float64, error) {
+	universe, err := obj.Db.FetchScreenerUniverse(constants.ScreenerTechnicalsFreq)
+	if err != nil {
+		return nil, err
+	}
+	marketCaps := make(map[string]float64, len(universe))
+	for _, stock := range universe {
+		marketCaps[stock.Isin] = stock.MarketCap
+	}
+	return marketCaps, nil
+}
+
+var CallFetchHeatmapCandles = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+	return technicalindicatorsV2.GetCachedChartData(req, reqH)
+}
+
+var heatmapNow = func() time.Time {
+	return time.Now().In(technicalindicatorsV2.LocationKolkata)
+}
+
+func (obj CmotsObj) FetchSectorHeatmap(reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	redisCli := cache.GetRedisClientObj()
+	if redisCli != nil {
+		cached, err := redisCli.GetRedis(constants.SectorHeatmapKey).Result()
+		if err == nil {
+			var heatmap models.SectorHeatmapRes
+			if err = json.Unmarshal([]byte(cached), &heatmap); err == nil {
+				apiRes.Data = heatmap
+				apiRes.Message = "SUCCESS"
+				apiRes.Status = true
+				return http.StatusOK, apiRes
+			}
+		}
+	}
+
+	value, err, _ := sectorHeatmapGroup.Do(constants.SectorHeatmapKey, func() (interface{}, error) {
+		return buildSectorHeatmap(obj, reqH)
+	})
+	if err != nil {
+		loggerconfig.Error("FetchSectorHeatmap, buildSectorHeatmap failed, error:", err, " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	heatmap := value.(models.SectorHeatmapRes)
+
+	if redisCli != nil {
+		if data, err := json.Marshal(heatmap); err == nil {
+			if err = redisCli.SetRedis(constants.SectorHeatmapKey, string(data), constants.SectorHeatmapTTL); err != nil {
+				loggerconfig.Error("FetchSectorHeatmap, SetRedis failed, error:", err, " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+			}
+		}
+	}
+
+	loggerconfig.Info("FetchSectorHeatmap Success, sectors:", len(heatmap.Sectors), " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Data = heatmap
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+func buildSectorHeatmap(obj CmotsObj, reqH models.ReqHeader) (models.SectorHeatmapRes, error) {
+	var heatmap models.SectorHeatmapRes
+
+	sectors, err := CallFetchHeatmapSectors(obj, reqH)
+	if err != nil {
+		return heatmap, err
+	}
+
+	marketCaps, err := CallFetchHeatmapMarketCaps(obj)
+	if err != nil {
+		// weights fall back to equal weighting, the heatmap is still useful
+		loggerconfig.Error("FetchSectorHeatmap, market caps unavailable, error:", err, " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+		marketCaps = map[string]float64{}
+	}
+
+	tokens := make(map[string]struct{})
+	for _, sector := range sectors {
+		for _, company := range sector.companies {
+			if company.Token1 != "" && company.Exchange1 == constants.SectorHeatmapBreadthNSE {
+				tokens[company.Token1] = struct{}{}
+			}
+		}
+	}
+	quotes := fetchHeatmapQuotes(tokens, reqH)
+
+	now := heatmapNow()
+	nseBreadth := models.MarketBreadth{Name: constants.SectorHeatmapBreadthNSE}
+	indexBreadth := make(map[string]*models.MarketBreadth)
+	var indexOrder []string
+	counted := make(map[string]bool)
+	indexCounted := make(map[string]bool)
+
+	for _, sector := range sectors {
+		var indices []models.Index
+		for _, code := range sector.sectCodes {
+			for _, index := range constants.SectorToIndices[code].NSEIndices {
+				indices = append(indices, models.Index{Name: index.Name, Token: index.Token})
+				if _, ok := indexBreadth[index.Name]; !ok {
+					indexBreadth[index.Name] = &models.MarketBreadth{Name: index.Name, Token: index.Token}
+					indexOrder = append(indexOrder, index.Name)
+				}
+			}
+		}
+
+		var stocks []models.SectorHeatmapStock
+		var weights []float64
+		for _, company := range sector.companies {
+			quote, ok := quotes[company.Token1]
+			if !ok || company.Exchange1 != constants.SectorHeatmapBreadthNSE {
+				continue
+			}
+			stocks = append(stocks, models.SectorHeatmapStock{
+				Isin:      company.Isin,
+				Symbol:    company.Symbol,
+				CoName:    company.CoName,
+				Token:     company.Token1,
+				Ltp:       quote.ltp,
+				ChangePct: quote.changePct,
+			})
+			weights = append(weights, marketCaps[company.Isin])
+
+			if !counted[company.Token1] {
+				counted[company.Token1] = true
+				addToBreadth(&nseBreadth, quote)
+			}
+			for _, index := range indices {
+				key := index.Name + "|" + company.Token1
+				if !indexCounted[key] {
+					indexCounted[key] = true
+					addToBreadth(indexBreadth[index.Name], quote)
+				}
+			}
+		}
+		heatmap.Sectors = append(heatmap.Sectors, summariseSector(sector, stocks, weights))
+	}
+
+	sort.SliceStable(heatmap.Sectors, func(i, j int) bool {
+		return heatmap.Sectors[i].ChangePct > heatmap.Sectors[j].ChangePct
+	})
+
+	heatmap.Breadth = append(heatmap.Breadth, finaliseBreadth(nseBreadth))
+	sort.Strings(indexOrder)
+	for _, name := range indexOrder {
+		heatmap.Breadth = append(heatmap.Breadth, finaliseBreadth(*indexBreadth[name]))
+	}
+	heatmap.UpdatedAt = now.Format(time.RFC3339)
+	return heatmap, nil
+}
+
+// fetchHeatmapQuotes loads daily candles for every token with a bounded pool.
+// Tokens whose candles cannot be loaded are left out rather than failing the map.
+func fetchHeatmapQuotes(tokens map[string]struct{}, reqH models.ReqHeader) map[string]heatmapQuote {
+	now := heatmapNow()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	startTime := strconv.FormatInt(dayStart.AddDate(0, 0, -constants.SectorHeatmapLookbackDays).Unix(), 10)
+	endTime := strconv.FormatInt(now.Unix(), 10)
+
+	quotes := make(map[string]heatmapQuote, len(tokens))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, constants.SectorHeatmapWorkers)
+
+	for token := range tokens {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(token string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err, chartData := CallFetchHeatmapCandles(models.ChartDataReq{
+				Exchange:     constants.SectorHeatmapBreadthNSE,
+				Token:        token,
+				CandleType:   "3",
+				DataDuration: "1",
+				StartTime:    startTime,
+				EndTime:      endTime,
+			}, reqH)
+			if err != nil {
+				loggerconfig.Error("FetchSectorHeatmap, candles failed for token:", token, " error:", err, " requestId:", reqH.RequestId)
+				return
+			}
+			quote, ok := heatmapQuoteFromCandles(chartData.Data.Candles)
+			if !ok {
+				return
+			}
+			mu.Lock()
+			quotes[token] = quote
+			mu.Unlock()
+		}(token)
+	}
+	wg.Wait()
+	return quotes
+}
+
+func heatmapQuoteFromCandles(candles [][]interface{}) (heatmapQuote, bool) {
+	var quote heatmapQuote
+	n := len(candles)
+	if n < 2 {
+		return quote, false
+	}
+
+	closes := make([]float64, n)
+	highs := make([]float64, n)
+	lows := make([]float64, n)
+	for i, candle := range candles {
+		if len(candle) < 5 {
+			return quote, false
+		}
+		var ok1, ok2, ok3 bool
+		highs[i], ok1 = candle[2].(float64)
+		lows[i], ok2 = candle[3].(float64)
+		closes[i], ok3 = candle[4].(float64)
+		if !ok1 || !ok2 || !ok3 {
+			return quote, false
+		}
+	}
+
+	quote.ltp = closes[n-1]
+	if prevClose := closes[n-2]; prevClose > 0 {
+		quote.changePct = roundTo((quote.ltp-prevClose)/prevClose*100, 2)
+	}
+
+	// 52 weeks of prior sessions, excluding today
+	window := 252
+	from := n - 1 - window
+	if from < 0 {
+		from = 0
+	}
+	prevHigh, prevLow := highs[from], lows[from]
+	for i := from; i < n-1; i++ {
+		prevHigh = math.Max(prevHigh, highs[i])
+		prevLow = math.Min(prevLow, lows[i])
+	}
+	quote.newHigh = highs[n-1] > prevHigh
+	quote.newLow = lows[n-1] < prevLow
+
+	if n >= 200 {
+		var sum float64
+		for _, c := range closes[n-200:] {
+			sum += c
+		}
+		quote.hasDma200 = true
+		quote.aboveDma200 = quote.ltp > sum/200
+	}
+	return quote, true
+}
+
+func addToBreadth(breadth *models.MarketBreadth, quote heatmapQuote) {
+	breadth.Total++
+	switch {
+	case quote.changePct > 0:
+		breadth.Advances++
+	case quote.changePct < 0:
+		breadth.Declines++
+	default:
+		breadth.Unchanged++
+	}
+	if quote.newHigh {
+		breadth.New52WeekHighs++
+	}
+	if quote.newLow {
+		breadth.New52WeekLows++
+	}
+	if quote.aboveDma200 {
+		breadth.AboveDma200++
+	}
+}
+
+func finaliseBreadth(breadth models.MarketBreadth) models.MarketBreadth {
+	if breadth.Declines > 0 {
+		breadth.AdRatio = roundTo(float64(breadth.Advances)/float64(breadth.Declines), 2)
+	} else {
+		breadth.AdRatio = float64(breadth.Advances)
+	}
+	if breadth.Total > 0 {
+		breadth.AboveDma200Pct = roundTo(float64(breadth.AboveDma200)/float64(breadth.Total)*100, 2)
+	}
+	return breadth
+}
+
+func summariseSector(sector heatmapSector, stocks []models.SectorHeatmapStock, weights []float64) models.SectorHeatmap {
+	summary := models.SectorHeatmap{
+		SectName:        sector.name,
+		SectCode:        sector.sectCodes,
+		TopContributors: []models.SectorHeatmapStock{},
+		TopLaggards:     []models.SectorHeatmapStock{},
+	}
+
+	var totalWeight float64
+	for _, w := range weights {
+		totalWeight += w
+	}
+	for i := range stocks {
+		weight := 1 / float64(len(stocks))
+		if totalWeight > 0 {
+			weight = weights[i] / totalWeight
+		}
+		stocks[i].Contribution = roundTo(stocks[i].ChangePct*weight, 4)
+		summary.ChangePct += stocks[i].ChangePct * weight
+
+		switch {
+		case stocks[i].ChangePct > 0:
+			summary.Advances++
+		case stocks[i].ChangePct < 0:
+			summary.Declines++
+		default:
+			summary.Unchanged++
+		}
+	}
+	summary.ChangePct = roundTo(summary.ChangePct, 2)
+
+	sort.SliceStable(stocks, func(i, j int) bool {
+		return stocks[i].Contribution > stocks[j].Contribution
+	})
+	for i := 0; i < len(stocks) && i < constants.SectorHeatmapTopMovers && stocks[i].Contribution > 0; i++ {
+		summary.TopContributors = append(summary.TopContributors, stocks[i])
+	}
+	for i := len(stocks) - 1; i >= 0 && len(summary.TopLaggards) < constants.SectorHeatmapTopMovers && stocks[i].Contribution < 0; i-- {
+		summary.TopLaggards = append(summary.TopLaggards, stocks[i])
+	}
+	return summary
+}
+
+func roundTo(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,125 @@
+package cmots
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dailyFixture builds n flat daily candles at base with the last bar moving to last.
+func dailyFixture(n int, base, last float64) [][]interface{} {
+	start := time.Date(2024, 1, 1, 9, 15, 0, 0, constants.LocationKolkata)
+	candles := make([][]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		c := base
+		if i == n-1 {
+			c = last
+		}
+		candles = append(candles, []interface{}{start.AddDate(0, 0, i).Format("2006-01-02T15:04:05-0700"), c, c, c, c, float64(100)})
+	}
+	return candles
+}
+
+func TestHeatmapQuoteFromCandles(t *testing.T) {
+	quote, ok := heatmapQuoteFromCandles(dailyFixture(210, 100, 110))
+	assert.True(t, ok)
+	assert.Equal(t, 110.0, quote.ltp)
+	assert.Equal(t, 10.0, quote.changePct)
+	assert.True(t, quote.newHigh)
+	assert.False(t, quote.newLow)
+	assert.True(t, quote.aboveDma200)
+
+	quote, ok = heatmapQuoteFromCandles(dailyFixture(20, 100, 90))
+	assert.True(t, ok)
+	assert.True(t, quote.newLow)
+	assert.False(t, quote.hasDma200)
+
+	_, ok = heatmapQuoteFromCandles(dailyFixture(1, 100, 100))
+	assert.False(t, ok)
+}
+
+func TestFetchSectorHeatmap(t *testing.T) {
+	origSectors, origMarketCaps, origCandles := CallFetchHeatmapSectors, CallFetchHeatmapMarketCaps, CallFetchHeatmapCandles
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		CallFetchHeatmapSectors, CallFetchHeatmapMarketCaps, CallFetchHeatmapCandles = origSectors, origMarketCaps, origCandles
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+
+	CallFetchHeatmapSectors = func(obj CmotsObj, reqH models.ReqHeader) ([]heatmapSector, error) {
+		return []heatmapSector{
+			{name: "Banks", sectCodes: []string{"00000006"}, companies: []models.SectorWiseCompanyDetails{
+				{Isin: "BANKA", Symbol: "BANKA", Token1: "1", Exchange1: "NSE"},
+				{Isin: "BANKB", Symbol: "BANKB", Token1: "2", Exchange1: "NSE"},
+				{Isin: "BANKC", Symbol: "BANKC", Token1: "3", Exchange1: "NSE"},
+			}},
+			{name: "IT", sectCodes: []string{"unmapped"}, companies: []models.SectorWiseCompanyDetails{
+				{Isin: "ITA", Symbol: "ITA", Token1: "4", Exchange1: "NSE"},
+				{Isin: "ITB", Symbol: "ITB", Token1: "5", Exchange1: "BSE"},
+				{Isin: "ITC", Symbol: "ITC", Token1: "6", Exchange1: "NSE"},
+			}},
+		}, nil
+	}
+	CallFetchHeatmapMarketCaps = func(obj CmotsObj) (map[string]float64, error) {
+		return map[string]float64{"BANKA": 300, "BANKB": 100, "BANKC": 100}, nil
+	}
+	moves := map[string]float64{"1": 110, "2": 95, "3": 100, "4": 98}
+	CallFetchHeatmapCandles = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+		last, ok := moves[req.Token]
+		if !ok {
+			return errors.New("no candles"), models.ChartDataResponse{}
+		}
+		return nil, models.ChartDataResponse{Data: models.CandleData{Candles: dailyFixture(210, 100, last)}}
+	}
+
+	code, res := CmotsObj{}.FetchSectorHeatmap(models.ReqHeader{ClientId: "CLIENT1"})
+	assert.Equal(t, http.StatusOK, code)
+	heatmap := res.Data.(models.SectorHeatmapRes)
+
+	assert.Len(t, heatmap.Sectors, 2)
+	banks := heatmap.Sectors[0]
+	assert.Equal(t, "Banks", banks.SectName)
+	// (300*10 + 100*-5 + 100*0) / 500
+	assert.Equal(t, 5.0, banks.ChangePct)
+	assert.Equal(t, 1, banks.Advances)
+	assert.Equal(t, 1, banks.Declines)
+	assert.Equal(t, 1, banks.Unchanged)
+	assert.Equal(t, "BANKA", banks.TopContributors[0].Symbol)
+	assert.Equal(t, "BANKB", banks.TopLaggards[0].Symbol)
+
+	it := heatmap.Sectors[1]
+	// no market caps, equal weighted over the one stock with candles
+	assert.Equal(t, -2.0, it.ChangePct)
+	assert.Equal(t, 1, it.Declines)
+
+	nse := heatmap.Breadth[0]
+	assert.Equal(t, "NSE", nse.Name)
+	assert.Equal(t, 4, nse.Total)
+	assert.Equal(t, 1, nse.Advances)
+	assert.Equal(t, 2, nse.Declines)
+	assert.Equal(t, 0.5, nse.AdRatio)
+	assert.Equal(t, 1, nse.New52WeekHighs)
+	assert.Equal(t, 2, nse.New52WeekLows)
+	assert.Equal(t, 1, nse.AboveDma200)
+
+	assert.Len(t, heatmap.Breadth, 4)
+	assert.Equal(t, "Nifty Bank", heatmap.Breadth[1].Name)
+	assert.Equal(t, 3, heatmap.Breadth[1].Total)
+
+	t.Run("sector list fails", func(t *testing.T) {
+		CallFetchHeatmapSectors = func(obj CmotsObj, reqH models.ReqHeader) ([]heatmapSector, error) {
+			return nil, errors.New("db down")
+		}
+		code, _ := CmotsObj{}.FetchSectorHeatmap(models.ReqHeader{})
+		assert.Equal(t, http.StatusInternalServerError, code)
+	})
+}
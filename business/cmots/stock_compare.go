@@ -0,0 +1,494 @@
+package cmots
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/business/screeners"
+	"space/constants"
+	"space/helpers"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/tealeg/xlsx/v3"
+)
+
+// compareInput is everything the comparison knows about one company. Target
+// stocks carry full statements, sector peers only the TTM and five year series.
+type compareInput struct {
+	ttm           models.ScreenerStock
+	hasTTM        bool
+	revenue       []float64
+	pat           []float64
+	assets        []float64
+	equity        []float64
+	years         []int
+	pl            map[string]float64
+	bs            map[string]float64
+	cfo           float64
+	hasCfo        bool
+	hasStatements bool
+}
+
+type compareStatements struct {
+	financials models.FetchFinancialsV4Res
+	pl         models.PLStatementResponse
+	bs         models.BalanceSheetsResponse
+	cf         models.CashflowResponse
+}
+
+type compareMetricDef struct {
+	key            string
+	label          string
+	group          string
+	higherIsBetter bool
+	value          func(in compareInput) (float64, bool)
+	series         func(in compareInput) ([]float64, bool)
+}
+
+func ttmMetric(get func(models.ScreenerStock) float64) func(compareInput) (float64, bool) {
+	return func(in compareInput) (float64, bool) {
+		if !in.hasTTM {
+			return 0, false
+		}
+		return get(in.ttm), true
+	}
+}
+
+func seriesOf(get func(compareInput) []float64) func(compareInput) ([]float64, bool) {
+	return func(in compareInput) ([]float64, bool) {
+		s := get(in)
+		return s, len(s) > 0
+	}
+}
+
+// ratioSeries divides two yearly series, dropping years with a zero denominator as NaN.
+func ratioSeries(num, den func(compareInput) []float64, scale float64) func(compareInput) ([]float64, bool) {
+	return func(in compareInput) ([]float64, bool) {
+		n, d := num(in), den(in)
+		if len(n) == 0 || len(n) != len(d) {
+			return nil, false
+		}
+		out := make([]float64, len(n))
+		for i := range n {
+			if d[i] == 0 {
+				out[i] = math.NaN()
+				continue
+			}
+			out[i] = n[i] / d[i] * scale
+		}
+		return out, true
+	}
+}
+
+func latestOf(series func(compareInput) ([]float64, bool)) func(compareInput) (float64, bool) {
+	return func(in compareInput) (float64, bool) {
+		s, ok := series(in)
+		if !ok || math.IsNaN(s[0]) {
+			return 0, false
+		}
+		return s[0], true
+	}
+}
+
+var (
+	revenueSeries     = seriesOf(func(in compareInput) []float64 { return in.revenue })
+	patSeries         = seriesOf(func(in compareInput) []float64 { return in.pat })
+	assetsSeries      = seriesOf(func(in compareInput) []float64 { return in.assets })
+	equitySeries      = seriesOf(func(in compareInput) []float64 { return in.equity })
+	netMarginSeries   = ratioSeries(func(in compareInput) []float64 { return in.pat }, func(in compareInput) []float64 { return in.revenue }, 100)
+	roaSeries         = ratioSeries(func(in compareInput) []float64 { return in.pat }, func(in compareInput) []float64 { return in.assets }, 100)
+	liabilitiesSeries = ratioSeries(func(in compareInput) []float64 {
+		if len(in.assets) != len(in.equity) {
+			return nil
+		}
+		liabilities := make([]float64, len(in.assets))
+		for i := range in.assets {
+			liabilities[i] = in.assets[i] - in.equity[i]
+		}
+		return liabilities
+	}, func(in compareInput) []float64 { return in.assets }, 100)
+)
+
+var compareMetrics = []compareMetricDef{
+	{key: "marketCap", label: "Market Cap (Cr)", group: constants.CompareGroupValuation, higherIsBetter: true, value: ttmMetric(func(s models.ScreenerStock) float64 { return s.MarketCap })},
+	{key: "pe", label: "P/E (TTM)", group: constants.CompareGroupValuation, value: ttmMetric(func(s models.ScreenerStock) float64 { return s.PE })},
+	{key: "pb", label: "P/B", group: constants.CompareGroupValuation, value: ttmMetric(func(s models.ScreenerStock) float64 { return s.PB })},
+	{key: "dividendYield", label: "Dividend Yield %", group: constants.CompareGroupValuation, higherIsBetter: true, value: ttmMetric(func(s models.ScreenerStock) float64 { return s.DivYield })},
+	{key: "eps", label: "EPS (TTM)", group: constants.CompareGroupValuation, higherIsBetter: true, value: ttmMetric(func(s models.ScreenerStock) float64 { return s.EPS })},
+
+	{key: "roe", label: "ROE % (TTM)", group: constants.CompareGroupProfitability, higherIsBetter: true, value: ttmMetric(func(s models.ScreenerStock) float64 { return s.ROE })},
+	{key: "netMargin", label: "Net Margin %", group: constants.CompareGroupProfitability, higherIsBetter: true, value: latestOf(netMarginSeries), series: netMarginSeries},
+	{key: "roa", label: "ROA %", group: constants.CompareGroupProfitability, higherIsBetter: true, value: latestOf(roaSeries), series: roaSeries},
+	{key: "cashConversion", label: "Operating Cash Flow / PAT", group: constants.CompareGroupProfitability, higherIsBetter: true, value: func(in compareInput) (float64, bool) {
+		pat := in.pl[constants.ProfitAfterTax]
+		if !in.hasCfo || pat == 0 {
+			return 0, false
+		}
+		return in.cfo / pat, true
+	}},
+
+	{key: "debtToEquity", label: "Debt / Equity", group: constants.CompareGroupLeverage, value: ttmMetric(func(s models.ScreenerStock) float64 { return s.DebtToEquity })},
+	{key: "liabilitiesToAssets", label: "Liabilities / Assets %", group: constants.CompareGroupLeverage, value: latestOf(liabilitiesSeries), series: liabilitiesSeries},
+	{key: "interestCoverage", label: "Interest Coverage", group: constants.CompareGroupLeverage, higherIsBetter: true, value: func(in compareInput) (float64, bool) {
+		finance := in.pl[constants.FinanceCosts]
+		if !in.hasStatements || finance == 0 {
+			return 0, false
+		}
+		return (in.pl[constants.ProfitAfterTax] + in.pl[constants.Taxation] + finance) / finance, true
+	}},
+	{key: "currentRatio", label: "Current Ratio", group: constants.CompareGroupLeverage, higherIsBetter: true, value: func(in compareInput) (float64, bool) {
+		liabilities := in.bs[constants.TotalCurrentLiabilities]
+		if !in.hasStatements || liabilities == 0 {
+			return 0, false
+		}
+		return in.bs[constants.TotalCurrentAssets] / liabilities, true
+	}},
+
+	{key: "revenue", label: "Revenue (Cr)", group: constants.CompareGroupGrowth, higherIsBetter: true, value: latestOf(revenueSeries), series: revenueSeries},
+	{key: "netProfit", label: "Net Profit (Cr)", group: constants.CompareGroupGrowth, higherIsBetter: true, value: latestOf(patSeries), series: patSeries},
+	{key: "totalAssets", label: "Total Assets (Cr)", group: constants.CompareGroupGrowth, higherIsBetter: true, value: latestOf(assetsSeries), series: assetsSeries},
+	{key: "equity", label: "Shareholders' Equity (Cr)", group: constants.CompareGroupGrowth, higherIsBetter: true, value: latestOf(equitySeries), series: equitySeries},
+	{key: "revenueGrowth", label: "Revenue Growth % (YoY)", group: constants.CompareGroupGrowth, higherIsBetter: true, value: func(in compareInput) (float64, bool) {
+		if len(in.revenue) < 2 || in.revenue[1] <= 0 {
+			return 0, false
+		}
+		return (in.revenue[0]/in.revenue[1] - 1) * 100, true
+	}},
+}
+
+var CallFetchCompareUniverse = func(obj CmotsObj) ([]models.ScreenerStock, error) {
+	return screeners.InitScreenersProvider(nil, obj.Db, cache.GetRedisClientObj()).FetchScreenerUniverse()
+}
+
+var CallFetchCompareStatements = func(obj CmotsObj, isin string) (compareStatements, error) {
+	var statements compareStatements
+	var errs [4]error
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		statements.financials, errs[0] = obj.Db.FetchFinancialsDataV4(models.FetchFinancialsReq{Isin: isin})
+	}()
+	go func() {
+		defer wg.Done()
+		statements.pl, errs[1] = obj.Db.FetchPLStatementData(isin)
+	}()
+	go func() {
+		defer wg.Done()
+		statements.bs, errs[2] = obj.Db.FetchBalanceSheetsData(isin)
+	}()
+	go func() {
+		defer wg.Done()
+		statements.cf, errs[3] = obj.Db.FetchCashFlowData(isin)
+	}()
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return statements, err
+		}
+	}
+	return statements, nil
+}
+
+var CallFetchSectorFinancialSeries = func(obj CmotsObj, sector string) ([]models.SectorFinancialSeries, error) {
+	return obj.Db.FetchSectorFinancialSeries(sector)
+}
+
+var CallUploadStockComparison = func(fileName string, file *xlsx.File) (string, error) {
+	return helpers.UploadFileToS3AndGetPresignedURL(constants.StockCompareS3FolderName, fileName, file, constants.CompareExportExpiryHours)
+}
+
+func (obj CmotsObj) CompareStocks(req models.CompareStocksReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	comparison, err := buildStockComparison(obj, req, reqH)
+	if err != nil {
+		loggerconfig.Error("CompareStocks, buildStockComparison failed, error:", err, " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	loggerconfig.Info("CompareStocks Success, isinList:", req.IsinList, " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Data = comparison
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+func (obj CmotsObj) ExportStockComparison(req models.CompareStocksReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	comparison, err := buildStockComparison(obj, req, reqH)
+	if err != nil {
+		loggerconfig.Error("ExportStockComparison, buildStockComparison failed, error:", err, " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	file, err := createStockComparisonExcel(comparison)
+	if err != nil {
+		loggerconfig.Error("ExportStockComparison, Error in getting excel file, error:", err, " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	fileName := fmt.Sprintf("%s_%s_%d.xlsx", reqH.ClientId, strings.Join(req.IsinList, "-"), time.Now().Unix())
+	url, err := CallUploadStockComparison(fileName, file)
+	if err != nil {
+		loggerconfig.Error("ExportStockComparison, failed to generate pre-signed URL:", err, " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	apiRes.Data = models.ExportStockComparisonRes{DownloadUrl: url}
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+func buildStockComparison(obj CmotsObj, req models.CompareStocksReq, reqH models.ReqHeader) (models.CompareStocksRes, error) {
+	var comparison models.CompareStocksRes
+
+	universe, err := CallFetchCompareUniverse(obj)
+	if err != nil {
+		return comparison, err
+	}
+	universeByIsin := make(map[string]models.ScreenerStock, len(universe))
+	for _, stock := range universe {
+		universeByIsin[stock.Isin] = stock
+	}
+
+	targets := make([]compareInput, len(req.IsinList))
+	sectors := make(map[string]bool)
+	for i, isin := range req.IsinList {
+		statements, err := CallFetchCompareStatements(obj, isin)
+		if err != nil {
+			return comparison, err
+		}
+		targets[i] = inputFromStatements(statements)
+		targets[i].ttm, targets[i].hasTTM = universeByIsin[isin]
+
+		info := models.CompareStockInfo{Isin: isin}
+		if targets[i].hasTTM {
+			info.CompanyName = targets[i].ttm.CompanyName
+			info.NseSymbol = targets[i].ttm.NseSymbol
+			info.Sector = targets[i].ttm.Sector
+			sectors[info.Sector] = true
+		}
+		comparison.Stocks = append(comparison.Stocks, info)
+	}
+
+	// sector peers, keyed by sector then isin
+	peers := make(map[string]map[string]*compareInput)
+	for sector := range sectors {
+		peers[sector] = make(map[string]*compareInput)
+		for _, stock := range universe {
+			if stock.Sector == sector {
+				peers[sector][stock.Isin] = &compareInput{ttm: stock, hasTTM: true}
+			}
+		}
+		rows, err := CallFetchSectorFinancialSeries(obj, sector)
+		if err != nil {
+			// percentiles for series metrics are dropped, the rest still ranks
+			loggerconfig.Error("CompareStocks, FetchSectorFinancialSeries failed for sector:", sector, " error:", err, " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+			continue
+		}
+		applySectorSeries(peers[sector], rows)
+	}
+
+	for _, def := range compareMetrics {
+		metric := models.CompareMetric{Key: def.key, Label: def.label, Group: def.group, HigherIsBetter: def.higherIsBetter}
+		for i, target := range targets {
+			value := models.CompareMetricValue{Isin: req.IsinList[i]}
+			if v, ok := def.value(target); ok && !math.IsNaN(v) && !math.IsInf(v, 0) {
+				value.Value = floatPtr(roundTo(v, 2))
+				if sectorPeers, ok := peers[comparison.Stocks[i].Sector]; ok {
+					value.SectorPercentile = sectorPercentile(def, v, sectorPeers)
+				}
+			}
+			if def.series != nil {
+				if s, ok := def.series(target); ok {
+					value.Cagr5Y = seriesCagr(s, target.years)
+				}
+			}
+			metric.Values = append(metric.Values, value)
+		}
+		comparison.Metrics = append(comparison.Metrics, metric)
+	}
+	return comparison, nil
+}
+
+func inputFromStatements(statements compareStatements) compareInput {
+	in := compareInput{
+		pl:            make(map[string]float64),
+		bs:            make(map[string]float64),
+		hasStatements: true,
+	}
+
+	financials := statements.financials
+	revenue, pat := financials.RevenueConsolidated, financials.NetProfitConsolidated
+	assets, liabilities := financials.BalanceSheetConsolidated.TotalAssets, financials.BalanceSheetConsolidated.TotalLiabilities
+	if revenue.ColumnName == "" {
+		revenue, pat = financials.RevenueStandalone, financials.NetProfitStandalone
+		assets, liabilities = financials.BalanceSheetStandalone.TotalAssets, financials.BalanceSheetStandalone.TotalLiabilities
+	}
+	if revenue.ColumnName != "" {
+		in.revenue = yearlyValues(revenue)
+		in.pat = yearlyValues(pat)
+		in.years = yearCodes(revenue)
+	}
+	if assets.ColumnName != "" {
+		in.assets = yearlyValues(assets)
+		in.equity = make([]float64, len(in.assets))
+		for i, l := range yearlyValues(liabilities) {
+			in.equity[i] = in.assets[i] - l
+		}
+		if in.years == nil {
+			in.years = yearCodes(assets)
+		}
+	}
+
+	for _, row := range statements.pl.Data {
+		in.pl[row.ColumnName] = row.Y0
+	}
+	for _, row := range statements.bs.Data {
+		in.bs[row.ColumnName] = row.Y0
+	}
+	for _, row := range statements.cf.CFO {
+		if row.ColumnName == constants.CashFlowFromOperations {
+			in.cfo, in.hasCfo = row.Y0, true
+		}
+	}
+	return in
+}
+
+// applySectorSeries fills peer series, consolidated figures win over standalone.
+func applySectorSeries(peers map[string]*compareInput, rows []models.SectorFinancialSeries) {
+	consolidated := make(map[string]bool)
+	for _, row := range rows {
+		key := row.Isin + "|" + row.ColumnName
+		isConsolidated := strings.ToLower(row.TypeCS) == "c"
+		if consolidated[key] && !isConsolidated {
+			continue
+		}
+		if isConsolidated {
+			consolidated[key] = true
+		}
+
+		peer, ok := peers[row.Isin]
+		if !ok {
+			peer = &compareInput{}
+			peers[row.Isin] = peer
+		}
+		values := yearlyValues(row.FetchFinancialsDataV4)
+		switch row.ColumnName {
+		case constants.TotalRevenue[1 : len(constants.TotalRevenue)-1]:
+			peer.revenue = values
+		case constants.ProfitAfterTax:
+			peer.pat = values
+		case constants.TotalAssets:
+			peer.assets = values
+		case constants.TotalEquity:
+			peer.equity = values
+		}
+	}
+}
+
+// sectorPercentile is the share of sector peers the value does at least as
+// well as, so 100 is always the best in sector whichever way the metric points.
+func sectorPercentile(def compareMetricDef, value float64, peers map[string]*compareInput) *float64 {
+	var total, beaten int
+	for _, peer := range peers {
+		v, ok := def.value(*peer)
+		if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		total++
+		if (def.higherIsBetter && v <= value) || (!def.higherIsBetter && v >= value) {
+			beaten++
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	return floatPtr(roundTo(float64(beaten)/float64(total)*100, 2))
+}
+
+// seriesCagr annualises the move from the oldest to the latest reported year.
+func seriesCagr(series []float64, years []int) *float64 {
+	last := len(series) - 1
+	if last < 1 || series[0] <= 0 || series[last] <= 0 || math.IsNaN(series[0]) || math.IsNaN(series[last]) {
+		return nil
+	}
+	span := float64(last)
+	if len(years) == len(series) && years[0] > 0 && years[last] > 0 {
+		// year codes look like 202403
+		if s := float64(years[0]/100 - years[last]/100); s > 0 {
+			span = s
+		}
+	}
+	return floatPtr(roundTo((math.Pow(series[0]/series[last], 1/span)-1)*100, 2))
+}
+
+func yearlyValues(row models.FetchFinancialsDataV4) []float64 {
+	return []float64{row.Y0, row.Y1, row.Y2, row.Y3, row.Y4}
+}
+
+func yearCodes(row models.FetchFinancialsDataV4) []int {
+	return []int{row.Yrc0, row.Yrc1, row.Yrc2, row.Yrc3, row.Yrc4}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+// createStockComparisonExcel lays the comparison out one metric per row, with
+// value, sector percentile and CAGR columns for each stock.
+func createStockComparisonExcel(comparison models.CompareStocksRes) (*xlsx.File, error) {
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet("Comparison")
+	if err != nil {
+		return nil, err
+	}
+
+	titleRow := sheet.AddRow()
+	titleCell := titleRow.AddCell()
+	titleCell.SetString("Stock Comparison")
+	titleCell.GetStyle().Font.Bold = true
+	sheet.AddRow()
+
+	headerRow := sheet.AddRow()
+	headerRow.AddCell().SetString("Metric")
+	headerRow.AddCell().SetString("Group")
+	for _, stock := range comparison.Stocks {
+		name := stock.NseSymbol
+		if name == "" {
+			name = stock.Isin
+		}
+		headerRow.AddCell().SetString(name)
+		headerRow.AddCell().SetString(name + " Sector Percentile")
+		headerRow.AddCell().SetString(name + " 5Y CAGR %")
+	}
+
+	for _, metric := range comparison.Metrics {
+		row := sheet.AddRow()
+		row.AddCell().SetString(metric.Label)
+		row.AddCell().SetString(metric.Group)
+		for _, value := range metric.Values {
+			for _, v := range []*float64{value.Value, value.SectorPercentile, value.Cagr5Y} {
+				if v == nil {
+					row.AddCell().SetString("-")
+				} else {
+					row.AddCell().SetString(strconv.FormatFloat(*v, 'f', 2, 64))
+				}
+			}
+		}
+	}
+
+	return file, nil
+}
@@ -0,0 +1,138 @@
+package cmots
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tealeg/xlsx/v3"
+)
+
+func v4Row(column string, values ...float64) models.FetchFinancialsDataV4 {
+	return models.FetchFinancialsDataV4{
+		TypeCS: "C", ColumnName: column,
+		Y0: values[0], Y1: values[1], Y2: values[2], Y3: values[3], Y4: values[4],
+		Yrc0: 202403, Yrc1: 202303, Yrc2: 202203, Yrc3: 202103, Yrc4: 202003,
+	}
+}
+
+func findMetric(res models.CompareStocksRes, key string) models.CompareMetric {
+	for _, metric := range res.Metrics {
+		if metric.Key == key {
+			return metric
+		}
+	}
+	return models.CompareMetric{}
+}
+
+func TestCompareStocks(t *testing.T) {
+	origUniverse, origStatements, origSeries, origUpload := CallFetchCompareUniverse, CallFetchCompareStatements, CallFetchSectorFinancialSeries, CallUploadStockComparison
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		CallFetchCompareUniverse, CallFetchCompareStatements, CallFetchSectorFinancialSeries, CallUploadStockComparison = origUniverse, origStatements, origSeries, origUpload
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+	CallFetchCompareUniverse = func(obj CmotsObj) ([]models.ScreenerStock, error) {
+		return []models.ScreenerStock{
+			{Isin: "A", CompanyName: "Alpha", NseSymbol: "ALPHA", Sector: "IT", MarketCap: 1000, PE: 20, ROE: 25, DebtToEquity: 0.1},
+			{Isin: "B", CompanyName: "Beta", NseSymbol: "BETA", Sector: "IT", MarketCap: 500, PE: 30, ROE: 15, DebtToEquity: 0.5},
+			{Isin: "C", CompanyName: "Gamma", NseSymbol: "GAMMA", Sector: "IT", MarketCap: 200, PE: 10, ROE: 5, DebtToEquity: 1},
+			{Isin: "D", CompanyName: "Delta", NseSymbol: "DELTA", Sector: "IT", MarketCap: 100, PE: 40, ROE: 10, DebtToEquity: 2},
+		}, nil
+	}
+	CallFetchCompareStatements = func(obj CmotsObj, isin string) (compareStatements, error) {
+		var s compareStatements
+		s.financials.RevenueConsolidated = v4Row("Total Revenue", 1610.51, 1464.1, 1331, 1210, 1000)
+		s.financials.NetProfitConsolidated = v4Row(constants.ProfitAfterTax, 161.05, 146.41, 133.1, 121, 100)
+		s.financials.BalanceSheetConsolidated.TotalAssets = v4Row(constants.TotalAssets, 2000, 1800, 1600, 1400, 1200)
+		s.financials.BalanceSheetConsolidated.TotalLiabilities = v4Row(constants.TotalLiabilities, 500, 500, 500, 500, 500)
+		s.pl.Data = []models.PLStatement{
+			{ColumnName: constants.ProfitAfterTax, Y0: 161.05},
+			{ColumnName: constants.Taxation, Y0: 40},
+			{ColumnName: constants.FinanceCosts, Y0: 20},
+		}
+		s.bs.Data = []models.BalanceSheets{
+			{ColumnName: constants.TotalCurrentAssets, Y0: 600},
+			{ColumnName: constants.TotalCurrentLiabilities, Y0: 300},
+		}
+		s.cf.CFO = []models.CashFlow{{ColumnName: constants.CashFlowFromOperations, Y0: 193.26}}
+		if isin == "B" {
+			s.financials = models.FetchFinancialsV4Res{}
+		}
+		return s, nil
+	}
+	CallFetchSectorFinancialSeries = func(obj CmotsObj, sector string) ([]models.SectorFinancialSeries, error) {
+		return []models.SectorFinancialSeries{
+			{Isin: "A", FetchFinancialsDataV4: v4Row("Total Revenue", 1610.51, 1464.1, 1331, 1210, 1000)},
+			{Isin: "A", FetchFinancialsDataV4: v4Row(constants.ProfitAfterTax, 161.05, 146.41, 133.1, 121, 100)},
+			{Isin: "C", FetchFinancialsDataV4: v4Row("Total Revenue", 100, 90, 80, 70, 60)},
+			{Isin: "C", FetchFinancialsDataV4: v4Row(constants.ProfitAfterTax, 20, 10, 8, 7, 6)},
+			{Isin: "D", FetchFinancialsDataV4: v4Row("Total Revenue", 100, 90, 80, 70, 60)},
+			{Isin: "D", FetchFinancialsDataV4: v4Row(constants.ProfitAfterTax, 5, 4, 3, 2, 1)},
+		}, nil
+	}
+
+	code, res := CmotsObj{}.CompareStocks(models.CompareStocksReq{IsinList: []string{"A", "B"}}, models.ReqHeader{ClientId: "CLIENT1"})
+	assert.Equal(t, http.StatusOK, code)
+	comparison := res.Data.(models.CompareStocksRes)
+
+	assert.Len(t, comparison.Stocks, 2)
+	assert.Equal(t, "ALPHA", comparison.Stocks[0].NseSymbol)
+
+	pe := findMetric(comparison, "pe")
+	assert.False(t, pe.HigherIsBetter)
+	assert.Equal(t, 20.0, *pe.Values[0].Value)
+	// PE 20 is at least as cheap as A, B and D
+	assert.Equal(t, 75.0, *pe.Values[0].SectorPercentile)
+	assert.Nil(t, pe.Values[0].Cagr5Y)
+
+	revenue := findMetric(comparison, "revenue")
+	assert.Equal(t, 1610.51, *revenue.Values[0].Value)
+	assert.Equal(t, 12.65, *revenue.Values[0].Cagr5Y)
+	assert.Equal(t, 100.0, *revenue.Values[0].SectorPercentile)
+	assert.Nil(t, revenue.Values[1].Value)
+
+	netMargin := findMetric(comparison, "netMargin")
+	assert.Equal(t, 10.0, *netMargin.Values[0].Value)
+	// A earns 10% against C at 20% and D at 5%
+	assert.Equal(t, 66.67, *netMargin.Values[0].SectorPercentile)
+
+	assert.Equal(t, 1.2, *findMetric(comparison, "cashConversion").Values[0].Value)
+	assert.Equal(t, 11.05, *findMetric(comparison, "interestCoverage").Values[0].Value)
+	assert.Equal(t, 2.0, *findMetric(comparison, "currentRatio").Values[0].Value)
+	assert.Equal(t, 25.0, *findMetric(comparison, "liabilitiesToAssets").Values[0].Value)
+
+	t.Run("export", func(t *testing.T) {
+		var uploaded *xlsx.File
+		CallUploadStockComparison = func(fileName string, file *xlsx.File) (string, error) {
+			uploaded = file
+			return "https://s3/" + fileName, nil
+		}
+
+		code, res := CmotsObj{}.ExportStockComparison(models.CompareStocksReq{IsinList: []string{"A", "B"}}, models.ReqHeader{ClientId: "CLIENT1"})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, res.Data.(models.ExportStockComparisonRes).DownloadUrl, "CLIENT1_A-B_")
+
+		sheet := uploaded.Sheets[0]
+		header, err := sheet.Row(2)
+		assert.NoError(t, err)
+		assert.Equal(t, "ALPHA", header.GetCell(2).Value)
+		assert.Equal(t, "BETA 5Y CAGR %", header.GetCell(7).Value)
+		assert.Equal(t, 3+len(compareMetrics), sheet.MaxRow)
+	})
+
+	t.Run("statements fail", func(t *testing.T) {
+		CallFetchCompareStatements = func(obj CmotsObj, isin string) (compareStatements, error) {
+			return compareStatements{}, errors.New("db down")
+		}
+		code, _ := CmotsObj{}.CompareStocks(models.CompareStocksReq{IsinList: []string{"A"}}, models.ReqHeader{})
+		assert.Equal(t, http.StatusInternalServerError, code)
+	})
+}
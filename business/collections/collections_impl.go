@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	apihelpers "space/apiHelpers"
+	"space/business/admins"
 	"space/constants"
 	"space/db"
 	"space/dbops"
@@ -67,11 +68,12 @@ func (obj CollectionsObj) CreateCollections(createCollectionsReq models.CreateCo
 	filter := bson.D{{"collectionId", id}}
 	update := bson.D{{"$set", mongoCollectionDetails}}
 	opts := options.Update().SetUpsert(true)
-	dbops.MongoRepo.UpdateOne(constants.COLLECTIONS, filter, update, opts)
+	err = dbops.MongoRepo.UpdateOne(constants.COLLECTIONS, filter, update, opts)
 	if err != nil {
 		loggerconfig.Error("CreateCollections Mongo Upsert failed error =", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
+	admins.RecordAdminMutation(reqH, constants.AdminActionCreate, constants.AdminEntityCollection, id, nil, mongoCollectionDetails)
 
 	var resp models.CreateCollectionsResponse
 	resp.CollectionId = id
@@ -129,6 +131,7 @@ func (obj CollectionsObj) ModifyCollections(modifyCollectionsReq models.ModifyCo
 		loggerconfig.Error("ModifyCollections Mongo Upsert failed error =", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
+	admins.RecordAdminMutation(reqH, constants.AdminActionModify, constants.AdminEntityCollection, modifyCollectionsReq.CollectionId, Collections, mongoCollectionDetails)
 
 	var resp models.ModifyCollectionsResponse
 	resp.CollectionId = modifyCollectionsReq.CollectionId
@@ -176,7 +179,7 @@ func (obj CollectionsObj) FetchCollections(fetchCollectionsReq models.FetchColle
 func (obj CollectionsObj) DeleteCollections(deleteCollectionsReq models.DeleteCollectionsRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
 
 	var Collections models.MongoCollections
-	err := dbops.MongoRepo.FindOne(constants.COLLECTIONS, bson.M{"collectionName": deleteCollectionsReq.CollectionId}, &Collections)
+	err := dbops.MongoRepo.FindOne(constants.COLLECTIONS, bson.M{"collectionId": deleteCollectionsReq.CollectionId}, &Collections)
 
 	var apiRes apihelpers.APIRes
 	// if Collection does not exists
@@ -192,6 +195,7 @@ func (obj CollectionsObj) DeleteCollections(deleteCollectionsReq models.DeleteCo
 		loggerconfig.Error("DeleteCollections Mongo DeleteOne failed error =", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
+	admins.RecordAdminMutation(reqH, constants.AdminActionDelete, constants.AdminEntityCollection, deleteCollectionsReq.CollectionId, Collections, nil)
 
 	var deleteCollectionResponse models.DeleteCollecionsResponse
 	deleteCollectionResponse.CollectionMetaData = Collections.CollectionMetaData
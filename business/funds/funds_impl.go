@@ -167,27 +167,37 @@ func (obj FundsObjV3) Payout(req models.AtomPayoutRequest, reqH models.ReqHeader
 func (obj FundsObjV3) CancelPayout(req models.CancelPayoutReqV3, reqH models.ReqHeader) (int, apihelpers.APIRes) {
 
 	//fetch data against transactionID
-	res, err := obj.Db.GetTransactionData(req.TransactionId)
+	res, errCode, err := obj.fetchTransaction(req.TransactionId, constants.Payout)
 	if err != nil {
 		loggerconfig.Error("Alert Severity:P0-Critical, platform:", reqH.Platform, " CancelPayout insert error =%v", err, " uccId:", req.ClientID, " requestId:", reqH.RequestId)
 		return http.StatusInternalServerError, apihelpers.APIRes{Status: false, Message: fmt.Sprintf("Error while fetching transactions: %v", err)}
 	}
+	if errCode == "" && res.ClientID != req.ClientID {
+		errCode = constants.TransactionNotFound
+	}
+	if errCode != "" {
+		return apihelpers.SendErrorResponse(false, errCode, http.StatusBadRequest)
+	}
 
 	if res.TransactionStatus == constants.PROCEED {
 		return http.StatusOK, apihelpers.APIRes{Status: true, Message: constants.ErrorCodeMap[constants.InvalidPayoutRequest]}
 	}
 
-	updates := map[string]interface{}{
-		"transaction_status": constants.CANCELLED,
-		"updated_at":         helpers.GetCurrentTimeInIST(),
+	transition := models.PayoutStatusHistory{
+		TransactionId:  req.TransactionId,
+		ToStatus:       constants.CANCELLED,
+		Reason:         "cancelled by client|" + reqH.Platform,
+		UpdatedBy:      req.ClientID,
+		IdempotencyKey: payoutIdempotencyKey(req.TransactionId, constants.CANCELLED),
 	}
-
-	// Simulate updating data in the database
-	err = obj.Db.UpdateTransactionData(req.TransactionId, updates)
+	_, errCode, err = obj.applyTransition(constants.Payout, transition, false, false)
 	if err != nil {
 		loggerconfig.Error("Alert Severity:P0-Critical, platform:", reqH.Platform, " CancelPayout update error =%v", err, " uccId:", req.ClientID, " requestId:", reqH.RequestId)
 		return http.StatusInternalServerError, apihelpers.APIRes{Status: false, Message: fmt.Sprintf("Error cancelling payout: %v", err)}
 	}
+	if errCode != "" {
+		return apihelpers.SendErrorResponse(false, errCode, http.StatusBadRequest)
+	}
 
 	// Remove from Redis
 	currentTime := helpers.GetCurrentTimeInIST()
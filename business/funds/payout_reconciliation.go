@@ -0,0 +1,328 @@
+package funds
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"space/business/backoffice"
+	"space/business/tradelab"
+	"space/constants"
+	"space/db"
+	"space/dbops"
+	"space/loggerconfig"
+	"space/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const payoutReconcileRequestId = "payout-reconciliation"
+
+var tradelabFundsOnce sync.Once
+var tradelabFunds tradelab.FundsObj
+
+var CallFetchTradelabTransactions = func(clientId string, reqH models.ReqHeader) (int, []models.ClientTransactionsResponseData, error) {
+	tradelabFundsOnce.Do(func() {
+		tradelabFunds = tradelab.InitFetchFunds(db.GetPgObj())
+	})
+	status, res := tradelabFunds.ClientTransactions(models.ClientTransactionsRequest{ClientID: clientId}, reqH)
+	if status != http.StatusOK {
+		return status, nil, errors.New(res.Message)
+	}
+	txns, _ := res.Data.([]models.ClientTransactionsResponseData)
+	return status, txns, nil
+}
+
+var CallFetchBackofficeLedger = func(clientId string, from, to time.Time, reqH models.ReqHeader) ([]models.FinancialLedgerData, error) {
+	req := models.GetFinancialLedgerDataReq{
+		UserID:   clientId,
+		DFDateFr: from.Format(constants.ShilpiDateFormat),
+		DFDateTo: to.Format(constants.ShilpiDateFormat),
+	}
+	res, err := backoffice.InitBackofficeObj().GetFinancialLedgerData(req, reqH)
+	return res.FinancialLedger, err
+}
+
+var CallSavePayoutMismatch = func(mismatch models.MongoPayoutMismatch) error {
+	filter := bson.M{"transactionId": mismatch.TransactionId, "kind": mismatch.Kind}
+	update := bson.M{
+		"$set": bson.M{
+			"clientId":   mismatch.ClientId,
+			"status":     mismatch.Status,
+			"amount":     mismatch.Amount,
+			"lastSeenAt": mismatch.LastSeenAt,
+		},
+		"$setOnInsert": bson.M{"firstSeenAt": mismatch.FirstSeenAt},
+	}
+	return dbops.MongoRepo.UpdateOne(constants.PAYOUTMISMATCHCOLLECTION, filter, update, options.Update().SetUpsert(true))
+}
+
+// reconcileSession is the service login the job reads Tradelab
+// transactions with. Without one only the backoffice side is compared.
+func reconcileSession() (models.ReqHeader, bool) {
+	if constants.TradelabReconcileToken == "" {
+		return models.ReqHeader{}, false
+	}
+	return models.ReqHeader{
+		Authorization: "Bearer " + constants.TradelabReconcileToken,
+		RequestId:     payoutReconcileRequestId,
+	}, true
+}
+
+// ReconcilePayouts compares the recent payouts against Tradelab and the
+// backoffice ledger every PayoutReconcileMins.
+func ReconcilePayouts(obj FundsObjV3) {
+	for {
+		obj.reconcilePayouts()
+		time.Sleep(constants.PayoutReconcileMins * time.Minute)
+	}
+}
+
+func (obj FundsObjV3) reconcilePayouts() {
+	defer models.HandlePanic()
+
+	now := payoutNow()
+	payouts, err := obj.Db.FetchPayoutsSince(now.AddDate(0, 0, -constants.PayoutReconcileDays))
+	if err != nil {
+		loggerconfig.Error("reconcilePayouts error fetching payouts:", err)
+		return
+	}
+
+	var clientIds []string
+	byClient := make(map[string][]models.PayoutDetails)
+	for _, payout := range payouts {
+		if !isPayoutStatus(payout.TransactionStatus) {
+			// written before the state machine, nothing to compare against
+			continue
+		}
+		if _, ok := byClient[payout.ClientID]; !ok {
+			clientIds = append(clientIds, payout.ClientID)
+		}
+		byClient[payout.ClientID] = append(byClient[payout.ClientID], payout)
+	}
+
+	for _, clientId := range clientIds {
+		obj.reconcileClientPayouts(clientId, byClient[clientId], now)
+	}
+}
+
+func (obj FundsObjV3) reconcileClientPayouts(clientId string, payouts []models.PayoutDetails, now time.Time) {
+	defer models.HandlePanic()
+
+	reqH, tradelabChecked := reconcileSession()
+	var txns []models.ClientTransactionsResponseData
+	if tradelabChecked {
+		reqH.ClientId = clientId
+		status, res, err := CallFetchTradelabTransactions(clientId, reqH)
+		if err != nil {
+			loggerconfig.Error("reconcilePayouts tradelab transactions error:", err, " status:", status, " clientId:", clientId)
+			tradelabChecked = false
+		}
+		txns = res
+	}
+
+	ledgerReqH := models.ReqHeader{ClientId: clientId, RequestId: uuid.New().String()}
+	ledger, err := CallFetchBackofficeLedger(clientId, payouts[0].CreateDate, now, ledgerReqH)
+	backofficeChecked := err == nil
+	if err != nil {
+		loggerconfig.Error("reconcilePayouts backoffice ledger error:", err, " clientId:", clientId)
+	}
+
+	inTradelab, inBackoffice := matchPayouts(payouts, txns, ledger)
+	for _, payout := range payouts {
+		result := reconcilePayout(payout, inTradelab[payout.TransactionId], tradelabChecked, inBackoffice[payout.TransactionId], backofficeChecked, now)
+		obj.applyReconciliation(payout, result)
+		for _, kind := range result.mismatches {
+			loggerconfig.Error("Alert Severity:P1-High, reconcilePayouts mismatch:", kind, " status:", payout.TransactionStatus, " amount:", payout.Amount, " transactionId:", payout.TransactionId, " clientId:", clientId)
+			mismatch := models.MongoPayoutMismatch{
+				TransactionId: payout.TransactionId,
+				ClientId:      clientId,
+				Kind:          kind,
+				Status:        payout.TransactionStatus,
+				Amount:        payout.Amount,
+				FirstSeenAt:   now.Unix(),
+				LastSeenAt:    now.Unix(),
+			}
+			if err := CallSavePayoutMismatch(mismatch); err != nil {
+				loggerconfig.Error("reconcilePayouts error saving mismatch:", err, " transactionId:", payout.TransactionId)
+			}
+		}
+	}
+}
+
+// applyReconciliation records the funds updates seen on either side and
+// settles a payout once both sides show it.
+func (obj FundsObjV3) applyReconciliation(payout models.PayoutDetails, result payoutReconciliation) {
+	if result.settle {
+		transition := models.PayoutStatusHistory{
+			TransactionId:  payout.TransactionId,
+			ToStatus:       constants.TransactionSuccess,
+			Reason:         "seen in tradelab and backoffice",
+			UpdatedBy:      constants.PayoutReconcileActor,
+			IdempotencyKey: payoutIdempotencyKey(payout.TransactionId, constants.TransactionSuccess),
+		}
+		_, errCode, err := obj.applyTransition(constants.Payout, transition, result.tradelabUpdated, result.backofficeUpdated)
+		if err != nil || errCode != "" {
+			loggerconfig.Error("reconcilePayouts error settling payout:", err, " code:", errCode, " transactionId:", payout.TransactionId)
+		}
+		return
+	}
+	if !result.tradelabUpdated && !result.backofficeUpdated {
+		return
+	}
+
+	updates := map[string]interface{}{"updated_at": payoutNow()}
+	if result.tradelabUpdated {
+		updates["tradelab_funds_updated"] = true
+	}
+	if result.backofficeUpdated {
+		updates["backoffice_funds_updated"] = true
+	}
+	if err := obj.Db.UpdateTransactionData(payout.TransactionId, updates); err != nil {
+		loggerconfig.Error("reconcilePayouts error updating funds flags:", err, " transactionId:", payout.TransactionId)
+	}
+}
+
+type payoutReconciliation struct {
+	mismatches        []string
+	tradelabUpdated   bool
+	backofficeUpdated bool
+	settle            bool
+}
+
+// reconcilePayout decides what a payout's Postgres record is missing or
+// contradicts, given whether it was found on each side.
+func reconcilePayout(payout models.PayoutDetails, inTradelab, tradelabChecked, inBackoffice, backofficeChecked bool, now time.Time) payoutReconciliation {
+	var result payoutReconciliation
+	status := payout.TransactionStatus
+
+	if isPayoutVoided(status) {
+		if tradelabChecked && inTradelab {
+			result.mismatches = append(result.mismatches, constants.PayoutMismatchTradelabUnexpected)
+		}
+		if backofficeChecked && inBackoffice {
+			result.mismatches = append(result.mismatches, constants.PayoutMismatchBackofficeUnexpected)
+		}
+		return result
+	}
+
+	settled := isPayoutSettled(status)
+	if tradelabChecked {
+		if inTradelab {
+			result.tradelabUpdated = !payout.TradelabFundsUpdated
+		} else if settled || payout.TradelabFundsUpdated {
+			result.mismatches = append(result.mismatches, constants.PayoutMismatchTradelabMissing)
+		}
+	}
+	if backofficeChecked {
+		if inBackoffice {
+			result.backofficeUpdated = !payout.BackofficeFundsUpdated
+		} else if settled || payout.BackofficeFundsUpdated {
+			result.mismatches = append(result.mismatches, constants.PayoutMismatchBackofficeMissing)
+		}
+	}
+
+	if !settled && !isTransactionTerminal(constants.Payout, status) {
+		tradelabDone := payout.TradelabFundsUpdated || (tradelabChecked && inTradelab)
+		backofficeDone := payout.BackofficeFundsUpdated || (backofficeChecked && inBackoffice)
+		result.settle = tradelabDone && backofficeDone && transitionAllowed(constants.Payout, status, constants.TransactionSuccess)
+		if !result.settle && now.Sub(payout.CreateDate) > constants.PayoutStuckHours*time.Hour {
+			result.mismatches = append(result.mismatches, constants.PayoutMismatchStuck)
+		}
+	}
+	return result
+}
+
+// matchPayouts pairs each payout with at most one Tradelab transaction and one
+// backoffice ledger entry, by transaction id where Tradelab carries it and by
+// amount otherwise. Payouts are expected oldest first.
+func matchPayouts(payouts []models.PayoutDetails, txns []models.ClientTransactionsResponseData, ledger []models.FinancialLedgerData) (map[string]bool, map[string]bool) {
+	inTradelab := make(map[string]bool)
+	inBackoffice := make(map[string]bool)
+	usedTxns := make([]bool, len(txns))
+	usedLedger := make([]bool, len(ledger))
+
+	for i, txn := range txns {
+		if !isTradelabPayout(txn) {
+			usedTxns[i] = true
+			continue
+		}
+		for _, payout := range payouts {
+			if txn.MerchantTransactionID != "" && txn.MerchantTransactionID == payout.TransactionId {
+				inTradelab[payout.TransactionId] = true
+				usedTxns[i] = true
+				break
+			}
+		}
+	}
+
+	for _, payout := range payouts {
+		if !inTradelab[payout.TransactionId] {
+			for i, txn := range txns {
+				if usedTxns[i] {
+					continue
+				}
+				amount, err := strconv.ParseFloat(txn.Amount, 64)
+				if err == nil && samePaisa(amount, payout.Amount) {
+					inTradelab[payout.TransactionId] = true
+					usedTxns[i] = true
+					break
+				}
+			}
+		}
+
+		year, month, day := payout.CreateDate.Date()
+		created := time.Date(year, month, day, 0, 0, 0, 0, payout.CreateDate.Location())
+		for i, entry := range ledger {
+			if usedLedger[i] || entry.TransactionDetails != constants.FundPayment || !samePaisa(entry.Debit, payout.Amount) {
+				continue
+			}
+			if entryDate, err := time.ParseInLocation(constants.DDMMYYYY, entry.TransactionDate, payout.CreateDate.Location()); err == nil && entryDate.Before(created) {
+				continue
+			}
+			inBackoffice[payout.TransactionId] = true
+			usedLedger[i] = true
+			break
+		}
+	}
+	return inTradelab, inBackoffice
+}
+
+// isTradelabPayout skips pay-ins and transactions Tradelab gave up on.
+func isTradelabPayout(txn models.ClientTransactionsResponseData) bool {
+	txnType := strings.ToLower(txn.TransactionType)
+	for _, payin := range []string{"payin", "pay_in", "deposit", "credit"} {
+		if strings.Contains(txnType, payin) {
+			return false
+		}
+	}
+	status := strings.ToLower(txn.Status)
+	for _, failed := range []string{"fail", "reject", "cancel"} {
+		if strings.Contains(status, failed) {
+			return false
+		}
+	}
+	return true
+}
+
+func samePaisa(rupees float64, paisa int64) bool {
+	return math.Abs(rupees*100-float64(paisa)) < 1
+}
+
+func isPayoutStatus(status string) bool {
+	_, open := constants.PayoutTransitions[status]
+	return open || isPayoutSettled(status) || isPayoutVoided(status)
+}
+
+func isPayoutSettled(status string) bool {
+	return status == constants.TransactionSuccess
+}
+
+func isPayoutVoided(status string) bool {
+	return status == constants.TransactionFailed || status == constants.CANCELLED
+}
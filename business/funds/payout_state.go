@@ -0,0 +1,147 @@
+package funds
+
+import (
+	"net/http"
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+	"strings"
+)
+
+var payoutNow = helpers.GetCurrentTimeInIST
+
+// TransitionPayout moves a payout to the requested status. Replaying a
+// request with the same idempotency key, or asking for the status the payout
+// is already in, succeeds without changing anything.
+func (obj FundsObjV3) TransitionPayout(req models.PayoutTransitionRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	transition := models.PayoutStatusHistory{
+		TransactionId:  req.TransactionId,
+		ToStatus:       req.Status,
+		Reason:         req.Reason,
+		UpdatedBy:      req.UpdatedBy,
+		IdempotencyKey: req.IdempotencyKey,
+	}
+	res, errCode, err := obj.applyTransition(constants.Payout, transition, req.TradelabFundsUpdated, req.BackofficeFundsUpdated)
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P0-Critical, platform:", reqH.Platform, " TransitionPayout error =", err, " transactionId:", req.TransactionId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	if errCode != "" {
+		loggerconfig.Info("TransitionPayout rejected ", res.From, " -> ", req.Status, " code:", errCode, " transactionId:", req.TransactionId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, errCode, http.StatusBadRequest)
+	}
+
+	loggerconfig.Info("TransitionPayout ", res.From, " -> ", res.Status, " applied:", res.Applied, " transactionId:", req.TransactionId, " updatedBy:", req.UpdatedBy, " requestId:", reqH.RequestId)
+	return http.StatusOK, apihelpers.APIRes{Status: true, Message: "SUCCESS", Data: res}
+}
+
+// FetchPayoutHistory returns a payout along with every status change it went through.
+func (obj FundsObjV3) FetchPayoutHistory(req models.FetchPayoutHistoryRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	payout, errCode, err := obj.fetchTransaction(req.TransactionId, constants.Payout)
+	if err != nil {
+		loggerconfig.Error("FetchPayoutHistory error fetching payout =", err, " transactionId:", req.TransactionId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	if errCode != "" {
+		return apihelpers.SendErrorResponse(false, errCode, http.StatusBadRequest)
+	}
+
+	history, err := obj.Db.GetPayoutStatusHistory(req.TransactionId)
+	if err != nil {
+		loggerconfig.Error("FetchPayoutHistory error fetching history =", err, " transactionId:", req.TransactionId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	return http.StatusOK, apihelpers.APIRes{Status: true, Message: "SUCCESS", Data: models.FetchPayoutHistoryResponse{Payout: *payout, History: history}}
+}
+
+// applyTransition is the only place payout and payin statuses change. It
+// returns an error code when the transition is not allowed and an error when
+// the database could not be reached.
+func (obj FundsObjV3) applyTransition(txnType string, transition models.PayoutStatusHistory, tradelabFundsUpdated bool, backofficeFundsUpdated bool) (models.PayoutTransitionResponse, string, error) {
+	res := models.PayoutTransitionResponse{TransactionId: transition.TransactionId, Status: transition.ToStatus}
+
+	if transition.IdempotencyKey != "" {
+		done, err := obj.Db.GetPayoutTransitionByKey(transition.IdempotencyKey)
+		if err != nil {
+			return res, "", err
+		}
+		if done != nil {
+			// the key was already used, possibly for a different change
+			if done.TransactionId != transition.TransactionId || done.ToStatus != transition.ToStatus {
+				return res, constants.InvalidTransactionTransition, nil
+			}
+			res.From = done.FromStatus
+			return res, "", nil
+		}
+	}
+
+	txn, errCode, err := obj.fetchTransaction(transition.TransactionId, txnType)
+	if err != nil || errCode != "" {
+		return res, errCode, err
+	}
+	res.From = txn.TransactionStatus
+	if txn.TransactionStatus == transition.ToStatus {
+		return res, "", nil
+	}
+	if !transitionAllowed(txnType, txn.TransactionStatus, transition.ToStatus) {
+		return res, constants.InvalidTransactionTransition, nil
+	}
+
+	transition.FromStatus = txn.TransactionStatus
+	transition.CreatedAt = payoutNow()
+	applied, err := obj.Db.TransitionTransactionStatus(transition, tradelabFundsUpdated, backofficeFundsUpdated)
+	if err != nil {
+		return res, "", err
+	}
+	if applied {
+		res.Applied = true
+		return res, "", nil
+	}
+
+	// another writer moved the transaction in between, which is only fine if it
+	// ended up where this transition was headed
+	txn, errCode, err = obj.fetchTransaction(transition.TransactionId, txnType)
+	if err != nil || errCode != "" {
+		return res, errCode, err
+	}
+	res.From = txn.TransactionStatus
+	if txn.TransactionStatus != transition.ToStatus {
+		return res, constants.InvalidTransactionTransition, nil
+	}
+	return res, "", nil
+}
+
+func (obj FundsObjV3) fetchTransaction(transactionId string, txnType string) (*models.PayoutDetails, string, error) {
+	txn, err := obj.Db.GetTransactionData(transactionId)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "no transaction found") {
+			return nil, constants.TransactionNotFound, nil
+		}
+		return nil, "", err
+	}
+	if txn.TransactionType != txnType {
+		return nil, constants.TransactionNotFound, nil
+	}
+	return txn, "", nil
+}
+
+func transitionAllowed(txnType, from, to string) bool {
+	for _, next := range constants.TransactionTransitions[txnType][from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func isTransactionTerminal(txnType, status string) bool {
+	_, ok := constants.TransactionTransitions[txnType][status]
+	return !ok
+}
+
+func payoutIdempotencyKey(parts ...string) string {
+	return strings.Join(parts, ":")
+}
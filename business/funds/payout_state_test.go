@@ -0,0 +1,203 @@
+package funds
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/db"
+	"space/loggerconfig"
+	"space/models"
+)
+
+// payoutDb keeps transaction_info_v2 and payout_status_history in memory.
+type payoutDb struct {
+	db.Database
+	payouts map[string]*models.PayoutDetails
+	history []models.PayoutStatusHistory
+	// raced moves the payout before the conditional update lands
+	raced string
+}
+
+func (p *payoutDb) GetTransactionData(transactionID string) (*models.PayoutDetails, error) {
+	payout, ok := p.payouts[transactionID]
+	if !ok {
+		return nil, fmt.Errorf("no transaction found for ID: %s", transactionID)
+	}
+	copied := *payout
+	return &copied, nil
+}
+
+func (p *payoutDb) InsertTransactionData(payoutDetails models.PayoutDetails) error {
+	p.payouts[payoutDetails.TransactionId] = &payoutDetails
+	return nil
+}
+
+func (p *payoutDb) UpdateTransactionData(transactionID string, updates map[string]interface{}) error {
+	payout := p.payouts[transactionID]
+	if updated, ok := updates["tradelab_funds_updated"].(bool); ok {
+		payout.TradelabFundsUpdated = updated
+	}
+	if updated, ok := updates["backoffice_funds_updated"].(bool); ok {
+		payout.BackofficeFundsUpdated = updated
+	}
+	return nil
+}
+
+func (p *payoutDb) GetPayoutTransitionByKey(idempotencyKey string) (*models.PayoutStatusHistory, error) {
+	for _, transition := range p.history {
+		if transition.IdempotencyKey == idempotencyKey {
+			return &transition, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *payoutDb) TransitionTransactionStatus(transition models.PayoutStatusHistory, tradelabFundsUpdated bool, backofficeFundsUpdated bool) (bool, error) {
+	payout := p.payouts[transition.TransactionId]
+	if p.raced != "" {
+		payout.TransactionStatus, p.raced = p.raced, ""
+	}
+	if payout.TransactionStatus != transition.FromStatus {
+		return false, nil
+	}
+	payout.TransactionStatus = transition.ToStatus
+	payout.TradelabFundsUpdated = payout.TradelabFundsUpdated || tradelabFundsUpdated
+	payout.BackofficeFundsUpdated = payout.BackofficeFundsUpdated || backofficeFundsUpdated
+	p.history = append(p.history, transition)
+	return true, nil
+}
+
+func TestTransitionPayout(t *testing.T) {
+	origNow, origInfo, origError := payoutNow, loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() { payoutNow, loggerconfig.Info, loggerconfig.Error = origNow, origInfo, origError })
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+	payoutNow = func() time.Time { return time.Date(2026, 10, 21, 11, 0, 0, 0, constants.LocationKolkata) }
+
+	store := &payoutDb{payouts: map[string]*models.PayoutDetails{
+		"t1": {TransactionId: "t1", TransactionType: constants.Payout, TransactionStatus: constants.Pending},
+	}}
+	obj := FundsObjV3{Db: store}
+	move := func(to, key string) (int, models.PayoutTransitionResponse, string) {
+		code, res := obj.TransitionPayout(models.PayoutTransitionRequest{TransactionId: "t1", Status: to, UpdatedBy: "ops", IdempotencyKey: key}, models.ReqHeader{})
+		data, _ := res.Data.(models.PayoutTransitionResponse)
+		return code, data, res.ErrorCode
+	}
+
+	if code, res, _ := move(constants.PROCESS, "k1"); code != http.StatusOK || !res.Applied || res.From != constants.Pending {
+		t.Fatalf("PENDING -> PROCESS = %d, %+v", code, res)
+	}
+	// a replayed key and a repeated status are both no-ops
+	if code, res, _ := move(constants.PROCESS, "k1"); code != http.StatusOK || res.Applied {
+		t.Errorf("replay = %d, %+v", code, res)
+	}
+	if code, res, _ := move(constants.PROCESS, "k2"); code != http.StatusOK || res.Applied {
+		t.Errorf("same status = %d, %+v", code, res)
+	}
+	// a key reused for another change is refused
+	if code, _, errCode := move(constants.TransactionSuccess, "k1"); code != http.StatusBadRequest || errCode != constants.InvalidTransactionTransition {
+		t.Errorf("reused key = %d, %s", code, errCode)
+	}
+
+	if code, _, _ := move(constants.TransactionSuccess, "k3"); code != http.StatusOK {
+		t.Fatalf("PROCESS -> SUCCESS = %d", code)
+	}
+	if code, _, errCode := move(constants.CANCELLED, "k4"); code != http.StatusBadRequest || errCode != constants.InvalidTransactionTransition {
+		t.Errorf("SUCCESS -> CANCELLED = %d, %s", code, errCode)
+	}
+
+	var moves []string
+	for _, transition := range store.history {
+		moves = append(moves, transition.FromStatus+">"+transition.ToStatus)
+	}
+	if want := []string{"PENDING>PROCESS", "PROCESS>SUCCESS"}; !reflect.DeepEqual(moves, want) {
+		t.Errorf("history = %v, want %v", moves, want)
+	}
+
+	code, res := obj.TransitionPayout(models.PayoutTransitionRequest{TransactionId: "missing", Status: constants.PROCESS, IdempotencyKey: "k5"}, models.ReqHeader{})
+	if code != http.StatusBadRequest || res.ErrorCode != constants.TransactionNotFound {
+		t.Errorf("missing payout = %d, %+v", code, res)
+	}
+}
+
+func TestApplyPayoutTransitionRace(t *testing.T) {
+	origNow, origInfo, origError := payoutNow, loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() { payoutNow, loggerconfig.Info, loggerconfig.Error = origNow, origInfo, origError })
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+	payoutNow = func() time.Time { return time.Date(2026, 10, 21, 11, 0, 0, 0, constants.LocationKolkata) }
+
+	store := &payoutDb{payouts: map[string]*models.PayoutDetails{
+		"t1": {TransactionId: "t1", TransactionType: constants.Payout, TransactionStatus: constants.PROCESS},
+	}}
+	obj := FundsObjV3{Db: store}
+
+	// the batch marked it PROCEED first, so the cancel must not go through
+	store.raced = constants.PROCEED
+	_, errCode, err := obj.applyTransition(constants.Payout, models.PayoutStatusHistory{TransactionId: "t1", ToStatus: constants.CANCELLED}, false, false)
+	if err != nil || errCode != constants.InvalidTransactionTransition || len(store.history) != 0 {
+		t.Errorf("lost race = %q, %v, history %+v", errCode, err, store.history)
+	}
+
+	// another instance already settled it, which is what was asked for
+	store.raced = constants.TransactionSuccess
+	res, errCode, err := obj.applyTransition(constants.Payout, models.PayoutStatusHistory{TransactionId: "t1", ToStatus: constants.TransactionSuccess}, true, true)
+	if err != nil || errCode != "" || res.Applied {
+		t.Errorf("concurrent settle = %+v, %q, %v", res, errCode, err)
+	}
+}
+
+func TestReconcilePayouts(t *testing.T) {
+	now := time.Date(2026, 10, 21, 18, 0, 0, 0, constants.LocationKolkata)
+	payouts := []models.PayoutDetails{
+		{TransactionId: "settled", Amount: 150000, TransactionStatus: constants.PROCEED, CreateDate: now.Add(-30 * time.Hour)},
+		{TransactionId: "sameAmount", Amount: 150000, TransactionStatus: constants.TransactionSuccess, CreateDate: now.Add(-6 * time.Hour)},
+		{TransactionId: "cancelled", Amount: 2550, TransactionStatus: constants.CANCELLED, CreateDate: now.Add(-5 * time.Hour)},
+		{TransactionId: "stuck", Amount: 99900, TransactionStatus: constants.Pending, CreateDate: now.Add(-50 * time.Hour)},
+	}
+	txns := []models.ClientTransactionsResponseData{
+		{Amount: "1500.00", MerchantTransactionID: "sameAmount", TransactionType: "PAYOUT"},
+		{Amount: "1500", TransactionType: "PAYOUT"},
+		{Amount: "25.50", TransactionType: "PAYOUT"},
+		{Amount: "999", TransactionType: "PAYIN"},
+		{Amount: "999", TransactionType: "PAYOUT", Status: "FAILED"},
+	}
+	ledger := []models.FinancialLedgerData{
+		{TransactionDetails: constants.FundPayment, Debit: 1500, TransactionDate: "20-10-2026"},
+		{TransactionDetails: constants.FundReceived, Credit: 999, TransactionDate: "21-10-2026"},
+	}
+
+	inTradelab, inBackoffice := matchPayouts(payouts, txns, ledger)
+	if want := map[string]bool{"settled": true, "sameAmount": true, "cancelled": true}; !reflect.DeepEqual(inTradelab, want) {
+		t.Errorf("inTradelab = %v, want %v", inTradelab, want)
+	}
+	// the single ledger entry goes to the older payout
+	if want := map[string]bool{"settled": true}; !reflect.DeepEqual(inBackoffice, want) {
+		t.Errorf("inBackoffice = %v, want %v", inBackoffice, want)
+	}
+
+	tests := []struct {
+		payout     models.PayoutDetails
+		tradelab   bool
+		backoffice bool
+		checked    bool
+		want       payoutReconciliation
+	}{
+		{payouts[0], true, true, true, payoutReconciliation{tradelabUpdated: true, backofficeUpdated: true, settle: true}},
+		{payouts[1], true, false, true, payoutReconciliation{mismatches: []string{constants.PayoutMismatchBackofficeMissing}, tradelabUpdated: true}},
+		{payouts[2], true, false, true, payoutReconciliation{mismatches: []string{constants.PayoutMismatchTradelabUnexpected}}},
+		{payouts[3], false, false, true, payoutReconciliation{mismatches: []string{constants.PayoutMismatchStuck}}},
+		// nothing is claimed missing when a side could not be read
+		{payouts[1], false, false, false, payoutReconciliation{}},
+	}
+	for _, tt := range tests {
+		got := reconcilePayout(tt.payout, tt.tradelab, tt.checked, tt.backoffice, tt.checked, now)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("reconcilePayout(%s) = %+v, want %+v", tt.payout.TransactionId, got, tt.want)
+		}
+	}
+}
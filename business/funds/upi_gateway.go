@@ -0,0 +1,133 @@
+package funds
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+	"strings"
+	"sync"
+)
+
+// UpiGateway is implemented once per payment gateway payins can be raised
+// with. The gateway in use is picked by constants.UpiGateway.
+type UpiGateway interface {
+	// Collect sends a collect request to the order's VPA.
+	Collect(order models.UpiPayinOrder) (models.UpiGatewayOrder, error)
+	// Intent returns a upi:// link the client app hands to a UPI app.
+	Intent(order models.UpiPayinOrder) (models.UpiGatewayOrder, error)
+	// VerifyWebhook checks a callback's signature before decoding it.
+	VerifyWebhook(body []byte, signature string) (models.UpiWebhookEvent, error)
+}
+
+var upiGatewaysMu sync.RWMutex
+var upiGateways = map[string]UpiGateway{}
+
+func RegisterUpiGateway(name string, gateway UpiGateway) {
+	upiGatewaysMu.Lock()
+	defer upiGatewaysMu.Unlock()
+	upiGateways[name] = gateway
+}
+
+func upiGateway(name string) (UpiGateway, bool) {
+	upiGatewaysMu.RLock()
+	defer upiGatewaysMu.RUnlock()
+	gateway, ok := upiGateways[name]
+	return gateway, ok
+}
+
+var errInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+/*
+InitUpiGateways registers the gateways payins can be raised with in env. The
+fake gateway settles whatever a correctly signed callback tells it to, so it
+is only registered in local and dev, and only when config names it and gives
+it a secret.
+*/
+func InitUpiGateways(env string) {
+	if constants.UpiGateway != constants.UpiGatewayFake {
+		return
+	}
+	if env != constants.LocalEnv && env != constants.DevEnv {
+		loggerconfig.Error("Alert Severity:P0-Critical, InitUpiGateways fake upi gateway is not allowed, env:", env)
+		return
+	}
+	if constants.FakeUpiSecret == "" {
+		loggerconfig.Error("Alert Severity:P1-High, InitUpiGateways fake upi gateway has no secret configured, env:", env)
+		return
+	}
+	RegisterUpiGateway(constants.UpiGatewayFake, FakeUpiGateway{Secret: constants.FakeUpiSecret, Vpa: constants.FakeUpiVpa})
+}
+
+// FakeUpiGateway accepts every order and expects webhooks signed with
+// HMAC-SHA256 over the raw body. It stands in for a real gateway in local
+// setups and tests.
+type FakeUpiGateway struct {
+	Secret string
+	Vpa    string
+}
+
+// FakeUpiWebhook is the callback body FakeUpiGateway understands.
+type FakeUpiWebhook struct {
+	EventId       string `json:"eventId"`
+	TransactionId string `json:"merchantRef"`
+	OrderId       string `json:"orderId"`
+	Status        string `json:"status"`
+	Amount        int64  `json:"amount"`
+	Reason        string `json:"reason"`
+}
+
+func (g FakeUpiGateway) Collect(order models.UpiPayinOrder) (models.UpiGatewayOrder, error) {
+	if order.UpiId == "" {
+		return models.UpiGatewayOrder{}, errors.New("vpa missing")
+	}
+	return models.UpiGatewayOrder{GatewayOrderId: "fake_" + order.TransactionId}, nil
+}
+
+func (g FakeUpiGateway) Intent(order models.UpiPayinOrder) (models.UpiGatewayOrder, error) {
+	intentUrl := fmt.Sprintf("upi://pay?pa=%s&tr=%s&am=%.2f&cu=INR", g.Vpa, order.TransactionId, float64(order.AmountInPaisa)/100)
+	return models.UpiGatewayOrder{GatewayOrderId: "fake_" + order.TransactionId, IntentUrl: intentUrl}, nil
+}
+
+func (g FakeUpiGateway) VerifyWebhook(body []byte, signature string) (models.UpiWebhookEvent, error) {
+	var event models.UpiWebhookEvent
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, g.sum(body)) {
+		return event, errInvalidWebhookSignature
+	}
+
+	var webhook FakeUpiWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return event, err
+	}
+	event = models.UpiWebhookEvent{
+		EventId:        webhook.EventId,
+		TransactionId:  webhook.TransactionId,
+		GatewayOrderId: webhook.OrderId,
+		AmountInPaisa:  webhook.Amount,
+		Reason:         webhook.Reason,
+	}
+	switch strings.ToLower(webhook.Status) {
+	case "captured":
+		event.Status = constants.TransactionSuccess
+	case "failed", "expired", "declined":
+		event.Status = constants.TransactionFailed
+	}
+	return event, nil
+}
+
+// Sign returns the signature header value for a webhook body.
+func (g FakeUpiGateway) Sign(body []byte) string {
+	return hex.EncodeToString(g.sum(body))
+}
+
+func (g FakeUpiGateway) sum(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(g.Secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
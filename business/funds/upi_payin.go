@@ -0,0 +1,279 @@
+package funds
+
+import (
+	"errors"
+	"net/http"
+	apihelpers "space/apiHelpers"
+	upipreference "space/business/upi"
+	"space/constants"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var CallFetchUpiPreferences = func(clientId string) ([]string, error) {
+	upiPreference, err := upipreference.CallFetchUpiPreferenceMongo(clientId, upipreference.UpiPreferenceObj{})
+	if err != nil && err.Error() != constants.MongoNoDocError {
+		return nil, err
+	}
+	return upiPreference.UpiIds, nil
+}
+
+// CallCreditPayin hands a settled payin to the funds consumer, which credits
+// it to the trading account once per transaction ID.
+var CallCreditPayin = func(event models.PayinCreditEvent) error {
+	return helpers.PublishMessage(constants.TopicExchange, constants.KeyFundsPayinCredit, event)
+}
+
+// UpiCollect sends a collect request to one of the client's saved VPAs, the
+// most recently saved one when none is given.
+func (obj FundsObjV3) UpiCollect(req models.UpiCollectRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	upiIds, err := CallFetchUpiPreferences(req.ClientID)
+	if err != nil {
+		loggerconfig.Error("UpiCollect error fetching upi preferences:", err, " uccId:", req.ClientID, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	upiId := req.UpiId
+	if upiId == "" && len(upiIds) > 0 {
+		upiId = upiIds[0]
+	}
+	saved := false
+	for _, id := range upiIds {
+		if id == upiId {
+			saved = true
+			break
+		}
+	}
+	if !saved {
+		loggerconfig.Info("UpiCollect vpa not in upi preferences, uccId:", req.ClientID, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.UpiDontExist, http.StatusBadRequest)
+	}
+
+	return obj.raisePayin(constants.UpiModeCollect, req.ClientID, req.Amount, upiId, reqH)
+}
+
+// UpiIntent returns a upi:// link for the client app to open in a UPI app.
+func (obj FundsObjV3) UpiIntent(req models.UpiIntentRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	return obj.raisePayin(constants.UpiModeIntent, req.ClientID, req.Amount, "", reqH)
+}
+
+func (obj FundsObjV3) raisePayin(mode, clientId, amount, upiId string, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	gatewayName := constants.UpiGateway
+	gateway, ok := upiGateway(gatewayName)
+	if !ok {
+		loggerconfig.Error("Alert Severity:P1-High, raisePayin no upi gateway configured, gateway:", gatewayName, " uccId:", clientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.UpiGatewayUnavailable, http.StatusServiceUnavailable)
+	}
+
+	amountInPaisa, ok := payinAmountInPaisa(amount)
+	if !ok {
+		return apihelpers.SendErrorResponse(false, constants.InvalidPayinAmount, http.StatusBadRequest)
+	}
+
+	now := payoutNow()
+	var payinDetails models.PayoutDetails
+	payinDetails.Amount = amountInPaisa
+	payinDetails.ClientID = clientId
+	payinDetails.AccountNumber = upiId
+	payinDetails.DebitCredit = constants.Credit
+	payinDetails.TradelabFundsUpdated = false
+	payinDetails.BackofficeFundsUpdated = false
+	payinDetails.Remarks = mode + "|" + gatewayName + "|" + reqH.Platform
+	payinDetails.CreateDate = now
+	payinDetails.UpdatedAt = now
+	payinDetails.TransactionType = constants.PayinTransaction
+	payinDetails.TransactionId = uuid.New().String()
+	payinDetails.TransactionStatus = constants.Pending
+
+	err := obj.Db.InsertTransactionData(payinDetails)
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P0-Critical, raisePayin Error inserting data into DB:", err, " uccId:", clientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	order := models.UpiPayinOrder{
+		TransactionId: payinDetails.TransactionId,
+		ClientId:      clientId,
+		UpiId:         upiId,
+		AmountInPaisa: amountInPaisa,
+		ExpiresAt:     now.Add(constants.UpiCollectExpiryMins * time.Minute).Unix(),
+	}
+	var gatewayOrder models.UpiGatewayOrder
+	if mode == constants.UpiModeCollect {
+		gatewayOrder, err = gateway.Collect(order)
+	} else {
+		gatewayOrder, err = gateway.Intent(order)
+	}
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, raisePayin gateway error:", err, " mode:", mode, " gateway:", gatewayName, " transactionId:", payinDetails.TransactionId, " uccId:", clientId, " requestId:", reqH.RequestId)
+		obj.failPayin(payinDetails.TransactionId, "gateway rejected: "+err.Error(), gatewayName)
+		return apihelpers.SendErrorResponse(false, constants.UpiGatewayUnavailable, http.StatusBadGateway)
+	}
+
+	loggerconfig.Info("raisePayin mode:", mode, " gateway:", gatewayName, " gatewayOrderId:", gatewayOrder.GatewayOrderId, " transactionId:", payinDetails.TransactionId, " uccId:", clientId, " requestId:", reqH.RequestId)
+	res := models.UpiPayinResponse{
+		TransactionId: payinDetails.TransactionId,
+		Mode:          mode,
+		Status:        payinDetails.TransactionStatus,
+		IntentUrl:     gatewayOrder.IntentUrl,
+		ExpiresAt:     order.ExpiresAt,
+	}
+	return http.StatusOK, apihelpers.APIRes{Status: true, Message: "SUCCESS", Data: res}
+}
+
+func (obj FundsObjV3) failPayin(transactionId, reason, gatewayName string) {
+	transition := models.PayoutStatusHistory{
+		TransactionId:  transactionId,
+		ToStatus:       constants.TransactionFailed,
+		Reason:         reason,
+		UpdatedBy:      gatewayName,
+		IdempotencyKey: payoutIdempotencyKey(transactionId, constants.TransactionFailed),
+	}
+	if _, errCode, err := obj.applyTransition(constants.PayinTransaction, transition, false, false); err != nil || errCode != "" {
+		loggerconfig.Error("failPayin error:", err, " code:", errCode, " transactionId:", transactionId)
+	}
+}
+
+// UpiPayinStatus returns where a payin stands, for the app to poll after
+// raising it.
+func (obj FundsObjV3) UpiPayinStatus(req models.UpiPayinStatusRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	payin, errCode, err := obj.fetchTransaction(req.TransactionId, constants.PayinTransaction)
+	if err != nil {
+		loggerconfig.Error("UpiPayinStatus error fetching payin:", err, " uccId:", req.ClientID, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	if errCode == "" && payin.ClientID != req.ClientID {
+		errCode = constants.TransactionNotFound
+	}
+	if errCode != "" {
+		return apihelpers.SendErrorResponse(false, errCode, http.StatusBadRequest)
+	}
+
+	res := models.UpiPayinStatusResponse{
+		TransactionId: payin.TransactionId,
+		Mode:          payinRemark(payin.Remarks, 0),
+		Status:        payin.TransactionStatus,
+		Amount:        payin.Amount,
+		UpdatedAt:     payin.UpdatedAt.Unix(),
+	}
+	return http.StatusOK, apihelpers.APIRes{Status: true, Message: "SUCCESS", Data: res}
+}
+
+// UpiPayinWebhook settles a payin from a gateway callback and credits it once
+// it succeeds. A callback whose credit could not be handed over is not
+// acknowledged, so the gateway sends it again. Replays of a credited payin
+// are acknowledged without a second credit.
+func (obj FundsObjV3) UpiPayinWebhook(req models.UpiWebhookRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	gateway, ok := upiGateway(req.Gateway)
+	if !ok {
+		loggerconfig.Error("UpiPayinWebhook unknown gateway:", req.Gateway, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.UpiGatewayUnavailable, http.StatusNotFound)
+	}
+
+	event, err := gateway.VerifyWebhook(req.Body, req.Signature)
+	if err != nil {
+		if errors.Is(err, errInvalidWebhookSignature) {
+			loggerconfig.Error("Alert Severity:P1-High, UpiPayinWebhook signature mismatch, gateway:", req.Gateway, " clientIp:", reqH.ClientPublicIP, " requestId:", reqH.RequestId)
+			return apihelpers.SendErrorResponse(false, constants.InvalidWebhookSignature, http.StatusUnauthorized)
+		}
+		loggerconfig.Error("UpiPayinWebhook error decoding callback:", err, " gateway:", req.Gateway, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidRequest, http.StatusBadRequest)
+	}
+
+	payin, errCode, err := obj.fetchTransaction(event.TransactionId, constants.PayinTransaction)
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P0-Critical, UpiPayinWebhook error fetching payin:", err, " transactionId:", event.TransactionId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	if errCode != "" {
+		loggerconfig.Error("Alert Severity:P1-High, UpiPayinWebhook callback for unknown payin, transactionId:", event.TransactionId, " gateway:", req.Gateway, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, errCode, http.StatusBadRequest)
+	}
+	// a payin is only ever settled by the gateway it was raised with
+	if raisedWith := payinGateway(payin.Remarks); raisedWith != req.Gateway {
+		loggerconfig.Error("Alert Severity:P0-Critical, UpiPayinWebhook callback from another gateway, gateway:", req.Gateway, " raisedWith:", raisedWith, " transactionId:", event.TransactionId, " uccId:", payin.ClientID, " clientIp:", reqH.ClientPublicIP, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidWebhookSignature, http.StatusUnauthorized)
+	}
+
+	if event.Status == "" {
+		loggerconfig.Info("UpiPayinWebhook payin still in progress, transactionId:", event.TransactionId, " requestId:", reqH.RequestId)
+		return http.StatusOK, apihelpers.APIRes{Status: true, Message: "SUCCESS"}
+	}
+	if event.Status == constants.TransactionSuccess && event.AmountInPaisa != payin.Amount {
+		loggerconfig.Error("Alert Severity:P0-Critical, UpiPayinWebhook amount mismatch, paid:", event.AmountInPaisa, " expected:", payin.Amount, " transactionId:", event.TransactionId, " uccId:", payin.ClientID, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidPayinAmount, http.StatusBadRequest)
+	}
+
+	transition := models.PayoutStatusHistory{
+		TransactionId:  event.TransactionId,
+		ToStatus:       event.Status,
+		Reason:         event.Reason,
+		UpdatedBy:      req.Gateway,
+		IdempotencyKey: payoutIdempotencyKey(req.Gateway, event.EventId),
+	}
+	if event.EventId == "" {
+		transition.IdempotencyKey = ""
+	}
+	res, errCode, err := obj.applyTransition(constants.PayinTransaction, transition, false, false)
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P0-Critical, UpiPayinWebhook error settling payin:", err, " transactionId:", event.TransactionId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	if errCode != "" {
+		// e.g. money captured after the payin was already marked failed
+		loggerconfig.Error("Alert Severity:P0-Critical, UpiPayinWebhook rejected ", res.From, " -> ", event.Status, " transactionId:", event.TransactionId, " uccId:", payin.ClientID, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, errCode, http.StatusBadRequest)
+	}
+
+	// a payin's tradelab funds flag is set once its credit is handed over, so a
+	// replay after a failed hand-over still credits it
+	if event.Status == constants.TransactionSuccess && !payin.TradelabFundsUpdated {
+		credit := models.PayinCreditEvent{
+			TransactionId: payin.TransactionId,
+			ClientId:      payin.ClientID,
+			AmountInPaisa: payin.Amount,
+			UpiId:         payin.AccountNumber,
+			Gateway:       req.Gateway,
+			CreditedAt:    payoutNow().Unix(),
+		}
+		if err := CallCreditPayin(credit); err != nil {
+			loggerconfig.Error("Alert Severity:P0-Critical, UpiPayinWebhook error crediting payin:", err, " transactionId:", payin.TransactionId, " uccId:", payin.ClientID, " requestId:", reqH.RequestId)
+			return apihelpers.SendInternalServerError()
+		}
+		updates := map[string]interface{}{"tradelab_funds_updated": true, "updated_at": payoutNow()}
+		if err := obj.Db.UpdateTransactionData(payin.TransactionId, updates); err != nil {
+			// the credit went out, acknowledging keeps the gateway from sending it again
+			loggerconfig.Error("Alert Severity:P1-High, UpiPayinWebhook payin credited but not marked:", err, " transactionId:", payin.TransactionId, " uccId:", payin.ClientID, " requestId:", reqH.RequestId)
+		}
+	}
+
+	loggerconfig.Info("UpiPayinWebhook ", res.From, " -> ", event.Status, " applied:", res.Applied, " transactionId:", event.TransactionId, " requestId:", reqH.RequestId)
+	return http.StatusOK, apihelpers.APIRes{Status: true, Message: "SUCCESS"}
+}
+
+// payinRemark is one part of the mode|gateway|platform remarks a payin is
+// raised with.
+func payinRemark(remarks string, part int) string {
+	parts := strings.Split(remarks, "|")
+	if part >= len(parts) {
+		return ""
+	}
+	return parts[part]
+}
+
+func payinGateway(remarks string) string {
+	return payinRemark(remarks, 1)
+}
+
+func payinAmountInPaisa(amount string) (int64, bool) {
+	rupees, err := strconv.ParseFloat(amount, 64)
+	if err != nil || rupees <= 0 || rupees > constants.UpiPayinMaxAmount {
+		return 0, false
+	}
+	return int64(rupees*100 + 0.5), true
+}
@@ -0,0 +1,171 @@
+package funds
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+)
+
+func TestInitUpiGateways(t *testing.T) {
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	origGateway, origSecret := constants.UpiGateway, constants.FakeUpiSecret
+	t.Cleanup(func() {
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+		constants.UpiGateway, constants.FakeUpiSecret = origGateway, origSecret
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+
+	constants.UpiGateway = constants.UpiGatewayFake
+	for _, tt := range []struct{ env, secret string }{{constants.Production, "s"}, {"preprod", "s"}, {constants.LocalEnv, ""}} {
+		constants.FakeUpiSecret = tt.secret
+		InitUpiGateways(tt.env)
+		if _, ok := upiGateway(constants.UpiGatewayFake); ok {
+			t.Errorf("fake gateway registered in %q with secret %q", tt.env, tt.secret)
+		}
+	}
+}
+
+func TestUpiPayin(t *testing.T) {
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	origNow, origFetch, origCredit := payoutNow, CallFetchUpiPreferences, CallCreditPayin
+	origGateway, origSecret := constants.UpiGateway, constants.FakeUpiSecret
+	t.Cleanup(func() {
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+		payoutNow, CallFetchUpiPreferences, CallCreditPayin = origNow, origFetch, origCredit
+		constants.UpiGateway, constants.FakeUpiSecret = origGateway, origSecret
+		upiGatewaysMu.Lock()
+		delete(upiGateways, constants.UpiGatewayFake)
+		delete(upiGateways, "other")
+		upiGatewaysMu.Unlock()
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+	payoutNow = func() time.Time { return time.Date(2026, 10, 21, 11, 0, 0, 0, constants.LocationKolkata) }
+
+	// register the fake gateway the way local config does
+	constants.UpiGateway, constants.FakeUpiSecret = constants.UpiGatewayFake, "test-upi-secret"
+	InitUpiGateways(constants.LocalEnv)
+	gateway := FakeUpiGateway{Secret: constants.FakeUpiSecret, Vpa: constants.FakeUpiVpa}
+
+	t.Run("webhook", func(t *testing.T) {
+		RegisterUpiGateway("other", gateway)
+
+		store := &payoutDb{payouts: map[string]*models.PayoutDetails{
+			"p1": {TransactionId: "p1", ClientID: "AB123", Amount: 50000, TransactionType: constants.PayinTransaction, TransactionStatus: constants.Pending, Remarks: constants.UpiModeIntent + "|" + constants.UpiGatewayFake + "|android"},
+		}}
+		obj := FundsObjV3{Db: store}
+		var credits []models.PayinCreditEvent
+		var creditErr error
+		CallCreditPayin = func(event models.PayinCreditEvent) error {
+			if creditErr != nil {
+				return creditErr
+			}
+			credits = append(credits, event)
+			return nil
+		}
+
+		callbackFrom := func(gatewayName string, webhook FakeUpiWebhook, signature string) (int, string) {
+			body, _ := json.Marshal(webhook)
+			if signature == "" {
+				signature = gateway.Sign(body)
+			}
+			code, res := obj.UpiPayinWebhook(models.UpiWebhookRequest{Gateway: gatewayName, Body: body, Signature: signature}, models.ReqHeader{})
+			return code, res.ErrorCode
+		}
+		callback := func(webhook FakeUpiWebhook, signature string) (int, string) {
+			return callbackFrom(constants.UpiGatewayFake, webhook, signature)
+		}
+		captured := FakeUpiWebhook{EventId: "e1", TransactionId: "p1", Status: "captured", Amount: 50000}
+
+		// a validly signed callback through a gateway the payin was not raised with
+		if code, errCode := callbackFrom("other", captured, ""); code != http.StatusUnauthorized || errCode != constants.InvalidWebhookSignature || len(credits) != 0 {
+			t.Errorf("callback from another gateway = %d, %s, credits = %d", code, errCode, len(credits))
+		}
+
+		if code, errCode := callback(captured, "00ff"); code != http.StatusUnauthorized || errCode != constants.InvalidWebhookSignature {
+			t.Errorf("forged callback = %d, %s", code, errCode)
+		}
+		short := captured
+		short.Amount = 100
+		if code, errCode := callback(short, ""); code != http.StatusBadRequest || errCode != constants.InvalidPayinAmount {
+			t.Errorf("short payment = %d, %s", code, errCode)
+		}
+
+		// a credit that cannot be handed over is not acknowledged, and the
+		// gateway's retry of the same callback credits it
+		creditErr = errors.New("broker down")
+		if code, _ := callback(captured, ""); code != http.StatusInternalServerError || len(credits) != 0 {
+			t.Errorf("callback with the credit down = %d, credits = %d", code, len(credits))
+		}
+		if store.payouts["p1"].TransactionStatus != constants.TransactionSuccess || store.payouts["p1"].TradelabFundsUpdated {
+			t.Errorf("payin after failed credit = %+v", store.payouts["p1"])
+		}
+		creditErr = nil
+
+		// the gateway retries until acknowledged, the payin is credited once
+		for i := 0; i < 2; i++ {
+			if code, errCode := callback(captured, ""); code != http.StatusOK {
+				t.Fatalf("captured callback = %d, %s", code, errCode)
+			}
+		}
+		if store.payouts["p1"].TransactionStatus != constants.TransactionSuccess || !store.payouts["p1"].TradelabFundsUpdated || len(credits) != 1 || credits[0].AmountInPaisa != 50000 || credits[0].ClientId != "AB123" {
+			t.Errorf("payin = %+v, credits = %+v", store.payouts["p1"], credits)
+		}
+
+		failed := FakeUpiWebhook{EventId: "e2", TransactionId: "p1", Status: "failed"}
+		if code, errCode := callback(failed, ""); code != http.StatusBadRequest || errCode != constants.InvalidTransactionTransition {
+			t.Errorf("failure after success = %d, %s", code, errCode)
+		}
+	})
+
+	t.Run("raise", func(t *testing.T) {
+		CallFetchUpiPreferences = func(clientId string) ([]string, error) {
+			return []string{"ab123@okbank", "ab123@oldbank"}, nil
+		}
+		store := &payoutDb{payouts: map[string]*models.PayoutDetails{}}
+		obj := FundsObjV3{Db: store}
+
+		code, res := obj.UpiCollect(models.UpiCollectRequest{ClientID: "AB123", Amount: "2500.50"}, models.ReqHeader{Platform: "android"})
+		if code != http.StatusOK {
+			t.Fatalf("UpiCollect() = %d, %+v", code, res)
+		}
+		collect := res.Data.(models.UpiPayinResponse)
+		payin := store.payouts[collect.TransactionId]
+		if payin.Amount != 250050 || payin.AccountNumber != "ab123@okbank" || payin.TransactionType != constants.PayinTransaction || payin.DebitCredit != constants.Credit || payin.TransactionStatus != constants.Pending || payinGateway(payin.Remarks) != constants.UpiGatewayFake {
+			t.Errorf("payin = %+v", payin)
+		}
+		if collect.ExpiresAt != payoutNow().Add(10*time.Minute).Unix() {
+			t.Errorf("ExpiresAt = %d", collect.ExpiresAt)
+		}
+
+		// only saved VPAs can be collected from
+		if code, res := obj.UpiCollect(models.UpiCollectRequest{ClientID: "AB123", Amount: "10", UpiId: "someone@bank"}, models.ReqHeader{}); code != http.StatusBadRequest || res.ErrorCode != constants.UpiDontExist {
+			t.Errorf("unsaved vpa = %d, %+v", code, res)
+		}
+		if code, res := obj.UpiIntent(models.UpiIntentRequest{ClientID: "AB123", Amount: "100001"}, models.ReqHeader{}); code != http.StatusBadRequest || res.ErrorCode != constants.InvalidPayinAmount {
+			t.Errorf("over the UPI limit = %d, %+v", code, res)
+		}
+
+		code, res = obj.UpiIntent(models.UpiIntentRequest{ClientID: "AB123", Amount: "99"}, models.ReqHeader{})
+		intent := res.Data.(models.UpiPayinResponse)
+		if code != http.StatusOK || intent.IntentUrl != "upi://pay?pa=pocketful@fakeupi&tr="+intent.TransactionId+"&am=99.00&cu=INR" {
+			t.Errorf("UpiIntent() = %d, %+v", code, intent)
+		}
+
+		if code, res := obj.UpiPayinStatus(models.UpiPayinStatusRequest{ClientID: "ZZ999", TransactionId: intent.TransactionId}, models.ReqHeader{}); code != http.StatusBadRequest || res.ErrorCode != constants.TransactionNotFound {
+			t.Errorf("another client's payin = %d, %+v", code, res)
+		}
+
+		constants.UpiGateway = "unknown"
+		if code, res := obj.UpiIntent(models.UpiIntentRequest{ClientID: "AB123", Amount: "99"}, models.ReqHeader{}); code != http.StatusServiceUnavailable || res.ErrorCode != constants.UpiGatewayUnavailable {
+			t.Errorf("no gateway = %d, %+v", code, res)
+		}
+	})
+}
@@ -0,0 +1,363 @@
+package globalsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/dbops"
+	"space/loggerconfig"
+	"space/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type GlobalSearchObj struct {
+	scrips      models.ContractDetailsProviderV2
+	pockets     models.ExecutePocketV3
+	collections models.CollectionsProvider
+	ipo         models.IpoProvider
+	cmots       models.CMOTSProviderV2
+}
+
+func InitGlobalSearchProvider(scrips models.ContractDetailsProviderV2, pockets models.ExecutePocketV3, collections models.CollectionsProvider, ipo models.IpoProvider, cmots models.CMOTSProviderV2) GlobalSearchObj {
+	defer models.HandlePanic()
+	return GlobalSearchObj{
+		scrips:      scrips,
+		pockets:     pockets,
+		collections: collections,
+		ipo:         ipo,
+		cmots:       cmots,
+	}
+}
+
+// searchSource returns every match of one type, scored but not yet limited.
+type searchSource func(obj GlobalSearchObj, query string, limit int, reqH models.ReqHeader) ([]models.GlobalSearchResult, error)
+
+var searchSources = map[string]searchSource{
+	constants.GlobalSearchTypeScrip:      searchScrips,
+	constants.GlobalSearchTypePocket:     searchPockets,
+	constants.GlobalSearchTypeCollection: searchCollections,
+	constants.GlobalSearchTypeIpo:        searchIpos,
+	constants.GlobalSearchTypeBond:       searchBonds,
+	constants.GlobalSearchTypeSector:     searchSectors,
+}
+
+var CallFindBonds = findBonds
+
+var sourceTimeout = constants.GlobalSearchSourceTimeout * time.Millisecond
+
+type sourceResult struct {
+	searchType string
+	results    []models.GlobalSearchResult
+	err        error
+}
+
+// GlobalSearch queries every requested source in parallel and returns the
+// matches grouped by type, along with the best of them in one relevance order.
+// A source that fails or misses the deadline leaves its group empty.
+func (obj GlobalSearchObj) GlobalSearch(req models.GlobalSearchReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	types, ok := requestedTypes(req.Types)
+	if !ok {
+		loggerconfig.Error("GlobalSearch invalid search types:", req.Types, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidSearchType, http.StatusBadRequest)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = constants.GlobalSearchDefaultLimit
+	}
+	if limit > constants.GlobalSearchMaxLimit {
+		limit = constants.GlobalSearchMaxLimit
+	}
+
+	resultCh := make(chan sourceResult, len(types))
+	for _, searchType := range types {
+		go func(searchType string) {
+			defer func() {
+				if r := recover(); r != nil {
+					resultCh <- sourceResult{searchType: searchType, err: fmt.Errorf("panic: %v", r)}
+				}
+			}()
+			results, err := searchSources[searchType](obj, req.Query, limit, reqH)
+			resultCh <- sourceResult{searchType: searchType, results: results, err: err}
+		}(searchType)
+	}
+
+	bySource := make(map[string]sourceResult)
+	timeout := time.NewTimer(sourceTimeout)
+	defer timeout.Stop()
+collect:
+	for len(bySource) < len(types) {
+		select {
+		case res := <-resultCh:
+			bySource[res.searchType] = res
+		case <-timeout.C:
+			break collect
+		}
+	}
+
+	response := models.GlobalSearchResponse{
+		Query:         req.Query,
+		TopResults:    []models.GlobalSearchResult{},
+		Groups:        []models.GlobalSearchGroup{},
+		FailedSources: []string{},
+	}
+	for _, searchType := range types {
+		res, done := bySource[searchType]
+		if !done || res.err != nil {
+			if !done {
+				res.err = errors.New("timed out")
+			}
+			loggerconfig.Error("GlobalSearch source:", searchType, " failed, error:", res.err, " query:", req.Query, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+			response.FailedSources = append(response.FailedSources, searchType)
+			response.Groups = append(response.Groups, models.GlobalSearchGroup{Type: searchType, Results: []models.GlobalSearchResult{}})
+			continue
+		}
+
+		weight := constants.GlobalSearchTypeWeights[searchType]
+		for i := range res.results {
+			res.results[i].Type = searchType
+			res.results[i].Score = math.Round(res.results[i].Score*weight*10000) / 10000
+		}
+		// stable so ties keep the order the source ranked them in
+		sort.SliceStable(res.results, func(i, j int) bool {
+			return res.results[i].Score > res.results[j].Score
+		})
+
+		group := models.GlobalSearchGroup{Type: searchType, Total: len(res.results), Results: res.results}
+		if len(group.Results) > limit {
+			group.Results = group.Results[:limit]
+		}
+		response.Groups = append(response.Groups, group)
+		response.TopResults = append(response.TopResults, group.Results...)
+	}
+
+	sort.SliceStable(response.TopResults, func(i, j int) bool {
+		return response.TopResults[i].Score > response.TopResults[j].Score
+	})
+	if len(response.TopResults) > constants.GlobalSearchTopLimit {
+		response.TopResults = response.TopResults[:constants.GlobalSearchTopLimit]
+	}
+
+	loggerconfig.Info("GlobalSearch query:", req.Query, " results:", len(response.TopResults), " failedSources:", response.FailedSources, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Data = response
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// requestedTypes validates the types filter, keeping the response group order.
+func requestedTypes(filter []string) ([]string, bool) {
+	if len(filter) == 0 {
+		return constants.GlobalSearchTypes, true
+	}
+	wanted := make(map[string]bool)
+	for _, searchType := range filter {
+		searchType = strings.ToLower(strings.TrimSpace(searchType))
+		if _, ok := searchSources[searchType]; !ok {
+			return nil, false
+		}
+		wanted[searchType] = true
+	}
+	var types []string
+	for _, searchType := range constants.GlobalSearchTypes {
+		if wanted[searchType] {
+			types = append(types, searchType)
+		}
+	}
+	return types, true
+}
+
+func sourceError(name string, code int, res apihelpers.APIRes) error {
+	return fmt.Errorf("%s returned %d: %s", name, code, res.Message)
+}
+
+func searchScrips(obj GlobalSearchObj, query string, limit int, reqH models.ReqHeader) ([]models.GlobalSearchResult, error) {
+	code, res := obj.scrips.SearchScrip(query, reqH, 0, limit, "")
+	if code != http.StatusOK {
+		return nil, sourceError("SearchScrip", code, res)
+	}
+	stocks, _ := res.Data.([]models.SearchScripResponseResult)
+
+	var results []models.GlobalSearchResult
+	for _, stock := range stocks {
+		// the scrip index already matched it, possibly fuzzily or as a derivative
+		score := bestScore(query, []string{stock.Symbol, stock.TradingSymbol, stock.DisplayName, stock.Company}, stock.Isin)
+		if score < containsMatchScore {
+			score = containsMatchScore
+		}
+		results = append(results, models.GlobalSearchResult{
+			Id:       stock.Exchange + "_" + stock.Token,
+			Title:    stock.DisplayName,
+			Subtitle: stock.Company,
+			Score:    score,
+			Data:     stock,
+		})
+	}
+	return results, nil
+}
+
+func searchPockets(obj GlobalSearchObj, query string, limit int, reqH models.ReqHeader) ([]models.GlobalSearchResult, error) {
+	code, res := obj.pockets.FetchAllPocketsV3(reqH)
+	if code != http.StatusOK {
+		return nil, sourceError("FetchAllPocketsV3", code, res)
+	}
+	pockets, _ := res.Data.([]models.MongoPocketsV3)
+
+	var results []models.GlobalSearchResult
+	for _, pocket := range pockets {
+		score := bestScore(query, []string{pocket.PocketName}, pocket.PocketShortDesc, pocket.Tag)
+		if score == 0 {
+			continue
+		}
+		results = append(results, models.GlobalSearchResult{
+			Id:       pocket.PocketId,
+			Title:    pocket.PocketName,
+			Subtitle: pocket.PocketShortDesc,
+			Score:    score,
+			Data:     pocket,
+		})
+	}
+	return results, nil
+}
+
+func searchCollections(obj GlobalSearchObj, query string, limit int, reqH models.ReqHeader) ([]models.GlobalSearchResult, error) {
+	code, res := obj.collections.FetchAllCollections(reqH)
+	if code != http.StatusOK {
+		return nil, sourceError("FetchAllCollections", code, res)
+	}
+	collections, _ := res.Data.(models.FetchAllCollectionsDetailsResponse)
+
+	var results []models.GlobalSearchResult
+	for _, collection := range collections.FetchAllCollectionsDetailsResponse {
+		score := bestScore(query, []string{collection.CollectionName}, collection.CollectionShortDesc)
+		if score == 0 {
+			continue
+		}
+		results = append(results, models.GlobalSearchResult{
+			Id:       collection.CollectionId,
+			Title:    collection.CollectionName,
+			Subtitle: collection.CollectionShortDesc,
+			Score:    score,
+			Data:     collection,
+		})
+	}
+	return results, nil
+}
+
+// searchIpos only looks at IPOs that are open or upcoming.
+func searchIpos(obj GlobalSearchObj, query string, limit int, reqH models.ReqHeader) ([]models.GlobalSearchResult, error) {
+	code, res := obj.ipo.GetAllIpo(models.GetAllIpoRequest{}, reqH)
+	if code != http.StatusOK {
+		return nil, sourceError("GetAllIpo", code, res)
+	}
+	allIpo, _ := res.Data.(models.GetAllIpoResponse)
+
+	var results []models.GlobalSearchResult
+	addIpos := func(ipos []models.IpoState, status string) {
+		for _, ipo := range ipos {
+			score := bestScore(query, []string{ipo.Symbol, ipo.Name}, ipo.Isin)
+			if score == 0 {
+				continue
+			}
+			results = append(results, models.GlobalSearchResult{
+				Id:       ipo.Symbol,
+				Title:    ipo.Name,
+				Subtitle: fmt.Sprintf("%s, %s - %s", status, strconv.FormatFloat(ipo.MinPrice, 'f', -1, 64), strconv.FormatFloat(ipo.MaxPrice, 'f', -1, 64)),
+				Score:    score,
+				Data:     ipo,
+			})
+		}
+	}
+	addIpos(allIpo.OpenIpo, "Open")
+	addIpos(allIpo.UpcomingIpo, "Upcoming")
+	return results, nil
+}
+
+func searchBonds(obj GlobalSearchObj, query string, limit int, reqH models.ReqHeader) ([]models.GlobalSearchResult, error) {
+	bonds, err := CallFindBonds(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.GlobalSearchResult
+	for _, bond := range bonds {
+		score := bestScore(query, []string{bond.IssuerName, bond.ISIN})
+		if score == 0 {
+			continue
+		}
+		results = append(results, models.GlobalSearchResult{
+			Id:       bond.ISIN,
+			Title:    bond.IssuerName,
+			Subtitle: fmt.Sprintf("Coupon %s, matures %s", bond.CouponRate, bond.MaturityDate),
+			Score:    score,
+			Data:     bond,
+		})
+	}
+	return results, nil
+}
+
+// findBonds matches issuer name or ISIN in the same collection FetchBondDataByIsin reads.
+func findBonds(query string) ([]models.GlobalSearchBond, error) {
+	pattern := regexp.QuoteMeta(strings.TrimSpace(query))
+	filter := bson.M{"$or": []bson.M{
+		{"issuername": bson.M{"$regex": pattern, "$options": "i"}},
+		{"isin": bson.M{"$regex": "^" + pattern, "$options": "i"}},
+	}}
+
+	cursor, err := dbops.MongoRepo.Find(constants.BondsDataCollection, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var bonds []models.GlobalSearchBond
+	if err := cursor.All(context.Background(), &bonds); err != nil {
+		return nil, err
+	}
+	return bonds, nil
+}
+
+// searchSectors matches the sector categories as well as the CMOTS sector
+// names grouped under them, so "insurance" finds Finance.
+func searchSectors(obj GlobalSearchObj, query string, limit int, reqH models.ReqHeader) ([]models.GlobalSearchResult, error) {
+	code, res := obj.cmots.FetchSectorListV2("", reqH)
+	if code != http.StatusOK {
+		return nil, sourceError("FetchSectorListV2", code, res)
+	}
+	sectors, _ := res.Data.([]map[string]interface{})
+
+	var results []models.GlobalSearchResult
+	for _, sector := range sectors {
+		name, _ := sector["sectName"].(string)
+		codes, _ := sector["sectCode"].([]string)
+		score := bestScore(query, []string{name}, constants.SectorMapping[name]...)
+		if score == 0 {
+			continue
+		}
+		results = append(results, models.GlobalSearchResult{
+			Id:       name,
+			Title:    name,
+			Subtitle: strings.Join(constants.SectorMapping[name], ", "),
+			Score:    score,
+			Data:     models.GlobalSearchSector{SectName: name, SectCodes: codes},
+		})
+	}
+	// the sector list comes out of a map, give it a stable order
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Title < results[j].Title
+	})
+	return results, nil
+}
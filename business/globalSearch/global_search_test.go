@@ -0,0 +1,213 @@
+package globalsearch
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeScrips struct {
+	models.ContractDetailsProviderV2
+	stocks []models.SearchScripResponseResult
+	code   int
+}
+
+func (f fakeScrips) SearchScrip(query string, reqH models.ReqHeader, offset, capacity int, exchange string) (int, apihelpers.APIRes) {
+	if f.code != 0 {
+		return f.code, apihelpers.APIRes{Message: "down"}
+	}
+	stocks := f.stocks
+	if len(stocks) > capacity {
+		stocks = stocks[:capacity]
+	}
+	return http.StatusOK, apihelpers.APIRes{Status: true, Data: stocks}
+}
+
+type fakePockets struct {
+	models.ExecutePocketV3
+	pockets []models.MongoPocketsV3
+	delay   time.Duration
+}
+
+func (f fakePockets) FetchAllPocketsV3(reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	time.Sleep(f.delay)
+	return http.StatusOK, apihelpers.APIRes{Status: true, Data: f.pockets}
+}
+
+type fakeCollections struct {
+	models.CollectionsProvider
+}
+
+func (f fakeCollections) FetchAllCollections(reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	return http.StatusOK, apihelpers.APIRes{Status: true, Data: models.FetchAllCollectionsDetailsResponse{
+		FetchAllCollectionsDetailsResponse: []models.FetchCollectionsDetailsResponse{
+			{CollectionId: "c1", CollectionName: "Tata Group"},
+			{CollectionId: "c2", CollectionName: "Defence", CollectionShortDesc: "Tata and others in defence"},
+			{CollectionId: "c3", CollectionName: "Pharma"},
+		},
+	}}
+}
+
+type fakeIpo struct {
+	models.IpoProvider
+	err bool
+}
+
+func (f fakeIpo) GetAllIpo(req models.GetAllIpoRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	if f.err {
+		return http.StatusInternalServerError, apihelpers.APIRes{Message: "tradelab down"}
+	}
+	return http.StatusOK, apihelpers.APIRes{Status: true, Data: models.GetAllIpoResponse{
+		OpenIpo:     []models.IpoState{{Symbol: "TATATECH", Name: "Tata Technologies", MinPrice: 475, MaxPrice: 500}},
+		UpcomingIpo: []models.IpoState{{Symbol: "SWIGGY", Name: "Swiggy"}},
+		ClosedIpo:   []models.IpoState{{Symbol: "TATACAP", Name: "Tata Capital"}},
+	}}
+}
+
+type fakeCmots struct {
+	models.CMOTSProviderV2
+}
+
+func (f fakeCmots) FetchSectorListV2(sectorCode string, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	return http.StatusOK, apihelpers.APIRes{Status: true, Data: []map[string]interface{}{
+		{"sectName": "Finance", "sectCode": []string{"00000034", "00000035"}},
+		{"sectName": "Banks", "sectCode": []string{"00000002"}},
+	}}
+}
+
+func searchFixture() GlobalSearchObj {
+	scrips := fakeScrips{stocks: []models.SearchScripResponseResult{
+		{Exchange: "NSE", Token: "3499", Symbol: "TATASTEEL", DisplayName: "TATASTEEL", Company: "TATA STEEL"},
+		{Exchange: "NSE", Token: "3456", Symbol: "TATAMOTORS", DisplayName: "TATAMOTORS", Company: "TATA MOTORS"},
+		{Exchange: "NSE", Token: "3432", Symbol: "TATACONSUM", DisplayName: "TATACONSUM", Company: "TATA CONSUMER"},
+	}}
+	pockets := fakePockets{pockets: []models.MongoPocketsV3{
+		{PocketId: "p1", PocketName: "Tata Titans"},
+		{PocketId: "p2", PocketName: "Green Energy"},
+	}}
+	return InitGlobalSearchProvider(scrips, pockets, fakeCollections{}, fakeIpo{}, fakeCmots{})
+}
+
+func resultIds(results []models.GlobalSearchResult) []string {
+	ids := []string{}
+	for _, result := range results {
+		ids = append(ids, result.Id)
+	}
+	return ids
+}
+
+func groupByType(res models.GlobalSearchResponse, searchType string) models.GlobalSearchGroup {
+	for _, group := range res.Groups {
+		if group.Type == searchType {
+			return group
+		}
+	}
+	return models.GlobalSearchGroup{}
+}
+
+func TestTextScore(t *testing.T) {
+	assert.Equal(t, 1.0, textScore("tata steel", "TATA  STEEL"))
+	assert.Equal(t, 0.8, textScore("tata", "Tata Titans"))
+	assert.Equal(t, 0.6, textScore("energy", "Green Energy"))
+	assert.Equal(t, 0.4, textScore("ergy", "Green Energy"))
+	assert.Equal(t, 0.0, textScore("pharma", "Green Energy"))
+	assert.Equal(t, 0.5, bestScore("insurance", []string{"Finance"}, "Insurance"))
+}
+
+func TestGlobalSearch(t *testing.T) {
+	origFindBonds, origTimeout := CallFindBonds, sourceTimeout
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		CallFindBonds, sourceTimeout = origFindBonds, origTimeout
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+	CallFindBonds = func(query string) ([]models.GlobalSearchBond, error) {
+		return []models.GlobalSearchBond{
+			{ISIN: "INE155A08191", IssuerName: "Tata Motors Finance"},
+			{ISIN: "INE001A07TB4", IssuerName: "Housing Development"},
+		}, nil
+	}
+
+	t.Run("groups every type and merges them by relevance", func(t *testing.T) {
+		code, res := searchFixture().GlobalSearch(models.GlobalSearchReq{Query: "tata", Limit: 2}, models.ReqHeader{})
+		assert.Equal(t, http.StatusOK, code)
+		data := res.Data.(models.GlobalSearchResponse)
+
+		var types []string
+		for _, group := range data.Groups {
+			types = append(types, group.Type)
+		}
+		assert.Equal(t, constants.GlobalSearchTypes, types)
+		assert.Empty(t, data.FailedSources)
+
+		scrips := groupByType(data, constants.GlobalSearchTypeScrip)
+		assert.Equal(t, 2, len(scrips.Results))
+		assert.Equal(t, []string{"NSE_3499", "NSE_3456"}, resultIds(scrips.Results))
+
+		// only open and upcoming IPOs are searched
+		assert.Equal(t, []string{"TATATECH"}, resultIds(groupByType(data, constants.GlobalSearchTypeIpo).Results))
+		assert.Equal(t, "Open, 475 - 500", groupByType(data, constants.GlobalSearchTypeIpo).Results[0].Subtitle)
+
+		// a name match beats a description match
+		assert.Equal(t, []string{"c1", "c2"}, resultIds(groupByType(data, constants.GlobalSearchTypeCollection).Results))
+		assert.Equal(t, []string{"p1"}, resultIds(groupByType(data, constants.GlobalSearchTypePocket).Results))
+		assert.Equal(t, []string{"INE155A08191"}, resultIds(groupByType(data, constants.GlobalSearchTypeBond).Results))
+		assert.Empty(t, groupByType(data, constants.GlobalSearchTypeSector).Results)
+
+		assert.Equal(t, []string{"NSE_3499", "NSE_3456", "TATATECH", "p1", "c1", "INE155A08191", "c2"}, resultIds(data.TopResults))
+		for i := 1; i < len(data.TopResults); i++ {
+			assert.GreaterOrEqual(t, data.TopResults[i-1].Score, data.TopResults[i].Score)
+		}
+	})
+
+	t.Run("sector matches its cmots sector names", func(t *testing.T) {
+		_, res := searchFixture().GlobalSearch(models.GlobalSearchReq{Query: "insurance", Types: []string{"SECTOR"}}, models.ReqHeader{})
+		data := res.Data.(models.GlobalSearchResponse)
+		assert.Len(t, data.Groups, 1)
+		assert.Equal(t, []string{"Finance"}, resultIds(data.TopResults))
+		assert.Equal(t, []string{"00000034", "00000035"}, data.TopResults[0].Data.(models.GlobalSearchSector).SectCodes)
+	})
+
+	t.Run("a failing source leaves its group empty", func(t *testing.T) {
+		obj := searchFixture()
+		obj.ipo = fakeIpo{err: true}
+		CallFindBonds = func(query string) ([]models.GlobalSearchBond, error) {
+			return nil, errors.New("mongo down")
+		}
+
+		code, res := obj.GlobalSearch(models.GlobalSearchReq{Query: "tata"}, models.ReqHeader{})
+		assert.Equal(t, http.StatusOK, code)
+		data := res.Data.(models.GlobalSearchResponse)
+		assert.Equal(t, []string{constants.GlobalSearchTypeIpo, constants.GlobalSearchTypeBond}, data.FailedSources)
+		assert.Empty(t, groupByType(data, constants.GlobalSearchTypeIpo).Results)
+		assert.Len(t, groupByType(data, constants.GlobalSearchTypeScrip).Results, 3)
+	})
+
+	t.Run("a slow source is dropped at the deadline", func(t *testing.T) {
+		sourceTimeout = 50 * time.Millisecond
+		t.Cleanup(func() { sourceTimeout = origTimeout })
+		obj := searchFixture()
+		obj.pockets = fakePockets{delay: time.Second}
+
+		_, res := obj.GlobalSearch(models.GlobalSearchReq{Query: "tata", Types: []string{"pocket", "scrip"}}, models.ReqHeader{})
+		data := res.Data.(models.GlobalSearchResponse)
+		assert.Equal(t, []string{constants.GlobalSearchTypePocket}, data.FailedSources)
+		assert.NotEmpty(t, data.TopResults)
+	})
+
+	t.Run("unknown type is rejected", func(t *testing.T) {
+		code, res := searchFixture().GlobalSearch(models.GlobalSearchReq{Query: "tata", Types: []string{"mutualfund"}}, models.ReqHeader{})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, constants.InvalidSearchType, res.ErrorCode)
+	})
+}
@@ -0,0 +1,61 @@
+package globalsearch
+
+import (
+	"strings"
+)
+
+const (
+	exactMatchScore      = 1
+	prefixMatchScore     = 0.8
+	wordPrefixMatchScore = 0.6
+	containsMatchScore   = 0.4
+	// description fields only count for half of a title match
+	secondaryFieldFactor = 0.5
+)
+
+// textScore is how well a single field matches the query: an exact match beats
+// a prefix, which beats a word inside the field starting with the query, which
+// beats the query appearing anywhere in it.
+func textScore(query, field string) float64 {
+	query = normalise(query)
+	field = normalise(field)
+	if query == "" || field == "" {
+		return 0
+	}
+	switch {
+	case field == query:
+		return exactMatchScore
+	case strings.HasPrefix(field, query):
+		return prefixMatchScore
+	}
+	for _, word := range strings.Fields(field) {
+		if strings.HasPrefix(word, query) {
+			return wordPrefixMatchScore
+		}
+	}
+	if strings.Contains(field, query) {
+		return containsMatchScore
+	}
+	return 0
+}
+
+// bestScore is the best match over the title fields, falling back to the
+// secondary fields at a discount.
+func bestScore(query string, titles []string, secondary ...string) float64 {
+	var best float64
+	for _, field := range titles {
+		if score := textScore(query, field); score > best {
+			best = score
+		}
+	}
+	for _, field := range secondary {
+		if score := textScore(query, field) * secondaryFieldFactor; score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+func normalise(text string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(text)), " ")
+}
@@ -1,9 +1,11 @@
 package health
 
 import (
+	searchscriptv2 "space/business/searchScriptV2"
 	"space/constants"
 	"space/db"
 	"space/helpers/cache"
+	"space/models"
 )
 
 func GetHealthInfo() map[string]string {
@@ -37,3 +39,9 @@ func GetHealthInfo() map[string]string {
 
 	return nil
 }
+
+// GetSearchBackendInfo reports whether scrip search is served by RediSearch or
+// the local fallback index. Serving from the fallback is not a failure.
+func GetSearchBackendInfo() models.SearchBackendStatus {
+	return searchscriptv2.SearchBackendStatus()
+}
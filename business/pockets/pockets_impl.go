@@ -11,6 +11,7 @@ import (
 	"time"
 
 	apihelpers "space/apiHelpers"
+	"space/business/admins"
 	"space/business/tradelab"
 	"space/constants"
 	"space/db"
@@ -61,6 +62,10 @@ func (obj ExecutePocketV2Obj) AdminLogin(loginReq models.AdminLoginRequest, reqH
 		return apihelpers.SendErrorResponse(false, constants.AdminInvalidCreds, http.StatusBadRequest)
 	}
 
+	if dbUser.Disabled {
+		return apihelpers.SendErrorResponse(false, constants.AdminDisabled, http.StatusForbidden)
+	}
+
 	//save the jwt token in redis
 	jwtToken, err := helpers.GenerateJWT(loginReq.UserId)
 	if err != nil {
@@ -76,6 +81,7 @@ func (obj ExecutePocketV2Obj) AdminLogin(loginReq models.AdminLoginRequest, reqH
 	//return jwt token in response body
 	var adminLogRes models.AdminLoginResponse
 	adminLogRes.AuthToken = jwtToken
+	adminLogRes.Role = dbUser.Role
 
 	loggerconfig.Info("AdminLogin Successful, response:", helpers.LogStructAsJSON(adminLogRes), "clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
 	apiRes.Data = adminLogRes
@@ -134,6 +140,7 @@ func (obj ExecutePocketV2Obj) CreatePockets(createPocketsReq models.CreatePocket
 		loggerconfig.Error("CreatePockets Mongo Upsert failed error =", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
+	admins.RecordAdminMutation(reqH, constants.AdminActionCreate, constants.AdminEntityPocket, id, nil, mongoPocketDetails)
 
 	var resp models.CreatePocketsResponse
 	resp.PocketId = id
@@ -195,6 +202,7 @@ func (obj ExecutePocketV2Obj) ModifyPockets(modifyPocketsReq models.ModifyPocket
 		loggerconfig.Error("ModifyPockets Mongo Upsert failed error =", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
+	admins.RecordAdminMutation(reqH, constants.AdminActionModify, constants.AdminEntityPocket, modifyPocketsReq.PocketId, pockets, mongoPocketDetails)
 
 	var resp models.ModifyPocketsResponse
 	resp.PocketId = modifyPocketsReq.PocketId
@@ -264,6 +272,7 @@ func (obj ExecutePocketV2Obj) DeletePockets(deletePocketsReq models.DeletePocket
 		loggerconfig.Error("DeletePockets Mongo Upsert failed error =", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
+	admins.RecordAdminMutation(reqH, constants.AdminActionDelete, constants.AdminEntityPocket, deletePocketsReq.PocketId, pockets, nil)
 
 	var resp models.DeletePocketsResponse
 	resp.PocketTokens = pockets.PocketTokens
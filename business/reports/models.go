@@ -38,6 +38,15 @@ type SendEmailFnoTradebook struct {
 	ApplicantName     string `json:"applicantName"`
 }
 
+type SendEmailFnoPnl struct {
+	ClientId          string `json:"clientId"`
+	EncodedReportFile string `json:"encodedReportFile"`
+	DateFrom          string `json:"dateFrom"`
+	DateTo            string `json:"dateTo"`
+	RecipientEmail    string `json:"recipientEmail"`
+	ApplicantName     string `json:"applicantName"`
+}
+
 type SendEmailDpCharges struct {
 	ClientId          string `json:"clientId"`
 	EncodedReportFile string `json:"encodedReportFile"`
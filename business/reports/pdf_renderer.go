@@ -0,0 +1,305 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"space/constants"
+	"strings"
+)
+
+// A4 landscape in points, so that the wide tradebooks fit on one page width.
+const (
+	pdfPageWidth   = 842.0
+	pdfPageHeight  = 595.0
+	pdfMargin      = 28.0
+	pdfBandHeight  = 30.0
+	pdfFooterSpace = 20.0
+	pdfFontSize    = 7.0
+	pdfRowHeight   = 12.0
+	pdfCellPadding = 3.0
+	pdfMinColumn   = 24.0
+)
+
+// pdfRenderer writes a plain PDF 1.4 file using the standard Helvetica fonts,
+// so no font files or external libraries are needed. Every page carries the
+// brand band and a page footer, and tables repeat their header on each page
+// they continue on.
+type pdfRenderer struct{}
+
+func (pdfRenderer) Extension() string {
+	return constants.ReportFormatPdf
+}
+
+func (pdfRenderer) Render(doc ReportDocument) ([]byte, error) {
+	layout := &pdfLayout{doc: doc}
+	layout.newPage()
+	layout.clientHeader()
+	for _, section := range doc.Sections {
+		layout.section(section)
+	}
+	layout.footers()
+	return writePdf(doc.Title, layout.pages), nil
+}
+
+type pdfLayout struct {
+	doc   ReportDocument
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.page = &bytes.Buffer{}
+	l.pages = append(l.pages, l.page)
+
+	bandY := pdfPageHeight - pdfMargin - pdfBandHeight
+	l.fill(pdfMargin, bandY, pdfPageWidth-2*pdfMargin, pdfBandHeight, [3]float64{0.11, 0.27, 0.53})
+	l.text(pdfMargin+10, bandY+10, 14, true, [3]float64{1, 1, 1}, constants.ReportBrandName)
+	titleWidth := pdfTextWidth(l.doc.Title, 10, true)
+	l.text(pdfPageWidth-pdfMargin-10-titleWidth, bandY+11, 10, true, [3]float64{1, 1, 1}, l.doc.Title)
+
+	l.y = bandY - 16
+}
+
+// ensure starts a new page when height does not fit above the footer and
+// reports whether it did.
+func (l *pdfLayout) ensure(height float64) bool {
+	if l.y-height >= pdfMargin+pdfFooterSpace {
+		return false
+	}
+	l.newPage()
+	return true
+}
+
+func (l *pdfLayout) clientHeader() {
+	for _, detail := range userDetailRows(l.doc.UserDetails, l.doc.ReportName) {
+		l.text(pdfMargin, l.y, 8, true, [3]float64{}, detail[0])
+		l.text(pdfMargin+80, l.y, 8, false, [3]float64{}, detail[1])
+		l.y -= pdfRowHeight
+	}
+	l.y -= pdfRowHeight
+}
+
+func (l *pdfLayout) section(section ReportSection) {
+	widths := pdfColumnWidths(section)
+
+	// keep the title and header together with at least one row
+	needed := 2 * pdfRowHeight
+	if section.Title != "" {
+		needed += 16
+	}
+	l.ensure(needed)
+	if section.Title != "" {
+		l.text(pdfMargin, l.y, 9, true, [3]float64{0.11, 0.27, 0.53}, section.Title)
+		l.y -= 16
+	}
+
+	l.tableHeader(section.Headers, widths)
+	for i, values := range section.Rows {
+		if l.ensure(pdfRowHeight) {
+			l.tableHeader(section.Headers, widths)
+		}
+		if i%2 == 1 {
+			l.fill(pdfMargin, l.y-pdfRowHeight, sumWidths(widths), pdfRowHeight, [3]float64{0.96, 0.96, 0.96})
+		}
+		x := pdfMargin
+		for c, value := range values {
+			if c >= len(widths) {
+				break
+			}
+			cell := pdfFitText(formatReportCell(value), widths[c]-2*pdfCellPadding, pdfFontSize, false)
+			cellX := x + pdfCellPadding
+			if isNumericCell(value) {
+				cellX = x + widths[c] - pdfCellPadding - pdfTextWidth(cell, pdfFontSize, false)
+			}
+			l.text(cellX, l.y-pdfRowHeight+3.5, pdfFontSize, false, [3]float64{}, cell)
+			x += widths[c]
+		}
+		l.y -= pdfRowHeight
+	}
+	l.y -= pdfRowHeight
+}
+
+func (l *pdfLayout) tableHeader(headers []string, widths []float64) {
+	if len(headers) == 0 {
+		return
+	}
+	l.fill(pdfMargin, l.y-pdfRowHeight, sumWidths(widths), pdfRowHeight, [3]float64{0.85, 0.88, 0.93})
+	x := pdfMargin
+	for c, header := range headers {
+		cell := pdfFitText(header, widths[c]-2*pdfCellPadding, pdfFontSize, true)
+		l.text(x+pdfCellPadding, l.y-pdfRowHeight+3.5, pdfFontSize, true, [3]float64{}, cell)
+		x += widths[c]
+	}
+	l.y -= pdfRowHeight
+}
+
+// footers is run once the page count is known.
+func (l *pdfLayout) footers() {
+	grey := [3]float64{0.4, 0.4, 0.4}
+	left := constants.ReportBrandName + " | " + l.doc.ReportName + " | Client ID: " + l.doc.UserDetails.ClientID
+	for i, page := range l.pages {
+		l.page = page
+		fmt.Fprintf(page, "0.8 G 0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, pdfMargin+12, pdfPageWidth-pdfMargin, pdfMargin+12)
+		l.text(pdfMargin, pdfMargin+2, 7, false, grey, left)
+		pageNo := fmt.Sprintf("Page %d of %d", i+1, len(l.pages))
+		l.text(pdfPageWidth-pdfMargin-pdfTextWidth(pageNo, 7, false), pdfMargin+2, 7, false, grey, pageNo)
+	}
+}
+
+func (l *pdfLayout) fill(x, y, width, height float64, color [3]float64) {
+	fmt.Fprintf(l.page, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n", color[0], color[1], color[2], x, y, width, height)
+}
+
+func (l *pdfLayout) text(x, y, size float64, bold bool, color [3]float64, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(l.page, "BT /%s %.1f Tf %.3f %.3f %.3f rg %.2f %.2f Td (%s) Tj ET\n", font, size, color[0], color[1], color[2], x, y, pdfEscape(s))
+}
+
+// pdfColumnWidths sizes columns to their widest cell and shrinks them all in
+// proportion when the table is wider than the page.
+func pdfColumnWidths(section ReportSection) []float64 {
+	columns := len(section.Headers)
+	for _, values := range section.Rows {
+		if len(values) > columns {
+			columns = len(values)
+		}
+	}
+	widths := make([]float64, columns)
+	for c := range widths {
+		widths[c] = pdfMinColumn
+	}
+	for c, header := range section.Headers {
+		widths[c] = maxFloat(widths[c], pdfTextWidth(header, pdfFontSize, true)+2*pdfCellPadding)
+	}
+	for _, values := range section.Rows {
+		for c, value := range values {
+			widths[c] = maxFloat(widths[c], pdfTextWidth(formatReportCell(value), pdfFontSize, false)+2*pdfCellPadding)
+		}
+	}
+
+	available := pdfPageWidth - 2*pdfMargin
+	if total := sumWidths(widths); total > available {
+		for c := range widths {
+			widths[c] = widths[c] * available / total
+		}
+	}
+	return widths
+}
+
+// pdfTextWidth approximates Helvetica advance widths, close enough to align
+// numbers and decide when a cell has to be cut short.
+func pdfTextWidth(s string, size float64, bold bool) float64 {
+	var units float64
+	for _, r := range s {
+		switch {
+		case r == ' ' || r == '.' || r == ',' || r == ':' || r == 'i' || r == 'l' || r == 'j':
+			units += 278
+		case r >= '0' && r <= '9':
+			units += 556
+		case r == 'm' || r == 'w' || r == 'M' || r == 'W':
+			units += 833
+		case r >= 'A' && r <= 'Z':
+			units += 667
+		default:
+			units += 520
+		}
+	}
+	if bold {
+		units *= 1.06
+	}
+	return units * size / 1000
+}
+
+func pdfFitText(s string, width float64, size float64, bold bool) string {
+	if pdfTextWidth(s, size, bold) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdfTextWidth(string(runes)+"..", size, bold) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + ".."
+}
+
+// pdfEscape keeps text inside a PDF string literal. The standard fonts only
+// cover Latin-1, anything else is replaced.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r > 255:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
+
+func isNumericCell(value interface{}) bool {
+	switch value.(type) {
+	case float64, float32, int, int64:
+		return true
+	}
+	return false
+}
+
+func sumWidths(widths []float64) float64 {
+	var total float64
+	for _, width := range widths {
+		total += width
+	}
+	return total
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// writePdf assembles the page content streams into a PDF file with a cross
+// reference table.
+func writePdf(title string, pages []*bytes.Buffer) []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	const firstPage = 6
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (%s) >>", pdfEscape(title), constants.ReportBrandName))
+	for i, page := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
@@ -0,0 +1,145 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"space/constants"
+	"space/helpers"
+	"space/models"
+	"strconv"
+
+	"github.com/tealeg/xlsx/v3"
+)
+
+// ReportSection is one block of a report, either a table with headers or a
+// list of label/value rows when Headers is empty.
+type ReportSection struct {
+	Title   string
+	Headers []string
+	Rows    [][]interface{}
+}
+
+// ReportDocument is the data a report is built from. CSV and PDF are laid
+// out from Sections; XLSX keeps the report's own workbook layout.
+type ReportDocument struct {
+	Title       string
+	ReportName  string
+	UserDetails models.ProfileDataResp
+	Sections    []ReportSection
+	Workbook    func() (*xlsx.File, error)
+}
+
+// ReportRenderer writes a ReportDocument in one file format.
+type ReportRenderer interface {
+	Render(doc ReportDocument) ([]byte, error)
+	Extension() string
+}
+
+var reportRenderers = map[string]ReportRenderer{
+	constants.ReportFormatXlsx: xlsxRenderer{},
+	constants.ReportFormatCsv:  csvRenderer{},
+	constants.ReportFormatPdf:  pdfRenderer{},
+}
+
+// reportRenderer returns the renderer for a format, XLSX when none was asked for.
+func reportRenderer(format string) (ReportRenderer, error) {
+	if format == "" {
+		format = constants.ReportFormatXlsx
+	}
+	renderer, ok := reportRenderers[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported report format %q", format)
+	}
+	return renderer, nil
+}
+
+var CallUploadReport = helpers.UploadBytesToS3AndGetPresignedURL
+
+// uploadReport renders the document in the requested format and returns a
+// pre-signed link to it.
+func uploadReport(doc ReportDocument, format string, folderName string, fileName string) (string, error) {
+	renderer, err := reportRenderer(format)
+	if err != nil {
+		return "", err
+	}
+	body, err := renderer.Render(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s report: %v", renderer.Extension(), err)
+	}
+	return CallUploadReport(folderName, fileName+"."+renderer.Extension(), body, constants.ReportDownloadExpiryHours)
+}
+
+// userDetailRows is the client header every report format starts with.
+func userDetailRows(userDetails models.ProfileDataResp, reportName string) [][]string {
+	return [][]string{
+		{"Client Name", userDetails.Name},
+		{"PAN", userDetails.PanNumber},
+		{"Client ID", userDetails.ClientID},
+		{"Report", reportName},
+	}
+}
+
+// formatReportCell renders a cell value the same way in CSV and PDF.
+func formatReportCell(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+type xlsxRenderer struct{}
+
+func (xlsxRenderer) Extension() string {
+	return constants.ReportFormatXlsx
+}
+
+func (xlsxRenderer) Render(doc ReportDocument) ([]byte, error) {
+	file, err := doc.Workbook()
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	if err := file.Write(&buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+type csvRenderer struct{}
+
+func (csvRenderer) Extension() string {
+	return constants.ReportFormatCsv
+}
+
+func (csvRenderer) Render(doc ReportDocument) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+
+	records := userDetailRows(doc.UserDetails, doc.ReportName)
+	for _, section := range doc.Sections {
+		records = append(records, []string{})
+		if section.Title != "" {
+			records = append(records, []string{section.Title})
+		}
+		if len(section.Headers) > 0 {
+			records = append(records, section.Headers)
+		}
+		for _, values := range section.Rows {
+			record := make([]string, len(values))
+			for i, value := range values {
+				record[i] = formatReportCell(value)
+			}
+			records = append(records, record)
+		}
+	}
+
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,142 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"space/constants"
+	"space/models"
+
+	"github.com/tealeg/xlsx/v3"
+)
+
+func testLedger(entries int) models.FinancialLedgerRes {
+	ledger := models.FinancialLedgerRes{
+		UserDetails:    models.ProfileDataResp{Name: "Asha Rao", PanNumber: "ABCDE1234F", ClientID: "AB123"},
+		OpeningBalance: 1000,
+		Inflow:         2500.5,
+		ClosingBalance: 3500.5,
+	}
+	for i := 0; i < entries; i++ {
+		ledger.FinancialLedger = append(ledger.FinancialLedger, models.FinancialLedgerData{
+			TransactionDate:    "21-10-2026",
+			TransactionDetails: fmt.Sprintf("Funds received (UPI) #%d", i),
+			Segment:            "NSE",
+			Credit:             25.5,
+			NetBalance:         1025.5,
+		})
+	}
+	return ledger
+}
+
+func TestCsvRenderer(t *testing.T) {
+	body, err := csvRenderer{}.Render(ledgerDocument(testLedger(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Client Name,Asha Rao
+PAN,ABCDE1234F
+Client ID,AB123
+Report,Ledger Statement
+
+OpeningBalance,Inflow,Outflow,FundsReceived,FundsWithdrawn,ClosingBalance
+1000.00,2500.50,0.00,0.00,0.00,3500.50
+
+TransactionDate,TransactionDetails,Segment,Exchange,Debit,Credit,SettlementNumber,NetBalance,SettlementDate
+21-10-2026,Funds received (UPI) #0,NSE,,0.00,25.50,,1025.50,
+`
+	if string(body) != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", body, want)
+	}
+}
+
+func TestXlsxRenderer(t *testing.T) {
+	body, err := xlsxRenderer{}.Render(ledgerDocument(testLedger(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := xlsx.OpenBinary(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet := file.Sheets[0]
+	cell := func(row, col int) string {
+		c, err := sheet.Cell(row, col)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.Value
+	}
+	// the ledger keeps its own layout: summary first, no title or client rows
+	if sheet.Name != "Sheet1" || cell(0, 0) != "OpeningBalance" || cell(1, 1) != "2500.5" || cell(2, 0) != "TransactionDate" || cell(3, 1) != "Funds received (UPI) #0" {
+		t.Errorf("unexpected layout: %q %q %q %q %q", sheet.Name, cell(0, 0), cell(1, 1), cell(2, 0), cell(3, 1))
+	}
+}
+
+func TestPdfRenderer(t *testing.T) {
+	body, err := pdfRenderer{}.Render(ledgerDocument(testLedger(120)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf := string(body)
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatalf("not a PDF file")
+	}
+
+	pages, _ := strconv.Atoi(regexp.MustCompile(`/Count (\d+)`).FindStringSubmatch(pdf)[1])
+	if pages < 2 {
+		t.Fatalf("120 ledger entries on %d page", pages)
+	}
+	// every page is branded and numbered, and the entries table header repeats
+	for _, text := range []string{"(" + constants.ReportBrandName + ") Tj", "(TransactionDate) Tj"} {
+		if got := strings.Count(pdf, text); got != pages {
+			t.Errorf("%s on %d pages, want %d", text, got, pages)
+		}
+	}
+	if !strings.Contains(pdf, fmt.Sprintf("(Page %d of %d) Tj", pages, pages)) || !strings.Contains(pdf, "(ABCDE1234F) Tj") {
+		t.Errorf("missing page footer or client header")
+	}
+	if !strings.Contains(pdf, `(Funds received \(UPI\) #119) Tj`) {
+		t.Errorf("last entry missing or not escaped")
+	}
+
+	// the cross reference table has to point at each object
+	xref, _ := strconv.Atoi(regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(pdf)[1])
+	if !strings.HasPrefix(pdf[xref:], "xref\n") {
+		t.Fatalf("startxref points at %q", pdf[xref:xref+10])
+	}
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf[xref:], -1)
+	for i, offset := range offsets {
+		at, _ := strconv.Atoi(offset[1])
+		if !bytes.HasPrefix(body[at:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))) {
+			t.Errorf("object %d not at offset %d", i+1, at)
+		}
+	}
+	if len(offsets) != 5+2*pages {
+		t.Errorf("%d objects for %d pages", len(offsets), pages)
+	}
+}
+
+func TestUploadReport(t *testing.T) {
+	var uploaded string
+	upload := CallUploadReport
+	t.Cleanup(func() { CallUploadReport = upload })
+	CallUploadReport = func(folderName string, fileName string, body []byte, expiryHours int64) (string, error) {
+		uploaded = folderName + "/" + fileName
+		return "https://s3/" + uploaded, nil
+	}
+
+	for format, want := range map[string]string{"": "ledger/Ledger_Report_AB123.xlsx", "csv": "ledger/Ledger_Report_AB123.csv", "pdf": "ledger/Ledger_Report_AB123.pdf"} {
+		url, err := uploadReport(ledgerDocument(testLedger(1)), format, "ledger", "Ledger_Report_AB123")
+		if err != nil || uploaded != want || url != "https://s3/"+want {
+			t.Errorf("uploadReport(%q) = %q, %v uploaded %q", format, url, err, uploaded)
+		}
+	}
+	if _, err := uploadReport(ledgerDocument(testLedger(1)), "docx", "ledger", "Ledger_Report_AB123"); err == nil {
+		t.Errorf("docx accepted")
+	}
+}
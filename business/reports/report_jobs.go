@@ -0,0 +1,451 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/dbops"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var reportJobNow = helpers.GetCurrentTimeInIST
+
+// reportJobWake lets a submit start a job on this instance without waiting for
+// the next poll.
+var reportJobWake = make(chan struct{}, 1)
+
+// reportJobRunner generates one kind of report for a job through the same
+// provider methods the synchronous endpoints use.
+type reportJobRunner struct {
+	// dated reports take a DD-MM-YYYY range, the others are as of yesterday
+	dated    bool
+	download func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes)
+	// email publishes the report to the mailer, nil when it cannot be emailed
+	email func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes)
+}
+
+var reportJobRunners = map[string]reportJobRunner{
+	constants.ReportLedger: {
+		dated: true,
+		download: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.DownloadLedger(models.LedgerReq{UserID: job.ClientId, DFDateFr: job.DateFrom, DFDateTo: job.DateTo, Format: job.Format}, reqH, job.UserDetails)
+		},
+		email: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.SendEmailLedger(models.LedgerReq{UserID: job.ClientId, DFDateFr: job.DateFrom, DFDateTo: job.DateTo}, reqH, job.UserDetails)
+		},
+	},
+	constants.ReportTradebook: {
+		dated: true,
+		download: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.DownloadTradebook(models.TradebookReq{UserID: job.ClientId, DFDateFr: job.DateFrom, DFDateTo: job.DateTo, Format: job.Format}, reqH, job.UserDetails)
+		},
+	},
+	constants.ReportOpenPosition: {
+		download: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.DownloadOpenPosition(models.OpenPositionReq{UserID: job.ClientId, Dsflag: job.DsFlag, Format: job.Format}, reqH, job.UserDetails)
+		},
+	},
+	constants.ReportFnoPnl: {
+		dated: true,
+		download: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.DownloadFnoPnl(models.FnoPnlReq{UserID: job.ClientId, DFDateFr: job.DateFrom, DFDateTo: job.DateTo, Format: job.Format}, reqH, job.UserDetails)
+		},
+		email: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.SendEmailFnoPnl(models.FnoPnlReq{UserID: job.ClientId, DFDateFr: job.DateFrom, DFDateTo: job.DateTo}, reqH, job.UserDetails)
+		},
+	},
+	constants.ReportHoldingFinancial: {
+		download: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.DownloadHoldingFinancial(models.GetHoldingFinancialDataReq{UserID: job.ClientId, Format: job.Format}, reqH, job.UserDetails)
+		},
+		email: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.SendEmailHoldingFinancial(models.GetHoldingFinancialDataReq{UserID: job.ClientId}, reqH, job.UserDetails)
+		},
+	},
+	constants.ReportDPCharges: {
+		dated: true,
+		download: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.DownloadDPCharges(dpChargesJobReq(job), reqH, job.UserDetails)
+		},
+		email: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.SendEmailDPCharges(dpChargesJobReq(job), reqH, job.UserDetails)
+		},
+	},
+	constants.ReportCommodityTradebook: {
+		dated: true,
+		download: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.DownloadCommodityTradebook(models.CommodityTradebookReq{UserID: job.ClientId, DFDateFr: job.DateFrom, DFDateTo: job.DateTo, Format: job.Format}, reqH, job.UserDetails)
+		},
+		email: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.SendEmailCommodityTradebook(models.CommodityTradebookReq{UserID: job.ClientId, DFDateFr: job.DateFrom, DFDateTo: job.DateTo}, reqH, job.UserDetails)
+		},
+	},
+	constants.ReportFnoTradebook: {
+		dated: true,
+		download: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.DownloadFnoTradebook(models.FNOTradebookReq{UserID: job.ClientId, DFDateFr: job.DateFrom, DFDateTo: job.DateTo, Format: job.Format}, reqH, job.UserDetails)
+		},
+		email: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.SendEmailFnoTradebook(models.FNOTradebookReq{UserID: job.ClientId, DFDateFr: job.DateFrom, DFDateTo: job.DateTo}, reqH, job.UserDetails)
+		},
+	},
+}
+
+// dpChargesJobReq converts the job's range to the date format the DP charges
+// backoffice call expects, as the DP charges controllers do.
+func dpChargesJobReq(job models.MongoReportJob) models.DPChargesReq {
+	dateFrom, _ := helpers.ConvertDateFormat(job.DateFrom, constants.DDMMYYYY, constants.ShilpiDateFormat)
+	dateTo, _ := helpers.ConvertDateFormat(job.DateTo, constants.DDMMYYYY, constants.ShilpiDateFormat)
+	return models.DPChargesReq{UserID: job.ClientId, DFDateFr: dateFrom, DFDateTo: dateTo, Format: job.Format}
+}
+
+/*
+EnsureReportJobIndexes creates the indexes the report jobs rely on: dedup
+keys are unique among live jobs, so two instances taking the same submit
+cannot both store it, and jobs are removed by mongo's TTL monitor once they
+expire.
+*/
+func EnsureReportJobIndexes() error {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.REPORTJOBSCOLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "dedupKey", Value: 1}},
+			Options: options.Index().SetName("dedupKey_live").SetUnique(true).SetPartialFilterExpression(bson.M{"live": true}),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("expiresAt_ttl").SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
+// CallCreateReportJob stores job unless a live job with the same dedup key,
+// one pending, running or completed, has not expired, and returns whichever
+// job is stored along with whether it is the new one.
+var CallCreateReportJob = func(job models.MongoReportJob) (models.MongoReportJob, bool, error) {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.REPORTJOBSCOLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// an expired job the TTL monitor has not removed yet still holds the key
+	expired := bson.M{"dedupKey": job.DedupKey, "live": true, "expiresAt": bson.M{"$lte": job.CreatedAt}}
+	if _, err := coll.UpdateMany(ctx, expired, bson.M{"$set": bson.M{"live": false}}); err != nil {
+		return job, false, err
+	}
+
+	filter := bson.M{
+		"dedupKey":  job.DedupKey,
+		"live":      true,
+		"expiresAt": bson.M{"$gt": job.CreatedAt},
+	}
+	res, err := coll.UpdateOne(ctx, filter, bson.M{"$setOnInsert": job}, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// another instance stored the same job in between
+		res, err = &mongo.UpdateResult{}, nil
+	}
+	if err != nil {
+		return job, false, err
+	}
+	if res.UpsertedCount > 0 {
+		return job, true, nil
+	}
+
+	var existing models.MongoReportJob
+	err = coll.FindOne(ctx, filter).Decode(&existing)
+	return existing, false, err
+}
+
+var CallFetchReportJob = func(jobId string) (*models.MongoReportJob, error) {
+	var job models.MongoReportJob
+	err := dbops.MongoRepo.FindOne(constants.REPORTJOBSCOLLECTION, bson.M{"jobId": jobId}, &job)
+	if err != nil {
+		if err.Error() == constants.MongoNoDocError {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CallClaimReportJob marks the oldest runnable job as running on this worker.
+// Jobs left running by a worker that went away are picked up again once stale.
+var CallClaimReportJob = func(now time.Time) (*models.MongoReportJob, error) {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.REPORTJOBSCOLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"expiresAt": bson.M{"$gt": now},
+		"$or": []bson.M{
+			{"status": constants.ReportJobPending},
+			{"status": constants.ReportJobRunning, "claimedAt": bson.M{"$lt": now.Add(-constants.ReportJobStaleMins * time.Minute)}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": constants.ReportJobRunning, "progress": constants.ReportJobProgressStarted, "claimedAt": now, "updatedAt": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"createdAt": 1}).SetReturnDocument(options.After)
+
+	var job models.MongoReportJob
+	err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CallFinishReportJob records the outcome of a run, unless another worker has
+// claimed the job since.
+var CallFinishReportJob = func(job models.MongoReportJob) error {
+	filter := bson.M{"jobId": job.JobId, "status": constants.ReportJobRunning, "claimedAt": job.ClaimedAt}
+	update := bson.M{"$set": bson.M{
+		"status":      job.Status,
+		"live":        job.Live,
+		"progress":    job.Progress,
+		"downloadUrl": job.DownloadUrl,
+		"error":       job.Error,
+		"updatedAt":   job.UpdatedAt,
+	}}
+	return dbops.MongoRepo.UpdateOne(constants.REPORTJOBSCOLLECTION, filter, update)
+}
+
+// SubmitReportJob queues a report to be generated in the background. Asking
+// again for the same report, range, format and delivery while the earlier job
+// is queued, running or done returns that job instead of starting another.
+func (obj ReportsObj) SubmitReportJob(req models.SubmitReportJobReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	runner := reportJobRunners[req.Report]
+	if req.Format == "" {
+		req.Format = constants.ReportFormatXlsx
+	}
+	if req.Delivery == "" {
+		req.Delivery = constants.ReportJobDeliveryDownload
+	}
+	// the mailer attaches the workbook the SendEmail* reports produce
+	if req.Delivery == constants.ReportJobDeliveryEmail && (runner.email == nil || req.Format != constants.ReportFormatXlsx) {
+		loggerconfig.Info("SubmitReportJob, unsupported delivery ", req.Delivery, " for report:", req.Report, " format:", req.Format, " clientId:", req.UserID, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.ReportDeliveryUnsupported, http.StatusBadRequest)
+	}
+
+	now := reportJobNow()
+	dateFrom, dateTo := req.DFDateFr, req.DFDateTo
+	if runner.dated {
+		if !helpers.ValidateDateQueryParam(dateFrom, constants.DDMMYYYY) || !helpers.ValidateDateQueryParam(dateTo, constants.DDMMYYYY) || !helpers.ValidateDateRange(dateFrom, dateTo, constants.DDMMYYYY) {
+			return apihelpers.SendErrorResponse(false, constants.InvalidDate, http.StatusBadRequest)
+		}
+	} else {
+		// holdings and open positions are reported as of the previous day
+		dateFrom = now.AddDate(0, 0, -1).Format(constants.DDMMYYYY)
+		dateTo = dateFrom
+	}
+	if req.Report == constants.ReportOpenPosition && req.DsFlag == "" {
+		return apihelpers.SendErrorResponse(false, constants.InvalidRequest, http.StatusBadRequest)
+	}
+
+	job := models.MongoReportJob{
+		JobId:       uuid.New().String(),
+		DedupKey:    strings.Join([]string{strings.ToUpper(req.UserID), req.Report, dateFrom, dateTo, req.DsFlag, req.Format, req.Delivery}, "|"),
+		ClientId:    req.UserID,
+		Report:      req.Report,
+		DateFrom:    dateFrom,
+		DateTo:      dateTo,
+		DsFlag:      req.DsFlag,
+		Format:      req.Format,
+		Delivery:    req.Delivery,
+		UserDetails: profileData,
+		Platform:    reqH.Platform,
+		DeviceType:  reqH.DeviceType,
+		DeviceId:    reqH.DeviceId,
+		Status:      constants.ReportJobPending,
+		Live:        true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(constants.ReportJobExpiryHours * time.Hour),
+	}
+	stored, created, err := CallCreateReportJob(job)
+	if err != nil {
+		loggerconfig.Error("SubmitReportJob, mongo error:", err, " report:", req.Report, " clientId:", req.UserID, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	if created {
+		select {
+		case reportJobWake <- struct{}{}:
+		default:
+		}
+	}
+
+	loggerconfig.Info("SubmitReportJob, jobId:", stored.JobId, " created:", created, " status:", stored.Status, " report:", req.Report, " clientId:", req.UserID, " requestId:", reqH.RequestId)
+	apiRes.Data = models.SubmitReportJobRes{JobId: stored.JobId, Status: stored.Status, Deduplicated: !created, ExpiresAt: stored.ExpiresAt.Unix()}
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// FetchReportJobStatus returns a job's progress and, once it has completed,
+// the link to the generated report.
+func (obj ReportsObj) FetchReportJobStatus(req models.ReportJobStatusReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	job, err := CallFetchReportJob(req.JobId)
+	if err != nil {
+		loggerconfig.Error("FetchReportJobStatus, mongo error:", err, " jobId:", req.JobId, " clientId:", req.UserID, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	// the TTL monitor removes expired jobs up to a minute late
+	if job == nil || !strings.EqualFold(job.ClientId, req.UserID) || !job.ExpiresAt.After(reportJobNow()) {
+		return apihelpers.SendErrorResponse(false, constants.ReportJobNotFound, http.StatusBadRequest)
+	}
+
+	apiRes.Data = models.ReportJobStatusRes{
+		JobId:       job.JobId,
+		Report:      job.Report,
+		Format:      job.Format,
+		Delivery:    job.Delivery,
+		Status:      job.Status,
+		Progress:    job.Progress,
+		DownloadUrl: job.DownloadUrl,
+		Error:       job.Error,
+		CreatedAt:   job.CreatedAt.Unix(),
+		UpdatedAt:   job.UpdatedAt.Unix(),
+		ExpiresAt:   job.ExpiresAt.Unix(),
+	}
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// RunReportJobs starts the workers that generate submitted reports. Every
+// instance runs them; jobs are claimed in Mongo so each is generated once.
+func RunReportJobs(obj ReportsObj) {
+	defer models.HandlePanic()
+
+	for i := 0; i < constants.ReportJobWorkers; i++ {
+		go obj.reportJobWorker()
+	}
+}
+
+func (obj ReportsObj) reportJobWorker() {
+	defer models.HandlePanic()
+
+	ticker := time.NewTicker(constants.ReportJobPollSeconds * time.Second)
+	defer ticker.Stop()
+	for {
+		for obj.runNextReportJob() {
+		}
+		select {
+		case <-ticker.C:
+		case <-reportJobWake:
+		}
+	}
+}
+
+// runNextReportJob runs one job if there is one waiting and reports whether it did.
+func (obj ReportsObj) runNextReportJob() bool {
+	defer models.HandlePanic()
+
+	job, err := CallClaimReportJob(reportJobNow())
+	if err != nil {
+		loggerconfig.Error("runNextReportJob, mongo error claiming job:", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+	obj.runReportJob(*job)
+	return true
+}
+
+func (obj ReportsObj) runReportJob(job models.MongoReportJob) {
+	reqH := models.ReqHeader{
+		ClientId:   job.ClientId,
+		RequestId:  job.JobId,
+		Platform:   job.Platform,
+		DeviceType: job.DeviceType,
+		DeviceId:   job.DeviceId,
+	}
+
+	if job.Attempts > constants.ReportJobMaxAttempts {
+		loggerconfig.Error("Alert Severity:P2-Mid, runReportJob giving up after ", job.Attempts-1, " attempts, jobId:", job.JobId, " report:", job.Report, " clientId:", job.ClientId)
+		obj.finishReportJob(job, "", "report generation did not finish")
+		return
+	}
+
+	runner, ok := reportJobRunners[job.Report]
+	if !ok {
+		obj.finishReportJob(job, "", "unknown report")
+		return
+	}
+
+	var code int
+	var res apihelpers.APIRes
+	if job.Delivery == constants.ReportJobDeliveryEmail {
+		code, res = runner.email(obj, job, reqH)
+	} else {
+		code, res = runner.download(obj, job, reqH)
+	}
+	if code != http.StatusOK {
+		loggerconfig.Error("runReportJob, report failed with code:", code, " message:", res.Message, " jobId:", job.JobId, " report:", job.Report, " clientId:", job.ClientId)
+		obj.finishReportJob(job, "", "report could not be generated, please try again")
+		return
+	}
+
+	loggerconfig.Info("runReportJob, completed jobId:", job.JobId, " report:", job.Report, " delivery:", job.Delivery, " clientId:", job.ClientId)
+	obj.finishReportJob(job, reportDownloadUrl(res.Data), "")
+}
+
+func (obj ReportsObj) finishReportJob(job models.MongoReportJob, downloadUrl string, failure string) {
+	job.Status = constants.ReportJobCompleted
+	job.Progress = constants.ReportJobProgressDone
+	job.DownloadUrl = downloadUrl
+	job.Error = failure
+	job.Live = failure == ""
+	if failure != "" {
+		job.Status = constants.ReportJobFailed
+	}
+	job.UpdatedAt = reportJobNow()
+	if err := CallFinishReportJob(job); err != nil {
+		loggerconfig.Error("finishReportJob, mongo error:", err, " jobId:", job.JobId, " status:", job.Status)
+	}
+}
+
+func reportDownloadUrl(data interface{}) string {
+	switch res := data.(type) {
+	case models.DownloadLedgerRes:
+		return res.DownloadUrl
+	case models.DownloadTradebookRes:
+		return res.DownloadUrl
+	case models.DownloadOpenPositionRes:
+		return res.DownloadUrl
+	case models.DownloadFnoPnlRes:
+		return res.DownloadUrl
+	case models.DownloadHoldingFinancialRes:
+		return res.DownloadUrl
+	case models.DownloadDPChargesRes:
+		return res.DownloadUrl
+	case models.DownloadCommodityTradebookRes:
+		return res.DownloadUrl
+	case models.DownloadFnoTradebookRes:
+		return res.DownloadUrl
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package reports
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+)
+
+func TestReportJobs(t *testing.T) {
+	origCreate, origFetch, origClaim, origFinish := CallCreateReportJob, CallFetchReportJob, CallClaimReportJob, CallFinishReportJob
+	origNow, origRunner := reportJobNow, reportJobRunners[constants.ReportTradebook]
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		CallCreateReportJob, CallFetchReportJob, CallClaimReportJob, CallFinishReportJob = origCreate, origFetch, origClaim, origFinish
+		reportJobNow, reportJobRunners[constants.ReportTradebook] = origNow, origRunner
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+
+	// jobs are kept in memory with the same dedup and claim rules as the
+	// Mongo queries
+	var jobs []*models.MongoReportJob
+	CallCreateReportJob = func(job models.MongoReportJob) (models.MongoReportJob, bool, error) {
+		for _, stored := range jobs {
+			if stored.DedupKey == job.DedupKey && stored.Live && stored.ExpiresAt.After(job.CreatedAt) {
+				return *stored, false, nil
+			}
+		}
+		jobs = append(jobs, &job)
+		return job, true, nil
+	}
+	CallFetchReportJob = func(jobId string) (*models.MongoReportJob, error) {
+		for _, stored := range jobs {
+			if stored.JobId == jobId {
+				job := *stored
+				return &job, nil
+			}
+		}
+		return nil, nil
+	}
+	CallClaimReportJob = func(now time.Time) (*models.MongoReportJob, error) {
+		for _, stored := range jobs {
+			stale := stored.Status == constants.ReportJobRunning && stored.ClaimedAt.Before(now.Add(-constants.ReportJobStaleMins*time.Minute))
+			if stored.ExpiresAt.After(now) && (stored.Status == constants.ReportJobPending || stale) {
+				stored.Status, stored.Progress, stored.ClaimedAt = constants.ReportJobRunning, constants.ReportJobProgressStarted, now
+				stored.Attempts++
+				job := *stored
+				return &job, nil
+			}
+		}
+		return nil, nil
+	}
+	CallFinishReportJob = func(job models.MongoReportJob) error {
+		for _, stored := range jobs {
+			if stored.JobId == job.JobId && stored.ClaimedAt.Equal(job.ClaimedAt) {
+				*stored = job
+			}
+		}
+		return nil
+	}
+
+	now := time.Date(2026, 10, 21, 11, 0, 0, 0, constants.LocationKolkata)
+	reset := func() {
+		jobs = nil
+		reportJobNow = func() time.Time { return now }
+	}
+
+	t.Run("submit", func(t *testing.T) {
+		reset()
+		obj := ReportsObj{}
+		reqH := models.ReqHeader{ClientId: "AB123", DeviceType: "android"}
+
+		req := models.SubmitReportJobReq{UserID: "AB123", Report: constants.ReportLedger, DFDateFr: "01-04-2026", DFDateTo: "30-09-2026"}
+		code, res := obj.SubmitReportJob(req, reqH, models.ProfileDataResp{ClientID: "AB123"})
+		if code != http.StatusOK {
+			t.Fatalf("SubmitReportJob() = %d, %+v", code, res)
+		}
+		first := res.Data.(models.SubmitReportJobRes)
+		if first.Deduplicated || first.Status != constants.ReportJobPending || first.ExpiresAt != now.Add(constants.ReportJobExpiryHours*time.Hour).Unix() {
+			t.Errorf("first job = %+v", first)
+		}
+		if job := jobs[0]; job.Format != constants.ReportFormatXlsx || job.Delivery != constants.ReportJobDeliveryDownload {
+			t.Errorf("defaults = %s %s", job.Format, job.Delivery)
+		}
+
+		// the same report and range is served by the job already queued
+		_, res = obj.SubmitReportJob(req, reqH, models.ProfileDataResp{})
+		if again := res.Data.(models.SubmitReportJobRes); !again.Deduplicated || again.JobId != first.JobId {
+			t.Errorf("repeat = %+v, want job %s", again, first.JobId)
+		}
+		req.Format = constants.ReportFormatPdf
+		if _, res = obj.SubmitReportJob(req, reqH, models.ProfileDataResp{}); res.Data.(models.SubmitReportJobRes).Deduplicated {
+			t.Errorf("pdf deduplicated against xlsx")
+		}
+
+		for name, bad := range map[string]models.SubmitReportJobReq{
+			"tradebook by email": {UserID: "AB123", Report: constants.ReportTradebook, DFDateFr: "01-04-2026", DFDateTo: "30-09-2026", Delivery: constants.ReportJobDeliveryEmail},
+			"pdf by email":       {UserID: "AB123", Report: constants.ReportLedger, DFDateFr: "01-04-2026", DFDateTo: "30-09-2026", Delivery: constants.ReportJobDeliveryEmail, Format: constants.ReportFormatPdf},
+		} {
+			if code, res := obj.SubmitReportJob(bad, reqH, models.ProfileDataResp{}); code != http.StatusBadRequest || res.ErrorCode != constants.ReportDeliveryUnsupported {
+				t.Errorf("%s = %d, %+v", name, code, res)
+			}
+		}
+		if code, res := obj.SubmitReportJob(models.SubmitReportJobReq{UserID: "AB123", Report: constants.ReportFnoPnl, DFDateFr: "30-09-2026", DFDateTo: "01-04-2026"}, reqH, models.ProfileDataResp{}); code != http.StatusBadRequest || res.ErrorCode != constants.InvalidDate {
+			t.Errorf("reversed range = %d, %+v", code, res)
+		}
+
+		// holdings are as of yesterday, so they dedup for the day without a range
+		code, res = obj.SubmitReportJob(models.SubmitReportJobReq{UserID: "AB123", Report: constants.ReportHoldingFinancial}, reqH, models.ProfileDataResp{})
+		holding := jobs[len(jobs)-1]
+		if code != http.StatusOK || holding.DateFrom != "20-10-2026" || holding.DateTo != "20-10-2026" {
+			t.Errorf("holding job = %d, %+v", code, holding)
+		}
+
+		if code, res := obj.FetchReportJobStatus(models.ReportJobStatusReq{UserID: "ZZ999", JobId: first.JobId}, reqH); code != http.StatusBadRequest || res.ErrorCode != constants.ReportJobNotFound {
+			t.Errorf("another client's job = %d, %+v", code, res)
+		}
+		reportJobNow = func() time.Time { return now.Add(constants.ReportJobExpiryHours * time.Hour) }
+		if code, res := obj.FetchReportJobStatus(models.ReportJobStatusReq{UserID: "AB123", JobId: first.JobId}, reqH); code != http.StatusBadRequest || res.ErrorCode != constants.ReportJobNotFound {
+			t.Errorf("expired job = %d, %+v", code, res)
+		}
+	})
+
+	t.Run("run", func(t *testing.T) {
+		reset()
+		obj := ReportsObj{}
+		reqH := models.ReqHeader{ClientId: "AB123", DeviceType: "android"}
+
+		var ran []models.ReqHeader
+		fail := false
+		reportJobRunners[constants.ReportTradebook] = reportJobRunner{
+			dated: true,
+			download: func(obj ReportsObj, job models.MongoReportJob, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+				ran = append(ran, reqH)
+				if fail {
+					return http.StatusInternalServerError, apihelpers.APIRes{Message: "backoffice down"}
+				}
+				return http.StatusOK, apihelpers.APIRes{Status: true, Data: models.DownloadTradebookRes{DownloadUrl: "https://s3/tradebook.pdf"}}
+			},
+		}
+
+		_, res := obj.SubmitReportJob(models.SubmitReportJobReq{UserID: "AB123", Report: constants.ReportTradebook, DFDateFr: "01-04-2026", DFDateTo: "30-09-2026", Format: constants.ReportFormatPdf}, reqH, models.ProfileDataResp{})
+		jobId := res.Data.(models.SubmitReportJobRes).JobId
+
+		if !obj.runNextReportJob() || obj.runNextReportJob() {
+			t.Fatalf("expected exactly one job to run")
+		}
+		if len(ran) != 1 || ran[0].RequestId != jobId || ran[0].ClientId != "AB123" || ran[0].DeviceType != "android" {
+			t.Errorf("runner headers = %+v", ran)
+		}
+		code, res := obj.FetchReportJobStatus(models.ReportJobStatusReq{UserID: "AB123", JobId: jobId}, reqH)
+		status := res.Data.(models.ReportJobStatusRes)
+		if code != http.StatusOK || status.Status != constants.ReportJobCompleted || status.Progress != constants.ReportJobProgressDone || status.DownloadUrl != "https://s3/tradebook.pdf" {
+			t.Errorf("completed status = %d, %+v", code, status)
+		}
+
+		// a failed report is not deduplicated against, the next submit retries it
+		fail = true
+		_, res = obj.SubmitReportJob(models.SubmitReportJobReq{UserID: "AB123", Report: constants.ReportTradebook, DFDateFr: "01-04-2026", DFDateTo: "31-03-2027"}, reqH, models.ProfileDataResp{})
+		failedId := res.Data.(models.SubmitReportJobRes).JobId
+		obj.runNextReportJob()
+		_, res = obj.FetchReportJobStatus(models.ReportJobStatusReq{UserID: "AB123", JobId: failedId}, reqH)
+		if status := res.Data.(models.ReportJobStatusRes); status.Status != constants.ReportJobFailed || status.Error == "" || status.DownloadUrl != "" {
+			t.Errorf("failed status = %+v", status)
+		}
+		_, res = obj.SubmitReportJob(models.SubmitReportJobReq{UserID: "AB123", Report: constants.ReportTradebook, DFDateFr: "01-04-2026", DFDateTo: "31-03-2027"}, reqH, models.ProfileDataResp{})
+		if retry := res.Data.(models.SubmitReportJobRes); retry.Deduplicated || retry.JobId == failedId {
+			t.Errorf("retry after failure = %+v", retry)
+		}
+
+		// a job whose worker went away is reclaimed once stale, and given up after
+		// the last attempt
+		stuck := jobs[len(jobs)-1]
+		stuck.Status, stuck.Attempts, stuck.ClaimedAt = constants.ReportJobRunning, constants.ReportJobMaxAttempts, now
+		reportJobNow = func() time.Time { return now.Add((constants.ReportJobStaleMins + 1) * time.Minute) }
+		ran = nil
+		if !obj.runNextReportJob() || len(ran) != 0 || stuck.Status != constants.ReportJobFailed {
+			t.Errorf("stuck job = %+v, ran %d", stuck, len(ran))
+		}
+	})
+}
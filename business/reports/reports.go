@@ -31,6 +31,8 @@ func InitReportsProvider(redisCli cache.RedisCache) ReportsObj {
 	return ReportsObj
 }
 
+var CallPublishReportEmail = helpers.PublishMessage
+
 func (obj ReportsObj) ViewDPCharges(viewDPchargesReq models.DPChargesReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
 	var viewDPchargesRes models.ViewDPchargesRes
 	var err error
@@ -208,6 +210,33 @@ func writeDPChargesData(sheet *xlsx.Sheet, dpChargesList []models.DPcharges) {
 	}
 }
 
+// dpChargesDocument lays out the DP charges summary followed by each charge
+func dpChargesDocument(data models.ViewDPchargesRes) ReportDocument {
+	summary := ReportSection{
+		Headers: []string{"DP Charges Summary", "Amount"},
+		Rows: [][]interface{}{
+			{"Charges", data.Charges},
+			{"GST", data.GST},
+			{"Total Charges", data.TotalCharges},
+		},
+	}
+
+	charges := ReportSection{Headers: []string{"Instrument Name", "Charges Details", "Quantity", "Charges", "GST", "Total Charges"}}
+	for _, dpCharge := range data.DPChargesList {
+		charges.Rows = append(charges.Rows, []interface{}{
+			dpCharge.InstrumentName, dpCharge.ChargesDetails, dpCharge.Qty, dpCharge.Charges, dpCharge.Gst, dpCharge.TotalCharges,
+		})
+	}
+
+	return ReportDocument{
+		Title:       "DP Charges",
+		Workbook:    func() (*xlsx.File, error) { return CreateExcelDPChargesReport(data) },
+		ReportName:  constants.DPChargesReportName,
+		UserDetails: data.UserDetails,
+		Sections:    []ReportSection{summary, charges},
+	}
+}
+
 func (obj ReportsObj) DownloadDPCharges(downloadDPChargesReq models.DPChargesReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
 	var viewDPchargesRes models.ViewDPchargesRes
 	var err error
@@ -282,19 +311,10 @@ func (obj ReportsObj) DownloadDPCharges(downloadDPChargesReq models.DPChargesReq
 		}
 	}
 
-	file, err := CreateExcelDPChargesReport(viewDPchargesRes)
-	if err != nil {
-		loggerconfig.Error("DownloadDPCharges, Error in getting excel file, error: ", err, "reqId: ", reqH.RequestId)
-		return apihelpers.SendInternalServerError()
-	}
-
-	s3FileName := fileName + ".xlsx"
-	expiryHours := int64(24)
-
-	// Generate a pre-signed URL for the XLSX file
-	url, err := helpers.UploadFileToS3AndGetPresignedURL(constants.DpChargesS3FolderName, s3FileName, file, expiryHours)
+	// Generate a pre-signed URL for the report in the requested format
+	url, err := uploadReport(dpChargesDocument(viewDPchargesRes), downloadDPChargesReq.Format, constants.DpChargesS3FolderName, fileName)
 	if err != nil {
-		loggerconfig.Error("DownloadDPCharges, failed to generate pre-signed URL:", err, "reqId: ", reqH.RequestId)
+		loggerconfig.Error("DownloadDPCharges, failed to generate pre-signed URL:", err, " format: ", downloadDPChargesReq.Format, " reqId: ", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
 
@@ -403,7 +423,11 @@ func (obj ReportsObj) SendEmailDPCharges(sendEmailDPChargesReq models.DPChargesR
 	sendEmailDpChargesDetails.DateTo = sendEmailDPChargesReq.DFDateTo
 	sendEmailDpChargesDetails.EncodedReportFile = encodedString
 
-	helpers.PublishMessage(constants.TopicExchange, constants.KeyDpChargesReport, sendEmailDpChargesDetails)
+	err = CallPublishReportEmail(constants.TopicExchange, constants.KeyDpChargesReport, sendEmailDpChargesDetails)
+	if err != nil {
+		loggerconfig.Error("SendEmailDPCharges, failed to publish report email, error:", err, " reqId: ", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
 
 	var apiRes apihelpers.APIRes
 	apiRes.Message = "SUCCESS"
@@ -518,6 +542,31 @@ func writeScripWiseCosting(sheet *xlsx.Sheet, row, col int, data models.ScripWis
 	}
 }
 
+// tradebookDocument lays out the equity charges totals followed by each trade
+func tradebookDocument(data models.ScripWiseCostingRes) ReportDocument {
+	summary := ReportSection{
+		Headers: []string{"TotalBrokerage", "TotalGST", "TotalSEBITax", "TotalSTT", "TotalTurnCharges", "TotalStampDuty", "TotalOtherCharges", "TotalCharges"},
+		Rows: [][]interface{}{
+			{data.TotalBrokerage, data.TotalGST, data.TotalSEBITax, data.TotalSTT, data.TotalTurnCharges, data.TotalStampDuty, data.TotalOtherCharges, data.TotalCharges},
+		},
+	}
+
+	trades := ReportSection{Headers: []string{"Brokerage", "OrderNo", "OtherCharges", "GST", "Stamp", "Price", "TrxDate", "ISINCode", "SEBIFee", "STT", "TurnTax", "Exchange", "BrokType", "ScripName", "BuySellType", "Quantity"}}
+	for _, scripData := range data.ScripWiseCosting {
+		trades.Rows = append(trades.Rows, []interface{}{
+			scripData.Brokerage, scripData.OrderNo, scripData.OtherCharges, scripData.GST, scripData.Stamp, scripData.Price, scripData.TrxDate, scripData.ISINCode, scripData.SEBIFee, scripData.STT, scripData.TurnTax, scripData.Exchange, scripData.BrokType, scripData.ScripName, scripData.BuySellType, scripData.Quantity,
+		})
+	}
+
+	return ReportDocument{
+		Title:       "Tradebook",
+		Workbook:    func() (*xlsx.File, error) { return makeExcelScripWiseData(data) },
+		ReportName:  constants.TradebookReportName,
+		UserDetails: data.UserDetails,
+		Sections:    []ReportSection{summary, trades},
+	}
+}
+
 func (obj ReportsObj) DownloadTradebook(tradebookReq models.TradebookReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
 	var scripWiseCosting models.ScripWiseCostingRes
 	var err error
@@ -550,19 +599,10 @@ func (obj ReportsObj) DownloadTradebook(tradebookReq models.TradebookReq, reqH m
 		}
 	}
 
-	file, err := makeExcelScripWiseData(scripWiseCosting)
+	// Generate a pre-signed URL for the report in the requested format
+	url, err := uploadReport(tradebookDocument(scripWiseCosting), tradebookReq.Format, constants.TradebookS3FolderName, fileName)
 	if err != nil {
-		loggerconfig.Error("DownloadTradebook, Error in getting excel file, error: ", err, " reqId: ", reqH.RequestId)
-		return apihelpers.SendInternalServerError()
-	}
-
-	s3FileName := fileName + ".xlsx"
-	expiryHours := int64(24)
-
-	// Generate a pre-signed URL for the XLSX file
-	url, err := helpers.UploadFileToS3AndGetPresignedURL(constants.TradebookS3FolderName, s3FileName, file, expiryHours)
-	if err != nil {
-		loggerconfig.Error("DownloadTradebook, failed to generate pre-signed URL:", err, " reqId: ", reqH.RequestId)
+		loggerconfig.Error("DownloadTradebook, failed to generate pre-signed URL:", err, " format: ", tradebookReq.Format, " reqId: ", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
 
@@ -686,6 +726,31 @@ func writeFinancialLedgerData(sheet *xlsx.Sheet, row, col int, data models.Finan
 	}
 }
 
+// ledgerDocument lays out the balances followed by each ledger entry
+func ledgerDocument(data models.FinancialLedgerRes) ReportDocument {
+	summary := ReportSection{
+		Headers: []string{"OpeningBalance", "Inflow", "Outflow", "FundsReceived", "FundsWithdrawn", "ClosingBalance"},
+		Rows: [][]interface{}{
+			{data.OpeningBalance, data.Inflow, data.Outflow, data.FundsReceived, data.FundsWithdrawn, data.ClosingBalance},
+		},
+	}
+
+	entries := ReportSection{Headers: []string{"TransactionDate", "TransactionDetails", "Segment", "Exchange", "Debit", "Credit", "SettlementNumber", "NetBalance", "SettlementDate"}}
+	for _, ledgerData := range data.FinancialLedger {
+		entries.Rows = append(entries.Rows, []interface{}{
+			ledgerData.TransactionDate, ledgerData.TransactionDetails, ledgerData.Segment, ledgerData.Exchange, ledgerData.Debit, ledgerData.Credit, ledgerData.SettlementNumber, ledgerData.NetBalance, ledgerData.SettlementDate,
+		})
+	}
+
+	return ReportDocument{
+		Title:       "Ledger",
+		Workbook:    func() (*xlsx.File, error) { return makeExcelFinancialLedgerData(data) },
+		ReportName:  constants.LedgerReportName,
+		UserDetails: data.UserDetails,
+		Sections:    []ReportSection{summary, entries},
+	}
+}
+
 func (obj ReportsObj) DownloadLedger(ledgerReq models.LedgerReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
 
 	var financialLedgerData models.FinancialLedgerRes
@@ -724,18 +789,10 @@ func (obj ReportsObj) DownloadLedger(ledgerReq models.LedgerReq, reqH models.Req
 		}
 	}
 
-	file, err := makeExcelFinancialLedgerData(financialLedgerData)
-	if err != nil {
-		loggerconfig.Error("DownloadLedger, Error in getting excel file, error: ", err, " reqId: ", reqH.RequestId)
-		return apihelpers.SendInternalServerError()
-	}
-
-	s3FileName := fileName + ".xlsx"
-	expiryHours := int64(24)
-	// Generate a pre-signed URL for the XLSX file
-	url, err := helpers.UploadFileToS3AndGetPresignedURL(constants.LedgerS3FolderName, s3FileName, file, expiryHours)
+	// Generate a pre-signed URL for the report in the requested format
+	url, err := uploadReport(ledgerDocument(financialLedgerData), ledgerReq.Format, constants.LedgerS3FolderName, fileName)
 	if err != nil {
-		loggerconfig.Error("DownloadLedger, failed to generate pre-signed URL:", err, " reqId: ", reqH.RequestId)
+		loggerconfig.Error("DownloadLedger, failed to generate pre-signed URL:", err, " format: ", ledgerReq.Format, " reqId: ", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
 
@@ -840,6 +897,49 @@ func addDerivativeSection(sheet *xlsx.Sheet, heading string, positions []models.
 	}
 }
 
+// openPositionDocument lays out the MTM summary followed by the open positions
+// of each derivative segment
+func openPositionDocument(data models.OpenPositionRes) ReportDocument {
+	summary := ReportSection{
+		Title:   "Summary",
+		Headers: []string{"Field", "Value"},
+		Rows: [][]interface{}{
+			{"EquityFutureMTM", data.EquityFutureMTM},
+			{"CurrencyFutureMTM", data.CurrencyFutureMTM},
+			{"CommodityFutureMTM", data.CommodityFutureMTM},
+			{"EquityOptionMTM", data.EquityOptionMTM},
+			{"CurrencyOptionMTM", data.CurrencyOptionMTM},
+			{"CommodityOptionMTM", data.CommodityOptionMTM},
+		},
+	}
+
+	return ReportDocument{
+		Title:       "Open Positions",
+		Workbook:    func() (*xlsx.File, error) { return makeExcelOpenPositionData("output.xlsx", data) },
+		ReportName:  constants.OpenPositionReportName,
+		UserDetails: data.UserDetails,
+		Sections: []ReportSection{
+			summary,
+			derivativeSection("Equity", data.EquityDerivative),
+			derivativeSection("Currency", data.CurrencyDerivative),
+			derivativeSection("Commodity", data.CommodityDerivative),
+		},
+	}
+}
+
+func derivativeSection(heading string, positions []models.OpenPositionData) ReportSection {
+	section := ReportSection{
+		Title:   "Open Position for " + heading + " Derivative",
+		Headers: []string{"InstrumentType", "OptionType", "BuySellType", "StrikePrice", "ExpiryDate", "Exchange", "OpenQuantity", "AveragePrice", "ClosingPrice", "UnrealisedProfitOrLoss"},
+	}
+	for _, position := range positions {
+		section.Rows = append(section.Rows, []interface{}{
+			position.InstrumentType, position.OptionType, position.BuySellType, position.StrikePrice, position.ExpiryDate, position.Exchange, position.OpenQuantity, position.AveragePrice, position.ClosingPrice, position.UnrealisedProfitOrLoss,
+		})
+	}
+	return section
+}
+
 func (obj ReportsObj) ViewOpenPosition(openPositionReq models.OpenPositionReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
 
 	var OpenPositionData models.OpenPositionRes
@@ -916,21 +1016,13 @@ func (obj ReportsObj) DownloadOpenPosition(openPositionReq models.OpenPositionRe
 		}
 	}
 
-	file, err := makeExcelOpenPositionData("output.xlsx", OpenPositionData)
+	// Generate a pre-signed URL for the report in the requested format
+	url, err := uploadReport(openPositionDocument(OpenPositionData), openPositionReq.Format, constants.OpenPositionS3FolderName, fileName)
 	if err != nil {
-		loggerconfig.Error("DownloadOpenPosition, Error creating Excel file:", err, " reqId: ", reqH.RequestId)
+		loggerconfig.Error("DownloadOpenPosition, failed to generate pre-signed URL:", err, " format: ", openPositionReq.Format, " reqId: ", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
 
-	s3FileName := fileName + ".xlsx"
-	expiryHours := int64(24)
-
-	// Generate a pre-signed URL for the XLSX file
-	url, err := helpers.UploadFileToS3AndGetPresignedURL(constants.OpenPositionS3FolderName, s3FileName, file, expiryHours)
-	if err != nil {
-		loggerconfig.Error("DownloadOpenPosition, failed to generate pre-signed URL:", err, " reqId: ", reqH.RequestId)
-		return apihelpers.SendInternalServerError()
-	}
 	var downloadOpenPositionRes models.DownloadOpenPositionRes
 	downloadOpenPositionRes.DownloadUrl = url
 
@@ -1072,6 +1164,57 @@ func addFONetPositionDetailsRow(sheet *xlsx.Sheet, data models.FONetPositionDeta
 	row.AddCell().SetString(fmt.Sprintf("%v", data.UnrealizedPNL))
 }
 
+// fnoPnlDocument lays out the P&L summary and charges followed by each F&O
+// net position
+func fnoPnlDocument(data models.FONetPositionRes) ReportDocument {
+	summary := ReportSection{
+		Title: "Summary",
+		Rows: [][]interface{}{
+			{"Date Range", data.Summary.DateRange},
+			{"Charges", data.Summary.Charges},
+			{"Realised PNL", data.Summary.RealisedPNL},
+			{"Unrealised PNL", data.Summary.UnRealisedPNL},
+			{"Net PNL", data.Summary.NetPNL},
+		},
+	}
+
+	charges := ReportSection{
+		Title: "Charges Details",
+		Rows: [][]interface{}{
+			{"Brokerage", data.ChargesDetails.Brockerage},
+			{"Exchange Transaction Charges", data.ChargesDetails.ExchangeTransactionCharges},
+			{"Clearing Charges", data.ChargesDetails.ClearingCharges},
+			{"Integrated GST", data.ChargesDetails.IntegratedGST},
+			{"Securities Transaction Tax", data.ChargesDetails.SecuritiesTransactionTax},
+			{"SEBI Fees", data.ChargesDetails.SEBIFees},
+			{"Stamp Duty", data.ChargesDetails.StampDuty},
+			{"Total Charges", data.ChargesDetails.TotalCharges},
+		},
+	}
+
+	positions := ReportSection{
+		Title: "FONetPositionDetails",
+		Headers: []string{
+			"Symbol", "Instrument Type", "Option Type", "Strike Price", "Expiry Date", "Quantity", "Buy Value", "Buy Price",
+			"Sell Value", "Sell Price", "Realized PNL", "Previous Closing Price", "Open Quantity", "Open Value", "Unrealized PNL",
+		},
+	}
+	for _, position := range data.FONetPositionDetails {
+		positions.Rows = append(positions.Rows, []interface{}{
+			position.Symbol, position.InstrumentType, position.OptionType, position.StrikePrice, position.ExpiryDate, position.Quantity, position.BuyValue, position.BuyPrice,
+			position.SellValue, position.SellPrice, position.RealizedPNL, position.PreviousClosingPrice, position.OpenQuantity, position.OpenValue, position.UnrealizedPNL,
+		})
+	}
+
+	return ReportDocument{
+		Title:       "F&O Profit & Loss",
+		Workbook:    func() (*xlsx.File, error) { return createFONetPositionExcel("output.xlsx", data) },
+		ReportName:  constants.FnoPnlReportName,
+		UserDetails: data.UserDetails,
+		Sections:    []ReportSection{summary, charges, positions},
+	}
+}
+
 func (obj ReportsObj) ViewFnoPnl(fnoPnlReq models.FnoPnlReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
 
 	var fONetPositionData models.FONetPositionRes
@@ -1154,26 +1297,89 @@ func (obj ReportsObj) DownloadFnoPnl(fnoPnlReq models.FnoPnlReq, reqH models.Req
 		}
 	}
 
+	// Generate a pre-signed URL for the report in the requested format
+	url, err := uploadReport(fnoPnlDocument(fONetPositionData), fnoPnlReq.Format, constants.FnoPnlS3FolderName, fileName)
+	if err != nil {
+		loggerconfig.Error("DownloadFnoPnl, failed to generate pre-signed URL:", err, " format: ", fnoPnlReq.Format, " reqId: ", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	var downloadFnoPnlRes models.DownloadFnoPnlRes
+	downloadFnoPnlRes.DownloadUrl = url
+
+	var apiRes apihelpers.APIRes
+	apiRes.Data = downloadFnoPnlRes
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+
+	return http.StatusOK, apiRes
+}
+
+func (obj ReportsObj) SendEmailFnoPnl(fnoPnlReq models.FnoPnlReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
+
+	var fONetPositionData models.FONetPositionRes
+	var err error
+	fileName := constants.FnoPnlReport + strings.ToUpper(fnoPnlReq.UserID) + "_" + strings.ToUpper(fnoPnlReq.DFDateFr) + "_to_" + strings.ToUpper(fnoPnlReq.DFDateTo)
+	storedReportData, _ := dbops.RedisRepo.Get(fileName)
+	if storedReportData != "" {
+		err = json.Unmarshal([]byte(storedReportData), &fONetPositionData)
+		if err != nil {
+			loggerconfig.Error("SendEmailFnoPnl, error in unmarshalling storedReportData : ", err, " for fileName: ", fileName)
+		}
+	}
+	if storedReportData == "" {
+
+		var getFONetPositionDataReq models.GetFONetPositionDataReq
+		getFONetPositionDataReq.UserID = fnoPnlReq.UserID
+		getFONetPositionDataReq.DFDateFr = fnoPnlReq.DFDateFr
+		getFONetPositionDataReq.DFDateTo = fnoPnlReq.DFDateTo
+		theBackofficeProvider := v1.GetBackOfficeProvider()
+		fONetPositionData, err = theBackofficeProvider.GetFONetPositionData(getFONetPositionDataReq, reqH)
+		if err != nil {
+			loggerconfig.Error("SendEmailFnoPnl, there is some error in fetching data from Shilpi api function: Error : ", err, " reqId: ", reqH.RequestId)
+			return apihelpers.SendInternalServerError()
+		}
+
+		fONetPositionData.UserDetails = profileData
+		reportData, err := json.Marshal(fONetPositionData)
+		if err != nil {
+			loggerconfig.Error("SendEmailFnoPnl, Error in marshalling fONetPositionData", err)
+		} else {
+			err = dbops.RedisRepo.Set(fileName, string(reportData), constants.ReportsCachingTTL*time.Minute)
+			if err != nil {
+				loggerconfig.Error("SendEmailFnoPnl, Report Data not written to redis:", fileName, " Failed to write to redis:", err)
+			}
+		}
+	}
+
 	file, err := createFONetPositionExcel("output.xlsx", fONetPositionData)
 	if err != nil {
-		loggerconfig.Error("DownloadFnoPnl, Error creating Excel file:", err, " reqId: ", reqH.RequestId)
+		loggerconfig.Error("SendEmailFnoPnl, Error in getting excel file, error: ", err, " reqId: ", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
 
-	s3FileName := fileName + ".xlsx"
-	expiryHours := int64(24)
+	encodedString, err := helpers.EncodeExcelToBase64(file)
+	if err != nil {
+		loggerconfig.Error("SendEmailFnoPnl, Error in creating base64 format from Excel, error:", err, " reqId: ", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	var sendEmailFnoPnlDetails SendEmailFnoPnl
+
+	sendEmailFnoPnlDetails.ClientId = profileData.ClientID
+	sendEmailFnoPnlDetails.ApplicantName = profileData.Name
+	sendEmailFnoPnlDetails.RecipientEmail = profileData.EmailID
+	sendEmailFnoPnlDetails.DateFrom = fnoPnlReq.DFDateFr
+	sendEmailFnoPnlDetails.DateTo = fnoPnlReq.DFDateTo
+	sendEmailFnoPnlDetails.EncodedReportFile = encodedString
 
-	// Generate a pre-signed URL for the XLSX file
-	url, err := helpers.UploadFileToS3AndGetPresignedURL(constants.FnoPnlS3FolderName, s3FileName, file, expiryHours)
+	err = CallPublishReportEmail(constants.TopicExchange, constants.KeyFnoPnlReport, sendEmailFnoPnlDetails)
 	if err != nil {
-		loggerconfig.Error("DownloadFnoPnl, failed to generate pre-signed URL:", err, " reqId: ", reqH.RequestId)
+		loggerconfig.Error("SendEmailFnoPnl, failed to publish report email, error:", err, " reqId: ", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
-	var downloadFnoPnlRes models.DownloadFnoPnlRes
-	downloadFnoPnlRes.DownloadUrl = url
 
 	var apiRes apihelpers.APIRes
-	apiRes.Data = downloadFnoPnlRes
 	apiRes.Message = "SUCCESS"
 	apiRes.Status = true
 
@@ -1332,6 +1538,43 @@ func writeHoldingFinancialData(sheet *xlsx.Sheet, holdings []models.GetHoldingFi
 	}
 }
 
+// holdingDocument lays out the holding summary followed by each holding
+func holdingDocument(data models.GetHoldingFinancialDataRes) ReportDocument {
+	summary := ReportSection{
+		Headers: []string{"Holding Summary", "Amount"},
+		Rows: [][]interface{}{
+			{"Invested Value", data.HoldingSummary.InvestedValue},
+			{"Current Value", data.HoldingSummary.CurrentValue},
+			{"Unrealised PNL", data.HoldingSummary.UnrealisedPNL},
+			{"Total Pledge Value", data.HoldingSummary.TotalPledgeValue},
+			{"Total Margin Value After Haircut", data.HoldingSummary.TotalMarginValueAfterHaircut},
+		},
+	}
+
+	holdings := ReportSection{
+		Headers: []string{
+			"ISIN", "Instrument", "Pledged Qty", "Free Qty", "Total Qty", "Total Pledged Value",
+			"Haircut Percentage", "Margin Available After Haircut", "Avg Buy Price", "Closing Price",
+			"Investment Value", "Current Value", "Contribution Percentage", "Unrealized P&L", "Net Change",
+		},
+	}
+	for _, holding := range data.HoldingFinancialData {
+		holdings.Rows = append(holdings.Rows, []interface{}{
+			holding.Isin, holding.Instrument, holding.PledgedQty, holding.FreeQty, holding.TotalQty, holding.TotalPledgedValue,
+			holding.HaircutPercentage, holding.MarginAvailableAfterHaircut, holding.AvgBuyPrice, holding.ClosingPrice,
+			holding.InvestmentValue, holding.CurrentValue, holding.ContributionPercentage, holding.UnrealizedProfitLoss, holding.NetChange,
+		})
+	}
+
+	return ReportDocument{
+		Title:       "Holding Report",
+		Workbook:    func() (*xlsx.File, error) { return CreateExcelHoldingReport(data) },
+		ReportName:  constants.HoldingReportName,
+		UserDetails: data.UserDetails,
+		Sections:    []ReportSection{summary, holdings},
+	}
+}
+
 func (obj ReportsObj) DownloadHoldingFinancial(holdingFinancialDataReq models.GetHoldingFinancialDataReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
 
 	var holdingFinancialData models.GetHoldingFinancialDataRes
@@ -1366,21 +1609,13 @@ func (obj ReportsObj) DownloadHoldingFinancial(holdingFinancialDataReq models.Ge
 		}
 	}
 
-	file, err := CreateExcelHoldingReport(holdingFinancialData)
+	// Generate a pre-signed URL for the report in the requested format
+	url, err := uploadReport(holdingDocument(holdingFinancialData), holdingFinancialDataReq.Format, constants.HoldingFinancialS3FolderName, fileName)
 	if err != nil {
-		loggerconfig.Error("DownloadHoldingFinancial, Error creating Excel file: ", err, " reqId: ", reqH.RequestId)
+		loggerconfig.Error("DownloadHoldingFinancial, failed to generate pre-signed URL:", err, " format: ", holdingFinancialDataReq.Format, " reqId: ", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
 
-	s3FileName := fileName + ".xlsx"
-	expiryHours := int64(24)
-
-	// Generate a pre-signed URL for the XLSX file
-	url, err := helpers.UploadFileToS3AndGetPresignedURL(constants.HoldingFinancialS3FolderName, s3FileName, file, expiryHours)
-	if err != nil {
-		loggerconfig.Error("DownloadOpenPosition, failed to generate pre-signed URL:", err, " reqId: ", reqH.RequestId)
-		return apihelpers.SendInternalServerError()
-	}
 	var downloadHoldingFinancialRes models.DownloadHoldingFinancialRes
 	downloadHoldingFinancialRes.DownloadUrl = url
 
@@ -1445,7 +1680,11 @@ func (obj ReportsObj) SendEmailHoldingFinancial(holdingFinancialDataReq models.G
 	sendEmailHoldingFinancial.RecipientEmail = profileData.EmailID
 	sendEmailHoldingFinancial.EncodedReportFile = encodedString
 
-	helpers.PublishMessage(constants.TopicExchange, constants.KeyHoldingFinancialReport, sendEmailHoldingFinancial)
+	err = CallPublishReportEmail(constants.TopicExchange, constants.KeyHoldingFinancialReport, sendEmailHoldingFinancial)
+	if err != nil {
+		loggerconfig.Error("SendEmailHoldingFinancial, failed to publish report email, error:", err, " reqId: ", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
 
 	var apiRes apihelpers.APIRes
 	apiRes.Message = "SUCCESS"
@@ -1514,7 +1753,11 @@ func (obj ReportsObj) SendEmailLedger(ledgerReq models.LedgerReq, reqH models.Re
 	sendEmailLedgerDetails.DateTo = ledgerReq.DFDateTo
 	sendEmailLedgerDetails.EncodedReportFile = encodedString
 
-	helpers.PublishMessage(constants.TopicExchange, constants.KeyLedgerReport, sendEmailLedgerDetails)
+	err = CallPublishReportEmail(constants.TopicExchange, constants.KeyLedgerReport, sendEmailLedgerDetails)
+	if err != nil {
+		loggerconfig.Error("SendEmailLedger, failed to publish report email, error:", err, " reqId: ", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
 
 	var apiRes apihelpers.APIRes
 	apiRes.Message = "SUCCESS"
@@ -1699,6 +1942,45 @@ func writeCommodityTransactionsData(sheet *xlsx.Sheet, transactions []models.Com
 	}
 }
 
+// commodityTradebookDocument lays out the commodity charges followed by each trade
+func commodityTradebookDocument(data models.CommodityTransactionRes) ReportDocument {
+	charges := ReportSection{
+		Headers: []string{"Trade Charges", "Amount"},
+		Rows: [][]interface{}{
+			{"Brokerage", data.TotalBrokerage},
+			{"GST", data.TotalGST},
+			{"SEBI Tax", data.TotalSEBITax},
+			{"STT (CTT)", data.TotalCTT},
+			{"Exchange Turnover Charges", data.TotalTurnCharges},
+			{"Stamp Duty", data.TotalStampDuty},
+			{"Total Charges", data.TotalCharges},
+		},
+	}
+
+	transactions := ReportSection{
+		Headers: []string{
+			"Symbol", "Instrument Type", "Expiry Date", "Option Type", "Strike Price", "Transaction Date",
+			"Buy/Sell", "Trade Price", "Market Lot", "Qty", "Brokerage", "GST", "CTT", "SEBI Fees",
+			"Turnover Fees", "Stamp Duty", "Clearing Charge", "Segment", "Exchange", "Order No", "Trade ID", "Trade Time",
+		},
+	}
+	for _, trx := range data.CommodityTransactions {
+		transactions.Rows = append(transactions.Rows, []interface{}{
+			trx.Symbol, trx.InstrumentType, trx.ExpiryDate, trx.OptionType, trx.StrikePrice, trx.TradeDate,
+			trx.BuySellInd, trx.TradePrice, trx.MarketLot, trx.TradeQty, trx.Brokerage, trx.GST, trx.CTT, trx.SEBITax,
+			trx.TurnoverTax, trx.StampDuty, trx.CLGTax, trx.Segment, trx.Exchange, trx.OrderNo, trx.TradeNo, trx.TradeTime,
+		})
+	}
+
+	return ReportDocument{
+		Title:       constants.CommodityTradebookReportName,
+		Workbook:    func() (*xlsx.File, error) { return CreateExcelCommodityTradebook(data) },
+		ReportName:  constants.CommodityTradebookReportName,
+		UserDetails: data.UserDetails,
+		Sections:    []ReportSection{charges, transactions},
+	}
+}
+
 func (obj ReportsObj) DownloadCommodityTradebook(commodityTradebookReq models.CommodityTradebookReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
 	var commodityTransaction models.CommodityTransactionRes
 	var err error
@@ -1732,19 +2014,10 @@ func (obj ReportsObj) DownloadCommodityTradebook(commodityTradebookReq models.Co
 		}
 	}
 
-	file, err := CreateExcelCommodityTradebook(commodityTransaction)
+	// Generate a pre-signed URL for the report in the requested format
+	url, err := uploadReport(commodityTradebookDocument(commodityTransaction), commodityTradebookReq.Format, constants.CommodityTradebookS3FolderName, fileName)
 	if err != nil {
-		loggerconfig.Error("DownloadCommodityTradebook, Error in getting excel file, error: ", err, " reqId: ", reqH.RequestId)
-		return apihelpers.SendInternalServerError()
-	}
-
-	s3FileName := fileName + ".xlsx"
-	expiryHours := int64(24)
-
-	// Generate a pre-signed URL for the XLSX file
-	url, err := helpers.UploadFileToS3AndGetPresignedURL(constants.CommodityTradebookS3FolderName, s3FileName, file, expiryHours)
-	if err != nil {
-		loggerconfig.Error("DownloadCommodityTradebook, failed to generate pre-signed URL:", err, " reqId: ", reqH.RequestId)
+		loggerconfig.Error("DownloadCommodityTradebook, failed to generate pre-signed URL:", err, " format: ", commodityTradebookReq.Format, " reqId: ", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
 
@@ -1814,7 +2087,11 @@ func (obj ReportsObj) SendEmailCommodityTradebook(commodityTradebookReq models.C
 	sendEmailCommodityTradebookDetails.DateTo = commodityTradebookReq.DFDateTo
 	sendEmailCommodityTradebookDetails.EncodedReportFile = encodedString
 
-	helpers.PublishMessage(constants.TopicExchange, constants.KeyCommodityTradebookReport, sendEmailCommodityTradebookDetails)
+	err = CallPublishReportEmail(constants.TopicExchange, constants.KeyCommodityTradebookReport, sendEmailCommodityTradebookDetails)
+	if err != nil {
+		loggerconfig.Error("SendEmailCommodityTradebook, failed to publish report email, error:", err, " reqId: ", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
 
 	var apiRes apihelpers.APIRes
 	apiRes.Message = "SUCCESS"
@@ -1984,6 +2261,46 @@ func writeFNOTransactionsData(sheet *xlsx.Sheet, transactions []models.FNOTransa
 	}
 }
 
+// fnoTradebookDocument lays out the F&O charges followed by each trade
+func fnoTradebookDocument(data models.FNOTransactionRes) ReportDocument {
+	charges := ReportSection{
+		Headers: []string{"Trade Charges", "Amount"},
+		Rows: [][]interface{}{
+			{"Brokerage", data.TotalBrokerage},
+			{"GST", data.TotalGST},
+			{"SEBI Tax", data.TotalSEBITax},
+			{"STT", data.TotalSTT},
+			{"Exchange Turnover Charges", data.TotalTurnCharges},
+			{"Stamp Duty", data.TotalStampDuty},
+			{"Clearing Charges", data.TotalClearingCharges},
+			{"Total Charges", data.TotalCharges},
+		},
+	}
+
+	transactions := ReportSection{
+		Headers: []string{
+			"Symbol", "Instrument Type", "Expiry Date", "Option Type", "Strike Price", "Transaction Date",
+			"Buy/Sell", "Trade Price", "Qty", "Brokerage", "GST", "STT", "SEBI Fees",
+			"Turnover Fees", "Stamp Duty", "IPF Tax", "Clearing Charges", "Segment", "Exchange", "Order No", "Trade ID", "Trade Time",
+		},
+	}
+	for _, trx := range data.FNOTransactions {
+		transactions.Rows = append(transactions.Rows, []interface{}{
+			trx.Symbol, trx.InstrumentType, trx.ExpiryDate, trx.OptionType, trx.StrikePrice, trx.TradeDate,
+			trx.BuySellInd, trx.TradePrice, trx.TradeQty, trx.Brokerage, trx.GST, trx.STT, trx.SEBITax,
+			trx.TurnoverTax, trx.StampDuty, trx.IPFTax, trx.ClearingCharges, trx.Segment, trx.Exchange, trx.OrderNo, trx.TradeNo, trx.TradeTime,
+		})
+	}
+
+	return ReportDocument{
+		Title:       "FNO Tradebook and Charges",
+		Workbook:    func() (*xlsx.File, error) { return CreateExcelFNOTradebook(data) },
+		ReportName:  constants.FnoTradebookReportName,
+		UserDetails: data.UserDetails,
+		Sections:    []ReportSection{charges, transactions},
+	}
+}
+
 func (obj ReportsObj) DownloadFnoTradebook(fnoTradebookReq models.FNOTradebookReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
 
 	var fnoTransaction models.FNOTransactionRes
@@ -2017,19 +2334,10 @@ func (obj ReportsObj) DownloadFnoTradebook(fnoTradebookReq models.FNOTradebookRe
 		}
 	}
 
-	file, err := CreateExcelFNOTradebook(fnoTransaction)
+	// Generate a pre-signed URL for the report in the requested format
+	url, err := uploadReport(fnoTradebookDocument(fnoTransaction), fnoTradebookReq.Format, constants.FnoTradebookS3FolderName, fileName)
 	if err != nil {
-		loggerconfig.Error("DownloadFnoTradebook, Error in getting excel file, error: ", err, "reqId: ", reqH.RequestId)
-		return apihelpers.SendInternalServerError()
-	}
-
-	s3FileName := fileName + ".xlsx"
-	expiryHours := int64(24)
-
-	// Generate a pre-signed URL for the XLSX file
-	url, err := helpers.UploadFileToS3AndGetPresignedURL(constants.FnoTradebookS3FolderName, s3FileName, file, expiryHours)
-	if err != nil {
-		loggerconfig.Error("DownloadFnoTradebook, failed to generate pre-signed URL:", err, "reqId: ", reqH.RequestId)
+		loggerconfig.Error("DownloadFnoTradebook, failed to generate pre-signed URL:", err, " format: ", fnoTradebookReq.Format, " reqId: ", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
 
@@ -2098,7 +2406,11 @@ func (obj ReportsObj) SendEmailFnoTradebook(fnoTradebookReq models.FNOTradebookR
 	sendEmailFnoTradebookDetails.DateTo = fnoTradebookReq.DFDateTo
 	sendEmailFnoTradebookDetails.EncodedReportFile = encodedString
 
-	helpers.PublishMessage(constants.TopicExchange, constants.KeyFnoTradebookReport, sendEmailFnoTradebookDetails)
+	err = CallPublishReportEmail(constants.TopicExchange, constants.KeyFnoTradebookReport, sendEmailFnoTradebookDetails)
+	if err != nil {
+		loggerconfig.Error("SendEmailFnoTradebook, failed to publish report email, error:", err, " reqId: ", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
 
 	var apiRes apihelpers.APIRes
 	apiRes.Message = "SUCCESS"
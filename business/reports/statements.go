@@ -0,0 +1,455 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/dbops"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var statementNow = helpers.GetCurrentTimeInIST
+
+// statementPeriod is the range a statement covers, Period being its label.
+type statementPeriod struct {
+	Period   string
+	DateFrom time.Time
+	DateTo   time.Time
+}
+
+// statementSchedule describes a recurring statement: the last period that
+// ended before a date, and how it is emailed.
+type statementSchedule struct {
+	period func(today time.Time) statementPeriod
+	send   func(obj ReportsObj, sub models.MongoStatementSubscription, run models.MongoStatementRun, reqH models.ReqHeader) (int, apihelpers.APIRes)
+}
+
+// statementOrder is the order statements are listed and sent in.
+var statementOrder = []string{constants.StatementMonthlyLedger, constants.StatementQuarterlyHoldings, constants.StatementYearlyPnl}
+
+var statementSchedules = map[string]statementSchedule{
+	constants.StatementMonthlyLedger: {
+		period: func(today time.Time) statementPeriod {
+			monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+			from := monthStart.AddDate(0, -1, 0)
+			return statementPeriod{Period: from.Format("Jan-2006"), DateFrom: from, DateTo: monthStart.AddDate(0, 0, -1)}
+		},
+		send: func(obj ReportsObj, sub models.MongoStatementSubscription, run models.MongoStatementRun, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.SendEmailLedger(models.LedgerReq{UserID: sub.ClientId, DFDateFr: run.DateFrom, DFDateTo: run.DateTo}, reqH, sub.UserDetails)
+		},
+	},
+	constants.StatementQuarterlyHoldings: {
+		period: func(today time.Time) statementPeriod {
+			quarterStart := time.Date(today.Year(), today.Month()-(today.Month()-1)%3, 1, 0, 0, 0, 0, today.Location())
+			from := quarterStart.AddDate(0, -3, 0)
+			to := quarterStart.AddDate(0, 0, -1)
+			return statementPeriod{Period: from.Format("Jan") + "-" + to.Format("Jan-2006"), DateFrom: from, DateTo: to}
+		},
+		// holdings are reported as they stand when the statement is sent
+		send: func(obj ReportsObj, sub models.MongoStatementSubscription, run models.MongoStatementRun, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.SendEmailHoldingFinancial(models.GetHoldingFinancialDataReq{UserID: sub.ClientId}, reqH, sub.UserDetails)
+		},
+	},
+	constants.StatementYearlyPnl: {
+		// financial years run April to March
+		period: func(today time.Time) statementPeriod {
+			endYear := today.Year()
+			if today.Month() < time.April {
+				endYear--
+			}
+			from := time.Date(endYear-1, time.April, 1, 0, 0, 0, 0, today.Location())
+			to := time.Date(endYear, time.March, 31, 0, 0, 0, 0, today.Location())
+			return statementPeriod{Period: fmt.Sprintf("FY%d-%02d", endYear-1, endYear%100), DateFrom: from, DateTo: to}
+		},
+		send: func(obj ReportsObj, sub models.MongoStatementSubscription, run models.MongoStatementRun, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			return obj.SendEmailFnoPnl(models.FnoPnlReq{UserID: sub.ClientId, DFDateFr: run.DateFrom, DFDateTo: run.DateTo}, reqH, sub.UserDetails)
+		},
+	},
+}
+
+// isExchangeBusinessDay checks a date against the exchange holiday calendar,
+// falling back to weekends for dates the calendar does not cover.
+func isExchangeBusinessDay(date time.Time) bool {
+	day := date.Format("02-Jan-2006")
+	for _, calendarDate := range helpers.HolidayCalendar.Date {
+		if strings.EqualFold(calendarDate.Date, day) {
+			return !calendarDate.IsHoliday
+		}
+	}
+	return date.Weekday() != time.Saturday && date.Weekday() != time.Sunday
+}
+
+var CallSaveStatementSubscription = func(sub models.MongoStatementSubscription) error {
+	filter := bson.M{"clientId": sub.ClientId, "statement": sub.Statement}
+	update := bson.M{
+		"$set": bson.M{
+			"active":        true,
+			"userDetails":   sub.UserDetails,
+			"platform":      sub.Platform,
+			"deviceType":    sub.DeviceType,
+			"deviceId":      sub.DeviceId,
+			"lastRunPeriod": sub.LastRunPeriod,
+			"updatedAt":     sub.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{"createdAt": sub.CreatedAt},
+	}
+	return dbops.MongoRepo.UpdateOne(constants.STATEMENTSUBSCRIPTIONSCOLL, filter, update, options.Update().SetUpsert(true))
+}
+
+// CallDeactivateStatementSubscription reports whether there was an active
+// subscription to stop.
+var CallDeactivateStatementSubscription = func(clientId string, statement string, now time.Time) (bool, error) {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.STATEMENTSUBSCRIPTIONSCOLL)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"clientId": clientId, "statement": statement, "active": true}
+	res, err := coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"active": false, "updatedAt": now}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+var CallFetchStatementSubscriptions = func(clientId string) ([]models.MongoStatementSubscription, error) {
+	return findStatementSubscriptions(bson.M{"clientId": clientId})
+}
+
+// CallFetchDueStatementSubscriptions returns the active subscriptions to a
+// statement that have not had a run for period yet.
+var CallFetchDueStatementSubscriptions = func(statement string, period string) ([]models.MongoStatementSubscription, error) {
+	return findStatementSubscriptions(bson.M{"statement": statement, "active": true, "lastRunPeriod": bson.M{"$ne": period}})
+}
+
+func findStatementSubscriptions(filter bson.M) ([]models.MongoStatementSubscription, error) {
+	cursor, err := dbops.MongoRepo.Find(constants.STATEMENTSUBSCRIPTIONSCOLL, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var subs []models.MongoStatementSubscription
+	if err := cursor.All(context.Background(), &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+var CallFetchStatementRuns = func(clientId string, limit int64) ([]models.MongoStatementRun, error) {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.STATEMENTRUNSCOLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(limit)
+	cursor, err := coll.Find(ctx, bson.M{"clientId": clientId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var runs []models.MongoStatementRun
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// CallClaimStatementRun takes the run for a client's statement period, either
+// by creating it or by retrying a failed or abandoned attempt. When the run
+// cannot be taken the stored run is returned instead.
+var CallClaimStatementRun = func(run models.MongoStatementRun) (models.MongoStatementRun, bool, error) {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.STATEMENTRUNSCOLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key := bson.M{"clientId": run.ClientId, "statement": run.Statement, "period": run.Period}
+	res, err := coll.UpdateOne(ctx, key, bson.M{"$setOnInsert": run}, options.Update().SetUpsert(true))
+	if err != nil {
+		return run, false, err
+	}
+	if res.UpsertedCount > 0 {
+		return run, true, nil
+	}
+
+	retry := bson.M{
+		"clientId":  run.ClientId,
+		"statement": run.Statement,
+		"period":    run.Period,
+		"attempts":  bson.M{"$lt": constants.StatementMaxAttempts},
+		"$or": []bson.M{
+			{"status": constants.StatementRunFailed},
+			{"status": constants.StatementRunRunning, "updatedAt": bson.M{"$lt": run.UpdatedAt.Add(-constants.StatementRunStaleMins * time.Minute)}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": constants.StatementRunRunning, "error": "", "updatedAt": run.UpdatedAt},
+		"$inc": bson.M{"attempts": 1},
+	}
+	var claimed models.MongoStatementRun
+	err = coll.FindOneAndUpdate(ctx, retry, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&claimed)
+	if err == nil {
+		return claimed, true, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return run, false, err
+	}
+
+	var stored models.MongoStatementRun
+	err = coll.FindOne(ctx, key).Decode(&stored)
+	return stored, false, err
+}
+
+// CallFinishStatementRun records the outcome of an attempt, unless a later
+// attempt has taken the run over.
+var CallFinishStatementRun = func(run models.MongoStatementRun) error {
+	filter := bson.M{"runId": run.RunId, "attempts": run.Attempts, "status": constants.StatementRunRunning}
+	update := bson.M{"$set": bson.M{"status": run.Status, "error": run.Error, "updatedAt": run.UpdatedAt}}
+	return dbops.MongoRepo.UpdateOne(constants.STATEMENTRUNSCOLLECTION, filter, update)
+}
+
+// CallMarkStatementPeriod stops the scheduler from looking at a subscription
+// again until its next period.
+var CallMarkStatementPeriod = func(clientId string, statement string, period string) error {
+	filter := bson.M{"clientId": clientId, "statement": statement}
+	return dbops.MongoRepo.UpdateOne(constants.STATEMENTSUBSCRIPTIONSCOLL, filter, bson.M{"$set": bson.M{"lastRunPeriod": period}})
+}
+
+// SubscribeStatement starts sending a recurring statement to the client's
+// email. The first one sent is for the period after the one that has just
+// ended, and subscribing again refreshes the email the statement goes to.
+func (obj ReportsObj) SubscribeStatement(req models.StatementSubscriptionReq, reqH models.ReqHeader, profileData models.ProfileDataResp) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	if profileData.EmailID == "" {
+		loggerconfig.Info("SubscribeStatement, no email id for clientId:", req.UserID, " statement:", req.Statement, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.StatementEmailMissing, http.StatusBadRequest)
+	}
+
+	now := statementNow()
+	sub := models.MongoStatementSubscription{
+		ClientId:      req.UserID,
+		Statement:     req.Statement,
+		Active:        true,
+		UserDetails:   profileData,
+		Platform:      reqH.Platform,
+		DeviceType:    reqH.DeviceType,
+		DeviceId:      reqH.DeviceId,
+		LastRunPeriod: statementSchedules[req.Statement].period(now).Period,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := CallSaveStatementSubscription(sub); err != nil {
+		loggerconfig.Error("SubscribeStatement, mongo error:", err, " clientId:", req.UserID, " statement:", req.Statement, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	loggerconfig.Info("SubscribeStatement, clientId:", req.UserID, " statement:", req.Statement, " requestId:", reqH.RequestId)
+	apiRes.Data = statementSubscriptionRes(req.Statement, &sub, now)
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+func (obj ReportsObj) UnsubscribeStatement(req models.StatementSubscriptionReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	found, err := CallDeactivateStatementSubscription(req.UserID, req.Statement, statementNow())
+	if err != nil {
+		loggerconfig.Error("UnsubscribeStatement, mongo error:", err, " clientId:", req.UserID, " statement:", req.Statement, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	if !found {
+		return apihelpers.SendErrorResponse(false, constants.StatementNotSubscribed, http.StatusBadRequest)
+	}
+
+	loggerconfig.Info("UnsubscribeStatement, clientId:", req.UserID, " statement:", req.Statement, " requestId:", reqH.RequestId)
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// FetchStatementSubscriptions lists every statement with whether the client
+// receives it, along with the latest deliveries.
+func (obj ReportsObj) FetchStatementSubscriptions(req models.StatementSubscriptionsReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	subs, err := CallFetchStatementSubscriptions(req.UserID)
+	if err != nil {
+		loggerconfig.Error("FetchStatementSubscriptions, mongo error fetching subscriptions:", err, " clientId:", req.UserID, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	runs, err := CallFetchStatementRuns(req.UserID, constants.StatementRunHistory)
+	if err != nil {
+		loggerconfig.Error("FetchStatementSubscriptions, mongo error fetching runs:", err, " clientId:", req.UserID, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	now := statementNow()
+	res := models.StatementSubscriptionsRes{Runs: []models.StatementRunRes{}}
+	for _, statement := range statementOrder {
+		var current *models.MongoStatementSubscription
+		for i := range subs {
+			if subs[i].Statement == statement && subs[i].Active {
+				current = &subs[i]
+			}
+		}
+		res.Subscriptions = append(res.Subscriptions, statementSubscriptionRes(statement, current, now))
+	}
+	for _, run := range runs {
+		res.Runs = append(res.Runs, models.StatementRunRes{
+			Statement: run.Statement,
+			Period:    run.Period,
+			DateFrom:  run.DateFrom,
+			DateTo:    run.DateTo,
+			Status:    run.Status,
+			Attempts:  run.Attempts,
+			Error:     run.Error,
+			UpdatedAt: run.UpdatedAt.Unix(),
+		})
+	}
+
+	apiRes.Data = res
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// statementSubscriptionRes describes a statement, sub being nil when the
+// client is not subscribed to it.
+func statementSubscriptionRes(statement string, sub *models.MongoStatementSubscription, now time.Time) models.StatementSubscriptionRes {
+	res := models.StatementSubscriptionRes{Statement: statement, NextPeriod: nextStatementPeriod(statementSchedules[statement], now).Period}
+	if sub != nil {
+		res.Subscribed = true
+		res.Email = sub.UserDetails.EmailID
+	}
+	return res
+}
+
+// nextStatementPeriod is the period in progress on now, which is the one the
+// next statement will cover.
+func nextStatementPeriod(schedule statementSchedule, now time.Time) statementPeriod {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	for {
+		month = month.AddDate(0, 1, 0)
+		if period := schedule.period(month); !period.DateTo.Before(today) {
+			return period
+		}
+	}
+}
+
+// RunStatementSchedule sends the statements that are due every half hour. A
+// statement for a period is due from the send hour of the first exchange
+// business day after the period ends; runs missed then are picked up on the
+// following business days.
+func RunStatementSchedule(obj ReportsObj) {
+	defer models.HandlePanic()
+
+	ticker := time.NewTicker(constants.StatementPollMins * time.Minute)
+	defer ticker.Stop()
+	for {
+		obj.sendDueStatements()
+		<-ticker.C
+	}
+}
+
+func (obj ReportsObj) sendDueStatements() {
+	defer models.HandlePanic()
+
+	now := statementNow()
+	if now.Hour() < constants.StatementSendHour || !isExchangeBusinessDay(now) {
+		return
+	}
+	for _, statement := range statementOrder {
+		period := statementSchedules[statement].period(now)
+		subs, err := CallFetchDueStatementSubscriptions(statement, period.Period)
+		if err != nil {
+			loggerconfig.Error("sendDueStatements, mongo error fetching subscriptions:", err, " statement:", statement, " period:", period.Period)
+			continue
+		}
+		if len(subs) > 0 {
+			loggerconfig.Info("sendDueStatements, statement:", statement, " period:", period.Period, " subscriptions due:", len(subs))
+		}
+		for _, sub := range subs {
+			obj.sendStatement(sub, period)
+		}
+	}
+}
+
+func (obj ReportsObj) sendStatement(sub models.MongoStatementSubscription, period statementPeriod) {
+	now := statementNow()
+	run, claimed, err := CallClaimStatementRun(models.MongoStatementRun{
+		RunId:     uuid.New().String(),
+		ClientId:  sub.ClientId,
+		Statement: sub.Statement,
+		Period:    period.Period,
+		DateFrom:  period.DateFrom.Format(constants.DDMMYYYY),
+		DateTo:    period.DateTo.Format(constants.DDMMYYYY),
+		Status:    constants.StatementRunRunning,
+		Attempts:  1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		loggerconfig.Error("sendStatement, mongo error claiming run:", err, " clientId:", sub.ClientId, " statement:", sub.Statement, " period:", period.Period)
+		return
+	}
+	if !claimed {
+		// another instance has it, or it is already settled
+		if statementRunSettled(run) {
+			obj.markStatementPeriod(sub, period)
+		}
+		return
+	}
+
+	reqH := models.ReqHeader{
+		ClientId:   sub.ClientId,
+		RequestId:  run.RunId,
+		Platform:   sub.Platform,
+		DeviceType: sub.DeviceType,
+		DeviceId:   sub.DeviceId,
+	}
+	code, res := statementSchedules[sub.Statement].send(obj, sub, run, reqH)
+
+	run.Status = constants.StatementRunSent
+	run.Error = ""
+	if code != http.StatusOK {
+		loggerconfig.Error("sendStatement, statement failed with code:", code, " message:", res.Message, " attempt:", run.Attempts, " runId:", run.RunId, " clientId:", sub.ClientId, " statement:", sub.Statement, " period:", period.Period)
+		run.Status = constants.StatementRunFailed
+		run.Error = "statement could not be generated"
+	}
+	run.UpdatedAt = statementNow()
+	if err := CallFinishStatementRun(run); err != nil {
+		loggerconfig.Error("sendStatement, mongo error recording run:", err, " runId:", run.RunId, " status:", run.Status)
+		return
+	}
+	if statementRunSettled(run) {
+		if run.Status == constants.StatementRunFailed {
+			loggerconfig.Error("Alert Severity:P2-Mid, sendStatement giving up after ", run.Attempts, " attempts, runId:", run.RunId, " clientId:", sub.ClientId, " statement:", sub.Statement, " period:", period.Period)
+		}
+		obj.markStatementPeriod(sub, period)
+	}
+}
+
+// statementRunSettled reports whether a run will not be attempted again.
+func statementRunSettled(run models.MongoStatementRun) bool {
+	return run.Status == constants.StatementRunSent || (run.Status == constants.StatementRunFailed && run.Attempts >= constants.StatementMaxAttempts)
+}
+
+func (obj ReportsObj) markStatementPeriod(sub models.MongoStatementSubscription, period statementPeriod) {
+	if err := CallMarkStatementPeriod(sub.ClientId, sub.Statement, period.Period); err != nil {
+		loggerconfig.Error("markStatementPeriod, mongo error:", err, " clientId:", sub.ClientId, " statement:", sub.Statement, " period:", period.Period)
+	}
+}
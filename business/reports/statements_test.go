@@ -0,0 +1,258 @@
+package reports
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+)
+
+func TestStatementPeriods(t *testing.T) {
+	tests := []struct {
+		statement string
+		today     time.Time
+		want      string
+		from, to  string
+		next      string
+	}{
+		{constants.StatementMonthlyLedger, time.Date(2026, 10, 2, 9, 0, 0, 0, constants.LocationKolkata), "Sep-2026", "01-09-2026", "30-09-2026", "Oct-2026"},
+		{constants.StatementMonthlyLedger, time.Date(2026, 1, 5, 9, 0, 0, 0, constants.LocationKolkata), "Dec-2025", "01-12-2025", "31-12-2025", "Jan-2026"},
+		{constants.StatementQuarterlyHoldings, time.Date(2026, 10, 2, 9, 0, 0, 0, constants.LocationKolkata), "Jul-Sep-2026", "01-07-2026", "30-09-2026", "Oct-Dec-2026"},
+		{constants.StatementQuarterlyHoldings, time.Date(2026, 2, 14, 9, 0, 0, 0, constants.LocationKolkata), "Oct-Dec-2025", "01-10-2025", "31-12-2025", "Jan-Mar-2026"},
+		{constants.StatementYearlyPnl, time.Date(2026, 4, 1, 9, 0, 0, 0, constants.LocationKolkata), "FY2025-26", "01-04-2025", "31-03-2026", "FY2026-27"},
+		{constants.StatementYearlyPnl, time.Date(2026, 3, 31, 9, 0, 0, 0, constants.LocationKolkata), "FY2024-25", "01-04-2024", "31-03-2025", "FY2025-26"},
+	}
+	for _, tt := range tests {
+		schedule := statementSchedules[tt.statement]
+		got := schedule.period(tt.today)
+		if got.Period != tt.want || got.DateFrom.Format(constants.DDMMYYYY) != tt.from || got.DateTo.Format(constants.DDMMYYYY) != tt.to {
+			t.Errorf("%s on %s = %s %s..%s, want %s %s..%s", tt.statement, tt.today.Format(constants.DDMMYYYY), got.Period, got.DateFrom.Format(constants.DDMMYYYY), got.DateTo.Format(constants.DDMMYYYY), tt.want, tt.from, tt.to)
+		}
+		if next := nextStatementPeriod(schedule, tt.today); next.Period != tt.next {
+			t.Errorf("next %s on %s = %s, want %s", tt.statement, tt.today.Format(constants.DDMMYYYY), next.Period, tt.next)
+		}
+	}
+}
+
+func TestStatements(t *testing.T) {
+	origSave, origDeactivate, origFetch, origDue := CallSaveStatementSubscription, CallDeactivateStatementSubscription, CallFetchStatementSubscriptions, CallFetchDueStatementSubscriptions
+	origRuns, origClaim, origFinish, origMark := CallFetchStatementRuns, CallClaimStatementRun, CallFinishStatementRun, CallMarkStatementPeriod
+	origNow, origCalendar, origSchedules := statementNow, helpers.HolidayCalendar, statementSchedules
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		CallSaveStatementSubscription, CallDeactivateStatementSubscription, CallFetchStatementSubscriptions, CallFetchDueStatementSubscriptions = origSave, origDeactivate, origFetch, origDue
+		CallFetchStatementRuns, CallClaimStatementRun, CallFinishStatementRun, CallMarkStatementPeriod = origRuns, origClaim, origFinish, origMark
+		statementNow, helpers.HolidayCalendar, statementSchedules = origNow, origCalendar, origSchedules
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+
+	// subscriptions and runs are kept in memory with the same rules as the
+	// Mongo queries
+	var storedSubs []*models.MongoStatementSubscription
+	var storedRuns []*models.MongoStatementRun
+	find := func(clientId, statement string) *models.MongoStatementSubscription {
+		for _, sub := range storedSubs {
+			if sub.ClientId == clientId && sub.Statement == statement {
+				return sub
+			}
+		}
+		return nil
+	}
+	CallSaveStatementSubscription = func(sub models.MongoStatementSubscription) error {
+		if stored := find(sub.ClientId, sub.Statement); stored != nil {
+			sub.CreatedAt = stored.CreatedAt
+			*stored = sub
+			return nil
+		}
+		storedSubs = append(storedSubs, &sub)
+		return nil
+	}
+	CallDeactivateStatementSubscription = func(clientId string, statement string, now time.Time) (bool, error) {
+		stored := find(clientId, statement)
+		if stored == nil || !stored.Active {
+			return false, nil
+		}
+		stored.Active = false
+		return true, nil
+	}
+	CallFetchStatementSubscriptions = func(clientId string) ([]models.MongoStatementSubscription, error) {
+		var found []models.MongoStatementSubscription
+		for _, sub := range storedSubs {
+			if sub.ClientId == clientId {
+				found = append(found, *sub)
+			}
+		}
+		return found, nil
+	}
+	CallFetchDueStatementSubscriptions = func(statement string, period string) ([]models.MongoStatementSubscription, error) {
+		var found []models.MongoStatementSubscription
+		for _, sub := range storedSubs {
+			if sub.Statement == statement && sub.Active && sub.LastRunPeriod != period {
+				found = append(found, *sub)
+			}
+		}
+		return found, nil
+	}
+	CallFetchStatementRuns = func(clientId string, limit int64) ([]models.MongoStatementRun, error) {
+		var found []models.MongoStatementRun
+		for i := len(storedRuns) - 1; i >= 0 && int64(len(found)) < limit; i-- {
+			if storedRuns[i].ClientId == clientId {
+				found = append(found, *storedRuns[i])
+			}
+		}
+		return found, nil
+	}
+	CallClaimStatementRun = func(run models.MongoStatementRun) (models.MongoStatementRun, bool, error) {
+		for _, stored := range storedRuns {
+			if stored.ClientId == run.ClientId && stored.Statement == run.Statement && stored.Period == run.Period {
+				if stored.Status == constants.StatementRunFailed && stored.Attempts < constants.StatementMaxAttempts {
+					stored.Status, stored.Error, stored.UpdatedAt = constants.StatementRunRunning, "", run.UpdatedAt
+					stored.Attempts++
+					return *stored, true, nil
+				}
+				return *stored, false, nil
+			}
+		}
+		storedRuns = append(storedRuns, &run)
+		return run, true, nil
+	}
+	CallFinishStatementRun = func(run models.MongoStatementRun) error {
+		for _, stored := range storedRuns {
+			if stored.RunId == run.RunId && stored.Attempts == run.Attempts {
+				*stored = run
+			}
+		}
+		return nil
+	}
+	CallMarkStatementPeriod = func(clientId string, statement string, period string) error {
+		find(clientId, statement).LastRunPeriod = period
+		return nil
+	}
+
+	t.Run("subscriptions", func(t *testing.T) {
+		storedSubs, storedRuns = nil, nil
+		statementNow = func() time.Time { return time.Date(2026, 10, 21, 11, 0, 0, 0, constants.LocationKolkata) }
+		obj := ReportsObj{}
+		reqH := models.ReqHeader{ClientId: "AB123", DeviceType: "android"}
+		profile := models.ProfileDataResp{ClientID: "AB123", Name: "Asha Rao", EmailID: "asha@example.com"}
+
+		if code, res := obj.SubscribeStatement(models.StatementSubscriptionReq{UserID: "AB123", Statement: constants.StatementMonthlyLedger}, reqH, models.ProfileDataResp{ClientID: "AB123"}); code != http.StatusBadRequest || res.ErrorCode != constants.StatementEmailMissing {
+			t.Errorf("subscribe without email = %d, %+v", code, res)
+		}
+
+		code, res := obj.SubscribeStatement(models.StatementSubscriptionReq{UserID: "AB123", Statement: constants.StatementMonthlyLedger}, reqH, profile)
+		if code != http.StatusOK || res.Data.(models.StatementSubscriptionRes).NextPeriod != "Oct-2026" {
+			t.Errorf("SubscribeStatement() = %d, %+v", code, res)
+		}
+		// the month that has already ended is not sent to a new subscriber
+		if storedSubs[0].LastRunPeriod != "Sep-2026" {
+			t.Errorf("LastRunPeriod = %s", storedSubs[0].LastRunPeriod)
+		}
+
+		_, res = obj.FetchStatementSubscriptions(models.StatementSubscriptionsReq{UserID: "AB123"}, reqH)
+		subs := res.Data.(models.StatementSubscriptionsRes).Subscriptions
+		if len(subs) != 3 || !subs[0].Subscribed || subs[0].Email != profile.EmailID || subs[1].Subscribed || subs[2].NextPeriod != "FY2026-27" {
+			t.Errorf("subscriptions = %+v", subs)
+		}
+
+		if code, _ := obj.UnsubscribeStatement(models.StatementSubscriptionReq{UserID: "AB123", Statement: constants.StatementMonthlyLedger}, reqH); code != http.StatusOK {
+			t.Errorf("UnsubscribeStatement() = %d", code)
+		}
+		if code, res := obj.UnsubscribeStatement(models.StatementSubscriptionReq{UserID: "AB123", Statement: constants.StatementMonthlyLedger}, reqH); code != http.StatusBadRequest || res.ErrorCode != constants.StatementNotSubscribed {
+			t.Errorf("second unsubscribe = %d, %+v", code, res)
+		}
+	})
+
+	t.Run("send due", func(t *testing.T) {
+		storedSubs, storedRuns = nil, nil
+		now := time.Date(2026, 10, 1, 9, 0, 0, 0, constants.LocationKolkata)
+		statementNow = func() time.Time { return now }
+		helpers.HolidayCalendar = models.Calendar{Date: []models.DateDetails{
+			{Date: "01-Oct-2026", DayOfWeek: "Thursday", IsHoliday: true},
+			{Date: "02-Oct-2026", DayOfWeek: "Friday"},
+		}}
+
+		for _, sub := range []models.MongoStatementSubscription{
+			{ClientId: "AB123", Statement: constants.StatementMonthlyLedger, Active: true, LastRunPeriod: "Aug-2026", DeviceType: "android"},
+			{ClientId: "CD456", Statement: constants.StatementMonthlyLedger, Active: true, LastRunPeriod: "Aug-2026"},
+			{ClientId: "AB123", Statement: constants.StatementQuarterlyHoldings, Active: true, LastRunPeriod: "Apr-Jun-2026"},
+			{ClientId: "EF789", Statement: constants.StatementMonthlyLedger, Active: false, LastRunPeriod: "Aug-2026"},
+		} {
+			sub := sub
+			storedSubs = append(storedSubs, &sub)
+		}
+
+		var sent []string
+		send := func(obj ReportsObj, sub models.MongoStatementSubscription, run models.MongoStatementRun, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+			if reqH.RequestId != run.RunId || reqH.ClientId != sub.ClientId {
+				t.Errorf("reqH = %+v for run %s", reqH, run.RunId)
+			}
+			sent = append(sent, sub.ClientId+" "+sub.Statement+" "+run.DateFrom+".."+run.DateTo)
+			if sub.ClientId == "CD456" {
+				return http.StatusInternalServerError, apihelpers.APIRes{}
+			}
+			return http.StatusOK, apihelpers.APIRes{Status: true}
+		}
+		statementSchedules = map[string]statementSchedule{}
+		for statement, schedule := range origSchedules {
+			schedule.send = send
+			statementSchedules[statement] = schedule
+		}
+		obj := ReportsObj{}
+
+		// the first of the month is an exchange holiday
+		obj.sendDueStatements()
+		if len(sent) != 0 {
+			t.Fatalf("sent on a holiday: %v", sent)
+		}
+		now = time.Date(2026, 10, 2, 6, 30, 0, 0, constants.LocationKolkata)
+		obj.sendDueStatements()
+		if len(sent) != 0 {
+			t.Fatalf("sent before the send hour: %v", sent)
+		}
+
+		now = time.Date(2026, 10, 2, 7, 0, 0, 0, constants.LocationKolkata)
+		obj.sendDueStatements()
+		want := []string{"AB123 monthlyLedger 01-09-2026..30-09-2026", "CD456 monthlyLedger 01-09-2026..30-09-2026", "AB123 quarterlyHoldings 01-07-2026..30-09-2026"}
+		if len(sent) != len(want) {
+			t.Fatalf("sent = %v, want %v", sent, want)
+		}
+		for i := range want {
+			if sent[i] != want[i] {
+				t.Errorf("sent[%d] = %s, want %s", i, sent[i], want[i])
+			}
+		}
+		if storedSubs[0].LastRunPeriod != "Sep-2026" || storedSubs[2].LastRunPeriod != "Jul-Sep-2026" || storedSubs[1].LastRunPeriod != "Aug-2026" {
+			t.Errorf("periods = %s %s %s", storedSubs[0].LastRunPeriod, storedSubs[1].LastRunPeriod, storedSubs[2].LastRunPeriod)
+		}
+
+		// a failing statement is retried on later ticks until it runs out of attempts
+		for i := 0; i < constants.StatementMaxAttempts+1; i++ {
+			sent = nil
+			now = now.Add(constants.StatementPollMins * time.Minute)
+			obj.sendDueStatements()
+			if i < constants.StatementMaxAttempts-1 && (len(sent) != 1 || sent[0] != want[1]) {
+				t.Errorf("retry %d sent %v", i, sent)
+			}
+			if i >= constants.StatementMaxAttempts-1 && len(sent) != 0 {
+				t.Errorf("sent %v after the last attempt", sent)
+			}
+		}
+		if storedSubs[1].LastRunPeriod != "Sep-2026" {
+			t.Errorf("exhausted subscription not moved on: %s", storedSubs[1].LastRunPeriod)
+		}
+
+		_, res := obj.FetchStatementSubscriptions(models.StatementSubscriptionsReq{UserID: "CD456"}, models.ReqHeader{})
+		runs := res.Data.(models.StatementSubscriptionsRes).Runs
+		if len(runs) != 1 || runs[0].Status != constants.StatementRunFailed || runs[0].Attempts != constants.StatementMaxAttempts || runs[0].Period != "Sep-2026" {
+			t.Errorf("runs = %+v", runs)
+		}
+	})
+}
@@ -0,0 +1,404 @@
+package screeners
+
+import (
+	"fmt"
+	"space/models"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// screenerField reads one metric off a stock. Technicals that are missing from
+// the nightly batch come back as zero, so those fields never match a filter.
+type screenerField struct {
+	numeric      func(models.ScreenerStock) float64
+	text         func(models.ScreenerStock) string
+	zeroIsAbsent bool
+}
+
+var screenerFields = map[string]screenerField{
+	"pe":            {numeric: func(s models.ScreenerStock) float64 { return s.PE }},
+	"pb":            {numeric: func(s models.ScreenerStock) float64 { return s.PB }},
+	"sectorpe":      {numeric: func(s models.ScreenerStock) float64 { return s.SectorPE }},
+	"roe":           {numeric: func(s models.ScreenerStock) float64 { return s.ROE }},
+	"eps":           {numeric: func(s models.ScreenerStock) float64 { return s.EPS }},
+	"divyield":      {numeric: func(s models.ScreenerStock) float64 { return s.DivYield }},
+	"dividendyield": {numeric: func(s models.ScreenerStock) float64 { return s.DivYield }},
+	"bookvalue":     {numeric: func(s models.ScreenerStock) float64 { return s.BookValue }},
+	"de":            {numeric: func(s models.ScreenerStock) float64 { return s.DebtToEquity }},
+	"debttoequity":  {numeric: func(s models.ScreenerStock) float64 { return s.DebtToEquity }},
+	"mcap":          {numeric: func(s models.ScreenerStock) float64 { return s.MarketCap }},
+	"marketcap":     {numeric: func(s models.ScreenerStock) float64 { return s.MarketCap }},
+	"price":         {numeric: func(s models.ScreenerStock) float64 { return s.Price }, zeroIsAbsent: true},
+	"ltp":           {numeric: func(s models.ScreenerStock) float64 { return s.Price }, zeroIsAbsent: true},
+	"rsi":           {numeric: func(s models.ScreenerStock) float64 { return s.RSI }, zeroIsAbsent: true},
+	"rsi14":         {numeric: func(s models.ScreenerStock) float64 { return s.RSI }, zeroIsAbsent: true},
+	"macd":          {numeric: func(s models.ScreenerStock) float64 { return s.MACD }, zeroIsAbsent: true},
+	"macdsignal":    {numeric: func(s models.ScreenerStock) float64 { return s.MACDSignal }, zeroIsAbsent: true},
+	"sma10":         {numeric: func(s models.ScreenerStock) float64 { return s.SMA10 }, zeroIsAbsent: true},
+	"sma20":         {numeric: func(s models.ScreenerStock) float64 { return s.SMA20 }, zeroIsAbsent: true},
+	"sma50":         {numeric: func(s models.ScreenerStock) float64 { return s.SMA50 }, zeroIsAbsent: true},
+	"sma100":        {numeric: func(s models.ScreenerStock) float64 { return s.SMA100 }, zeroIsAbsent: true},
+	"sma200":        {numeric: func(s models.ScreenerStock) float64 { return s.SMA200 }, zeroIsAbsent: true},
+	"ema10":         {numeric: func(s models.ScreenerStock) float64 { return s.EMA10 }, zeroIsAbsent: true},
+	"ema20":         {numeric: func(s models.ScreenerStock) float64 { return s.EMA20 }, zeroIsAbsent: true},
+	"ema50":         {numeric: func(s models.ScreenerStock) float64 { return s.EMA50 }, zeroIsAbsent: true},
+	"sector":        {text: func(s models.ScreenerStock) string { return s.Sector }},
+	"symbol":        {text: func(s models.ScreenerStock) string { return s.NseSymbol }},
+	"name":          {text: func(s models.ScreenerStock) string { return s.CompanyName }},
+	"isin":          {text: func(s models.ScreenerStock) string { return s.Isin }},
+}
+
+// market cap in CMOTS is stored in crores
+var numberSuffixes = map[string]float64{
+	"cr":    1,
+	"crore": 1,
+	"l":     0.01,
+	"lakh":  0.01,
+	"lac":   0.01,
+	"%":     1,
+}
+
+type screenerExpr interface {
+	match(stock models.ScreenerStock) bool
+}
+
+type andExpr []screenerExpr
+
+func (e andExpr) match(stock models.ScreenerStock) bool {
+	for _, child := range e {
+		if !child.match(stock) {
+			return false
+		}
+	}
+	return true
+}
+
+type orExpr []screenerExpr
+
+func (e orExpr) match(stock models.ScreenerStock) bool {
+	for _, child := range e {
+		if child.match(stock) {
+			return true
+		}
+	}
+	return false
+}
+
+type notExpr struct {
+	inner screenerExpr
+}
+
+func (e notExpr) match(stock models.ScreenerStock) bool {
+	return !e.inner.match(stock)
+}
+
+type screenerOperand struct {
+	field   *screenerField
+	number  float64
+	text    string
+	isText  bool
+	literal bool
+}
+
+func (o screenerOperand) textValue(stock models.ScreenerStock) string {
+	if o.literal {
+		return o.text
+	}
+	return o.field.text(stock)
+}
+
+func (o screenerOperand) numericValue(stock models.ScreenerStock) (float64, bool) {
+	if o.literal {
+		return o.number, true
+	}
+	value := o.field.numeric(stock)
+	if value == 0 && o.field.zeroIsAbsent {
+		return 0, false
+	}
+	return value, true
+}
+
+type comparison struct {
+	left  screenerOperand
+	op    string
+	right screenerOperand
+}
+
+func (c comparison) match(stock models.ScreenerStock) bool {
+	if c.left.isText {
+		equal := strings.EqualFold(strings.TrimSpace(c.left.textValue(stock)), strings.TrimSpace(c.right.textValue(stock)))
+		if c.op == "=" {
+			return equal
+		}
+		return !equal
+	}
+
+	left, ok := c.left.numericValue(stock)
+	if !ok {
+		return false
+	}
+	right, ok := c.right.numericValue(stock)
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	case "=":
+		return left == right
+	default:
+		return left != right
+	}
+}
+
+type screenerToken struct {
+	kind  string // ident, number, string, op, lparen, rparen
+	value string
+}
+
+func tokenizeScreenerQuery(query string) ([]screenerToken, error) {
+	var tokens []screenerToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, screenerToken{kind: "lparen", value: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, screenerToken{kind: "rparen", value: ")"})
+			i++
+		case r == '\'' || r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, screenerToken{kind: "string", value: string(runes[i+1 : end])})
+			i = end + 1
+		case strings.ContainsRune("<>=!", r):
+			op := string(r)
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '<' && runes[i+1] == '>')) {
+				op += string(runes[i+1])
+			}
+			i += len([]rune(op))
+			switch op {
+			case "==":
+				op = "="
+			case "<>":
+				op = "!="
+			case "!":
+				return nil, fmt.Errorf("unexpected '!' at position %d", i-1)
+			}
+			tokens = append(tokens, screenerToken{kind: "op", value: op})
+		case unicode.IsDigit(r) || r == '.' || r == '-':
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			number, err := strconv.ParseFloat(string(runes[i:end]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", string(runes[i:end]))
+			}
+			suffixEnd := end
+			for suffixEnd < len(runes) && (unicode.IsLetter(runes[suffixEnd]) || runes[suffixEnd] == '%') {
+				suffixEnd++
+			}
+			if suffixEnd > end {
+				multiplier, ok := numberSuffixes[strings.ToLower(string(runes[end:suffixEnd]))]
+				if !ok {
+					return nil, fmt.Errorf("unknown unit %q", string(runes[end:suffixEnd]))
+				}
+				number *= multiplier
+			}
+			tokens = append(tokens, screenerToken{kind: "number", value: strconv.FormatFloat(number, 'f', -1, 64)})
+			i = suffixEnd
+		case unicode.IsLetter(r) || r == '_':
+			end := i + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			tokens = append(tokens, screenerToken{kind: "ident", value: strings.ToLower(string(runes[i:end]))})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+	return tokens, nil
+}
+
+type screenerParser struct {
+	tokens []screenerToken
+	pos    int
+}
+
+// parseScreenerQuery compiles a filter such as
+// `PE < 20 AND ROE > 15 AND RSI(14) < 30 AND sector = 'IT' AND mcap > 5000cr`.
+func parseScreenerQuery(query string) (screenerExpr, error) {
+	tokens, err := tokenizeScreenerQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	parser := &screenerParser{tokens: tokens}
+	expr, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected %q", parser.tokens[parser.pos].value)
+	}
+	return expr, nil
+}
+
+func (p *screenerParser) peek() (screenerToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return screenerToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *screenerParser) keyword(word string) bool {
+	token, ok := p.peek()
+	if ok && token.kind == "ident" && token.value == word {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *screenerParser) parseOr() (screenerExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	exprs := orExpr{left}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+	if len(exprs) == 1 {
+		return left, nil
+	}
+	return exprs, nil
+}
+
+func (p *screenerParser) parseAnd() (screenerExpr, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	exprs := andExpr{left}
+	for p.keyword("and") {
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+	if len(exprs) == 1 {
+		return left, nil
+	}
+	return exprs, nil
+}
+
+func (p *screenerParser) parseTerm() (screenerExpr, error) {
+	if p.keyword("not") {
+		inner, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{inner: inner}, nil
+	}
+
+	token, ok := p.peek()
+	if ok && token.kind == "lparen" {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if token, ok := p.peek(); !ok || token.kind != "rparen" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *screenerParser) parseComparison() (screenerExpr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	token, ok := p.peek()
+	if !ok || token.kind != "op" {
+		return nil, fmt.Errorf("expected comparison operator")
+	}
+	p.pos++
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if left.literal && right.literal {
+		return nil, fmt.Errorf("comparison needs at least one field")
+	}
+	if left.isText != right.isText {
+		return nil, fmt.Errorf("cannot compare text with a number")
+	}
+	if left.isText && token.value != "=" && token.value != "!=" {
+		return nil, fmt.Errorf("text fields only support = and !=")
+	}
+	return comparison{left: left, op: token.value, right: right}, nil
+}
+
+func (p *screenerParser) parseOperand() (screenerOperand, error) {
+	token, ok := p.peek()
+	if !ok {
+		return screenerOperand{}, fmt.Errorf("unexpected end of query")
+	}
+	p.pos++
+
+	switch token.kind {
+	case "number":
+		number, _ := strconv.ParseFloat(token.value, 64)
+		return screenerOperand{number: number, literal: true}, nil
+	case "string":
+		return screenerOperand{text: token.value, isText: true, literal: true}, nil
+	case "ident":
+		name := token.value
+		// indicator calls like RSI(14) or SMA(200) resolve to rsi14 / sma200
+		if next, ok := p.peek(); ok && next.kind == "lparen" {
+			if p.pos+2 >= len(p.tokens) || p.tokens[p.pos+1].kind != "number" || p.tokens[p.pos+2].kind != "rparen" {
+				return screenerOperand{}, fmt.Errorf("invalid arguments for %s", name)
+			}
+			name += p.tokens[p.pos+1].value
+			p.pos += 3
+		}
+		field, ok := screenerFields[name]
+		if !ok {
+			return screenerOperand{}, fmt.Errorf("unknown field %q", name)
+		}
+		return screenerOperand{field: &field, isText: field.text != nil}, nil
+	}
+	return screenerOperand{}, fmt.Errorf("unexpected %q", token.value)
+}
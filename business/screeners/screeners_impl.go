@@ -0,0 +1,354 @@
+package screeners
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/db"
+	"space/dbops"
+	"space/helpers"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ScreenersObj struct {
+	mongodb  db.MongoDatabase
+	Db       db.Database
+	redisCli cache.RedisCache
+}
+
+func InitScreenersProvider(mongodb db.MongoDatabase, dbInstance db.Database, redisCli cache.RedisCache) ScreenersObj {
+	defer models.HandlePanic()
+	screenersObj := ScreenersObj{
+		mongodb:  mongodb,
+		Db:       dbInstance,
+		redisCli: redisCli,
+	}
+	return screenersObj
+}
+
+func (obj ScreenersObj) RunScreener(req models.RunScreenerReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	query := req.Query
+	if req.ScreenerId != "" {
+		screeners, err := CallFetchScreenersMongo(reqH.ClientId, obj)
+		if err != nil && err.Error() != constants.MongoNoDocError {
+			loggerconfig.Error("RunScreener FetchScreenersMongo err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+			return apihelpers.SendInternalServerError()
+		}
+		saved, found := findScreener(screeners.Screeners, req.ScreenerId)
+		if !found {
+			loggerconfig.Error("RunScreener screener not found, screenerId:", req.ScreenerId, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+			return apihelpers.SendErrorResponse(false, constants.ScreenerDoesNotExists, http.StatusBadRequest)
+		}
+		query = saved.Query
+		if req.SortBy == "" {
+			req.SortBy = saved.SortBy
+			req.SortOrder = saved.SortOrder
+		}
+	}
+
+	expr, err := parseScreenerQuery(query)
+	if err != nil {
+		loggerconfig.Error("RunScreener invalid query:", query, " err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidScreenerQuery, http.StatusBadRequest)
+	}
+
+	sortBy := req.SortBy
+	if sortBy == "" {
+		sortBy = constants.ScreenerDefaultSortBy
+	}
+	sortField, err := parseSortField(sortBy)
+	if err != nil {
+		loggerconfig.Error("RunScreener invalid sortBy:", req.SortBy, " err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidScreenerQuery, http.StatusBadRequest)
+	}
+
+	universe, err := CallFetchScreenerUniverse(obj)
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P2-Mid, RunScreener FetchScreenerUniverse err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	matched := make([]models.ScreenerStock, 0)
+	for _, stock := range universe {
+		if expr.match(stock) {
+			matched = append(matched, stock)
+		}
+	}
+	sortScreenerResults(matched, sortField, req.SortOrder != constants.ScreenerSortAsc)
+
+	page, pageSize := req.Page, req.PageSize
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = constants.ScreenerDefaultPageSize
+	}
+	if pageSize > constants.ScreenerMaxPageSize {
+		pageSize = constants.ScreenerMaxPageSize
+	}
+
+	var res models.RunScreenerRes
+	res.Query = query
+	res.Total = len(matched)
+	res.Page = page
+	res.PageSize = pageSize
+	res.Results = make([]models.ScreenerStock, 0)
+	start := (page - 1) * pageSize
+	if start < len(matched) {
+		end := start + pageSize
+		if end > len(matched) {
+			end = len(matched)
+		}
+		res.Results = matched[start:end]
+	}
+
+	loggerconfig.Info("RunScreener Successful, query:", query, " total:", res.Total, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Data = res
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+func (obj ScreenersObj) SaveScreener(req models.SaveScreenerReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	if _, err := parseScreenerQuery(req.Query); err != nil {
+		loggerconfig.Error("SaveScreener invalid query:", req.Query, " err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidScreenerQuery, http.StatusBadRequest)
+	}
+	if req.SortBy != "" {
+		if _, err := parseSortField(req.SortBy); err != nil {
+			loggerconfig.Error("SaveScreener invalid sortBy:", req.SortBy, " err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+			return apihelpers.SendErrorResponse(false, constants.InvalidScreenerQuery, http.StatusBadRequest)
+		}
+	}
+
+	screeners, err := CallFetchScreenersMongo(reqH.ClientId, obj)
+	if err != nil && err.Error() != constants.MongoNoDocError {
+		loggerconfig.Error("SaveScreener FetchScreenersMongo err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	screeners.ClientId = reqH.ClientId
+
+	now := time.Now().Unix()
+	var saved models.SavedScreener
+	if req.ScreenerId != "" {
+		index := -1
+		for i := range screeners.Screeners {
+			if screeners.Screeners[i].ScreenerId == req.ScreenerId {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			loggerconfig.Error("SaveScreener screener not found, screenerId:", req.ScreenerId, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+			return apihelpers.SendErrorResponse(false, constants.ScreenerDoesNotExists, http.StatusBadRequest)
+		}
+		screeners.Screeners[index].Name = req.Name
+		screeners.Screeners[index].Query = req.Query
+		screeners.Screeners[index].SortBy = req.SortBy
+		screeners.Screeners[index].SortOrder = req.SortOrder
+		screeners.Screeners[index].UpdatedAt = now
+		saved = screeners.Screeners[index]
+	} else {
+		if len(screeners.Screeners) >= constants.ScreenerCapacity {
+			loggerconfig.Error("SaveScreener capacity full clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+			return apihelpers.SendErrorResponse(false, constants.ScreenerCapacityFull, http.StatusBadRequest)
+		}
+		saved = models.SavedScreener{
+			ScreenerId: uuid.New().String(),
+			Name:       req.Name,
+			Query:      req.Query,
+			SortBy:     req.SortBy,
+			SortOrder:  req.SortOrder,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		screeners.Screeners = append(screeners.Screeners, saved)
+	}
+
+	err = CallUpdateScreenersMongo(reqH.ClientId, screeners, obj)
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P2-Mid, SaveScreener Mongo Upsert failed error =", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	apiRes.Data = saved
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+func (obj ScreenersObj) FetchScreeners(reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	screeners, err := CallFetchScreenersMongo(reqH.ClientId, obj)
+	if err != nil && err.Error() != constants.MongoNoDocError {
+		loggerconfig.Error("FetchScreeners FetchScreenersMongo err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	var res models.FetchScreenersRes
+	res.ClientId = reqH.ClientId
+	res.Screeners = make([]models.SavedScreener, 0)
+	res.Screeners = append(res.Screeners, screeners.Screeners...)
+
+	loggerconfig.Info("FetchScreeners Successful, response:", helpers.LogStructAsJSON(res), " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Data = res
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+func (obj ScreenersObj) DeleteScreener(req models.DeleteScreenerReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	screeners, err := CallFetchScreenersMongo(reqH.ClientId, obj)
+	if err != nil && err.Error() != constants.MongoNoDocError {
+		loggerconfig.Error("DeleteScreener FetchScreenersMongo err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	remaining := make([]models.SavedScreener, 0, len(screeners.Screeners))
+	for _, screener := range screeners.Screeners {
+		if screener.ScreenerId != req.ScreenerId {
+			remaining = append(remaining, screener)
+		}
+	}
+	if len(remaining) == len(screeners.Screeners) {
+		loggerconfig.Error("DeleteScreener screener not found, screenerId:", req.ScreenerId, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.ScreenerDoesNotExists, http.StatusBadRequest)
+	}
+	screeners.Screeners = remaining
+
+	err = CallUpdateScreenersMongo(reqH.ClientId, screeners, obj)
+	if err != nil {
+		loggerconfig.Error("DeleteScreener Mongo Upsert failed error =", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+func findScreener(screeners []models.SavedScreener, screenerId string) (models.SavedScreener, bool) {
+	for _, screener := range screeners {
+		if screener.ScreenerId == screenerId {
+			return screener, true
+		}
+	}
+	return models.SavedScreener{}, false
+}
+
+var errInvalidSortField = errors.New("sortBy must name a single field")
+
+func parseSortField(sortBy string) (screenerOperand, error) {
+	parser := &screenerParser{}
+	tokens, err := tokenizeScreenerQuery(sortBy)
+	if err != nil {
+		return screenerOperand{}, err
+	}
+	parser.tokens = tokens
+	operand, err := parser.parseOperand()
+	if err != nil {
+		return operand, err
+	}
+	if operand.literal || parser.pos != len(tokens) {
+		return operand, errInvalidSortField
+	}
+	return operand, nil
+}
+
+func sortScreenerResults(stocks []models.ScreenerStock, field screenerOperand, descending bool) {
+	sort.SliceStable(stocks, func(i, j int) bool {
+		if field.isText {
+			a, b := strings.ToLower(field.textValue(stocks[i])), strings.ToLower(field.textValue(stocks[j]))
+			if descending {
+				return a > b
+			}
+			return a < b
+		}
+		a, okA := field.numericValue(stocks[i])
+		b, okB := field.numericValue(stocks[j])
+		// stocks without the metric always sink to the bottom
+		if okA != okB {
+			return okA
+		}
+		if descending {
+			return a > b
+		}
+		return a < b
+	})
+}
+
+var CallFetchScreenerUniverse = func(obj ScreenersObj) ([]models.ScreenerStock, error) {
+	return obj.FetchScreenerUniverse()
+}
+
+// FetchScreenerUniverse loads fundamentals and technicals for every listed
+// company, shared across users through a short lived Redis copy.
+func (obj ScreenersObj) FetchScreenerUniverse() ([]models.ScreenerStock, error) {
+	var universe []models.ScreenerStock
+
+	if obj.redisCli != nil {
+		cached, err := obj.redisCli.GetRedis(constants.ScreenerUniverseKey).Result()
+		if err == nil && cached != "" {
+			if err = json.Unmarshal([]byte(cached), &universe); err == nil {
+				return universe, nil
+			}
+			loggerconfig.Error("FetchScreenerUniverse unable to unmarshal cached universe err:", err)
+		}
+	}
+
+	universe, err := obj.Db.FetchScreenerUniverse(constants.ScreenerTechnicalsFreq)
+	if err != nil {
+		return universe, err
+	}
+
+	if obj.redisCli != nil {
+		raw, err := json.Marshal(universe)
+		if err == nil {
+			err = obj.redisCli.SetRedis(constants.ScreenerUniverseKey, string(raw), constants.ScreenerUniverseTTL)
+		}
+		if err != nil {
+			loggerconfig.Error("FetchScreenerUniverse unable to cache universe err:", err)
+		}
+	}
+	return universe, nil
+}
+
+var CallFetchScreenersMongo = func(clientId string, obj ScreenersObj) (models.MongoScreeners, error) {
+	return obj.FetchScreenersMongo(clientId)
+}
+
+func (obj ScreenersObj) FetchScreenersMongo(clientId string) (models.MongoScreeners, error) {
+	var screeners models.MongoScreeners
+	err := dbops.MongoRepo.FindOne(constants.SCREENERS, bson.M{"clientId": clientId}, &screeners)
+	return screeners, err
+}
+
+var CallUpdateScreenersMongo = func(clientId string, screeners models.MongoScreeners, obj ScreenersObj) error {
+	return obj.UpdateScreenersMongo(clientId, screeners)
+}
+
+func (obj ScreenersObj) UpdateScreenersMongo(clientId string, screeners models.MongoScreeners) error {
+	filter := bson.D{{Key: "clientId", Value: clientId}}
+	update := bson.D{{Key: "$set", Value: screeners}}
+	opts := options.Update().SetUpsert(true)
+	return dbops.MongoRepo.UpdateOne(constants.SCREENERS, filter, update, opts)
+}
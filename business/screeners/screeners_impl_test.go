@@ -0,0 +1,196 @@
+package screeners
+
+import (
+	"errors"
+	"net/http"
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var screenerFixture = []models.ScreenerStock{
+	{Isin: "INE467B01029", CompanyName: "Tata Consultancy Services", NseSymbol: "TCS", Sector: "IT", MarketCap: 1400000, PE: 28, ROE: 48, RSI: 41, Price: 3900, SMA200: 3800},
+	{Isin: "INE009A01021", CompanyName: "Infosys", NseSymbol: "INFY", Sector: "IT", MarketCap: 650000, PE: 18, ROE: 31, RSI: 27, Price: 1500, SMA200: 1600},
+	{Isin: "INE860A01027", CompanyName: "HCL Technologies", NseSymbol: "HCLTECH", Sector: "IT", MarketCap: 420000, PE: 19, ROE: 23, RSI: 29, Price: 1400, SMA200: 1350},
+	{Isin: "INE062A01020", CompanyName: "State Bank of India", NseSymbol: "SBIN", Sector: "Banks", MarketCap: 700000, PE: 9, ROE: 17, RSI: 25, Price: 800, SMA200: 760},
+	{Isin: "INE000X01010", CompanyName: "Small IT Co", NseSymbol: "SMALLIT", Sector: "IT", MarketCap: 900, PE: 12, ROE: 20},
+}
+
+func matchingSymbols(t *testing.T, query string) []string {
+	expr, err := parseScreenerQuery(query)
+	if err != nil {
+		t.Fatalf("parseScreenerQuery(%q) unexpected error: %v", query, err)
+	}
+	symbols := make([]string, 0)
+	for _, stock := range screenerFixture {
+		if expr.match(stock) {
+			symbols = append(symbols, stock.NseSymbol)
+		}
+	}
+	return symbols
+}
+
+func TestParseScreenerQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"PE < 20 AND ROE > 15 AND RSI(14) < 30 AND sector = 'IT' AND mcap > 5000cr", []string{"INFY", "HCLTECH"}},
+		{"sector = 'it' AND rsi < 30", []string{"INFY", "HCLTECH"}},
+		{"sector != 'IT'", []string{"SBIN"}},
+		{"price > SMA(200)", []string{"TCS", "HCLTECH", "SBIN"}},
+		{"(pe < 10 OR roe > 40) AND mcap >= 1000", []string{"TCS", "SBIN"}},
+		{"NOT sector = \"IT\"", []string{"SBIN"}},
+		{"mcap < 10l", []string{}},
+		{"rsi < 100", []string{"TCS", "INFY", "HCLTECH", "SBIN"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchingSymbols(t, tt.query))
+		})
+	}
+}
+
+func TestParseScreenerQueryErrors(t *testing.T) {
+	queries := []string{
+		"",
+		"pe <",
+		"pe < 20 AND",
+		"foo > 1",
+		"sector > 'IT'",
+		"sector = 20",
+		"20 < 30",
+		"(pe < 20",
+		"rsi(14 < 30",
+		"mcap > 10xyz",
+		"name = 'unterminated",
+	}
+
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			_, err := parseScreenerQuery(query)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRunScreener(t *testing.T) {
+	origUniverse, origFetch := CallFetchScreenerUniverse, CallFetchScreenersMongo
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		CallFetchScreenerUniverse, CallFetchScreenersMongo = origUniverse, origFetch
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+
+	CallFetchScreenerUniverse = func(obj ScreenersObj) ([]models.ScreenerStock, error) {
+		return screenerFixture, nil
+	}
+	CallFetchScreenersMongo = func(clientId string, obj ScreenersObj) (models.MongoScreeners, error) {
+		return models.MongoScreeners{
+			ClientId: clientId,
+			Screeners: []models.SavedScreener{
+				{ScreenerId: "saved-1", Name: "Cheap IT", Query: "sector = 'IT' AND pe < 20", SortBy: "name", SortOrder: constants.ScreenerSortAsc},
+			},
+		}, nil
+	}
+	reqH := models.ReqHeader{ClientId: "CLIENT1", DeviceType: "web"}
+	obj := ScreenersObj{}
+
+	t.Run("sorts and paginates", func(t *testing.T) {
+		code, res := obj.RunScreener(models.RunScreenerReq{Query: "sector = 'IT'", SortBy: "mcap", Page: 2, PageSize: 2}, reqH)
+		assert.Equal(t, http.StatusOK, code)
+		data := res.Data.(models.RunScreenerRes)
+		assert.Equal(t, 4, data.Total)
+		assert.Len(t, data.Results, 2)
+		assert.Equal(t, "HCLTECH", data.Results[0].NseSymbol)
+		assert.Equal(t, "SMALLIT", data.Results[1].NseSymbol)
+	})
+
+	t.Run("stocks without technicals sort last", func(t *testing.T) {
+		code, res := obj.RunScreener(models.RunScreenerReq{Query: "mcap > 0", SortBy: "RSI(14)", SortOrder: constants.ScreenerSortAsc}, reqH)
+		assert.Equal(t, http.StatusOK, code)
+		data := res.Data.(models.RunScreenerRes)
+		assert.Equal(t, "SBIN", data.Results[0].NseSymbol)
+		assert.Equal(t, "SMALLIT", data.Results[4].NseSymbol)
+	})
+
+	t.Run("runs a saved screener", func(t *testing.T) {
+		code, res := obj.RunScreener(models.RunScreenerReq{ScreenerId: "saved-1"}, reqH)
+		assert.Equal(t, http.StatusOK, code)
+		data := res.Data.(models.RunScreenerRes)
+		assert.Equal(t, 3, data.Total)
+		assert.Equal(t, "HCLTECH", data.Results[0].NseSymbol)
+	})
+
+	t.Run("unknown saved screener", func(t *testing.T) {
+		code, res := obj.RunScreener(models.RunScreenerReq{ScreenerId: "missing"}, reqH)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, constants.ScreenerDoesNotExists, res.ErrorCode)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		code, res := obj.RunScreener(models.RunScreenerReq{Query: "pe <<< 3"}, reqH)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, constants.InvalidScreenerQuery, res.ErrorCode)
+	})
+
+	t.Run("universe fetch fails", func(t *testing.T) {
+		CallFetchScreenerUniverse = func(obj ScreenersObj) ([]models.ScreenerStock, error) {
+			return nil, errors.New("db down")
+		}
+		code, _ := obj.RunScreener(models.RunScreenerReq{Query: "pe < 20"}, reqH)
+		assert.Equal(t, http.StatusInternalServerError, code)
+	})
+}
+
+func TestSaveScreener(t *testing.T) {
+	origFetch, origUpdate := CallFetchScreenersMongo, CallUpdateScreenersMongo
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		CallFetchScreenersMongo, CallUpdateScreenersMongo = origFetch, origUpdate
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+	reqH := models.ReqHeader{ClientId: "CLIENT1", DeviceType: "web"}
+	obj := ScreenersObj{}
+
+	var stored models.MongoScreeners
+	CallUpdateScreenersMongo = func(clientId string, screeners models.MongoScreeners, obj ScreenersObj) error {
+		stored = screeners
+		return nil
+	}
+
+	t.Run("creates a screener", func(t *testing.T) {
+		CallFetchScreenersMongo = func(clientId string, obj ScreenersObj) (models.MongoScreeners, error) {
+			return models.MongoScreeners{}, errors.New(constants.MongoNoDocError)
+		}
+		code, res := obj.SaveScreener(models.SaveScreenerReq{Name: "Oversold IT", Query: "sector = 'IT' AND rsi < 30"}, reqH)
+		assert.Equal(t, http.StatusOK, code)
+		saved := res.Data.(models.SavedScreener)
+		assert.NotEmpty(t, saved.ScreenerId)
+		assert.Equal(t, "CLIENT1", stored.ClientId)
+		assert.Len(t, stored.Screeners, 1)
+	})
+
+	t.Run("rejects invalid query", func(t *testing.T) {
+		code, res := obj.SaveScreener(models.SaveScreenerReq{Name: "Bad", Query: "pe <"}, reqH)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, constants.InvalidScreenerQuery, res.ErrorCode)
+	})
+
+	t.Run("capacity full", func(t *testing.T) {
+		CallFetchScreenersMongo = func(clientId string, obj ScreenersObj) (models.MongoScreeners, error) {
+			return models.MongoScreeners{ClientId: clientId, Screeners: make([]models.SavedScreener, constants.ScreenerCapacity)}, nil
+		}
+		code, res := obj.SaveScreener(models.SaveScreenerReq{Name: "One more", Query: "pe < 10"}, reqH)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, constants.ScreenerCapacityFull, res.ErrorCode)
+	})
+}
@@ -0,0 +1,389 @@
+package searchscriptv2
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"space/constants"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+)
+
+// localContract is the searchable part of a contract master entry.
+type localContract struct {
+	key           string
+	exchange      string
+	symbol        string
+	tradingSymbol string
+	name          string
+	isin          string
+	strike        string
+	// the fields below joined, used for infix and fuzzy matching
+	text string
+}
+
+type localTerm struct {
+	term string
+	idx  int32
+}
+
+// localSearchIndex is an in memory stand in for the RediSearch stocks index,
+// built from the contract master. Terms are kept sorted so a prefix lookup is
+// a binary search, and trigram postings answer infix and fuzzy queries.
+type localSearchIndex struct {
+	mu          sync.RWMutex
+	contracts   []localContract
+	terms       []localTerm
+	strikes     []localTerm
+	trigrams    map[string][]int32
+	refreshedAt time.Time
+}
+
+var localIndex = &localSearchIndex{}
+
+// search backend health, shared by every SearchScriptV2 value
+var (
+	smartCacheDownUntil atomic.Int64
+	servingLocalIndex   atomic.Bool
+)
+
+var errLocalIndexEmpty = errors.New("local search index not built")
+
+var exchangeOrder = map[string]int{"NSE": 0, "BSE": 1, "NFO": 2, "BFO": 3, "MCX": 4, "CDS": 5}
+
+// buildLocalIndex parses the stock_key hash into a fresh index.
+func buildLocalIndex(stockKeys map[string]string, now time.Time) *localSearchIndex {
+	index := &localSearchIndex{trigrams: make(map[string][]int32), refreshedAt: now}
+
+	for key, raw := range stockKeys {
+		var contract models.ContractDetails
+		if err := json.Unmarshal([]byte(raw), &contract); err != nil {
+			continue
+		}
+		entry := localContract{
+			key:           key,
+			exchange:      strings.ToUpper(contract.Exchange),
+			symbol:        strings.ToUpper(contract.Symbol),
+			tradingSymbol: strings.ToUpper(contract.TradingSymbol),
+			name:          strings.ToUpper(contract.Name),
+			isin:          strings.ToUpper(contract.Isin),
+		}
+		if contract.Strike > 0 {
+			entry.strike = strconv.Itoa(contract.Strike)
+		}
+		entry.text = searchableText(entry.tradingSymbol) + " " + searchableText(entry.symbol) + " " + searchableText(entry.name)
+		index.contracts = append(index.contracts, entry)
+	}
+
+	// a stable order in place of instIdentifier: cash before derivatives, then
+	// shorter symbols and nearer contracts first
+	sort.Slice(index.contracts, func(i, j int) bool {
+		a, b := index.contracts[i], index.contracts[j]
+		if exchangeOrder[a.exchange] != exchangeOrder[b.exchange] {
+			return exchangeOrder[a.exchange] < exchangeOrder[b.exchange]
+		}
+		if len(a.symbol) != len(b.symbol) {
+			return len(a.symbol) < len(b.symbol)
+		}
+		if a.tradingSymbol != b.tradingSymbol {
+			return a.tradingSymbol < b.tradingSymbol
+		}
+		return a.key < b.key
+	})
+
+	for i, contract := range index.contracts {
+		idx := int32(i)
+		seen := make(map[string]bool)
+		for _, field := range []string{contract.tradingSymbol, contract.symbol, contract.name, contract.isin} {
+			for _, term := range append(strings.FieldsFunc(field, notAlphaNumeric), searchableText(field)) {
+				if term != "" && !seen[term] {
+					seen[term] = true
+					index.terms = append(index.terms, localTerm{term: term, idx: idx})
+				}
+			}
+		}
+		if contract.strike != "" {
+			index.strikes = append(index.strikes, localTerm{term: contract.strike, idx: idx})
+		}
+		for trigram := range trigramsOf(contract.text) {
+			index.trigrams[trigram] = append(index.trigrams[trigram], idx)
+		}
+	}
+	sortTerms(index.terms)
+	sortTerms(index.strikes)
+	return index
+}
+
+func sortTerms(terms []localTerm) {
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].term != terms[j].term {
+			return terms[i].term < terms[j].term
+		}
+		return terms[i].idx < terms[j].idx
+	})
+}
+
+func notAlphaNumeric(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// searchableText drops what SearchScrip strips from queries so "state bank"
+// typed as statebank still matches.
+func searchableText(value string) string {
+	value = strings.ReplaceAll(value, "&", "AND")
+	return strings.Map(func(r rune) rune {
+		if notAlphaNumeric(r) {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+func trigramsOf(text string) map[string]bool {
+	trigrams := make(map[string]bool)
+	for _, word := range strings.Fields(text) {
+		for i := 0; i+3 <= len(word); i++ {
+			trigrams[word[i:i+3]] = true
+		}
+	}
+	return trigrams
+}
+
+// prefixMatches returns contract positions with a term starting with prefix,
+// in index order.
+func prefixMatches(terms []localTerm, prefix string) []int32 {
+	start := sort.Search(len(terms), func(i int) bool { return terms[i].term >= prefix })
+	seen := make(map[int32]bool)
+	var matches []int32
+	for i := start; i < len(terms) && strings.HasPrefix(terms[i].term, prefix); i++ {
+		if !seen[terms[i].idx] {
+			seen[terms[i].idx] = true
+			matches = append(matches, terms[i].idx)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i] < matches[j] })
+	return matches
+}
+
+// infixMatches narrows candidates with the rarest trigram before checking
+// the substring, short terms fall back to a scan.
+func (index *localSearchIndex) infixMatches(term string) []int32 {
+	var matches []int32
+	if len(term) < 3 {
+		for i, contract := range index.contracts {
+			if strings.Contains(contract.text, term) || strings.Contains(contract.isin, term) {
+				matches = append(matches, int32(i))
+			}
+		}
+		return matches
+	}
+
+	var postings []int32
+	for trigram := range trigramsOf(term) {
+		list, ok := index.trigrams[trigram]
+		if !ok {
+			return nil
+		}
+		if postings == nil || len(list) < len(postings) {
+			postings = list
+		}
+	}
+	for _, idx := range postings {
+		contract := index.contracts[idx]
+		if strings.Contains(contract.text, term) || strings.Contains(contract.isin, term) {
+			matches = append(matches, idx)
+		}
+	}
+	return matches
+}
+
+// fuzzyMatches ranks contracts on the exchange by shared trigrams and keeps
+// those sharing at least half of the term's.
+func (index *localSearchIndex) fuzzyMatches(exchange, term string) []int32 {
+	termTrigrams := trigramsOf(term)
+	if len(termTrigrams) == 0 {
+		return nil
+	}
+	shared := make(map[int32]int)
+	for trigram := range termTrigrams {
+		for _, idx := range index.trigrams[trigram] {
+			if index.contracts[idx].exchange == exchange {
+				shared[idx]++
+			}
+		}
+	}
+	var matches []int32
+	for idx, count := range shared {
+		if count*2 >= len(termTrigrams) {
+			matches = append(matches, idx)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if shared[matches[i]] != shared[matches[j]] {
+			return shared[matches[i]] > shared[matches[j]]
+		}
+		return matches[i] < matches[j]
+	})
+	return matches
+}
+
+func (index *localSearchIndex) filterExchange(matches []int32, exchange string) []int32 {
+	if exchange == "" {
+		return matches
+	}
+	filtered := matches[:0:0]
+	for _, idx := range matches {
+		if index.contracts[idx].exchange == exchange {
+			filtered = append(filtered, idx)
+		}
+	}
+	return filtered
+}
+
+func (index *localSearchIndex) page(matches []int32, offset, limit int) []cache.SearchHit {
+	hits := make([]cache.SearchHit, 0)
+	if offset >= len(matches) || limit <= 0 {
+		return hits
+	}
+	end := offset + limit
+	if end > len(matches) {
+		end = len(matches)
+	}
+	for _, idx := range matches[offset:end] {
+		hits = append(hits, cache.SearchHit{StockKey: index.contracts[idx].key})
+	}
+	return hits
+}
+
+// PerformScoredSearch answers the same queries as the RediSearch client:
+// prefix matches followed by infix matches, strike lookup for numbers and
+// fuzzy matching over NSE and BSE. There is no volume, hits score 0 on it.
+func (index *localSearchIndex) PerformScoredSearch(exchange, searchTerm string, offset, capacity int, fuzzy bool) ([]cache.SearchHit, error) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	if len(index.contracts) == 0 {
+		return nil, errLocalIndexEmpty
+	}
+	term := strings.ToUpper(searchTerm)
+	exchange = strings.ToUpper(exchange)
+
+	if exchange == "" && fuzzy {
+		hits := index.page(index.fuzzyMatches(strings.ToUpper(constants.NSE), term), offset, capacity)
+		return append(hits, index.page(index.fuzzyMatches(strings.ToUpper(constants.BSE), term), offset, capacity)...), nil
+	}
+
+	if exchange == "" && isNumeric(term) {
+		return index.page(prefixMatches(index.strikes, term), offset, capacity), nil
+	}
+
+	// prefix matches rank ahead of infix ones, pages run across both
+	matches := index.filterExchange(prefixMatches(index.terms, term), exchange)
+	if len(matches) < offset+capacity {
+		seen := make(map[int32]bool, len(matches))
+		for _, idx := range matches {
+			seen[idx] = true
+		}
+		for _, idx := range index.filterExchange(index.infixMatches(term), exchange) {
+			if !seen[idx] {
+				matches = append(matches, idx)
+			}
+		}
+	}
+	return index.page(matches, offset, capacity), nil
+}
+
+func isNumeric(value string) bool {
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+func (index *localSearchIndex) size() (int, time.Time) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	return len(index.contracts), index.refreshedAt
+}
+
+func (index *localSearchIndex) replace(fresh *localSearchIndex) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.contracts = fresh.contracts
+	index.terms = fresh.terms
+	index.strikes = fresh.strikes
+	index.trigrams = fresh.trigrams
+	index.refreshedAt = fresh.refreshedAt
+}
+
+// RefreshLocalSearchIndex rebuilds the fallback index from the contract master
+// now and then every SearchLocalIndexRefreshMins. A failed refresh keeps the
+// previous snapshot.
+func RefreshLocalSearchIndex(contractCacheCli cache.ContractCache) {
+	defer models.HandlePanic()
+	for {
+		if err := refreshLocalSearchIndex(contractCacheCli); err != nil {
+			loggerconfig.Error("Alert Severity:P2-Mid, RefreshLocalSearchIndex failed err:", err)
+		}
+		time.Sleep(constants.SearchLocalIndexRefreshMins * time.Minute)
+	}
+}
+
+func refreshLocalSearchIndex(contractCacheCli cache.ContractCache) error {
+	stockKeys, err := contractCacheCli.GetAllFromHashWithPipeline("stock_key", 1000)
+	if err != nil {
+		return err
+	}
+	fresh := buildLocalIndex(stockKeys, searchNow())
+	localIndex.replace(fresh)
+	loggerconfig.Info("RefreshLocalSearchIndex built local index with contracts:", len(fresh.contracts), " terms:", len(fresh.terms))
+	return nil
+}
+
+// scoredSearch queries RediSearch and fails over to the local index while it
+// is down. After a failure RediSearch is skipped for SearchFailoverCooldownSecs
+// so users are not held up by its timeouts.
+func (obj SearchScriptV2) scoredSearch(exchange, searchTerm string, offset, capacity int, fuzzy bool, reqH models.ReqHeader) ([]cache.SearchHit, error) {
+	if searchNow().Unix() >= smartCacheDownUntil.Load() {
+		hits, err := obj.smartCacheCli.PerformScoredSearch(exchange, searchTerm, offset, capacity, fuzzy)
+		if err == nil {
+			if servingLocalIndex.Swap(false) {
+				loggerconfig.Info("SearchScrip2: RediSearch recovered, serving search from it again")
+			}
+			return hits, nil
+		}
+		loggerconfig.Error("Alert Severity:P1-High, platform:", reqH.Platform, " SearchScrip2: RediSearch failed, failing over to local index, query:", searchTerm, " requestId:", reqH.RequestId, " err:", err)
+		smartCacheDownUntil.Store(searchNow().Add(constants.SearchFailoverCooldownSecs * time.Second).Unix())
+	}
+
+	hits, err := localIndex.PerformScoredSearch(exchange, searchTerm, offset, capacity, fuzzy)
+	if err != nil {
+		return hits, err
+	}
+	servingLocalIndex.Store(true)
+	return hits, nil
+}
+
+// SearchBackendStatus reports which index is answering scrip searches.
+func SearchBackendStatus() models.SearchBackendStatus {
+	contracts, refreshedAt := localIndex.size()
+	status := models.SearchBackendStatus{
+		Backend:            constants.SearchBackendRediSearch,
+		LocalIndexSize:     contracts,
+		LocalIndexBuiltAt:  refreshedAt.Unix(),
+		RediSearchDownTill: smartCacheDownUntil.Load(),
+	}
+	if refreshedAt.IsZero() {
+		status.LocalIndexBuiltAt = 0
+	}
+	if servingLocalIndex.Load() {
+		status.Backend = constants.SearchBackendLocal
+	}
+	return status
+}
@@ -0,0 +1,142 @@
+package searchscriptv2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func stockKeyFixture() map[string]string {
+	contracts := map[string]models.ContractDetails{
+		"NSE_3045":   {Exchange: "NSE", Token1: "3045", Symbol: "SBIN", TradingSymbol: "SBIN-EQ", Isin: "INE062A01020", Name: "STATE BANK OF INDIA"},
+		"BSE_500112": {Exchange: "BSE", Token1: "500112", Symbol: "SBIN", TradingSymbol: "SBIN", Isin: "INE062A01020", Name: "STATE BANK OF INDIA"},
+		"NSE_4306":   {Exchange: "NSE", Token1: "4306", Symbol: "SBICARD", TradingSymbol: "SBICARD-EQ", Isin: "INE018E01016", Name: "SBI CARDS AND PAYMENT"},
+		"NSE_21808":  {Exchange: "NSE", Token1: "21808", Symbol: "SBILIFE", TradingSymbol: "SBILIFE-EQ", Isin: "INE123W01016", Name: "SBI LIFE INSURANCE"},
+		"NSE_1333":   {Exchange: "NSE", Token1: "1333", Symbol: "HDFCBANK", TradingSymbol: "HDFCBANK-EQ", Isin: "INE040A01034", Name: "HDFC BANK"},
+		"NFO_35012":  {Exchange: "NFO", Token1: "35012", Symbol: "NIFTY", TradingSymbol: "NIFTY24DEC24000CE", Strike: 24000},
+	}
+	stockKeys := make(map[string]string)
+	for key, contract := range contracts {
+		raw, _ := json.Marshal(contract)
+		stockKeys[key] = string(raw)
+	}
+	stockKeys["NSE_BAD"] = "{not json"
+	return stockKeys
+}
+
+func hitKeys(hits []cache.SearchHit) []string {
+	keys := []string{}
+	for _, hit := range hits {
+		keys = append(keys, hit.StockKey)
+	}
+	return keys
+}
+
+func TestLocalSearchIndex(t *testing.T) {
+	index := buildLocalIndex(stockKeyFixture(), time.Now())
+	assert.Len(t, index.contracts, 6)
+
+	hits, err := index.PerformScoredSearch("NSE", "sbi", 0, 20, false)
+	assert.NoError(t, err)
+	// shorter symbols first
+	assert.Equal(t, []string{"NSE_3045", "NSE_4306", "NSE_21808"}, hitKeys(hits))
+
+	hits, _ = index.PerformScoredSearch("NSE", "sbi", 1, 1, false)
+	assert.Equal(t, []string{"NSE_4306"}, hitKeys(hits))
+
+	hits, _ = index.PerformScoredSearch("BSE", "statebank", 0, 1, false)
+	assert.Equal(t, []string{"BSE_500112"}, hitKeys(hits))
+
+	hits, _ = index.PerformScoredSearch("", "INE040", 0, 20, false)
+	assert.Equal(t, "NSE_1333", hitKeys(hits)[0])
+
+	hits, _ = index.PerformScoredSearch("", "LIFE", 0, 20, false)
+	assert.Equal(t, []string{"NSE_21808"}, hitKeys(hits))
+
+	hits, _ = index.PerformScoredSearch("", "24000", 0, 20, false)
+	assert.Equal(t, []string{"NFO_35012"}, hitKeys(hits))
+
+	// infix only, no term starts with it
+	hits, _ = index.PerformScoredSearch("NFO", "DEC24000", 0, 20, false)
+	assert.Equal(t, []string{"NFO_35012"}, hitKeys(hits))
+
+	hits, _ = index.PerformScoredSearch("", "HDFCBNK", 0, 20, true)
+	assert.Equal(t, []string{"NSE_1333"}, hitKeys(hits))
+
+	_, err = (&localSearchIndex{}).PerformScoredSearch("", "SBI", 0, 20, false)
+	assert.Equal(t, errLocalIndexEmpty, err)
+}
+
+func TestSearchFailover(t *testing.T) {
+	origNow, origInfo, origError := searchNow, loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		searchNow, loggerconfig.Info, loggerconfig.Error = origNow, origInfo, origError
+		localIndex.replace(&localSearchIndex{})
+		smartCacheDownUntil.Store(0)
+		servingLocalIndex.Store(false)
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+	now := time.Date(2024, time.November, 20, 10, 0, 0, 0, time.UTC)
+	searchNow = func() time.Time { return now }
+	localIndex.replace(buildLocalIndex(stockKeyFixture(), now))
+
+	smart, contracts := searchFixture()
+	obj := InitSearchScript(contracts, smart, nil)
+
+	code, res := obj.SearchScrip("BOOM", models.ReqHeader{}, 0, 20, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, res.Data)
+	assert.Equal(t, constants.SearchBackendLocal, SearchBackendStatus().Backend)
+	assert.Equal(t, 6, SearchBackendStatus().LocalIndexSize)
+
+	// within the cooldown RediSearch is not tried at all
+	smart.searched = nil
+	code, res = obj.SearchScrip("HDFC", models.ReqHeader{}, 0, 20, "NSE")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, smart.searched)
+	assert.Equal(t, []string{"HDFCBANK"}, resultSymbols(res.Data))
+
+	now = now.Add(constants.SearchFailoverCooldownSecs * time.Second)
+	_, _ = obj.SearchScrip("TATA", models.ReqHeader{}, 0, 20, "")
+	assert.Equal(t, []string{"|TATA"}, smart.searched)
+	assert.Equal(t, constants.SearchBackendRediSearch, SearchBackendStatus().Backend)
+}
+
+func TestRefreshLocalSearchIndex(t *testing.T) {
+	origInfo := loggerconfig.Info
+	t.Cleanup(func() {
+		loggerconfig.Info = origInfo
+		localIndex.replace(&localSearchIndex{})
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+
+	assert.Error(t, refreshLocalSearchIndex(fakeStockKeyCache{err: errors.New("redis down")}))
+	assert.NoError(t, refreshLocalSearchIndex(fakeStockKeyCache{stockKeys: stockKeyFixture()}))
+	size, _ := localIndex.size()
+	assert.Equal(t, 6, size)
+
+	// a failed refresh keeps the last snapshot
+	assert.Error(t, refreshLocalSearchIndex(fakeStockKeyCache{err: errors.New("redis down")}))
+	size, _ = localIndex.size()
+	assert.Equal(t, 6, size)
+}
+
+type fakeStockKeyCache struct {
+	cache.ContractCache
+	stockKeys map[string]string
+	err       error
+}
+
+func (f fakeStockKeyCache) GetAllFromHashWithPipeline(hash string, batchSize int64) (map[string]string, error) {
+	return f.stockKeys, f.err
+}
@@ -0,0 +1,172 @@
+package searchscriptv2
+
+import (
+	"fmt"
+	"space/constants"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fnoQuery is a free text derivative search such as "nifty 24000 ce dec".
+type fnoQuery struct {
+	underlying string
+	strike     string
+	instrument string // CE, PE or FUT
+	month      time.Month
+	day        int
+}
+
+var fnoInstrumentWords = map[string]string{
+	"CE":      "CE",
+	"CALL":    "CE",
+	"CALLS":   "CE",
+	"PE":      "PE",
+	"PUT":     "PE",
+	"PUTS":    "PE",
+	"FUT":     "FUT",
+	"FUTS":    "FUT",
+	"FUTURE":  "FUT",
+	"FUTURES": "FUT",
+}
+
+var fnoMonthWords = map[string]time.Month{
+	"JAN": time.January, "JANUARY": time.January,
+	"FEB": time.February, "FEBRUARY": time.February,
+	"MAR": time.March, "MARCH": time.March,
+	"APR": time.April, "APRIL": time.April,
+	"MAY": time.May,
+	"JUN": time.June, "JUNE": time.June,
+	"JUL": time.July, "JULY": time.July,
+	"AUG": time.August, "AUGUST": time.August,
+	"SEP": time.September, "SEPT": time.September, "SEPTEMBER": time.September,
+	"OCT": time.October, "OCTOBER": time.October,
+	"NOV": time.November, "NOVEMBER": time.November,
+	"DEC": time.December, "DECEMBER": time.December,
+}
+
+// parseFnoQuery picks the underlying, strike, option type and expiry out of a
+// space separated query. Queries without CE, PE or FUT are not derivative searches.
+func parseFnoQuery(raw string) (fnoQuery, bool) {
+	var q fnoQuery
+	var numbers []float64
+
+	words := strings.Fields(strings.ToUpper(raw))
+	if len(words) < 2 {
+		return q, false
+	}
+
+	for _, word := range words {
+		if instrument, ok := fnoInstrumentWords[word]; ok {
+			q.instrument = instrument
+			continue
+		}
+		if month, ok := fnoMonthWords[word]; ok {
+			q.month = month
+			continue
+		}
+		if number, err := strconv.ParseFloat(word, 64); err == nil {
+			numbers = append(numbers, number)
+			continue
+		}
+		// "bank nifty" is typed as two words
+		q.underlying += word
+	}
+
+	if q.underlying == "" || q.instrument == "" {
+		return q, false
+	}
+	if synonym, ok := constants.SearchSynonyms[q.underlying]; ok {
+		q.underlying = synonym
+	}
+
+	if q.instrument == "FUT" {
+		return q, true
+	}
+
+	switch len(numbers) {
+	case 1:
+		q.strike = formatStrike(numbers[0])
+	case 2:
+		// with an expiry month, the small number is the weekly expiry day
+		strike, day := numbers[0], numbers[1]
+		if strike < day {
+			strike, day = day, strike
+		}
+		if q.month == 0 || day < 1 || day > 31 || day != float64(int(day)) {
+			return q, false
+		}
+		q.strike = formatStrike(strike)
+		q.day = int(day)
+	default:
+		return q, false
+	}
+	return q, true
+}
+
+func formatStrike(strike float64) string {
+	return strconv.FormatFloat(strike, 'f', -1, 64)
+}
+
+// fnoExchange is the derivative segment the underlying trades on, unless the
+// caller already narrowed the search to one.
+func fnoExchange(q fnoQuery, exchange string) string {
+	switch exchange {
+	case "NFO", "BFO", "MCX", "CDS":
+		return exchange
+	}
+	if constants.SearchBseDerivatives[q.underlying] {
+		return "BFO"
+	}
+	if _, ok := constants.CommodityMap[q.underlying]; ok {
+		return "MCX"
+	}
+	return "NFO"
+}
+
+// fnoTradingSymbols builds the trading symbol prefixes the query can refer to,
+// e.g. NIFTY24DEC24000CE for the monthly and NIFTY24D1224000CE for a weekly.
+// Without a month the next few monthly expiries are tried in order.
+func fnoTradingSymbols(q fnoQuery, now time.Time) []string {
+	var expiries []time.Time
+	if q.month != 0 {
+		year := now.Year()
+		if q.month < now.Month() {
+			year++
+		}
+		expiries = append(expiries, time.Date(year, q.month, 1, 0, 0, 0, 0, now.Location()))
+	} else {
+		first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		for i := 0; i < constants.SearchFnoLookaheadMonths; i++ {
+			expiries = append(expiries, first.AddDate(0, i, 0))
+		}
+	}
+
+	var symbols []string
+	for _, expiry := range expiries {
+		yy := fmt.Sprintf("%02d", expiry.Year()%100)
+		month := strings.ToUpper(expiry.Month().String()[:3])
+		if q.instrument == "FUT" {
+			symbols = append(symbols, q.underlying+yy+month+"FUT")
+			continue
+		}
+		if q.day > 0 {
+			symbols = append(symbols, q.underlying+yy+weeklyMonthCode(expiry.Month())+fmt.Sprintf("%02d", q.day)+q.strike+q.instrument)
+		}
+		symbols = append(symbols, q.underlying+yy+month+q.strike+q.instrument)
+	}
+	return symbols
+}
+
+// weeklyMonthCode is the inverse of getMonthName.
+func weeklyMonthCode(month time.Month) string {
+	switch month {
+	case time.October:
+		return "O"
+	case time.November:
+		return "N"
+	case time.December:
+		return "D"
+	}
+	return strconv.Itoa(int(month))
+}
@@ -0,0 +1,379 @@
+package searchscriptv2
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/business/tradelab"
+	"space/constants"
+	"space/dbops"
+	"space/helpers"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// searchCandidate is a stocks index hit before ranking.
+type searchCandidate struct {
+	key       string
+	volume    float64
+	relevance float64 // 1 for the first index hit, falling to 0 for the last
+	fno       bool
+}
+
+// searchSignals are the per client boosts, all keyed by stock key or isin.
+type searchSignals struct {
+	holdingIsins   map[string]bool
+	positionKeys   map[string]bool
+	watchlistKeys  map[string]bool
+	watchlistIsins map[string]bool
+	clicks         map[string]float64
+	queries        map[string]float64
+}
+
+var searchNow = func() time.Time {
+	return helpers.GetCurrentTimeInIST()
+}
+
+// normaliseSearchTerm strips what SearchScrip strips so recorded queries and
+// synonyms compare equal to what was actually searched.
+func normaliseSearchTerm(term string) string {
+	term = strings.ToUpper(strings.TrimSpace(term))
+	term = strings.ReplaceAll(term, " ", "")
+	term = strings.ReplaceAll(term, "-", "")
+	term = strings.ReplaceAll(term, "&", "AND")
+	term = strings.ReplaceAll(term, ".", "")
+	return term
+}
+
+// candidatesFromHits keeps the index order as a relevance signal and drops
+// keys already seen in an earlier, better matching search.
+func candidatesFromHits(candidates []searchCandidate, seen map[string]bool, hits []cache.SearchHit, fno bool) []searchCandidate {
+	for i, hit := range hits {
+		if hit.StockKey == "" || seen[hit.StockKey] {
+			continue
+		}
+		seen[hit.StockKey] = true
+		relevance := 1.0
+		if len(hits) > 1 {
+			relevance = 1 - float64(i)/float64(len(hits)-1)
+		}
+		candidates = append(candidates, searchCandidate{key: hit.StockKey, volume: hit.Volume, relevance: relevance, fno: fno})
+	}
+	return candidates
+}
+
+func signalsFromAffinity(affinity models.SearchAffinity, recent models.SearchRecent, now time.Time) searchSignals {
+	signals := searchSignals{
+		holdingIsins:   toSet(affinity.HoldingIsins),
+		positionKeys:   toSet(affinity.PositionKeys),
+		watchlistKeys:  toSet(affinity.WatchlistKeys),
+		watchlistIsins: toSet(affinity.WatchlistIsins),
+		clicks:         make(map[string]float64),
+		queries:        make(map[string]float64),
+	}
+	for _, click := range recent.Clicks {
+		signals.clicks[click.Key] = decayedCount(click, now)
+	}
+	for _, query := range recent.Queries {
+		signals.queries[query.Key] = decayedCount(query, now)
+	}
+	return signals
+}
+
+// decayedCount halves the weight of an entry every SearchRecentHalfLifeDays.
+func decayedCount(entry models.SearchRecentEntry, now time.Time) float64 {
+	ageDays := now.Sub(time.Unix(entry.LastAt, 0)).Hours() / 24
+	if ageDays < 0 {
+		ageDays = 0
+	}
+	return float64(entry.Count) * math.Pow(0.5, ageDays/constants.SearchRecentHalfLifeDays)
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// scoreResult adds up the boosts for one result. Each boost is capped so no
+// single signal can bury an exact symbol match.
+func scoreResult(result models.SearchScripResponseResult, candidate searchCandidate, maxVolume float64, terms []string, signals searchSignals) float64 {
+	score := constants.SearchWeightRelevance * candidate.relevance
+
+	for _, term := range terms {
+		if strings.EqualFold(result.Symbol, term) || strings.EqualFold(result.TradingSymbol, term) {
+			score += constants.SearchWeightExactSymbol
+			break
+		}
+	}
+	if maxVolume > 0 && candidate.volume > 0 {
+		score += constants.SearchWeightVolume * math.Log1p(candidate.volume) / math.Log1p(maxVolume)
+	}
+	if result.Isin != "" && signals.holdingIsins[result.Isin] && result.Segment == constants.SegmentEquity {
+		score += constants.SearchWeightHolding
+	}
+	if signals.positionKeys[candidate.key] {
+		score += constants.SearchWeightPosition
+	}
+	if signals.watchlistKeys[candidate.key] || (result.Isin != "" && signals.watchlistIsins[result.Isin] && result.Segment == constants.SegmentEquity) {
+		score += constants.SearchWeightWatchlist
+	}
+	if clicks := signals.clicks[candidate.key]; clicks > 0 {
+		score += constants.SearchWeightClick * math.Min(1, clicks)
+	}
+	if queries := signals.queries[normaliseSearchTerm(result.Symbol)]; queries > 0 {
+		score += constants.SearchWeightRecentQuery * math.Min(1, queries)
+	}
+	if candidate.fno {
+		score += constants.SearchWeightFnoContract
+	}
+	return math.Round(score*10000) / 10000
+}
+
+// rankResults scores results in place and orders them best first. Ties keep
+// the index order.
+func rankResults(results []models.SearchScripResponseResult, candidates []searchCandidate, terms []string, signals searchSignals) {
+	var maxVolume float64
+	for _, candidate := range candidates {
+		maxVolume = math.Max(maxVolume, candidate.volume)
+	}
+	for i := range results {
+		results[i].Score = scoreResult(results[i], candidates[i], maxVolume, terms, signals)
+	}
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return results[order[a]].Score > results[order[b]].Score
+	})
+	ranked := make([]models.SearchScripResponseResult, len(results))
+	for i, idx := range order {
+		ranked[i] = results[idx]
+	}
+	copy(results, ranked)
+}
+
+// fetchSearchSignals loads the client's boosts. Anonymous searches and any
+// lookup failure fall back to unpersonalised ranking.
+func (obj SearchScriptV2) fetchSearchSignals(reqH models.ReqHeader) searchSignals {
+	var affinity models.SearchAffinity
+	var recent models.SearchRecent
+	if reqH.ClientId == "" {
+		return signalsFromAffinity(affinity, recent, searchNow())
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		var err error
+		if affinity, err = CallFetchSearchAffinity(obj, reqH); err != nil {
+			loggerconfig.Error("SearchScrip2: fetchSearchSignals affinity err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		var err error
+		if recent, err = obj.fetchSearchRecent(reqH.ClientId); err != nil {
+			loggerconfig.Error("SearchScrip2: fetchSearchSignals recent err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		}
+	}()
+	wg.Wait()
+	return signalsFromAffinity(affinity, recent, searchNow())
+}
+
+var CallFetchSearchAffinity = func(obj SearchScriptV2, reqH models.ReqHeader) (models.SearchAffinity, error) {
+	return obj.FetchSearchAffinity(reqH)
+}
+
+// FetchSearchAffinity collects the client's holdings, positions and watchlist
+// instruments, cached for SearchAffinityTTL. A failing source is skipped.
+func (obj SearchScriptV2) FetchSearchAffinity(reqH models.ReqHeader) (models.SearchAffinity, error) {
+	var affinity models.SearchAffinity
+	key := constants.SearchAffinityKey + reqH.ClientId
+
+	if obj.redisCli != nil {
+		cached, err := obj.redisCli.GetRedis(key).Result()
+		if err == nil && cached != "" {
+			if err = json.Unmarshal([]byte(cached), &affinity); err == nil {
+				return affinity, nil
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		affinity.HoldingIsins = CallFetchSearchHoldings(reqH)
+	}()
+	go func() {
+		defer wg.Done()
+		affinity.PositionKeys = CallFetchSearchPositions(reqH)
+	}()
+	go func() {
+		defer wg.Done()
+		affinity.WatchlistKeys, affinity.WatchlistIsins = CallFetchSearchWatchlists(reqH)
+	}()
+	wg.Wait()
+
+	if obj.redisCli != nil {
+		raw, err := json.Marshal(affinity)
+		if err == nil {
+			err = obj.redisCli.SetRedis(key, string(raw), constants.SearchAffinityTTL)
+		}
+		if err != nil {
+			loggerconfig.Error("SearchScrip2: FetchSearchAffinity unable to cache affinity err:", err, " clientId:", reqH.ClientId)
+		}
+	}
+	return affinity, nil
+}
+
+var CallFetchSearchHoldings = func(reqH models.ReqHeader) []string {
+	var isins []string
+	status, res := tradelab.InitPortfolio().FetchDematHoldings(models.FetchDematHoldingsRequest{ClientID: reqH.ClientId}, reqH)
+	if status != http.StatusOK {
+		loggerconfig.Error("SearchScrip2: FetchDematHoldings status:", status, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return isins
+	}
+	holdings, ok := res.Data.(models.FetchDematHoldingsResponse)
+	if !ok {
+		return isins
+	}
+	for _, holding := range holdings.Holdings {
+		if holding.Isin != "" {
+			isins = append(isins, holding.Isin)
+		}
+	}
+	return isins
+}
+
+var CallFetchSearchPositions = func(reqH models.ReqHeader) []string {
+	var keys []string
+	status, res := tradelab.GetPositionsInternal(models.GetPositionRequest{ClientID: reqH.ClientId, Type: constants.Historical}, reqH)
+	if status != http.StatusOK {
+		loggerconfig.Error("SearchScrip2: GetPositions status:", status, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return keys
+	}
+	positions, ok := res.Data.([]models.GetPositionResponseData)
+	if !ok {
+		return keys
+	}
+	for _, position := range positions {
+		if position.NetQuantity != 0 {
+			keys = append(keys, stockKey(position.Exchange, position.Token))
+		}
+	}
+	return keys
+}
+
+var CallFetchSearchWatchlists = func(reqH models.ReqHeader) ([]string, []string) {
+	var keys, isins []string
+	var stockLists models.MongoNewWatchListsV2
+	err := dbops.MongoRepo.FindOne(constants.WATCHLISTSTOCKSCOLLECTIONNEW, bson.M{"clientId": reqH.ClientId}, &stockLists)
+	if err != nil {
+		if err.Error() != constants.MongoNoDocError {
+			loggerconfig.Error("SearchScrip2: watchlists mongo err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		}
+		return keys, isins
+	}
+	for _, list := range [][]models.StockDetailsV2{stockLists.WatchList1, stockLists.WatchList2, stockLists.WatchList3, stockLists.WatchList4, stockLists.WatchList5} {
+		for _, stock := range list {
+			if stock.Isin != "" {
+				isins = append(isins, stock.Isin)
+				continue
+			}
+			keys = append(keys, strings.ToUpper(stock.Exchange)+"_"+stock.Token)
+		}
+	}
+	return keys, isins
+}
+
+func stockKey(exchange string, token int) string {
+	return strings.ToUpper(exchange) + "_" + strconv.Itoa(token)
+}
+
+func (obj SearchScriptV2) fetchSearchRecent(clientId string) (models.SearchRecent, error) {
+	var recent models.SearchRecent
+	if obj.redisCli == nil {
+		return recent, nil
+	}
+	cached, err := obj.redisCli.GetRedis(constants.SearchRecentKey + clientId).Result()
+	if err != nil || cached == "" {
+		// a client without history has no key
+		return recent, nil
+	}
+	err = json.Unmarshal([]byte(cached), &recent)
+	return recent, err
+}
+
+// recordSearchActivity bumps a query or click in the client's recent history.
+// It is best effort, concurrent updates may drop a count.
+func (obj SearchScriptV2) recordSearchActivity(clientId, query, clickedKey string) error {
+	if obj.redisCli == nil || clientId == "" {
+		return nil
+	}
+	recent, err := obj.fetchSearchRecent(clientId)
+	if err != nil {
+		recent = models.SearchRecent{}
+	}
+	now := searchNow().Unix()
+	if query != "" {
+		recent.Queries = bumpRecent(recent.Queries, normaliseSearchTerm(query), now, constants.SearchRecentMaxQueries)
+	}
+	if clickedKey != "" {
+		recent.Clicks = bumpRecent(recent.Clicks, clickedKey, now, constants.SearchRecentMaxClicks)
+	}
+	raw, err := json.Marshal(recent)
+	if err != nil {
+		return err
+	}
+	return obj.redisCli.SetRedis(constants.SearchRecentKey+clientId, string(raw), constants.SearchRecentTTL)
+}
+
+// bumpRecent moves key to the front with one more count and trims to limit.
+func bumpRecent(entries []models.SearchRecentEntry, key string, now int64, limit int) []models.SearchRecentEntry {
+	entry := models.SearchRecentEntry{Key: key, Count: 1, LastAt: now}
+	updated := []models.SearchRecentEntry{}
+	for _, existing := range entries {
+		if existing.Key == key {
+			entry.Count = existing.Count + 1
+			continue
+		}
+		updated = append(updated, existing)
+	}
+	updated = append([]models.SearchRecentEntry{entry}, updated...)
+	if len(updated) > limit {
+		updated = updated[:limit]
+	}
+	return updated
+}
+
+// RecordSearchClick stores the result a client picked so it ranks higher next time.
+func (obj SearchScriptV2) RecordSearchClick(req models.SearchClickReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	key := strings.ToUpper(req.Exchange) + "_" + req.Token
+	if err := obj.recordSearchActivity(reqH.ClientId, req.Query, key); err != nil {
+		loggerconfig.Error("RecordSearchClick redis err:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	loggerconfig.Info("RecordSearchClick Successful, stockKey:", key, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
@@ -0,0 +1,186 @@
+package searchscriptv2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSmartCache struct {
+	cache.SmartCache
+	hits     map[string][]cache.SearchHit // keyed by exchange|term
+	searched []string
+}
+
+func (f *fakeSmartCache) PerformScoredSearch(exchange, searchTerm string, offset, capacity int, fuzzy bool) ([]cache.SearchHit, error) {
+	f.searched = append(f.searched, exchange+"|"+searchTerm)
+	if searchTerm == "BOOM" {
+		return nil, errors.New("index down")
+	}
+	return f.hits[exchange+"|"+searchTerm], nil
+}
+
+type fakeContractCache struct {
+	cache.ContractCache
+	contracts map[string]models.ContractDetails
+}
+
+func (f fakeContractCache) GetFromHash(hash, key string) (error, string) {
+	contract, ok := f.contracts[key]
+	if !ok {
+		return errors.New("redis: nil"), ""
+	}
+	raw, _ := json.Marshal(contract)
+	return nil, string(raw)
+}
+
+func searchFixture() (*fakeSmartCache, fakeContractCache) {
+	smart := &fakeSmartCache{hits: map[string][]cache.SearchHit{
+		"|SBI": {
+			{StockKey: "NSE_3045", Volume: 100},
+			{StockKey: "NSE_4306", Volume: 5000000},
+			{StockKey: "NSE_21808", Volume: 10},
+		},
+		"|SBIN": {
+			{StockKey: "NSE_3045", Volume: 100},
+		},
+		"|TATA": {
+			{StockKey: "NSE_3456", Volume: 10},
+			{StockKey: "NSE_3499", Volume: 1000},
+		},
+		"NFO|NIFTY24DEC24000CE": {
+			{StockKey: "NFO_35012", Volume: 10},
+		},
+	}}
+	contracts := fakeContractCache{contracts: map[string]models.ContractDetails{
+		"NSE_3045":  {Exchange: "NSE", Token1: "3045", Symbol: "SBIN", TradingSymbol: "SBIN-EQ", Isin: "INE062A01020", Name: "STATE BANK OF INDIA"},
+		"NSE_4306":  {Exchange: "NSE", Token1: "4306", Symbol: "SBICARD", TradingSymbol: "SBICARD-EQ", Isin: "INE018E01016", Name: "SBI CARDS"},
+		"NSE_21808": {Exchange: "NSE", Token1: "21808", Symbol: "SBILIFE", TradingSymbol: "SBILIFE-EQ", Isin: "INE123W01016", Name: "SBI LIFE"},
+		"NSE_3456":  {Exchange: "NSE", Token1: "3456", Symbol: "TATAMOTORS", TradingSymbol: "TATAMOTORS-EQ", Isin: "INE155A01022"},
+		"NSE_3499":  {Exchange: "NSE", Token1: "3499", Symbol: "TATASTEEL", TradingSymbol: "TATASTEEL-EQ", Isin: "INE081A01020"},
+		"NSE_1333":  {Exchange: "NSE", Token1: "1333", Symbol: "HDFCBANK", TradingSymbol: "HDFCBANK-EQ", Isin: "INE040A01034"},
+		"NFO_35012": {Exchange: "NFO", Token1: "35012", Symbol: "NIFTY", TradingSymbol: "NIFTY24DEC24000CE", Expiry1: "2024-12-26"},
+	}}
+	return smart, contracts
+}
+
+func resultSymbols(res interface{}) []string {
+	var symbols []string
+	for _, stock := range res.([]models.SearchScripResponseResult) {
+		symbols = append(symbols, stock.Symbol)
+	}
+	return symbols
+}
+
+func TestParseFnoQuery(t *testing.T) {
+	q, ok := parseFnoQuery("nifty 24000 ce dec")
+	assert.True(t, ok)
+	assert.Equal(t, fnoQuery{underlying: "NIFTY", strike: "24000", instrument: "CE", month: time.December}, q)
+
+	q, ok = parseFnoQuery("bank nifty 12 dec 51500 put")
+	assert.True(t, ok)
+	assert.Equal(t, fnoQuery{underlying: "BANKNIFTY", strike: "51500", instrument: "PE", month: time.December, day: 12}, q)
+
+	q, ok = parseFnoQuery("ril fut")
+	assert.True(t, ok)
+	assert.Equal(t, "RELIANCE", q.underlying)
+
+	_, ok = parseFnoQuery("nifty 24000")
+	assert.False(t, ok)
+	_, ok = parseFnoQuery("nifty ce")
+	assert.False(t, ok)
+
+	now := time.Date(2024, time.November, 20, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"BANKNIFTY24D1251500PE", "BANKNIFTY24DEC51500PE"},
+		fnoTradingSymbols(fnoQuery{underlying: "BANKNIFTY", strike: "51500", instrument: "PE", month: time.December, day: 12}, now))
+	// a month already behind us is next year's
+	assert.Equal(t, []string{"NIFTY25JAN24000CE"}, fnoTradingSymbols(fnoQuery{underlying: "NIFTY", strike: "24000", instrument: "CE", month: time.January}, now))
+	assert.Equal(t, []string{"NIFTY24NOVFUT", "NIFTY24DECFUT", "NIFTY25JANFUT"}, fnoTradingSymbols(fnoQuery{underlying: "NIFTY", instrument: "FUT"}, now))
+
+	assert.Equal(t, "BFO", fnoExchange(fnoQuery{underlying: "SENSEX"}, ""))
+	assert.Equal(t, "NFO", fnoExchange(fnoQuery{underlying: "NIFTY"}, "NSE"))
+}
+
+func TestSearchScripRanking(t *testing.T) {
+	origNow, origAffinity := searchNow, CallFetchSearchAffinity
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		searchNow, CallFetchSearchAffinity = origNow, origAffinity
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+	searchNow = func() time.Time { return time.Date(2024, time.November, 20, 10, 0, 0, 0, time.UTC) }
+
+	t.Run("synonym puts the canonical symbol first", func(t *testing.T) {
+		smart, contracts := searchFixture()
+		obj := InitSearchScript(contracts, smart, nil)
+
+		code, res := obj.SearchScrip("SBI", models.ReqHeader{}, 0, 20, "")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"SBIN", "SBICARD", "SBILIFE"}, resultSymbols(res.Data))
+		assert.Equal(t, []string{"|SBIN", "|SBI"}, smart.searched)
+	})
+
+	t.Run("volume outweighs index order", func(t *testing.T) {
+		smart, contracts := searchFixture()
+		smart.hits["|TATA"][1].Volume = 900000
+		obj := InitSearchScript(contracts, smart, nil)
+
+		_, res := obj.SearchScrip("TATA", models.ReqHeader{}, 0, 20, "")
+		assert.Equal(t, []string{"TATASTEEL", "TATAMOTORS"}, resultSymbols(res.Data))
+	})
+
+	t.Run("holdings lift a client's own stocks", func(t *testing.T) {
+		smart, contracts := searchFixture()
+		smart.hits["|TATA"][1].Volume = 900000
+		obj := InitSearchScript(contracts, smart, nil)
+		CallFetchSearchAffinity = func(obj SearchScriptV2, reqH models.ReqHeader) (models.SearchAffinity, error) {
+			return models.SearchAffinity{HoldingIsins: []string{"INE155A01022"}}, nil
+		}
+
+		_, res := obj.SearchScrip("TATA", models.ReqHeader{ClientId: "CLIENT1"}, 0, 20, "")
+		stocks := res.Data.([]models.SearchScripResponseResult)
+		assert.Equal(t, "TATAMOTORS", stocks[0].Symbol)
+		assert.Greater(t, stocks[0].Score, stocks[1].Score)
+	})
+
+	t.Run("derivative query resolves the option contract", func(t *testing.T) {
+		smart, contracts := searchFixture()
+		obj := InitSearchScript(contracts, smart, nil)
+
+		code, res := obj.SearchScrip("nifty 24000 ce dec", models.ReqHeader{}, 0, 20, "")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"NIFTY"}, resultSymbols(res.Data))
+		assert.Equal(t, "NFO|NIFTY24DEC24000CE", smart.searched[0])
+	})
+
+	t.Run("index failure is a server error", func(t *testing.T) {
+		smart, contracts := searchFixture()
+		obj := InitSearchScript(contracts, smart, nil)
+
+		code, _ := obj.SearchScrip("BOOM", models.ReqHeader{}, 0, 20, "")
+		assert.Equal(t, http.StatusInternalServerError, code)
+	})
+}
+
+func TestRecentDecay(t *testing.T) {
+	now := time.Date(2024, time.November, 20, 10, 0, 0, 0, time.UTC)
+	entries := bumpRecent(nil, "NSE_3045", now.Unix(), 2)
+	entries = bumpRecent(entries, "NSE_4306", now.Unix(), 2)
+	entries = bumpRecent(entries, "NSE_3045", now.Unix(), 2)
+	assert.Equal(t, "NSE_3045", entries[0].Key)
+	assert.Equal(t, 2, entries[0].Count)
+	assert.Len(t, bumpRecent(entries, "NSE_1", now.Unix(), 2), 2)
+
+	week := models.SearchRecentEntry{Key: "NSE_3045", Count: 4, LastAt: now.AddDate(0, 0, -7).Unix()}
+	assert.InDelta(t, 2.0, decayedCount(week, now), 0.0001)
+}
@@ -21,13 +21,15 @@ import (
 type SearchScriptV2 struct {
 	contractCacheCli cache.ContractCache
 	smartCacheCli    cache.SmartCache
+	redisCli         cache.RedisCache
 }
 
-func InitSearchScript(contractCacheCli cache.ContractCache, smartCacheCli cache.SmartCache) SearchScriptV2 {
+func InitSearchScript(contractCacheCli cache.ContractCache, smartCacheCli cache.SmartCache, redisCli cache.RedisCache) SearchScriptV2 {
 	defer models.HandlePanic()
 	obj := SearchScriptV2{
 		contractCacheCli: contractCacheCli,
 		smartCacheCli:    smartCacheCli,
+		redisCli:         redisCli,
 	}
 	return obj
 }
@@ -36,6 +38,7 @@ func (obj SearchScriptV2) SearchScrip(querySearch string, reqH models.ReqHeader,
 
 	var apiRes apihelpers.APIRes
 
+	rawQuery := querySearch
 	querySearch = strings.ReplaceAll(querySearch, " ", "")
 
 	containHyphen := strings.Contains(querySearch, "-")
@@ -62,41 +65,49 @@ func (obj SearchScriptV2) SearchScrip(querySearch string, reqH models.ReqHeader,
 		return http.StatusOK, apiRes
 	}
 
-	//prefix search
-	// searchTerm := querySearch + "*"
+	candidates := make([]searchCandidate, 0, capacity)
+	seen := make(map[string]bool)
 
-	uniqueKeys, err := obj.smartCacheCli.PerformNewSearch(exchange, querySearch, offset, capacity, false)
-	if err != nil {
-		loggerconfig.Error("Alert Severity:P1-High, platform:", reqH.Platform, " SearchScrip2: Error while prefix searching script substrig v2 for query ", querySearch, " and reqId ", reqH.RequestId, " and err ", querySearch, reqH.RequestId, err)
-		return apihelpers.SendInternalServerError()
+	// derivative queries like "nifty 24000 ce dec" resolve to the contract first
+	if fno, ok := parseFnoQuery(rawQuery); ok && offset == 0 {
+		candidates = candidatesFromHits(candidates, seen, obj.searchFnoContracts(fno, exchange, capacity, reqH), true)
 	}
 
-	loggerconfig.Info(" capacity uk and capacity ", len(uniqueKeys), capacity)
-
-	loggerconfig.Info(" uniqueKeysSubstring  and capacity ", len(uniqueKeys), capacity)
+	terms := []string{querySearch}
+	if synonym, ok := constants.SearchSynonyms[strings.ToUpper(querySearch)]; ok {
+		terms = []string{synonym, querySearch}
+	}
 
-	if len(uniqueKeys) == 0 {
-		uniqueKeysFuzzy, err := obj.smartCacheCli.PerformNewSearch(exchange, querySearch, offset, capacity, true)
+	for _, term := range terms {
+		hits, err := obj.scoredSearch(exchange, term, offset, capacity, false, reqH)
 		if err != nil {
-			loggerconfig.Error("Alert Severity:P1-High, platform:", reqH.Platform, " SearchScrip2: Error while searching fuzzy script v2 for query  ", querySearch, " and reqId ", reqH.RequestId, " and err ", querySearch, reqH.RequestId, err)
+			loggerconfig.Error("Alert Severity:P1-High, platform:", reqH.Platform, " SearchScrip2: Error while prefix searching script substrig v2 for query ", term, " and reqId ", reqH.RequestId, " and err ", err)
 			return apihelpers.SendInternalServerError()
 		}
-		uniqueKeys = append(uniqueKeys, uniqueKeysFuzzy...)
+		candidates = candidatesFromHits(candidates, seen, hits, false)
 	}
 
-	loggerconfig.Info(" uniqueKeysFuzzy  and capacity ", len(uniqueKeys), capacity)
+	loggerconfig.Info(" uniqueKeysSubstring  and capacity ", len(candidates), capacity)
 
-	loggerconfig.Info("uniqueKeys", uniqueKeys)
+	if len(candidates) == 0 {
+		hitsFuzzy, err := obj.scoredSearch(exchange, querySearch, offset, capacity, true, reqH)
+		if err != nil {
+			loggerconfig.Error("Alert Severity:P1-High, platform:", reqH.Platform, " SearchScrip2: Error while searching fuzzy script v2 for query  ", querySearch, " and reqId ", reqH.RequestId, " and err ", err)
+			return apihelpers.SendInternalServerError()
+		}
+		candidates = candidatesFromHits(candidates, seen, hitsFuzzy, false)
+	}
 
-	results := removeDuplicates(uniqueKeys)
+	loggerconfig.Info(" uniqueKeysFuzzy  and capacity ", len(candidates), capacity)
 
-	if len(results) > capacity {
-		results = results[:capacity]
+	if len(candidates) > capacity {
+		candidates = candidates[:capacity]
 	}
 
 	var stocks []models.SearchScripResponseResult
 	// var stocks []models.ContractDetails
-	for _, uniqueKey := range results {
+	for _, candidate := range candidates {
+		uniqueKey := candidate.key
 		err, val := obj.contractCacheCli.GetFromHash("stock_key", uniqueKey)
 		if err != nil {
 			loggerconfig.Error("Alert Severity:P1-High, platform:", reqH.Platform, " SearchScrip2: Error while GetFromHashSetNew script v2 for query ", querySearch, " and reqId ", reqH.RequestId, " & uniqueKey-", uniqueKey, " and err ", querySearch, reqH.RequestId, uniqueKey, err)
@@ -175,6 +186,16 @@ func (obj SearchScriptV2) SearchScrip(querySearch string, reqH models.ReqHeader,
 		//stocks = append(stocks, stockDetail)
 	}
 
+	rankResults(stocks, candidates, terms, obj.fetchSearchSignals(reqH))
+
+	if reqH.ClientId != "" && offset == 0 {
+		go func() {
+			if err := obj.recordSearchActivity(reqH.ClientId, querySearch, ""); err != nil {
+				loggerconfig.Error("SearchScrip2: unable to record search for clientId:", reqH.ClientId, " requestId:", reqH.RequestId, " err:", err)
+			}
+		}()
+	}
+
 	// Return the matching stocks with metadata
 	apiRes.Data = stocks
 	apiRes.Message = "SUCCESS"
@@ -183,6 +204,25 @@ func (obj SearchScriptV2) SearchScrip(querySearch string, reqH models.ReqHeader,
 	return http.StatusOK, apiRes
 }
 
+// searchFnoContracts looks up the trading symbols a derivative query can mean,
+// nearest expiry first.
+func (obj SearchScriptV2) searchFnoContracts(fno fnoQuery, exchange string, capacity int, reqH models.ReqHeader) []cache.SearchHit {
+	var hits []cache.SearchHit
+	segment := fnoExchange(fno, exchange)
+	for _, tradingSymbol := range fnoTradingSymbols(fno, searchNow()) {
+		found, err := obj.scoredSearch(segment, tradingSymbol, 0, capacity, false, reqH)
+		if err != nil {
+			loggerconfig.Error("SearchScrip2: searchFnoContracts err:", err, " tradingSymbol:", tradingSymbol, " requestId:", reqH.RequestId)
+			continue
+		}
+		hits = append(hits, found...)
+		if len(hits) >= capacity {
+			break
+		}
+	}
+	return hits
+}
+
 func (obj SearchScriptV2) PerformSearch(exchange, searchTerm string, offset int, capacity int) ([]interface{}, error) {
 
 	var searchResults []interface{}
@@ -233,20 +273,6 @@ func (obj SearchScriptV2) PerformSearch(exchange, searchTerm string, offset int,
 	return searchResults, nil
 }
 
-func removeDuplicates(input []string) []string {
-	uniqueMap := make(map[string]bool)
-	result := []string{} // Stores the unique elements in order
-
-	for _, item := range input {
-		if _, exists := uniqueMap[item]; !exists {
-			uniqueMap[item] = true
-			result = append(result, item)
-		}
-	}
-
-	return result
-}
-
 func buildDisplayName(symbol, exchange, tradingSymbol string) string {
 	if strings.EqualFold(exchange, constants.NSE) || strings.EqualFold(exchange, constants.BSE) {
 		return tradingSymbol
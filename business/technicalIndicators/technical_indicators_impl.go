@@ -1,17 +1,14 @@
 package technicalindicators
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
-	"io"
 	"math"
 	"net/http"
 	"strconv"
 	"sync"
 
 	apihelpers "space/apiHelpers"
-	"space/business/tradelab"
+	technicalindicatorsV2 "space/business/technicalIndicatorsV2"
 	"space/constants"
 	"space/helpers"
 	"space/loggerconfig"
@@ -31,38 +28,18 @@ func InitTechnicalIndicators() TIObj {
 	return tiObj
 }
 
-var FetchChartData = func(url string, reqH models.ReqHeader) ([]models.TLChartCandleData, bool) {
-	return FetchChartDataActual(url, reqH)
+var FetchChartData = func(req models.ChartDataReq, reqH models.ReqHeader) ([]models.TLChartCandleData, bool) {
+	return FetchChartDataActual(req, reqH)
 }
 
-func FetchChartDataActual(url string, reqH models.ReqHeader) ([]models.TLChartCandleData, bool) {
+func FetchChartDataActual(req models.ChartDataReq, reqH models.ReqHeader) ([]models.TLChartCandleData, bool) {
 	candleData := make([]models.TLChartCandleData, 0)
 
-	payload := new(bytes.Buffer)
-	//call api
-	res, err := apihelpers.CallAPIFunc(http.MethodGet, url, payload, reqH.DeviceType, reqH.DeviceId, reqH.Platform, reqH.ClientPublicIP, reqH.Authorization)
+	err, tlChartDataBenchmark := technicalindicatorsV2.GetCachedChartData(req, reqH)
 	if err != nil {
-		loggerconfig.Error("Alert Severity:TLP1-High, platform:", reqH.Platform, " FetchChartData call api error =", err, " requestId:", reqH.RequestId)
+		loggerconfig.Error("Alert Severity:TLP1-High, platform:", reqH.Platform, " FetchChartData error =", err, " requestId:", reqH.RequestId)
 		return candleData, false
 	}
-	defer res.Body.Close()
-
-	body, err := io.ReadAll(res.Body)
-	tlErrorRes := tradelab.TradeLabErrorRes{}
-	err = json.Unmarshal([]byte(string(body)), &tlErrorRes)
-	if err == nil && tlErrorRes.Status == tradelab.TLERROR {
-		loggerconfig.Error("FetchChartData res error =", tlErrorRes.Message, " requestId:", reqH.RequestId)
-		return candleData, false
-	}
-
-	tlChartDataBenchmark := tradelab.TradeLabChartDataResponse{}
-	json.Unmarshal([]byte(string(body)), &tlChartDataBenchmark)
-
-	if res.StatusCode != http.StatusOK {
-		loggerconfig.Error("FetchChartData tl status not ok =", tlChartDataBenchmark.Status, " StatusCode : ", res.StatusCode, " requestId:", reqH.RequestId)
-		return candleData, false
-	}
-	loggerconfig.Info("FetchChartData TradelabResponse:=", helpers.LogStructAsJSON(tlChartDataBenchmark))
 
 	start := 0
 	for i := 0; i < len(tlChartDataBenchmark.Data.Candles); i++ {
@@ -105,9 +82,16 @@ func (obj TIObj) TechnicalIndicatorsValues(req models.TechnicalIndicatorsValuesR
 	starttime := helpers.GetCurrentTimeInIST().Unix() - constants.EIGHTYDAYS
 	endtime := helpers.GetCurrentTimeInIST().Unix()
 
-	urlChartData := obj.tradeLabURL + tradelab.Charts + "?exchange=" + req.Exchange + "&token=" + req.Token + "&candletype=" + constants.DayWise + "&starttime=" + strconv.Itoa(int(starttime)) + "&endtime=" + strconv.Itoa(int(endtime)) + "&data_duration=" + constants.DataDuration
+	reqChartData := models.ChartDataReq{
+		Exchange:     req.Exchange,
+		Token:        req.Token,
+		CandleType:   constants.DayWise,
+		StartTime:    strconv.Itoa(int(starttime)),
+		EndTime:      strconv.Itoa(int(endtime)),
+		DataDuration: constants.DataDuration,
+	}
 
-	chartData, status := FetchChartData(urlChartData, reqH)
+	chartData, status := FetchChartData(reqChartData, reqH)
 	if !status {
 		loggerconfig.Error("TechnicalIndicatorsValues FetchChartData failed, clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
 		apiRes.Message = constants.ErrorCodeMap[constants.TLChartDataFetchFailed]
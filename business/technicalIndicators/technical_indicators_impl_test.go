@@ -454,7 +454,7 @@ func TestTechnicalIndicatorsObj_TechnicalIndicatorsValues(t *testing.T) {
 	}
 
 	//mock tl candles
-	FetchChartData = func(url string, reqH models.ReqHeader) ([]models.TLChartCandleData, bool) {
+	FetchChartData = func(req models.ChartDataReq, reqH models.ReqHeader) ([]models.TLChartCandleData, bool) {
 		var candles []models.TLChartCandleData
 		return candles, true
 	}
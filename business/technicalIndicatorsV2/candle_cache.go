@@ -0,0 +1,346 @@
+package technicalindicatorsV2
+
+import (
+	"encoding/json"
+	"fmt"
+	"space/constants"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Tradelab keeps printing bars for the closing session after MarketClose, so
+// the candle cache only treats a trading day as settled after this time.
+var (
+	CandleSessionEndHour = 15
+	CandleSessionEndMin  = 30
+)
+
+// fetchChartData is the Tradelab call sitting behind the candle cache.
+var fetchChartData = GetChartData
+
+var candleFetchGroup singleflight.Group
+
+type candleCacheEntry struct {
+	Candles          [][]interface{} `json:"candles"`
+	StartTime        int64           `json:"startTime"`
+	FetchedAt        int64           `json:"fetchedAt"`
+	FetchedWhileOpen bool            `json:"fetchedWhileOpen"`
+}
+
+type indicatorCacheEntry struct {
+	Fingerprint string          `json:"fingerprint"`
+	Data        json.RawMessage `json:"data"`
+}
+
+type allIndicatorsCacheData struct {
+	Result models.AllTechnicalIndicatorsRes `json:"result"`
+	Pivots []models.PivotsValues            `json:"pivots"`
+}
+
+// GetCachedChartData serves candles from the shared Redis candle cache and only
+// asks Tradelab for the bars that closed after the cached series was fetched.
+func GetCachedChartData(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+	var chartDataResponse models.ChartDataResponse
+
+	redisCli := cache.GetRedisClientObj()
+	startTime, errStart := strconv.ParseInt(req.StartTime, 10, 64)
+	endTime, errEnd := strconv.ParseInt(req.EndTime, 10, 64)
+	if redisCli == nil || errStart != nil || errEnd != nil {
+		return fetchChartData(req, reqH)
+	}
+
+	key := candleCacheKey(req)
+	value, err, _ := candleFetchGroup.Do(key, func() (interface{}, error) {
+		return loadCandleSeries(redisCli, key, req, startTime, reqH)
+	})
+	if err != nil {
+		return err, chartDataResponse
+	}
+
+	entry := value.(candleCacheEntry)
+	if entry.StartTime > startTime {
+		// a concurrent caller loaded a shorter window, fetch ours on its own
+		entry, err = loadCandleSeries(redisCli, key, req, startTime, reqH)
+		if err != nil {
+			return err, chartDataResponse
+		}
+	}
+
+	chartDataResponse.Data.Candles = filterCandles(entry.Candles, startTime, endTime)
+	return nil, chartDataResponse
+}
+
+func loadCandleSeries(redisCli cache.RedisCache, key string, req models.ChartDataReq, startTime int64, reqH models.ReqHeader) (candleCacheEntry, error) {
+	now := getNow()
+
+	entry, found := readCandleCache(redisCli, key)
+	if found && entry.StartTime <= startTime && len(entry.Candles) > 0 {
+		if !isCandleCacheStale(entry, req, now) {
+			recordCandleCacheMetric(redisCli, "candles", constants.CandleCacheHit)
+			return entry, nil
+		}
+
+		lastBar, err := candleTime(entry.Candles[len(entry.Candles)-1])
+		if err == nil {
+			tailReq := req
+			tailReq.StartTime = strconv.FormatInt(lastBar.Unix(), 10)
+			tailReq.EndTime = strconv.FormatInt(now.Unix(), 10)
+			err, tail := fetchChartData(tailReq, reqH)
+			if err != nil {
+				return entry, err
+			}
+
+			entry.Candles = mergeCandles(entry.Candles, tail.Data.Candles)
+			entry.FetchedAt = now.Unix()
+			entry.FetchedWhileOpen = IsMarketOpen()
+			writeCandleCache(redisCli, key, entry)
+			recordCandleCacheMetric(redisCli, "candles", constants.CandleCachePartial)
+			return entry, nil
+		}
+	}
+
+	recordCandleCacheMetric(redisCli, "candles", constants.CandleCacheMiss)
+
+	fullReq := req
+	if endTime, err := strconv.ParseInt(req.EndTime, 10, 64); err == nil && endTime < now.Unix() {
+		fullReq.EndTime = strconv.FormatInt(now.Unix(), 10)
+	}
+	err, chartData := fetchChartData(fullReq, reqH)
+	if err != nil {
+		return entry, err
+	}
+
+	entry = candleCacheEntry{
+		Candles:          chartData.Data.Candles,
+		StartTime:        startTime,
+		FetchedAt:        now.Unix(),
+		FetchedWhileOpen: IsMarketOpen(),
+	}
+	writeCandleCache(redisCli, key, entry)
+	return entry, nil
+}
+
+func candleSeriesId(req models.ChartDataReq) string {
+	return req.Exchange + "|" + req.Token + "|" + req.CandleType + "|" + req.DataDuration
+}
+
+func candleCacheKey(req models.ChartDataReq) string {
+	return constants.CandleCacheKeyPrefix + candleSeriesId(req)
+}
+
+func indicatorCacheKey(req models.ChartDataReq, name string) string {
+	return constants.IndicatorCacheKeyPrefix + candleSeriesId(req) + "|" + name
+}
+
+func readCandleCache(redisCli cache.RedisCache, key string) (candleCacheEntry, bool) {
+	var entry candleCacheEntry
+
+	cached, err := redisCli.GetRedis(key).Result()
+	if err != nil || cached == "" {
+		return entry, false
+	}
+	if err := json.Unmarshal([]byte(cached), &entry); err != nil {
+		loggerconfig.Warn("readCandleCache, unable to unmarshal cached candles for key:", key, " err:", err)
+		return entry, false
+	}
+	return entry, true
+}
+
+func writeCandleCache(redisCli cache.RedisCache, key string, entry candleCacheEntry) {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		loggerconfig.Warn("writeCandleCache, unable to marshal candles for key:", key, " err:", err)
+		return
+	}
+	if err := redisCli.SetRedis(key, string(raw), constants.CandleCacheTTL); err != nil {
+		loggerconfig.Warn("writeCandleCache, unable to store candles for key:", key, " err:", err)
+	}
+}
+
+func recordCandleCacheMetric(redisCli cache.RedisCache, kind string, outcome string) {
+	if err := redisCli.Incr(constants.CandleCacheMetricsKey + kind + "|" + outcome).Err(); err != nil {
+		loggerconfig.Warn("recordCandleCacheMetric, unable to record ", kind, " ", outcome, " err:", err)
+	}
+}
+
+// candleTime reads the timestamp Tradelab puts in the first column of a candle.
+func candleTime(candle []interface{}) (time.Time, error) {
+	if len(candle) == 0 {
+		return time.Time{}, fmt.Errorf("empty candle")
+	}
+	ts, ok := candle[0].(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("candle timestamp is not a string: %v", candle[0])
+	}
+	return time.Parse(constants.CandleTimestampLayout, ts)
+}
+
+// mergeCandles appends fresh bars to the cached series, replacing any cached
+// bars from the first fresh timestamp onwards since those may have been partial.
+func mergeCandles(cached [][]interface{}, fresh [][]interface{}) [][]interface{} {
+	if len(fresh) == 0 {
+		return cached
+	}
+	firstFresh, err := candleTime(fresh[0])
+	if err != nil {
+		return append(cached, fresh...)
+	}
+
+	cut := len(cached)
+	for cut > 0 {
+		ts, err := candleTime(cached[cut-1])
+		if err != nil || ts.Before(firstFresh) {
+			break
+		}
+		cut--
+	}
+
+	merged := make([][]interface{}, 0, cut+len(fresh))
+	merged = append(merged, cached[:cut]...)
+	return append(merged, fresh...)
+}
+
+func filterCandles(candles [][]interface{}, startTime, endTime int64) [][]interface{} {
+	filtered := make([][]interface{}, 0, len(candles))
+	for _, candle := range candles {
+		ts, err := candleTime(candle)
+		if err == nil && (ts.Unix() < startTime || ts.Unix() > endTime) {
+			continue
+		}
+		filtered = append(filtered, candle)
+	}
+	return filtered
+}
+
+// candleInterval maps Tradelab's candletype (1 minute, 2 hour, 3 day) and
+// data_duration to the length of one bar.
+func candleInterval(candleType, dataDuration string) time.Duration {
+	duration, err := strconv.Atoi(dataDuration)
+	if err != nil || duration <= 0 {
+		duration = 1
+	}
+	switch candleType {
+	case "1":
+		return time.Duration(duration) * time.Minute
+	case "2":
+		return time.Duration(duration) * time.Hour
+	default:
+		return time.Duration(duration) * 24 * time.Hour
+	}
+}
+
+// nextCandleClose is the first bar close after t for bars anchored at the market open.
+func nextCandleClose(t time.Time, candleType, dataDuration string) time.Time {
+	t = t.In(LocationKolkata)
+	sessionEnd := time.Date(t.Year(), t.Month(), t.Day(), CandleSessionEndHour, CandleSessionEndMin, 0, 0, LocationKolkata)
+
+	interval := candleInterval(candleType, dataDuration)
+	if interval >= 24*time.Hour {
+		return sessionEnd
+	}
+
+	open := time.Date(t.Year(), t.Month(), t.Day(), MarketOpenHour, MarketOpenMin, 0, 0, LocationKolkata)
+	if t.Before(open) {
+		return open.Add(interval)
+	}
+	bars := t.Sub(open)/interval + 1
+	next := open.Add(bars * interval)
+	if next.After(sessionEnd) {
+		return sessionEnd
+	}
+	return next
+}
+
+// lastSessionEnd is the end of the most recent trading session finished by t.
+func lastSessionEnd(t time.Time) time.Time {
+	t = t.In(LocationKolkata)
+	for i := 0; i < 15; i++ {
+		day := t.AddDate(0, 0, -i)
+		end := time.Date(day.Year(), day.Month(), day.Day(), CandleSessionEndHour, CandleSessionEndMin, 0, 0, LocationKolkata)
+		if end.After(t) || day.Weekday() == time.Saturday || day.Weekday() == time.Sunday || isHoliday(day.Format("02-Jan-2006")) {
+			continue
+		}
+		return end
+	}
+	return time.Time{}
+}
+
+// isCandleCacheStale reports whether a bar has closed since the entry was
+// fetched. While the market is open that is the next candle close; once it is
+// closed the entry stays valid until another session has finished.
+func isCandleCacheStale(entry candleCacheEntry, req models.ChartDataReq, now time.Time) bool {
+	fetchedAt := time.Unix(entry.FetchedAt, 0).In(LocationKolkata)
+	if IsMarketOpen() {
+		if !entry.FetchedWhileOpen {
+			return true
+		}
+		return !now.Before(nextCandleClose(fetchedAt, req.CandleType, req.DataDuration))
+	}
+	return fetchedAt.Before(lastSessionEnd(now))
+}
+
+// seriesFingerprint identifies the exact candles an indicator was computed on.
+func seriesFingerprint(chartData models.ChartDataResponse) string {
+	candles := chartData.Data.Candles
+	if len(candles) == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d|%v|%v", len(candles), candles[0], candles[len(candles)-1])
+}
+
+func readIndicatorCache(redisCli cache.RedisCache, key, fingerprint string, out interface{}) bool {
+	cached, err := redisCli.GetRedis(key).Result()
+	if err != nil || cached == "" {
+		return false
+	}
+	var entry indicatorCacheEntry
+	if err := json.Unmarshal([]byte(cached), &entry); err != nil || entry.Fingerprint != fingerprint {
+		return false
+	}
+	return json.Unmarshal(entry.Data, out) == nil
+}
+
+func writeIndicatorCache(redisCli cache.RedisCache, key, fingerprint string, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	entry, err := json.Marshal(indicatorCacheEntry{Fingerprint: fingerprint, Data: raw})
+	if err != nil {
+		return
+	}
+	if err := redisCli.SetRedis(key, string(entry), constants.CandleCacheTTL); err != nil {
+		loggerconfig.Warn("writeIndicatorCache, unable to store indicator for key:", key, " err:", err)
+	}
+}
+
+// memoIndicator returns the cached output of the named indicator for the same
+// candle series and parameters, computing and storing it on a miss.
+func memoIndicator[T any](name string, params interface{}, req models.ChartDataReq, chartData models.ChartDataResponse, compute func() (T, error)) (T, error) {
+	redisCli := cache.GetRedisClientObj()
+	if redisCli == nil {
+		return compute()
+	}
+
+	key := indicatorCacheKey(req, fmt.Sprintf("%s%+v", name, params))
+	fingerprint := seriesFingerprint(chartData)
+
+	var res T
+	if readIndicatorCache(redisCli, key, fingerprint, &res) {
+		recordCandleCacheMetric(redisCli, "indicators", constants.CandleCacheHit)
+		return res, nil
+	}
+	recordCandleCacheMetric(redisCli, "indicators", constants.CandleCacheMiss)
+
+	res, err := compute()
+	if err != nil {
+		return res, err
+	}
+	writeIndicatorCache(redisCli, key, fingerprint, res)
+	return res, nil
+}
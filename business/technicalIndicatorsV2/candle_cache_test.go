@@ -0,0 +1,226 @@
+package technicalindicatorsV2
+
+import (
+	"encoding/json"
+	"errors"
+	"space/constants"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeCandles(t *testing.T) {
+	cached := [][]interface{}{
+		{"2025-01-27T11:33:00+0530", 8.84, 8.84, 8.84, 8.84, 20.0},
+		{"2025-01-27T11:34:00+0530", 8.85, 8.85, 8.85, 8.85, 1059.0},
+		{"2025-01-27T11:35:00+0530", 8.86, 8.86, 8.86, 8.86, 10.0},
+	}
+	fresh := [][]interface{}{
+		{"2025-01-27T11:35:00+0530", 8.86, 8.88, 8.85, 8.87, 230.0},
+		{"2025-01-27T11:36:00+0530", 8.89, 8.89, 8.89, 8.89, 150.0},
+	}
+
+	merged := mergeCandles(cached, fresh)
+	assert.Len(t, merged, 4)
+	assert.Equal(t, "2025-01-27T11:34:00+0530", merged[1][0])
+	assert.Equal(t, 230.0, merged[2][5])
+	assert.Equal(t, "2025-01-27T11:36:00+0530", merged[3][0])
+
+	assert.Equal(t, cached, mergeCandles(cached, nil))
+}
+
+func TestFilterCandles(t *testing.T) {
+	candles := [][]interface{}{
+		{"2025-01-27T11:33:00+0530", 8.84, 8.84, 8.84, 8.84, 20.0},
+		{"2025-01-27T11:34:00+0530", 8.85, 8.85, 8.85, 8.85, 1059.0},
+		{"2025-01-27T11:35:00+0530", 8.86, 8.86, 8.86, 8.86, 10.0},
+	}
+	start, _ := time.Parse(constants.CandleTimestampLayout, "2025-01-27T11:34:00+0530")
+	end, _ := time.Parse(constants.CandleTimestampLayout, "2025-01-27T11:34:30+0530")
+
+	filtered := filterCandles(candles, start.Unix(), end.Unix())
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, "2025-01-27T11:34:00+0530", filtered[0][0])
+}
+
+func TestNextCandleClose(t *testing.T) {
+	tests := []struct {
+		name         string
+		at           time.Time
+		candleType   string
+		dataDuration string
+		want         time.Time
+	}{
+		{"one minute", time.Date(2025, 1, 27, 11, 33, 20, 0, LocationKolkata), "1", "1", time.Date(2025, 1, 27, 11, 34, 0, 0, LocationKolkata)},
+		{"five minute", time.Date(2025, 1, 27, 11, 33, 20, 0, LocationKolkata), "1", "5", time.Date(2025, 1, 27, 11, 35, 0, 0, LocationKolkata)},
+		{"hourly anchored at open", time.Date(2025, 1, 27, 10, 20, 0, 0, LocationKolkata), "2", "1", time.Date(2025, 1, 27, 11, 15, 0, 0, LocationKolkata)},
+		{"before open", time.Date(2025, 1, 27, 8, 0, 0, 0, LocationKolkata), "1", "15", time.Date(2025, 1, 27, 9, 30, 0, 0, LocationKolkata)},
+		{"daily", time.Date(2025, 1, 27, 10, 0, 0, 0, LocationKolkata), "3", "1", time.Date(2025, 1, 27, 15, 30, 0, 0, LocationKolkata)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.want.Equal(nextCandleClose(tt.at, tt.candleType, tt.dataDuration)))
+		})
+	}
+}
+
+func TestIsCandleCacheStale(t *testing.T) {
+	origNow := timeNow
+	t.Cleanup(func() { timeNow = origNow })
+	req := models.ChartDataReq{CandleType: "1", DataDuration: "5"}
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		entry candleCacheEntry
+		want  bool
+	}{
+		{
+			name:  "open, bar still forming",
+			now:   time.Date(2025, 1, 27, 11, 34, 0, 0, LocationKolkata),
+			entry: candleCacheEntry{FetchedAt: time.Date(2025, 1, 27, 11, 31, 0, 0, LocationKolkata).Unix(), FetchedWhileOpen: true},
+			want:  false,
+		},
+		{
+			name:  "open, bar closed since fetch",
+			now:   time.Date(2025, 1, 27, 11, 36, 0, 0, LocationKolkata),
+			entry: candleCacheEntry{FetchedAt: time.Date(2025, 1, 27, 11, 31, 0, 0, LocationKolkata).Unix(), FetchedWhileOpen: true},
+			want:  true,
+		},
+		{
+			name:  "open, fetched before the session",
+			now:   time.Date(2025, 1, 27, 9, 20, 0, 0, LocationKolkata),
+			entry: candleCacheEntry{FetchedAt: time.Date(2025, 1, 27, 8, 0, 0, 0, LocationKolkata).Unix()},
+			want:  true,
+		},
+		{
+			name:  "closed, fetched after the session",
+			now:   time.Date(2025, 1, 27, 20, 0, 0, 0, LocationKolkata),
+			entry: candleCacheEntry{FetchedAt: time.Date(2025, 1, 27, 16, 0, 0, 0, LocationKolkata).Unix()},
+			want:  false,
+		},
+		{
+			name:  "closed, fetched during the session",
+			now:   time.Date(2025, 1, 27, 20, 0, 0, 0, LocationKolkata),
+			entry: candleCacheEntry{FetchedAt: time.Date(2025, 1, 27, 14, 0, 0, 0, LocationKolkata).Unix(), FetchedWhileOpen: true},
+			want:  true,
+		},
+		{
+			name:  "weekend, fetched friday evening",
+			now:   time.Date(2025, 2, 1, 12, 0, 0, 0, LocationKolkata),
+			entry: candleCacheEntry{FetchedAt: time.Date(2025, 1, 31, 18, 0, 0, 0, LocationKolkata).Unix()},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeNow = func() time.Time { return tt.now }
+			assert.Equal(t, tt.want, isCandleCacheStale(tt.entry, req, tt.now))
+		})
+	}
+}
+
+func TestGetCachedChartData(t *testing.T) {
+	origFetch, origNow, origWarn := fetchChartData, timeNow, loggerconfig.Warn
+	t.Cleanup(func() {
+		fetchChartData, timeNow, loggerconfig.Warn = origFetch, origNow, origWarn
+		cache.SetRedisClientObj(nil)
+	})
+	loggerconfig.Warn = func(args ...interface{}) {}
+
+	now := time.Date(2025, 1, 27, 20, 0, 0, 0, LocationKolkata)
+	timeNow = func() time.Time { return now }
+
+	candles := [][]interface{}{
+		{"2025-01-24T09:15:00+0530", 100.0, 101.0, 99.0, 100.5, 1000.0},
+		{"2025-01-27T09:15:00+0530", 100.5, 102.0, 100.0, 101.5, 1200.0},
+	}
+	req := models.ChartDataReq{
+		Exchange:     "NSE",
+		Token:        "2885",
+		CandleType:   "3",
+		DataDuration: "1",
+		StartTime:    strconv.FormatInt(now.AddDate(0, 0, -10).Unix(), 10),
+		EndTime:      strconv.FormatInt(now.Unix(), 10),
+	}
+	key := candleCacheKey(req)
+
+	t.Run("miss fetches from tradelab and stores", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		cache.SetRedisClientObj(&cache.RedisClient{Client: db, OrderClient: db})
+
+		calls := 0
+		fetchChartData = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+			calls++
+			var res models.ChartDataResponse
+			res.Data.Candles = candles
+			return nil, res
+		}
+
+		mock.ExpectGet(key).RedisNil()
+		mock.ExpectIncr(constants.CandleCacheMetricsKey + "candles|" + constants.CandleCacheMiss).SetVal(1)
+		mock.Regexp().ExpectSet(key, `.*`, constants.CandleCacheTTL*time.Minute).SetVal("OK")
+
+		err, res := GetCachedChartData(req, models.ReqHeader{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Len(t, res.Data.Candles, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("hit serves from redis", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		cache.SetRedisClientObj(&cache.RedisClient{Client: db, OrderClient: db})
+
+		fetchChartData = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+			return errors.New("tradelab should not be called"), models.ChartDataResponse{}
+		}
+
+		start, _ := strconv.ParseInt(req.StartTime, 10, 64)
+		raw, _ := json.Marshal(candleCacheEntry{Candles: candles, StartTime: start, FetchedAt: now.Add(-time.Hour).Unix()})
+		mock.ExpectGet(key).SetVal(string(raw))
+		mock.ExpectIncr(constants.CandleCacheMetricsKey + "candles|" + constants.CandleCacheHit).SetVal(1)
+
+		err, res := GetCachedChartData(req, models.ReqHeader{})
+		assert.NoError(t, err)
+		assert.Len(t, res.Data.Candles, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stale entry only fetches the tail", func(t *testing.T) {
+		db, mock := redismock.NewClientMock()
+		cache.SetRedisClientObj(&cache.RedisClient{Client: db, OrderClient: db})
+
+		var tailReq models.ChartDataReq
+		fetchChartData = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+			tailReq = req
+			var res models.ChartDataResponse
+			res.Data.Candles = [][]interface{}{
+				{"2025-01-27T09:15:00+0530", 100.5, 103.0, 100.0, 102.5, 2500.0},
+			}
+			return nil, res
+		}
+
+		start, _ := strconv.ParseInt(req.StartTime, 10, 64)
+		raw, _ := json.Marshal(candleCacheEntry{Candles: candles, StartTime: start, FetchedAt: time.Date(2025, 1, 27, 14, 0, 0, 0, LocationKolkata).Unix(), FetchedWhileOpen: true})
+		mock.ExpectGet(key).SetVal(string(raw))
+		mock.Regexp().ExpectSet(key, `.*`, constants.CandleCacheTTL*time.Minute).SetVal("OK")
+		mock.ExpectIncr(constants.CandleCacheMetricsKey + "candles|" + constants.CandleCachePartial).SetVal(1)
+
+		err, res := GetCachedChartData(req, models.ReqHeader{})
+		assert.NoError(t, err)
+		lastBar, _ := time.Parse(constants.CandleTimestampLayout, "2025-01-27T09:15:00+0530")
+		assert.Equal(t, strconv.FormatInt(lastBar.Unix(), 10), tailReq.StartTime)
+		assert.Len(t, res.Data.Candles, 2)
+		assert.Equal(t, 102.5, res.Data.Candles[1][4])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -52,21 +52,19 @@ func sma(req models.GetSMAReq, reqH models.ReqHeader) ([]models.TechnicalIndicat
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	rawData, err := CalculateSMA(chartData, req.SMAType)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("sma", req.SMAType, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		rawData, err := CalculateSMA(chartData, req.SMAType)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, rawData, calculateMovingAverageSignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, rawData, calculateMovingAverageSignal)
+	})
 }
 
 func (obj TIV2Obj) GetEMA(req models.GetEMAReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -99,21 +97,19 @@ func ema(req models.GetEMAReq, reqH models.ReqHeader) ([]models.TechnicalIndicat
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	rawData, err := CalculateEMA(chartData, req.EMAType)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("ema", req.EMAType, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		rawData, err := CalculateEMA(chartData, req.EMAType)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, rawData, calculateMovingAverageSignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, rawData, calculateMovingAverageSignal)
+	})
 }
 
 func (obj TIV2Obj) GetHullMA(req models.GetHullMAReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -146,21 +142,19 @@ func hullma(req models.GetHullMAReq, reqH models.ReqHeader) ([]models.TechnicalI
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	rawData, err := CalculateHullMA(chartData, req.HullMAType)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("hullma", req.HullMAType, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		rawData, err := CalculateHullMA(chartData, req.HullMAType)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, rawData, calculateHullMASignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, rawData, calculateHullMASignal)
+	})
 }
 
 func (obj TIV2Obj) GetVWMA(req models.GetVWMAReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -193,21 +187,19 @@ func vwma(req models.GetVWMAReq, reqH models.ReqHeader) ([]models.TechnicalIndic
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	rawData, err := CalculateVWMA(chartData, req.VWMAType)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("vwma", req.VWMAType, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		rawData, err := CalculateVWMA(chartData, req.VWMAType)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, rawData, calculateVWMASignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, rawData, calculateVWMASignal)
+	})
 }
 
 func (obj TIV2Obj) GetRSI(req models.GetRSIReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -240,21 +232,19 @@ func rsi(req models.GetRSIReq, reqH models.ReqHeader) ([]models.TechnicalIndicat
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	rawData, err := CalculateRSI(chartData, req.RSIType)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("rsi", req.RSIType, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		rawData, err := CalculateRSI(chartData, req.RSIType)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, rawData, calculateRsiSignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, rawData, calculateRsiSignal)
+	})
 }
 
 func (obj TIV2Obj) GetCCI(req models.GetCCIReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -287,21 +277,19 @@ func cci(req models.GetCCIReq, reqH models.ReqHeader) ([]models.TechnicalIndicat
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	rawData, err := CalculateCCI(chartData, req.CCIType)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("cci", req.CCIType, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		rawData, err := CalculateCCI(chartData, req.CCIType)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, rawData, calculateCCISignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, rawData, calculateCCISignal)
+	})
 }
 
 func (obj TIV2Obj) GetMACD(req models.GetMACDReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -334,21 +322,19 @@ func macd(req models.GetMACDReq, reqH models.ReqHeader) ([]models.TechnicalIndic
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	macd, _, _, err := CalculateMACD(chartData, req.FastPeriod, req.SlowPeriod, req.SignalPeriod)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("macd", []int{req.FastPeriod, req.SlowPeriod, req.SignalPeriod}, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		macd, _, _, err := CalculateMACD(chartData, req.FastPeriod, req.SlowPeriod, req.SignalPeriod)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, macd, calculateMacdSignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, macd, calculateMacdSignal)
+	})
 }
 
 func (obj TIV2Obj) GetStochastic(req models.GetStochasticReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -381,21 +367,19 @@ func stochastic(req models.GetStochasticReq, reqH models.ReqHeader) ([]models.Te
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	resultK, _, err := CalculateStochastic(chartData, req.KPeriod, req.DPeriod, req.Smooth)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("stochastic", []int{req.KPeriod, req.DPeriod, req.Smooth}, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		resultK, _, err := CalculateStochastic(chartData, req.KPeriod, req.DPeriod, req.Smooth)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, resultK, calculateStochasticKSignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, resultK, calculateStochasticKSignal)
+	})
 }
 
 func (obj TIV2Obj) GetIchimokuBaseLine(req models.GetIchimokuBaseLineReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -428,21 +412,19 @@ func ichimokuBaseLine(req models.GetIchimokuBaseLineReq, reqH models.ReqHeader)
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	rawData, err := CalculateIchimokuBaseLine(chartData)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("ichimokuBaseLine", nil, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		rawData, err := CalculateIchimokuBaseLine(chartData)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, rawData, calculateIchimokuBaseLineSignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, rawData, calculateIchimokuBaseLineSignal)
+	})
 }
 
 func (obj TIV2Obj) GetADX(req models.GetADXReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -475,21 +457,19 @@ func adx(req models.GetADXReq, reqH models.ReqHeader) ([]models.TechnicalIndicat
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	rawData, err := CalculateADX(chartData, req.Period)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("adx", req.Period, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		rawData, err := CalculateADX(chartData, req.Period)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, rawData, calculateADXSignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, rawData, calculateADXSignal)
+	})
 }
 
 func (obj TIV2Obj) GetAwesomeOscillator(req models.GetAwesomeOscillatorReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -522,21 +502,19 @@ func awesomeOscillator(req models.GetAwesomeOscillatorReq, reqH models.ReqHeader
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	ao, err := CalculateAwesomeOscillator(chartData)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("awesomeOscillator", nil, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		ao, err := CalculateAwesomeOscillator(chartData)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResultsDiff(chartData, ao, calculateAwesomeOscillatorSignalWithCrossover)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResultsDiff(chartData, ao, calculateAwesomeOscillatorSignalWithCrossover)
+	})
 }
 
 func (obj TIV2Obj) GetMomentum(req models.GetMomentumReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -569,21 +547,19 @@ func momentum(req models.GetMomentumReq, reqH models.ReqHeader) ([]models.Techni
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	rawData, err := CalculateMomentum(chartData, req.Period)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("momentum", req.Period, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		rawData, err := CalculateMomentum(chartData, req.Period)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResultsDiff(chartData, rawData, calculateMomentumSignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResultsDiff(chartData, rawData, calculateMomentumSignal)
+	})
 }
 
 func (obj TIV2Obj) GetStochRSIFast(req models.GetStochRSIFastReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -616,21 +592,19 @@ func stochasticGetStochRSIFast(req models.GetStochRSIFastReq, reqH models.ReqHea
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	smoothedK, _, err := CalculateStochRSIFast(chartData, req.SmoothK, req.SmoothD, req.RsiPeriod, req.StochPeriod)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("stochasticGetStochRSIFast", []int{req.SmoothK, req.SmoothD, req.RsiPeriod, req.StochPeriod}, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		smoothedK, _, err := CalculateStochRSIFast(chartData, req.SmoothK, req.SmoothD, req.RsiPeriod, req.StochPeriod)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, smoothedK, calculateStochRSISignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, smoothedK, calculateStochRSISignal)
+	})
 }
 
 func (obj TIV2Obj) GetWilliamsRange(req models.GetWilliamsRangeReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -663,21 +637,19 @@ func williamsRange(req models.GetWilliamsRangeReq, reqH models.ReqHeader) ([]mod
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	rawData, err := CalculateWilliamsR(chartData, req.Period)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("williamsRange", req.Period, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		rawData, err := CalculateWilliamsR(chartData, req.Period)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, rawData, calculateWilliamsRSignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, rawData, calculateWilliamsRSignal)
+	})
 }
 
 func (obj TIV2Obj) GetUltimateOscillator(req models.GetUltimateOscillatorReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -710,21 +682,19 @@ func ultimateOscillator(req models.GetUltimateOscillatorReq, reqH models.ReqHead
 	reqChartData.StartTime = req.TLChartData.StartTime
 	reqChartData.EndTime = req.TLChartData.EndTime
 	reqChartData.DataDuration = req.TLChartData.DataDuration
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
 		return res, err
 	}
 
-	rawData, err := CalculateUltimateOscillator(chartData, req.Period1, req.Period2, req.Period3)
-	if err != nil {
-		return res, err
-	}
+	return memoIndicator("ultimateOscillator", []int{req.Period1, req.Period2, req.Period3}, reqChartData, chartData, func() ([]models.TechnicalIndicatorsRes, error) {
+		rawData, err := CalculateUltimateOscillator(chartData, req.Period1, req.Period2, req.Period3)
+		if err != nil {
+			return nil, err
+		}
 
-	res, err = formatIndicatorResults(chartData, rawData, calculateUltimateOscillatorSignal)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+		return formatIndicatorResults(chartData, rawData, calculateUltimateOscillatorSignal)
+	})
 }
 
 func (obj TIV2Obj) GetAllTechnicalIndicators(req models.GetAllTechnicalIndicatorsReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
@@ -764,10 +734,6 @@ func (obj TIV2Obj) GetAllTechnicalIndicators(req models.GetAllTechnicalIndicator
 }
 
 func getAllTechnicalIndicators(req models.TLChartDataReq, reqH models.ReqHeader) (models.AllTechnicalIndicatorsRes, []models.PivotsValues, error) {
-	var result models.AllTechnicalIndicatorsRes
-	var pivotsResult []models.PivotsValues
-	var individualEntries []models.TechnicalIndicatorsResFull
-
 	reqChartData := models.ChartDataReq{
 		Exchange:     req.Exchange,
 		Token:        req.Token,
@@ -777,11 +743,23 @@ func getAllTechnicalIndicators(req models.TLChartDataReq, reqH models.ReqHeader)
 		DataDuration: req.DataDuration,
 	}
 
-	err, chartData := GetChartData(reqChartData, reqH)
+	err, chartData := GetCachedChartData(reqChartData, reqH)
 	if err != nil {
-		return result, pivotsResult, err
+		return models.AllTechnicalIndicatorsRes{}, nil, err
 	}
 
+	all, err := memoIndicator("ALL", nil, reqChartData, chartData, func() (allIndicatorsCacheData, error) {
+		result, pivots, err := computeAllTechnicalIndicators(chartData)
+		return allIndicatorsCacheData{Result: result, Pivots: pivots}, err
+	})
+	return all.Result, all.Pivots, err
+}
+
+func computeAllTechnicalIndicators(chartData models.ChartDataResponse) (models.AllTechnicalIndicatorsRes, []models.PivotsValues, error) {
+	var result models.AllTechnicalIndicatorsRes
+	var pivotsResult []models.PivotsValues
+	var individualEntries []models.TechnicalIndicatorsResFull
+
 	smaPeriods := []int{10, 20, 30, 50, 100, 200}
 	for _, period := range smaPeriods {
 		entry, err := calculateAndFormatSMA(chartData, period)
@@ -0,0 +1,280 @@
+package tradelab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/dbops"
+	"space/helpers"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var appScopeNow = helpers.GetCurrentTimeInIST
+
+var knownAppScopes = map[string]bool{
+	constants.ScopeOrdersRead:    true,
+	constants.ScopeOrdersWrite:   true,
+	constants.ScopePortfolioRead: true,
+	constants.ScopeFundsRead:     true,
+	constants.ScopeFundsWrite:    true,
+	constants.ScopeReportsRead:   true,
+	constants.ScopeProfileRead:   true,
+	constants.ScopeMarketRead:    true,
+}
+
+// a write scope also grants reading what it writes
+var impliedAppScopes = map[string]string{
+	constants.ScopeOrdersWrite: constants.ScopeOrdersRead,
+	constants.ScopeFundsWrite:  constants.ScopeFundsRead,
+}
+
+// ParseAppScopes splits an OAuth scope string on spaces or commas. It fails
+// when the string is empty or names a scope this service does not know.
+func ParseAppScopes(scope string) ([]string, bool) {
+	fields := strings.FieldsFunc(scope, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 0 {
+		return nil, false
+	}
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, field := range fields {
+		field = strings.ToLower(field)
+		if !knownAppScopes[field] {
+			return nil, false
+		}
+		if !seen[field] {
+			seen[field] = true
+			scopes = append(scopes, field)
+		}
+	}
+	return scopes, true
+}
+
+func appQuotaPerMin(quota int) int {
+	if quota <= 0 {
+		return constants.AppDefaultQuotaMin
+	}
+	if quota > constants.AppMaxQuotaMin {
+		return constants.AppMaxQuotaMin
+	}
+	return quota
+}
+
+// AppHasScope reports whether an app was granted a scope, directly or through
+// the write scope it belongs to.
+func AppHasScope(grant models.AppGrant, scope string) bool {
+	for _, granted := range grant.Scopes {
+		if granted == scope || impliedAppScopes[granted] == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func appGrant(appOwner string, app models.AppDetails) models.AppGrant {
+	// scopes saved before they were checked may not parse, those apps get none
+	scopes, _ := ParseAppScopes(app.Scope)
+	return models.AppGrant{
+		AppId:       app.AppID,
+		AppOwner:    appOwner,
+		Scopes:      scopes,
+		QuotaPerMin: appQuotaPerMin(app.QuotaPerMin),
+	}
+}
+
+/*
+EnsureAppTokenIndex creates the multikey index CallFindAppByToken looks app
+tokens up by. Every bearer request that misses the grant cache asks for its
+token, so without it each miss scans the app details collection.
+*/
+func EnsureAppTokenIndex() error {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.APPDETAILS)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "apps.accessToken", Value: 1}},
+		Options: options.Index().SetName("apps_accessToken"),
+	})
+	return err
+}
+
+var CallFindAppByToken = func(token string) (models.AppGrant, bool, error) {
+	var result models.CreateAppRes
+	err := dbops.MongoRepo.FindOne(constants.APPDETAILS, bson.M{"apps.accessToken": token}, &result)
+	if err != nil {
+		if err.Error() == constants.MongoNoDocError {
+			return models.AppGrant{}, false, nil
+		}
+		return models.AppGrant{}, false, err
+	}
+	for _, app := range result.Apps {
+		if app.AccessToken == token {
+			return appGrant(result.AppOwner, app), true, nil
+		}
+	}
+	return models.AppGrant{}, false, nil
+}
+
+// CallFetchAppGrant finds the app an access token was issued to, if any. Both
+// outcomes are cached so first-party tokens do not reach mongo every request.
+var CallFetchAppGrant = func(token string) (models.AppGrant, bool, error) {
+	redisCli := cache.GetRedisClientObj()
+	key := constants.AppTokenKey + SessionId(token)
+
+	if cached, err := redisCli.GetRedis(key).Result(); err == nil && cached != "" {
+		if cached == constants.AppNotAnApp {
+			return models.AppGrant{}, false, nil
+		}
+		var grant models.AppGrant
+		if err := json.Unmarshal([]byte(cached), &grant); err == nil {
+			return grant, true, nil
+		}
+	}
+
+	grant, found, err := CallFindAppByToken(token)
+	if err != nil {
+		return models.AppGrant{}, false, err
+	}
+	cached := constants.AppNotAnApp
+	if found {
+		data, _ := json.Marshal(grant)
+		cached = string(data)
+	}
+	if err := redisCli.SetRedis(key, cached, time.Duration(constants.AppTokenCacheMin)); err != nil {
+		loggerconfig.Error("CallFetchAppGrant, error caching app grant:", err, " appId:", grant.AppId)
+	}
+	return grant, found, nil
+}
+
+// CallForgetAppGrant drops the cached grant of an access token, so a deleted
+// app or a replaced token stops being honoured before the cache expires.
+var CallForgetAppGrant = func(token string) error {
+	if token == "" {
+		return nil
+	}
+	return cache.GetRedisClientObj().DeleteRedis(constants.AppTokenKey + SessionId(token))
+}
+
+// IsWriteAppScope reports whether a scope lets an app change something rather
+// than only read it.
+func IsWriteAppScope(scope string) bool {
+	_, ok := impliedAppScopes[scope]
+	return ok
+}
+
+// CallIncrAppQuota counts a request against the app's quota for one minute
+// window and returns the count so far.
+var CallIncrAppQuota = func(appId string, window int64) (int64, error) {
+	redisCli := cache.GetRedisClientObj()
+	key := constants.AppQuotaKey + appId + "_" + strconv.FormatInt(window, 10)
+	count, err := redisCli.Incr(key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := redisCli.Expire(key, 2); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+var CallLogAppActivity = func(activity models.MongoAppActivity) error {
+	return dbops.MongoRepo.InsertOne(constants.APPACTIVITYCOLLECTION, activity)
+}
+
+var CallFetchAppActivity = func(appId string) ([]models.MongoAppActivity, error) {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.APPACTIVITYCOLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(constants.AppActivityLimit)
+	cursor, err := coll.Find(ctx, bson.M{"appId": appId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var activity []models.MongoAppActivity
+	if err := cursor.All(ctx, &activity); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// CheckAppQuota counts a request of an app and reports whether it is within
+// the app's per minute quota, or else how many seconds until the next window.
+// Quotas are not enforced while redis is unavailable.
+func CheckAppQuota(grant models.AppGrant, requestId string) (bool, int) {
+	now := appScopeNow()
+	window := now.Unix() / 60
+	count, err := CallIncrAppQuota(grant.AppId, window)
+	if err != nil {
+		loggerconfig.Error("CheckAppQuota, redis error:", err, " appId:", grant.AppId, " requestId:", requestId)
+		return true, 0
+	}
+	if count > int64(grant.QuotaPerMin) {
+		return false, int((window+1)*60 - now.Unix())
+	}
+	return true, 0
+}
+
+// RecordAppActivity adds a request made with an app token to the app's
+// activity log.
+func RecordAppActivity(activity models.MongoAppActivity, requestId string) {
+	activity.CreatedAt = appScopeNow()
+	if err := CallLogAppActivity(activity); err != nil {
+		loggerconfig.Error("RecordAppActivity, mongo error:", err, " appId:", activity.AppId, " path:", activity.Path, " requestId:", requestId)
+	}
+}
+
+func (obj LoginObj) FetchAppActivity(appId string, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	var owner models.CreateAppRes
+	err := dbops.MongoRepo.FindOne(constants.APPDETAILS, bson.M{"appowner": reqH.ClientId, "apps.appId": appId}, &owner)
+	if err != nil {
+		if err.Error() == constants.MongoNoDocError {
+			loggerconfig.Error("FetchAppActivity, app not found for client, appId:", appId, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+			return apihelpers.SendErrorResponse(false, constants.AppDoesNotExists, http.StatusBadRequest)
+		}
+		loggerconfig.Error("FetchAppActivity, error finding app:", err, " appId:", appId, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	activity, err := CallFetchAppActivity(appId)
+	if err != nil {
+		loggerconfig.Error("FetchAppActivity, mongo error:", err, " appId:", appId, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	res := models.AppActivityRes{AppId: appId, Activity: []models.AppActivityItem{}}
+	for _, item := range activity {
+		res.Activity = append(res.Activity, models.AppActivityItem{
+			Method:     item.Method,
+			Path:       item.Path,
+			Scope:      item.Scope,
+			StatusCode: item.StatusCode,
+			ErrorCode:  item.ErrorCode,
+			Ip:         item.Ip,
+			CreatedAt:  item.CreatedAt.Unix(),
+		})
+	}
+
+	apiRes.Data = res
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
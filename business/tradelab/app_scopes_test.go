@@ -0,0 +1,95 @@
+package tradelab
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+)
+
+func TestParseAppScopes(t *testing.T) {
+	tests := []struct {
+		scope  string
+		scopes []string
+		ok     bool
+	}{
+		{"orders:write portfolio:read", []string{constants.ScopeOrdersWrite, constants.ScopePortfolioRead}, true},
+		{"Funds:Read,reports:read  funds:read", []string{constants.ScopeFundsRead, constants.ScopeReportsRead}, true},
+		{"orders:write admin", nil, false},
+		{" , ", nil, false},
+		{"", nil, false},
+	}
+	for _, tt := range tests {
+		scopes, ok := ParseAppScopes(tt.scope)
+		if ok != tt.ok || !reflect.DeepEqual(scopes, tt.scopes) {
+			t.Errorf("ParseAppScopes(%q) = %v, %v, want %v, %v", tt.scope, scopes, ok, tt.scopes, tt.ok)
+		}
+	}
+}
+
+func TestAppHasScope(t *testing.T) {
+	grant := appGrant("ABC123", models.AppDetails{AppID: "app1", Scope: "orders:write reports:read"})
+	if grant.QuotaPerMin != constants.AppDefaultQuotaMin {
+		t.Errorf("QuotaPerMin = %d, want default", grant.QuotaPerMin)
+	}
+	for scope, want := range map[string]bool{
+		constants.ScopeOrdersWrite:   true,
+		constants.ScopeOrdersRead:    true,
+		constants.ScopeReportsRead:   true,
+		constants.ScopePortfolioRead: false,
+		constants.ScopeFundsRead:     false,
+	} {
+		if got := AppHasScope(grant, scope); got != want {
+			t.Errorf("AppHasScope(%s) = %v, want %v", scope, got, want)
+		}
+	}
+
+	legacy := appGrant("ABC123", models.AppDetails{AppID: "app2", Scope: "openid", QuotaPerMin: 5000})
+	if AppHasScope(legacy, constants.ScopeOrdersRead) || legacy.QuotaPerMin != constants.AppMaxQuotaMin {
+		t.Errorf("legacy app grant = %+v", legacy)
+	}
+}
+
+func TestCheckAppQuota(t *testing.T) {
+	now := time.Date(2026, 10, 19, 10, 0, 45, 0, constants.LocationKolkata)
+
+	origNow, origIncr, origError := appScopeNow, CallIncrAppQuota, loggerconfig.Error
+	t.Cleanup(func() { appScopeNow, CallIncrAppQuota, loggerconfig.Error = origNow, origIncr, origError })
+	loggerconfig.Error = func(args ...interface{}) {}
+	appScopeNow = func() time.Time { return now }
+
+	counts := map[string]int64{}
+	CallIncrAppQuota = func(appId string, window int64) (int64, error) {
+		key := appId + "_" + time.Unix(window*60, 0).Format(time.RFC3339)
+		counts[key]++
+		return counts[key], nil
+	}
+
+	grant := models.AppGrant{AppId: "app1", QuotaPerMin: 2}
+	for i := 0; i < 2; i++ {
+		if allowed, _ := CheckAppQuota(grant, ""); !allowed {
+			t.Fatalf("request %d rejected within quota", i+1)
+		}
+	}
+	allowed, retryAfter := CheckAppQuota(grant, "")
+	if allowed || retryAfter != 15 {
+		t.Errorf("CheckAppQuota() over quota = %v, %d, want false, 15", allowed, retryAfter)
+	}
+	if allowed, _ := CheckAppQuota(models.AppGrant{AppId: "app2", QuotaPerMin: 2}, ""); !allowed {
+		t.Errorf("quota of one app limited another")
+	}
+
+	now = now.Add(20 * time.Second)
+	if allowed, _ := CheckAppQuota(grant, ""); !allowed {
+		t.Errorf("quota not reset in the next window")
+	}
+
+	CallIncrAppQuota = func(appId string, window int64) (int64, error) { return 0, errors.New("redis down") }
+	if allowed, _ := CheckAppQuota(grant, ""); !allowed {
+		t.Errorf("CheckAppQuota() should allow requests when redis fails")
+	}
+}
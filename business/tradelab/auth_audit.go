@@ -0,0 +1,378 @@
+package tradelab
+
+import (
+	"context"
+	"net"
+	"net/http"
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/dbops"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var authAuditNow = helpers.GetCurrentTimeInIST
+
+// events that finish a login and hand out a token, the rules run on these
+var authTwoFaEvents = []string{
+	constants.AuthEventValidateTwoFa,
+	constants.AuthEventValidateTwofaV2,
+}
+
+// authHistoryEvents are what the rules look back at. An email OTP login is
+// kept alongside the 2FA events as it is how a step-up is answered.
+var authHistoryEvents = append([]string{constants.AuthEventValidateLoginOtp}, authTwoFaEvents...)
+
+var CallRecordAuthEvent = func(event models.MongoAuthEvent) error {
+	return dbops.MongoRepo.InsertOne(constants.AUTHEVENTSCOLLECTION, event)
+}
+
+// CallFetchAuthEvents returns the client's events of the given kinds since a
+// time, most recent first.
+var CallFetchAuthEvents = func(clientId string, events []string, since time.Time) ([]models.MongoAuthEvent, error) {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.AUTHEVENTSCOLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"clientId": clientId, "event": bson.M{"$in": events}, "createdAt": bson.M{"$gte": since}}
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(constants.AuthEventHistoryLimit)
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var history []models.MongoAuthEvent
+	if err := cursor.All(ctx, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+var CallFetchAuthSecurity = func(clientId string) (models.MongoAuthSecurity, error) {
+	var state models.MongoAuthSecurity
+	err := dbops.MongoRepo.FindOne(constants.AUTHSECURITYCOLLECTION, bson.M{"clientId": clientId}, &state)
+	if err != nil && err.Error() == constants.MongoNoDocError {
+		return models.MongoAuthSecurity{ClientId: clientId}, nil
+	}
+	return state, err
+}
+
+var CallSaveAuthSecurity = func(state models.MongoAuthSecurity) error {
+	return dbops.MongoRepo.UpdateOne(constants.AUTHSECURITYCOLLECTION, bson.M{"clientId": state.ClientId}, bson.M{"$set": state}, options.Update().SetUpsert(true))
+}
+
+var CallPublishSecurityAlert = func(alert models.AuthSecurityAlert) error {
+	return helpers.PublishMessage(constants.TopicExchange, constants.KeyAuthSecurityAlert, alert)
+}
+
+// authIdentity tells apart the client IDs and email IDs the login flows are
+// started with.
+func authIdentity(id string) (string, string) {
+	id = strings.TrimSpace(id)
+	if strings.Contains(id, "@") {
+		return "", strings.ToLower(id)
+	}
+	return strings.ToUpper(id), ""
+}
+
+// ipNetwork is the /16 of an IPv4 or the /32 of an IPv6 address, coarse
+// enough that a phone moving between towers of one carrier stays inside it.
+func ipNetwork(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(16, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(32, 128)).String()
+}
+
+func authOutcome(statusCode int, res apihelpers.APIRes) string {
+	if res.ErrorCode == constants.AuthAccountLocked || res.ErrorCode == constants.AuthStepUpRequired {
+		return constants.AuthOutcomeBlocked
+	}
+	if statusCode == http.StatusOK && res.Status {
+		return constants.AuthOutcomeSuccess
+	}
+	return constants.AuthOutcomeFailure
+}
+
+// authResult picks the client ID and token out of a successful login response.
+func authResult(res apihelpers.APIRes) (string, string) {
+	switch data := res.Data.(type) {
+	case models.ValidateTwoFAResponse:
+		return "", data.AuthToken
+	case models.ValidateTwofaV2Res:
+		return "", data.AuthToken
+	case models.LoginV2Response:
+		return data.LoginID, data.AuthToken
+	case models.LoginResponse:
+		return data.LoginID, data.AuthToken
+	}
+	return "", ""
+}
+
+type authRuleHit struct {
+	rule       string
+	action     string
+	previousIp string
+}
+
+/*
+matchAuthRules checks a 2FA event against the client's recent history, most
+recent first:
+  - failedTwoFa locks the client once AuthFailedTwoFaLimit attempts have failed
+    within AuthFailedTwoFaWindowMins
+  - newDevice notifies the client of a login from a device none of their
+    earlier logins came from
+  - impossibleTravel asks for a step-up when a login from another device and
+    another network follows the last one within AuthTravelWindowMins, unless
+    it comes right after an email OTP login from the same device
+*/
+func matchAuthRules(event models.MongoAuthEvent, history []models.MongoAuthEvent) []authRuleHit {
+	var hits []authRuleHit
+
+	if event.Outcome == constants.AuthOutcomeFailure {
+		since := event.CreatedAt.Add(-constants.AuthFailedTwoFaWindowMins * time.Minute)
+		failed := 1
+		for _, past := range history {
+			if past.Outcome == constants.AuthOutcomeFailure && past.Event != constants.AuthEventValidateLoginOtp && !past.CreatedAt.Before(since) {
+				failed++
+			}
+		}
+		if failed >= constants.AuthFailedTwoFaLimit {
+			hits = append(hits, authRuleHit{rule: constants.AuthRuleFailedTwoFa, action: constants.AuthActionLock})
+		}
+		return hits
+	}
+	if event.Outcome != constants.AuthOutcomeSuccess {
+		return hits
+	}
+
+	var last *models.MongoAuthEvent
+	knownDevice := false
+	for i, past := range history {
+		if past.Outcome != constants.AuthOutcomeSuccess {
+			continue
+		}
+		if last == nil {
+			last = &history[i]
+		}
+		if past.DeviceId == event.DeviceId {
+			knownDevice = true
+		}
+	}
+	if last == nil {
+		// nothing to compare the first login with
+		return hits
+	}
+
+	if event.DeviceId != "" && !knownDevice {
+		hits = append(hits, authRuleHit{rule: constants.AuthRuleNewDevice, action: constants.AuthActionNotify})
+	}
+
+	if last.Event == constants.AuthEventValidateLoginOtp && last.DeviceId == event.DeviceId {
+		return hits
+	}
+	previous, current := ipNetwork(last.Ip), ipNetwork(event.Ip)
+	if previous != "" && current != "" && previous != current && last.DeviceId != event.DeviceId &&
+		event.CreatedAt.Sub(last.CreatedAt) <= constants.AuthTravelWindowMins*time.Minute {
+		hits = append(hits, authRuleHit{rule: constants.AuthRuleImpossibleTravel, action: constants.AuthActionStepUp, previousIp: last.Ip})
+	}
+	return hits
+}
+
+// checkAuthRestrictions refuses logins of a locked client, and of a client
+// that has to step up when the flow is not the email OTP one. Logins are let
+// through if the restrictions cannot be read.
+func checkAuthRestrictions(id string, stepUpApplies bool, reqH models.ReqHeader) (int, apihelpers.APIRes, bool) {
+	clientId, _ := authIdentity(id)
+	if clientId == "" {
+		return 0, apihelpers.APIRes{}, false
+	}
+	state, err := CallFetchAuthSecurity(clientId)
+	if err != nil {
+		loggerconfig.Error("checkAuthRestrictions, mongo error:", err, " clientId:", clientId, " requestId:", reqH.RequestId)
+		return 0, apihelpers.APIRes{}, false
+	}
+	if state.Locked && lockExpired(state, authAuditNow()) {
+		loggerconfig.Info("checkAuthRestrictions, lock expired, reason:", state.LockReason, " clientId:", clientId, " requestId:", reqH.RequestId)
+		state.Locked = false
+	}
+	if state.Locked {
+		loggerconfig.Info("checkAuthRestrictions, client locked, reason:", state.LockReason, " clientId:", clientId, " requestId:", reqH.RequestId)
+		code, res := apihelpers.SendErrorResponse(false, constants.AuthAccountLocked, http.StatusForbidden)
+		return code, res, true
+	}
+	if state.StepUp && stepUpApplies {
+		loggerconfig.Info("checkAuthRestrictions, step-up required, reason:", state.StepUpReason, " clientId:", clientId, " requestId:", reqH.RequestId)
+		code, res := apihelpers.SendErrorResponse(false, constants.AuthStepUpRequired, http.StatusForbidden)
+		return code, res, true
+	}
+	return 0, apihelpers.APIRes{}, false
+}
+
+// lockExpired reports whether a failedTwoFa lock has run out. Anyone who knows
+// a client ID can fail its 2FA, so that lock only holds off guessing for
+// AuthFailedTwoFaLockMins, other locks stay until the account is unblocked.
+func lockExpired(state models.MongoAuthSecurity, now time.Time) bool {
+	return state.LockReason == constants.AuthRuleFailedTwoFa && !now.Before(state.LockedAt.Add(constants.AuthFailedTwoFaLockMins*time.Minute))
+}
+
+/*
+auditAuthEvent records the outcome of an auth flow and applies the rules to
+2FA logins. A login that trips a step-up has its token blocked and is answered
+with AuthStepUpRequired instead, every other response is returned as it was.
+Failures to record or apply are only logged so logins never depend on them.
+*/
+func auditAuthEvent(eventName string, id string, reqH models.ReqHeader, statusCode int, res apihelpers.APIRes) (int, apihelpers.APIRes) {
+	clientId, emailId := authIdentity(id)
+	loginId, authToken := authResult(res)
+	if loginId != "" {
+		// the client tradelab logged in, id is whatever the caller sent
+		clientId = strings.ToUpper(loginId)
+	}
+
+	event := models.MongoAuthEvent{
+		ClientId:   clientId,
+		EmailId:    emailId,
+		Event:      eventName,
+		Outcome:    authOutcome(statusCode, res),
+		StatusCode: statusCode,
+		ErrorCode:  res.ErrorCode,
+		Message:    res.Message,
+		DeviceId:   reqH.DeviceId,
+		DeviceType: reqH.DeviceType,
+		Platform:   reqH.Platform,
+		Ip:         reqH.ClientPublicIP,
+		AppVersion: reqH.ClientVersion,
+		CreatedAt:  authAuditNow(),
+	}
+
+	if clientId != "" && event.Outcome == constants.AuthOutcomeSuccess {
+		switch eventName {
+		case constants.AuthEventUnblockUser:
+			clearAuthRestrictions(clientId, false, reqH)
+		case constants.AuthEventValidateLoginOtp:
+			// only for the client tradelab answered with, never the caller's id
+			if loginId != "" {
+				clearAuthRestrictions(clientId, true, reqH)
+			}
+		}
+	}
+
+	if clientId != "" && isTwoFaEvent(eventName) {
+		since := event.CreatedAt.AddDate(0, 0, -constants.AuthKnownDeviceLookbackDay)
+		history, err := CallFetchAuthEvents(clientId, authHistoryEvents, since)
+		if err != nil {
+			loggerconfig.Error("auditAuthEvent, error fetching auth history:", err, " clientId:", clientId, " requestId:", reqH.RequestId)
+		} else {
+			for _, hit := range matchAuthRules(event, history) {
+				event.Rules = append(event.Rules, hit.rule)
+				if applyAuthRule(hit, event, authToken, reqH) {
+					statusCode, res = apihelpers.SendErrorResponse(false, constants.AuthStepUpRequired, http.StatusForbidden)
+					event.Outcome = constants.AuthOutcomeBlocked
+					event.StatusCode = statusCode
+					event.ErrorCode = res.ErrorCode
+					event.Message = res.Message
+				}
+			}
+		}
+	}
+
+	if err := CallRecordAuthEvent(event); err != nil {
+		loggerconfig.Error("auditAuthEvent, error recording auth event:", err, " event:", eventName, " clientId:", clientId, " requestId:", reqH.RequestId)
+	}
+	return statusCode, res
+}
+
+func isTwoFaEvent(eventName string) bool {
+	for _, name := range authTwoFaEvents {
+		if name == eventName {
+			return true
+		}
+	}
+	return false
+}
+
+// applyAuthRule acts on a rule and tells the client about it. It reports
+// whether the token of the login has to be withheld.
+func applyAuthRule(hit authRuleHit, event models.MongoAuthEvent, authToken string, reqH models.ReqHeader) bool {
+	loggerconfig.Info("applyAuthRule, rule:", hit.rule, " action:", hit.action, " clientId:", event.ClientId, " deviceId:", event.DeviceId, " ip:", event.Ip, " requestId:", reqH.RequestId)
+
+	withhold := false
+	if hit.action == constants.AuthActionLock || hit.action == constants.AuthActionStepUp {
+		state, err := CallFetchAuthSecurity(event.ClientId)
+		if err != nil {
+			loggerconfig.Error("Alert Severity:P1-High, applyAuthRule, error fetching auth security:", err, " rule:", hit.rule, " clientId:", event.ClientId, " requestId:", reqH.RequestId)
+			return false
+		}
+		if hit.action == constants.AuthActionLock {
+			state.Locked, state.LockReason, state.LockedAt = true, hit.rule, event.CreatedAt
+		} else {
+			state.StepUp, state.StepUpReason, state.StepUpAt = true, hit.rule, event.CreatedAt
+		}
+		state.ClientId = event.ClientId
+		state.UpdatedAt = event.CreatedAt
+		if err := CallSaveAuthSecurity(state); err != nil {
+			loggerconfig.Error("Alert Severity:P1-High, applyAuthRule, error saving auth security:", err, " rule:", hit.rule, " clientId:", event.ClientId, " requestId:", reqH.RequestId)
+			return false
+		}
+
+		if hit.action == constants.AuthActionStepUp && authToken != "" {
+			if err := CallBlockSession(event.ClientId, SessionId(authToken), constants.AuthStepUpBlockMins); err != nil {
+				loggerconfig.Error("Alert Severity:P1-High, applyAuthRule, error blocking token of step-up login:", err, " clientId:", event.ClientId, " requestId:", reqH.RequestId)
+			}
+			withhold = true
+		}
+	}
+
+	alert := models.AuthSecurityAlert{
+		ClientId:   event.ClientId,
+		Rule:       hit.rule,
+		Action:     hit.action,
+		Event:      event.Event,
+		DeviceId:   event.DeviceId,
+		DeviceType: event.DeviceType,
+		Platform:   event.Platform,
+		Ip:         event.Ip,
+		PreviousIp: hit.previousIp,
+		CreatedAt:  event.CreatedAt.Unix(),
+	}
+	if err := CallPublishSecurityAlert(alert); err != nil {
+		loggerconfig.Error("applyAuthRule, error publishing security alert:", err, " rule:", hit.rule, " clientId:", event.ClientId, " requestId:", reqH.RequestId)
+	}
+	return withhold
+}
+
+// clearAuthRestrictions lifts a lock and step-up after the account is
+// unblocked, or only the step-up after an email OTP login.
+func clearAuthRestrictions(clientId string, stepUpOnly bool, reqH models.ReqHeader) {
+	state, err := CallFetchAuthSecurity(clientId)
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, clearAuthRestrictions, mongo error:", err, " clientId:", clientId, " requestId:", reqH.RequestId)
+		return
+	}
+	if !state.StepUp && (stepUpOnly || !state.Locked) {
+		return
+	}
+	state.StepUp, state.StepUpReason = false, ""
+	if !stepUpOnly {
+		state.Locked, state.LockReason = false, ""
+	}
+	state.ClientId = clientId
+	state.UpdatedAt = authAuditNow()
+	if err := CallSaveAuthSecurity(state); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, clearAuthRestrictions, mongo error:", err, " clientId:", clientId, " requestId:", reqH.RequestId)
+		return
+	}
+	loggerconfig.Info("clearAuthRestrictions, restrictions lifted, stepUpOnly:", stepUpOnly, " clientId:", clientId, " requestId:", reqH.RequestId)
+}
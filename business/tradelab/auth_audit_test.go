@@ -0,0 +1,250 @@
+package tradelab
+
+import (
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+)
+
+type fakeAuthStore struct {
+	events  []models.MongoAuthEvent
+	states  map[string]models.MongoAuthSecurity
+	alerts  []models.AuthSecurityAlert
+	blocked map[string]int
+}
+
+func TestMain(m *testing.M) {
+	// the login flows are tested without mongo, so their auth events are
+	// dropped and the clients they reset have no sessions to revoke
+	CallRecordAuthEvent = func(event models.MongoAuthEvent) error { return nil }
+	CallFetchAuthEvents = func(clientId string, events []string, since time.Time) ([]models.MongoAuthEvent, error) {
+		return nil, nil
+	}
+	CallFetchAuthSecurity = func(clientId string) (models.MongoAuthSecurity, error) {
+		return models.MongoAuthSecurity{ClientId: clientId}, nil
+	}
+	CallSaveAuthSecurity = func(state models.MongoAuthSecurity) error { return nil }
+	CallPublishSecurityAlert = func(alert models.AuthSecurityAlert) error { return nil }
+	CallFetchClientSessions = func(clientId string, now time.Time) ([]models.MongoClientSession, error) { return nil, nil }
+	authAuditNow = time.Now
+	os.Exit(m.Run())
+}
+
+func TestIpNetwork(t *testing.T) {
+	tests := map[string]string{
+		"49.36.12.7":             "49.36.0.0",
+		" 49.36.200.1 ":          "49.36.0.0",
+		"2401:4900:1c2a:9::1":    "2401:4900::",
+		"::ffff:49.36.12.7":      "49.36.0.0",
+		"":                       "",
+		"not-an-ip":              "",
+		"49.36.12.7, 10.0.0.1":   "",
+		"2401:4900:ffff:ffff::1": "2401:4900::",
+	}
+	for ip, want := range tests {
+		if got := ipNetwork(ip); got != want {
+			t.Errorf("ipNetwork(%q) = %q, want %q", ip, got, want)
+		}
+	}
+}
+
+func TestMatchAuthRules(t *testing.T) {
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, constants.LocationKolkata)
+	at := func(mins int) time.Time { return now.Add(time.Duration(-mins) * time.Minute) }
+	twoFa := func(outcome string, device string, ip string, mins int) models.MongoAuthEvent {
+		return models.MongoAuthEvent{Event: constants.AuthEventValidateTwofaV2, Outcome: outcome, DeviceId: device, Ip: ip, CreatedAt: at(mins)}
+	}
+	failures := func(n int, mins int) []models.MongoAuthEvent {
+		var events []models.MongoAuthEvent
+		for i := 0; i < n; i++ {
+			events = append(events, twoFa(constants.AuthOutcomeFailure, "phone", "49.36.1.1", mins))
+		}
+		return events
+	}
+
+	tests := []struct {
+		name    string
+		event   models.MongoAuthEvent
+		history []models.MongoAuthEvent
+		rules   []string
+	}{
+		{"first login", twoFa(constants.AuthOutcomeSuccess, "phone", "49.36.1.1", 0), nil, nil},
+		{"known device", twoFa(constants.AuthOutcomeSuccess, "phone", "49.36.1.1", 0),
+			[]models.MongoAuthEvent{twoFa(constants.AuthOutcomeSuccess, "phone", "49.36.9.9", 120)}, nil},
+		{"new device same network", twoFa(constants.AuthOutcomeSuccess, "laptop", "49.36.1.1", 0),
+			[]models.MongoAuthEvent{twoFa(constants.AuthOutcomeSuccess, "phone", "49.36.9.9", 10)}, []string{constants.AuthRuleNewDevice}},
+		{"new device other network", twoFa(constants.AuthOutcomeSuccess, "laptop", "103.5.1.1", 0),
+			[]models.MongoAuthEvent{twoFa(constants.AuthOutcomeSuccess, "phone", "49.36.9.9", 10)}, []string{constants.AuthRuleNewDevice, constants.AuthRuleImpossibleTravel}},
+		{"other network after the window", twoFa(constants.AuthOutcomeSuccess, "laptop", "103.5.1.1", 0),
+			[]models.MongoAuthEvent{twoFa(constants.AuthOutcomeSuccess, "laptop", "49.36.9.9", constants.AuthTravelWindowMins+1)}, nil},
+		{"same device moving network", twoFa(constants.AuthOutcomeSuccess, "phone", "103.5.1.1", 0),
+			[]models.MongoAuthEvent{twoFa(constants.AuthOutcomeSuccess, "phone", "49.36.9.9", 5)}, nil},
+		{"after email otp", twoFa(constants.AuthOutcomeSuccess, "laptop", "103.5.1.1", 0),
+			[]models.MongoAuthEvent{
+				{Event: constants.AuthEventValidateLoginOtp, Outcome: constants.AuthOutcomeSuccess, DeviceId: "laptop", Ip: "103.5.1.1", CreatedAt: at(1)},
+				twoFa(constants.AuthOutcomeSuccess, "phone", "49.36.9.9", 10),
+			}, nil},
+		{"failures below limit", twoFa(constants.AuthOutcomeFailure, "phone", "49.36.1.1", 0),
+			failures(constants.AuthFailedTwoFaLimit-2, 1), nil},
+		{"failures at limit", twoFa(constants.AuthOutcomeFailure, "phone", "49.36.1.1", 0),
+			failures(constants.AuthFailedTwoFaLimit-1, 1), []string{constants.AuthRuleFailedTwoFa}},
+		{"old failures", twoFa(constants.AuthOutcomeFailure, "phone", "49.36.1.1", 0),
+			failures(constants.AuthFailedTwoFaLimit-1, constants.AuthFailedTwoFaWindowMins+1), nil},
+		{"blocked attempt", twoFa(constants.AuthOutcomeBlocked, "phone", "49.36.1.1", 0),
+			failures(constants.AuthFailedTwoFaLimit, 1), nil},
+	}
+	for _, tt := range tests {
+		var rules []string
+		for _, hit := range matchAuthRules(tt.event, tt.history) {
+			rules = append(rules, hit.rule)
+		}
+		if !reflect.DeepEqual(rules, tt.rules) {
+			t.Errorf("%s: matchAuthRules() = %v, want %v", tt.name, rules, tt.rules)
+		}
+	}
+}
+
+func TestAuditAuthEvent(t *testing.T) {
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, constants.LocationKolkata)
+
+	origRecord, origFetch, origFetchState, origSave, origPublish, origBlock := CallRecordAuthEvent, CallFetchAuthEvents, CallFetchAuthSecurity, CallSaveAuthSecurity, CallPublishSecurityAlert, CallBlockSession
+	origNow, origInfo, origError := authAuditNow, loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		CallRecordAuthEvent, CallFetchAuthEvents, CallFetchAuthSecurity, CallSaveAuthSecurity, CallPublishSecurityAlert, CallBlockSession = origRecord, origFetch, origFetchState, origSave, origPublish, origBlock
+		authAuditNow, loggerconfig.Info, loggerconfig.Error = origNow, origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+	authAuditNow = func() time.Time { return now }
+	store := &fakeAuthStore{states: map[string]models.MongoAuthSecurity{}, blocked: map[string]int{}}
+	CallRecordAuthEvent = func(event models.MongoAuthEvent) error {
+		store.events = append(store.events, event)
+		return nil
+	}
+	CallFetchAuthEvents = func(clientId string, events []string, since time.Time) ([]models.MongoAuthEvent, error) {
+		var history []models.MongoAuthEvent
+		for i := len(store.events) - 1; i >= 0; i-- {
+			event := store.events[i]
+			if event.ClientId != clientId || event.CreatedAt.Before(since) {
+				continue
+			}
+			for _, name := range events {
+				if event.Event == name {
+					history = append(history, event)
+				}
+			}
+		}
+		return history, nil
+	}
+	CallFetchAuthSecurity = func(clientId string) (models.MongoAuthSecurity, error) {
+		if state, ok := store.states[clientId]; ok {
+			return state, nil
+		}
+		return models.MongoAuthSecurity{ClientId: clientId}, nil
+	}
+	CallSaveAuthSecurity = func(state models.MongoAuthSecurity) error {
+		store.states[state.ClientId] = state
+		return nil
+	}
+	CallPublishSecurityAlert = func(alert models.AuthSecurityAlert) error {
+		store.alerts = append(store.alerts, alert)
+		return nil
+	}
+	CallBlockSession = func(clientId string, sessionId string, ttlMins int) error {
+		store.blocked[sessionId] = ttlMins
+		return nil
+	}
+
+	phone := models.ReqHeader{DeviceId: "phone", ClientPublicIP: "49.36.1.1", RequestId: "r1"}
+	laptop := models.ReqHeader{DeviceId: "laptop", ClientPublicIP: "103.5.1.1", RequestId: "r2"}
+	success := func(token string) apihelpers.APIRes {
+		return apihelpers.APIRes{Status: true, Message: "SUCCESS", Data: models.ValidateTwofaV2Res{AuthToken: token}}
+	}
+	failure := apihelpers.APIRes{Status: false, Message: "Invalid Answer", ErrorCode: "400"}
+
+	code, res := auditAuthEvent(constants.AuthEventValidateTwofaV2, "abc123", phone, http.StatusOK, success("token1"))
+	if code != http.StatusOK || !res.Status {
+		t.Fatalf("first login = %d %+v", code, res)
+	}
+	if len(store.events) != 1 || store.events[0].ClientId != "ABC123" || store.events[0].Outcome != constants.AuthOutcomeSuccess || store.events[0].DeviceId != "phone" {
+		t.Fatalf("recorded events = %+v", store.events)
+	}
+
+	// a login from another device and network straight after is held back
+	now = now.Add(10 * time.Minute)
+	code, res = auditAuthEvent(constants.AuthEventValidateTwofaV2, "ABC123", laptop, http.StatusOK, success("token2"))
+	if code != http.StatusForbidden || res.ErrorCode != constants.AuthStepUpRequired || res.Data != nil {
+		t.Fatalf("impossible travel = %d %+v", code, res)
+	}
+	if _, ok := store.blocked[SessionId("token2")]; !ok {
+		t.Errorf("token of the step-up login was not blocked")
+	}
+	if !store.states["ABC123"].StepUp || len(store.alerts) != 2 {
+		t.Errorf("state = %+v alerts = %+v", store.states["ABC123"], store.alerts)
+	}
+	if last := store.events[len(store.events)-1]; last.Outcome != constants.AuthOutcomeBlocked || !reflect.DeepEqual(last.Rules, []string{constants.AuthRuleNewDevice, constants.AuthRuleImpossibleTravel}) {
+		t.Errorf("step-up event = %+v", last)
+	}
+	if code, res, blocked := checkAuthRestrictions("abc123", true, laptop); !blocked || code != http.StatusForbidden || res.ErrorCode != constants.AuthStepUpRequired {
+		t.Errorf("password login during step-up = %d %+v %v", code, res, blocked)
+	}
+	if _, _, blocked := checkAuthRestrictions("abc123", false, laptop); blocked {
+		t.Errorf("step-up should not stop flows it does not apply to")
+	}
+
+	// an email OTP login of another client cannot clear it, whatever id it claims
+	now = now.Add(time.Minute)
+	auditAuthEvent(constants.AuthEventValidateLoginOtp, "abc123", laptop, http.StatusOK, apihelpers.APIRes{Status: true, Data: models.LoginV2Response{LoginID: "xyz999"}})
+	auditAuthEvent(constants.AuthEventValidateLoginOtp, "abc123", laptop, http.StatusOK, apihelpers.APIRes{Status: true})
+	if !store.states["ABC123"].StepUp {
+		t.Fatalf("email otp login of another client cleared step-up")
+	}
+
+	// an email OTP login answers the step-up and lets the laptop through
+	auditAuthEvent(constants.AuthEventValidateLoginOtp, "user@example.com", laptop, http.StatusOK, apihelpers.APIRes{Status: true, Data: models.LoginV2Response{LoginID: "abc123"}})
+	if store.states["ABC123"].StepUp {
+		t.Fatalf("email otp login did not clear step-up")
+	}
+	if code, res := auditAuthEvent(constants.AuthEventValidateTwofaV2, "ABC123", laptop, http.StatusOK, success("token3")); code != http.StatusOK || !res.Status {
+		t.Errorf("login after email otp = %d %+v", code, res)
+	}
+
+	// repeated wrong answers lock the client for a while or until it is unblocked
+	for i := 0; i < constants.AuthFailedTwoFaLimit; i++ {
+		now = now.Add(time.Minute)
+		if code, _ := auditAuthEvent(constants.AuthEventValidateTwoFa, "ABC123", phone, http.StatusBadRequest, failure); code != http.StatusBadRequest {
+			t.Fatalf("failed attempt %d = %d", i+1, code)
+		}
+	}
+	if state := store.states["ABC123"]; !state.Locked || state.LockReason != constants.AuthRuleFailedTwoFa {
+		t.Fatalf("state after failures = %+v", state)
+	}
+	if code, res, blocked := checkAuthRestrictions("ABC123", false, phone); !blocked || res.ErrorCode != constants.AuthAccountLocked {
+		t.Errorf("locked client = %d %+v %v", code, res, blocked)
+	}
+	// anyone can fail 2FA for a client ID, so that lock runs out
+	now = now.Add(constants.AuthFailedTwoFaLockMins*time.Minute - time.Second)
+	if _, _, blocked := checkAuthRestrictions("ABC123", false, phone); !blocked {
+		t.Errorf("failedTwoFa lock ran out early")
+	}
+	now = now.Add(time.Second)
+	if _, _, blocked := checkAuthRestrictions("ABC123", false, phone); blocked {
+		t.Errorf("failedTwoFa lock did not expire")
+	}
+
+	auditAuthEvent(constants.AuthEventUnblockUser, "abc123", phone, http.StatusBadRequest, failure)
+	if !store.states["ABC123"].Locked {
+		t.Errorf("failed unblock lifted the lock")
+	}
+	auditAuthEvent(constants.AuthEventUnblockUser, "abc123", phone, http.StatusOK, apihelpers.APIRes{Status: true, Message: "SUCCESS"})
+	if _, _, blocked := checkAuthRestrictions("ABC123", true, phone); blocked {
+		t.Errorf("unblock did not lift the lock")
+	}
+}
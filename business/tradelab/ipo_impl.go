@@ -645,6 +645,9 @@ func (obj IpoObj) PlaceIpoOrder(placeIpoOrderRequest models.PlaceIpoOrderRequest
 	placeIpoOrderResponse.Data = tlPlaceIpoRes.Data
 
 	loggerconfig.Info("placeIpoOrderRes tl resp=", helpers.LogStructAsJSON(placeIpoOrderResponse), " uccId:", placeIpoOrderRequest.ClientID, " StatusCode: ", res.StatusCode, " requestId:", reqH.RequestId)
+	if tlPlaceIpoRes.Success {
+		go recordIpoApplication(placeIpoOrderRequest, tlPlaceIpoRes.Data, reqH)
+	}
 
 	apiRes.Data = placeIpoOrderResponse
 	apiRes.Message = tlPlaceIpoRes.Message
@@ -784,6 +787,7 @@ func (obj IpoObj) FetchIpoOrder(fetchIpoOrderRequest models.FetchIpoOrderRequest
 		allResponseData = append(allResponseData, resData)
 	}
 	fetchIpoOrderResponse.Data = allResponseData
+	go trackIpoOrders(fetchIpoOrderRequest.ClientID, allResponseData, reqH)
 
 	maskedFetchIpoOrderResponse, err := maskObj.Struct(fetchIpoOrderResponse)
 	if err != nil {
@@ -0,0 +1,495 @@
+package tradelab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/dbops"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ipoNow = helpers.GetCurrentTimeInIST
+
+// ipoPollRequestId marks FetchIpoOrder calls made by the poller, which must not
+// extend the lifetime of the session they borrow.
+const ipoPollRequestId = "ipoApplicationPoll"
+
+// ipoSyncLocks serialises syncs of the same client on this instance so a poll
+// and a user fetch do not record the same transition twice.
+var ipoSyncLocks sync.Map
+
+var ipoTerminalStatuses = []string{
+	constants.IpoApplicationMandateRejected,
+	constants.IpoApplicationRefunded,
+	constants.IpoApplicationListed,
+	constants.IpoApplicationCancelled,
+}
+
+var CallPublishIpoTransition = func(event models.IpoApplicationEvent) error {
+	return helpers.PublishMessage(constants.TopicExchange, constants.KeyIpoApplicationStatus, event)
+}
+
+var CallRecordIpoApplication = func(app models.MongoIpoApplication) error {
+	filter := bson.M{"clientId": app.ClientId, "symbol": app.Symbol, "status": bson.M{"$nin": ipoTerminalStatuses}}
+	update := bson.M{"$setOnInsert": app}
+	return dbops.MongoRepo.UpdateOne(constants.IPOAPPLICATIONSCOLLECTION, filter, update, options.Update().SetUpsert(true))
+}
+
+var CallFetchIpoApplications = func(clientId string) ([]models.MongoIpoApplication, error) {
+	cursor, err := dbops.MongoRepo.Find(constants.IPOAPPLICATIONSCOLLECTION, bson.M{"clientId": clientId})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var apps []models.MongoIpoApplication
+	if err := cursor.All(context.Background(), &apps); err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+// CallSaveIpoApplication stores app if the stored copy still has the status
+// and update time it was read with, and reports whether it did.
+var CallSaveIpoApplication = func(app models.MongoIpoApplication, prev models.MongoIpoApplication, isNew bool) (bool, error) {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.IPOAPPLICATIONSCOLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if isNew {
+		filter := bson.M{"clientId": app.ClientId, "applicationNumber": app.ApplicationNumber}
+		res, err := coll.UpdateOne(ctx, filter, bson.M{"$setOnInsert": app}, options.Update().SetUpsert(true))
+		if err != nil {
+			return false, err
+		}
+		return res.UpsertedCount > 0, nil
+	}
+
+	filter := bson.M{"clientId": prev.ClientId, "symbol": prev.Symbol, "applicationNumber": prev.ApplicationNumber, "status": prev.Status, "updatedAt": prev.UpdatedAt}
+	res, err := coll.UpdateOne(ctx, filter, bson.M{"$set": app})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+var CallFetchIpoCalendar = func(reqH models.ReqHeader) (map[string]models.IpoState, error) {
+	status, res := InitIpoProvider(nil, nil).GetAllIpo(models.GetAllIpoRequest{}, reqH)
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("GetAllIpo status %d %s", status, res.Message)
+	}
+	ipos, ok := res.Data.(models.GetAllIpoResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected GetAllIpo response")
+	}
+	calendar := make(map[string]models.IpoState)
+	for _, list := range [][]models.IpoState{ipos.AllIpo.Data, ipos.OpenIpo, ipos.UpcomingIpo, ipos.ClosedIpo} {
+		for _, ipo := range list {
+			calendar[ipo.Symbol] = ipo
+		}
+	}
+	return calendar, nil
+}
+
+var CallFetchIpoOrders = func(clientId string, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	return InitIpoProvider(nil, nil).FetchIpoOrder(models.FetchIpoOrderRequest{ClientID: clientId}, reqH)
+}
+
+// FetchIpoHistory returns the client's IPO applications with every status
+// change recorded for them, latest application first.
+func (obj IpoObj) FetchIpoHistory(req models.FetchIpoHistoryRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	apps, err := CallFetchIpoApplications(req.ClientID)
+	if err != nil {
+		loggerconfig.Error("FetchIpoHistory mongo error:", err, " clientId:", req.ClientID, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	sort.SliceStable(apps, func(i, j int) bool { return apps[i].CreatedAt > apps[j].CreatedAt })
+	if apps == nil {
+		apps = []models.MongoIpoApplication{}
+	}
+
+	apiRes.Data = models.FetchIpoHistoryResponse{Applications: apps}
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// recordIpoApplication starts tracking an application accepted by Tradelab. A
+// modification of an application still in progress keeps its history.
+func recordIpoApplication(req models.PlaceIpoOrderRequest, data interface{}, reqH models.ReqHeader) {
+	defer models.HandlePanic()
+
+	if !isNewIpoApplication(req) {
+		return
+	}
+	now := ipoNow().Unix()
+	app := models.MongoIpoApplication{
+		ClientId:          req.ClientID,
+		Symbol:            req.Symbol,
+		ApplicationNumber: ipoApplicationNumber(data),
+		Category:          req.Category,
+		Status:            constants.IpoApplicationSubmitted,
+		History:           []models.IpoApplicationTransition{{To: constants.IpoApplicationSubmitted, At: now}},
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	for _, bid := range req.Bids {
+		app.Quantity += bid.Quantity
+		app.Amount += bid.Amount
+	}
+	if err := CallRecordIpoApplication(app); err != nil {
+		loggerconfig.Error("recordIpoApplication mongo error:", err, " clientId:", req.ClientID, " symbol:", req.Symbol, " requestId:", reqH.RequestId)
+		return
+	}
+	rememberIpoSession(req.ClientID, reqH)
+}
+
+func isNewIpoApplication(req models.PlaceIpoOrderRequest) bool {
+	for _, bid := range req.Bids {
+		activity := strings.ToLower(bid.ActivityType)
+		if activity != "modify" && activity != "cancel" {
+			return true
+		}
+	}
+	return len(req.Bids) == 0
+}
+
+// ipoApplicationNumber reads the application number from Tradelab's place
+// order payload when it carries one.
+func ipoApplicationNumber(data interface{}) string {
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	for _, key := range []string{"application_number", "applicationNumber", "application_no"} {
+		if value, ok := fields[key]; ok && value != nil {
+			return fmt.Sprintf("%v", value)
+		}
+	}
+	return ""
+}
+
+// rememberIpoSession keeps the client's session so the poller can refresh
+// their applications while it stays valid.
+func rememberIpoSession(clientId string, reqH models.ReqHeader) {
+	if clientId == "" || reqH.Authorization == "" || reqH.RequestId == ipoPollRequestId {
+		return
+	}
+	session := models.ReqHeader{
+		Authorization:  reqH.Authorization,
+		DeviceType:     reqH.DeviceType,
+		DeviceId:       reqH.DeviceId,
+		Platform:       reqH.Platform,
+		ClientPublicIP: reqH.ClientPublicIP,
+		ClientId:       clientId,
+	}
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return
+	}
+	if err := dbops.RedisRepo.Set(constants.IpoApplicationSessionKeyPrefix+clientId, string(raw), constants.IpoApplicationSessionHours*time.Hour); err != nil {
+		loggerconfig.Error("rememberIpoSession redis error:", err, " clientId:", clientId)
+	}
+}
+
+// trackIpoOrders syncs the applications behind a FetchIpoOrder response and
+// keeps the session for the poller while any of them is in progress.
+func trackIpoOrders(clientId string, orders []models.FetchIpoOrderResponseData, reqH models.ReqHeader) {
+	defer models.HandlePanic()
+
+	if syncIpoApplications(clientId, orders, reqH) {
+		rememberIpoSession(clientId, reqH)
+	}
+}
+
+// syncIpoApplications moves the client's applications forward to the state
+// Tradelab reports and publishes each change. It reports whether any
+// application is still in progress.
+func syncIpoApplications(clientId string, orders []models.FetchIpoOrderResponseData, reqH models.ReqHeader) bool {
+	defer models.HandlePanic()
+
+	lock, _ := ipoSyncLocks.LoadOrStore(clientId, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	apps, err := CallFetchIpoApplications(clientId)
+	if err != nil {
+		loggerconfig.Error("syncIpoApplications mongo error:", err, " clientId:", clientId, " requestId:", reqH.RequestId)
+		return false
+	}
+
+	var calendar map[string]models.IpoState
+	if needsIpoCalendar(orders) {
+		calendar, err = CallFetchIpoCalendar(reqH)
+		if err != nil {
+			// listing and refund wait for the next sync
+			loggerconfig.Error("syncIpoApplications calendar error:", err, " clientId:", clientId, " requestId:", reqH.RequestId)
+		}
+	}
+
+	now := ipoNow()
+	for _, order := range orders {
+		idx := matchIpoApplication(apps, order)
+		isNew := idx < 0
+		var app models.MongoIpoApplication
+		if isNew {
+			if order.ApplicationNumber == "" {
+				continue
+			}
+			app = models.MongoIpoApplication{
+				ClientId:  clientId,
+				Symbol:    order.Symbol,
+				Status:    constants.IpoApplicationSubmitted,
+				History:   []models.IpoApplicationTransition{{To: constants.IpoApplicationSubmitted, At: now.Unix()}},
+				CreatedAt: now.Unix(),
+			}
+		} else {
+			app = apps[idx]
+		}
+		prev := app
+
+		to, reason := deriveIpoApplicationStatus(order, calendar[order.Symbol], now)
+		transition, moved := advanceIpoApplication(&app, to, reason, now.Unix())
+		filled := fillIpoApplication(&app, order)
+		if !isNew && !moved && !filled {
+			continue
+		}
+		app.UpdatedAt = now.Unix()
+
+		saved, err := CallSaveIpoApplication(app, prev, isNew)
+		if err != nil {
+			loggerconfig.Error("syncIpoApplications save error:", err, " clientId:", clientId, " applicationNumber:", order.ApplicationNumber, " requestId:", reqH.RequestId)
+			continue
+		}
+		if isNew {
+			apps = append(apps, app)
+		} else {
+			apps[idx] = app
+		}
+		if saved && moved {
+			publishIpoTransition(app, transition)
+		}
+	}
+
+	for _, app := range apps {
+		if !isIpoApplicationTerminal(app.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+func publishIpoTransition(app models.MongoIpoApplication, transition models.IpoApplicationTransition) {
+	event := models.IpoApplicationEvent{
+		ClientId:          app.ClientId,
+		Symbol:            app.Symbol,
+		ApplicationNumber: app.ApplicationNumber,
+		From:              transition.From,
+		To:                transition.To,
+		Reason:            transition.Reason,
+		At:                transition.At,
+	}
+	if err := CallPublishIpoTransition(event); err != nil {
+		loggerconfig.Error("publishIpoTransition error:", err, " clientId:", app.ClientId, " applicationNumber:", app.ApplicationNumber)
+	}
+}
+
+func needsIpoCalendar(orders []models.FetchIpoOrderResponseData) bool {
+	for _, order := range orders {
+		if order.AllotmentQuantity > 0 || order.AllotmentStatus != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchIpoApplication finds the stored application for an order, first by
+// application number and then as the unnumbered live application of the IPO.
+func matchIpoApplication(apps []models.MongoIpoApplication, order models.FetchIpoOrderResponseData) int {
+	for i, app := range apps {
+		if order.ApplicationNumber != "" && app.ApplicationNumber == order.ApplicationNumber {
+			return i
+		}
+	}
+	for i, app := range apps {
+		if app.ApplicationNumber == "" && app.Symbol == order.Symbol && !isIpoApplicationTerminal(app.Status) {
+			return i
+		}
+	}
+	return -1
+}
+
+// fillIpoApplication copies the order details Tradelab knows better than the
+// placed request and reports whether anything changed.
+func fillIpoApplication(app *models.MongoIpoApplication, order models.FetchIpoOrderResponseData) bool {
+	before := *app
+	if order.ApplicationNumber != "" {
+		app.ApplicationNumber = order.ApplicationNumber
+	}
+	if order.Category != "" {
+		app.Category = order.Category
+	}
+	if len(order.Bids) > 0 {
+		app.Quantity, app.Amount = 0, 0
+		for _, bid := range order.Bids {
+			app.Quantity += bid.Quantity
+			app.Amount += bid.Amount
+		}
+	}
+	app.AllotmentQuantity = order.AllotmentQuantity
+	return app.ApplicationNumber != before.ApplicationNumber || app.Category != before.Category ||
+		app.Quantity != before.Quantity || app.Amount != before.Amount || app.AllotmentQuantity != before.AllotmentQuantity
+}
+
+// deriveIpoApplicationStatus maps a Tradelab order to the furthest state it
+// has reached. Listing and refund come from the IPO calendar.
+func deriveIpoApplicationStatus(order models.FetchIpoOrderResponseData, ipo models.IpoState, now time.Time) (string, string) {
+	orderStatus := strings.ToLower(order.Status)
+	if strings.Contains(orderStatus, "cancel") {
+		return constants.IpoApplicationCancelled, order.Reason
+	}
+	if strings.Contains(orderStatus, "reject") || strings.Contains(orderStatus, "fail") {
+		return constants.IpoApplicationMandateRejected, firstNonEmpty(order.Reason, order.UpiPaymentStatusMessage)
+	}
+
+	allotment := strings.ToLower(order.AllotmentStatus)
+	notAllotted := strings.Contains(allotment, "not") || strings.Contains(allotment, "non")
+	if order.AllotmentQuantity > 0 || (strings.Contains(allotment, "allot") && !notAllotted) {
+		if ipoDateReached(ipo.ListingDate, now) {
+			return constants.IpoApplicationListed, ""
+		}
+		return constants.IpoApplicationAllotted, ""
+	}
+	if notAllotted {
+		if ipoDateReached(ipo.RefundDate, now) {
+			return constants.IpoApplicationRefunded, ""
+		}
+		return constants.IpoApplicationNotAllotted, ""
+	}
+
+	upi := strings.ToLower(order.UpiPaymentStatusMessage)
+	for _, word := range []string{"reject", "declin", "fail", "expir", "not accept"} {
+		if strings.Contains(upi, word) {
+			return constants.IpoApplicationMandateRejected, order.UpiPaymentStatusMessage
+		}
+	}
+	if order.ExchangeUpdatedUpiBlockedAmount > 0 {
+		return constants.IpoApplicationMandateAccepted, ""
+	}
+	for _, word := range []string{"accept", "success", "approv", "block"} {
+		if strings.Contains(upi, word) {
+			return constants.IpoApplicationMandateAccepted, ""
+		}
+	}
+	if upi != "" {
+		return constants.IpoApplicationMandatePending, ""
+	}
+	return constants.IpoApplicationSubmitted, ""
+}
+
+// advanceIpoApplication moves app to state `to` when it lies ahead of the
+// current state; anything else, including a repeat, is ignored.
+func advanceIpoApplication(app *models.MongoIpoApplication, to, reason string, at int64) (models.IpoApplicationTransition, bool) {
+	if app.Status == to || !ipoStateReachable(app.Status, to) {
+		return models.IpoApplicationTransition{}, false
+	}
+	transition := models.IpoApplicationTransition{From: app.Status, To: to, Reason: reason, At: at}
+	app.Status = to
+	app.History = append(app.History, transition)
+	return transition, true
+}
+
+func ipoStateReachable(from, to string) bool {
+	for _, next := range constants.IpoApplicationTransitions[from] {
+		if next == to || ipoStateReachable(next, to) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIpoApplicationTerminal(status string) bool {
+	return len(constants.IpoApplicationTransitions[status]) == 0
+}
+
+func ipoDateReached(value string, now time.Time) bool {
+	date, ok := parseIpoDate(value, now.Location())
+	return ok && !date.After(now)
+}
+
+func parseIpoDate(value string, loc *time.Location) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"02-01-2006", "2006-01-02", time.RFC3339, "02-Jan-2006", "Jan 2, 2006"} {
+		if date, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// RefreshIpoApplications polls Tradelab for every client with an application
+// in progress whose session is still remembered. FetchIpoOrder syncs the
+// applications it returns.
+func RefreshIpoApplications() {
+	for {
+		time.Sleep(constants.IpoApplicationPollMins * time.Minute)
+		refreshIpoApplications()
+	}
+}
+
+func refreshIpoApplications() {
+	defer models.HandlePanic()
+
+	clientIds, err := dbops.MongoRepo.FindDistinct(constants.IPOAPPLICATIONSCOLLECTION, "clientId", bson.M{"status": bson.M{"$nin": ipoTerminalStatuses}})
+	if err != nil {
+		loggerconfig.Error("refreshIpoApplications mongo error:", err)
+		return
+	}
+	for _, id := range clientIds {
+		clientId, ok := id.(string)
+		if !ok {
+			continue
+		}
+		raw, err := dbops.RedisRepo.Get(constants.IpoApplicationSessionKeyPrefix + clientId)
+		if err != nil {
+			continue
+		}
+		var reqH models.ReqHeader
+		if err := json.Unmarshal([]byte(raw), &reqH); err != nil {
+			continue
+		}
+		reqH.RequestId = ipoPollRequestId
+		status, res := CallFetchIpoOrders(clientId, reqH)
+		if status == http.StatusUnauthorized || status == http.StatusForbidden {
+			_ = dbops.RedisRepo.Delete(constants.IpoApplicationSessionKeyPrefix + clientId)
+			continue
+		}
+		if status != http.StatusOK {
+			loggerconfig.Error("refreshIpoApplications FetchIpoOrder status:", status, " message:", res.Message, " clientId:", clientId)
+		}
+	}
+}
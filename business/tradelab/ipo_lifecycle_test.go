@@ -0,0 +1,162 @@
+package tradelab
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+)
+
+func TestDeriveIpoApplicationStatus(t *testing.T) {
+	now := time.Date(2026, 10, 21, 11, 0, 0, 0, constants.LocationKolkata)
+	listed := models.IpoState{ListingDate: "21-10-2026", RefundDate: "20-10-2026"}
+	upcoming := models.IpoState{ListingDate: "24-10-2026", RefundDate: "23-10-2026"}
+
+	tests := []struct {
+		name   string
+		order  models.FetchIpoOrderResponseData
+		ipo    models.IpoState
+		status string
+		reason string
+	}{
+		{"placed", models.FetchIpoOrderResponseData{Status: "success"}, upcoming, constants.IpoApplicationSubmitted, ""},
+		{"mandate sent", models.FetchIpoOrderResponseData{UpiPaymentStatusMessage: "Mandate request sent to UPI app"}, upcoming, constants.IpoApplicationMandatePending, ""},
+		{"mandate accepted", models.FetchIpoOrderResponseData{UpiPaymentStatusMessage: "Mandate Accepted"}, upcoming, constants.IpoApplicationMandateAccepted, ""},
+		{"amount blocked", models.FetchIpoOrderResponseData{UpiPaymentStatusMessage: "pending", ExchangeUpdatedUpiBlockedAmount: 14800}, upcoming, constants.IpoApplicationMandateAccepted, ""},
+		{"mandate not accepted", models.FetchIpoOrderResponseData{UpiPaymentStatusMessage: "Mandate not accepted by investor"}, upcoming, constants.IpoApplicationMandateRejected, "Mandate not accepted by investor"},
+		{"mandate expired", models.FetchIpoOrderResponseData{UpiPaymentStatusMessage: "Mandate Expired"}, upcoming, constants.IpoApplicationMandateRejected, "Mandate Expired"},
+		{"order rejected", models.FetchIpoOrderResponseData{Status: "REJECTED", Reason: "Invalid DP id"}, upcoming, constants.IpoApplicationMandateRejected, "Invalid DP id"},
+		{"cancelled", models.FetchIpoOrderResponseData{Status: "cancelled", UpiPaymentStatusMessage: "Mandate Accepted"}, upcoming, constants.IpoApplicationCancelled, ""},
+		{"allotted", models.FetchIpoOrderResponseData{AllotmentStatus: "Allotted", AllotmentQuantity: 40}, upcoming, constants.IpoApplicationAllotted, ""},
+		{"listed", models.FetchIpoOrderResponseData{AllotmentQuantity: 40}, listed, constants.IpoApplicationListed, ""},
+		{"not allotted", models.FetchIpoOrderResponseData{AllotmentStatus: "Not Allotted"}, upcoming, constants.IpoApplicationNotAllotted, ""},
+		{"refunded", models.FetchIpoOrderResponseData{AllotmentStatus: "NON-ALLOTTED"}, listed, constants.IpoApplicationRefunded, ""},
+		{"no calendar", models.FetchIpoOrderResponseData{AllotmentQuantity: 40}, models.IpoState{}, constants.IpoApplicationAllotted, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, reason := deriveIpoApplicationStatus(tt.order, tt.ipo, now)
+			if status != tt.status || reason != tt.reason {
+				t.Errorf("deriveIpoApplicationStatus() = %q, %q, want %q, %q", status, reason, tt.status, tt.reason)
+			}
+		})
+	}
+}
+
+func TestAdvanceIpoApplication(t *testing.T) {
+	app := models.MongoIpoApplication{Status: constants.IpoApplicationSubmitted}
+
+	transition, moved := advanceIpoApplication(&app, constants.IpoApplicationMandateAccepted, "", 10)
+	if !moved || transition.From != constants.IpoApplicationSubmitted || transition.To != constants.IpoApplicationMandateAccepted {
+		t.Fatalf("advanceIpoApplication() = %+v, %v", transition, moved)
+	}
+
+	// repeats and moves backwards are ignored
+	for _, to := range []string{constants.IpoApplicationMandateAccepted, constants.IpoApplicationMandatePending, constants.IpoApplicationSubmitted} {
+		if _, moved := advanceIpoApplication(&app, to, "", 20); moved {
+			t.Errorf("advanceIpoApplication() moved %s to %s", constants.IpoApplicationMandateAccepted, to)
+		}
+	}
+
+	// a missed poll skips the states in between
+	if _, moved := advanceIpoApplication(&app, constants.IpoApplicationListed, "", 30); !moved {
+		t.Fatal("advanceIpoApplication() did not move to listed")
+	}
+	if _, moved := advanceIpoApplication(&app, constants.IpoApplicationCancelled, "", 40); moved {
+		t.Error("advanceIpoApplication() moved a listed application")
+	}
+
+	want := []models.IpoApplicationTransition{
+		{From: constants.IpoApplicationSubmitted, To: constants.IpoApplicationMandateAccepted, At: 10},
+		{From: constants.IpoApplicationMandateAccepted, To: constants.IpoApplicationListed, At: 30},
+	}
+	if !reflect.DeepEqual(app.History, want) {
+		t.Errorf("History = %+v, want %+v", app.History, want)
+	}
+}
+
+func TestIpoApplicationRequestHelpers(t *testing.T) {
+	newBid := models.PlaceIpoOrderRequest{Bids: []models.PlaceIpoOrderRequestBids{{ActivityType: "new"}}}
+	modify := models.PlaceIpoOrderRequest{Bids: []models.PlaceIpoOrderRequestBids{{ActivityType: "modify"}, {ActivityType: "cancel"}}}
+	if !isNewIpoApplication(newBid) || isNewIpoApplication(modify) {
+		t.Error("isNewIpoApplication() misread the bid activity")
+	}
+
+	if got := ipoApplicationNumber(map[string]interface{}{"application_number": "1200456"}); got != "1200456" {
+		t.Errorf("ipoApplicationNumber() = %q", got)
+	}
+	if got := ipoApplicationNumber("placed"); got != "" {
+		t.Errorf("ipoApplicationNumber() = %q, want empty", got)
+	}
+}
+
+func TestSyncIpoApplications(t *testing.T) {
+	origNow, origError := ipoNow, loggerconfig.Error
+	origFetch, origSave, origPublish, origCalendar := CallFetchIpoApplications, CallSaveIpoApplication, CallPublishIpoTransition, CallFetchIpoCalendar
+	t.Cleanup(func() {
+		ipoNow, loggerconfig.Error = origNow, origError
+		CallFetchIpoApplications, CallSaveIpoApplication, CallPublishIpoTransition, CallFetchIpoCalendar = origFetch, origSave, origPublish, origCalendar
+	})
+	loggerconfig.Error = func(args ...interface{}) {}
+	ipoNow = func() time.Time { return time.Date(2026, 10, 21, 11, 0, 0, 0, constants.LocationKolkata) }
+
+	stored := []models.MongoIpoApplication{
+		{ClientId: "AB123", Symbol: "ACME", Status: constants.IpoApplicationSubmitted, CreatedAt: 1, UpdatedAt: 1},
+		{ClientId: "AB123", Symbol: "OLDCO", ApplicationNumber: "900", Status: constants.IpoApplicationListed, CreatedAt: 1, UpdatedAt: 1},
+	}
+	CallFetchIpoApplications = func(clientId string) ([]models.MongoIpoApplication, error) {
+		return append([]models.MongoIpoApplication(nil), stored...), nil
+	}
+	var saved []models.MongoIpoApplication
+	CallSaveIpoApplication = func(app, prev models.MongoIpoApplication, isNew bool) (bool, error) {
+		saved = append(saved, app)
+		return true, nil
+	}
+	var events []models.IpoApplicationEvent
+	CallPublishIpoTransition = func(event models.IpoApplicationEvent) error {
+		events = append(events, event)
+		return nil
+	}
+	CallFetchIpoCalendar = func(reqH models.ReqHeader) (map[string]models.IpoState, error) {
+		t.Fatal("the calendar is only needed once allotment is out")
+		return nil, nil
+	}
+
+	orders := []models.FetchIpoOrderResponseData{
+		{Symbol: "ACME", ApplicationNumber: "1200456", UpiPaymentStatusMessage: "Mandate Accepted", Bids: []models.FetchIpoOrderResponseBids{{Quantity: 40, Amount: 14800}}},
+		{Symbol: "OLDCO", ApplicationNumber: "900", UpiPaymentStatusMessage: "Mandate Accepted"},
+		{Symbol: "NEWCO", ApplicationNumber: "1300", UpiPaymentStatusMessage: "Mandate request sent"},
+	}
+	if !syncIpoApplications("AB123", orders, models.ReqHeader{}) {
+		t.Error("syncIpoApplications() reported no application in progress")
+	}
+
+	if len(saved) != 2 || saved[0].ApplicationNumber != "1200456" || saved[0].Quantity != 40 || saved[1].Symbol != "NEWCO" {
+		t.Fatalf("saved = %+v", saved)
+	}
+	want := []models.IpoApplicationEvent{
+		{ClientId: "AB123", Symbol: "ACME", ApplicationNumber: "1200456", From: constants.IpoApplicationSubmitted, To: constants.IpoApplicationMandateAccepted, At: ipoNow().Unix()},
+		{ClientId: "AB123", Symbol: "NEWCO", ApplicationNumber: "1300", From: constants.IpoApplicationSubmitted, To: constants.IpoApplicationMandatePending, At: ipoNow().Unix()},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %+v, want %+v", events, want)
+	}
+
+	// a sync that loses the race to another instance publishes nothing
+	stored = saved[:1]
+	saved, events = nil, nil
+	CallSaveIpoApplication = func(app, prev models.MongoIpoApplication, isNew bool) (bool, error) {
+		saved = append(saved, app)
+		return false, nil
+	}
+	CallFetchIpoCalendar = func(reqH models.ReqHeader) (map[string]models.IpoState, error) {
+		return map[string]models.IpoState{"ACME": {ListingDate: "24-10-2026"}}, nil
+	}
+	syncIpoApplications("AB123", []models.FetchIpoOrderResponseData{{Symbol: "ACME", ApplicationNumber: "1200456", AllotmentQuantity: 40, Bids: []models.FetchIpoOrderResponseBids{{Quantity: 40, Amount: 14800}}}}, models.ReqHeader{})
+	if len(saved) != 1 || saved[0].Status != constants.IpoApplicationAllotted || len(events) != 0 {
+		t.Errorf("saved = %+v, events = %+v", saved, events)
+	}
+}
@@ -0,0 +1,429 @@
+package tradelab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	technicalindicatorsV2 "space/business/technicalIndicatorsV2"
+	"space/constants"
+	"space/dbops"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ipoNumberPattern = regexp.MustCompile(`-?\d+(\.\d+)?`)
+
+var CallFetchOpenEIpos = func() ([]map[string]interface{}, error) {
+	cursor, err := dbops.MongoRepo.Find(constants.EIPODataCollection, bson.M{"status": constants.CURRENT})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var docs []map[string]interface{}
+	if err := cursor.All(context.Background(), &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// CallFetchIpoGmp returns the latest grey market premium scraped for an IPO.
+var CallFetchIpoGmp = func(name string) (float64, bool) {
+	var gmpData models.FetchIpoGmpDataResponse
+	if err := dbops.MongoRepo.FindOne(constants.IPOGMPDATA, bson.M{"ipo_name": name}, &gmpData); err != nil {
+		return 0, false
+	}
+	return parseIpoGmp(gmpData.GmpDetails)
+}
+
+var CallSaveIpoSubscription = func(snapshot models.MongoIpoSubscription) error {
+	filter := bson.M{"symbol": snapshot.Symbol, "sourceUpdatedAt": snapshot.SourceUpdatedAt}
+	return dbops.MongoRepo.UpdateOne(constants.IPOSUBSCRIPTIONSCOLLECTION, filter, bson.M{"$setOnInsert": snapshot}, options.Update().SetUpsert(true))
+}
+
+var CallFetchIpoSubscriptions = func(symbol string) ([]models.MongoIpoSubscription, error) {
+	cursor, err := dbops.MongoRepo.Find(constants.IPOSUBSCRIPTIONSCOLLECTION, bson.M{"symbol": symbol})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var snapshots []models.MongoIpoSubscription
+	if err := cursor.All(context.Background(), &snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+var CallFetchIpoListing = func(symbol string) (models.MongoIpoListing, error) {
+	var listing models.MongoIpoListing
+	err := dbops.MongoRepo.FindOne(constants.IPOLISTINGCOLLECTION, bson.M{"symbol": symbol}, &listing)
+	return listing, err
+}
+
+var CallSaveIpoListing = func(listing models.MongoIpoListing) error {
+	return dbops.MongoRepo.UpdateOne(constants.IPOLISTINGCOLLECTION, bson.M{"symbol": listing.Symbol}, bson.M{"$set": listing}, options.Update().SetUpsert(true))
+}
+
+// CallResolveIpoContract finds the listed contract of an IPO, NSE first.
+var CallResolveIpoContract = func(isin string) (string, string, error) {
+	contractCache := cache.GetContractCacheClientObj()
+	for _, exchange := range []string{strings.ToUpper(constants.NSE), strings.ToUpper(constants.BSE)} {
+		err, val := contractCache.GetFromHash("isin_data", exchange+"-"+isin)
+		if err != nil {
+			continue
+		}
+		var contract models.ContractDetails
+		if err := json.Unmarshal([]byte(val), &contract); err != nil || contract.Token1 == "" {
+			continue
+		}
+		return exchange, contract.Token1, nil
+	}
+	return "", "", fmt.Errorf("no contract for isin %s", isin)
+}
+
+// CallFetchIpoListingCandle returns the open and close of the listing day.
+var CallFetchIpoListingCandle = func(exchange, token string, day time.Time, reqH models.ReqHeader) (float64, float64, error) {
+	err, chartData := technicalindicatorsV2.GetCachedChartData(models.ChartDataReq{
+		Exchange:     exchange,
+		Token:        token,
+		CandleType:   "3",
+		DataDuration: "1",
+		StartTime:    strconv.FormatInt(day.Unix(), 10),
+		EndTime:      strconv.FormatInt(day.AddDate(0, 0, 1).Unix()-1, 10),
+	}, reqH)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(chartData.Data.Candles) == 0 || len(chartData.Data.Candles[0]) < 5 {
+		return 0, 0, errors.New("no listing day candle")
+	}
+	candle := chartData.Data.Candles[0]
+	open, okOpen := candle[1].(float64)
+	closePrice, okClose := candle[4].(float64)
+	if !okOpen || !okClose {
+		return 0, 0, errors.New("malformed listing day candle")
+	}
+	return open, closePrice, nil
+}
+
+// FetchIpoSubscriptionHistory returns the subscription and GMP history of an
+// IPO as chart series and, once it has listed, how the listing compared with
+// the issue price and the GMP.
+func (obj IpoObj) FetchIpoSubscriptionHistory(req models.FetchIpoSubscriptionHistoryRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	snapshots, err := CallFetchIpoSubscriptions(req.Symbol)
+	if err != nil {
+		loggerconfig.Error("FetchIpoSubscriptionHistory mongo error:", err, " symbol:", req.Symbol, " clientID:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].CapturedAt < snapshots[j].CapturedAt })
+
+	resp := models.FetchIpoSubscriptionHistoryResponse{
+		Symbol: req.Symbol,
+		Series: ipoSubscriptionSeries(snapshots),
+	}
+	if len(snapshots) > 0 {
+		resp.Latest = &snapshots[len(snapshots)-1]
+	}
+	resp.Listing = ipoListingAnalytics(req.Symbol, snapshots, reqH)
+
+	apiRes.Data = resp
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// RecordIpoSubscriptions snapshots the subscription of every IPO in its bid
+// window. A snapshot is kept per exchange update, so instances polling the
+// same data store it once.
+func RecordIpoSubscriptions() {
+	for {
+		time.Sleep(constants.IpoSubscriptionSnapshotMins * time.Minute)
+		recordIpoSubscriptions()
+	}
+}
+
+func recordIpoSubscriptions() {
+	defer models.HandlePanic()
+
+	docs, err := CallFetchOpenEIpos()
+	if err != nil {
+		loggerconfig.Error("recordIpoSubscriptions mongo error:", err)
+		return
+	}
+	now := ipoNow()
+	for _, doc := range docs {
+		snapshot, ok := ipoSubscriptionSnapshot(doc, now)
+		if !ok {
+			continue
+		}
+		if snapshot.Name != "" {
+			if gmp, ok := CallFetchIpoGmp(snapshot.Name); ok {
+				snapshot.Gmp = &gmp
+			}
+		}
+		if err := CallSaveIpoSubscription(snapshot); err != nil {
+			loggerconfig.Error("recordIpoSubscriptions save error:", err, " symbol:", snapshot.Symbol)
+		}
+	}
+}
+
+// ipoSubscriptionSnapshot reads the per category subscription multiples from
+// an NSE e-IPO document.
+func ipoSubscriptionSnapshot(doc map[string]interface{}, now time.Time) (models.MongoIpoSubscription, bool) {
+	snapshot := models.MongoIpoSubscription{Multiples: make(map[string]float64), CapturedAt: now.Unix()}
+	snapshot.Symbol, _ = doc["symbol"].(string)
+	if snapshot.Symbol == "" {
+		return snapshot, false
+	}
+	if ipoData, ok := doc["ipodata"].(map[string]interface{}); ok {
+		snapshot.Name, _ = ipoData["companyName"].(string)
+	}
+
+	if aggregated, ok := doc["eipoaggregateddata"].(map[string]interface{}); ok {
+		for _, section := range []struct{ name, list string }{{"activeCat", "dataList"}, {"bidDetails", "data"}} {
+			block, ok := aggregated[section.name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			rows, ok := block[section.list].(primitive.A)
+			if !ok {
+				continue
+			}
+			for _, row := range filterDataList(rows) {
+				category := ipoSubscriptionCategory(fmt.Sprintf("%v", row["category"]))
+				if category == "" {
+					continue
+				}
+				if _, seen := snapshot.Multiples[category]; seen {
+					continue
+				}
+				if multiple, ok := ipoSubscriptionMultiple(row); ok {
+					snapshot.Multiples[category] = multiple
+				}
+			}
+			if len(snapshot.Multiples) > 0 {
+				if updateTime, ok := block["updateTime"]; ok && updateTime != nil {
+					snapshot.SourceUpdatedAt = fmt.Sprintf("%v", updateTime)
+				}
+				break
+			}
+		}
+	}
+	if _, ok := snapshot.Multiples[constants.IpoCategoryTotal]; !ok {
+		if total, ok := parseIpoNumber(extractSubscriptionTimes(doc, models.ReqHeader{})); ok {
+			snapshot.Multiples[constants.IpoCategoryTotal] = total
+		}
+	}
+	if len(snapshot.Multiples) == 0 {
+		return snapshot, false
+	}
+	if snapshot.SourceUpdatedAt == "" {
+		bucket := now.Truncate(constants.IpoSubscriptionSnapshotMins * time.Minute)
+		snapshot.SourceUpdatedAt = bucket.Format(time.RFC3339)
+	}
+	return snapshot, true
+}
+
+func ipoSubscriptionCategory(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.Contains(name, "employee"):
+		return constants.IpoCategoryEmployee
+	case strings.Contains(name, "retail") || strings.Contains(name, "rii"):
+		return constants.IpoCategoryRii
+	case strings.Contains(name, "non institutional") || strings.Contains(name, "non-institutional") || strings.Contains(name, "nii") || strings.Contains(name, "hni"):
+		return constants.IpoCategoryNii
+	case strings.Contains(name, "qualified") || strings.Contains(name, "qib"):
+		return constants.IpoCategoryQib
+	case strings.Contains(name, "total"):
+		return constants.IpoCategoryTotal
+	}
+	return ""
+}
+
+func ipoSubscriptionMultiple(row map[string]interface{}) (float64, bool) {
+	for _, key := range []string{"noOfTotalMeant", "noOfTimesSubscribed", "noOfTimesIssueSubscribed"} {
+		if multiple, ok := parseIpoNumber(row[key]); ok {
+			return multiple, true
+		}
+	}
+	bid, okBid := parseIpoNumber(row["noOfSharesBid"])
+	offered, okOffered := parseIpoNumber(row["noOfShareOffered"])
+	if !okOffered {
+		offered, okOffered = parseIpoNumber(row["noOfSharesOffered"])
+	}
+	if !okBid || !okOffered || offered <= 0 {
+		return 0, false
+	}
+	return roundIpoValue(bid / offered), true
+}
+
+func parseIpoNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case string:
+		match := ipoNumberPattern.FindString(strings.ReplaceAll(v, ",", ""))
+		if match == "" {
+			return 0, false
+		}
+		number, err := strconv.ParseFloat(match, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// parseIpoGmp reads the premium from the scraped GMP lines, which list the
+// latest premium first.
+func parseIpoGmp(details []string) (float64, bool) {
+	for _, line := range details {
+		if gmp, ok := parseIpoNumber(line); ok {
+			return gmp, true
+		}
+	}
+	return 0, false
+}
+
+// ipoSubscriptionSeries lays the snapshots out as one series per category in
+// a fixed order followed by the GMP.
+func ipoSubscriptionSeries(snapshots []models.MongoIpoSubscription) []models.IpoSubscriptionSeries {
+	series := []models.IpoSubscriptionSeries{}
+	for _, category := range constants.IpoSubscriptionCategories {
+		points := []models.IpoSeriesPoint{}
+		for _, snapshot := range snapshots {
+			if multiple, ok := snapshot.Multiples[category]; ok {
+				points = append(points, models.IpoSeriesPoint{At: snapshot.CapturedAt, Value: multiple})
+			}
+		}
+		if len(points) > 0 {
+			series = append(series, models.IpoSubscriptionSeries{Name: category, Points: points})
+		}
+	}
+
+	gmpPoints := []models.IpoSeriesPoint{}
+	for _, snapshot := range snapshots {
+		if snapshot.Gmp != nil {
+			gmpPoints = append(gmpPoints, models.IpoSeriesPoint{At: snapshot.CapturedAt, Value: *snapshot.Gmp})
+		}
+	}
+	if len(gmpPoints) > 0 {
+		series = append(series, models.IpoSubscriptionSeries{Name: constants.IpoSeriesGmp, Points: gmpPoints})
+	}
+	return series
+}
+
+// ipoListingAnalytics compares the listing with the issue price and the GMP.
+// It is stored once the listing day has closed; until then it is computed on
+// each request.
+func ipoListingAnalytics(symbol string, snapshots []models.MongoIpoSubscription, reqH models.ReqHeader) *models.MongoIpoListing {
+	if listing, err := CallFetchIpoListing(symbol); err == nil {
+		return &listing
+	} else if err.Error() != constants.MongoNoDocError {
+		loggerconfig.Error("ipoListingAnalytics mongo error:", err, " symbol:", symbol, " requestId:", reqH.RequestId)
+	}
+
+	calendar, err := CallFetchIpoCalendar(reqH)
+	if err != nil {
+		loggerconfig.Error("ipoListingAnalytics calendar error:", err, " symbol:", symbol, " requestId:", reqH.RequestId)
+		return nil
+	}
+	ipo, ok := calendar[symbol]
+	if !ok {
+		return nil
+	}
+	now := ipoNow()
+	listingDay, ok := parseIpoDate(ipo.ListingDate, now.Location())
+	if !ok || listingDay.After(now) {
+		return nil
+	}
+
+	exchange, token, err := CallResolveIpoContract(ipo.Isin)
+	if err != nil {
+		loggerconfig.Error("ipoListingAnalytics contract error:", err, " symbol:", symbol, " requestId:", reqH.RequestId)
+		return nil
+	}
+	open, closePrice, err := CallFetchIpoListingCandle(exchange, token, listingDay, reqH)
+	if err != nil {
+		loggerconfig.Error("ipoListingAnalytics candle error:", err, " symbol:", symbol, " requestId:", reqH.RequestId)
+		return nil
+	}
+
+	gmp, hasGmp := CallFetchIpoGmp(ipo.Name)
+	if !hasGmp {
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			if snapshots[i].Gmp != nil {
+				gmp, hasGmp = *snapshots[i].Gmp, true
+				break
+			}
+		}
+	}
+
+	listing, ok := buildIpoListing(ipo, exchange, open, closePrice, gmp, hasGmp, now)
+	if !ok {
+		return nil
+	}
+	if listingDay.AddDate(0, 0, 1).Before(now) {
+		if err := CallSaveIpoListing(listing); err != nil {
+			loggerconfig.Error("ipoListingAnalytics save error:", err, " symbol:", symbol, " requestId:", reqH.RequestId)
+		}
+	}
+	return &listing
+}
+
+func buildIpoListing(ipo models.IpoState, exchange string, open, closePrice, gmp float64, hasGmp bool, now time.Time) (models.MongoIpoListing, bool) {
+	issuePrice := ipo.CutOffPrice
+	if issuePrice <= 0 {
+		issuePrice = ipo.MaxPrice
+	}
+	if issuePrice <= 0 || open <= 0 {
+		return models.MongoIpoListing{}, false
+	}
+	listing := models.MongoIpoListing{
+		Symbol:          ipo.Symbol,
+		ListingDate:     ipo.ListingDate,
+		Exchange:        exchange,
+		IssuePrice:      issuePrice,
+		ListingPrice:    open,
+		ListingDayClose: closePrice,
+		ListingGainPct:  roundIpoValue((open - issuePrice) / issuePrice * 100),
+		ComputedAt:      now.Unix(),
+	}
+	if hasGmp {
+		implied := issuePrice + gmp
+		listing.Gmp = &gmp
+		listing.GmpImpliedPrice = &implied
+		if implied > 0 {
+			gain := roundIpoValue((open - implied) / implied * 100)
+			listing.GainVsGmpPct = &gain
+		}
+	}
+	return listing, true
+}
+
+func roundIpoValue(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,158 @@
+package tradelab
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestIpoSubscriptionSnapshot(t *testing.T) {
+	origInfo := loggerconfig.Info
+	t.Cleanup(func() { loggerconfig.Info = origInfo })
+	loggerconfig.Info = func(args ...interface{}) {}
+	now := time.Date(2026, 10, 21, 11, 10, 0, 0, constants.LocationKolkata)
+
+	doc := map[string]interface{}{
+		"symbol":  "ACME",
+		"ipodata": map[string]interface{}{"companyName": "Acme Industries Limited"},
+		"eipoaggregateddata": map[string]interface{}{
+			"activeCat": map[string]interface{}{
+				"updateTime": "21-Oct-2026 11:00:00",
+				"dataList": primitive.A{
+					map[string]interface{}{"srNo": "1", "category": "Qualified Institutional Buyers(QIBs)", "noOfTotalMeant": "0.85"},
+					map[string]interface{}{"srNo": "1(a)", "category": "Anchor Investors", "noOfTotalMeant": "1"},
+					map[string]interface{}{"srNo": "2", "category": "Non Institutional Investors", "noOfShareOffered": "2,00,000", "noOfSharesBid": "5,00,000"},
+					map[string]interface{}{"srNo": "3", "category": "Retail Individual Investors(RIIs)", "noOfTotalMeant": 3.4},
+					map[string]interface{}{"srNo": "4", "category": "Employees", "noOfTotalMeant": "-"},
+					map[string]interface{}{"srNo": nil, "category": "Total", "noOfTotalMeant": "2.1"},
+				},
+			},
+		},
+	}
+
+	snapshot, ok := ipoSubscriptionSnapshot(doc, now)
+	if !ok {
+		t.Fatal("ipoSubscriptionSnapshot() found no subscription")
+	}
+	want := map[string]float64{
+		constants.IpoCategoryQib:   0.85,
+		constants.IpoCategoryNii:   2.5,
+		constants.IpoCategoryRii:   3.4,
+		constants.IpoCategoryTotal: 2.1,
+	}
+	if !reflect.DeepEqual(snapshot.Multiples, want) {
+		t.Errorf("Multiples = %v, want %v", snapshot.Multiples, want)
+	}
+	if snapshot.Name != "Acme Industries Limited" || snapshot.SourceUpdatedAt != "21-Oct-2026 11:00:00" || snapshot.CapturedAt != now.Unix() {
+		t.Errorf("snapshot = %+v", snapshot)
+	}
+
+	// SME issues only carry the overall figure
+	sme := map[string]interface{}{
+		"symbol": "TINY",
+		"eipoaggregateddata": map[string]interface{}{
+			"demandGraph": map[string]interface{}{"noOfTimesIssueSubscribed": "12.40"},
+		},
+	}
+	snapshot, ok = ipoSubscriptionSnapshot(sme, now)
+	if !ok || snapshot.Multiples[constants.IpoCategoryTotal] != 12.4 || snapshot.SourceUpdatedAt != "2026-10-21T11:00:00+05:30" {
+		t.Errorf("snapshot = %+v, %v", snapshot, ok)
+	}
+
+	if _, ok := ipoSubscriptionSnapshot(map[string]interface{}{"symbol": "NONE"}, now); ok {
+		t.Error("ipoSubscriptionSnapshot() accepted a document without subscription data")
+	}
+}
+
+func TestParseIpoGmp(t *testing.T) {
+	if gmp, ok := parseIpoGmp([]string{"GMP", "₹1,045 (18.2%)", "₹980"}); !ok || gmp != 1045 {
+		t.Errorf("parseIpoGmp() = %v, %v", gmp, ok)
+	}
+	if gmp, ok := parseIpoGmp([]string{"-12"}); !ok || gmp != -12 {
+		t.Errorf("parseIpoGmp() = %v, %v", gmp, ok)
+	}
+	if _, ok := parseIpoGmp(nil); ok {
+		t.Error("parseIpoGmp() found a premium in nothing")
+	}
+}
+
+func TestIpoSubscriptionSeries(t *testing.T) {
+	gmp := 40.0
+	snapshots := []models.MongoIpoSubscription{
+		{CapturedAt: 100, Multiples: map[string]float64{constants.IpoCategoryRii: 0.5, constants.IpoCategoryTotal: 0.2}},
+		{CapturedAt: 200, Multiples: map[string]float64{constants.IpoCategoryQib: 1.1, constants.IpoCategoryRii: 1.5, constants.IpoCategoryTotal: 1.2}, Gmp: &gmp},
+	}
+	want := []models.IpoSubscriptionSeries{
+		{Name: constants.IpoCategoryQib, Points: []models.IpoSeriesPoint{{At: 200, Value: 1.1}}},
+		{Name: constants.IpoCategoryRii, Points: []models.IpoSeriesPoint{{At: 100, Value: 0.5}, {At: 200, Value: 1.5}}},
+		{Name: constants.IpoCategoryTotal, Points: []models.IpoSeriesPoint{{At: 100, Value: 0.2}, {At: 200, Value: 1.2}}},
+		{Name: constants.IpoSeriesGmp, Points: []models.IpoSeriesPoint{{At: 200, Value: 40}}},
+	}
+	if got := ipoSubscriptionSeries(snapshots); !reflect.DeepEqual(got, want) {
+		t.Errorf("ipoSubscriptionSeries() = %+v, want %+v", got, want)
+	}
+	if got := ipoSubscriptionSeries(nil); got == nil || len(got) != 0 {
+		t.Errorf("ipoSubscriptionSeries(nil) = %#v, want an empty list", got)
+	}
+}
+
+func TestIpoListingAnalytics(t *testing.T) {
+	origNow, origError, origListing, origCalendar := ipoNow, loggerconfig.Error, CallFetchIpoListing, CallFetchIpoCalendar
+	origContract, origCandle, origGmp, origSave := CallResolveIpoContract, CallFetchIpoListingCandle, CallFetchIpoGmp, CallSaveIpoListing
+	t.Cleanup(func() {
+		ipoNow, loggerconfig.Error, CallFetchIpoListing, CallFetchIpoCalendar = origNow, origError, origListing, origCalendar
+		CallResolveIpoContract, CallFetchIpoListingCandle, CallFetchIpoGmp, CallSaveIpoListing = origContract, origCandle, origGmp, origSave
+	})
+	loggerconfig.Error = func(args ...interface{}) {}
+	ipoNow = func() time.Time { return time.Date(2026, 10, 23, 16, 0, 0, 0, constants.LocationKolkata) }
+
+	CallFetchIpoListing = func(symbol string) (models.MongoIpoListing, error) {
+		return models.MongoIpoListing{}, errors.New(constants.MongoNoDocError)
+	}
+	CallFetchIpoCalendar = func(reqH models.ReqHeader) (map[string]models.IpoState, error) {
+		return map[string]models.IpoState{
+			"ACME":  {Symbol: "ACME", Name: "Acme", Isin: "INE0ACME0001", MaxPrice: 200, ListingDate: "22-10-2026"},
+			"LATER": {Symbol: "LATER", ListingDate: "30-10-2026"},
+		}, nil
+	}
+	CallResolveIpoContract = func(isin string) (string, string, error) { return "NSE", "99001", nil }
+	CallFetchIpoListingCandle = func(exchange, token string, day time.Time, reqH models.ReqHeader) (float64, float64, error) {
+		if day.Format("2006-01-02") != "2026-10-22" {
+			t.Errorf("listing candle requested for %v", day)
+		}
+		return 250, 262.5, nil
+	}
+	CallFetchIpoGmp = func(name string) (float64, bool) { return 0, false }
+	var saved []models.MongoIpoListing
+	CallSaveIpoListing = func(listing models.MongoIpoListing) error {
+		saved = append(saved, listing)
+		return nil
+	}
+
+	// the scraped GMP is gone, so the last snapshot's premium is used
+	lastGmp := 60.0
+	listing := ipoListingAnalytics("ACME", []models.MongoIpoSubscription{{Gmp: &lastGmp}}, models.ReqHeader{})
+	if listing == nil {
+		t.Fatal("ipoListingAnalytics() returned nothing for a listed IPO")
+	}
+	if listing.IssuePrice != 200 || listing.ListingPrice != 250 || listing.ListingGainPct != 25 {
+		t.Errorf("listing = %+v", listing)
+	}
+	if *listing.GmpImpliedPrice != 260 || *listing.GainVsGmpPct != -3.85 {
+		t.Errorf("gmp comparison = %v, %v", *listing.GmpImpliedPrice, *listing.GainVsGmpPct)
+	}
+	if len(saved) != 1 {
+		t.Errorf("a closed listing day was saved %d times", len(saved))
+	}
+
+	if ipoListingAnalytics("LATER", nil, models.ReqHeader{}) != nil {
+		t.Error("ipoListingAnalytics() returned a listing before the listing date")
+	}
+}
@@ -49,7 +49,10 @@ func InitLogin(mongodb db.MongoDatabase, redisCli cache.RedisCache) LoginObj {
 	return loginObj
 }
 
-func (obj LoginObj) LoginByEmail(loginReq models.LoginByEmailRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) LoginByEmail(loginReq models.LoginByEmailRequest, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventLoginByEmail, loginReq.EmailId, reqH, statusCode, authRes)
+	}()
 
 	var apiRes apihelpers.APIRes
 
@@ -68,6 +71,10 @@ func (obj LoginObj) LoginByEmail(loginReq models.LoginByEmailRequest, reqH model
 		return http.StatusOK, apiRes
 	}
 
+	if code, res, blocked := checkAuthRestrictions(clientDetails.ClientID, true, reqH); blocked {
+		return code, res
+	}
+
 	url := obj.tradeLabURL + LOGINURL
 	var tlLoginReq TradeLabLoginReq
 	tlLoginReq.Device = reqH.DeviceType
@@ -138,7 +145,13 @@ func (obj LoginObj) LoginByEmail(loginReq models.LoginByEmailRequest, reqH model
 
 }
 
-func (obj LoginObj) LoginByPass(loginReq models.LoginRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) LoginByPass(loginReq models.LoginRequest, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventLoginByPass, loginReq.UserName, reqH, statusCode, authRes)
+	}()
+	if code, res, blocked := checkAuthRestrictions(loginReq.UserName, true, reqH); blocked {
+		return code, res
+	}
 
 	url := obj.tradeLabURL + LOGINURL
 	var tlLoginReq TradeLabLoginReq
@@ -211,7 +224,14 @@ func (obj LoginObj) LoginByPass(loginReq models.LoginRequest, reqH models.ReqHea
 
 }
 
-func (obj LoginObj) ValidateTwoFa(validateTwoFaReq models.ValidateTwoFARequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) ValidateTwoFa(validateTwoFaReq models.ValidateTwoFARequest, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventValidateTwoFa, validateTwoFaReq.LoginID, reqH, statusCode, authRes)
+	}()
+	if code, res, blocked := checkAuthRestrictions(validateTwoFaReq.LoginID, true, reqH); blocked {
+		return code, res
+	}
+
 	url := obj.tradeLabURL + VERIFYTWOFA
 
 	//fill up the TL Req
@@ -280,7 +300,10 @@ func (obj LoginObj) ValidateTwoFa(validateTwoFaReq models.ValidateTwoFARequest,
 
 }
 
-func (obj LoginObj) SetTwoFaPin(setTwoFaPinReq models.SetTwoFAPinRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) SetTwoFaPin(setTwoFaPinReq models.SetTwoFAPinRequest, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventSetTwoFaPin, setTwoFaPinReq.LoginID, reqH, statusCode, authRes)
+	}()
 
 	url := obj.tradeLabURL + SETTWOFA
 
@@ -330,12 +353,19 @@ func (obj LoginObj) SetTwoFaPin(setTwoFaPinReq models.SetTwoFAPinRequest, reqH m
 
 	loggerconfig.Info("SetTwoFaPin  tl resp=", helpers.LogStructAsJSON(tlSetTwoFaPinRes), " uccId:", setTwoFaPinReq.LoginID, " StatusCode: ", res.StatusCode, " requestId:", reqH.RequestId)
 
+	// a new PIN completes a 2FA reset, and keeps only the session it was set from
+	revokeOnReset(setTwoFaPinReq.LoginID, sessionToken(reqH.Authorization), constants.SessionRevokedTwoFaReset, reqH)
+
 	apiRes.Message = "SUCCESS"
 	apiRes.Status = true
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) ForgetPassword(forgetPasswordReq models.ForgotPasswordRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) ForgetPassword(forgetPasswordReq models.ForgotPasswordRequest, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventForgetPassword, forgetPasswordReq.LoginID, reqH, statusCode, authRes)
+	}()
+
 	url := obj.tradeLabURL + FORGOTPASSWORD
 
 	//fill up the TL Req
@@ -424,7 +454,11 @@ func (obj LoginObj) ForgetPasswordEmail(req models.ForgetResetEmailRequest, reqH
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) SetPassword(setPasswordReq models.SetPasswordRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) SetPassword(setPasswordReq models.SetPasswordRequest, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventSetPassword, reqH.ClientId, reqH, statusCode, authRes)
+	}()
+
 	url := obj.tradeLabURL + SETPASSWORD
 
 	//fill up the TL Req
@@ -471,6 +505,9 @@ func (obj LoginObj) SetPassword(setPasswordReq models.SetPasswordRequest, reqH m
 
 	loggerconfig.Info("SetPassword  tl resp=", helpers.LogStructAsJSON(tlSetPasswordRes), " uccId:", reqH.ClientId, " StatusCode: ", res.StatusCode, " requestId:", reqH.RequestId)
 
+	// a changed password keeps only the session it was changed from
+	revokeOnReset(reqH.ClientId, sessionToken(reqH.Authorization), constants.SessionRevokedPasswordReset, reqH)
+
 	apiRes.Message = "SUCCESS"
 	apiRes.Status = true
 	return http.StatusOK, apiRes
@@ -502,7 +539,11 @@ func (obj LoginObj) ValidateToken(req models.ValidateTokenRequest, reqH models.R
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) ForgetResetTwoFa(forgetResetTwoFaReq models.ForgetResetTwoFaRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) ForgetResetTwoFa(forgetResetTwoFaReq models.ForgetResetTwoFaRequest, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventForgetResetTwoFa, forgetResetTwoFaReq.ClientID, reqH, statusCode, authRes)
+	}()
+
 	url := obj.tradeLabURL + FORGETRESETTWOFA + "?client_id=" + url.QueryEscape(forgetResetTwoFaReq.ClientID) + "&pan=" + forgetResetTwoFaReq.Pan
 
 	//empty payload
@@ -585,7 +626,13 @@ func (obj LoginObj) ForgetResetTwoFaEmail(req models.ForgetResetEmailRequest, re
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) LoginV2(loginReq models.LoginV2Request, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) LoginV2(loginReq models.LoginV2Request, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventLoginV2, loginReq.ID, reqH, statusCode, authRes)
+	}()
+	if code, res, blocked := checkAuthRestrictions(loginReq.ID, true, reqH); blocked {
+		return code, res
+	}
 
 	url := obj.tradeLabURL + LOGINV2URL
 	var tlLoginReq TradeLabLoginV2Req
@@ -699,7 +746,13 @@ func (obj LoginObj) LoginV2(loginReq models.LoginV2Request, reqH models.ReqHeade
 
 }
 
-func (obj LoginObj) ValidateTwofaV2(validateTwoFaReq models.ValidateTwofaV2Req, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) ValidateTwofaV2(validateTwoFaReq models.ValidateTwofaV2Req, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventValidateTwofaV2, validateTwoFaReq.LoginID, reqH, statusCode, authRes)
+	}()
+	if code, res, blocked := checkAuthRestrictions(validateTwoFaReq.LoginID, true, reqH); blocked {
+		return code, res
+	}
 
 	url := obj.tradeLabURL + TWOFAV2URL
 
@@ -769,7 +822,10 @@ func (obj LoginObj) ValidateTwofaV2(validateTwoFaReq models.ValidateTwofaV2Req,
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) SetupTotpV2(setupTotpReq models.SetupTotpV2Req, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) SetupTotpV2(setupTotpReq models.SetupTotpV2Req, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventSetupTotp, setupTotpReq.ClientID, reqH, statusCode, authRes)
+	}()
 
 	url := obj.tradeLabURL + SETTOTPV2URL
 
@@ -827,7 +883,10 @@ func (obj LoginObj) SetupTotpV2(setupTotpReq models.SetupTotpV2Req, reqH models.
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) ChooseTwofaV2(chooseTwofaReq models.ChooseTwofaV2Req, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) ChooseTwofaV2(chooseTwofaReq models.ChooseTwofaV2Req, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventChooseTwofa, chooseTwofaReq.LoginID, reqH, statusCode, authRes)
+	}()
 
 	url := obj.tradeLabURL + CHOOSETWOFAV2URL
 
@@ -881,7 +940,10 @@ func (obj LoginObj) ChooseTwofaV2(chooseTwofaReq models.ChooseTwofaV2Req, reqH m
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) ForgetTotpV2(forgetTotpReq models.ForgetTotpV2Req, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) ForgetTotpV2(forgetTotpReq models.ForgetTotpV2Req, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventForgetTotp, forgetTotpReq.LoginID, reqH, statusCode, authRes)
+	}()
 
 	url := obj.tradeLabURL + FORGETTOTPV2URL
 
@@ -929,12 +991,21 @@ func (obj LoginObj) ForgetTotpV2(forgetTotpReq models.ForgetTotpV2Req, reqH mode
 
 	loggerconfig.Info("forget Totp v2 tl resp=success", " uccId:", forgetTotpReq.LoginID, " requestId:", reqH.RequestId)
 
+	revokeOnReset(forgetTotpReq.LoginID, "", constants.SessionRevokedTwoFaReset, reqH)
+
 	apiRes.Message = "SUCCESS"
 	apiRes.Status = true
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) ValidateLoginOtpV2(validateLoginOtpReq models.ValidateLoginOtpV2Req, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) ValidateLoginOtpV2(validateLoginOtpReq models.ValidateLoginOtpV2Req, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		// the ClientId header of this flow is the email the OTP went to and is
+		// not checked, so only an email is taken from it, the client comes
+		// from tradelab's answer
+		_, emailId := authIdentity(reqH.ClientId)
+		statusCode, authRes = auditAuthEvent(constants.AuthEventValidateLoginOtp, emailId, reqH, statusCode, authRes)
+	}()
 
 	url := obj.tradeLabURL + VALIDATELOGINOTP
 
@@ -1066,7 +1137,10 @@ func (obj LoginObj) ValidateLoginOtpV2(validateLoginOtpReq models.ValidateLoginO
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) SetupBiometricV2(setupBiometricReq models.SetupBiometricV2Req, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) SetupBiometricV2(setupBiometricReq models.SetupBiometricV2Req, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventSetupBiometric, setupBiometricReq.ClientID, reqH, statusCode, authRes)
+	}()
 
 	url := obj.tradeLabURL + BIOMETRIC + "?client_id=" + url.QueryEscape(setupBiometricReq.ClientID) + "&fingerprint=" + setupBiometricReq.FingerPrint
 
@@ -1114,7 +1188,10 @@ func (obj LoginObj) SetupBiometricV2(setupBiometricReq models.SetupBiometricV2Re
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) DisableBiometricV2(disableBiometricReq models.DisableBiometricV2Req, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) DisableBiometricV2(disableBiometricReq models.DisableBiometricV2Req, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventDisableBiometric, disableBiometricReq.ClientID, reqH, statusCode, authRes)
+	}()
 
 	url := obj.tradeLabURL + BIOMETRIC + "?client_id=" + url.QueryEscape(disableBiometricReq.ClientID) + "&fingerprint=" + disableBiometricReq.FingerPrint
 
@@ -1217,7 +1294,14 @@ func (obj LoginObj) GuestUserStatus(guestUserStatusReq models.GuestUserStatusReq
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) QRWebLogin(req models.LoginWithQRReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) QRWebLogin(req models.LoginWithQRReq, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventQRWebLogin, reqH.ClientId, reqH, statusCode, authRes)
+	}()
+	if code, res, blocked := checkAuthRestrictions(reqH.ClientId, false, reqH); blocked {
+		return code, res
+	}
+
 	var apiRes apihelpers.APIRes
 
 	err := dbops.RedisRepo.Set(req.WebsocketID, reqH.Authorization, 3*time.Minute)
@@ -1231,7 +1315,10 @@ func (obj LoginObj) QRWebLogin(req models.LoginWithQRReq, reqH models.ReqHeader)
 	return http.StatusOK, apiRes
 }
 
-func (obj LoginObj) UnblockUser(reqParams models.UnblockUserReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) UnblockUser(reqParams models.UnblockUserReq, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventUnblockUser, reqParams.LoginID, reqH, statusCode, authRes)
+	}()
 
 	url := obj.tradeLabURL + UNBLOCKUSER
 
@@ -1360,11 +1447,17 @@ func (obj LoginObj) UnblockUserV2(unblockUserV2Req models.UnblockUserV2Req, reqH
 func (obj LoginObj) CreateApp(reqParams models.CreateAppReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
 	url := obj.tradelaboAuthURL + CREATEAPP
 
+	scopes, ok := ParseAppScopes(reqParams.Scope)
+	if !ok {
+		loggerconfig.Error("CreateApp invalid scope:", reqParams.Scope, " clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidAppScope, http.StatusBadRequest)
+	}
+
 	// fill TL Req
 	var tlCreateAppReq TradeLabCreateAppReq
 	tlCreateAppReq.AppName = reqParams.AppName
 	tlCreateAppReq.RedirectUris = reqParams.RedirectUris
-	tlCreateAppReq.Scope = reqParams.Scope
+	tlCreateAppReq.Scope = strings.Join(scopes, " ")
 	tlCreateAppReq.GrantTypes = reqParams.GrantTypes
 	tlCreateAppReq.Owner = reqParams.Owner
 
@@ -1418,6 +1511,7 @@ func (obj LoginObj) CreateApp(reqParams models.CreateAppReq, reqH models.ReqHead
 	appdetails.GrantTypes = tlCreateAppRes.Data.GrantTypes
 	appdetails.RedirectUris = tlCreateAppRes.Data.RedirectUris
 	appdetails.Scope = tlCreateAppRes.Data.Scope
+	appdetails.QuotaPerMin = appQuotaPerMin(reqParams.QuotaPerMin)
 
 	// adding uuid as unique identifier for state field
 	id := uuid.New().String()
@@ -1523,6 +1617,13 @@ func (obj LoginObj) DeleteApp(appId string, reqH models.ReqHeader) (int, apihelp
 
 	filter := bson.M{"appowner": reqH.ClientId}
 
+	var owner models.CreateAppRes
+	err = dbops.MongoRepo.FindOne(constants.APPDETAILS, filter, &owner)
+	if err != nil && err.Error() != constants.MongoNoDocError {
+		loggerconfig.Error("Alert Severity:P1-Critical, DeleteApp (controller), Error finding the app in mongo: ", err, " requestId: ", reqH.RequestId, "ClientID: ", reqH.ClientId, " deviceId: ", reqH.DeviceId)
+		return apihelpers.SendInternalServerError()
+	}
+
 	// Define the pull operation to remove the app with the given appId from the apps array
 	update := bson.M{
 		"$pull": bson.M{
@@ -1536,6 +1637,14 @@ func (obj LoginObj) DeleteApp(appId string, reqH models.ReqHeader) (int, apihelp
 		loggerconfig.Error("Alert Severity:P1-Critical, DeleteApp (controller), Error updating deletion in mongo: ", err, " requestId: ", reqH.RequestId, "ClientID: ", reqH.ClientId, " deviceId: ", reqH.DeviceId)
 		return apihelpers.SendInternalServerError()
 	}
+	for _, app := range owner.Apps {
+		if app.AppID != appId {
+			continue
+		}
+		if err := CallForgetAppGrant(app.AccessToken); err != nil {
+			loggerconfig.Error("Alert Severity:P1-High, DeleteApp (controller), Error clearing the cached app grant: ", err, " appId: ", appId, " requestId: ", reqH.RequestId, "ClientID: ", reqH.ClientId)
+		}
+	}
 	loggerconfig.Info("DeleteApp success response", helpers.LogStructAsJSON(tlDeleteRes), "requestId", reqH.RequestId)
 
 	apiRes.Data = tlDeleteRes
@@ -1644,6 +1753,12 @@ func (obj LoginObj) GenerateAccessToken(appState string, authCode string, reqH m
 		loggerconfig.Error("Alert Severity:P1-Critical, platform:", reqH.Platform, "GenerateAccessToken call api error =", err, "clientID: ", reqH.ClientId, " requestId:", reqH.RequestId)
 		return apihelpers.SendInternalServerError()
 	}
+	// the replaced token must not keep the grant it was cached with
+	if finalApp.AccessToken != tlOauthAccessTokenRes.AccessToken {
+		if err := CallForgetAppGrant(finalApp.AccessToken); err != nil {
+			loggerconfig.Error("Alert Severity:P1-High, GenerateAccessToken, Error clearing the cached app grant: ", err, " appId: ", finalApp.AppID, " requestId: ", reqH.RequestId, "ClientID: ", reqH.ClientId)
+		}
+	}
 
 	loggerconfig.Info("GenerateAccessToken success response:", helpers.LogStructAsJSON(tlOauthAccessTokenRes), "RequestId: ", reqH.RequestId)
 	apiRes.Data = tlOauthAccessTokenRes
@@ -1803,7 +1918,10 @@ func (obj LoginObj) GetAccessTokenV2(reqParams models.GetAccessTokenV2Req, reqH
 	}
 }
 
-func (obj LoginObj) LoginByEmailOtp(req models.LoginByEmailOtpReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+func (obj LoginObj) LoginByEmailOtp(req models.LoginByEmailOtpReq, reqH models.ReqHeader) (statusCode int, authRes apihelpers.APIRes) {
+	defer func() {
+		statusCode, authRes = auditAuthEvent(constants.AuthEventLoginByEmailOtp, req.Email, reqH, statusCode, authRes)
+	}()
 
 	loggerconfig.Info("LoginByEmailOtp (Service):", "Request Packet:", helpers.LogStructAsJSON(req), " requestid=", reqH.RequestId, "platform:", reqH.Platform, "clientVersion:", reqH.ClientVersion)
 
@@ -0,0 +1,378 @@
+package tradelab
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/dbops"
+	"space/helpers"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var sessionNow = helpers.GetCurrentTimeInIST
+
+// SessionId identifies the session of a bearer token without keeping the token.
+func SessionId(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SessionCacheKey is the redis key a validated token is cached under.
+func SessionCacheKey(clientId string, token string) string {
+	return strings.ToUpper(clientId) + "_" + SessionId(token)
+}
+
+func sessionToken(authorization string) string {
+	if len(authorization) <= 7 {
+		return ""
+	}
+	return authorization[7:]
+}
+
+var CallRecordClientSession = func(session models.MongoClientSession) error {
+	filter := bson.M{"sessionId": session.SessionId}
+	update := bson.M{
+		"$set": bson.M{
+			"deviceId":   session.DeviceId,
+			"deviceType": session.DeviceType,
+			"platform":   session.Platform,
+			"ip":         session.Ip,
+			"appVersion": session.AppVersion,
+			"lastSeenAt": session.LastSeenAt,
+			"expiresAt":  session.ExpiresAt,
+		},
+		"$setOnInsert": bson.M{
+			"clientId":  session.ClientId,
+			"status":    constants.SessionActive,
+			"createdAt": session.CreatedAt,
+		},
+	}
+	return dbops.MongoRepo.UpdateOne(constants.CLIENTSESSIONSCOLLECTION, filter, update, options.Update().SetUpsert(true))
+}
+
+// CallFetchClientSessions returns the client's sessions that are neither
+// revoked nor expired, most recently seen first.
+var CallFetchClientSessions = func(clientId string, now time.Time) ([]models.MongoClientSession, error) {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.CLIENTSESSIONSCOLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"clientId": clientId, "status": constants.SessionActive, "expiresAt": bson.M{"$gt": now}}
+	opts := options.Find().SetSort(bson.M{"lastSeenAt": -1}).SetLimit(constants.ClientSessionListLimit)
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []models.MongoClientSession
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+var CallMarkSessionsRevoked = func(clientId string, sessionIds []string, reason string, now time.Time) error {
+	coll := dbops.MongoRepo.GetMongoCollection(constants.CLIENTSESSIONSCOLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"clientId": clientId, "sessionId": bson.M{"$in": sessionIds}, "status": constants.SessionActive}
+	update := bson.M{"$set": bson.M{"status": constants.SessionRevoked, "revokedReason": reason, "revokedAt": now}}
+	_, err := coll.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// CallBlockSession drops the cached validation of a session and keeps it from
+// being accepted again until its token would have expired anyway.
+var CallBlockSession = func(clientId string, sessionId string, ttlMins int) error {
+	redisCli := cache.GetRedisClientObj()
+	if err := redisCli.SetRedis(constants.RevokedSessionKey+sessionId, "", time.Duration(ttlMins)); err != nil {
+		return err
+	}
+	return redisCli.DeleteRedis(strings.ToUpper(clientId) + "_" + sessionId)
+}
+
+// CallFetchRevokedSession reports whether a session is revoked and, if it is,
+// whether its token has already been ended at Tradelab.
+var CallFetchRevokedSession = func(sessionId string) (bool, bool, error) {
+	marker, err := cache.GetRedisClientObj().GetRedis(constants.RevokedSessionKey + sessionId).Result()
+	if err == redis.Nil {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return true, marker == constants.RevokedSessionEnded, nil
+}
+
+var CallMarkRevokedSessionEnded = func(sessionId string, ttlMins int) error {
+	return cache.GetRedisClientObj().SetRedis(constants.RevokedSessionKey+sessionId, constants.RevokedSessionEnded, time.Duration(ttlMins))
+}
+
+// CallTradelabLogout ends a token at Tradelab, the same call a client makes
+// to log out of its own device.
+var CallTradelabLogout = func(token string, reqH models.ReqHeader) error {
+	url := constants.TLURL + LOGOUTURL
+	res, err := apihelpers.CallAPIFunc(http.MethodDelete, url, new(bytes.Buffer), reqH.DeviceType, reqH.DeviceId, reqH.Platform, reqH.ClientPublicIP, "Bearer "+token)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("tradelab logout status %d", res.StatusCode)
+	}
+	return nil
+}
+
+/*
+RejectRevokedSession reports whether the token of a request belongs to a
+session logged out from another device or by a password or 2FA reset. Only a
+hash of each token is kept, so a revoked token is ended at Tradelab here, the
+first time it is presented again, and not when it is revoked. A redis error
+lets the request through rather than logging every client out.
+*/
+func RejectRevokedSession(reqH models.ReqHeader) bool {
+	token := sessionToken(reqH.Authorization)
+	if token == "" {
+		return false
+	}
+	sessionId := SessionId(token)
+	revoked, ended, err := CallFetchRevokedSession(sessionId)
+	if err != nil {
+		loggerconfig.Error("RejectRevokedSession, redis error:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return false
+	}
+	if !revoked {
+		return false
+	}
+	if ended {
+		return true
+	}
+
+	if err := CallTradelabLogout(token, reqH); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, RejectRevokedSession, error ending revoked token at tradelab:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return true
+	}
+	ttlMins := constants.RevokedSessionMinMins
+	if tokenHeader, err := helpers.ExtractTokenHeader(token); err == nil {
+		ttlMins = revokedSessionTtlMins(time.Unix(tokenHeader.Exp, 0), sessionNow())
+	}
+	if err := CallMarkRevokedSessionEnded(sessionId, ttlMins); err != nil {
+		loggerconfig.Error("RejectRevokedSession, redis error marking session ended:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+	}
+	loggerconfig.Info("RejectRevokedSession, revoked token ended at tradelab, clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+	return true
+}
+
+// revokedSessionTtlMins is how long a revoked session has to stay marked,
+// until its token would have expired anyway.
+func revokedSessionTtlMins(expiresAt time.Time, now time.Time) int {
+	ttlMins := int(math.Ceil(expiresAt.Sub(now).Minutes()))
+	if ttlMins < constants.RevokedSessionMinMins {
+		ttlMins = constants.RevokedSessionMinMins
+	}
+	return ttlMins
+}
+
+// RecordClientSession adds a validated token to the client's sessions, or
+// refreshes the device details and last seen time of one already there.
+func RecordClientSession(reqH models.ReqHeader, token string, expiresAt int64) {
+	now := sessionNow()
+	session := models.MongoClientSession{
+		SessionId:  SessionId(token),
+		ClientId:   strings.ToUpper(reqH.ClientId),
+		DeviceId:   reqH.DeviceId,
+		DeviceType: reqH.DeviceType,
+		Platform:   reqH.Platform,
+		Ip:         reqH.ClientPublicIP,
+		AppVersion: reqH.ClientVersion,
+		Status:     constants.SessionActive,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  time.Unix(expiresAt, 0).In(now.Location()),
+	}
+	if err := CallRecordClientSession(session); err != nil {
+		loggerconfig.Error("RecordClientSession, mongo error:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+	}
+}
+
+// CallClaimSessionSeen reports whether a session is due to be written back,
+// holding off further writes for SessionSeenMins when it is.
+var CallClaimSessionSeen = func(sessionId string) (bool, error) {
+	return cache.GetRedisClientObj().SetRedisNX(constants.SessionSeenKey+sessionId, "", time.Duration(constants.SessionSeenMins))
+}
+
+// TouchClientSession records the session of a token on any request, at most
+// once every SessionSeenMins so cached tokens keep their last seen time
+// current without a mongo write per request.
+func TouchClientSession(reqH models.ReqHeader, token string, expiresAt int64) {
+	due, err := CallClaimSessionSeen(SessionId(token))
+	if err != nil {
+		loggerconfig.Error("TouchClientSession, redis error:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return
+	}
+	if due {
+		RecordClientSession(reqH, token, expiresAt)
+	}
+}
+
+// RevokeClientSessions logs out every active session of a client except the
+// one of keepToken, which may be empty, and returns how many were revoked.
+func RevokeClientSessions(clientId string, keepToken string, reason string, requestId string) (int, error) {
+	clientId = strings.ToUpper(clientId)
+	now := sessionNow()
+	sessions, err := CallFetchClientSessions(clientId, now)
+	if err != nil {
+		return 0, err
+	}
+
+	keep := ""
+	if keepToken != "" {
+		keep = SessionId(keepToken)
+	}
+	var revoke []models.MongoClientSession
+	for _, session := range sessions {
+		if session.SessionId != keep {
+			revoke = append(revoke, session)
+		}
+	}
+	if err := revokeSessions(clientId, revoke, reason, now); err != nil {
+		return 0, err
+	}
+	loggerconfig.Info("RevokeClientSessions, revoked:", len(revoke), " reason:", reason, " clientId:", clientId, " requestId:", requestId)
+	return len(revoke), nil
+}
+
+func revokeSessions(clientId string, sessions []models.MongoClientSession, reason string, now time.Time) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	sessionIds := make([]string, len(sessions))
+	for i, session := range sessions {
+		sessionIds[i] = session.SessionId
+	}
+	if err := CallMarkSessionsRevoked(clientId, sessionIds, reason, now); err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := CallBlockSession(clientId, session.SessionId, revokedSessionTtlMins(session.ExpiresAt, now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeOnReset logs a client out everywhere after a credential reset. The
+// reset has already gone through, so a failure here is only logged.
+func revokeOnReset(clientId string, keepToken string, reason string, reqH models.ReqHeader) {
+	if clientId == "" {
+		return
+	}
+	if _, err := RevokeClientSessions(clientId, keepToken, reason, reqH.RequestId); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, revokeOnReset failed to revoke sessions, error:", err, " reason:", reason, " clientId:", clientId, " requestId:", reqH.RequestId)
+	}
+}
+
+func (obj LogoutObj) ListSessions(req models.ClientSessionsReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	sessions, err := CallFetchClientSessions(strings.ToUpper(req.ClientId), sessionNow())
+	if err != nil {
+		loggerconfig.Error("ListSessions, mongo error:", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	current := SessionId(sessionToken(reqH.Authorization))
+	res := models.ClientSessionsRes{Sessions: []models.ClientSessionRes{}}
+	for _, session := range sessions {
+		res.Sessions = append(res.Sessions, models.ClientSessionRes{
+			SessionId:  session.SessionId,
+			DeviceId:   session.DeviceId,
+			DeviceType: session.DeviceType,
+			Platform:   session.Platform,
+			Ip:         session.Ip,
+			AppVersion: session.AppVersion,
+			Current:    session.SessionId == current,
+			CreatedAt:  session.CreatedAt.Unix(),
+			LastSeenAt: session.LastSeenAt.Unix(),
+		})
+	}
+
+	apiRes.Data = res
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+func (obj LogoutObj) RevokeSession(req models.RevokeSessionReq, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	clientId := strings.ToUpper(req.ClientId)
+	now := sessionNow()
+	sessions, err := CallFetchClientSessions(clientId, now)
+	if err != nil {
+		loggerconfig.Error("RevokeSession, mongo error:", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	var target []models.MongoClientSession
+	for _, session := range sessions {
+		if session.SessionId == req.SessionId {
+			target = append(target, session)
+		}
+	}
+	if len(target) == 0 {
+		return apihelpers.SendErrorResponse(false, constants.SessionNotFound, http.StatusBadRequest)
+	}
+
+	if err := revokeSessions(clientId, target, constants.SessionRevokedUser, now); err != nil {
+		loggerconfig.Error("RevokeSession, error revoking session:", err, " sessionId:", req.SessionId, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	// the token of the device making the request is at hand, so it is ended
+	// at tradelab now rather than the next time it is presented
+	if token := sessionToken(reqH.Authorization); SessionId(token) == req.SessionId {
+		if err := CallTradelabLogout(token, reqH); err != nil {
+			loggerconfig.Error("RevokeSession, error ending token at tradelab:", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		} else if err := CallMarkRevokedSessionEnded(req.SessionId, revokedSessionTtlMins(target[0].ExpiresAt, now)); err != nil {
+			loggerconfig.Error("RevokeSession, redis error marking session ended:", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		}
+	}
+
+	loggerconfig.Info("RevokeSession, revoked sessionId:", req.SessionId, " deviceId:", target[0].DeviceId, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// LogoutOtherDevices revokes every session of the client except the one
+// making the request.
+func (obj LogoutObj) LogoutOtherDevices(reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	revoked, err := RevokeClientSessions(reqH.ClientId, sessionToken(reqH.Authorization), constants.SessionRevokedOtherDevices, reqH.RequestId)
+	if err != nil {
+		loggerconfig.Error("LogoutOtherDevices, error revoking sessions:", err, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	apiRes.Data = models.LogoutOtherDevicesRes{Revoked: revoked}
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
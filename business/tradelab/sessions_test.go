@@ -0,0 +1,254 @@
+package tradelab
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+)
+
+type fakeSessionStore struct {
+	sessions []models.MongoClientSession
+	blocked  map[string]int
+	revoked  map[string]string
+	ended    map[string]bool
+	// tokens ended at tradelab, in call order
+	loggedOut []string
+}
+
+func testSession(clientId, token, deviceId string, expiresAt time.Time) models.MongoClientSession {
+	return models.MongoClientSession{SessionId: SessionId(token), ClientId: clientId, DeviceId: deviceId, Status: constants.SessionActive, ExpiresAt: expiresAt}
+}
+
+func TestSessionCacheKey(t *testing.T) {
+	key := SessionCacheKey("abc123", "token-1")
+	if key != "ABC123_"+SessionId("token-1") {
+		t.Errorf("SessionCacheKey() = %q", key)
+	}
+	if SessionId("token-1") == SessionId("token-2") || len(SessionId("token-1")) != 64 {
+		t.Errorf("SessionId() does not identify tokens")
+	}
+}
+
+func TestClientSessions(t *testing.T) {
+	origNow, origFetch, origMark, origBlock := sessionNow, CallFetchClientSessions, CallMarkSessionsRevoked, CallBlockSession
+	origFetchRevoked, origEnded, origLogout := CallFetchRevokedSession, CallMarkRevokedSessionEnded, CallTradelabLogout
+	origClaim, origRecord := CallClaimSessionSeen, CallRecordClientSession
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		sessionNow, CallFetchClientSessions, CallMarkSessionsRevoked, CallBlockSession = origNow, origFetch, origMark, origBlock
+		CallFetchRevokedSession, CallMarkRevokedSessionEnded, CallTradelabLogout = origFetchRevoked, origEnded, origLogout
+		CallClaimSessionSeen, CallRecordClientSession = origClaim, origRecord
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, constants.LocationKolkata)
+	var store *fakeSessionStore
+	reset := func(sessions ...models.MongoClientSession) {
+		store = &fakeSessionStore{sessions: sessions, blocked: map[string]int{}, revoked: map[string]string{}, ended: map[string]bool{}}
+	}
+	sessionNow = func() time.Time { return now }
+	CallFetchClientSessions = func(clientId string, now time.Time) ([]models.MongoClientSession, error) {
+		var active []models.MongoClientSession
+		for _, session := range store.sessions {
+			if session.ClientId == clientId && store.revoked[session.SessionId] == "" && session.ExpiresAt.After(now) {
+				active = append(active, session)
+			}
+		}
+		return active, nil
+	}
+	CallMarkSessionsRevoked = func(clientId string, sessionIds []string, reason string, now time.Time) error {
+		for _, id := range sessionIds {
+			store.revoked[id] = reason
+		}
+		return nil
+	}
+	CallBlockSession = func(clientId string, sessionId string, ttlMins int) error {
+		store.blocked[sessionId] = ttlMins
+		return nil
+	}
+	CallFetchRevokedSession = func(sessionId string) (bool, bool, error) {
+		_, ok := store.blocked[sessionId]
+		return ok, store.ended[sessionId], nil
+	}
+	CallMarkRevokedSessionEnded = func(sessionId string, ttlMins int) error {
+		store.ended[sessionId] = true
+		return nil
+	}
+	CallTradelabLogout = func(token string, reqH models.ReqHeader) error {
+		store.loggedOut = append(store.loggedOut, token)
+		return nil
+	}
+
+	t.Run("list", func(t *testing.T) {
+		reset(
+			testSession("ABC123", "phone", "d1", now.Add(time.Hour)),
+			testSession("ABC123", "laptop", "d2", now.Add(time.Hour)),
+			testSession("ABC123", "expired", "d3", now.Add(-time.Minute)),
+			testSession("XYZ999", "other", "d4", now.Add(time.Hour)),
+		)
+
+		status, res := LogoutObj{}.ListSessions(models.ClientSessionsReq{ClientId: "abc123"}, models.ReqHeader{Authorization: "Bearer laptop"})
+		if status != http.StatusOK {
+			t.Fatalf("ListSessions() status = %d", status)
+		}
+		sessions := res.Data.(models.ClientSessionsRes).Sessions
+		if len(sessions) != 2 {
+			t.Fatalf("ListSessions() returned %d sessions, want 2", len(sessions))
+		}
+		for _, session := range sessions {
+			if session.Current != (session.DeviceId == "d2") {
+				t.Errorf("session %s Current = %v", session.DeviceId, session.Current)
+			}
+		}
+	})
+
+	t.Run("revoke one", func(t *testing.T) {
+		reset(
+			testSession("ABC123", "phone", "d1", now.Add(90*time.Minute+10*time.Second)),
+			testSession("ABC123", "laptop", "d3", now.Add(time.Hour)),
+			testSession("XYZ999", "other", "d2", now.Add(time.Hour)),
+		)
+		reqH := models.ReqHeader{ClientId: "ABC123", Authorization: "Bearer laptop"}
+
+		for _, sessionId := range []string{SessionId("other"), "unknown"} {
+			status, res := LogoutObj{}.RevokeSession(models.RevokeSessionReq{ClientId: "ABC123", SessionId: sessionId}, reqH)
+			if status != http.StatusBadRequest || res.ErrorCode != constants.SessionNotFound {
+				t.Errorf("RevokeSession(%s) = %d %+v, want SessionNotFound", sessionId, status, res)
+			}
+		}
+		if len(store.revoked) != 0 {
+			t.Fatalf("revoked sessions of another client: %v", store.revoked)
+		}
+
+		status, _ := LogoutObj{}.RevokeSession(models.RevokeSessionReq{ClientId: "abc123", SessionId: SessionId("phone")}, reqH)
+		if status != http.StatusOK {
+			t.Fatalf("RevokeSession() status = %d", status)
+		}
+		if store.revoked[SessionId("phone")] != constants.SessionRevokedUser {
+			t.Errorf("revoked reason = %q", store.revoked[SessionId("phone")])
+		}
+		if store.blocked[SessionId("phone")] != 91 {
+			t.Errorf("blocked for %d mins, want 91", store.blocked[SessionId("phone")])
+		}
+		if len(store.loggedOut) != 0 {
+			t.Errorf("ended %v at tradelab without their tokens being presented", store.loggedOut)
+		}
+
+		// revoking the session making the request ends its token straight away
+		status, _ = LogoutObj{}.RevokeSession(models.RevokeSessionReq{ClientId: "ABC123", SessionId: SessionId("laptop")}, reqH)
+		if status != http.StatusOK || len(store.loggedOut) != 1 || store.loggedOut[0] != "laptop" || !store.ended[SessionId("laptop")] {
+			t.Errorf("revoking the current session = %d, ended at tradelab: %v", status, store.loggedOut)
+		}
+
+		status, res := LogoutObj{}.RevokeSession(models.RevokeSessionReq{ClientId: "ABC123", SessionId: SessionId("phone")}, reqH)
+		if status != http.StatusBadRequest || res.ErrorCode != constants.SessionNotFound {
+			t.Errorf("revoking twice = %d %+v", status, res)
+		}
+	})
+
+	t.Run("logout other devices", func(t *testing.T) {
+		reset(
+			testSession("ABC123", "phone", "d1", now.Add(time.Hour)),
+			testSession("ABC123", "laptop", "d2", now.Add(time.Hour)),
+			testSession("ABC123", "tablet", "d3", now.Add(10*time.Second)),
+			testSession("XYZ999", "other", "d4", now.Add(time.Hour)),
+		)
+
+		status, res := LogoutObj{}.LogoutOtherDevices(models.ReqHeader{ClientId: "abc123", Authorization: "Bearer laptop"})
+		if status != http.StatusOK || res.Data.(models.LogoutOtherDevicesRes).Revoked != 2 {
+			t.Fatalf("LogoutOtherDevices() = %d %+v", status, res)
+		}
+		if _, ok := store.revoked[SessionId("laptop")]; ok {
+			t.Errorf("current session was revoked")
+		}
+		if _, ok := store.revoked[SessionId("other")]; ok {
+			t.Errorf("another client's session was revoked")
+		}
+		if store.blocked[SessionId("tablet")] != constants.RevokedSessionMinMins {
+			t.Errorf("tablet blocked for %d mins", store.blocked[SessionId("tablet")])
+		}
+
+		// a password reset from a logged out flow revokes every session
+		revokeOnReset("ABC123", "", constants.SessionRevokedPasswordReset, models.ReqHeader{})
+		if store.revoked[SessionId("laptop")] != constants.SessionRevokedPasswordReset {
+			t.Errorf("reset left the current session active")
+		}
+	})
+
+	t.Run("reject revoked", func(t *testing.T) {
+		reset(
+			testSession("ABC123", "phone", "d1", now.Add(time.Hour)),
+			testSession("ABC123", "laptop", "d2", now.Add(time.Hour)),
+		)
+		if _, err := RevokeClientSessions("ABC123", "laptop", constants.SessionRevokedOtherDevices, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		phone := models.ReqHeader{ClientId: "ABC123", Authorization: "Bearer phone"}
+		if RejectRevokedSession(models.ReqHeader{ClientId: "ABC123", Authorization: "Bearer laptop"}) || RejectRevokedSession(models.ReqHeader{}) {
+			t.Errorf("RejectRevokedSession() rejected a live session")
+		}
+		// the revoked token is ended at tradelab the first time it comes back
+		for i := 0; i < 2; i++ {
+			if !RejectRevokedSession(phone) {
+				t.Errorf("RejectRevokedSession() let a revoked session through")
+			}
+		}
+		if len(store.loggedOut) != 1 || store.loggedOut[0] != "phone" || !store.ended[SessionId("phone")] {
+			t.Errorf("ended at tradelab = %v", store.loggedOut)
+		}
+
+		// a failed logout is retried the next time
+		CallTradelabLogout = func(token string, reqH models.ReqHeader) error { return errors.New("tradelab down") }
+		store.ended = map[string]bool{}
+		if !RejectRevokedSession(phone) || store.ended[SessionId("phone")] {
+			t.Errorf("session marked ended although tradelab logout failed")
+		}
+	})
+
+	t.Run("reject revoked fails open", func(t *testing.T) {
+		reset()
+		CallFetchRevokedSession = func(sessionId string) (bool, bool, error) { return true, false, errors.New("redis down") }
+		if RejectRevokedSession(models.ReqHeader{Authorization: "Bearer phone"}) {
+			t.Errorf("RejectRevokedSession() should not lock clients out when redis fails")
+		}
+	})
+
+	t.Run("touch", func(t *testing.T) {
+		seen := map[string]bool{}
+		CallClaimSessionSeen = func(sessionId string) (bool, error) {
+			if seen[sessionId] {
+				return false, nil
+			}
+			seen[sessionId] = true
+			return true, nil
+		}
+		var recorded []models.MongoClientSession
+		CallRecordClientSession = func(session models.MongoClientSession) error {
+			recorded = append(recorded, session)
+			return nil
+		}
+
+		reqH := models.ReqHeader{ClientId: "abc123", DeviceId: "d1"}
+		expiresAt := now.Add(time.Hour).Unix()
+		TouchClientSession(reqH, "phone", expiresAt)
+		TouchClientSession(reqH, "phone", expiresAt)
+		TouchClientSession(reqH, "laptop", expiresAt)
+		if len(recorded) != 2 || recorded[0].SessionId != SessionId("phone") || recorded[0].ClientId != "ABC123" || !recorded[0].LastSeenAt.Equal(now) {
+			t.Fatalf("recorded sessions = %+v", recorded)
+		}
+
+		CallClaimSessionSeen = func(sessionId string) (bool, error) { return false, errors.New("redis down") }
+		TouchClientSession(reqH, "tablet", expiresAt)
+		if len(recorded) != 2 {
+			t.Errorf("session recorded without the throttle")
+		}
+	})
+}
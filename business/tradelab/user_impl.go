@@ -74,10 +74,17 @@ func (obj LogoutObj) LogoutSingleDevice(reqH models.ReqHeader) (int, apihelpers.
 
 	logrus.Info("LogoutSingleDevice tl success uccId:", reqH.ClientId, " StatusCode : ", res.StatusCode, " requestId:", reqH.RequestId, " clientVersion:", reqH.ClientVersion)
 
-	key := strings.ToUpper(reqH.ClientId) + "_" + reqH.Authorization[7:]
+	token := sessionToken(reqH.Authorization)
+	key := SessionCacheKey(reqH.ClientId, token)
 	delStatus := dbops.RedisRepo.Delete(key)
 	logrus.Info("LogoutSingleDevice auth key removed from redis: ", delStatus, " uccid: ", reqH.ClientId, " StatusCode : ", res.StatusCode, " requestId:", reqH.RequestId, " clientVersion:", reqH.ClientVersion)
 
+	// tradelab has ended the token, it only has to leave the session list
+	err = CallMarkSessionsRevoked(strings.ToUpper(reqH.ClientId), []string{SessionId(token)}, constants.SessionLoggedOut, sessionNow())
+	if err != nil {
+		logrus.Error("LogoutSingleDevice error closing session: ", err, " uccId: ", reqH.ClientId, " requestId:", reqH.RequestId, " clientVersion:", reqH.ClientVersion)
+	}
+
 	if reqH.ClientId != "" && reqH.FCMToken != "" {
 		// delete FCM Token
 		err = db.GetPgObj().DeleteFCMToken(reqH.ClientId, reqH.FCMToken)
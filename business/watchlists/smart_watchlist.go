@@ -0,0 +1,602 @@
+package watchlists
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/business/screeners"
+	"space/business/tradelab"
+	"space/constants"
+	"space/db"
+	"space/dbops"
+	"space/helpers"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var smartNow = helpers.GetCurrentTimeInIST
+
+// smartMembers is the last evaluation of a market rule, shared by every client
+// using the same rule.
+type smartMembers struct {
+	rule        models.WatchListRule
+	stocks      []models.StockDetailsV2
+	evaluatedAt int64
+}
+
+var smartRuleCache sync.Map
+
+var CallFetchHoldingIsins = func(reqH models.ReqHeader) ([]string, error) {
+	status, res := tradelab.InitPortfolio().FetchDematHoldings(models.FetchDematHoldingsRequest{ClientID: reqH.ClientId}, reqH)
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("FetchDematHoldings status %d %s", status, res.Message)
+	}
+	holdings, ok := res.Data.(models.FetchDematHoldingsResponse)
+	if !ok {
+		return nil, errors.New("unexpected FetchDematHoldings response")
+	}
+	var isins []string
+	for _, holding := range holdings.Holdings {
+		if holding.Isin != "" {
+			isins = append(isins, holding.Isin)
+		}
+	}
+	return isins, nil
+}
+
+var CallFetchIndexGainers = func(index string, reqH models.ReqHeader) ([]models.LosersGainers, error) {
+	status, res := tradelab.InitGainerLoserProvider().GainerLoserNiftyFifty(models.GainersLosersMostActiveVolumeReq{Index: index}, reqH)
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("GainerLoserNiftyFifty status %d %s", status, res.Message)
+	}
+	gainersLosers, ok := res.Data.(models.TopGainerLoserResponse)
+	if !ok {
+		return nil, errors.New("unexpected GainerLoserNiftyFifty response")
+	}
+	return gainersLosers.Gainers, nil
+}
+
+var CallFetchScreenerUniverse = func() ([]models.ScreenerStock, error) {
+	return screeners.InitScreenersProvider(nil, db.GetPgObj(), cache.GetRedisClientObj()).FetchScreenerUniverse()
+}
+
+var CallFetchBoardMeetings = func(coCodes string) ([]models.BoardMeetingForthComing, error) {
+	return db.GetPgObj().FetchBoardMeeting(coCodes)
+}
+
+var CallFetchAllIpo = func(reqH models.ReqHeader) (models.GetAllIpoResponse, error) {
+	status, res := tradelab.InitIpoProvider(nil, cache.GetRedisClientObj()).GetAllIpo(models.GetAllIpoRequest{}, reqH)
+	if status != http.StatusOK {
+		return models.GetAllIpoResponse{}, fmt.Errorf("GetAllIpo status %d %s", status, res.Message)
+	}
+	ipos, ok := res.Data.(models.GetAllIpoResponse)
+	if !ok {
+		return ipos, errors.New("unexpected GetAllIpo response")
+	}
+	return ipos, nil
+}
+
+// CreateSmartWatchList saves a rule based watchlist next to the client's five
+// static ones and evaluates its members right away.
+func (obj WatchlistsObj) CreateSmartWatchList(req models.CreateSmartWatchListRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	rule, ok := normaliseSmartRule(req.Rule)
+	if !ok {
+		loggerconfig.Error("CreateSmartWatchList invalid rule:", helpers.LogStructAsJSON(req.Rule), " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidSmartWatchListRule, http.StatusBadRequest)
+	}
+
+	lists, err := fetchSmartWatchLists(req.ClientId)
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, CreateSmartWatchList Mongo error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	watchListId := nextSmartWatchListId(lists)
+	if watchListId == "" {
+		loggerconfig.Error("CreateSmartWatchList limit reached, clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.SmartWatchListLimitReached, http.StatusBadRequest)
+	}
+
+	now := smartNow().Unix()
+	list := models.MongoSmartWatchList{
+		ClientId:    req.ClientId,
+		WatchListId: watchListId,
+		Name:        req.Name,
+		Rule:        rule,
+		Stocks:      []models.StockDetailsV2{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	obj.evaluateSmartWatchList(&list, reqH)
+
+	if err := dbops.MongoRepo.InsertOne(constants.SMARTWATCHLISTSCOLLECTION, list); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, CreateSmartWatchList Mongo insert failed error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	loggerconfig.Info("CreateSmartWatchList Successful, watchListId:", watchListId, " rule:", rule.Type, " stocks:", len(list.Stocks), " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Data = obj.enrichSmartWatchList(list, reqH)
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// UpdateSmartWatchList renames a smart watchlist or changes its rule, members
+// are evaluated again.
+func (obj WatchlistsObj) UpdateSmartWatchList(req models.UpdateSmartWatchListRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	rule, ok := normaliseSmartRule(req.Rule)
+	if !ok {
+		loggerconfig.Error("UpdateSmartWatchList invalid rule:", helpers.LogStructAsJSON(req.Rule), " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidSmartWatchListRule, http.StatusBadRequest)
+	}
+
+	filter := bson.M{"clientId": req.ClientId, "watchListId": req.WatchListId}
+	var list models.MongoSmartWatchList
+	err := dbops.MongoRepo.FindOne(constants.SMARTWATCHLISTSCOLLECTION, filter, &list)
+	if err != nil && err.Error() == constants.MongoNoDocError {
+		loggerconfig.Error("UpdateSmartWatchList not found, watchListId:", req.WatchListId, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.SmartWatchListNotFound, http.StatusBadRequest)
+	}
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, UpdateSmartWatchList Mongo error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	list.Name = req.Name
+	list.Rule = rule
+	list.Stocks = []models.StockDetailsV2{}
+	list.EvaluatedAt = 0
+	list.UpdatedAt = smartNow().Unix()
+	obj.evaluateSmartWatchList(&list, reqH)
+
+	update := bson.M{"$set": bson.M{"name": list.Name, "rule": list.Rule, "stocks": list.Stocks, "evaluatedAt": list.EvaluatedAt, "updatedAt": list.UpdatedAt}}
+	if err := dbops.MongoRepo.UpdateOne(constants.SMARTWATCHLISTSCOLLECTION, filter, update); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, UpdateSmartWatchList Mongo update failed error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	loggerconfig.Info("UpdateSmartWatchList Successful, watchListId:", req.WatchListId, " rule:", rule.Type, " stocks:", len(list.Stocks), " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Data = obj.enrichSmartWatchList(list, reqH)
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+func (obj WatchlistsObj) DeleteSmartWatchList(req models.DeleteSmartWatchListRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	res, err := dbops.MongoRepo.DeleteOne(constants.SMARTWATCHLISTSCOLLECTION, bson.M{"clientId": req.ClientId, "watchListId": req.WatchListId})
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, DeleteSmartWatchList Mongo error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	if res == nil || res.DeletedCount == 0 {
+		loggerconfig.Error("DeleteSmartWatchList not found, watchListId:", req.WatchListId, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.SmartWatchListNotFound, http.StatusBadRequest)
+	}
+
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// RefreshSmartWatchLists re-evaluates the market rules clients have used every
+// SmartWatchListRefreshMins. Only rules that need no client session are
+// refreshed here, the rest are evaluated when the watchlists are fetched.
+func RefreshSmartWatchLists(contractCacheCli cache.ContractCache) {
+	defer models.HandlePanic()
+	obj := InitWatchlists(nil, contractCacheCli)
+	for {
+		time.Sleep(constants.SmartWatchListRefreshMins * time.Minute)
+		refreshSmartRules(obj)
+	}
+}
+
+func refreshSmartRules(obj WatchlistsObj) {
+	var rules []models.WatchListRule
+	smartRuleCache.Range(func(_, value interface{}) bool {
+		rule := value.(smartMembers).rule
+		if rule.Type == constants.SmartWatchListRuleSector || rule.Type == constants.SmartWatchListRuleUpcomingResults {
+			rules = append(rules, rule)
+		}
+		return true
+	})
+	for _, rule := range rules {
+		stocks, err := obj.resolveSmartRule(rule, models.ReqHeader{})
+		if err != nil {
+			loggerconfig.Error("Alert Severity:P2-Mid, RefreshSmartWatchLists rule:", rule.Type, " err:", err)
+			continue
+		}
+		smartRuleCache.Store(smartRuleKey(rule), smartMembers{rule: rule, stocks: stocks, evaluatedAt: smartNow().Unix()})
+	}
+	loggerconfig.Info("RefreshSmartWatchLists refreshed rules:", len(rules))
+}
+
+// normaliseSmartRule checks the rule parameters and fills in defaults so equal
+// rules share one cache entry.
+func normaliseSmartRule(rule models.WatchListRule) (models.WatchListRule, bool) {
+	if rule.Limit <= 0 {
+		rule.Limit = constants.SmartWatchListDefaultLimit
+	}
+	if rule.Limit > constants.SmartWatchListMaxStocks {
+		rule.Limit = constants.SmartWatchListMaxStocks
+	}
+
+	switch rule.Type {
+	case constants.SmartWatchListRuleSector:
+		sector := smartSector(rule.Sector)
+		if sector == "" {
+			return rule, false
+		}
+		rule.Sector, rule.Index = sector, ""
+	case constants.SmartWatchListRuleIndexGainers:
+		rule.Sector = ""
+		rule.Index = strings.ToLower(strings.TrimSpace(rule.Index))
+		if rule.Index == "" {
+			rule.Index = constants.SmartWatchListDefaultIndex
+		}
+	case constants.SmartWatchListRuleHoldings, constants.SmartWatchListRuleUpcomingResults, constants.SmartWatchListRuleIpoListing:
+		rule.Sector, rule.Index = "", ""
+	default:
+		return rule, false
+	}
+	return rule, true
+}
+
+// smartSector resolves a SectorMapping category, one of its CMOTS sector names
+// or a common alias such as "Banking".
+func smartSector(sector string) string {
+	sector = strings.TrimSpace(sector)
+	if alias, ok := constants.SmartWatchListSectorAliases[strings.ToLower(sector)]; ok {
+		return alias
+	}
+	for category, names := range constants.SectorMapping {
+		if strings.EqualFold(category, sector) {
+			return category
+		}
+		for _, name := range names {
+			if strings.EqualFold(name, sector) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func smartRuleKey(rule models.WatchListRule) string {
+	return rule.Type + "|" + rule.Sector + "|" + rule.Index + "|" + strconv.Itoa(rule.Limit)
+}
+
+func nextSmartWatchListId(lists []models.MongoSmartWatchList) string {
+	used := make(map[string]bool)
+	for _, list := range lists {
+		used[list.WatchListId] = true
+	}
+	for i := 1; i <= constants.SmartWatchListMaxLists; i++ {
+		watchListId := constants.SmartWatchListIdPrefix + strconv.Itoa(i)
+		if !used[watchListId] {
+			return watchListId
+		}
+	}
+	return ""
+}
+
+func fetchSmartWatchLists(clientId string) ([]models.MongoSmartWatchList, error) {
+	cursor, err := dbops.MongoRepo.Find(constants.SMARTWATCHLISTSCOLLECTION, bson.M{"clientId": clientId})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var lists []models.MongoSmartWatchList
+	if err := cursor.All(context.Background(), &lists); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(lists, func(i, j int) bool {
+		return lists[i].WatchListId < lists[j].WatchListId
+	})
+	return lists, nil
+}
+
+// refreshSmartWatchLists re-evaluates the client's stale smart watchlists in
+// parallel and saves the new members. A list that fails to evaluate keeps its
+// last members.
+func (obj WatchlistsObj) refreshSmartWatchLists(lists []models.MongoSmartWatchList, reqH models.ReqHeader) {
+	staleBefore := smartNow().Unix() - constants.SmartWatchListRefreshMins*60
+	var wg sync.WaitGroup
+	for i := range lists {
+		if lists[i].EvaluatedAt > staleBefore {
+			continue
+		}
+		wg.Add(1)
+		go func(list *models.MongoSmartWatchList) {
+			defer wg.Done()
+			defer models.HandlePanic()
+			if !obj.evaluateSmartWatchList(list, reqH) {
+				return
+			}
+			filter := bson.M{"clientId": list.ClientId, "watchListId": list.WatchListId}
+			update := bson.M{"$set": bson.M{"stocks": list.Stocks, "evaluatedAt": list.EvaluatedAt}}
+			if err := dbops.MongoRepo.UpdateOne(constants.SMARTWATCHLISTSCOLLECTION, filter, update); err != nil {
+				loggerconfig.Error("Alert Severity:P2-Mid, refreshSmartWatchLists Mongo update failed error =", err, " watchListId:", list.WatchListId, " clientId:", list.ClientId, " requestId:", reqH.RequestId)
+			}
+		}(&lists[i])
+	}
+	wg.Wait()
+}
+
+func (obj WatchlistsObj) evaluateSmartWatchList(list *models.MongoSmartWatchList, reqH models.ReqHeader) bool {
+	stocks, err := obj.evaluateSmartRule(list.Rule, reqH)
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P2-Mid, evaluateSmartWatchList rule:", list.Rule.Type, " watchListId:", list.WatchListId, " clientId:", list.ClientId, " requestId:", reqH.RequestId, " err:", err)
+		return false
+	}
+	list.Stocks = stocks
+	list.EvaluatedAt = smartNow().Unix()
+	return true
+}
+
+// evaluateSmartRule returns the members of a rule. Market rules are the same
+// for every client and are served from smartRuleCache while fresh.
+func (obj WatchlistsObj) evaluateSmartRule(rule models.WatchListRule, reqH models.ReqHeader) ([]models.StockDetailsV2, error) {
+	if rule.Type == constants.SmartWatchListRuleHoldings {
+		return obj.resolveSmartRule(rule, reqH)
+	}
+
+	key := smartRuleKey(rule)
+	now := smartNow().Unix()
+	if value, ok := smartRuleCache.Load(key); ok {
+		cached := value.(smartMembers)
+		if now-cached.evaluatedAt < constants.SmartWatchListRefreshMins*60 {
+			return cached.stocks, nil
+		}
+	}
+
+	stocks, err := obj.resolveSmartRule(rule, reqH)
+	if err != nil {
+		return nil, err
+	}
+	smartRuleCache.Store(key, smartMembers{rule: rule, stocks: stocks, evaluatedAt: now})
+	return stocks, nil
+}
+
+func (obj WatchlistsObj) resolveSmartRule(rule models.WatchListRule, reqH models.ReqHeader) ([]models.StockDetailsV2, error) {
+	var stocks []models.StockDetailsV2
+	var err error
+	switch rule.Type {
+	case constants.SmartWatchListRuleHoldings:
+		var isins []string
+		if isins, err = CallFetchHoldingIsins(reqH); err == nil {
+			stocks = obj.isinStocks(isins, rule.Limit)
+		}
+	case constants.SmartWatchListRuleSector:
+		stocks, err = obj.sectorStocks(rule)
+	case constants.SmartWatchListRuleIndexGainers:
+		stocks, err = obj.gainerStocks(rule, reqH)
+	case constants.SmartWatchListRuleUpcomingResults:
+		stocks, err = obj.upcomingResultStocks(rule)
+	case constants.SmartWatchListRuleIpoListing:
+		stocks, err = obj.ipoListingStocks(rule, reqH)
+	default:
+		err = errors.New("unknown smart watchlist rule " + rule.Type)
+	}
+	if stocks == nil {
+		stocks = []models.StockDetailsV2{}
+	}
+	return stocks, err
+}
+
+// sectorStocks picks the largest companies of the sector by market cap.
+func (obj WatchlistsObj) sectorStocks(rule models.WatchListRule) ([]models.StockDetailsV2, error) {
+	universe, err := CallFetchScreenerUniverse()
+	if err != nil {
+		return nil, err
+	}
+	sectors := constants.SectorMapping[rule.Sector]
+	if sectors == nil {
+		sectors = []string{rule.Sector}
+	}
+
+	var members []models.ScreenerStock
+	for _, stock := range universe {
+		for _, sector := range sectors {
+			if strings.EqualFold(stock.Sector, sector) {
+				members = append(members, stock)
+				break
+			}
+		}
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].MarketCap > members[j].MarketCap
+	})
+
+	isins := make([]string, 0, len(members))
+	for _, stock := range members {
+		isins = append(isins, stock.Isin)
+	}
+	return obj.isinStocks(isins, rule.Limit), nil
+}
+
+// gainerStocks keeps the exchange order of the index gainers.
+func (obj WatchlistsObj) gainerStocks(rule models.WatchListRule, reqH models.ReqHeader) ([]models.StockDetailsV2, error) {
+	gainers, err := CallFetchIndexGainers(rule.Index, reqH)
+	if err != nil {
+		return nil, err
+	}
+	stocks := []models.StockDetailsV2{}
+	for _, gainer := range gainers {
+		if len(stocks) == rule.Limit {
+			break
+		}
+		exchange := strings.ToUpper(gainer.Exchange)
+		token := strconv.Itoa(gainer.InstrumentToken)
+		stock := models.StockDetailsV2{StockId: exchange + "-" + token, Exchange: exchange, Token: token}
+		if err, val := obj.contractCacheCli.GetFromHash("stock_key", exchange+"_"+token); err == nil {
+			var detail models.ContractDetails
+			if json.Unmarshal([]byte(val), &detail) == nil && detail.Isin != "" {
+				stock = models.StockDetailsV2{Isin: detail.Isin, Exchange: exchange, IsinStockId: exchange + "-" + detail.Isin}
+			}
+		}
+		if duplicateStockInWatchlistV2(smartStockId(stock), stocks) {
+			continue
+		}
+		stocks = append(stocks, stock)
+	}
+	return stocks, nil
+}
+
+// upcomingResultStocks lists companies with a results board meeting between
+// Monday and Sunday of the current week, earliest first.
+func (obj WatchlistsObj) upcomingResultStocks(rule models.WatchListRule) ([]models.StockDetailsV2, error) {
+	universe, err := CallFetchScreenerUniverse()
+	if err != nil {
+		return nil, err
+	}
+	isinByCoCode := make(map[int]string, len(universe))
+	coCodes := make([]string, 0, len(universe))
+	for _, stock := range universe {
+		if stock.CoCode == 0 || stock.Isin == "" {
+			continue
+		}
+		isinByCoCode[stock.CoCode] = stock.Isin
+		coCodes = append(coCodes, strconv.Itoa(stock.CoCode))
+	}
+	meetings, err := CallFetchBoardMeetings(strings.Join(coCodes, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	now := smartNow()
+	weekStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -((int(now.Weekday()) + 6) % 7))
+	weekEnd := weekStart.AddDate(0, 0, 7)
+
+	type result struct {
+		isin string
+		date time.Time
+	}
+	var results []result
+	for _, meeting := range meetings {
+		if !strings.Contains(strings.ToLower(meeting.Note), "result") {
+			continue
+		}
+		date, ok := parseWatchListDate(meeting.Date, now.Location())
+		if !ok || date.Before(weekStart) || !date.Before(weekEnd) {
+			continue
+		}
+		if isin := isinByCoCode[int(meeting.CoCode)]; isin != "" {
+			results = append(results, result{isin: isin, date: date})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].date.Before(results[j].date)
+	})
+
+	isins := make([]string, 0, len(results))
+	for _, result := range results {
+		isins = append(isins, result.isin)
+	}
+	return obj.isinStocks(isins, rule.Limit), nil
+}
+
+// ipoListingStocks lists IPOs with a listing date in the current month that
+// are already in the contract master.
+func (obj WatchlistsObj) ipoListingStocks(rule models.WatchListRule, reqH models.ReqHeader) ([]models.StockDetailsV2, error) {
+	ipos, err := CallFetchAllIpo(reqH)
+	if err != nil {
+		return nil, err
+	}
+	now := smartNow()
+
+	var all []models.IpoState
+	all = append(all, ipos.AllIpo.Data...)
+	all = append(all, ipos.ClosedIpo...)
+	all = append(all, ipos.OpenIpo...)
+	all = append(all, ipos.UpcomingIpo...)
+
+	var isins []string
+	for _, ipo := range all {
+		date, ok := parseWatchListDate(ipo.ListingDate, now.Location())
+		if !ok || ipo.Isin == "" || date.Year() != now.Year() || date.Month() != now.Month() {
+			continue
+		}
+		isins = append(isins, ipo.Isin)
+	}
+	return obj.isinStocks(isins, rule.Limit), nil
+}
+
+// isinStocks maps ISINs to watchlist entries in the same form
+// AddStockToWatchListV3 stores, preferring NSE. ISINs missing from the
+// contract master and repeats are dropped.
+func (obj WatchlistsObj) isinStocks(isins []string, limit int) []models.StockDetailsV2 {
+	stocks := []models.StockDetailsV2{}
+	seen := make(map[string]bool)
+	for _, isin := range isins {
+		if len(stocks) == limit {
+			break
+		}
+		if isin == "" || seen[isin] {
+			continue
+		}
+		seen[isin] = true
+		for _, exchange := range []string{strings.ToUpper(constants.NSE), strings.ToUpper(constants.BSE)} {
+			if err, _ := obj.contractCacheCli.GetFromHash("isin_data", exchange+"-"+isin); err == nil {
+				stocks = append(stocks, models.StockDetailsV2{Isin: isin, Exchange: exchange, IsinStockId: exchange + "-" + isin})
+				break
+			}
+		}
+	}
+	return stocks
+}
+
+func smartStockId(stock models.StockDetailsV2) string {
+	if stock.Isin != "" {
+		return stock.IsinStockId
+	}
+	return stock.StockId
+}
+
+var watchListDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "02-01-2006", "02-Jan-2006"}
+
+func parseWatchListDate(value string, loc *time.Location) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range watchListDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return date.In(loc), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// enrichSmartWatchList fills in contract details the same way static lists are
+// and attaches the rule.
+func (obj WatchlistsObj) enrichSmartWatchList(list models.MongoSmartWatchList, reqH models.ReqHeader) models.WatchListsDetailsV2 {
+	watchList := obj.enrichWatchList(list.WatchListId, list.Stocks, reqH)
+	return smartWatchListDetails(watchList, list)
+}
+
+func smartWatchListDetails(watchList models.WatchListsDetailsV2, list models.MongoSmartWatchList) models.WatchListsDetailsV2 {
+	rule := list.Rule
+	watchList.IsSmart = true
+	watchList.Name = list.Name
+	watchList.Rule = &rule
+	watchList.EvaluatedAt = list.EvaluatedAt
+	if watchList.Stocks == nil {
+		watchList.Stocks = []models.StockDetailsV2{}
+	}
+	return watchList
+}
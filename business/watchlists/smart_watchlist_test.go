@@ -0,0 +1,229 @@
+package watchlists
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSmartCache struct {
+	fakeIsinCache
+	stockKeys map[string]models.ContractDetails // keyed by EXCHANGE_token
+}
+
+func (f fakeSmartCache) GetFromHash(hash, key string) (error, string) {
+	if hash != "stock_key" {
+		return f.fakeIsinCache.GetFromHash(hash, key)
+	}
+	contract, ok := f.stockKeys[key]
+	if !ok {
+		return errors.New("redis: nil"), ""
+	}
+	raw, _ := json.Marshal(contract)
+	return nil, string(raw)
+}
+
+func smartFixture() WatchlistsObj {
+	return InitWatchlists(nil, fakeSmartCache{
+		fakeIsinCache: isinFixture(),
+		stockKeys: map[string]models.ContractDetails{
+			"NSE_3045": {Exchange: "NSE", Token1: "3045", Isin: "INE062A01020"},
+		},
+	})
+}
+
+func resetSmartRuleCache() {
+	smartRuleCache.Range(func(key, _ interface{}) bool {
+		smartRuleCache.Delete(key)
+		return true
+	})
+}
+
+func isinStockIds(stocks []models.StockDetailsV2) []string {
+	ids := []string{}
+	for _, stock := range stocks {
+		ids = append(ids, smartStockId(stock))
+	}
+	return ids
+}
+
+func TestNormaliseSmartRule(t *testing.T) {
+	rule, ok := normaliseSmartRule(models.WatchListRule{Type: constants.SmartWatchListRuleSector, Sector: " banking ", Index: "nifty_50"})
+	assert.True(t, ok)
+	assert.Equal(t, models.WatchListRule{Type: constants.SmartWatchListRuleSector, Sector: "Banks", Limit: constants.SmartWatchListDefaultLimit}, rule)
+
+	rule, ok = normaliseSmartRule(models.WatchListRule{Type: constants.SmartWatchListRuleSector, Sector: "insurance", Limit: 500})
+	assert.True(t, ok)
+	assert.Equal(t, "Insurance", rule.Sector)
+	assert.Equal(t, constants.SmartWatchListMaxStocks, rule.Limit)
+
+	rule, ok = normaliseSmartRule(models.WatchListRule{Type: constants.SmartWatchListRuleIndexGainers})
+	assert.True(t, ok)
+	assert.Equal(t, constants.SmartWatchListDefaultIndex, rule.Index)
+
+	_, ok = normaliseSmartRule(models.WatchListRule{Type: constants.SmartWatchListRuleSector, Sector: "Shipping"})
+	assert.False(t, ok)
+	_, ok = normaliseSmartRule(models.WatchListRule{Type: "topLosers"})
+	assert.False(t, ok)
+}
+
+func TestResolveSmartRule(t *testing.T) {
+	origNow, origUniverse, origHoldings, origGainers := smartNow, CallFetchScreenerUniverse, CallFetchHoldingIsins, CallFetchIndexGainers
+	origMeetings, origIpo, origError := CallFetchBoardMeetings, CallFetchAllIpo, loggerconfig.Error
+	t.Cleanup(func() {
+		smartNow, CallFetchScreenerUniverse, CallFetchHoldingIsins, CallFetchIndexGainers = origNow, origUniverse, origHoldings, origGainers
+		CallFetchBoardMeetings, CallFetchAllIpo, loggerconfig.Error = origMeetings, origIpo, origError
+	})
+	loggerconfig.Error = func(args ...interface{}) {}
+	smartNow = func() time.Time { return time.Date(2026, 10, 21, 11, 0, 0, 0, constants.LocationKolkata) } // Wednesday
+	obj := smartFixture()
+
+	universe := []models.ScreenerStock{
+		{Isin: "INE040A01034", CoCode: 1333, Sector: "Banks", MarketCap: 1200000},
+		{Isin: "INE062A01020", CoCode: 3045, Sector: "Banks", MarketCap: 700000},
+		{Isin: "INE0BSEONLY1", CoCode: 9001, Sector: "Banks", MarketCap: 900},
+		{Isin: "INE009A01021", CoCode: 1594, Sector: "IT - Software", MarketCap: 600000},
+	}
+	CallFetchScreenerUniverse = func() ([]models.ScreenerStock, error) { return universe, nil }
+
+	t.Run("holdings prefer NSE and drop unknown isins", func(t *testing.T) {
+		CallFetchHoldingIsins = func(reqH models.ReqHeader) ([]string, error) {
+			return []string{"INE0BSEONLY1", "INE062A01020", "INE000UNKNWN", "INE062A01020"}, nil
+		}
+		stocks, err := obj.resolveSmartRule(models.WatchListRule{Type: constants.SmartWatchListRuleHoldings, Limit: 20}, models.ReqHeader{})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.StockDetailsV2{
+			{Isin: "INE0BSEONLY1", Exchange: "BSE", IsinStockId: "BSE-INE0BSEONLY1"},
+			{Isin: "INE062A01020", Exchange: "NSE", IsinStockId: "NSE-INE062A01020"},
+		}, stocks)
+	})
+
+	t.Run("sector is ordered by market cap and limited", func(t *testing.T) {
+		stocks, err := obj.resolveSmartRule(models.WatchListRule{Type: constants.SmartWatchListRuleSector, Sector: "Banks", Limit: 2}, models.ReqHeader{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"NSE-INE040A01034", "NSE-INE062A01020"}, isinStockIds(stocks))
+	})
+
+	t.Run("gainers resolve to isin entries when the contract has one", func(t *testing.T) {
+		CallFetchIndexGainers = func(index string, reqH models.ReqHeader) ([]models.LosersGainers, error) {
+			assert.Equal(t, "nifty_50", index)
+			return []models.LosersGainers{
+				{Exchange: "NSE", InstrumentToken: 3045},
+				{Exchange: "NSE", InstrumentToken: 99999},
+				{Exchange: "NSE", InstrumentToken: 3045},
+			}, nil
+		}
+		stocks, err := obj.resolveSmartRule(models.WatchListRule{Type: constants.SmartWatchListRuleIndexGainers, Index: "nifty_50", Limit: 20}, models.ReqHeader{})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.StockDetailsV2{
+			{Isin: "INE062A01020", Exchange: "NSE", IsinStockId: "NSE-INE062A01020"},
+			{StockId: "NSE-99999", Exchange: "NSE", Token: "99999"},
+		}, stocks)
+	})
+
+	t.Run("results are limited to this week's results meetings", func(t *testing.T) {
+		CallFetchBoardMeetings = func(coCodes string) ([]models.BoardMeetingForthComing, error) {
+			assert.Equal(t, "1333,3045,9001,1594", coCodes)
+			return []models.BoardMeetingForthComing{
+				{CoCode: 3045, Date: "2026-10-25T00:00:00Z", Note: "Quarterly Results"},
+				{CoCode: 1333, Date: "2026-10-19", Note: "Audited Results & Dividend"},
+				{CoCode: 9001, Date: "2026-10-26", Note: "Quarterly Results"},
+				{CoCode: 1594, Date: "2026-10-22", Note: "Fund Raising"},
+				{CoCode: 1594, Date: "2026-10-18", Note: "Quarterly Results"},
+			}, nil
+		}
+		stocks, err := obj.resolveSmartRule(models.WatchListRule{Type: constants.SmartWatchListRuleUpcomingResults, Limit: 20}, models.ReqHeader{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"NSE-INE040A01034", "NSE-INE062A01020"}, isinStockIds(stocks))
+	})
+
+	t.Run("ipos listing this month", func(t *testing.T) {
+		CallFetchAllIpo = func(reqH models.ReqHeader) (models.GetAllIpoResponse, error) {
+			var ipos models.GetAllIpoResponse
+			ipos.ClosedIpo = []models.IpoState{
+				{Isin: "INE040A01034", ListingDate: "30-10-2026"},
+				{Isin: "INE062A01020", ListingDate: "01-11-2026"},
+				{Isin: "INE0BSEONLY1", ListingDate: "bad date"},
+			}
+			ipos.UpcomingIpo = []models.IpoState{{Isin: "INE000UNKNWN", ListingDate: "28-10-2026"}}
+			return ipos, nil
+		}
+		stocks, err := obj.resolveSmartRule(models.WatchListRule{Type: constants.SmartWatchListRuleIpoListing, Limit: 20}, models.ReqHeader{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"NSE-INE040A01034"}, isinStockIds(stocks))
+	})
+
+	t.Run("a failing source is an error", func(t *testing.T) {
+		CallFetchAllIpo = func(reqH models.ReqHeader) (models.GetAllIpoResponse, error) {
+			return models.GetAllIpoResponse{}, errors.New("tradelab down")
+		}
+		_, err := obj.resolveSmartRule(models.WatchListRule{Type: constants.SmartWatchListRuleIpoListing, Limit: 20}, models.ReqHeader{})
+		assert.Error(t, err)
+	})
+}
+
+func TestEvaluateSmartRuleCache(t *testing.T) {
+	origNow, origUniverse, origHoldings, origInfo := smartNow, CallFetchScreenerUniverse, CallFetchHoldingIsins, loggerconfig.Info
+	t.Cleanup(func() {
+		smartNow, CallFetchScreenerUniverse, CallFetchHoldingIsins, loggerconfig.Info = origNow, origUniverse, origHoldings, origInfo
+		resetSmartRuleCache()
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	resetSmartRuleCache()
+	now := time.Date(2026, 10, 21, 11, 0, 0, 0, time.UTC)
+	smartNow = func() time.Time { return now }
+	obj := smartFixture()
+
+	calls := 0
+	CallFetchScreenerUniverse = func() ([]models.ScreenerStock, error) {
+		calls++
+		return []models.ScreenerStock{{Isin: "INE062A01020", Sector: "Banks"}}, nil
+	}
+	rule := models.WatchListRule{Type: constants.SmartWatchListRuleSector, Sector: "Banks", Limit: 20}
+
+	for i := 0; i < 3; i++ {
+		stocks, err := obj.evaluateSmartRule(rule, models.ReqHeader{})
+		assert.NoError(t, err)
+		assert.Len(t, stocks, 1)
+	}
+	assert.Equal(t, 1, calls)
+
+	// the scheduled refresh re-evaluates rules already in use
+	refreshSmartRules(obj)
+	assert.Equal(t, 2, calls)
+
+	now = now.Add(constants.SmartWatchListRefreshMins * time.Minute)
+	_, _ = obj.evaluateSmartRule(rule, models.ReqHeader{})
+	assert.Equal(t, 3, calls)
+
+	holdingCalls := 0
+	CallFetchHoldingIsins = func(reqH models.ReqHeader) ([]string, error) {
+		holdingCalls++
+		return nil, nil
+	}
+	holdings := models.WatchListRule{Type: constants.SmartWatchListRuleHoldings, Limit: 20}
+	_, _ = obj.evaluateSmartRule(holdings, models.ReqHeader{})
+	_, _ = obj.evaluateSmartRule(holdings, models.ReqHeader{})
+	assert.Equal(t, 2, holdingCalls)
+}
+
+func TestSmartWatchListDetails(t *testing.T) {
+	assert.Equal(t, "sl2", nextSmartWatchListId([]models.MongoSmartWatchList{{WatchListId: "sl1"}, {WatchListId: "sl3"}}))
+	full := []models.MongoSmartWatchList{{WatchListId: "sl1"}, {WatchListId: "sl2"}, {WatchListId: "sl3"}, {WatchListId: "sl4"}, {WatchListId: "sl5"}}
+	assert.Equal(t, "", nextSmartWatchListId(full))
+
+	list := models.MongoSmartWatchList{WatchListId: "sl1", Name: "Banks", Rule: models.WatchListRule{Type: constants.SmartWatchListRuleSector, Sector: "Banks"}, EvaluatedAt: 10}
+	details := smartWatchListDetails(models.WatchListsDetailsV2{WatchListId: "sl1"}, list)
+	assert.True(t, details.IsSmart)
+	assert.Equal(t, "Banks", details.Name)
+	assert.Equal(t, "Banks", details.Rule.Sector)
+	assert.Equal(t, int64(10), details.EvaluatedAt)
+	assert.NotNil(t, details.Stocks)
+}
@@ -0,0 +1,404 @@
+package watchlists
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	technicalindicatorsV2 "space/business/technicalIndicatorsV2"
+	"space/constants"
+	"space/db"
+	"space/loggerconfig"
+	"space/models"
+)
+
+// candleQuote is what the enrichment needs from a stock's daily candles.
+type candleQuote struct {
+	ltp       float64
+	prevClose float64
+	high52    float64
+	low52     float64
+}
+
+var CallFetchWatchListCandles = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+	return technicalindicatorsV2.GetCachedChartData(req, reqH)
+}
+
+var CallFetchSplits = func(coCodes string) ([]models.Splits, error) {
+	return db.GetPgObj().FetchSplits(coCodes)
+}
+
+var CallFetchBonus = func(coCodes string) ([]models.Bonus, error) {
+	return db.GetPgObj().FetchBonus(coCodes)
+}
+
+// needsQuotes is true when the response carries quotes or is arranged by
+// something only the quotes know.
+func needsQuotes(req models.FetchWatchListV3Request) bool {
+	return req.Enrich || req.SortBy == constants.WatchListSortChangePct || req.GroupBy == constants.WatchListGroupSector
+}
+
+// arrangeWatchLists attaches quotes, sorts and groups every list in place. It
+// is a no-op for a plain fetch so the default response is unchanged.
+func arrangeWatchLists(watchLists []models.WatchListsDetailsV2, req models.FetchWatchListV3Request, reqH models.ReqHeader) {
+	if needsQuotes(req) {
+		var stocks []models.StockDetailsV2
+		for _, watchList := range watchLists {
+			stocks = append(stocks, watchList.Stocks...)
+		}
+		quotes := fetchWatchListQuotes(stocks, reqH)
+		for i := range watchLists {
+			for j := range watchLists[i].Stocks {
+				if quote, ok := quotes[watchLists[i].Stocks[j].Exchange+"-"+watchLists[i].Stocks[j].Token]; ok {
+					quote := quote
+					watchLists[i].Stocks[j].Quote = &quote
+				}
+			}
+		}
+	}
+
+	for i := range watchLists {
+		sortWatchListStocks(watchLists[i].Stocks, req.SortBy, req.SortOrder)
+		if req.GroupBy == constants.WatchListGroupSector {
+			watchLists[i].Groups = groupWatchListBySector(watchLists[i].Stocks)
+		}
+	}
+}
+
+// fetchWatchListQuotes builds one quote per exchange token. Candles give the
+// price fields and the screener universe gives market cap, sector and the
+// corporate actions lookup. Each source fails on its own: a stock without
+// candles falls back to the universe price, and a stock outside the universe
+// still gets its candle fields.
+func fetchWatchListQuotes(stocks []models.StockDetailsV2, reqH models.ReqHeader) map[string]models.WatchListQuote {
+	tokens := make(map[string]models.StockDetailsV2)
+	isins := make(map[string]bool)
+	for _, stock := range stocks {
+		if stock.Token == "" {
+			continue
+		}
+		tokens[stock.Exchange+"-"+stock.Token] = stock
+		if stock.Isin != "" {
+			isins[stock.Isin] = true
+		}
+	}
+
+	var candles map[string]candleQuote
+	var universe map[string]models.ScreenerStock
+	var actions map[string]models.WatchListCorporateAction
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				loggerconfig.Error("Alert Severity:P2-Mid, fetchWatchListQuotes candles panic:", r, " requestId:", reqH.RequestId)
+			}
+		}()
+		candles = fetchWatchListCandles(tokens, reqH)
+	}()
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				loggerconfig.Error("Alert Severity:P2-Mid, fetchWatchListQuotes universe panic:", r, " requestId:", reqH.RequestId)
+			}
+		}()
+		universe = fetchWatchListUniverse(isins, reqH)
+		actions = fetchNextCorporateActions(universe, reqH)
+	}()
+	wg.Wait()
+
+	quotes := make(map[string]models.WatchListQuote, len(tokens))
+	for key, stock := range tokens {
+		var quote models.WatchListQuote
+		found := false
+		if candle, ok := candles[key]; ok {
+			found = true
+			quote.Ltp = candle.ltp
+			quote.Week52High = candle.high52
+			quote.Week52Low = candle.low52
+			if candle.prevClose > 0 {
+				quote.Change = roundPrice(candle.ltp - candle.prevClose)
+				quote.ChangePct = roundPrice((candle.ltp - candle.prevClose) / candle.prevClose * 100)
+			}
+		}
+		if company, ok := universe[stock.Isin]; ok && stock.Isin != "" {
+			found = true
+			if quote.Ltp == 0 {
+				quote.Ltp = company.Price
+			}
+			quote.MarketCap = company.MarketCap
+			quote.Sector = company.Sector
+			if action, ok := actions[stock.Isin]; ok {
+				action := action
+				quote.NextCorporateAction = &action
+			}
+		}
+		if found {
+			quotes[key] = quote
+		}
+	}
+	return quotes
+}
+
+// fetchWatchListCandles loads daily candles for every token with a bounded
+// pool. Tokens whose candles cannot be loaded are left out.
+func fetchWatchListCandles(tokens map[string]models.StockDetailsV2, reqH models.ReqHeader) map[string]candleQuote {
+	now := smartNow()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	startTime := strconv.FormatInt(dayStart.AddDate(0, 0, -constants.WatchListEnrichLookbackDays).Unix(), 10)
+	endTime := strconv.FormatInt(now.Unix(), 10)
+
+	quotes := make(map[string]candleQuote, len(tokens))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, constants.WatchListEnrichWorkers)
+
+	for key, stock := range tokens {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key string, stock models.StockDetailsV2) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err, chartData := CallFetchWatchListCandles(models.ChartDataReq{
+				Exchange:     stock.Exchange,
+				Token:        stock.Token,
+				CandleType:   "3",
+				DataDuration: "1",
+				StartTime:    startTime,
+				EndTime:      endTime,
+			}, reqH)
+			if err != nil {
+				loggerconfig.Error("fetchWatchListCandles failed for:", key, " error:", err, " requestId:", reqH.RequestId)
+				return
+			}
+			quote, ok := candleQuoteFromCandles(chartData.Data.Candles)
+			if !ok {
+				return
+			}
+			mu.Lock()
+			quotes[key] = quote
+			mu.Unlock()
+		}(key, stock)
+	}
+	wg.Wait()
+	return quotes
+}
+
+func candleQuoteFromCandles(candles [][]interface{}) (candleQuote, bool) {
+	var quote candleQuote
+	n := len(candles)
+	if n == 0 {
+		return quote, false
+	}
+
+	from := n - constants.WatchListEnrichSessions52W
+	if from < 0 {
+		from = 0
+	}
+	for i := from; i < n; i++ {
+		if len(candles[i]) < 5 {
+			return quote, false
+		}
+		high, ok1 := candles[i][2].(float64)
+		low, ok2 := candles[i][3].(float64)
+		closePrice, ok3 := candles[i][4].(float64)
+		if !ok1 || !ok2 || !ok3 {
+			return quote, false
+		}
+		if i == from {
+			quote.high52, quote.low52 = high, low
+		}
+		quote.high52 = math.Max(quote.high52, high)
+		quote.low52 = math.Min(quote.low52, low)
+		if i == n-2 {
+			quote.prevClose = closePrice
+		}
+		quote.ltp = closePrice
+	}
+	return quote, true
+}
+
+// fetchWatchListUniverse keeps the screener universe rows of the watched ISINs.
+func fetchWatchListUniverse(isins map[string]bool, reqH models.ReqHeader) map[string]models.ScreenerStock {
+	companies := make(map[string]models.ScreenerStock, len(isins))
+	if len(isins) == 0 {
+		return companies
+	}
+	universe, err := CallFetchScreenerUniverse()
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P2-Mid, fetchWatchListUniverse failed, error:", err, " requestId:", reqH.RequestId)
+		return companies
+	}
+	for _, company := range universe {
+		if isins[company.Isin] {
+			companies[company.Isin] = company
+		}
+	}
+	return companies
+}
+
+// fetchNextCorporateActions finds the earliest board meeting, split or bonus in
+// the next WatchListCorporateActionDays for each company, in one query per
+// source for the whole watchlist.
+func fetchNextCorporateActions(companies map[string]models.ScreenerStock, reqH models.ReqHeader) map[string]models.WatchListCorporateAction {
+	next := make(map[string]models.WatchListCorporateAction)
+	isinByCoCode := make(map[int]string, len(companies))
+	coCodes := make([]string, 0, len(companies))
+	for isin, company := range companies {
+		if company.CoCode == 0 {
+			continue
+		}
+		isinByCoCode[company.CoCode] = isin
+		coCodes = append(coCodes, strconv.Itoa(company.CoCode))
+	}
+	if len(coCodes) == 0 {
+		return next
+	}
+	sort.Strings(coCodes)
+	coCodeList := strings.Join(coCodes, ",")
+
+	now := smartNow()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 0, constants.WatchListCorporateActionDays)
+
+	var mu sync.Mutex
+	nextDate := make(map[string]time.Time)
+	consider := func(coCode float64, value, actionType, description string) {
+		isin := isinByCoCode[int(coCode)]
+		date, ok := parseWatchListDate(value, now.Location())
+		if isin == "" || !ok || date.Before(from) || date.After(to) {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if current, ok := nextDate[isin]; ok && !date.Before(current) {
+			return
+		}
+		nextDate[isin] = date
+		next[isin] = models.WatchListCorporateAction{Type: actionType, Date: date.Format("2006-01-02"), Description: strings.TrimSpace(description)}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		meetings, err := CallFetchBoardMeetings(coCodeList)
+		if err != nil {
+			loggerconfig.Error("fetchNextCorporateActions board meetings failed, error:", err, " requestId:", reqH.RequestId)
+			return
+		}
+		for _, meeting := range meetings {
+			consider(meeting.CoCode, meeting.Date, constants.WatchListCorporateActionMeeting, meeting.Note)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		splits, err := CallFetchSplits(coCodeList)
+		if err != nil {
+			loggerconfig.Error("fetchNextCorporateActions splits failed, error:", err, " requestId:", reqH.RequestId)
+			return
+		}
+		for _, split := range splits {
+			description := split.SplitRatio
+			if description == "" {
+				description = split.Remark
+			}
+			consider(split.CoCode, split.SplitDate, constants.WatchListCorporateActionSplit, description)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		bonuses, err := CallFetchBonus(coCodeList)
+		if err != nil {
+			loggerconfig.Error("fetchNextCorporateActions bonus failed, error:", err, " requestId:", reqH.RequestId)
+			return
+		}
+		for _, bonus := range bonuses {
+			description := bonus.BonusRatio
+			if description == "" {
+				description = bonus.Remark
+			}
+			consider(bonus.CoCode, bonus.BonusDate, constants.WatchListCorporateActionBonus, description)
+		}
+	}()
+	wg.Wait()
+	return next
+}
+
+// sortWatchListStocks orders a list by day change (largest first by default)
+// or by name. The custom order is the one the client arranged and is kept.
+// Stocks without a quote stay at the end of a change sort.
+func sortWatchListStocks(stocks []models.StockDetailsV2, sortBy, sortOrder string) {
+	switch sortBy {
+	case constants.WatchListSortChangePct:
+		desc := sortOrder != constants.WatchListSortAsc
+		sort.SliceStable(stocks, func(i, j int) bool {
+			a, b := stocks[i].Quote, stocks[j].Quote
+			if a == nil || b == nil {
+				return a != nil
+			}
+			if desc {
+				return a.ChangePct > b.ChangePct
+			}
+			return a.ChangePct < b.ChangePct
+		})
+	case constants.WatchListSortName:
+		desc := sortOrder == constants.WatchListSortDesc
+		sort.SliceStable(stocks, func(i, j int) bool {
+			a, b := strings.ToUpper(stockName(stocks[i])), strings.ToUpper(stockName(stocks[j]))
+			if desc {
+				return a > b
+			}
+			return a < b
+		})
+	}
+}
+
+func stockName(stock models.StockDetailsV2) string {
+	if stock.DisplayName != "" {
+		return stock.DisplayName
+	}
+	if stock.Symbol != "" {
+		return stock.Symbol
+	}
+	return stock.TradingSymbol
+}
+
+// groupWatchListBySector splits an already sorted list by sector, keeping the
+// order inside each group. Groups are alphabetical with unknown sectors, F&O
+// and commodities last under Others.
+func groupWatchListBySector(stocks []models.StockDetailsV2) []models.WatchListGroup {
+	index := make(map[string]int)
+	groups := []models.WatchListGroup{}
+	for _, stock := range stocks {
+		sector := constants.WatchListSectorOthers
+		if stock.Quote != nil && stock.Quote.Sector != "" {
+			sector = stock.Quote.Sector
+		}
+		i, ok := index[sector]
+		if !ok {
+			i = len(groups)
+			index[sector] = i
+			groups = append(groups, models.WatchListGroup{Name: sector})
+		}
+		groups[i].Stocks = append(groups[i].Stocks, stock)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Name == constants.WatchListSectorOthers || groups[j].Name == constants.WatchListSectorOthers {
+			return groups[j].Name == constants.WatchListSectorOthers && groups[i].Name != constants.WatchListSectorOthers
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+func roundPrice(value float64) float64 {
+	return math.Round(value*100) / 100
+}
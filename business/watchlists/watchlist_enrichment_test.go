@@ -0,0 +1,159 @@
+package watchlists
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func candles(closes ...float64) [][]interface{} {
+	var rows [][]interface{}
+	for i, c := range closes {
+		rows = append(rows, []interface{}{float64(i), c, c + 5, c - 5, c, 1000.0})
+	}
+	return rows
+}
+
+func TestCandleQuoteFromCandles(t *testing.T) {
+	quote, ok := candleQuoteFromCandles(candles(100, 120, 90, 110))
+	assert.True(t, ok)
+	assert.Equal(t, candleQuote{ltp: 110, prevClose: 90, high52: 125, low52: 85}, quote)
+
+	// a listing day has no previous close
+	quote, ok = candleQuoteFromCandles(candles(50))
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, quote.prevClose)
+
+	_, ok = candleQuoteFromCandles(nil)
+	assert.False(t, ok)
+	_, ok = candleQuoteFromCandles([][]interface{}{{1.0, "bad"}})
+	assert.False(t, ok)
+}
+
+func TestFetchWatchListQuotes(t *testing.T) {
+	origNow, origCandles, origUniverse, origMeetings := smartNow, CallFetchWatchListCandles, CallFetchScreenerUniverse, CallFetchBoardMeetings
+	origSplits, origBonus, origError := CallFetchSplits, CallFetchBonus, loggerconfig.Error
+	t.Cleanup(func() {
+		smartNow, CallFetchWatchListCandles, CallFetchScreenerUniverse, CallFetchBoardMeetings = origNow, origCandles, origUniverse, origMeetings
+		CallFetchSplits, CallFetchBonus, loggerconfig.Error = origSplits, origBonus, origError
+	})
+	loggerconfig.Error = func(args ...interface{}) {}
+	smartNow = func() time.Time { return time.Date(2026, 10, 21, 11, 0, 0, 0, constants.LocationKolkata) }
+
+	CallFetchWatchListCandles = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+		var res models.ChartDataResponse
+		switch req.Token {
+		case "3045":
+			res.Data.Candles = candles(800, 820)
+		case "35012":
+			res.Data.Candles = candles(200, 190)
+		default:
+			return errors.New("no candles"), res
+		}
+		return nil, res
+	}
+	CallFetchScreenerUniverse = func() ([]models.ScreenerStock, error) {
+		return []models.ScreenerStock{
+			{Isin: "INE062A01020", CoCode: 3045, Sector: "Banks", MarketCap: 700000, Price: 815},
+			{Isin: "INE040A01034", CoCode: 1333, Sector: "Banks", MarketCap: 1200000, Price: 1650},
+			{Isin: "INE009A01021", CoCode: 1594, Sector: "IT - Software", MarketCap: 600000},
+		}, nil
+	}
+	CallFetchBoardMeetings = func(coCodes string) ([]models.BoardMeetingForthComing, error) {
+		assert.Equal(t, "1333,3045", coCodes)
+		return []models.BoardMeetingForthComing{
+			{CoCode: 3045, Date: "2026-11-05", Note: "Quarterly Results"},
+			{CoCode: 1333, Date: "2026-10-01", Note: "Results"},
+		}, nil
+	}
+	CallFetchSplits = func(coCodes string) ([]models.Splits, error) {
+		return nil, errors.New("postgres down")
+	}
+	CallFetchBonus = func(coCodes string) ([]models.Bonus, error) {
+		return []models.Bonus{{CoCode: 3045, BonusDate: "2026-10-28T00:00:00Z", BonusRatio: "1:1"}}, nil
+	}
+
+	quotes := fetchWatchListQuotes([]models.StockDetailsV2{
+		{Isin: "INE062A01020", Exchange: "NSE", Token: "3045"},
+		{Isin: "INE040A01034", Exchange: "NSE", Token: "1333"},
+		{StockId: "NFO-35012", Exchange: "NFO", Token: "35012"},
+		{StockId: "MCX-1", Exchange: "MCX", Token: "1"},
+	}, models.ReqHeader{})
+
+	sbin := quotes["NSE-3045"]
+	assert.Equal(t, 820.0, sbin.Ltp)
+	assert.Equal(t, 20.0, sbin.Change)
+	assert.Equal(t, 2.5, sbin.ChangePct)
+	assert.Equal(t, 825.0, sbin.Week52High)
+	assert.Equal(t, 795.0, sbin.Week52Low)
+	assert.Equal(t, 700000.0, sbin.MarketCap)
+	assert.Equal(t, "Banks", sbin.Sector)
+	// the bonus is earlier than the board meeting, a failed splits lookup is skipped
+	assert.Equal(t, &models.WatchListCorporateAction{Type: constants.WatchListCorporateActionBonus, Date: "2026-10-28", Description: "1:1"}, sbin.NextCorporateAction)
+
+	// no candles: the universe price stands in and there is no day change
+	hdfc := quotes["NSE-1333"]
+	assert.Equal(t, 1650.0, hdfc.Ltp)
+	assert.Equal(t, 0.0, hdfc.ChangePct)
+	assert.Nil(t, hdfc.NextCorporateAction)
+
+	assert.Equal(t, -5.0, quotes["NFO-35012"].ChangePct)
+	assert.Empty(t, quotes["NFO-35012"].Sector)
+	_, ok := quotes["MCX-1"]
+	assert.False(t, ok)
+}
+
+func TestSortAndGroupWatchList(t *testing.T) {
+	stocks := []models.StockDetailsV2{
+		{DisplayName: "TCS", Quote: &models.WatchListQuote{ChangePct: 1.2, Sector: "IT - Software"}},
+		{DisplayName: "NIFTY24DECFUT"},
+		{DisplayName: "hdfcbank", Quote: &models.WatchListQuote{ChangePct: -0.4, Sector: "Banks"}},
+		{DisplayName: "SBIN", Quote: &models.WatchListQuote{ChangePct: 2.5, Sector: "Banks"}},
+	}
+	names := func() []string {
+		var out []string
+		for _, stock := range stocks {
+			out = append(out, stock.DisplayName)
+		}
+		return out
+	}
+
+	sortWatchListStocks(stocks, constants.WatchListSortCustom, "")
+	assert.Equal(t, []string{"TCS", "NIFTY24DECFUT", "hdfcbank", "SBIN"}, names())
+
+	sortWatchListStocks(stocks, constants.WatchListSortChangePct, "")
+	assert.Equal(t, []string{"SBIN", "TCS", "hdfcbank", "NIFTY24DECFUT"}, names())
+
+	sortWatchListStocks(stocks, constants.WatchListSortChangePct, constants.WatchListSortAsc)
+	assert.Equal(t, []string{"hdfcbank", "TCS", "SBIN", "NIFTY24DECFUT"}, names())
+
+	sortWatchListStocks(stocks, constants.WatchListSortName, "")
+	assert.Equal(t, []string{"hdfcbank", "NIFTY24DECFUT", "SBIN", "TCS"}, names())
+
+	groups := groupWatchListBySector(stocks)
+	assert.Len(t, groups, 3)
+	assert.Equal(t, "Banks", groups[0].Name)
+	assert.Equal(t, []string{"hdfcbank", "SBIN"}, []string{groups[0].Stocks[0].DisplayName, groups[0].Stocks[1].DisplayName})
+	assert.Equal(t, "IT - Software", groups[1].Name)
+	assert.Equal(t, constants.WatchListSectorOthers, groups[2].Name)
+}
+
+func TestArrangeWatchListsPlainFetch(t *testing.T) {
+	origCandles := CallFetchWatchListCandles
+	t.Cleanup(func() { CallFetchWatchListCandles = origCandles })
+	CallFetchWatchListCandles = func(req models.ChartDataReq, reqH models.ReqHeader) (error, models.ChartDataResponse) {
+		t.Fatal("a plain fetch must not load quotes")
+		return nil, models.ChartDataResponse{}
+	}
+	watchLists := []models.WatchListsDetailsV2{{WatchListId: "wl1", Stocks: []models.StockDetailsV2{{DisplayName: "B", Token: "2"}, {DisplayName: "A", Token: "1"}}}}
+	arrangeWatchLists(watchLists, models.FetchWatchListV3Request{SortBy: constants.WatchListSortName}, models.ReqHeader{})
+	assert.Equal(t, "A", watchLists[0].Stocks[0].DisplayName)
+	assert.Nil(t, watchLists[0].Stocks[0].Quote)
+	assert.Nil(t, watchLists[0].Groups)
+}
@@ -0,0 +1,162 @@
+package watchlists
+
+import (
+	"net/http"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/dbops"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ShareWatchList publishes a read-only link to one of the client's watchlists.
+// Sharing the same watchlist again returns the link already published.
+func (obj WatchlistsObj) ShareWatchList(req models.ShareWatchListRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	var share models.MongoWatchListShare
+	err := dbops.MongoRepo.FindOne(constants.WATCHLISTSHARESCOLLECTION, bson.M{"clientId": req.ClientId, "watchListId": req.WatchListId, "revoked": false}, &share)
+	if err != nil && err.Error() != constants.MongoNoDocError {
+		loggerconfig.Error("Alert Severity:P1-High, ShareWatchList Mongo error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	if err != nil {
+		now := helpers.GetCurrentTimeInIST().Unix()
+		share = models.MongoWatchListShare{
+			ShareId:     uuid.NewString(),
+			ClientId:    req.ClientId,
+			WatchListId: req.WatchListId,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := dbops.MongoRepo.InsertOne(constants.WATCHLISTSHARESCOLLECTION, share); err != nil {
+			loggerconfig.Error("Alert Severity:P1-High, ShareWatchList Mongo insert failed error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+			return apihelpers.SendInternalServerError()
+		}
+	}
+
+	resp := models.ShareWatchListResponse{ShareId: share.ShareId}
+	if constants.WatchListShareBaseUrl != "" {
+		resp.ShareLink = constants.WatchListShareBaseUrl + share.ShareId
+	}
+
+	loggerconfig.Info("ShareWatchList Successful, shareId:", share.ShareId, " watchListId:", req.WatchListId, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Data = resp
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// FetchSharedWatchList shows the current stocks of a shared watchlist to any
+// client. The owner's client id is not part of the response.
+func (obj WatchlistsObj) FetchSharedWatchList(req models.FetchSharedWatchListRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	share, stocks, code, errRes := fetchSharedStocks(req.ShareId, reqH)
+	if code != http.StatusOK {
+		return code, errRes
+	}
+
+	watchList := obj.enrichWatchList(share.WatchListId, stocks, reqH)
+
+	apiRes.Data = models.FetchSharedWatchListResponse{
+		ShareId:  share.ShareId,
+		SharedAt: share.CreatedAt,
+		Stocks:   watchList.Stocks,
+	}
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// CloneSharedWatchList copies the stocks of a shared watchlist into one of the
+// caller's own watchlists, skipping stocks already in it.
+func (obj WatchlistsObj) CloneSharedWatchList(req models.CloneSharedWatchListRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	_, stocks, code, errRes := fetchSharedStocks(req.ShareId, reqH)
+	if code != http.StatusOK {
+		return code, errRes
+	}
+
+	var stockLists models.MongoNewWatchListsV2
+	err := dbops.MongoRepo.FindOne(constants.WATCHLISTSTOCKSCOLLECTIONNEW, bson.M{"clientId": req.ClientId}, &stockLists)
+	if err != nil && err.Error() != constants.MongoNoDocError {
+		loggerconfig.Error("Alert Severity:P1-High, CloneSharedWatchList Mongo error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	stockLists.ClientId = req.ClientId
+
+	added, duplicates := mergeIntoWatchList(watchListById(&stockLists, req.WatchListId), stocks, req.Replace)
+
+	if err := saveWatchLists(stockLists); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, CloneSharedWatchList Mongo Upsert failed error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	loggerconfig.Info("CloneSharedWatchList Successful, shareId:", req.ShareId, " watchListId:", req.WatchListId, " added:", added, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Data = models.CloneSharedWatchListResponse{WatchListId: req.WatchListId, Added: added, Duplicates: duplicates}
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// RevokeSharedWatchList disables a link the client published.
+func (obj WatchlistsObj) RevokeSharedWatchList(req models.RevokeSharedWatchListRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	filter := bson.M{"shareId": req.ShareId, "clientId": req.ClientId, "revoked": false}
+	var share models.MongoWatchListShare
+	err := dbops.MongoRepo.FindOne(constants.WATCHLISTSHARESCOLLECTION, filter, &share)
+	if err != nil && err.Error() == constants.MongoNoDocError {
+		loggerconfig.Error("RevokeSharedWatchList share not found, shareId:", req.ShareId, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.SharedWatchListNotFound, http.StatusBadRequest)
+	}
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, RevokeSharedWatchList Mongo error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	update := bson.M{"$set": bson.M{"revoked": true, "updatedAt": helpers.GetCurrentTimeInIST().Unix()}}
+	if err := dbops.MongoRepo.UpdateOne(constants.WATCHLISTSHARESCOLLECTION, filter, update); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, RevokeSharedWatchList Mongo update failed error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// fetchSharedStocks loads a live share and the owner's stored entries for the
+// shared watchlist.
+func fetchSharedStocks(shareId string, reqH models.ReqHeader) (models.MongoWatchListShare, []models.StockDetailsV2, int, apihelpers.APIRes) {
+	var share models.MongoWatchListShare
+	err := dbops.MongoRepo.FindOne(constants.WATCHLISTSHARESCOLLECTION, bson.M{"shareId": shareId, "revoked": false}, &share)
+	if err != nil && err.Error() == constants.MongoNoDocError {
+		loggerconfig.Error("fetchSharedStocks share not found, shareId:", shareId, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+		code, res := apihelpers.SendErrorResponse(false, constants.SharedWatchListNotFound, http.StatusBadRequest)
+		return share, nil, code, res
+	}
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, fetchSharedStocks Mongo error =", err, " shareId:", shareId, " requestId:", reqH.RequestId)
+		code, res := apihelpers.SendInternalServerError()
+		return share, nil, code, res
+	}
+
+	var stockLists models.MongoNewWatchListsV2
+	err = dbops.MongoRepo.FindOne(constants.WATCHLISTSTOCKSCOLLECTIONNEW, bson.M{"clientId": share.ClientId}, &stockLists)
+	if err != nil && err.Error() != constants.MongoNoDocError {
+		loggerconfig.Error("Alert Severity:P1-High, fetchSharedStocks Mongo error =", err, " shareId:", shareId, " requestId:", reqH.RequestId)
+		code, res := apihelpers.SendInternalServerError()
+		return share, nil, code, res
+	}
+
+	return share, *watchListById(&stockLists, share.WatchListId), http.StatusOK, apihelpers.APIRes{}
+}
@@ -0,0 +1,380 @@
+package watchlists
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/dbops"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/tealeg/xlsx/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var errWatchListFileHeader = errors.New("file needs a header row with a symbol or isin column")
+
+// watchListFileRow is one data row of an imported file, Line is the line
+// number in the file so users can find it.
+type watchListFileRow struct {
+	Line     int
+	Symbol   string
+	Isin     string
+	Exchange string
+}
+
+var exportColumns = []string{constants.WatchListColumnSymbol, constants.WatchListColumnIsin, constants.WatchListColumnExchange, "token", "tradingSymbol", "company"}
+
+var CallFetchIsinContracts = func(obj WatchlistsObj) (map[string]string, error) {
+	return obj.contractCacheCli.GetAllFromHashWithPipeline("isin_data", 1000)
+}
+
+var CallUploadWatchListExport = func(fileName string, body []byte) (string, error) {
+	return helpers.UploadBytesToS3AndGetPresignedURL(constants.WatchListS3FolderName, fileName, body, constants.WatchListExportExpiryHours)
+}
+
+// ImportWatchList adds the stocks of a CSV or XLSX file to one watchlist. Rows are
+// resolved by ISIN, or by symbol when the ISIN is blank, against the contract
+// cache. Rows that resolve to nothing are reported back instead of failing the import.
+func (obj WatchlistsObj) ImportWatchList(req models.ImportWatchListRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	rows, err := parseWatchListFile(req.FileName, req.Content)
+	if err != nil || len(rows) > constants.WatchListImportMaxRows {
+		loggerconfig.Error("ImportWatchList invalid file:", req.FileName, " rows:", len(rows), " error:", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendErrorResponse(false, constants.InvalidWatchListFile, http.StatusBadRequest)
+	}
+
+	var stockLists models.MongoNewWatchListsV2
+	err = dbops.MongoRepo.FindOne(constants.WATCHLISTSTOCKSCOLLECTIONNEW, bson.M{"clientId": req.ClientId}, &stockLists)
+	if err != nil && err.Error() != constants.MongoNoDocError {
+		loggerconfig.Error("Alert Severity:P1-High, ImportWatchList Mongo error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+	stockLists.ClientId = req.ClientId
+
+	stocks, unmatched := obj.resolveImportRows(rows, reqH)
+
+	watchList := watchListById(&stockLists, req.WatchListId)
+	added, duplicates := mergeIntoWatchList(watchList, stocks, req.Replace)
+
+	if err := saveWatchLists(stockLists); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, ImportWatchList Mongo Upsert failed error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	resp := models.ImportWatchListResponse{
+		WatchListId:   req.WatchListId,
+		TotalRows:     len(rows),
+		Imported:      added,
+		Duplicates:    duplicates,
+		UnmatchedRows: unmatched,
+	}
+	loggerconfig.Info("ImportWatchList Successful, response:", helpers.LogStructAsJSON(resp), " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+	apiRes.Data = resp
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+// ExportWatchList writes one watchlist as CSV or XLSX with the same columns the
+// import reads, and returns a pre-signed download link.
+func (obj WatchlistsObj) ExportWatchList(req models.ExportWatchListRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	var apiRes apihelpers.APIRes
+
+	var stockLists models.MongoNewWatchListsV2
+	err := dbops.MongoRepo.FindOne(constants.WATCHLISTSTOCKSCOLLECTIONNEW, bson.M{"clientId": req.ClientId}, &stockLists)
+	if err != nil && err.Error() != constants.MongoNoDocError {
+		loggerconfig.Error("Alert Severity:P1-High, ExportWatchList Mongo error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	watchList := obj.enrichWatchList(req.WatchListId, *watchListById(&stockLists, req.WatchListId), reqH)
+	records := watchListRecords(watchList.Stocks)
+
+	var body []byte
+	if req.Format == constants.WatchListFormatXlsx {
+		body, err = writeWatchListXlsx(req.WatchListId, records)
+	} else {
+		body, err = writeWatchListCsv(records)
+	}
+	if err != nil {
+		loggerconfig.Error("ExportWatchList, Error in writing ", req.Format, " file, error:", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	fileName := fmt.Sprintf("%s_%s_%d.%s", req.ClientId, req.WatchListId, time.Now().Unix(), req.Format)
+	url, err := CallUploadWatchListExport(fileName, body)
+	if err != nil {
+		loggerconfig.Error("ExportWatchList, failed to generate pre-signed URL:", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId)
+		return apihelpers.SendInternalServerError()
+	}
+
+	apiRes.Data = models.ExportWatchListResponse{DownloadUrl: url}
+	apiRes.Message = "SUCCESS"
+	apiRes.Status = true
+	return http.StatusOK, apiRes
+}
+
+func parseWatchListFile(fileName string, content []byte) ([]watchListFileRow, error) {
+	var records [][]string
+	var lines []int
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(fileName), ".")) {
+	case constants.WatchListFormatCsv:
+		reader := csv.NewReader(bytes.NewReader(content))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			// blank lines are skipped by the reader, keep the real line number
+			line, _ := reader.FieldPos(0)
+			records = append(records, record)
+			lines = append(lines, line)
+		}
+	case constants.WatchListFormatXlsx:
+		file, err := xlsx.OpenBinary(content)
+		if err != nil {
+			return nil, err
+		}
+		if len(file.Sheets) == 0 {
+			return nil, errWatchListFileHeader
+		}
+		err = file.Sheets[0].ForEachRow(func(row *xlsx.Row) error {
+			var record []string
+			err := row.ForEachCell(func(cell *xlsx.Cell) error {
+				record = append(record, cell.String())
+				return nil
+			})
+			records = append(records, record)
+			lines = append(lines, row.GetCoordinate()+1)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file type %q", fileName)
+	}
+
+	if len(records) == 0 {
+		return nil, errWatchListFileHeader
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	_, hasSymbol := columns[constants.WatchListColumnSymbol]
+	_, hasIsin := columns[constants.WatchListColumnIsin]
+	if !hasSymbol && !hasIsin {
+		return nil, errWatchListFileHeader
+	}
+
+	column := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.ToUpper(strings.TrimSpace(record[i]))
+		}
+		return ""
+	}
+
+	var rows []watchListFileRow
+	for i := 1; i < len(records); i++ {
+		row := watchListFileRow{
+			Line:     lines[i],
+			Symbol:   column(records[i], constants.WatchListColumnSymbol),
+			Isin:     column(records[i], constants.WatchListColumnIsin),
+			Exchange: column(records[i], constants.WatchListColumnExchange),
+		}
+		if row.Symbol == "" && row.Isin == "" && row.Exchange == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// resolveImportRows maps file rows to watchlist entries in the same ISIN form
+// AddStockToWatchListV3 stores. Without an exchange NSE is tried before BSE.
+func (obj WatchlistsObj) resolveImportRows(rows []watchListFileRow, reqH models.ReqHeader) ([]models.StockDetailsV2, []models.WatchListImportRow) {
+	stocks := []models.StockDetailsV2{}
+	unmatched := []models.WatchListImportRow{}
+	var bySymbol map[string]models.ContractDetails
+
+	for _, row := range rows {
+		report := models.WatchListImportRow{Row: row.Line, Symbol: row.Symbol, Isin: row.Isin, Exchange: row.Exchange}
+
+		exchanges := []string{strings.ToUpper(constants.NSE), strings.ToUpper(constants.BSE)}
+		if row.Exchange != "" {
+			if !strings.EqualFold(row.Exchange, constants.NSE) && !strings.EqualFold(row.Exchange, constants.BSE) {
+				report.Reason = constants.WatchListUnmatchedExchange
+				unmatched = append(unmatched, report)
+				continue
+			}
+			exchanges = []string{row.Exchange}
+		}
+		if row.Symbol == "" && row.Isin == "" {
+			report.Reason = constants.WatchListUnmatchedNoKey
+			unmatched = append(unmatched, report)
+			continue
+		}
+
+		var stock models.StockDetailsV2
+		for _, exchange := range exchanges {
+			isin := row.Isin
+			if isin == "" {
+				if bySymbol == nil {
+					bySymbol = obj.symbolIndex(reqH)
+				}
+				isin = bySymbol[exchange+"|"+row.Symbol].Isin
+			} else if err, _ := obj.contractCacheCli.GetFromHash("isin_data", exchange+"-"+isin); err != nil {
+				isin = ""
+			}
+			if isin != "" {
+				stock = models.StockDetailsV2{Isin: isin, Exchange: exchange, IsinStockId: exchange + "-" + isin}
+				break
+			}
+		}
+		if stock.IsinStockId == "" {
+			report.Reason = constants.WatchListUnmatchedNotFound
+			unmatched = append(unmatched, report)
+			continue
+		}
+		stocks = append(stocks, stock)
+	}
+	return stocks, unmatched
+}
+
+// symbolIndex keys the contract cache isin_data bucket by exchange and symbol,
+// as well as trading symbol so "SBIN-EQ" resolves too.
+func (obj WatchlistsObj) symbolIndex(reqH models.ReqHeader) map[string]models.ContractDetails {
+	index := make(map[string]models.ContractDetails)
+	data, err := CallFetchIsinContracts(obj)
+	if err != nil {
+		loggerconfig.Error("Alert Severity:P2-Mid, ImportWatchList symbolIndex GetAllFromHash isin_data failed, err:", err, " requestId:", reqH.RequestId)
+		return index
+	}
+	for _, val := range data {
+		var detail models.ContractDetails
+		if err := json.Unmarshal([]byte(val), &detail); err != nil || detail.Isin == "" {
+			continue
+		}
+		exchange := strings.ToUpper(detail.Exchange)
+		index[exchange+"|"+strings.ToUpper(detail.TradingSymbol)] = detail
+		index[exchange+"|"+strings.ToUpper(detail.Symbol)] = detail
+	}
+	return index
+}
+
+func watchListById(stockLists *models.MongoNewWatchListsV2, watchListId string) *[]models.StockDetailsV2 {
+	switch watchListId {
+	case "wl2":
+		return &stockLists.WatchList2
+	case "wl3":
+		return &stockLists.WatchList3
+	case "wl4":
+		return &stockLists.WatchList4
+	case "wl5":
+		return &stockLists.WatchList5
+	}
+	return &stockLists.WatchList1
+}
+
+// mergeIntoWatchList appends stocks that are not already in the list, keeping
+// their order. With replace the list is emptied first.
+func mergeIntoWatchList(watchList *[]models.StockDetailsV2, stocks []models.StockDetailsV2, replace bool) (int, int) {
+	if replace {
+		*watchList = []models.StockDetailsV2{}
+	}
+	added, duplicates := 0, 0
+	for _, stock := range stocks {
+		stockId := stock.StockId
+		if stock.Isin != "" {
+			stockId = stock.IsinStockId
+		}
+		if duplicateStockInWatchlistV2(stockId, *watchList) {
+			duplicates++
+			continue
+		}
+		*watchList = append(*watchList, stock)
+		added++
+	}
+	return added, duplicates
+}
+
+func saveWatchLists(stockLists models.MongoNewWatchListsV2) error {
+	filter := bson.D{{Key: "clientId", Value: stockLists.ClientId}}
+	update := bson.D{{Key: "$set", Value: stockLists}}
+	opts := options.Update().SetUpsert(true)
+	return dbops.MongoRepo.UpdateOne(constants.WATCHLISTSTOCKSCOLLECTIONNEW, filter, update, opts)
+}
+
+// enrichWatchList fills in contract details the same way FetchWatchListsV3 does.
+func (obj WatchlistsObj) enrichWatchList(watchListId string, stocks []models.StockDetailsV2, reqH models.ReqHeader) models.WatchListsDetailsV2 {
+	var wg sync.WaitGroup
+	resultChan := make(chan models.WatchListsDetailsV2, 1)
+	errChan := make(chan error, len(stocks))
+
+	wg.Add(1)
+	obj.AddWatchlistStockData(watchListId, stocks, &wg, resultChan, errChan)
+	close(errChan)
+
+	for err := range errChan {
+		loggerconfig.Error("Alert Severity:P2-Mid, enrichWatchList Error in fetching contractDetails from redis or Unmarshalling, watchListId:", watchListId, " requestId:", reqH.RequestId, " err ", err)
+	}
+	return <-resultChan
+}
+
+func watchListRecords(stocks []models.StockDetailsV2) [][]string {
+	records := [][]string{exportColumns}
+	for _, stock := range stocks {
+		records = append(records, []string{stock.Symbol, stock.Isin, stock.Exchange, stock.Token, stock.TradingSymbol, stock.Company})
+	}
+	return records
+}
+
+func writeWatchListCsv(records [][]string) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func writeWatchListXlsx(watchListId string, records [][]string) ([]byte, error) {
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet(watchListId)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		row := sheet.AddRow()
+		for _, value := range record {
+			row.AddCell().SetString(value)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := file.Write(&buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,127 @@
+package watchlists
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"space/constants"
+	"space/helpers/cache"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeIsinCache struct {
+	cache.ContractCache
+	contracts map[string]models.ContractDetails // keyed by EXCHANGE-ISIN
+}
+
+func (f fakeIsinCache) GetFromHash(hash, key string) (error, string) {
+	contract, ok := f.contracts[key]
+	if hash != "isin_data" || !ok {
+		return errors.New("redis: nil"), ""
+	}
+	raw, _ := json.Marshal(contract)
+	return nil, string(raw)
+}
+
+func (f fakeIsinCache) GetAllFromHashWithPipeline(hash string, batchSize int64) (map[string]string, error) {
+	data := make(map[string]string)
+	for key := range f.contracts {
+		_, data[key] = f.GetFromHash(hash, key)
+	}
+	return data, nil
+}
+
+func isinFixture() fakeIsinCache {
+	return fakeIsinCache{contracts: map[string]models.ContractDetails{
+		"NSE-INE062A01020": {Exchange: "NSE", Token1: "3045", Symbol: "SBIN", TradingSymbol: "SBIN-EQ", Isin: "INE062A01020", Name: "STATE BANK OF INDIA"},
+		"BSE-INE062A01020": {Exchange: "BSE", Token1: "500112", Symbol: "SBIN", TradingSymbol: "SBIN", Isin: "INE062A01020", Name: "STATE BANK OF INDIA"},
+		"NSE-INE040A01034": {Exchange: "NSE", Token1: "1333", Symbol: "HDFCBANK", TradingSymbol: "HDFCBANK-EQ", Isin: "INE040A01034", Name: "HDFC BANK"},
+		"BSE-INE0BSEONLY1": {Exchange: "BSE", Token1: "543000", Symbol: "SMALLCO", TradingSymbol: "SMALLCO", Isin: "INE0BSEONLY1", Name: "SMALL CO"},
+	}}
+}
+
+func TestParseWatchListFile(t *testing.T) {
+	csvFile := "\ufeffSymbol, ISIN ,Exchange\nsbin,,nse\n,INE040A01034,\n\n,,\nHDFCBANK\n"
+	rows, err := parseWatchListFile("my list.CSV", []byte(csvFile))
+	assert.NoError(t, err)
+	assert.Equal(t, []watchListFileRow{
+		{Line: 2, Symbol: "SBIN", Exchange: "NSE"},
+		{Line: 3, Isin: "INE040A01034"},
+		{Line: 6, Symbol: "HDFCBANK"},
+	}, rows)
+
+	_, err = parseWatchListFile("list.csv", []byte("name,qty\nSBIN,10\n"))
+	assert.Equal(t, errWatchListFileHeader, err)
+	_, err = parseWatchListFile("list.pdf", []byte("symbol\nSBIN\n"))
+	assert.Error(t, err)
+
+	// an export reads back as an import
+	body, err := writeWatchListXlsx("wl1", watchListRecords([]models.StockDetailsV2{
+		{Symbol: "SBIN", Isin: "INE062A01020", Exchange: "NSE", Token: "3045"},
+	}))
+	assert.NoError(t, err)
+	rows, err = parseWatchListFile("wl1.xlsx", body)
+	assert.NoError(t, err)
+	assert.Equal(t, []watchListFileRow{{Line: 2, Symbol: "SBIN", Isin: "INE062A01020", Exchange: "NSE"}}, rows)
+}
+
+func TestResolveImportRows(t *testing.T) {
+	origError := loggerconfig.Error
+	t.Cleanup(func() { loggerconfig.Error = origError })
+	loggerconfig.Error = func(args ...interface{}) {}
+	obj := InitWatchlists(nil, isinFixture())
+
+	stocks, unmatched := obj.resolveImportRows([]watchListFileRow{
+		{Line: 2, Isin: "INE062A01020"},
+		{Line: 3, Symbol: "SBIN", Exchange: "BSE"},
+		{Line: 4, Symbol: "HDFCBANK-EQ"},
+		{Line: 5, Symbol: "SMALLCO"},
+		{Line: 6, Symbol: "NIFTY24DECFUT", Exchange: "NFO"},
+		{Line: 7, Exchange: "NSE"},
+		{Line: 8, Symbol: "UNKNOWN"},
+		{Line: 9, Isin: "INE040A01034", Exchange: "BSE"},
+	}, models.ReqHeader{})
+
+	assert.Equal(t, []models.StockDetailsV2{
+		{Isin: "INE062A01020", Exchange: "NSE", IsinStockId: "NSE-INE062A01020"},
+		{Isin: "INE062A01020", Exchange: "BSE", IsinStockId: "BSE-INE062A01020"},
+		{Isin: "INE040A01034", Exchange: "NSE", IsinStockId: "NSE-INE040A01034"},
+		// falls through to BSE when NSE has no listing
+		{Isin: "INE0BSEONLY1", Exchange: "BSE", IsinStockId: "BSE-INE0BSEONLY1"},
+	}, stocks)
+	assert.Equal(t, []models.WatchListImportRow{
+		{Row: 6, Symbol: "NIFTY24DECFUT", Exchange: "NFO", Reason: constants.WatchListUnmatchedExchange},
+		{Row: 7, Exchange: "NSE", Reason: constants.WatchListUnmatchedNoKey},
+		{Row: 8, Symbol: "UNKNOWN", Reason: constants.WatchListUnmatchedNotFound},
+		{Row: 9, Isin: "INE040A01034", Exchange: "BSE", Reason: constants.WatchListUnmatchedNotFound},
+	}, unmatched)
+}
+
+func TestMergeIntoWatchList(t *testing.T) {
+	var lists models.MongoNewWatchListsV2
+	lists.WatchList3 = []models.StockDetailsV2{
+		{Isin: "INE062A01020", Exchange: "NSE", IsinStockId: "NSE-INE062A01020"},
+		{StockId: "NFO-35012", Exchange: "NFO", Token: "35012"},
+	}
+	imported := []models.StockDetailsV2{
+		{Isin: "INE040A01034", Exchange: "NSE", IsinStockId: "NSE-INE040A01034"},
+		{Isin: "INE062A01020", Exchange: "NSE", IsinStockId: "NSE-INE062A01020"},
+		{Isin: "INE040A01034", Exchange: "NSE", IsinStockId: "NSE-INE040A01034"},
+	}
+
+	added, duplicates := mergeIntoWatchList(watchListById(&lists, "wl3"), imported, false)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, 2, duplicates)
+	assert.Len(t, lists.WatchList3, 3)
+	assert.Equal(t, "NSE-INE040A01034", lists.WatchList3[2].IsinStockId)
+	assert.Empty(t, lists.WatchList1)
+
+	added, duplicates = mergeIntoWatchList(watchListById(&lists, "wl3"), imported, true)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 1, duplicates)
+	assert.Equal(t, []string{"NSE-INE040A01034", "NSE-INE062A01020"}, []string{lists.WatchList3[0].IsinStockId, lists.WatchList3[1].IsinStockId})
+}
@@ -866,21 +866,33 @@ func (obj WatchlistsObj) FetchWatchListsV3(req models.FetchWatchListV3Request, r
 
 	resp.ClientId = req.ClientId
 
-	watchLists := make([]models.WatchListsDetailsV2, 5)
+	smartLists, err := fetchSmartWatchLists(req.ClientId)
+	if err != nil {
+		// static lists are still served when smart lists can't be read
+		loggerconfig.Error("Alert Severity:P2-Mid, FetchWatchListsV3 smart watchlists mongo error =", err, " clientId:", req.ClientId, " requestId:", reqH.RequestId, " clientVersion:", reqH.ClientVersion)
+	}
+	obj.refreshSmartWatchLists(smartLists, reqH)
+
+	watchLists := make([]models.WatchListsDetailsV2, 5+len(smartLists))
+	smartIndex := make(map[string]int, len(smartLists))
 
 	var wg sync.WaitGroup
 
 	// Channels for results and errors
-	resultChan := make(chan models.WatchListsDetailsV2, 5)
+	resultChan := make(chan models.WatchListsDetailsV2, 5+len(smartLists))
 	errChan := make(chan error, 10)
 
 	// Start a goroutine to process the watchList
-	wg.Add(5)
+	wg.Add(5 + len(smartLists))
 	go obj.AddWatchlistStockData("wl1", stockLists.WatchList1, &wg, resultChan, errChan)
 	go obj.AddWatchlistStockData("wl2", stockLists.WatchList2, &wg, resultChan, errChan)
 	go obj.AddWatchlistStockData("wl3", stockLists.WatchList3, &wg, resultChan, errChan)
 	go obj.AddWatchlistStockData("wl4", stockLists.WatchList4, &wg, resultChan, errChan)
 	go obj.AddWatchlistStockData("wl5", stockLists.WatchList5, &wg, resultChan, errChan)
+	for i, smartList := range smartLists {
+		smartIndex[smartList.WatchListId] = 5 + i
+		go obj.AddWatchlistStockData(smartList.WatchListId, smartList.Stocks, &wg, resultChan, errChan)
+	}
 
 	// Wait for the goroutine to finish
 	go func() {
@@ -907,12 +919,18 @@ func (obj WatchlistsObj) FetchWatchListsV3(req models.FetchWatchListV3Request, r
 		case "wl5":
 			watchLists[4] = result
 		default:
+			if i, ok := smartIndex[result.WatchListId]; ok {
+				watchLists[i] = smartWatchListDetails(result, smartLists[i-5])
+				continue
+			}
 			// Handle unknown watchlistId case, if needed
 			loggerconfig.Error("Alert Severity:P1-High, platform:", reqH.Platform, " FetchWatchListsV3 Invalid watchlistId: ", result.WatchListId, " ReqID:", reqH.RequestId, " clientVersion:", reqH.ClientVersion)
 		}
 
 	}
 
+	arrangeWatchLists(watchLists, req, reqH)
+
 	resp.WatchLists = watchLists
 	loggerconfig.Info("FetchWatchListsV3 Successful, response:", helpers.LogStructAsJSON(resp), "clientID: ", req.ClientId, " requestId:", reqH.RequestId, " deviceType: ", reqH.DeviceType, " clientVersion:", reqH.ClientVersion)
 	apiRes.Data = resp
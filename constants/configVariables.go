@@ -70,6 +70,16 @@ var (
 
 	AdminSecretKey string
 
+	// AdminSuperUsers are the admins without a role given superAdmin at
+	// startup, every other admin without one gets supportReadOnly
+	AdminSuperUsers []string
+
+	RateLimitEnabled  bool
+	RateLimitAllowIps []string
+
+	// TrustedProxies are the load balancers whose X-Forwarded-For is believed
+	TrustedProxies []string
+
 	TokenCacheTime int
 
 	LedgerS3FolderName             string
@@ -80,6 +90,9 @@ var (
 	CommodityTradebookS3FolderName string
 	FnoTradebookS3FolderName       string
 	DpChargesS3FolderName          string
+	StockCompareS3FolderName       string
+	WatchListS3FolderName          string
+	WatchListShareBaseUrl          string
 
 	RedisUrl      string
 	OrderRedisUrl string
@@ -14,6 +14,20 @@ const (
 	WATCHLISTSCOLLECTION         = "watchLists"
 	WATCHLISTSTOCKSCOLLECTION    = "dummy"
 	WATCHLISTSTOCKSCOLLECTIONNEW = "watchlistsStocks"
+	WATCHLISTSHARESCOLLECTION    = "watchlistShares"
+	SMARTWATCHLISTSCOLLECTION    = "smartWatchlists"
+	IPOAPPLICATIONSCOLLECTION    = "ipoApplications"
+	IPOSUBSCRIPTIONSCOLLECTION   = "ipoSubscriptions"
+	IPOLISTINGCOLLECTION         = "ipoListingAnalytics"
+	PAYOUTMISMATCHCOLLECTION     = "payoutReconciliation"
+	REPORTJOBSCOLLECTION         = "reportJobs"
+	STATEMENTSUBSCRIPTIONSCOLL   = "statementSubscriptions"
+	STATEMENTRUNSCOLLECTION      = "statementRuns"
+	CLIENTSESSIONSCOLLECTION     = "clientSessions"
+	APPACTIVITYCOLLECTION        = "appActivity"
+	AUTHEVENTSCOLLECTION         = "authEvents"
+	AUTHSECURITYCOLLECTION       = "authSecurity"
+	ADMINAUDITCOLLECTION         = "adminAudit"
 	PINS                         = "pins"
 	IDLOGPARAM                   = "id"
 	EMAILUSERIDMAPPINGCOLLECTION = "emailUserIds"
@@ -38,6 +52,7 @@ const (
 	APPDETAILS                   = "App-Details"
 	AccountFreezeStatus          = "account-freeze-status"
 	INSTRUMENTS_COLLECTION       = "instruments"
+	SCREENERS                    = "screeners"
 
 	EQUITY    = "equity"
 	CURRENCY  = "currency"
@@ -195,7 +210,9 @@ const (
 	FetchProfileUrlTL = "/api/v1/user/profile"
 	FundsUpdateUrl    = "/api/v1/backoffice/funds/transactions"
 	Debit             = "DEBIT"
+	Credit            = "CREDIT"
 	Payout            = "PAYOUT"
+	PayinTransaction  = "PAYIN"
 	Success           = "SUCCESS"
 	Started           = "STARTED"
 	CalculateWB       = "calculateWB"
@@ -230,9 +247,10 @@ const (
 
 // Health Components Consts
 const (
-	REDIS    = "redis/cache"
-	MONGO    = "mongo"
-	POSTGRES = "postgres"
+	REDIS         = "redis/cache"
+	MONGO         = "mongo"
+	POSTGRES      = "postgres"
+	SEARCHBACKEND = "searchBackend"
 )
 
 const (
@@ -607,6 +625,7 @@ const (
 
 const (
 	LocalEnv = "local"
+	DevEnv   = "dev"
 )
 
 const (
@@ -659,6 +678,10 @@ const (
 	KeyFnoTradebookReport       = "PKTFLFnoTradebook"
 	KeyDpChargesReport          = "PKTFLDpCharges"
 	KeyHoldingFinancialReport   = "PKTFLHoldingFinancial"
+	KeyFnoPnlReport             = "PKTFLFnoPnl"
+	KeyIpoApplicationStatus     = "PKTFLIpoApplicationStatus"
+	KeyFundsPayinCredit         = "PKTFLFundsPayinCredit"
+	KeyAuthSecurityAlert        = "PKTFLAuthSecurityAlert"
 )
 
 const (
@@ -687,6 +710,19 @@ const (
 	FnoTradebookReportName       = "Tradebook & Charges for F&O"
 	HoldingReportName            = "Holding Statement"
 	DPChargesReportName          = "DP Charges Statement"
+	LedgerReportName             = "Ledger Statement"
+	TradebookReportName          = "Tradebook & Charges for Equity"
+	OpenPositionReportName       = "Open Position Statement"
+	FnoPnlReportName             = "F&O Profit & Loss Statement"
+)
+
+// Report Format Constants
+const (
+	ReportFormatXlsx          = "xlsx"
+	ReportFormatCsv           = "csv"
+	ReportFormatPdf           = "pdf"
+	ReportBrandName           = "Pocketful"
+	ReportDownloadExpiryHours = 24
 )
 
 var Env string
@@ -702,7 +738,9 @@ const (
 	OtpLen          = 4
 )
 
-var LocationKolkata *time.Location
+// LocationKolkata is replaced with the Asia/Kolkata zone from the tz database
+// at startup
+var LocationKolkata = time.FixedZone("IST", 5*60*60+30*60)
 
 const (
 	ASIAKOLKATA = "Asia/Kolkata"
@@ -717,6 +755,17 @@ const (
 	Charts = "/api/v1/charts"
 )
 
+const (
+	CandleCacheKeyPrefix    = "candles|"
+	IndicatorCacheKeyPrefix = "indicators|"
+	CandleCacheMetricsKey   = "candlecache|metrics|"
+	CandleCacheTTL          = 1440 // 24 hours, in minutes
+	CandleTimestampLayout   = "2006-01-02T15:04:05-0700"
+	CandleCacheHit          = "hit"
+	CandleCacheMiss         = "miss"
+	CandleCachePartial      = "partial"
+)
+
 //secret update
 
 const (
@@ -934,3 +983,496 @@ var ChartDataPeriod = []string{"1D", "1W", "1M", "6M", "1Y", "3Y", "5Y"}
 const (
 	ContractSearchPageLimit = 20 // Default number of results per page for contract search API
 )
+
+// Custom Screener Constants
+const (
+	ScreenerUniverseKey     = "screener|universe"
+	ScreenerUniverseTTL     = 60 // minutes, technicals are refreshed by the nightly batch
+	ScreenerTechnicalsFreq  = "daily"
+	ScreenerCapacity        = 20
+	ScreenerDefaultPageSize = 20
+	ScreenerMaxPageSize     = 100
+	ScreenerDefaultSortBy   = "mcap"
+	ScreenerSortAsc         = "asc"
+	ScreenerSortDesc        = "desc"
+)
+
+// Backtest Constants
+const (
+	BacktestCrossesAbove    = "crosses_above"
+	BacktestCrossesBelow    = "crosses_below"
+	BacktestSizeFixedQty    = "fixed_qty"
+	BacktestSizeFixedAmount = "fixed_capital"
+	BacktestSizeEquityPct   = "equity_pct"
+	BacktestExitStopLoss    = "stop_loss"
+	BacktestExitTarget      = "target"
+	BacktestExitSignal      = "exit_signal"
+	BacktestExitEndOfData   = "end_of_data"
+	BacktestExitSquareOff   = "square_off"
+	BacktestMaxRules        = 10
+)
+
+// Sector Heatmap Constants
+const (
+	SectorHeatmapKey          = "sector|heatmap"
+	SectorHeatmapTTL          = 1 // minutes
+	SectorHeatmapLookbackDays = 400
+	SectorHeatmapWorkers      = 16
+	SectorHeatmapTopMovers    = 3
+	SectorHeatmapBreadthNSE   = "NSE"
+)
+
+// Stock Compare Constants
+const (
+	CompareGroupValuation     = "valuation"
+	CompareGroupProfitability = "profitability"
+	CompareGroupLeverage      = "leverage"
+	CompareGroupGrowth        = "growth"
+	CompareExportExpiryHours  = 24
+	CashFlowFromOperations    = "Cash Flow from Operating Activities"
+	TotalCurrentAssets        = "Total Current Assets"
+	FinanceCosts              = "Finance Costs"
+	Taxation                  = "Taxation"
+)
+
+// Search Ranking Constants
+const (
+	SearchAffinityKey        = "search|affinity|"
+	SearchAffinityTTL        = 15 // minutes, holdings and watchlists rarely change mid session
+	SearchRecentKey          = "search|recent|"
+	SearchRecentTTL          = 30 * 24 * 60 // minutes
+	SearchRecentMaxQueries   = 20
+	SearchRecentMaxClicks    = 50
+	SearchRecentHalfLifeDays = 7
+	SearchFnoLookaheadMonths = 3
+	SearchWeightRelevance    = 0.5
+	SearchWeightExactSymbol  = 2.0
+	SearchWeightVolume       = 1.0
+	SearchWeightHolding      = 1.5
+	SearchWeightPosition     = 1.5
+	SearchWeightWatchlist    = 1.0
+	SearchWeightClick        = 1.2
+	SearchWeightRecentQuery  = 0.5
+	SearchWeightFnoContract  = 5.0
+)
+
+// SearchSynonyms maps commonly typed aliases to the exchange symbol they mean.
+var SearchSynonyms = map[string]string{
+	"RIL":       "RELIANCE",
+	"SBI":       "SBIN",
+	"HUL":       "HINDUNILVR",
+	"LANDT":     "LT",
+	"LNT":       "LT",
+	"INFOSYS":   "INFY",
+	"BAJAJFIN":  "BAJFINANCE",
+	"KOTAK":     "KOTAKBANK",
+	"AIRTEL":    "BHARTIARTL",
+	"BHARTI":    "BHARTIARTL",
+	"NIFTYBANK": "BANKNIFTY",
+	"NIFTY50":   "NIFTY",
+	"ZOMATO":    "ETERNAL",
+	"HDFC":      "HDFCBANK",
+	"ICICI":     "ICICIBANK",
+	"AXIS":      "AXISBANK",
+}
+
+// SearchBseDerivatives are the underlyings whose contracts trade on BFO.
+var SearchBseDerivatives = map[string]bool{
+	"SENSEX":   true,
+	"BANKEX":   true,
+	"SENSEX50": true,
+}
+
+// Search Failover Constants
+const (
+	SearchLocalIndexRefreshMins = 30
+	SearchFailoverCooldownSecs  = 30
+	SearchBackendRediSearch     = "redisearch"
+	SearchBackendLocal          = "local"
+)
+
+// Global Search Constants
+const (
+	GlobalSearchTypeScrip      = "scrip"
+	GlobalSearchTypePocket     = "pocket"
+	GlobalSearchTypeCollection = "collection"
+	GlobalSearchTypeIpo        = "ipo"
+	GlobalSearchTypeBond       = "bond"
+	GlobalSearchTypeSector     = "sector"
+	GlobalSearchDefaultLimit   = 5
+	GlobalSearchMaxLimit       = 20
+	GlobalSearchTopLimit       = 10
+	GlobalSearchSourceTimeout  = 1500 // milliseconds
+	GlobalSearchMinQueryLength = 2
+)
+
+// Watchlist Import/Export Constants
+const (
+	WatchListImportMaxRows      = 500
+	WatchListImportMaxFileBytes = 1 << 20
+	WatchListExportExpiryHours  = 24
+	WatchListFormatCsv          = "csv"
+	WatchListFormatXlsx         = "xlsx"
+	WatchListColumnSymbol       = "symbol"
+	WatchListColumnIsin         = "isin"
+	WatchListColumnExchange     = "exchange"
+	WatchListUnmatchedNotFound  = "not found in contract master"
+	WatchListUnmatchedNoKey     = "symbol or isin is required"
+	WatchListUnmatchedExchange  = "unsupported exchange"
+)
+
+// Smart Watchlist Constants
+const (
+	SmartWatchListRuleHoldings        = "holdings"
+	SmartWatchListRuleSector          = "sector"
+	SmartWatchListRuleIndexGainers    = "indexGainers"
+	SmartWatchListRuleUpcomingResults = "upcomingResults"
+	SmartWatchListRuleIpoListing      = "ipoListing"
+	SmartWatchListIdPrefix            = "sl"
+	SmartWatchListMaxLists            = 5
+	SmartWatchListDefaultLimit        = 20
+	SmartWatchListMaxStocks           = 50
+	SmartWatchListDefaultIndex        = "nifty_50"
+	SmartWatchListRefreshMins         = 15
+)
+
+// Watchlist Enrichment Constants
+const (
+	WatchListSortChangePct          = "changePct"
+	WatchListSortName               = "name"
+	WatchListSortCustom             = "custom"
+	WatchListSortAsc                = "asc"
+	WatchListSortDesc               = "desc"
+	WatchListGroupSector            = "sector"
+	WatchListSectorOthers           = "Others"
+	WatchListEnrichWorkers          = 8
+	WatchListEnrichLookbackDays     = 400
+	WatchListEnrichSessions52W      = 252
+	WatchListCorporateActionDays    = 90
+	WatchListCorporateActionMeeting = "boardMeeting"
+	WatchListCorporateActionSplit   = "split"
+	WatchListCorporateActionBonus   = "bonus"
+)
+
+// SmartWatchListSectorAliases maps the names clients commonly use to the
+// SectorMapping categories.
+var SmartWatchListSectorAliases = map[string]string{
+	"banking":    "Banks",
+	"bank":       "Banks",
+	"technology": "IT",
+	"pharma":     "Healthcare",
+	"auto":       "Automobile",
+}
+
+// GlobalSearchTypes is the order groups are returned in.
+var GlobalSearchTypes = []string{
+	GlobalSearchTypeScrip,
+	GlobalSearchTypeIpo,
+	GlobalSearchTypePocket,
+	GlobalSearchTypeCollection,
+	GlobalSearchTypeSector,
+	GlobalSearchTypeBond,
+}
+
+// GlobalSearchTypeWeights scale text relevance so results of different types
+// share one ordering, stocks first on an equal match.
+var GlobalSearchTypeWeights = map[string]float64{
+	GlobalSearchTypeScrip:      1,
+	GlobalSearchTypeIpo:        0.95,
+	GlobalSearchTypePocket:     0.9,
+	GlobalSearchTypeCollection: 0.85,
+	GlobalSearchTypeSector:     0.85,
+	GlobalSearchTypeBond:       0.8,
+}
+
+// IPO Application Lifecycle Constants
+const (
+	IpoApplicationSubmitted       = "submitted"
+	IpoApplicationMandatePending  = "mandatePending"
+	IpoApplicationMandateAccepted = "mandateAccepted"
+	IpoApplicationMandateRejected = "mandateRejected"
+	IpoApplicationAllotted        = "allotted"
+	IpoApplicationNotAllotted     = "notAllotted"
+	IpoApplicationRefunded        = "refunded"
+	IpoApplicationListed          = "listed"
+	IpoApplicationCancelled       = "cancelled"
+
+	IpoApplicationPollMins         = 15
+	IpoApplicationSessionHours     = 12
+	IpoApplicationSessionKeyPrefix = "ipoApplicationSession:"
+)
+
+// IpoApplicationTransitions lists the states an application may move to from
+// each state. States missing from the map are terminal.
+var IpoApplicationTransitions = map[string][]string{
+	IpoApplicationSubmitted:       {IpoApplicationMandatePending, IpoApplicationMandateAccepted, IpoApplicationMandateRejected, IpoApplicationCancelled},
+	IpoApplicationMandatePending:  {IpoApplicationMandateAccepted, IpoApplicationMandateRejected, IpoApplicationCancelled},
+	IpoApplicationMandateAccepted: {IpoApplicationAllotted, IpoApplicationNotAllotted, IpoApplicationCancelled},
+	IpoApplicationAllotted:        {IpoApplicationListed},
+	IpoApplicationNotAllotted:     {IpoApplicationRefunded},
+}
+
+// IPO Subscription Constants
+const (
+	IpoSubscriptionSnapshotMins = 30
+
+	IpoCategoryQib      = "qib"
+	IpoCategoryNii      = "nii"
+	IpoCategoryRii      = "rii"
+	IpoCategoryEmployee = "employee"
+	IpoCategoryTotal    = "total"
+	IpoSeriesGmp        = "gmp"
+)
+
+// IpoSubscriptionCategories is the order category series are returned in.
+var IpoSubscriptionCategories = []string{
+	IpoCategoryQib,
+	IpoCategoryNii,
+	IpoCategoryRii,
+	IpoCategoryEmployee,
+	IpoCategoryTotal,
+}
+
+// Payout And Payin State Machine Constants
+const (
+	TransactionSuccess = "SUCCESS"
+	TransactionFailed  = "FAILED"
+
+	PayoutReconcileMins  = 30
+	PayoutReconcileDays  = 3
+	PayoutStuckHours     = 48
+	PayoutReconcileActor = "reconciliation"
+
+	PayoutMismatchTradelabMissing      = "tradelabMissing"
+	PayoutMismatchTradelabUnexpected   = "tradelabUnexpected"
+	PayoutMismatchBackofficeMissing    = "backofficeMissing"
+	PayoutMismatchBackofficeUnexpected = "backofficeUnexpected"
+	PayoutMismatchStuck                = "stuck"
+)
+
+// PayoutTransitions lists the statuses a payout may move to from each status.
+// PROCEED is written by the payout batch once the bank transfer is initiated
+// and can no longer be cancelled. Statuses missing from the map are terminal.
+var PayoutTransitions = map[string][]string{
+	Pending: {PROCESS, CANCELLED, TransactionFailed},
+	PROCESS: {PROCEED, TransactionSuccess, TransactionFailed, CANCELLED},
+	PROCEED: {TransactionSuccess, TransactionFailed},
+}
+
+// PayinTransitions lists the statuses a payin may move to. A payin is created
+// PENDING when the collect request or intent is raised and the gateway
+// webhook settles it.
+var PayinTransitions = map[string][]string{
+	Pending: {TransactionSuccess, TransactionFailed},
+}
+
+// TransactionTransitions holds the state machine for each transaction_type.
+var TransactionTransitions = map[string]map[string][]string{
+	Payout:           PayoutTransitions,
+	PayinTransaction: PayinTransitions,
+}
+
+// UPI Payin Constants
+const (
+	UpiModeCollect       = "UPI_COLLECT"
+	UpiModeIntent        = "UPI_INTENT"
+	UpiCollectExpiryMins = 10
+	UpiPayinMaxAmount    = 100000
+	UpiGatewayFake       = "fake"
+	FakeUpiVpa           = "pocketful@fakeupi"
+	UpiSignatureHeader   = "X-Upi-Signature"
+)
+
+// UpiGateway names the payment gateway payins are raised with, set from config.
+var UpiGateway string
+
+// TradelabReconcileToken is the service login the payout reconciliation job
+// reads Tradelab transactions with, set from config.
+var TradelabReconcileToken string
+
+// FakeUpiSecret signs the fake gateway's webhooks in local and dev, set from config.
+var FakeUpiSecret string
+
+// Report Job Constants
+const (
+	ReportJobPending          = "PENDING"
+	ReportJobRunning          = "RUNNING"
+	ReportJobCompleted        = "COMPLETED"
+	ReportJobFailed           = "FAILED"
+	ReportJobDeliveryDownload = "download"
+	ReportJobDeliveryEmail    = "email"
+	ReportJobExpiryHours      = 12
+	ReportJobWorkers          = 4
+	ReportJobPollSeconds      = 5
+	ReportJobStaleMins        = 10
+	ReportJobMaxAttempts      = 3
+	ReportJobProgressStarted  = 10
+	ReportJobProgressDone     = 100
+
+	ReportLedger             = "ledger"
+	ReportTradebook          = "tradebook"
+	ReportOpenPosition       = "openPosition"
+	ReportFnoPnl             = "fnoPnl"
+	ReportHoldingFinancial   = "holdingFinancial"
+	ReportDPCharges          = "dpCharges"
+	ReportCommodityTradebook = "commodityTradebook"
+	ReportFnoTradebook       = "fnoTradebook"
+)
+
+// Statement Subscription Constants
+const (
+	StatementMonthlyLedger     = "monthlyLedger"
+	StatementQuarterlyHoldings = "quarterlyHoldings"
+	StatementYearlyPnl         = "yearlyPnl"
+
+	StatementRunRunning = "RUNNING"
+	StatementRunSent    = "SENT"
+	StatementRunFailed  = "FAILED"
+
+	// statements go out from this hour IST on the first business day after the period
+	StatementSendHour     = 7
+	StatementPollMins     = 30
+	StatementMaxAttempts  = 3
+	StatementRunStaleMins = 30
+	StatementRunHistory   = 12
+)
+
+// Client Session Constants
+const (
+	SessionActive  = "ACTIVE"
+	SessionRevoked = "REVOKED"
+
+	SessionLoggedOut            = "logout"
+	SessionRevokedUser          = "revokedByUser"
+	SessionRevokedOtherDevices  = "logoutOtherDevices"
+	SessionRevokedPasswordReset = "passwordReset"
+	SessionRevokedTwoFaReset    = "twoFaReset"
+
+	// marks a revoked session in redis until its token expires, the marker
+	// becomes RevokedSessionEnded once the token is ended at tradelab
+	RevokedSessionKey      = "revokedSession_"
+	RevokedSessionEnded    = "ended"
+	RevokedSessionMinMins  = 1
+	ClientSessionListLimit = 50
+
+	// a session is written back at most once per SessionSeenMins
+	SessionSeenKey  = "sessionSeen_"
+	SessionSeenMins = 5
+)
+
+// OAuth App Scope Constants
+const (
+	ScopeOrdersRead    = "orders:read"
+	ScopeOrdersWrite   = "orders:write"
+	ScopePortfolioRead = "portfolio:read"
+	ScopeFundsRead     = "funds:read"
+	ScopeFundsWrite    = "funds:write"
+	ScopeReportsRead   = "reports:read"
+	ScopeProfileRead   = "profile:read"
+	ScopeMarketRead    = "market:read"
+
+	// cached app of an access token, "-" when the token is not an app's
+	AppTokenKey      = "appToken_"
+	AppTokenCacheMin = 10
+	AppNotAnApp      = "-"
+
+	AppQuotaKey        = "appQuota_"
+	AppDefaultQuotaMin = 60
+	AppMaxQuotaMin     = 600
+
+	AppActivityLimit = 100
+)
+
+// Rate Limit Constants
+const (
+	RateLimitKey = "rateLimit_"
+)
+
+// Auth Audit Constants
+const (
+	AuthEventLoginByPass      = "loginByPass"
+	AuthEventLoginByEmail     = "loginByEmail"
+	AuthEventLoginV2          = "loginV2"
+	AuthEventLoginByEmailOtp  = "loginByEmailOtp"
+	AuthEventValidateTwoFa    = "validateTwoFa"
+	AuthEventValidateTwofaV2  = "validateTwofaV2"
+	AuthEventValidateLoginOtp = "validateLoginOtp"
+	AuthEventSetTwoFaPin      = "setTwoFaPin"
+	AuthEventSetupTotp        = "setupTotp"
+	AuthEventChooseTwofa      = "chooseTwofa"
+	AuthEventForgetTotp       = "forgetTotp"
+	AuthEventForgetResetTwoFa = "forgetResetTwoFa"
+	AuthEventForgetPassword   = "forgetPassword"
+	AuthEventSetPassword      = "setPassword"
+	AuthEventSetupBiometric   = "setupBiometric"
+	AuthEventDisableBiometric = "disableBiometric"
+	AuthEventQRWebLogin       = "qrWebLogin"
+	AuthEventUnblockUser      = "unblockUser"
+
+	AuthOutcomeSuccess = "SUCCESS"
+	AuthOutcomeFailure = "FAILURE"
+	AuthOutcomeBlocked = "BLOCKED"
+
+	AuthRuleFailedTwoFa      = "failedTwoFa"
+	AuthRuleNewDevice        = "newDevice"
+	AuthRuleImpossibleTravel = "impossibleTravel"
+
+	AuthActionLock   = "lock"
+	AuthActionStepUp = "stepUp"
+	AuthActionNotify = "notify"
+
+	// a client is locked after this many failed 2FA attempts within the window
+	AuthFailedTwoFaLimit       = 5
+	AuthFailedTwoFaWindowMins  = 15
+	AuthFailedTwoFaLockMins    = 30
+	AuthTravelWindowMins       = 60
+	AuthKnownDeviceLookbackDay = 90
+	AuthEventHistoryLimit      = 200
+	// a token withheld for step-up stays blocked for longer than any token lives
+	AuthStepUpBlockMins = 24 * 60
+)
+
+// Admin Access Constants
+const (
+	AdminRoleSuper           = "superAdmin"
+	AdminRolePocketEditor    = "pocketEditor"
+	AdminRolePocketPublisher = "pocketPublisher"
+	AdminRoleContentAdmin    = "contentAdmin"
+	AdminRoleSupportReadOnly = "supportReadOnly"
+
+	AdminPermPocketsRead      = "pockets:read"
+	AdminPermPocketsEdit      = "pockets:edit"
+	AdminPermPocketsPublish   = "pockets:publish"
+	AdminPermCollectionsRead  = "collections:read"
+	AdminPermCollectionsWrite = "collections:write"
+	AdminPermBlockDealsWrite  = "blockDeals:write"
+	AdminPermFundsRead        = "funds:read"
+	AdminPermFundsWrite       = "funds:write"
+	AdminPermClientSupport    = "clients:support"
+	AdminPermClientWrite      = "clients:write"
+	AdminPermAdminsManage     = "admins:manage"
+
+	AdminActionCreate = "create"
+	AdminActionModify = "modify"
+	AdminActionDelete = "delete"
+
+	AdminEntityPocket     = "pocket"
+	AdminEntityCollection = "collection"
+	AdminEntityBlockDeal  = "blockDeal"
+	AdminEntityAdmin      = "admin"
+
+	AdminAuditHistoryLimit = 200
+
+	// AdminRoleBootstrap is recorded as the admin behind the roles given at startup
+	AdminRoleBootstrap = "roleBootstrap"
+)
+
+// Block Deals Constants
+const (
+	BlockDealsDefaultPageSize  = 50
+	BlockDealsMaxPageSize      = 500
+	BlockDealsBulkMaxRows      = 10000
+	BlockDealsBulkMaxFileBytes = 10 << 20
+	BlockDealsFormatCsv        = "csv"
+	BlockDealsFormatJson       = "json"
+)
@@ -66,6 +66,38 @@ const (
 	AppDoesNotExists             = "P11076"
 	LotSizeExceeds               = "P11077"
 	InvalidPage                  = "P11078"
+	InvalidScreenerQuery         = "P11079"
+	ScreenerDoesNotExists        = "P11080"
+	ScreenerCapacityFull         = "P11081"
+	InvalidBacktestStrategy      = "P11082"
+	InvalidSearchType            = "P11083"
+	InvalidWatchListFile         = "P11084"
+	SharedWatchListNotFound      = "P11085"
+	InvalidSmartWatchListRule    = "P11086"
+	SmartWatchListNotFound       = "P11087"
+	SmartWatchListLimitReached   = "P11088"
+	InvalidTransactionTransition = "P11089"
+	TransactionNotFound          = "P11090"
+	UpiGatewayUnavailable        = "P11091"
+	InvalidWebhookSignature      = "P11092"
+	InvalidPayinAmount           = "P11093"
+	ReportJobNotFound            = "P11094"
+	ReportDeliveryUnsupported    = "P11095"
+	StatementNotSubscribed       = "P11096"
+	StatementEmailMissing        = "P11097"
+	SessionNotFound              = "P11098"
+	SessionRevokedError          = "P11099"
+	InvalidAppScope              = "P11100"
+	AppScopeMissing              = "P11101"
+	AppQuotaExceeded             = "P11102"
+	TooManyRequests              = "P11103"
+	AuthAccountLocked            = "P11104"
+	AuthStepUpRequired           = "P11105"
+	AdminPermissionDenied        = "P11106"
+	AdminDisabled                = "P11107"
+	AdminAlreadyExists           = "P11108"
+	AdminDoesNotExists           = "P11109"
+	InvalidAdminRole             = "P11110"
 )
 
 // Errors Code Map
@@ -134,6 +166,38 @@ var ErrorCodeMap = map[string]string{
 	"P11076": "App Does Not Exists",
 	"P11077": "Lot Size exceeds the available quantity",
 	"P11078": "Invalid Page",
+	"P11079": "Invalid Screener Query",
+	"P11080": "Screener Does Not Exists",
+	"P11081": "Screener Capacity Full",
+	"P11082": "Invalid Backtest Strategy",
+	"P11083": "Invalid Search Type",
+	"P11084": "Invalid WatchList File",
+	"P11085": "Shared WatchList Not Found",
+	"P11086": "Invalid Smart WatchList Rule",
+	"P11087": "Smart WatchList Not Found",
+	"P11088": "Smart WatchList Limit Reached",
+	"P11089": "Invalid Transaction Status Transition",
+	"P11090": "Transaction Not Found",
+	"P11091": "UPI Gateway Unavailable",
+	"P11092": "Invalid Webhook Signature",
+	"P11093": "Invalid Payin Amount",
+	"P11094": "Report Job Not Found",
+	"P11095": "Delivery Not Supported For This Report",
+	"P11096": "Not Subscribed To This Statement",
+	"P11097": "Email Id Not Available For Statements",
+	"P11098": "Session Not Found",
+	"P11099": "Session Has Been Logged Out, Please Login Again",
+	"P11100": "Invalid App Scope",
+	"P11101": "App Is Not Permitted To Access This Api",
+	"P11102": "App Request Quota Exceeded, Please Try Again Later",
+	"P11103": "Too Many Requests, Please Try Again Later",
+	"P11104": "Account Locked Due To Suspicious Activity, Please Unblock Your Account Using PAN",
+	"P11105": "Unusual Login Detected, Please Login With The OTP Sent To Your Email",
+	"P11106": "Admin Is Not Permitted To Access This Api",
+	"P11107": "Admin Account Is Disabled",
+	"P11108": "Admin Already Exists",
+	"P11109": "Admin Does Not Exist",
+	"P11110": "Invalid Admin Role",
 }
 
 const (
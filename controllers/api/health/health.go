@@ -4,6 +4,7 @@ import (
 	"net/http"
 	apihelpers "space/apiHelpers"
 	"space/business/health"
+	"space/constants"
 	"space/helpers"
 	"space/loggerconfig"
 	"space/models"
@@ -15,9 +16,11 @@ func GetHealthStatus(ctx *gin.Context) {
 
 	res := apihelpers.APIRes{}
 	resp := health.GetHealthInfo()
+	searchBackend := health.GetSearchBackendInfo()
 	if len(resp) == 0 {
 		pong := models.Pong{
-			DT: helpers.GetCurrentTimeInIST(),
+			DT:            helpers.GetCurrentTimeInIST(),
+			SearchBackend: &searchBackend,
 		}
 
 		res.Status = true
@@ -31,6 +34,7 @@ func GetHealthStatus(ctx *gin.Context) {
 
 	// utils.PrintlnLog(constants.ERROR, "health check api error : ", resp)
 	loggerconfig.Error("health (controller), check api error : ", resp)
+	resp[constants.SEARCHBACKEND] = searchBackend.Backend
 	res.Status = false
 	res.Message = "FAILURE"
 	res.Data = resp
@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"encoding/json"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+var adminsProvider models.AdminsProvider
+
+func InitAdminsProvider(provider models.AdminsProvider) {
+	defer models.HandlePanic()
+	adminsProvider = provider
+}
+
+// CreateAdmin
+// @Tags space admin users V1
+// @Description CreateAdmin - Add a named admin with a role, superAdmin only
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.CreateAdminRequest true "admin"
+// @Success 200 {object} apihelpers.APIRes{data=models.AdminDetails}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/adminapis/createAdmin [POST]
+func CreateAdmin(c *gin.Context) {
+	var reqParams models.CreateAdminRequest
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("CreateAdmin (controller), error decoding body, error:", err, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(reqParams); err != nil {
+		loggerconfig.Error("CreateAdmin (controller), error validating struct:", err, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	maskedReq, err := maskObj.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("CreateAdmin (controller), error masking request:", err, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		return
+	}
+	loggerconfig.Info("CreateAdmin (controller), reqParams:", helpers.LogStructAsJSON(maskedReq), " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+
+	code, resp := adminsProvider.CreateAdmin(reqParams, reqH)
+
+	logDetail := "adminUserId: " + reqH.AdminUserId + " function: CreateAdmin requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// UpdateAdmin
+// @Tags space admin users V1
+// @Description UpdateAdmin - Change the name, role or password of an admin or disable them, superAdmin only
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.UpdateAdminRequest true "admin"
+// @Success 200 {object} apihelpers.APIRes{data=models.AdminDetails}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/adminapis/updateAdmin [POST]
+func UpdateAdmin(c *gin.Context) {
+	var reqParams models.UpdateAdminRequest
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("UpdateAdmin (controller), error decoding body, error:", err, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(reqParams); err != nil {
+		loggerconfig.Error("UpdateAdmin (controller), error validating struct:", err, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	maskedReq, err := maskObj.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("UpdateAdmin (controller), error masking request:", err, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		return
+	}
+	loggerconfig.Info("UpdateAdmin (controller), reqParams:", helpers.LogStructAsJSON(maskedReq), " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+
+	code, resp := adminsProvider.UpdateAdmin(reqParams, reqH)
+
+	logDetail := "adminUserId: " + reqH.AdminUserId + " function: UpdateAdmin requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// FetchAdmins
+// @Tags space admin users V1
+// @Description FetchAdmins - All admins with their roles, superAdmin only
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Success 200 {object} apihelpers.APIRes{data=models.FetchAdminsResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/adminapis/fetchAdmins [GET]
+func FetchAdmins(c *gin.Context) {
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	code, resp := adminsProvider.FetchAdmins(reqH)
+
+	logDetail := "adminUserId: " + reqH.AdminUserId + " function: FetchAdmins requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// FetchAdminAudit
+// @Tags space admin users V1
+// @Description FetchAdminAudit - Latest changes made through the admin apis with their before and after state, superAdmin only
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.FetchAdminAuditRequest true "filters"
+// @Success 200 {object} apihelpers.APIRes{data=models.FetchAdminAuditResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/adminapis/fetchAdminAudit [POST]
+func FetchAdminAudit(c *gin.Context) {
+	var reqParams models.FetchAdminAuditRequest
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("FetchAdminAudit (controller), error decoding body, error:", err, " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+	loggerconfig.Info("FetchAdminAudit (controller), reqParams:", helpers.LogStructAsJSON(reqParams), " adminUserId:", reqH.AdminUserId, " requestId:", reqH.RequestId)
+
+	code, resp := adminsProvider.FetchAdminAudit(reqParams, reqH)
+
+	logDetail := "adminUserId: " + reqH.AdminUserId + " function: FetchAdminAudit requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
@@ -1,11 +1,16 @@
 package v1
 
 import (
+	"io"
 	"net/http"
+	"space/constants"
+	"space/loggerconfig"
 	"space/models"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
 var blockdealsobj models.BlockDealService
@@ -14,8 +19,21 @@ func NewBlockDealController(blockDealService models.BlockDealService) {
 	blockdealsobj = blockDealService
 }
 
+// GetAllBlockDeals returns a page of block deals, latest first, filtered by
+// the fromDate, toDate, cocode, client, buySell and minValue query params
 func GetAllBlockDeals(c *gin.Context) {
-	blockDeals, err := blockdealsobj.GetAllBlockDeals(c)
+	var filter models.BlockDealFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query params"})
+		return
+	}
+	filter.BuySell = strings.ToUpper(filter.BuySell)
+	if err := validator.New().Struct(filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	blockDeals, err := blockdealsobj.GetAllBlockDeals(c, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -29,6 +47,10 @@ func CreateBlockDeal(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := blockdealsobj.ValidateBlockDeal(&blockDeal); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := blockdealsobj.CreateBlockDeal(c, blockDeal); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -72,7 +94,12 @@ func UpdateBlockDeal(c *gin.Context) {
 	}
 
 	updatedBlockDeal.Cocode = cocode
-	err = blockdealsobj.UpdateBlockDeal(cocode, updatedBlockDeal)
+	if err := blockdealsobj.ValidateBlockDeal(&updatedBlockDeal); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = blockdealsobj.UpdateBlockDeal(c, cocode, updatedBlockDeal)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update block deal"})
 		return
@@ -98,3 +125,51 @@ func DeleteBlockDeal(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Block deal deleted successfully"})
 }
+
+// BulkUpsertBlockDeals loads the deals of a csv or json file, sent as the
+// file form field or as the request body, updating the deals already stored
+func BulkUpsertBlockDeals(c *gin.Context) {
+	var req models.BulkBlockDealsRequest
+
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		if fileHeader.Size > constants.BlockDealsBulkMaxFileBytes {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File too large"})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+			return
+		}
+		defer file.Close()
+		req.FileName = fileHeader.Filename
+		req.Content, err = io.ReadAll(file)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+			return
+		}
+	} else {
+		req.FileName = "body." + constants.BlockDealsFormatJson
+		if strings.Contains(c.ContentType(), constants.BlockDealsFormatCsv) {
+			req.FileName = "body." + constants.BlockDealsFormatCsv
+		}
+		req.Content, err = io.ReadAll(io.LimitReader(c.Request.Body, constants.BlockDealsBulkMaxFileBytes+1))
+		if err != nil || len(req.Content) > constants.BlockDealsBulkMaxFileBytes {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	res, err := blockdealsobj.BulkUpsertBlockDeals(c, req)
+	if err != nil {
+		loggerconfig.Error("BulkUpsertBlockDeals (controller), error loading deals:", err, " fileName:", req.FileName)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load block deals"})
+		return
+	}
+	if len(res.Errors) > 0 {
+		c.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -34,10 +34,8 @@ func InitCollectionProvider(provider models.CollectionsProvider) {
 func CreateCollections(c *gin.Context) {
 	var CollectionsReq models.CreateCollectionsRequest
 
-	var reqH models.ReqHeader
-	if err := c.ShouldBindHeader(&reqH); err != nil {
-		loggerconfig.Error("CreateCollections (controller), error parsing header, error:", err, "clientID: ", reqH.ClientId)
-	}
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
 
 	err := json.NewDecoder(c.Request.Body).Decode(&CollectionsReq)
 	if err != nil {
@@ -70,10 +68,8 @@ func CreateCollections(c *gin.Context) {
 func ModifyCollections(c *gin.Context) {
 	var CollectionsReq models.ModifyCollectionsRequest
 
-	var reqH models.ReqHeader
-	if err := c.ShouldBindHeader(&reqH); err != nil {
-		loggerconfig.Error("ModifyCollections (controller), error parsing header, error:", err, "clientID: ", reqH.ClientId)
-	}
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
 
 	err := json.NewDecoder(c.Request.Body).Decode(&CollectionsReq)
 	if err != nil {
@@ -180,10 +176,8 @@ func FetchCollectionsUser(c *gin.Context) {
 func DeleteCollections(c *gin.Context) {
 	var CollectionsReq models.DeleteCollectionsRequest
 
-	var reqH models.ReqHeader
-	if err := c.ShouldBindHeader(&reqH); err != nil {
-		loggerconfig.Error("DeleteCollections (controller), error parsing header, error:", err, "clientID: ", reqH.ClientId)
-	}
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
 
 	err := json.NewDecoder(c.Request.Body).Decode(&CollectionsReq)
 	if err != nil {
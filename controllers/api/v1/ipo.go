@@ -248,6 +248,64 @@ func CancelIpoOrder(c *gin.Context) {
 	apihelpers.CustomResponse(c, code, resp, logDetail)
 }
 
+// FetchIpoHistory
+// @Tags space ipo V1
+// @Description Fetch Ipo History - It will give the client's IPO applications with their status changes
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param P-ClientVersion  header string false "P-ClientVersion Header"
+// @Param request body models.FetchIpoHistoryRequest true "ipo"
+// @Success 200 {object} apihelpers.APIRes{data=models.FetchIpoHistoryResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/tradeipo/fetchIpoHistory [POST]
+func FetchIpoHistory(c *gin.Context) {
+	var reqParams models.FetchIpoHistoryRequest
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("FetchIpoHistory (controller), error decoding body, error:", err)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("FetchIpoHistory (controller), Empty Device Type requestId: ", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("FetchIpoHistory (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(reqParams.ClientID, requestH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("FetchIpoHistory (controller) CheckAuthWithClient invalid authtoken", " clientId: ", reqParams.ClientID, " requestId:", requestH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("FetchIpoHistory (controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", reqParams.ClientID, " requestId:", requestH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	loggerconfig.Info("FetchIpoHistory (controller), reqParams:", helpers.LogStructAsJSON(reqParams), " uccId: ", reqParams.ClientID, "requestId:", requestH.RequestId)
+	code, resp := theIpoProvider.FetchIpoHistory(reqParams, requestH)
+	logDetail := "clientId: " + reqParams.ClientID + " function: FetchIpoHistory requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
 // FetchIpoData
 // @Tags space ipo V1
 // @Description Fetch Ipo Data - It will give details of IPO from ipo name
@@ -344,6 +402,55 @@ func FetchIpoGmpData(c *gin.Context) {
 	apihelpers.CustomResponse(c, code, resp, logDetail)
 }
 
+// FetchIpoSubscriptionHistory
+// @Tags space ipo V1
+// @Description Fetch Ipo Subscription History - It provides category wise subscription and GMP over the bid window and the listing gain
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param P-ClientType header string false "P-ClientType Header"
+// @Param P-ClientVersion  header string false "P-ClientVersion Header"
+// @Param request body models.FetchIpoSubscriptionHistoryRequest true "ipo"
+// @Success 200 {object} apihelpers.APIRes{data=models.FetchIpoSubscriptionHistoryResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/tradeipo/fetchIpoSubscriptionHistory [POST]
+func FetchIpoSubscriptionHistory(c *gin.Context) {
+	var reqParams models.FetchIpoSubscriptionHistoryRequest
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("FetchIpoSubscriptionHistory (controller), error decoding body, error:", err)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("FetchIpoSubscriptionHistory (controller), Empty Device Type requestId: ", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	reqParams.Symbol = strings.ToUpper(strings.TrimSpace(reqParams.Symbol))
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("FetchIpoSubscriptionHistory (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("FetchIpoSubscriptionHistory (controller), reqParams:", helpers.LogStructAsJSON(reqParams), "requestId:", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+	code, resp := theIpoProvider.FetchIpoSubscriptionHistory(reqParams, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: FetchIpoSubscriptionHistory requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
 // FetchEIpo
 // @Tags space ipo V1
 // @Description Fetch EIpo Data - fetch ipo-current-issue, ipo-closed, ipo-upcoming
@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"net/http"
+
+	"space/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FetchJwks
+// @Tags space auth V1
+// @Description FetchJwks - Public keys guest and admin tokens are signed with, for services verifying them
+// @Success 200 {object} models.Jwks
+// @Router /api/space/.well-known/jwks.json [GET]
+func FetchJwks(c *gin.Context) {
+	// verifiers refetch on an unknown kid, so a short cache is enough
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, helpers.FetchJwks())
+}
@@ -720,6 +720,44 @@ func DeleteApp(c *gin.Context) {
 	apihelpers.CustomResponse(c, code, resp, logDetail)
 }
 
+// FetchAppActivity
+// @Tags space Oauth2 V1
+// @Description FetchAppActivity - Recent calls made with the access tokens of an app
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param appId path string true "App ID"
+// @Success 200 {object} apihelpers.APIRes{data=models.AppActivityRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/authapis/appActivity/{appId} [GET]
+func FetchAppActivity(c *gin.Context) {
+	appId := c.Param("appId")
+
+	if appId == "" {
+		loggerconfig.Error("FetchAppActivity (controller), error parsing the path params in Get request, not found error!")
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("FetchAppActivity (controller), Empty Device Type requestId: ", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	loggerconfig.Info("FetchAppActivity (controller), reqParams: appId:", appId, " clientID: ", requestH.ClientId, " requestId:", requestH.RequestId)
+	code, resp := theLoginProvider.FetchAppActivity(appId, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: FetchAppActivity requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
 // The method will get called by the oauth/auth API as a redirect url and auth code will be handled here.
 func HandleAuthCode(c *gin.Context) {
 	authCode := c.Query("code")
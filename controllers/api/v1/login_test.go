@@ -35,6 +35,7 @@ var (
 	getAccessToken            func(reqParams models.GetAccessTokenReq, reqH models.ReqHeader) (int, apihelpers.APIRes)
 	forgetResetTwoFaEmailMock func(req models.ForgetResetEmailRequest, reqH models.ReqHeader) (int, apihelpers.APIRes)
 	forgetPasswordEmailMock   func(req models.ForgetResetEmailRequest, reqH models.ReqHeader) (int, apihelpers.APIRes)
+	fetchAppActivityMock      func(appId string, reqH models.ReqHeader) (int, apihelpers.APIRes)
 )
 
 type loginApisMock struct{}
@@ -107,6 +108,10 @@ func (m loginApisMock) HandleAuthCode(authCode string, appState string, reqH mod
 	return handleAuthCode(authCode, appState, reqH)
 }
 
+func (m loginApisMock) FetchAppActivity(appId string, reqH models.ReqHeader) (int, apihelpers.APIRes) {
+	return fetchAppActivityMock(appId, reqH)
+}
+
 //
 
 func (m loginApisMock) ForgetResetTwoFaEmail(req models.ForgetResetEmailRequest, reqH models.ReqHeader) (int, apihelpers.APIRes) {
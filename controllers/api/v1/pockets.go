@@ -84,10 +84,8 @@ func AdminLogin(c *gin.Context) {
 func CreatePockets(c *gin.Context) {
 	var pocketsReq models.CreatePocketsRequest
 
-	var reqH models.ReqHeader
-	if err := c.ShouldBindHeader(&reqH); err != nil {
-		loggerconfig.Error("CreatePockets (controller), error decoding header, error:", err)
-	}
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
 
 	err := json.NewDecoder(c.Request.Body).Decode(&pocketsReq)
 	if err != nil {
@@ -121,10 +119,8 @@ func CreatePockets(c *gin.Context) {
 func ModifyPockets(c *gin.Context) {
 	var pocketsReq models.ModifyPocketsRequest
 
-	var reqH models.ReqHeader
-	if err := c.ShouldBindHeader(&reqH); err != nil {
-		loggerconfig.Error("ModifyPockets (controller), error decoding header, error:", err)
-	}
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
 
 	err := json.NewDecoder(c.Request.Body).Decode(&pocketsReq)
 	if err != nil {
@@ -234,10 +230,8 @@ func FetchPocketsUser(c *gin.Context) {
 func DeletePockets(c *gin.Context) {
 	var pocketsReq models.DeletePocketsRequest
 
-	var reqH models.ReqHeader
-	if err := c.ShouldBindHeader(&reqH); err != nil {
-		loggerconfig.Error("DeletePockets (controller), error decoding header, error:", err)
-	}
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
 
 	err := json.NewDecoder(c.Request.Body).Decode(&pocketsReq)
 	if err != nil {
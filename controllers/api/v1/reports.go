@@ -104,6 +104,7 @@ func ViewDPCharges(c *gin.Context) {
 // @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
 // @Param dfdatefr query string true "dfdatefr Query Parameter" dataType(string)
 // @Param dfdateto query string true "dfdateto Query Parameter" dataType(string)
+// @Param format query string false "format Query Parameter, xlsx (default), csv or pdf" dataType(string)
 // @Success 200 {object} apihelpers.APIRes{data=models.DownloadDPChargesRes}
 // @Failure 400 {object} apihelpers.APIRes
 // @Failure 403 {object} apihelpers.APIRes
@@ -145,6 +146,7 @@ func DownloadDPCharges(c *gin.Context) {
 	profileInfo, _ := (profileData).(models.ProfileDataResp)
 
 	reqParams.UserID = requestH.ClientId
+	reqParams.Format = c.Query("format")
 	reqParams.DFDateFr = dfDateFr
 	reqParams.DFDateTo = dfDateTo
 
@@ -315,6 +317,7 @@ func ViewTradebook(c *gin.Context) {
 // @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
 // @Param dfdatefr query string true "dfdatefr Query Parameter" dataType(string)
 // @Param dfdateto query string true "dfdateto Query Parameter" dataType(string)
+// @Param format query string false "format Query Parameter, xlsx (default), csv or pdf" dataType(string)
 // @Success 200 {object} apihelpers.APIRes{data=models.DownloadTradebookRes}
 // @Failure 400 {object} apihelpers.APIRes
 // @Failure 403 {object} apihelpers.APIRes
@@ -343,6 +346,7 @@ func DownloadTradebook(c *gin.Context) {
 	profileInfo, _ := (profileData).(models.ProfileDataResp)
 
 	reqParams.UserID = requestH.ClientId
+	reqParams.Format = c.Query("format")
 	reqParams.DFDateFr = dfDateFr
 	reqParams.DFDateTo = dfDateTo
 
@@ -439,6 +443,7 @@ func ViewLedger(c *gin.Context) {
 // @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
 // @Param dfdatefr query string true "dfdatefr Query Parameter" dataType(string)
 // @Param dfdateto query string true "dfdateto Query Parameter" dataType(string)
+// @Param format query string false "format Query Parameter, xlsx (default), csv or pdf" dataType(string)
 // @Success 200 {object} apihelpers.APIRes{data=models.DownloadLedgerRes}
 // @Failure 400 {object} apihelpers.APIRes
 // @Failure 403 {object} apihelpers.APIRes
@@ -467,6 +472,7 @@ func DownloadLedger(c *gin.Context) {
 	profileInfo, _ := (profileData).(models.ProfileDataResp)
 
 	reqParams.UserID = requestH.ClientId
+	reqParams.Format = c.Query("format")
 	reqParams.DFDateFr = dfDateFr
 	reqParams.DFDateTo = dfDateTo
 
@@ -552,6 +558,7 @@ func ViewOpenPosition(c *gin.Context) {
 // @Param Authorization header string true "Authorization Header"
 // @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
 // @Param dsFlag query string true "dsFlag Query Parameter" dataType(string)
+// @Param format query string false "format Query Parameter, xlsx (default), csv or pdf" dataType(string)
 // @Success 200 {object} apihelpers.APIRes{data=models.DownloadOpenPositionRes}
 // @Failure 400 {object} apihelpers.APIRes
 // @Failure 403 {object} apihelpers.APIRes
@@ -573,6 +580,7 @@ func DownloadOpenPosition(c *gin.Context) {
 	profileInfo, _ := (profileData).(models.ProfileDataResp)
 
 	reqParams.UserID = requestH.ClientId
+	reqParams.Format = c.Query("format")
 	reqParams.Dsflag = dsFlag
 
 	if requestH.DeviceType == "" {
@@ -668,6 +676,7 @@ func ViewFnoPnl(c *gin.Context) {
 // @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
 // @Param dfdatefr query string true "dfdatefr Query Parameter" dataType(string)
 // @Param dfdateto query string true "dfdateto Query Parameter" dataType(string)
+// @Param format query string false "format Query Parameter, xlsx (default), csv or pdf" dataType(string)
 // @Success 200 {object} apihelpers.APIRes{data=models.DownloadFnoPnlRes}
 // @Failure 400 {object} apihelpers.APIRes
 // @Failure 403 {object} apihelpers.APIRes
@@ -696,6 +705,7 @@ func DownloadFnoPnl(c *gin.Context) {
 	profileInfo, _ := (profileData).(models.ProfileDataResp)
 
 	reqParams.UserID = requestH.ClientId
+	reqParams.Format = c.Query("format")
 	reqParams.DFDateFr = dfDateFr
 	reqParams.DFDateTo = dfDateTo
 
@@ -763,6 +773,7 @@ func ViewHoldingFinancial(c *gin.Context) {
 // @Param ClientId header string true "ClientId"
 // @Param Authorization header string true "Authorization Header"
 // @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param format query string false "format Query Parameter, xlsx (default), csv or pdf" dataType(string)
 // @Success 200 {object} apihelpers.APIRes{data=models.DownloadHoldingFinancialRes}
 // @Failure 400 {object} apihelpers.APIRes
 // @Failure 403 {object} apihelpers.APIRes
@@ -776,6 +787,7 @@ func DownloadHoldingFinancial(c *gin.Context) {
 	profileInfo, _ := (profileData).(models.ProfileDataResp)
 
 	reqParams.UserID = requestH.ClientId
+	reqParams.Format = c.Query("format")
 
 	if requestH.DeviceType == "" {
 		loggerconfig.Error("DownloadHoldingFinancial (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
@@ -783,6 +795,14 @@ func DownloadHoldingFinancial(c *gin.Context) {
 		return
 	}
 
+	validate := validator.New()
+	err := validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("DownloadHoldingFinancial (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
 	loggerconfig.Info("DownloadHoldingFinancial (controller), requestId:", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
 	code, resp := theReportsProvider.DownloadHoldingFinancial(reqParams, requestH, profileInfo)
 	logDetail := "clientId: " + requestH.ClientId + " function: PendingOrder requestId: " + requestH.RequestId
@@ -885,6 +905,68 @@ func SendEmailLedger(c *gin.Context) {
 	apihelpers.CustomResponse(c, code, resp, logDetail)
 }
 
+// SendEmailFnoPnl
+// @Tags space Reports V1
+// @Description Send Email F&O Profit & Loss
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param ClientId header string true "ClientId"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param dfdatefr query string true "dfdatefr Query Parameter" dataType(string)
+// @Param dfdateto query string true "dfdateto Query Parameter" dataType(string)
+// @Success 200 {object} apihelpers.APIRes
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/reports/sendEmailFnoPnl [GET]
+func SendEmailFnoPnl(c *gin.Context) {
+	var reqParams models.FnoPnlReq
+
+	dfDateFr := c.Query("dfdatefr")
+	dfDateTo := c.Query("dfdateto")
+
+	if dfDateFr == "" || dfDateTo == "" {
+		loggerconfig.Error("SendEmailFnoPnl (controller), error parsing the query params in Get request, not found error!")
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	if !helpers.ValidateDateQueryParam(dfDateFr, constants.DDMMYYYY) || !helpers.ValidateDateQueryParam(dfDateTo, constants.DDMMYYYY) || !helpers.ValidateDateRange(dfDateFr, dfDateTo, constants.DDMMYYYY) {
+		loggerconfig.Error("SendEmailFnoPnl (controller), Invalid date format !")
+		apihelpers.ErrorMessage(c, constants.InvalidDate)
+		return
+	}
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+	profileData, _ := c.Get("profileData")
+	profileInfo, _ := (profileData).(models.ProfileDataResp)
+
+	reqParams.UserID = requestH.ClientId
+	reqParams.DFDateFr = dfDateFr
+	reqParams.DFDateTo = dfDateTo
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("SendEmailFnoPnl (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err := validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("SendEmailFnoPnl (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("SendEmailFnoPnl (controller), reqParams:", helpers.LogStructAsJSON(reqParams), "requestId:", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+	code, resp := theReportsProvider.SendEmailFnoPnl(reqParams, requestH, profileInfo)
+	logDetail := "clientId: " + requestH.ClientId + " function: SendEmailFnoPnl requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
 // ViewCommodityTradebook
 // @Tags space Reports V1
 // @Description View Commodity Tradebook
@@ -952,6 +1034,7 @@ func ViewCommodityTradebook(c *gin.Context) {
 // @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
 // @Param dfdatefr query string true "dfdatefr Query Parameter" dataType(string)
 // @Param dfdateto query string true "dfdateto Query Parameter" dataType(string)
+// @Param format query string false "format Query Parameter, xlsx (default), csv or pdf" dataType(string)
 // @Success 200 {object} apihelpers.APIRes{data=models.DownloadCommodityTradebookRes}
 // @Failure 400 {object} apihelpers.APIRes
 // @Failure 403 {object} apihelpers.APIRes
@@ -973,6 +1056,7 @@ func DownloadCommodityTradebook(c *gin.Context) {
 	profileInfo, _ := (profileData).(models.ProfileDataResp)
 
 	reqParams.UserID = requestH.ClientId
+	reqParams.Format = c.Query("format")
 	reqParams.DFDateFr = dfDateFr
 	reqParams.DFDateTo = dfDateTo
 
@@ -1117,6 +1201,7 @@ func ViewFnoTradebook(c *gin.Context) {
 // @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
 // @Param dfdatefr query string true "dfdatefr Query Parameter" dataType(string)
 // @Param dfdateto query string true "dfdateto Query Parameter" dataType(string)
+// @Param format query string false "format Query Parameter, xlsx (default), csv or pdf" dataType(string)
 // @Success 200 {object} apihelpers.APIRes{data=models.DownloadFnoTradebookRes}
 // @Failure 400 {object} apihelpers.APIRes
 // @Failure 403 {object} apihelpers.APIRes
@@ -1139,6 +1224,7 @@ func DownloadFnoTradebook(c *gin.Context) {
 	profileInfo, _ := (profileData).(models.ProfileDataResp)
 
 	reqParams.UserID = requestH.ClientId
+	reqParams.Format = c.Query("format")
 	reqParams.DFDateFr = dfDateFr
 	reqParams.DFDateTo = dfDateTo
 
@@ -1216,3 +1302,227 @@ func SendEmailFnoTradebook(c *gin.Context) {
 	logDetail := "clientId: " + requestH.ClientId + " function: SendEmailFnoTradebook requestId: " + requestH.RequestId
 	apihelpers.CustomResponse(c, code, resp, logDetail)
 }
+
+// SubmitReportJob
+// @Tags space Reports V1
+// @Description Submit Report Job - queues a report to be generated in the background, poll reportJobStatus for the link
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param ClientId header string true "ClientId"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.SubmitReportJobReq true "report job"
+// @Success 200 {object} apihelpers.APIRes{data=models.SubmitReportJobRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/reports/submitReportJob [POST]
+func SubmitReportJob(c *gin.Context) {
+	var reqParams models.SubmitReportJobReq
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+	profileData, _ := c.Get("profileData")
+	profileInfo, _ := (profileData).(models.ProfileDataResp)
+
+	err := c.ShouldBindJSON(&reqParams)
+	if err != nil {
+		loggerconfig.Error("SubmitReportJob (controller), error decoding body, error:", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+	reqParams.UserID = requestH.ClientId
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("SubmitReportJob (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("SubmitReportJob (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("SubmitReportJob (controller), reqParams:", helpers.LogStructAsJSON(reqParams), " requestId:", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+	code, resp := theReportsProvider.SubmitReportJob(reqParams, requestH, profileInfo)
+	logDetail := "clientId: " + requestH.ClientId + " function: SubmitReportJob requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// ReportJobStatus
+// @Tags space Reports V1
+// @Description Report Job Status - progress of a submitted report job and its download link once completed
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param ClientId header string true "ClientId"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param jobId query string true "jobId Query Parameter" dataType(string)
+// @Success 200 {object} apihelpers.APIRes{data=models.ReportJobStatusRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/reports/reportJobStatus [GET]
+func ReportJobStatus(c *gin.Context) {
+	var reqParams models.ReportJobStatusReq
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	reqParams.UserID = requestH.ClientId
+	reqParams.JobId = c.Query("jobId")
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("ReportJobStatus (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err := validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("ReportJobStatus (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("ReportJobStatus (controller), jobId:", reqParams.JobId, " requestId:", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+	code, resp := theReportsProvider.FetchReportJobStatus(reqParams, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: ReportJobStatus requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// StatementSubscriptions
+// @Tags space Reports V1
+// @Description Statement Subscriptions - recurring statements the client can receive by email, and the latest deliveries
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param ClientId header string true "ClientId"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Success 200 {object} apihelpers.APIRes{data=models.StatementSubscriptionsRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/reports/statementSubscriptions [GET]
+func StatementSubscriptions(c *gin.Context) {
+	var reqParams models.StatementSubscriptionsReq
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	reqParams.UserID = requestH.ClientId
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("StatementSubscriptions (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	loggerconfig.Info("StatementSubscriptions (controller), requestId:", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+	code, resp := theReportsProvider.FetchStatementSubscriptions(reqParams, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: StatementSubscriptions requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// SubscribeStatement
+// @Tags space Reports V1
+// @Description Subscribe Statement - email a monthly ledger, quarterly holdings or yearly P&L statement on the first business day after each period
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param ClientId header string true "ClientId"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.StatementSubscriptionReq true "statement"
+// @Success 200 {object} apihelpers.APIRes{data=models.StatementSubscriptionRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/reports/subscribeStatement [POST]
+func SubscribeStatement(c *gin.Context) {
+	var reqParams models.StatementSubscriptionReq
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+	profileData, _ := c.Get("profileData")
+	profileInfo, _ := (profileData).(models.ProfileDataResp)
+
+	err := c.ShouldBindJSON(&reqParams)
+	if err != nil {
+		loggerconfig.Error("SubscribeStatement (controller), error decoding body, error:", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+	reqParams.UserID = requestH.ClientId
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("SubscribeStatement (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("SubscribeStatement (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("SubscribeStatement (controller), statement:", reqParams.Statement, " requestId:", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+	code, resp := theReportsProvider.SubscribeStatement(reqParams, requestH, profileInfo)
+	logDetail := "clientId: " + requestH.ClientId + " function: SubscribeStatement requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// UnsubscribeStatement
+// @Tags space Reports V1
+// @Description Unsubscribe Statement - stop emailing a recurring statement
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param ClientId header string true "ClientId"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.StatementSubscriptionReq true "statement"
+// @Success 200 {object} apihelpers.APIRes
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v1/reports/unsubscribeStatement [POST]
+func UnsubscribeStatement(c *gin.Context) {
+	var reqParams models.StatementSubscriptionReq
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	err := c.ShouldBindJSON(&reqParams)
+	if err != nil {
+		loggerconfig.Error("UnsubscribeStatement (controller), error decoding body, error:", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+	reqParams.UserID = requestH.ClientId
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("UnsubscribeStatement (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("UnsubscribeStatement (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("UnsubscribeStatement (controller), statement:", reqParams.Statement, " requestId:", requestH.RequestId, "clientId: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+	code, resp := theReportsProvider.UnsubscribeStatement(reqParams, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: UnsubscribeStatement requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
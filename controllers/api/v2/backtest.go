@@ -0,0 +1,67 @@
+package v2
+
+import (
+	"encoding/json"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+var theBacktestProviderV2 models.BacktestProvider
+
+func InitBacktestProviderV2(provider models.BacktestProvider) {
+	defer models.HandlePanic()
+	theBacktestProviderV2 = provider
+}
+
+// RunBacktest
+// @Tags space Backtest V2
+// @Description Backtest a rule-based strategy over historical candles, net of charges
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.RunBacktestReq true "Strategy"
+// @Success 200 {object} apihelpers.APIRes{data=models.RunBacktestRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/backtest/run [POST]
+func RunBacktest(c *gin.Context) {
+	var reqParams models.RunBacktestReq
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("RunBacktest (controller), error decoding body, error:", err)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("RunBacktest (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("RunBacktest (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("RunBacktest (controller), reqParams:", helpers.LogStructAsJSON(reqParams), " uccId: ", requestH.ClientId, "requestId:", requestH.RequestId)
+
+	code, resp := theBacktestProviderV2.RunBacktest(reqParams, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: RunBacktest requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
@@ -235,3 +235,126 @@ func FetchSectorWiseCompanyV2(c *gin.Context) {
 	logDetail := "FetchSectorWiseCompanyV2 (controller) clientId: " + requestH.ClientId + " requestId: " + requestH.RequestId
 	apihelpers.CustomResponse(c, code, resp, logDetail)
 }
+
+// FetchSectorHeatmap
+// @Tags space cmots V2
+// @Description Sector heatmap with weighted % change, movers and market breadth
+// @Param ClientId header string false "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientType header string false "P-ClientType Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Success 200 {object} apihelpers.APIRes{data=models.SectorHeatmapRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/cmots/sectorHeatmap [GET]
+func FetchSectorHeatmap(c *gin.Context) {
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("FetchSectorHeatmap (controller), Empty Device Type requestId: ", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	code, resp := theCmotsProviderV2.FetchSectorHeatmap(requestH)
+	logDetail := "FetchSectorHeatmap (controller) clientId: " + requestH.ClientId + " requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// CompareStocks
+// @Tags space cmots V2
+// @Description Side-by-side valuation, profitability, leverage and growth metrics for up to 5 stocks
+// @Param ClientId header string false "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientType header string false "P-ClientType Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.CompareStocksReq true "cmots"
+// @Success 200 {object} apihelpers.APIRes{data=models.CompareStocksRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/cmots/compareStocks [POST]
+func CompareStocks(c *gin.Context) {
+	var reqParams models.CompareStocksReq
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("CompareStocks (controller), error decoding body, error:", err)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("CompareStocks (controller), Empty Device Type requestId: ", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("CompareStocks (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("CompareStocks (controller), reqParams:", helpers.LogStructAsJSON(reqParams), "requestId:", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+	code, resp := theCmotsProviderV2.CompareStocks(reqParams, requestH)
+	logDetail := "CompareStocks (controller) clientId: " + requestH.ClientId + " requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// ExportStockComparison
+// @Tags space cmots V2
+// @Description Export the stock comparison as XLSX and return a download url
+// @Param ClientId header string false "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientType header string false "P-ClientType Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.CompareStocksReq true "cmots"
+// @Success 200 {object} apihelpers.APIRes{data=models.ExportStockComparisonRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/cmots/compareStocks/export [POST]
+func ExportStockComparison(c *gin.Context) {
+	var reqParams models.CompareStocksReq
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("ExportStockComparison (controller), error decoding body, error:", err)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("ExportStockComparison (controller), Empty Device Type requestId: ", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("ExportStockComparison (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("ExportStockComparison (controller), reqParams:", helpers.LogStructAsJSON(reqParams), "requestId:", requestH.RequestId, "ClientID: ", requestH.ClientId, " deviceId: ", requestH.DeviceId)
+	code, resp := theCmotsProviderV2.ExportStockComparison(reqParams, requestH)
+	logDetail := "ExportStockComparison (controller) clientId: " + requestH.ClientId + " requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
@@ -0,0 +1,188 @@
+package v2
+
+import (
+	"encoding/json"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+var theScreenerProviderV2 models.ScreenerProvider
+
+func InitScreenerProviderV2(provider models.ScreenerProvider) {
+	defer models.HandlePanic()
+	theScreenerProviderV2 = provider
+}
+
+// RunScreener
+// @Tags space Custom Screeners V2
+// @Description Run an ad hoc or saved screener over fundamentals and technicals
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.RunScreenerReq true "Screener"
+// @Success 200 {object} apihelpers.APIRes{data=models.RunScreenerRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/customScreeners/run [POST]
+func RunScreener(c *gin.Context) {
+	var reqParams models.RunScreenerReq
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("RunScreener (controller), error decoding body, error:", err)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("RunScreener (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil || (reqParams.Query == "" && reqParams.ScreenerId == "") {
+		loggerconfig.Error("RunScreener (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("RunScreener (controller), reqParams:", helpers.LogStructAsJSON(reqParams), " uccId: ", requestH.ClientId, "requestId:", requestH.RequestId)
+
+	code, resp := theScreenerProviderV2.RunScreener(reqParams, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: RunScreener requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// SaveScreener
+// @Tags space Custom Screeners V2
+// @Description Create a named screener, or update it when screenerId is passed
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.SaveScreenerReq true "Screener"
+// @Success 200 {object} apihelpers.APIRes{data=models.SavedScreener}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/customScreeners/save [POST]
+func SaveScreener(c *gin.Context) {
+	var reqParams models.SaveScreenerReq
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("SaveScreener (controller), error decoding body, error:", err)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("SaveScreener (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("SaveScreener (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("SaveScreener (controller), reqParams:", helpers.LogStructAsJSON(reqParams), " uccId: ", requestH.ClientId, "requestId:", requestH.RequestId)
+
+	code, resp := theScreenerProviderV2.SaveScreener(reqParams, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: SaveScreener requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// FetchScreeners
+// @Tags space Custom Screeners V2
+// @Description Fetch the client's saved screeners
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Success 200 {object} apihelpers.APIRes{data=models.FetchScreenersRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/customScreeners/fetch [GET]
+func FetchScreeners(c *gin.Context) {
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("FetchScreeners (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	loggerconfig.Info("FetchScreeners (controller), uccId: ", requestH.ClientId, "requestId:", requestH.RequestId)
+
+	code, resp := theScreenerProviderV2.FetchScreeners(requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: FetchScreeners requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// DeleteScreener
+// @Tags space Custom Screeners V2
+// @Description Delete a saved screener
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.DeleteScreenerReq true "Screener"
+// @Success 200 {object} apihelpers.APIRes
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/customScreeners/delete [POST]
+func DeleteScreener(c *gin.Context) {
+	var reqParams models.DeleteScreenerReq
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("DeleteScreener (controller), error decoding body, error:", err)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("DeleteScreener (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("DeleteScreener (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("DeleteScreener (controller), reqParams:", helpers.LogStructAsJSON(reqParams), " uccId: ", requestH.ClientId, "requestId:", requestH.RequestId)
+
+	code, resp := theScreenerProviderV2.DeleteScreener(reqParams, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: DeleteScreener requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
@@ -1,14 +1,17 @@
 package v2
 
 import (
+	"encoding/json"
 	apihelpers "space/apiHelpers"
 	"space/constants"
+	"space/helpers"
 	"space/loggerconfig"
 	"space/models"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
 var theContractDetailsProviderV2 models.ContractDetailsProviderV2
@@ -34,6 +37,7 @@ func InitContractDetailsProvider(provider models.ContractDetailsProviderV2) {
 // @Failure 400 {object} apihelpers.APIRes
 // @Failure 403 {object} apihelpers.APIRes
 // @Router /api/space/v2/contractdetails/searchScrip [GET]
+// @Router /api/space/v2/userSearch/searchScrip [GET]
 func SearchScript(ctx *gin.Context) {
 
 	query := ctx.Query("searchText")
@@ -77,3 +81,49 @@ func SearchScript(ctx *gin.Context) {
 	logDetail := "clientId: " + requestH.ClientId + " function: logout V2 requestId: " + requestH.RequestId
 	apihelpers.CustomResponse(ctx, code, resp, logDetail)
 }
+
+// RecordSearchClick
+// @Tags space contractdetails V2
+// @Description Record the search result a client opened, used to rank their later searches
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.SearchClickReq true "Clicked result"
+// @Success 200 {object} apihelpers.APIRes
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/userSearch/click [POST]
+func RecordSearchClick(c *gin.Context) {
+	var reqParams models.SearchClickReq
+	err := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if err != nil {
+		loggerconfig.Error("RecordSearchClick (controller), error decoding body, error:", err)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("RecordSearchClick (controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("RecordSearchClick (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("RecordSearchClick (controller), reqParams:", helpers.LogStructAsJSON(reqParams), " uccId: ", requestH.ClientId, "requestId:", requestH.RequestId)
+
+	code, resp := theContractDetailsProviderV2.RecordSearchClick(reqParams, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: RecordSearchClick requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
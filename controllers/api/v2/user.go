@@ -6,6 +6,7 @@ import (
 	"space/models"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
 )
 
@@ -77,3 +78,109 @@ func Logout(c *gin.Context) {
 	apihelpers.CustomResponse(c, code, resp, logDetail)
 
 }
+
+// ListSessions
+// @Tags space auth V2
+// @Description List the devices the client is signed in on
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Success 200 {object} apihelpers.APIRes{data=models.ClientSessionsRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/user/sessions [GET]
+func ListSessions(c *gin.Context) {
+	var reqParams models.ClientSessionsReq
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		logrus.Error("ListSessions (controller), Empty Device type requestId: ", requestH.RequestId, " clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	reqParams.ClientId = requestH.ClientId
+
+	logrus.Info("ListSessions (controller), clientId: ", requestH.ClientId, " requestId:", requestH.RequestId)
+	code, resp := LogoutProvider.ListSessions(reqParams, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: ListSessions requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// RevokeSession
+// @Tags space auth V2
+// @Description Log out one of the devices the client is signed in on. Space rejects its token straight away and ends it at Tradelab the next time the device uses it
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param request body models.RevokeSessionReq true "session"
+// @Success 200 {object} apihelpers.APIRes
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/user/revokeSession [POST]
+func RevokeSession(c *gin.Context) {
+	var reqParams models.RevokeSessionReq
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if err := c.ShouldBindJSON(&reqParams); err != nil {
+		logrus.Error("RevokeSession (controller), error decoding body, error:", err, " requestId: ", requestH.RequestId, " clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+	reqParams.ClientId = requestH.ClientId
+
+	if requestH.DeviceType == "" {
+		logrus.Error("RevokeSession (controller), Empty Device type requestId: ", requestH.RequestId, " clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(reqParams); err != nil {
+		logrus.Error("RevokeSession (controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, " clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	logrus.Info("RevokeSession (controller), sessionId: ", reqParams.SessionId, " clientId: ", requestH.ClientId, " requestId:", requestH.RequestId)
+	code, resp := LogoutProvider.RevokeSession(reqParams, requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: RevokeSession requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// LogoutOtherDevices
+// @Tags space auth V2
+// @Description Log out every device except the one making the request. Space rejects their tokens straight away and ends each at Tradelab the next time it is used
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Success 200 {object} apihelpers.APIRes{data=models.LogoutOtherDevicesRes}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v2/user/logoutOtherDevices [POST]
+func LogoutOtherDevices(c *gin.Context) {
+
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+
+	if requestH.DeviceType == "" {
+		logrus.Error("LogoutOtherDevices (controller), Empty Device type requestId: ", requestH.RequestId, " clientId: ", requestH.ClientId)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	logrus.Info("LogoutOtherDevices (controller), clientId: ", requestH.ClientId, " requestId:", requestH.RequestId)
+	code, resp := LogoutProvider.LogoutOtherDevices(requestH)
+	logDetail := "clientId: " + requestH.ClientId + " function: LogoutOtherDevices requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
@@ -2,6 +2,7 @@ package v3
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	apihelpers "space/apiHelpers"
 	"space/constants"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 var theFetchFundsProviderV3 models.FetchFundsProviderV3
@@ -132,3 +134,284 @@ func CancelPayout(c *gin.Context) {
 	apihelpers.CustomResponse(c, code, resp, logDetail)
 
 }
+
+// TransitionPayout
+// @Tags space admin funds V3
+// @Description Move a payout to a new status. Replays with the same idempotency key are no-ops.
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.PayoutTransitionRequest true "funds"
+// @Success 200 {object} apihelpers.APIRes{data=models.PayoutTransitionResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/adminapis/funds/transitionPayout [POST]
+func TransitionPayout(c *gin.Context) {
+	var reqParams models.PayoutTransitionRequest
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+	errr := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if errr != nil {
+		loggerconfig.Error("TransitionPayout V3(controller), error decoding body, error:", errr, " requestId:", requestH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	validate := validator.New()
+	err := validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("TransitionPayout V3(controller), Error validating struct: ", err, " requestId: ", requestH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("TransitionPayout V3(controller), reqParams:", helpers.LogStructAsJSON(reqParams), " requestId:", requestH.RequestId)
+	code, resp := theFetchFundsProviderV3.TransitionPayout(reqParams, requestH)
+	logDetail := "transactionId: " + reqParams.TransactionId + " function: TransitionPayout requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// FetchPayoutHistory
+// @Tags space admin funds V3
+// @Description Fetch a payout with its status history
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.FetchPayoutHistoryRequest true "funds"
+// @Success 200 {object} apihelpers.APIRes{data=models.FetchPayoutHistoryResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/adminapis/funds/fetchPayoutHistory [POST]
+func FetchPayoutHistory(c *gin.Context) {
+	var reqParams models.FetchPayoutHistoryRequest
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+	errr := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if errr != nil {
+		loggerconfig.Error("FetchPayoutHistory V3(controller), error decoding body, error:", errr, " requestId:", requestH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	validate := validator.New()
+	err := validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("FetchPayoutHistory V3(controller), Error validating struct: ", err, " requestId: ", requestH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("FetchPayoutHistory V3(controller), reqParams:", helpers.LogStructAsJSON(reqParams), " requestId:", requestH.RequestId)
+	code, resp := theFetchFundsProviderV3.FetchPayoutHistory(reqParams, requestH)
+	logDetail := "transactionId: " + reqParams.TransactionId + " function: FetchPayoutHistory requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// UpiCollect
+// @Tags space funds V3
+// @Description Add funds with a UPI collect request to a saved UPI id
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.UpiCollectRequest true "funds"
+// @Success 200 {object} apihelpers.APIRes{data=models.UpiPayinResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/funds/view/upiCollect [POST]
+func UpiCollect(c *gin.Context) {
+	var reqParams models.UpiCollectRequest
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+	errr := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if errr != nil {
+		loggerconfig.Error("UpiCollect V3(controller), error decoding body, error:", errr, " requestId:", requestH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("UpiCollect V3(controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", reqParams.ClientID)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err := validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("UpiCollect V3(controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", reqParams.ClientID)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(reqParams.ClientID, requestH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("UpiCollect V3(controller) CheckAuthWithClient invalid authtoken", " clientId: ", reqParams.ClientID, " requestId:", requestH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("UpiCollect V3(controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", reqParams.ClientID, " requestId:", requestH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	loggerconfig.Info("UpiCollect V3(controller), amount:", reqParams.Amount, " uccId: ", reqParams.ClientID, " requestId:", requestH.RequestId)
+	code, resp := theFetchFundsProviderV3.UpiCollect(reqParams, requestH)
+	logDetail := "clientId: " + reqParams.ClientID + " function: UpiCollect requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// UpiIntent
+// @Tags space funds V3
+// @Description Add funds through a UPI intent link
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.UpiIntentRequest true "funds"
+// @Success 200 {object} apihelpers.APIRes{data=models.UpiPayinResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/funds/view/upiIntent [POST]
+func UpiIntent(c *gin.Context) {
+	var reqParams models.UpiIntentRequest
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+	errr := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if errr != nil {
+		loggerconfig.Error("UpiIntent V3(controller), error decoding body, error:", errr, " requestId:", requestH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("UpiIntent V3(controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", reqParams.ClientID)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err := validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("UpiIntent V3(controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", reqParams.ClientID)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(reqParams.ClientID, requestH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("UpiIntent V3(controller) CheckAuthWithClient invalid authtoken", " clientId: ", reqParams.ClientID, " requestId:", requestH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("UpiIntent V3(controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", reqParams.ClientID, " requestId:", requestH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	loggerconfig.Info("UpiIntent V3(controller), reqParams:", helpers.LogStructAsJSON(reqParams), " uccId: ", reqParams.ClientID, " requestId:", requestH.RequestId)
+	code, resp := theFetchFundsProviderV3.UpiIntent(reqParams, requestH)
+	logDetail := "clientId: " + reqParams.ClientID + " function: UpiIntent requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// UpiPayinStatus
+// @Tags space funds V3
+// @Description Status of a UPI payin
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.UpiPayinStatusRequest true "funds"
+// @Success 200 {object} apihelpers.APIRes{data=models.UpiPayinStatusResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/funds/view/upiPayinStatus [POST]
+func UpiPayinStatus(c *gin.Context) {
+	var reqParams models.UpiPayinStatusRequest
+	cRH, _ := c.Get("reqH")
+	requestH, _ := (cRH).(models.ReqHeader)
+	errr := json.NewDecoder(c.Request.Body).Decode(&reqParams)
+	if errr != nil {
+		loggerconfig.Error("UpiPayinStatus V3(controller), error decoding body, error:", errr, " requestId:", requestH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	if requestH.DeviceType == "" {
+		loggerconfig.Error("UpiPayinStatus V3(controller), Empty Device Type requestId: ", requestH.RequestId, "clientId: ", reqParams.ClientID)
+		apihelpers.ErrorMessage(c, constants.InvalidDeviceType)
+		return
+	}
+
+	validate := validator.New()
+	err := validate.Struct(reqParams)
+	if err != nil {
+		loggerconfig.Error("UpiPayinStatus V3(controller), Error validating struct: ", err, " requestId: ", requestH.RequestId, "clientId: ", reqParams.ClientID)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(reqParams.ClientID, requestH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("UpiPayinStatus V3(controller) CheckAuthWithClient invalid authtoken", " clientId: ", reqParams.ClientID, " requestId:", requestH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("UpiPayinStatus V3(controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", reqParams.ClientID, " requestId:", requestH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	loggerconfig.Info("UpiPayinStatus V3(controller), reqParams:", helpers.LogStructAsJSON(reqParams), " uccId: ", reqParams.ClientID, " requestId:", requestH.RequestId)
+	code, resp := theFetchFundsProviderV3.UpiPayinStatus(reqParams, requestH)
+	logDetail := "clientId: " + reqParams.ClientID + " function: UpiPayinStatus requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// UpiPayinWebhook
+// @Tags space funds V3
+// @Description Payment gateway callback for UPI payins, verified against the signature header
+// @Param gateway path string true "Gateway"
+// @Param X-Upi-Signature header string true "Webhook signature"
+// @Success 200 {object} apihelpers.APIRes
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 401 {object} apihelpers.APIRes
+// @Router /api/space/v3/funds/webhook/upi/{gateway} [POST]
+func UpiPayinWebhook(c *gin.Context) {
+	var requestH models.ReqHeader
+	if err := c.ShouldBindHeader(&requestH); err != nil {
+		loggerconfig.Error("UpiPayinWebhook V3(controller), error decoding header, error:", err)
+	}
+	requestH.RequestId = uuid.New().String()
+	if requestH.ClientPublicIP == "" {
+		requestH.ClientPublicIP = c.ClientIP()
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		loggerconfig.Error("UpiPayinWebhook V3(controller), error reading body, error:", err, " requestId:", requestH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	reqParams := models.UpiWebhookRequest{
+		Gateway:   c.Param("gateway"),
+		Body:      body,
+		Signature: c.GetHeader(constants.UpiSignatureHeader),
+	}
+	loggerconfig.Info("UpiPayinWebhook V3(controller), gateway:", reqParams.Gateway, " requestId:", requestH.RequestId)
+	code, resp := theFetchFundsProviderV3.UpiPayinWebhook(reqParams, requestH)
+	logDetail := "gateway: " + reqParams.Gateway + " function: UpiPayinWebhook requestId: " + requestH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
@@ -0,0 +1,74 @@
+package v3
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var theGlobalSearchProvider models.GlobalSearchProvider
+
+func InitGlobalSearchProvider(provider models.GlobalSearchProvider) {
+	defer models.HandlePanic()
+	theGlobalSearchProvider = provider
+}
+
+// GlobalSearch
+// @Tags space search V3
+// @Description Search stocks, F&O, pockets, collections, live and upcoming IPOs, bonds and sectors in one call, grouped by type with a combined relevance order
+// @Param query query string true "query"
+// @Param types query string false "comma separated types: scrip, ipo, pocket, collection, sector, bond"
+// @Param limit query string false "results per type"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param P-ClientType header string false "P-ClientType Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Success 200 {object} apihelpers.APIRes{data=models.GlobalSearchResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/search [GET]
+func GlobalSearch(ctx *gin.Context) {
+	var reqH models.ReqHeader
+	if err := ctx.ShouldBindHeader(&reqH); err != nil {
+		loggerconfig.Error("GlobalSearch (controller), Error in parsing header, error = ", err, " requestId:", reqH.RequestId, " platform:", reqH.Platform, " deviceId:", reqH.DeviceId)
+		return
+	}
+
+	req := models.GlobalSearchReq{
+		Query: strings.TrimSpace(ctx.Query("query")),
+	}
+	if len(req.Query) < constants.GlobalSearchMinQueryLength {
+		loggerconfig.Error("GlobalSearch (controller), invalid query:", req.Query, " requestId:", reqH.RequestId, " platform:", reqH.Platform, " deviceId:", reqH.DeviceId)
+		apihelpers.ErrorMessage(ctx, constants.InvalidRequest)
+		return
+	}
+	if types := ctx.Query("types"); types != "" {
+		req.Types = strings.Split(types, ",")
+	}
+	if limit := ctx.Query("limit"); limit != "" {
+		limitInt, err := strconv.Atoi(limit)
+		if err != nil || limitInt < 1 {
+			loggerconfig.Error("GlobalSearch (controller), invalid limit:", limit, " requestId:", reqH.RequestId, " platform:", reqH.Platform, " deviceId:", reqH.DeviceId)
+			apihelpers.ErrorMessage(ctx, constants.InvalidRequest)
+			return
+		}
+		req.Limit = limitInt
+	}
+
+	loggerconfig.Info("GlobalSearch (controller), reqParams:", helpers.LogStructAsJSON(req), " clientId:", reqH.ClientId, " requestId:", reqH.RequestId, " platform:", reqH.Platform, " deviceId:", reqH.DeviceId)
+
+	statusCode, apiRes := theGlobalSearchProvider.GlobalSearch(req, reqH)
+
+	logDetail := fmt.Sprintf("clientId: %s function: GlobalSearch query: %s requestId: %s", reqH.ClientId, req.Query, reqH.RequestId)
+	apihelpers.CustomResponse(ctx, statusCode, apiRes, logDetail)
+}
@@ -2,7 +2,9 @@ package v3
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	apihelpers "space/apiHelpers"
@@ -33,6 +35,10 @@ func InitWatchListProviderV3(provider models.WatchListProvider) {
 // @Param Authorization header string true "Authorization Header"
 // @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
 // @Param clientId query string true "clientId Query Parameter" dataType(string)
+// @Param enrich query bool false "attach LTP, day change, 52-week range, market cap and next corporate action"
+// @Param sortBy query string false "changePct, name or custom"
+// @Param sortOrder query string false "asc or desc"
+// @Param groupBy query string false "sector"
 // @Success 200 {object} apihelpers.APIRes{data=models.FetchWatchListV3Response}
 // @Failure 400 {object} apihelpers.APIRes
 // @Failure 403 {object} apihelpers.APIRes
@@ -47,10 +53,28 @@ func FetchWatchList(c *gin.Context) {
 		return
 	}
 	reqParams.ClientId = strings.ToUpper(clientID)
+	reqParams.SortBy = c.Query("sortBy")
+	reqParams.SortOrder = c.Query("sortOrder")
+	reqParams.GroupBy = c.Query("groupBy")
 
 	cRH, _ := c.Get("reqH")
 	reqH, _ := (cRH).(models.ReqHeader)
 
+	if enrich := c.Query("enrich"); enrich != "" {
+		enrichBool, err := strconv.ParseBool(enrich)
+		if err != nil {
+			loggerconfig.Error("FetchWatchList V3 (controller), invalid enrich:", enrich, " requestId:", reqH.RequestId)
+			apihelpers.ErrorMessage(c, constants.InvalidRequest)
+			return
+		}
+		reqParams.Enrich = enrichBool
+	}
+	if err := validator.New().Struct(reqParams); err != nil {
+		loggerconfig.Error("FetchWatchList V3 (controller) validation error: ", err, " requestId: ", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
 	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(reqParams.ClientId, reqH.Authorization)
 	if !tokenValidStatus {
 		loggerconfig.Error("FetchWatchList V3 (controller) CheckAuthWithClient invalid authtoken", " clientId: ", reqParams.ClientId, " requestId:", reqH.RequestId)
@@ -292,3 +316,458 @@ func DeleteStockInWatchListUpdated(c *gin.Context) {
 	apihelpers.CustomResponse(c, code, resp, logDetail)
 
 }
+
+// ImportWatchList
+// @Tags space watchlist V3
+// @Description Import stocks into a watchlist from a CSV or XLSX file with symbol, isin and exchange columns. Rows that cannot be matched are reported back.
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param clientId formData string true "clientId"
+// @Param watchListId formData string true "watchListId"
+// @Param replace formData bool false "replace the watchlist instead of appending"
+// @Param file formData file true "csv or xlsx file"
+// @Success 200 {object} apihelpers.APIRes{data=models.ImportWatchListResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/watchlist/importWatchList [POST]
+func ImportWatchList(c *gin.Context) {
+	var importReq models.ImportWatchListRequest
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	importReq.ClientId = strings.ToUpper(c.PostForm("clientId"))
+	importReq.WatchListId = c.PostForm("watchListId")
+	importReq.Replace, _ = strconv.ParseBool(c.PostForm("replace"))
+
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(importReq.ClientId, reqH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("ImportWatchList V3 (controller) CheckAuthWithClient invalid authtoken", " clientId: ", importReq.ClientId, " requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("ImportWatchList V3 (controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", importReq.ClientId, "requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil || fileHeader.Size > constants.WatchListImportMaxFileBytes {
+		loggerconfig.Error("ImportWatchList V3 (controller), invalid file, error:", err, " clientId: ", importReq.ClientId, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidWatchListFile)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		loggerconfig.Error("ImportWatchList V3 (controller), error opening file, error:", err, " clientId: ", importReq.ClientId, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidWatchListFile)
+		return
+	}
+	defer file.Close()
+	importReq.FileName = fileHeader.Filename
+	if importReq.Content, err = io.ReadAll(file); err != nil {
+		loggerconfig.Error("ImportWatchList V3 (controller), error reading file, error:", err, " clientId: ", importReq.ClientId, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidWatchListFile)
+		return
+	}
+
+	if err := validator.New().Struct(importReq); err != nil {
+		loggerconfig.Error("ImportWatchList V3 (controller) validation error: ", err, " requestId: ", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	loggerconfig.Info("ImportWatchList V3 (controller), clientId:", importReq.ClientId, " watchListId:", importReq.WatchListId, " fileName:", importReq.FileName, " size:", fileHeader.Size, " requestId:", reqH.RequestId)
+
+	code, resp := watchListProviderV3.ImportWatchList(importReq, reqH)
+
+	logDetail := "clientId: " + reqH.ClientId + " function: ImportWatchList v3 requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// ExportWatchList
+// @Tags space watchlist V3
+// @Description Export a watchlist as CSV or XLSX, returns a download link
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param clientId query string true "clientId"
+// @Param watchListId query string true "watchListId"
+// @Param format query string true "csv or xlsx"
+// @Success 200 {object} apihelpers.APIRes{data=models.ExportWatchListResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/watchlist/exportWatchList [GET]
+func ExportWatchList(c *gin.Context) {
+	exportReq := models.ExportWatchListRequest{
+		ClientId:    strings.ToUpper(c.Query("clientId")),
+		WatchListId: c.Query("watchListId"),
+		Format:      strings.ToLower(c.Query("format")),
+	}
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(exportReq.ClientId, reqH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("ExportWatchList V3 (controller) CheckAuthWithClient invalid authtoken", " clientId: ", exportReq.ClientId, " requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("ExportWatchList V3 (controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", exportReq.ClientId, "requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	if err := validator.New().Struct(exportReq); err != nil {
+		loggerconfig.Error("ExportWatchList V3 (controller) validation error: ", err, " requestId: ", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	code, resp := watchListProviderV3.ExportWatchList(exportReq, reqH)
+
+	logDetail := "clientId: " + reqH.ClientId + " function: ExportWatchList v3 requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// ShareWatchList
+// @Tags space watchlist V3
+// @Description Publish a read-only link to a watchlist that other clients can view and clone
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.ShareWatchListRequest true "watchlist"
+// @Success 200 {object} apihelpers.APIRes{data=models.ShareWatchListResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/watchlist/shareWatchList [POST]
+func ShareWatchList(c *gin.Context) {
+	var shareReq models.ShareWatchListRequest
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	if err := json.NewDecoder(c.Request.Body).Decode(&shareReq); err != nil {
+		loggerconfig.Error("ShareWatchList V3 (controller), error decoding body, error:", err, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+	shareReq.ClientId = strings.ToUpper(shareReq.ClientId)
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(shareReq.ClientId, reqH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("ShareWatchList V3 (controller) CheckAuthWithClient invalid authtoken", " clientId: ", shareReq.ClientId, " requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("ShareWatchList V3 (controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", shareReq.ClientId, "requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	if err := validator.New().Struct(shareReq); err != nil {
+		loggerconfig.Error("ShareWatchList V3 (controller) validation error: ", err, " requestId: ", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	code, resp := watchListProviderV3.ShareWatchList(shareReq, reqH)
+
+	logDetail := "clientId: " + reqH.ClientId + " function: ShareWatchList v3 requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// FetchSharedWatchList
+// @Tags space watchlist V3
+// @Description View the stocks of a watchlist shared by another client
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param shareId query string true "shareId"
+// @Success 200 {object} apihelpers.APIRes{data=models.FetchSharedWatchListResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/watchlist/fetchSharedWatchList [GET]
+func FetchSharedWatchList(c *gin.Context) {
+	fetchReq := models.FetchSharedWatchListRequest{ShareId: c.Query("shareId")}
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	if err := validator.New().Struct(fetchReq); err != nil {
+		loggerconfig.Error("FetchSharedWatchList V3 (controller) validation error: ", err, " requestId: ", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	code, resp := watchListProviderV3.FetchSharedWatchList(fetchReq, reqH)
+
+	logDetail := "clientId: " + reqH.ClientId + " function: FetchSharedWatchList v3 requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// CloneSharedWatchList
+// @Tags space watchlist V3
+// @Description Copy a shared watchlist into one of the client's own watchlists
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.CloneSharedWatchListRequest true "watchlist"
+// @Success 200 {object} apihelpers.APIRes{data=models.CloneSharedWatchListResponse}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/watchlist/cloneSharedWatchList [POST]
+func CloneSharedWatchList(c *gin.Context) {
+	var cloneReq models.CloneSharedWatchListRequest
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	if err := json.NewDecoder(c.Request.Body).Decode(&cloneReq); err != nil {
+		loggerconfig.Error("CloneSharedWatchList V3 (controller), error decoding body, error:", err, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+	cloneReq.ClientId = strings.ToUpper(cloneReq.ClientId)
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(cloneReq.ClientId, reqH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("CloneSharedWatchList V3 (controller) CheckAuthWithClient invalid authtoken", " clientId: ", cloneReq.ClientId, " requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("CloneSharedWatchList V3 (controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", cloneReq.ClientId, "requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	if err := validator.New().Struct(cloneReq); err != nil {
+		loggerconfig.Error("CloneSharedWatchList V3 (controller) validation error: ", err, " requestId: ", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	code, resp := watchListProviderV3.CloneSharedWatchList(cloneReq, reqH)
+
+	logDetail := "clientId: " + reqH.ClientId + " function: CloneSharedWatchList v3 requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// RevokeSharedWatchList
+// @Tags space watchlist V3
+// @Description Disable a watchlist link the client published
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.RevokeSharedWatchListRequest true "watchlist"
+// @Success 200 {object} apihelpers.APIRes
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/watchlist/revokeSharedWatchList [DELETE]
+func RevokeSharedWatchList(c *gin.Context) {
+	var revokeReq models.RevokeSharedWatchListRequest
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	if err := json.NewDecoder(c.Request.Body).Decode(&revokeReq); err != nil {
+		loggerconfig.Error("RevokeSharedWatchList V3 (controller), error decoding body, error:", err, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+	revokeReq.ClientId = strings.ToUpper(revokeReq.ClientId)
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(revokeReq.ClientId, reqH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("RevokeSharedWatchList V3 (controller) CheckAuthWithClient invalid authtoken", " clientId: ", revokeReq.ClientId, " requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("RevokeSharedWatchList V3 (controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", revokeReq.ClientId, "requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	if err := validator.New().Struct(revokeReq); err != nil {
+		loggerconfig.Error("RevokeSharedWatchList V3 (controller) validation error: ", err, " requestId: ", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	code, resp := watchListProviderV3.RevokeSharedWatchList(revokeReq, reqH)
+
+	logDetail := "clientId: " + reqH.ClientId + " function: RevokeSharedWatchList v3 requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// CreateSmartWatchList
+// @Tags space watchlist V3
+// @Description Create a watchlist whose stocks follow a rule: holdings, sector, index gainers, upcoming results or IPOs listing this month
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.CreateSmartWatchListRequest true "watchlist"
+// @Success 200 {object} apihelpers.APIRes{data=models.WatchListsDetailsV2}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/watchlist/createSmartWatchList [POST]
+func CreateSmartWatchList(c *gin.Context) {
+	var createReq models.CreateSmartWatchListRequest
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	if err := json.NewDecoder(c.Request.Body).Decode(&createReq); err != nil {
+		loggerconfig.Error("CreateSmartWatchList V3 (controller), error decoding body, error:", err, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+	createReq.ClientId = strings.ToUpper(createReq.ClientId)
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(createReq.ClientId, reqH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("CreateSmartWatchList V3 (controller) CheckAuthWithClient invalid authtoken", " clientId: ", createReq.ClientId, " requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("CreateSmartWatchList V3 (controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", createReq.ClientId, "requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	if err := validator.New().Struct(createReq); err != nil {
+		loggerconfig.Error("CreateSmartWatchList V3 (controller) validation error: ", err, " requestId: ", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	code, resp := watchListProviderV3.CreateSmartWatchList(createReq, reqH)
+
+	logDetail := "clientId: " + reqH.ClientId + " function: CreateSmartWatchList v3 requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// UpdateSmartWatchList
+// @Tags space watchlist V3
+// @Description Rename a smart watchlist or change its rule, members are evaluated again
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.UpdateSmartWatchListRequest true "watchlist"
+// @Success 200 {object} apihelpers.APIRes{data=models.WatchListsDetailsV2}
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/watchlist/updateSmartWatchList [POST]
+func UpdateSmartWatchList(c *gin.Context) {
+	var updateReq models.UpdateSmartWatchListRequest
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	if err := json.NewDecoder(c.Request.Body).Decode(&updateReq); err != nil {
+		loggerconfig.Error("UpdateSmartWatchList V3 (controller), error decoding body, error:", err, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+	updateReq.ClientId = strings.ToUpper(updateReq.ClientId)
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(updateReq.ClientId, reqH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("UpdateSmartWatchList V3 (controller) CheckAuthWithClient invalid authtoken", " clientId: ", updateReq.ClientId, " requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("UpdateSmartWatchList V3 (controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", updateReq.ClientId, "requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	if err := validator.New().Struct(updateReq); err != nil {
+		loggerconfig.Error("UpdateSmartWatchList V3 (controller) validation error: ", err, " requestId: ", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	code, resp := watchListProviderV3.UpdateSmartWatchList(updateReq, reqH)
+
+	logDetail := "clientId: " + reqH.ClientId + " function: UpdateSmartWatchList v3 requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
+
+// DeleteSmartWatchList
+// @Tags space watchlist V3
+// @Description Delete a smart watchlist
+// @Param ClientId header string true "ClientId"
+// @Param P-DeviceType header string true "P-DeviceType Header"
+// @Param P-Platform header string false "P-Platform Header"
+// @Param P-DeviceId header string false "P-DeviceId Header"
+// @Param Authorization header string true "Authorization Header"
+// @Param P-ClientPublicIP header string false "P-ClientPublicIP Header"
+// @Param request body models.DeleteSmartWatchListRequest true "watchlist"
+// @Success 200 {object} apihelpers.APIRes
+// @Failure 400 {object} apihelpers.APIRes
+// @Failure 403 {object} apihelpers.APIRes
+// @Router /api/space/v3/watchlist/deleteSmartWatchList [DELETE]
+func DeleteSmartWatchList(c *gin.Context) {
+	var deleteReq models.DeleteSmartWatchListRequest
+
+	cRH, _ := c.Get("reqH")
+	reqH, _ := (cRH).(models.ReqHeader)
+
+	if err := json.NewDecoder(c.Request.Body).Decode(&deleteReq); err != nil {
+		loggerconfig.Error("DeleteSmartWatchList V3 (controller), error decoding body, error:", err, " requestId:", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+	deleteReq.ClientId = strings.ToUpper(deleteReq.ClientId)
+	matchStatus, tokenValidStatus := helpers.CheckAuthWithClient(deleteReq.ClientId, reqH.Authorization)
+	if !tokenValidStatus {
+		loggerconfig.Error("DeleteSmartWatchList V3 (controller) CheckAuthWithClient invalid authtoken", " clientId: ", deleteReq.ClientId, " requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.InvalidToken, http.StatusUnauthorized)
+		return
+	}
+	if !matchStatus {
+		loggerconfig.Error("DeleteSmartWatchList V3 (controller) CheckAuthWithClient difference in authtoken-clientId and clientId", " clientId: ", deleteReq.ClientId, "requestId:", reqH.RequestId)
+		apihelpers.SendErrorController(c, false, constants.MismatchAuthClient, http.StatusForbidden)
+		return
+	}
+
+	if err := validator.New().Struct(deleteReq); err != nil {
+		loggerconfig.Error("DeleteSmartWatchList V3 (controller) validation error: ", err, " requestId: ", reqH.RequestId)
+		apihelpers.ErrorMessage(c, constants.InvalidRequest)
+		return
+	}
+
+	code, resp := watchListProviderV3.DeleteSmartWatchList(deleteReq, reqH)
+
+	logDetail := "clientId: " + reqH.ClientId + " function: DeleteSmartWatchList v3 requestId: " + reqH.RequestId
+	apihelpers.CustomResponse(c, code, resp, logDetail)
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"space/models"
+	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -62,11 +63,17 @@ type Database interface {
 	UpdateTransactionData(transactionID string, updates map[string]interface{}) error
 	GetTransactionData(transactionID string) (*models.PayoutDetails, error)
 	CheckExistingPayoutRequest(clientID string) (bool, error)
+	TransitionTransactionStatus(transition models.PayoutStatusHistory, tradelabFundsUpdated bool, backofficeFundsUpdated bool) (bool, error)
+	GetPayoutTransitionByKey(idempotencyKey string) (*models.PayoutStatusHistory, error)
+	GetPayoutStatusHistory(transactionID string) ([]models.PayoutStatusHistory, error)
+	FetchPayoutsSince(since time.Time) ([]models.PayoutDetails, error)
 	InsertPledgeData(pledgeData models.PledgeData) (int64, error)
 	FetchCorporateAnnouncements(req models.FetchCorporateActionsIndividualReq) ([]models.CorporateAnnouncements, error)
 	FetchCorporateAnnouncementsAll(req models.FetchCorporateActionsAllReq) ([]models.CorporateAnnouncements, error)
 	FetchSectorWiseCompanyDataV2(sectorCode []string) ([]models.SectorWiseCompanyV2, error)
 	GetSectorWiseCompanyList(page int, sectorName string) ([]models.SectorWiseCompany, error)
+	FetchScreenerUniverse(frequency string) ([]models.ScreenerStock, error)
+	FetchSectorFinancialSeries(sectorName string) ([]models.SectorFinancialSeries, error)
 }
 
 type MongoDatabase interface {
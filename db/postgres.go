@@ -16,9 +16,12 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// pgUniqueViolation is the SQLSTATE postgres returns when a unique index rejects a write.
+const pgUniqueViolation = "23505"
+
 type Postgres struct {
 	conn *sql.DB
 }
@@ -3620,6 +3623,176 @@ func (pgObj *Postgres) CheckExistingPayoutRequest(clientID string) (bool, error)
 	return count > 0, nil
 }
 
+// TransitionTransactionStatus moves a payout from transition.FromStatus to
+// transition.ToStatus and records the change in payout_status_history in the
+// same statement. The update only matches while the payout is still in
+// FromStatus, so it returns false when another writer got there first. The
+// funds flags are only ever switched on.
+func (pgObj *Postgres) TransitionTransactionStatus(transition models.PayoutStatusHistory, tradelabFundsUpdated bool, backofficeFundsUpdated bool) (bool, error) {
+	queryStatement := `
+	WITH updated AS (
+		UPDATE transaction_info_v2
+		SET transaction_status = $1, updated_at = $2,
+			tradelab_funds_updated = tradelab_funds_updated OR $3,
+			backoffice_funds_updated = backoffice_funds_updated OR $4
+		WHERE transaction_id = $5 AND transaction_status = $6
+		RETURNING transaction_id
+	)
+	INSERT INTO payout_status_history (transaction_id, from_status, to_status, reason, updated_by, idempotency_key, created_at)
+	SELECT transaction_id, $6, $1, $7, $8, $9, $2 FROM updated
+	RETURNING id;`
+
+	rows, err := dbops.PostgresRepo.Insert(queryStatement,
+		transition.ToStatus,
+		transition.CreatedAt,
+		tradelabFundsUpdated,
+		backofficeFundsUpdated,
+		transition.TransactionId,
+		transition.FromStatus,
+		transition.Reason,
+		transition.UpdatedBy,
+		transition.IdempotencyKey,
+	)
+	if err == nil {
+		defer rows.Close()
+		if rows.Next() {
+			return true, nil
+		}
+		err = rows.Err()
+	}
+	if isUniqueViolation(err) {
+		// a replica recorded the same idempotency key first and the whole
+		// statement rolled back, the caller re-reads where the payout ended up
+		loggerconfig.Info("TransitionTransactionStatus idempotency key already used:", transition.IdempotencyKey, " transactionId:", transition.TransactionId)
+		return false, nil
+	}
+	if err != nil {
+		loggerconfig.Error("TransitionTransactionStatus Error while updating data:", err, " transactionId:", transition.TransactionId)
+		return false, err
+	}
+	return false, nil
+}
+
+// EnsurePayoutStatusHistoryIndex makes idempotency keys unique in
+// payout_status_history, so two replicas replaying the same transition cannot
+// both record it. Transitions without a key are left out of the index.
+func (pgObj *Postgres) EnsurePayoutStatusHistoryIndex() error {
+	queryStatement := `
+	CREATE UNIQUE INDEX IF NOT EXISTS payout_status_history_idempotency_key_idx
+	ON payout_status_history (idempotency_key)
+	WHERE idempotency_key <> '';`
+
+	rows, err := dbops.PostgresRepo.Update(queryStatement)
+	if err != nil {
+		loggerconfig.Error("EnsurePayoutStatusHistoryIndex Error while creating index:", err)
+		return err
+	}
+	return rows.Close()
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation
+}
+
+// GetPayoutTransitionByKey returns the transition recorded under an
+// idempotency key, or nil when there is none.
+func (pgObj *Postgres) GetPayoutTransitionByKey(idempotencyKey string) (*models.PayoutStatusHistory, error) {
+	queryStatement := `
+	SELECT transaction_id, from_status, to_status, reason, updated_by, idempotency_key, created_at
+	FROM payout_status_history
+	WHERE idempotency_key = $1;`
+
+	rows, err := dbops.PostgresRepo.Fetch(queryStatement, idempotencyKey)
+	if err != nil {
+		loggerconfig.Error("GetPayoutTransitionByKey Error while executing query:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, nil
+	}
+
+	var transition models.PayoutStatusHistory
+	err = rows.Scan(&transition.TransactionId, &transition.FromStatus, &transition.ToStatus, &transition.Reason, &transition.UpdatedBy, &transition.IdempotencyKey, &transition.CreatedAt)
+	if err != nil {
+		loggerconfig.Error("GetPayoutTransitionByKey Error while scanning row:", err)
+		return nil, err
+	}
+	return &transition, nil
+}
+
+func (pgObj *Postgres) GetPayoutStatusHistory(transactionID string) ([]models.PayoutStatusHistory, error) {
+	queryStatement := `
+	SELECT transaction_id, from_status, to_status, reason, updated_by, idempotency_key, created_at
+	FROM payout_status_history
+	WHERE transaction_id = $1
+	ORDER BY created_at, id;`
+
+	rows, err := dbops.PostgresRepo.Fetch(queryStatement, transactionID)
+	if err != nil {
+		loggerconfig.Error("GetPayoutStatusHistory Error while executing query:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.PayoutStatusHistory{}
+	for rows.Next() {
+		var transition models.PayoutStatusHistory
+		err = rows.Scan(&transition.TransactionId, &transition.FromStatus, &transition.ToStatus, &transition.Reason, &transition.UpdatedBy, &transition.IdempotencyKey, &transition.CreatedAt)
+		if err != nil {
+			loggerconfig.Error("GetPayoutStatusHistory Error while scanning row:", err)
+			return nil, err
+		}
+		history = append(history, transition)
+	}
+	return history, nil
+}
+
+// FetchPayoutsSince returns the payouts created at or after since, oldest first.
+func (pgObj *Postgres) FetchPayoutsSince(since time.Time) ([]models.PayoutDetails, error) {
+	queryStatement := `
+	SELECT amount_in_paisa, client_id, ifsc, customer_account_number, customer_bank_name, debit_credit, tradelab_funds_updated, backoffice_funds_updated, transaction_type, transaction_id, transaction_status, remarks, created_at, updated_at
+	FROM transaction_info_v2
+	WHERE transaction_type = $1 AND created_at >= $2
+	ORDER BY created_at;`
+
+	rows, err := dbops.PostgresRepo.Fetch(queryStatement, constants.Payout, since)
+	if err != nil {
+		loggerconfig.Error("FetchPayoutsSince Error while executing query:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payouts []models.PayoutDetails
+	for rows.Next() {
+		var payoutDetails models.PayoutDetails
+		err = rows.Scan(
+			&payoutDetails.Amount,
+			&payoutDetails.ClientID,
+			&payoutDetails.Ifsc,
+			&payoutDetails.AccountNumber,
+			&payoutDetails.BankName,
+			&payoutDetails.DebitCredit,
+			&payoutDetails.TradelabFundsUpdated,
+			&payoutDetails.BackofficeFundsUpdated,
+			&payoutDetails.TransactionType,
+			&payoutDetails.TransactionId,
+			&payoutDetails.TransactionStatus,
+			&payoutDetails.Remarks,
+			&payoutDetails.CreateDate,
+			&payoutDetails.UpdatedAt,
+		)
+		if err != nil {
+			loggerconfig.Error("FetchPayoutsSince Error while scanning row:", err)
+			return nil, err
+		}
+		payouts = append(payouts, payoutDetails)
+	}
+	return payouts, nil
+}
+
 func (pgObj *Postgres) InsertPledgeData(pledgeData models.PledgeData) (int64, error) {
 	queryStatement := `
 	INSERT INTO pledge_data (client_id, segment_id, timestamp, isin, quantity, price, exchange, bo_id, depository, pledge_unpledge, dp_id, pledge_tls, req_id, version, status)
@@ -4068,3 +4241,119 @@ func (pgObj *Postgres) GetSectorWiseCompanyList(page int, sectorName string) ([]
 
 	return dbResponse, nil
 }
+
+func (pgObj *Postgres) FetchScreenerUniverse(frequency string) ([]models.ScreenerStock, error) {
+	ctx := context.Background()
+	var dbResponse []models.ScreenerStock
+	err := pgObj.conn.PingContext(ctx)
+	if err != nil {
+		loggerconfig.Error("FetchScreenerUniverse Error if database is alive :", err.Error())
+		return dbResponse, err
+	}
+
+	// consolidated ttm figures are preferred over standalone when a company reports both
+	queryStatement := `SELECT DISTINCT ON (CM.cocode) CM.isin, CM.cocode, CM.companyname, CM.nsesymbol, CM.sectorname,
+	TTM.mcap, TTM.pettm, TTM.pbttm, TTM.sectorpe, TTM.roettm, TTM.epsttm, TTM.dividendyield, TTM.bookvalue, TTM.debttoequity,
+	SE.*, PF.*
+	FROM companymaster AS CM
+	INNER JOIN ttmdata AS TTM ON TTM.cocode = CM.cocode
+	LEFT JOIN SmaEma AS SE ON SE.cocode = CM.cocode
+	LEFT JOIN PivotFibonacci AS PF ON PF.cocode = CM.cocode AND PF.frequency = $1
+	WHERE CM.isin IS NOT NULL AND CM.isin <> ''
+	ORDER BY CM.cocode, TTM.typecs;`
+	res, err := dbops.PostgresRepo.Fetch(queryStatement, frequency)
+	if err != nil {
+		loggerconfig.Error("FetchScreenerUniverse Error fetching data:", err.Error())
+		return dbResponse, err
+	}
+	defer res.Close()
+
+	for res.Next() {
+		var row models.ScreenerStock
+		var nseSymbol, sector sql.NullString
+		var seCoCode, pfCoCode sql.NullInt64
+		var rsi, macd, avg20, avg50, avg100, avg200, avg10, ema10, ema20, ema50, macdSignal sql.NullFloat64
+		var currPrice, pivot, s1, s2, s3, r1, r2, r3 sql.NullFloat64
+		var seExchange, pfFrequency, pfCoName, pfCurrTime, pfExchange sql.NullString
+		err = res.Scan(&row.Isin, &row.CoCode, &row.CompanyName, &nseSymbol, &sector,
+			&row.MarketCap, &row.PE, &row.PB, &row.SectorPE, &row.ROE, &row.EPS, &row.DivYield, &row.BookValue, &row.DebtToEquity,
+			&seCoCode, &rsi, &macd, &avg20, &avg50, &avg100, &avg200, &avg10, &ema10, &ema20, &ema50, &macdSignal, &seExchange,
+			&pfCoCode, &pfFrequency, &pfCoName, &currPrice, &pivot, &s1, &s2, &s3, &r1, &r2, &r3, &pfCurrTime, &pfExchange)
+		if err != nil {
+			loggerconfig.Error("FetchScreenerUniverse Error Scan data:", err.Error())
+			return dbResponse, err
+		}
+		row.NseSymbol = nseSymbol.String
+		row.Sector = sector.String
+		row.RSI = rsi.Float64
+		row.MACD = macd.Float64
+		row.MACDSignal = macdSignal.Float64
+		row.SMA10 = avg10.Float64
+		row.SMA20 = avg20.Float64
+		row.SMA50 = avg50.Float64
+		row.SMA100 = avg100.Float64
+		row.SMA200 = avg200.Float64
+		row.EMA10 = ema10.Float64
+		row.EMA20 = ema20.Float64
+		row.EMA50 = ema50.Float64
+		row.Price = currPrice.Float64
+		dbResponse = append(dbResponse, row)
+	}
+
+	if err := res.Err(); err != nil {
+		loggerconfig.Error("FetchScreenerUniverse Error iterating rows:", err.Error())
+		return nil, err
+	}
+
+	return dbResponse, nil
+}
+
+func (pgObj *Postgres) FetchSectorFinancialSeries(sectorName string) ([]models.SectorFinancialSeries, error) {
+	ctx := context.Background()
+	var dbResponse []models.SectorFinancialSeries
+	err := pgObj.conn.PingContext(ctx)
+	if err != nil {
+		loggerconfig.Error("FetchSectorFinancialSeries Error if database is alive :", err.Error())
+		return dbResponse, err
+	}
+
+	queryStatement := `SELECT isin, cocode, typecs, columnname, y0, y1, y2, y3, y4, yrc0, yrc1, yrc2, yrc3, yrc4
+	FROM (
+	  SELECT cm.isin, cm.cocode, pl.typecs AS typecs, columnname, y0, y1, y2, y3, y4, yrc0, yrc1, yrc2, yrc3, yrc4
+	  FROM plstatement pl
+	  INNER JOIN companymaster cm ON pl.cocode = cm.cocode
+	  INNER JOIN plstatement_yrc_mapping plsyrc ON (plsyrc.cocode,plsyrc.typecs)=(pl.cocode,pl.typecs)
+	  WHERE cm.sectorname = $1
+	  AND columnname IN ('Profit After Tax', 'Total Revenue')
+
+	  UNION ALL
+
+	  SELECT cm.isin, cm.cocode, bs.typecs AS typecs, columnname, y0, y1, y2, y3, y4, yrc0, yrc1, yrc2, yrc3, yrc4
+	  FROM balancesheets bs
+	  INNER JOIN companymaster cm ON bs.cocode = cm.cocode
+	  INNER JOIN balancesheets_yrc_mapping bsyrc ON (bsyrc.cocode,bsyrc.typecs)=(bs.cocode,bs.typecs)
+	  WHERE cm.sectorname = $1
+	  AND columnname IN ('Total Equity','TOTAL ASSETS')
+	) AS subquery
+	WHERE isin IS NOT NULL AND isin <> ''
+	ORDER BY cocode, columnname;`
+	res, err := dbops.PostgresRepo.Fetch(queryStatement, sectorName)
+	if err != nil {
+		loggerconfig.Error("FetchSectorFinancialSeries Error fetching data:", err.Error())
+		return dbResponse, err
+	}
+	defer res.Close()
+
+	for res.Next() {
+		var row models.SectorFinancialSeries
+		err = res.Scan(&row.Isin, &row.CoCode, &row.TypeCS, &row.ColumnName, &row.Y0, &row.Y1, &row.Y2, &row.Y3, &row.Y4, &row.Yrc0, &row.Yrc1, &row.Yrc2, &row.Yrc3, &row.Yrc4)
+		if err != nil {
+			loggerconfig.Error("FetchSectorFinancialSeries Error Scan data:", err.Error())
+			continue
+		}
+		dbResponse = append(dbResponse, row)
+	}
+
+	loggerconfig.Info("FetchSectorFinancialSeries Successful, rows:", len(dbResponse))
+	return dbResponse, nil
+}
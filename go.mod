@@ -34,6 +34,7 @@ require (
 	github.com/redis/go-redis/v9 v9.5.1
 	github.com/streadway/amqp v1.1.0
 	github.com/tealeg/xlsx/v3 v3.3.4
+	golang.org/x/sync v0.5.0
 )
 
 require (
@@ -109,7 +110,6 @@ require (
 	golang.org/x/lint v0.0.0-20210508222113-6edffad5e616 // indirect
 	golang.org/x/mod v0.14.0 // indirect
 	golang.org/x/net v0.19.0 // indirect
-	golang.org/x/sync v0.5.0 // indirect
 	golang.org/x/sys v0.15.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	golang.org/x/tools v0.16.1 // indirect
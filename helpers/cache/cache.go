@@ -38,6 +38,11 @@ func (r *RedisClient) SetRedis(key string, val string, timeInMins time.Duration)
 	return r.Client.Set(context.Background(), key, val, time.Minute*timeInMins).Err()
 }
 
+// SetRedisNX sets key only if it does not exist yet and reports whether it did.
+func (r *RedisClient) SetRedisNX(key string, val string, timeInMins time.Duration) (bool, error) {
+	return r.Client.SetNX(context.Background(), key, val, time.Minute*timeInMins).Result()
+}
+
 func (r *RedisClient) GetRedis(key string) *redis.StringCmd {
 	return r.Client.Get(context.Background(), key)
 }
@@ -54,6 +59,10 @@ func (r *RedisClient) Incr(key string) *redis.IntCmd {
 	return r.Client.Incr(context.Background(), key)
 }
 
+func (r *RedisClient) Expire(key string, timeInMins time.Duration) error {
+	return r.Client.Expire(context.Background(), key, time.Minute*timeInMins).Err()
+}
+
 func (r *RedisClient) HSetField(key string, field string, value string) error {
 	return r.OrderClient.HSet(context.Background(), key, field, value).Err()
 }
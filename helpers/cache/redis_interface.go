@@ -8,10 +8,12 @@ import (
 
 type RedisCache interface {
 	SetRedis(key string, val string, timeInMins time.Duration) error
+	SetRedisNX(key string, val string, timeInMins time.Duration) (bool, error)
 	GetRedis(key string) *redis.StringCmd
 	DelRedis(uemail string) *redis.IntCmd
 	Exists(key string) *redis.IntCmd
 	Incr(key string) *redis.IntCmd
+	Expire(key string, timeInMins time.Duration) error
 	HSetField(key string, field string, value string) error
 	HGetField(key string, field string) (string, error)
 	Close() error
@@ -46,6 +48,13 @@ type SmartCache interface {
 	InitSmartCache() error
 	GetStatusRedisSmartCache() error
 	PerformNewSearch(exchange, searchTerm string, offset, capacity int, fuzzy bool) ([]string, error)
+	PerformScoredSearch(exchange, searchTerm string, offset, capacity int, fuzzy bool) ([]SearchHit, error)
 	ExecFTCommand(args []interface{}) ([]interface{}, error)
 	GetFromHashSetNew(hash, key string) (string, error)
 }
+
+// SearchHit is a stocks index match with the traded volume it was indexed with.
+type SearchHit struct {
+	StockKey string
+	Volume   float64
+}
@@ -62,8 +62,20 @@ func (c *SmartCacheRedisClient) GetStatusRedisSmartCache() error {
 	return nil
 }
 
+// PerformNewSearch returns the stock keys matching searchTerm in index order.
 func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, offset, capacity int, fuzzy bool) ([]string, error) {
-	keys := make([]string, 0)
+	hits, err := c.PerformScoredSearch(exchange, searchTerm, offset, capacity, fuzzy)
+	keys := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		keys = append(keys, hit.StockKey)
+	}
+	return keys, err
+}
+
+// PerformScoredSearch runs the same queries as PerformNewSearch but keeps the
+// traded volume of every document so callers can rank on it.
+func (c *SmartCacheRedisClient) PerformScoredSearch(exchange, searchTerm string, offset, capacity int, fuzzy bool) ([]SearchHit, error) {
+	hits := make([]SearchHit, 0)
 
 	if exchange != "" {
 
@@ -78,7 +90,7 @@ func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, of
 
 		if err != nil {
 			logrus.Infof("error searching via tradingSymbol -%v\n", err)
-			return keys, err
+			return hits, err
 		}
 
 		if total < capacity {
@@ -93,7 +105,7 @@ func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, of
 
 			if err != nil {
 				logrus.Infof("error searching via tradingSymbol -%v\n", err)
-				return keys, err
+				return hits, err
 			}
 
 			docsTradingSymbol = append(docsTradingSymbol, docsNew...)
@@ -107,7 +119,7 @@ func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, of
 			// 	mapOfKeys[doc.Properties["stockKey"].(string)] = atoi(vol)
 			// }
 
-			keys = append(keys, doc.Properties["stockKey"].(string))
+			hits = append(hits, searchHitFromDoc(doc))
 		}
 
 	} else {
@@ -126,7 +138,7 @@ func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, of
 
 			if err != nil {
 				logrus.Infof("error searching via nse fuzzy -%v\n", err)
-				return keys, err
+				return hits, err
 			}
 
 			for _, doc := range docsNSE {
@@ -136,7 +148,7 @@ func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, of
 				// 	mapOfKeys[doc.Properties["stockKey"].(string)] = atoi(vol)
 				// }
 
-				keys = append(keys, doc.Properties["stockKey"].(string))
+				hits = append(hits, searchHitFromDoc(doc))
 			}
 
 			valBSE := "@exchange:BSE (@tradingSymbol:%%" + searchTerm + "%%)|(@symbol:%%" + searchTerm + "%%)|(@name:%%" + searchTerm + "%%)"
@@ -150,7 +162,7 @@ func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, of
 
 			if err != nil {
 				logrus.Infof("error searching via bse fuzzy -%v\n", err)
-				return keys, err
+				return hits, err
 			}
 
 			for _, doc := range docsBSE {
@@ -160,7 +172,7 @@ func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, of
 				// 	mapOfKeys[doc.Properties["stockKey"].(string)] = atoi(vol)
 				// }
 
-				keys = append(keys, doc.Properties["stockKey"].(string))
+				hits = append(hits, searchHitFromDoc(doc))
 			}
 
 		} else {
@@ -187,15 +199,15 @@ func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, of
 
 				if err != nil {
 					logrus.Errorf("error querying searchTerm %v\n", err)
-					return keys, err
+					return hits, err
 				}
 				for _, doc := range docs {
 					fmt.Printf("Name: %s, Symbol: %s, Trading Symbol: %s, Strike: %s, key: %s\n",
 						doc.Properties["name"], doc.Properties["symbol"],
 						doc.Properties["tradingSymbol"], doc.Properties["strike"], doc.Properties["stockKey"])
-					keys = append(keys, doc.Properties["stockKey"].(string))
+					hits = append(hits, searchHitFromDoc(doc))
 				}
-				return keys, nil
+				return hits, nil
 			}
 			logrus.Infof("queryNormal  -%v\n", val)
 			query := redisearch.NewQuery(val).
@@ -213,7 +225,7 @@ func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, of
 
 			if err != nil {
 				logrus.Errorf("error querying searchTerm %v\n", err)
-				return keys, err
+				return hits, err
 			}
 
 			if total < capacity {
@@ -229,7 +241,7 @@ func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, of
 
 				if err != nil {
 					logrus.Errorf("error querying searchTerm %v\n", err)
-					return keys, err
+					return hits, err
 				}
 
 				docs = append(docs, docsNew...)
@@ -240,11 +252,21 @@ func (c *SmartCacheRedisClient) PerformNewSearch(exchange, searchTerm string, of
 			fmt.Printf("Name: %s, Symbol: %s, Trading Symbol: %s, Strike: %s, key: %s\n",
 				doc.Properties["name"], doc.Properties["symbol"],
 				doc.Properties["tradingSymbol"], doc.Properties["strike"], doc.Properties["stockKey"])
-			keys = append(keys, doc.Properties["stockKey"].(string))
+			hits = append(hits, searchHitFromDoc(doc))
 		}
 	}
 
-	return keys, nil
+	return hits, nil
+}
+
+// searchHitFromDoc reads the stock key and volume off a stocks index document.
+func searchHitFromDoc(doc redisearch.Document) SearchHit {
+	hit := SearchHit{}
+	hit.StockKey, _ = doc.Properties["stockKey"].(string)
+	if vol, ok := doc.Properties["volume"].(string); ok {
+		hit.Volume, _ = strconv.ParseFloat(vol, 64)
+	}
+	return hit
 }
 
 func atoi(val string) int {
@@ -24,8 +24,9 @@ type Claims struct {
 
 func ValidateToken(token string) (string, error) { //TODO: use claims.OmneManagerID for gm1, gm2, gm3. gm4
 
+	keys := currentJwtKeys()
 	tokens, err := jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(constants.SECRET_KEY), nil
+		return keys.verifyingKey(token, GetCurrentTimeInIST())
 	})
 
 	if err != nil {
@@ -58,8 +59,12 @@ func GenerateJWT(userId string) (string, error) {
 	atClaims["iat"] = iat
 	atClaims["subject"] = userId
 	atClaims["exp"] = exp.Unix()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, atClaims)
-	tokenString, err := token.SignedString([]byte(constants.SECRET_KEY))
+	active := currentJwtKeys().active
+	token := jwt.NewWithClaims(active.method, atClaims)
+	if active.kid != "" {
+		token.Header["kid"] = active.kid
+	}
+	tokenString, err := token.SignedString(active.signKey)
 	if err != nil {
 		log.Printf("Error in JWT token generation=%v\n", err)
 		return "", err
@@ -0,0 +1,213 @@
+package helpers
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"space/constants"
+	"space/models"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+type jwtKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	retireAt  time.Time
+}
+
+type jwtKeySet struct {
+	active *jwtKey
+	keys   map[string]*jwtKey
+	// tokens without a kid are checked with the legacy secret while this is
+	// set, for good when legacy is also the active key
+	legacy      *jwtKey
+	legacyUntil time.Time
+}
+
+var legacyJwtKey = &jwtKey{
+	method:    jwt.SigningMethodHS512,
+	signKey:   []byte(constants.SECRET_KEY),
+	verifyKey: []byte(constants.SECRET_KEY),
+}
+
+var (
+	jwtKeysMu sync.RWMutex
+	jwtKeys   = &jwtKeySet{active: legacyJwtKey, keys: map[string]*jwtKey{}, legacy: legacyJwtKey}
+)
+
+func currentJwtKeys() *jwtKeySet {
+	jwtKeysMu.RLock()
+	defer jwtKeysMu.RUnlock()
+	return jwtKeys
+}
+
+/*
+InitJwtKeys replaces the keyset tokens are signed and verified with. Without
+any keys configured the legacy HS512 secret keeps signing. The keyset in use
+is left as it was when the config is invalid.
+*/
+func InitJwtKeys(config models.JwtKeysConfig) error {
+	set, err := parseJwtKeys(config)
+	if err != nil {
+		return err
+	}
+	jwtKeysMu.Lock()
+	jwtKeys = set
+	jwtKeysMu.Unlock()
+	return nil
+}
+
+func parseJwtKeys(config models.JwtKeysConfig) (*jwtKeySet, error) {
+	if len(config.Keys) == 0 {
+		return &jwtKeySet{active: legacyJwtKey, keys: map[string]*jwtKey{}, legacy: legacyJwtKey}, nil
+	}
+
+	set := &jwtKeySet{keys: map[string]*jwtKey{}}
+	for _, keyConfig := range config.Keys {
+		if keyConfig.Kid == "" {
+			return nil, errors.New("jwt key without kid")
+		}
+		if _, ok := set.keys[keyConfig.Kid]; ok {
+			return nil, fmt.Errorf("duplicate jwt kid %s", keyConfig.Kid)
+		}
+		key, err := parseJwtKey(keyConfig)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %v", keyConfig.Kid, err)
+		}
+		set.keys[key.kid] = key
+	}
+
+	active, ok := set.keys[config.ActiveKid]
+	if !ok || active.signKey == nil {
+		return nil, fmt.Errorf("active jwt kid %q has no private key", config.ActiveKid)
+	}
+	set.active = active
+
+	if config.LegacyUntil != "" {
+		until, err := time.Parse(time.RFC3339, config.LegacyUntil)
+		if err != nil {
+			return nil, fmt.Errorf("jwt legacyUntil: %v", err)
+		}
+		set.legacy, set.legacyUntil = legacyJwtKey, until
+	}
+	return set, nil
+}
+
+func parseJwtKey(config models.JwtKeyConfig) (*jwtKey, error) {
+	key := &jwtKey{kid: config.Kid}
+	if config.RetireAt != "" {
+		retireAt, err := time.Parse(time.RFC3339, config.RetireAt)
+		if err != nil {
+			return nil, fmt.Errorf("retireAt: %v", err)
+		}
+		key.retireAt = retireAt
+	}
+
+	switch config.Alg {
+	case jwt.SigningMethodRS256.Alg():
+		key.method = jwt.SigningMethodRS256
+		if config.PrivateKey != "" {
+			private, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(config.PrivateKey))
+			if err != nil {
+				return nil, err
+			}
+			key.signKey, key.verifyKey = private, &private.PublicKey
+		}
+		if config.PublicKey != "" {
+			public, err := jwt.ParseRSAPublicKeyFromPEM([]byte(config.PublicKey))
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = public
+		}
+	case jwt.SigningMethodEdDSA.Alg():
+		key.method = jwt.SigningMethodEdDSA
+		if config.PrivateKey != "" {
+			private, err := jwt.ParseEdPrivateKeyFromPEM([]byte(config.PrivateKey))
+			if err != nil {
+				return nil, err
+			}
+			key.signKey, key.verifyKey = private, private.(ed25519.PrivateKey).Public()
+		}
+		if config.PublicKey != "" {
+			public, err := jwt.ParseEdPublicKeyFromPEM([]byte(config.PublicKey))
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = public
+		}
+	default:
+		return nil, fmt.Errorf("unsupported alg %q", config.Alg)
+	}
+
+	if key.verifyKey == nil {
+		return nil, errors.New("no private or public key")
+	}
+	return key, nil
+}
+
+// verifyingKey finds the key a token names in its kid header, refusing keys
+// that have retired and signing methods other than the key's own.
+func (set *jwtKeySet) verifyingKey(token *jwt.Token, now time.Time) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	var key *jwtKey
+	if kid == "" {
+		if set.legacy == nil || (!set.legacyUntil.IsZero() && !now.Before(set.legacyUntil)) {
+			return nil, errors.New("token without kid is no longer accepted")
+		}
+		key = set.legacy
+	} else {
+		key = set.keys[kid]
+		if key == nil {
+			return nil, fmt.Errorf("unknown kid %s", kid)
+		}
+		if !key.retireAt.IsZero() && !now.Before(key.retireAt) {
+			return nil, fmt.Errorf("kid %s has retired", kid)
+		}
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.verifyKey, nil
+}
+
+// FetchJwks returns the public keys of the keyset that have not retired. The
+// legacy secret is never published.
+func FetchJwks() models.Jwks {
+	set := currentJwtKeys()
+	now := GetCurrentTimeInIST()
+
+	jwks := models.Jwks{Keys: []models.Jwk{}}
+	for _, key := range set.keys {
+		if !key.retireAt.IsZero() && !now.Before(key.retireAt) {
+			continue
+		}
+		jwk := models.Jwk{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
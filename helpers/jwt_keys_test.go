@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/models"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func testJwtPems(t *testing.T) (string, string, string) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateDer, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDer, err := x509.MarshalPKIXPublicKey(edPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPem := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDer})
+	edPublicPem := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer})
+	return string(rsaPem), string(edPem), string(edPublicPem)
+}
+
+func TestJwtKeyRotation(t *testing.T) {
+	origKeys := jwtKeys
+	t.Cleanup(func() { jwtKeys = origKeys })
+	rsaPem, edPem, edPublicPem := testJwtPems(t)
+	now := GetCurrentTimeInIST()
+
+	legacyToken, err := GenerateJWT("guest1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub, err := ValidateToken(legacyToken); err != nil || sub != "guest1" {
+		t.Fatalf("legacy token = %q, %v", sub, err)
+	}
+
+	// the new key is published alongside the old before it signs anything
+	config := models.JwtKeysConfig{
+		ActiveKid:   "2026-09",
+		LegacyUntil: now.Add(time.Hour).Format(time.RFC3339),
+		Keys: []models.JwtKeyConfig{
+			{Kid: "2026-09", Alg: "RS256", PrivateKey: rsaPem},
+			{Kid: "2026-10", Alg: "EdDSA", PrivateKey: edPem},
+		},
+	}
+	if err := InitJwtKeys(config); err != nil {
+		t.Fatal(err)
+	}
+	oldToken, _ := GenerateJWT("guest2")
+	if header, _ := jwt.Parse(oldToken, nil); header == nil || header.Header["kid"] != "2026-09" || header.Header["alg"] != "RS256" {
+		t.Fatalf("token header = %+v", header)
+	}
+	jwks := FetchJwks()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kty != "RSA" || jwks.Keys[0].E != "AQAB" || jwks.Keys[1].Kty != "OKP" || jwks.Keys[1].Crv != "Ed25519" {
+		t.Fatalf("jwks = %+v", jwks)
+	}
+
+	// rotate: the new key signs while tokens of the old one stay valid
+	config.ActiveKid = "2026-10"
+	if err := InitJwtKeys(config); err != nil {
+		t.Fatal(err)
+	}
+	newToken, _ := GenerateJWT("guest3")
+	for token, want := range map[string]string{legacyToken: "guest1", oldToken: "guest2", newToken: "guest3"} {
+		if sub, err := ValidateToken(token); err != nil || sub != want {
+			t.Errorf("ValidateToken() during overlap = %q, %v, want %s", sub, err, want)
+		}
+	}
+
+	// retire the old key and the legacy secret, keeping only the public half
+	// of the new key on this instance
+	config.Keys = []models.JwtKeyConfig{
+		{Kid: "2026-09", Alg: "RS256", PrivateKey: rsaPem, RetireAt: now.Add(-time.Minute).Format(time.RFC3339)},
+		{Kid: "2026-10", Alg: "EdDSA", PrivateKey: edPem, PublicKey: edPublicPem},
+	}
+	config.LegacyUntil = now.Add(-time.Minute).Format(time.RFC3339)
+	if err := InitJwtKeys(config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(oldToken); err == nil {
+		t.Errorf("token of a retired key was accepted")
+	}
+	if _, err := ValidateToken(legacyToken); err == nil {
+		t.Errorf("legacy token was accepted after legacyUntil")
+	}
+	if sub, err := ValidateToken(newToken); err != nil || sub != "guest3" {
+		t.Errorf("ValidateToken() after retiring = %q, %v", sub, err)
+	}
+	if jwks := FetchJwks(); len(jwks.Keys) != 1 || jwks.Keys[0].Kid != "2026-10" {
+		t.Errorf("jwks after retiring = %+v", jwks)
+	}
+
+	// a token naming a key must be signed with that key's algorithm
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{"subject": "guest4", "exp": now.Add(time.Hour).Unix()})
+	forged.Header["kid"] = "2026-10"
+	forgedToken, _ := forged.SignedString([]byte(constants.SECRET_KEY))
+	if _, err := ValidateToken(forgedToken); err == nil {
+		t.Errorf("token signed with another algorithm was accepted")
+	}
+}
+
+func TestInitJwtKeysInvalid(t *testing.T) {
+	origKeys := jwtKeys
+	t.Cleanup(func() { jwtKeys = origKeys })
+	rsaPem, edPem, edPublicPem := testJwtPems(t)
+
+	tests := map[string]models.JwtKeysConfig{
+		"unknown alg":    {ActiveKid: "a", Keys: []models.JwtKeyConfig{{Kid: "a", Alg: "HS256", PrivateKey: rsaPem}}},
+		"missing kid":    {ActiveKid: "", Keys: []models.JwtKeyConfig{{Alg: "RS256", PrivateKey: rsaPem}}},
+		"duplicate kid":  {ActiveKid: "a", Keys: []models.JwtKeyConfig{{Kid: "a", Alg: "RS256", PrivateKey: rsaPem}, {Kid: "a", Alg: "EdDSA", PrivateKey: edPem}}},
+		"public active":  {ActiveKid: "a", Keys: []models.JwtKeyConfig{{Kid: "a", Alg: "EdDSA", PublicKey: edPublicPem}}},
+		"unknown active": {ActiveKid: "b", Keys: []models.JwtKeyConfig{{Kid: "a", Alg: "RS256", PrivateKey: rsaPem}}},
+		"wrong pem":      {ActiveKid: "a", Keys: []models.JwtKeyConfig{{Kid: "a", Alg: "RS256", PrivateKey: edPem}}},
+		"bad retireAt":   {ActiveKid: "a", Keys: []models.JwtKeyConfig{{Kid: "a", Alg: "RS256", PrivateKey: rsaPem, RetireAt: "tomorrow"}}},
+	}
+	for name, config := range tests {
+		if err := InitJwtKeys(config); err == nil {
+			t.Errorf("%s: InitJwtKeys() accepted an invalid keyset", name)
+		}
+	}
+	if currentJwtKeys().active != legacyJwtKey {
+		t.Errorf("an invalid keyset replaced the one in use")
+	}
+}
@@ -91,6 +91,17 @@ func UploadToS3(key string, body io.Reader) (error, string) {
 
 func UploadFileToS3AndGetPresignedURL(folderName string, fileName string, file *xlsx.File, expiryHours int64) (string, error) {
 
+	// Convert *xlsx.File to bytes
+	var buffer bytes.Buffer
+	if err := file.Write(&buffer); err != nil {
+		return "", fmt.Errorf("failed to write XLSX file to buffer: %v", err)
+	}
+
+	return UploadBytesToS3AndGetPresignedURL(folderName, fileName, buffer.Bytes(), expiryHours)
+}
+
+func UploadBytesToS3AndGetPresignedURL(folderName string, fileName string, body []byte, expiryHours int64) (string, error) {
+
 	// Create a new AWS session using the default credential chain
 	if awsSession == nil {
 		if err := StartAwsSession(); err != nil {
@@ -104,21 +115,15 @@ func UploadFileToS3AndGetPresignedURL(folderName string, fileName string, file *
 	// Specify the object key
 	objectKey := fmt.Sprintf("%s/%s", folderName, fileName)
 
-	// Convert *xlsx.File to bytes
-	var buffer bytes.Buffer
-	if err := file.Write(&buffer); err != nil {
-		return "", fmt.Errorf("failed to write XLSX file to buffer: %v", err)
-	}
-
 	// Perform the actual upload to S3
 	uploader := s3manager.NewUploaderWithClient(s3Client)
 	_, err := uploader.Upload(&s3manager.UploadInput{
 		Bucket: aws.String(constants.AWSBucketName),
 		Key:    aws.String(objectKey),
-		Body:   bytes.NewReader(buffer.Bytes()),
+		Body:   bytes.NewReader(body),
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to upload XLSX file to S3: %v", err)
+		return "", fmt.Errorf("failed to upload %s to S3: %v", fileName, err)
 	}
 
 	// Generate a pre-signed URL for the object
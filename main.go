@@ -5,7 +5,13 @@ import (
 	"log"
 	"os"
 	"space/base"
+	"space/business/admins"
 	srv "space/business/blockdeals"
+	"space/business/funds"
+	"space/business/reports"
+	searchscriptv2 "space/business/searchScriptV2"
+	"space/business/tradelab"
+	"space/business/watchlists"
 	"space/constants"
 	"space/db"
 	"space/dbops"
@@ -56,8 +62,6 @@ func main() {
 		fmt.Print(e)
 	}
 
-	r := routers.SetupRouter()
-
 	port := os.Getenv("port")
 
 	// For run on requested port
@@ -88,6 +92,8 @@ func main() {
 
 	loggerconfig.LogrusInitialize()
 
+	r := routers.SetupRouter()
+
 	factory := dbops.NewDatabaseConnectorFactory()
 	redisUrl := loggerconfig.GetConfig().GetString("config.normal." + env + ".redisUrl")
 	if len(redisUrl) == 0 || !strings.Contains(redisUrl, ":") {
@@ -251,6 +257,9 @@ func main() {
 		loggerconfig.Panic("Alert Severity:P0-Critical, unable to init mongo client=", err)
 	}
 
+	// admins saved before roles existed get a role
+	admins.BootstrapAdminRoles()
+
 	// utils.GetLoggerObj(utils.LOG_FILE_NAME)
 
 	elasticApmName := os.Getenv(constants.ElasticApmServiceName)
@@ -314,9 +323,43 @@ func main() {
 	waitTime := loggerconfig.GetConfig().GetInt("config.normal." + env + ".reconnectwait")
 	constants.LatencyThresholdLow = loggerconfig.GetConfig().GetInt64("config.normal." + env + ".latencyThresholdLow")
 	constants.LatencyThresholdHigh = loggerconfig.GetConfig().GetInt64("config.normal." + env + ".latencyThresholdHigh")
+	constants.UpiGateway = loggerconfig.GetConfig().GetString("config.normal." + env + ".upiGateway")
+	funds.InitUpiGateways(env)
 
 	go health.CheckConnection(env, attempts, waitTime)
 
+	// fallback index for scrip search while RediSearch is unavailable
+	go searchscriptv2.RefreshLocalSearchIndex(contractCacheClient)
+
+	// scheduled re-evaluation of smart watchlist market rules
+	go watchlists.RefreshSmartWatchLists(contractCacheClient)
+
+	// app tokens are looked up on every bearer request that misses the grant cache
+	if err := tradelab.EnsureAppTokenIndex(); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, unable to ensure app token index=", err)
+	}
+
+	// IPO application status polling
+	go tradelab.RefreshIpoApplications()
+
+	// IPO subscription snapshots during the bid window
+	go tradelab.RecordIpoSubscriptions()
+
+	// payout reconciliation against Tradelab and the backoffice ledger
+	if err := db.GetPgObj().EnsurePayoutStatusHistoryIndex(); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, unable to ensure payout status history index=", err)
+	}
+	go funds.ReconcilePayouts(funds.InitFundsV3(db.GetPgObj(), redisClient))
+
+	// background report generation for submitted report jobs
+	if err := reports.EnsureReportJobIndexes(); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, unable to ensure report job indexes=", err)
+	}
+	go reports.RunReportJobs(reports.InitReportsProvider(redisClient))
+
+	// recurring statement emails for subscribed clients
+	go reports.RunStatementSchedule(reports.InitReportsProvider(redisClient))
+
 	if port == "" {
 		port = "8082" //localhost
 	}
@@ -0,0 +1,100 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"space/business/admins"
+	"space/constants"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+)
+
+// adminRoutePermissions maps the routes behind AdminMiddleware to the
+// permission an admin's role needs to call them. A route that is not listed
+// is open to superAdmin only.
+var adminRoutePermissions = map[string]string{
+	"/api/space/v1/adminapis/createPockets":   constants.AdminPermPocketsPublish,
+	"/api/space/v1/adminapis/modifyPockets":   constants.AdminPermPocketsEdit,
+	"/api/space/v1/adminapis/deletePockets":   constants.AdminPermPocketsPublish,
+	"/api/space/v1/adminapis/fetchPockets":    constants.AdminPermPocketsRead,
+	"/api/space/v1/adminapis/fetchAllPockets": constants.AdminPermPocketsRead,
+
+	"/api/space/v1/adminapis/createCollections":   constants.AdminPermCollectionsWrite,
+	"/api/space/v1/adminapis/modifyCollections":   constants.AdminPermCollectionsWrite,
+	"/api/space/v1/adminapis/deleteCollections":   constants.AdminPermCollectionsWrite,
+	"/api/space/v1/adminapis/fetchCollections":    constants.AdminPermCollectionsRead,
+	"/api/space/v1/adminapis/fetchAllCollections": constants.AdminPermCollectionsRead,
+
+	"/api/space/v1/adminapis/createAdmin":     constants.AdminPermAdminsManage,
+	"/api/space/v1/adminapis/updateAdmin":     constants.AdminPermAdminsManage,
+	"/api/space/v1/adminapis/fetchAdmins":     constants.AdminPermAdminsManage,
+	"/api/space/v1/adminapis/fetchAdminAudit": constants.AdminPermAdminsManage,
+
+	"/api/space/v3/adminapis/funds/transitionPayout":   constants.AdminPermFundsWrite,
+	"/api/space/v3/adminapis/funds/fetchPayoutHistory": constants.AdminPermFundsRead,
+
+	"/api/v1/blockdeals/create":  constants.AdminPermBlockDealsWrite,
+	"/api/v1/blockdeals/bulk":    constants.AdminPermBlockDealsWrite,
+	"/api/v1/blockdeals/:cocode": constants.AdminPermBlockDealsWrite,
+}
+
+func adminRoutePermission(fullPath string) string {
+	return adminRoutePermissions[strings.TrimSuffix(fullPath, "/")]
+}
+
+// adminClientReadRoutes are the POST routes behind UserAuthentication that
+// only read the client's data.
+var adminClientReadRoutes = map[string]bool{
+	"/api/space/v1/orderapis/pendingOrder":           true,
+	"/api/space/v1/orderapis/completedOrder":         true,
+	"/api/space/v1/orderapis/tradeBook":              true,
+	"/api/space/v1/orderapis/orderHistory":           true,
+	"/api/space/v1/orderapis/fetchGTTOrder":          true,
+	"/api/space/v1/portfolioapis/fetchDematHoldings": true,
+	"/api/space/v1/portfolioapis/getPositions":       true,
+	"/api/space/v1/pockets/fetchPocketPortfolio":     true,
+	"/api/space/v2/pockets/fetchPocketPortfolio":     true,
+	"/api/space/v3/pockets/fetchPocketPortfolio":     true,
+}
+
+// adminClientPermission is what an admin acting on a client's route needs:
+// reading is support, anything else acts for the client.
+func adminClientPermission(method, fullPath string) string {
+	if method == http.MethodGet || adminClientReadRoutes[strings.TrimSuffix(fullPath, "/")] {
+		return constants.AdminPermClientSupport
+	}
+	return constants.AdminPermClientWrite
+}
+
+/*
+authenticateAdmin finds the named admin an admin token was issued to and
+checks their role grants the permission. On failure it returns the status
+and error code to reject the request with.
+*/
+func authenticateAdmin(token, permission, requestId string) (models.MongoAdmin, int, string) {
+	sub, err := helpers.ValidateToken(token)
+	if err != nil {
+		return models.MongoAdmin{}, http.StatusForbidden, constants.InvalidToken
+	}
+
+	admin, err := admins.CallFetchAdmin(sub)
+	if err != nil {
+		if err.Error() == constants.MongoNoDocError {
+			loggerconfig.Info("authenticateAdmin, no admin for token subject:", sub, " requestId:", requestId)
+			return admin, http.StatusForbidden, constants.InvalidToken
+		}
+		loggerconfig.Error("authenticateAdmin, error finding admin:", err, " userId:", sub, " requestId:", requestId)
+		return admin, http.StatusInternalServerError, constants.InternalServerError
+	}
+
+	if admin.Disabled {
+		return admin, http.StatusForbidden, constants.AdminDisabled
+	}
+	if !admins.RoleHasPermission(admin.Role, permission) {
+		loggerconfig.Info("authenticateAdmin, role lacks permission:", permission, " role:", admin.Role, " userId:", admin.UserId, " requestId:", requestId)
+		return admin, http.StatusForbidden, constants.AdminPermissionDenied
+	}
+	return admin, http.StatusOK, ""
+}
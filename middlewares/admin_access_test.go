@@ -0,0 +1,140 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apihelpers "space/apiHelpers"
+	"space/business/admins"
+	"space/constants"
+	"space/helpers"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func adminAccessRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	ok := func(c *gin.Context) {
+		cRH, _ := c.Get("reqH")
+		reqH, _ := (cRH).(models.ReqHeader)
+		c.String(http.StatusOK, reqH.AdminUserId)
+	}
+	admin := r.Group("/api/space/v1/adminapis/")
+	admin.Use(AdminMiddleware())
+	admin.POST("/modifyPockets", ok)
+	admin.POST("/deletePockets", ok)
+	admin.POST("/unlistedRoute", ok)
+
+	user := r.Group("/api/space/v1/pockets")
+	user.Use(UserAuthentication())
+	user.POST("/fetchPocketPortfolio", ok)
+	user.POST("/createPockets", ok)
+
+	order := r.Group("/api/space/v1/orderapis")
+	order.Use(UserAuthentication())
+	order.POST("/placeOrder", ok)
+	order.GET("/fetchOrders", ok)
+	return r
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	origFetch, origKey := admins.CallFetchAdmin, constants.AdminSecretKey
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		admins.CallFetchAdmin, constants.AdminSecretKey = origFetch, origKey
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+
+	stored := map[string]models.MongoAdmin{
+		"root":     {UserId: "root", Role: constants.AdminRoleSuper},
+		"editor1":  {UserId: "editor1", Role: constants.AdminRolePocketEditor},
+		"support1": {UserId: "support1", Role: constants.AdminRoleSupportReadOnly},
+		"legacy1":  {UserId: "legacy1"},
+		"gone1":    {UserId: "gone1", Role: constants.AdminRoleSuper, Disabled: true},
+	}
+	admins.CallFetchAdmin = func(userId string) (models.MongoAdmin, error) {
+		admin, ok := stored[userId]
+		if !ok {
+			return admin, errors.New(constants.MongoNoDocError)
+		}
+		return admin, nil
+	}
+	r := adminAccessRouter()
+
+	tests := []struct {
+		userId string
+		path   string
+		code   int
+		err    string
+	}{
+		{"editor1", "/api/space/v1/adminapis/modifyPockets", http.StatusOK, ""},
+		{"editor1", "/api/space/v1/adminapis/deletePockets", http.StatusForbidden, constants.AdminPermissionDenied},
+		{"root", "/api/space/v1/adminapis/deletePockets", http.StatusOK, ""},
+		{"root", "/api/space/v1/adminapis/unlistedRoute", http.StatusOK, ""},
+		{"editor1", "/api/space/v1/adminapis/unlistedRoute", http.StatusForbidden, constants.AdminPermissionDenied},
+		{"legacy1", "/api/space/v1/adminapis/modifyPockets", http.StatusForbidden, constants.AdminPermissionDenied},
+		{"gone1", "/api/space/v1/adminapis/modifyPockets", http.StatusForbidden, constants.AdminDisabled},
+		{"nobody", "/api/space/v1/adminapis/modifyPockets", http.StatusForbidden, constants.InvalidToken},
+		{"editor1", "/api/space/v1/pockets/fetchPocketPortfolio", http.StatusForbidden, constants.AdminPermissionDenied},
+		{"support1", "/api/space/v1/pockets/fetchPocketPortfolio", http.StatusOK, ""},
+		// support only reads, acting for the client is superAdmin's
+		{"support1", "/api/space/v1/pockets/createPockets", http.StatusForbidden, constants.AdminPermissionDenied},
+		{"support1", "/api/space/v1/orderapis/placeOrder", http.StatusForbidden, constants.AdminPermissionDenied},
+		{"support1", "GET /api/space/v1/orderapis/fetchOrders", http.StatusOK, ""},
+		{"root", "/api/space/v1/orderapis/placeOrder", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		token, err := helpers.GenerateJWT(tt.userId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		method, path := http.MethodPost, tt.path
+		if strings.HasPrefix(path, "GET ") {
+			method, path = http.MethodGet, strings.TrimPrefix(path, "GET ")
+		}
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("ClientId", "AB1234")
+		req.Header.Set("P-ClientType", constants.ADMIN)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.code {
+			t.Errorf("%s %s: status = %d, want %d", tt.userId, tt.path, w.Code, tt.code)
+			continue
+		}
+		if tt.err == "" {
+			if w.Body.String() != tt.userId {
+				t.Errorf("%s %s: admin on the request = %q", tt.userId, tt.path, w.Body.String())
+			}
+			continue
+		}
+		var res apihelpers.APIRes
+		json.Unmarshal(w.Body.Bytes(), &res)
+		if res.ErrorCode != tt.err {
+			t.Errorf("%s %s: error = %s, want %s", tt.userId, tt.path, res.ErrorCode, tt.err)
+		}
+	}
+
+	// the retired shared admin key is no way around the token
+	constants.AdminSecretKey = "admin-secret"
+	req := httptest.NewRequest(http.MethodPost, "/api/space/v1/pockets/fetchPocketPortfolio", nil)
+	req.Header.Set("Authorization", "Bearer not-an-admin-token")
+	req.Header.Set("ClientId", "AB1234")
+	req.Header.Set("P-ClientType", constants.ADMIN)
+	req.Header.Set("P-AdminRequestKey", "admin-secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("admin secret key: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
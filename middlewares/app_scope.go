@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	apihelpers "space/apiHelpers"
+	"space/business/tradelab"
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// appRouteScopes maps router groups to the scope an app token needs to call
+// them, with entries for the routes of a group that need a different one. An
+// empty scope, or a route under no listed group, is closed to apps.
+var appRouteScopes = map[string]string{
+	"/api/space/v1/orderapis":                    constants.ScopeOrdersWrite,
+	"/api/space/v1/orderapis/pendingOrder":       constants.ScopeOrdersRead,
+	"/api/space/v1/orderapis/completedOrder":     constants.ScopeOrdersRead,
+	"/api/space/v1/orderapis/tradeBook":          constants.ScopeOrdersRead,
+	"/api/space/v1/orderapis/orderHistory":       constants.ScopeOrdersRead,
+	"/api/space/v1/orderapis/marginCalculations": constants.ScopeOrdersRead,
+	"/api/space/v1/orderapis/fetchGTTOrder":      constants.ScopeOrdersRead,
+	"/api/space/v1/orderapis/lastTradedPrice":    constants.ScopeMarketRead,
+	"/api/space/v2/orderapis":                    constants.ScopeOrdersWrite,
+	"/api/space/v2/orderapis/pendingOrder":       constants.ScopeOrdersRead,
+	"/api/space/v2/orderapis/completedOrder":     constants.ScopeOrdersRead,
+	"/api/space/v2/orderapis/tradeBook":          constants.ScopeOrdersRead,
+	"/api/space/v2/orderapis/orderHistory":       constants.ScopeOrdersRead,
+	"/api/space/v1/basket":                       constants.ScopeOrdersWrite,
+	"/api/space/v1/basket/fetchBasket":           constants.ScopeOrdersRead,
+	"/api/space/v1/sip":                          constants.ScopeOrdersWrite,
+	"/api/space/v1/sip/fetchStockSipOrder":       constants.ScopeOrdersRead,
+
+	"/api/space/v1/portfolioapis":                  constants.ScopePortfolioRead,
+	"/api/space/v1/portfolioapis/convertPositions": constants.ScopeOrdersWrite,
+	"/api/space/v2/portfolioapis":                  constants.ScopePortfolioRead,
+	"/api/space/v2/portfolioapis/convertPositions": constants.ScopeOrdersWrite,
+
+	"/api/space/v1/funds/view":                constants.ScopeFundsRead,
+	"/api/space/v1/funds/view/payout":         constants.ScopeFundsWrite,
+	"/api/space/v1/funds/view/cancelPayout":   constants.ScopeFundsWrite,
+	"/api/space/v2/funds/view":                constants.ScopeFundsRead,
+	"/api/space/v2/funds/view/cancelPayout":   constants.ScopeFundsWrite,
+	"/api/space/v3/funds/view":                constants.ScopeFundsWrite,
+	"/api/space/v3/funds/view/upiPayinStatus": constants.ScopeFundsRead,
+
+	"/api/space/v1/reports": constants.ScopeReportsRead,
+
+	"/api/space/v1/user/profile":               constants.ScopeProfileRead,
+	"/api/space/v1/user/profile/sendAFOtp":     "",
+	"/api/space/v1/user/profile/verifyAFOtp":   "",
+	"/api/space/v1/user/profile/accountFreeze": "",
+	"/api/space/v2/user/profile":               constants.ScopeProfileRead,
+
+	"/api/space/v1/optionchain":     constants.ScopeMarketRead,
+	"/api/space/v2/optionchain":     constants.ScopeMarketRead,
+	"/api/space/v3/optionchain":     constants.ScopeMarketRead,
+	"/api/space/v1/contractdetails": constants.ScopeMarketRead,
+	"/api/space/v2/contractdetails": constants.ScopeMarketRead,
+}
+
+// appRouteScope finds the scope for a route, trying the route itself and then
+// each group above it.
+func appRouteScope(fullPath string) string {
+	path := strings.TrimSuffix(fullPath, "/")
+	for path != "" {
+		if scope, ok := appRouteScopes[path]; ok {
+			return scope
+		}
+		path = path[:strings.LastIndex(path, "/")]
+	}
+	return ""
+}
+
+/*
+AppScopeAuthorization lets access tokens issued to third-party apps only reach
+the routes their scopes cover, within the app's quota, and logs every call they
+make. Tokens that were not issued to an app pass through untouched.
+*/
+func AppScopeAuthorization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorization := c.GetHeader("Authorization")
+		if len(authorization) <= 7 || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+
+		requestId := uuid.New().String()
+		grant, isApp, err := tradelab.CallFetchAppGrant(authorization[7:])
+		if err != nil {
+			// without the app registry an app token cannot be told apart. Reads
+			// are left to the route's own authentication, writes are refused
+			// as the token could be an app's without the scope
+			loggerconfig.Error("AppScopeAuthorization, error finding app of token:", err, " path:", c.FullPath(), " requestId:", requestId)
+			if tradelab.IsWriteAppScope(appRouteScope(c.FullPath())) {
+				var resJS apihelpers.APIRes
+				resJS.Status = false
+				resJS.Message = constants.ErrorCodeMap[constants.InternalServerError]
+				resJS.ErrorCode = constants.InternalServerError
+				c.JSON(http.StatusInternalServerError, resJS)
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		if !isApp {
+			c.Next()
+			return
+		}
+
+		activity := models.MongoAppActivity{
+			AppId:    grant.AppId,
+			AppOwner: grant.AppOwner,
+			ClientId: strings.ToUpper(c.GetHeader("ClientId")),
+			Method:   c.Request.Method,
+			Path:     c.FullPath(),
+			Scope:    appRouteScope(c.FullPath()),
+			Ip:       c.GetHeader("P-ClientPublicIP"),
+		}
+
+		if activity.Scope == "" || !tradelab.AppHasScope(grant, activity.Scope) {
+			loggerconfig.Info("AppScopeAuthorization, app lacks scope:", activity.Scope, " appId:", grant.AppId, " path:", activity.Path, " requestId:", requestId)
+			rejectAppRequest(c, activity, http.StatusForbidden, constants.AppScopeMissing, requestId)
+			return
+		}
+
+		if allowed, retryAfter := tradelab.CheckAppQuota(grant, requestId); !allowed {
+			loggerconfig.Info("AppScopeAuthorization, app quota exceeded, appId:", grant.AppId, " quotaPerMin:", grant.QuotaPerMin, " requestId:", requestId)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			rejectAppRequest(c, activity, http.StatusTooManyRequests, constants.AppQuotaExceeded, requestId)
+			return
+		}
+
+		c.Next()
+
+		activity.StatusCode = c.Writer.Status()
+		tradelab.RecordAppActivity(activity, requestId)
+	}
+}
+
+func rejectAppRequest(c *gin.Context, activity models.MongoAppActivity, status int, errorCode string, requestId string) {
+	var resJS apihelpers.APIRes
+	resJS.Status = false
+	resJS.Message = constants.ErrorCodeMap[errorCode]
+	resJS.ErrorCode = errorCode
+	c.JSON(status, resJS)
+	c.Abort()
+
+	activity.StatusCode = status
+	activity.ErrorCode = errorCode
+	tradelab.RecordAppActivity(activity, requestId)
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"space/business/tradelab"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAppScopeAuthorizationRegistryDown(t *testing.T) {
+	origFetch, origError := tradelab.CallFetchAppGrant, loggerconfig.Error
+	t.Cleanup(func() { tradelab.CallFetchAppGrant, loggerconfig.Error = origFetch, origError })
+	loggerconfig.Error = func(args ...interface{}) {}
+	tradelab.CallFetchAppGrant = func(token string) (models.AppGrant, bool, error) {
+		return models.AppGrant{}, false, errors.New("mongo down")
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AppScopeAuthorization())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.POST("/api/space/v1/orderapis/placeOrder", ok)
+	r.POST("/api/space/v1/orderapis/tradeBook", ok)
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/api/space/v1/orderapis/placeOrder", http.StatusInternalServerError},
+		{"/api/space/v1/orderapis/tradeBook", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer some-token")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("%s = %d, want %d", tt.path, w.Code, tt.code)
+		}
+	}
+}
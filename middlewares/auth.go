@@ -3,7 +3,6 @@ package middlewares
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -71,6 +70,18 @@ func Middleware() gin.HandlerFunc {
 				c.JSON(http.StatusForbidden, authDaoValidate)
 				c.Abort()
 			}
+		} else if tradelab.RejectRevokedSession(reqH) {
+			// logged out from another device or by a password or 2FA reset
+			loggerconfig.Info("Middleware revoked session for client:", reqH.ClientId, " requestId:", reqH.RequestId)
+			var resJS apihelpers.APIRes
+			resJS.Status = false
+			resJS.Message = constants.ErrorCodeMap[constants.SessionRevokedError]
+			resJS.ErrorCode = constants.SessionRevokedError
+			c.JSON(http.StatusUnauthorized, resJS)
+			c.Abort()
+			return
+		} else {
+			touchClientSession(reqH)
 		}
 
 		c.Set("reqH", reqH)
@@ -78,6 +89,20 @@ func Middleware() gin.HandlerFunc {
 	}
 }
 
+// touchClientSession records the session of a request on a Middleware route.
+// The token is not checked with tradelab on these routes, so only a token
+// issued to the client of the request and not yet expired is recorded.
+func touchClientSession(reqH models.ReqHeader) {
+	if reqH.ClientId == "" || len(reqH.Authorization) <= 7 {
+		return
+	}
+	tokenHeader, err := helpers.ExtractTokenHeader(reqH.Authorization[7:])
+	if err != nil || !strings.EqualFold(tokenHeader.ClientID, reqH.ClientId) || tokenHeader.Exp <= helpers.GetCurrentTimeInIST().Unix() {
+		return
+	}
+	tradelab.TouchClientSession(reqH, reqH.Authorization[7:], tokenHeader.Exp)
+}
+
 func GuestDaoMiddleware(reqH models.ReqHeader) apihelpers.APIRes {
 	//Code for middlewares
 
@@ -224,18 +249,18 @@ func AdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		sub, err := helpers.ValidateToken(reqH.Authorization[7:])
-		fmt.Printf("error validating auth token =%v\n", err)
-		if err != nil {
+		reqH.RequestId = uuid.New().String()
+
+		admin, status, errorCode := authenticateAdmin(reqH.Authorization[7:], adminRoutePermission(c.FullPath()), reqH.RequestId)
+		if errorCode != "" {
 			resJS.Status = false
-			resJS.Message = constants.ErrorCodeMap[constants.InvalidToken]
-			resJS.ErrorCode = constants.InvalidToken
-			c.JSON(http.StatusForbidden, resJS)
+			resJS.Message = constants.ErrorCodeMap[errorCode]
+			resJS.ErrorCode = errorCode
+			c.JSON(status, resJS)
 			c.Abort()
 			return
 		}
-
-		reqH.RequestId = uuid.New().String()
+		reqH.AdminUserId, reqH.AdminRole = admin.UserId, admin.Role
 
 		//check for token expiry
 		// val := cache.Exists(reqH.Authorization)
@@ -249,7 +274,6 @@ func AdminMiddleware() gin.HandlerFunc {
 		// 	return
 		// }
 
-		fmt.Printf("subject=%v\n", sub)
 		c.Set("reqH", reqH)
 		c.Next()
 	}
@@ -391,11 +415,19 @@ func UserAuthentication() gin.HandlerFunc {
 			return
 		}
 
-		// auth for admin calls
-		adminRequestKey := reqH.AdminRequestKey
-
-		if reqH.ClientType == constants.ADMIN && constants.AdminSecretKey == adminRequestKey {
-			loggerconfig.Info("Admin access granted with secret key")
+		// auth for admin calls, made with the token of a named admin
+		if reqH.ClientType == constants.ADMIN {
+			admin, status, errorCode := authenticateAdmin(reqH.Authorization[7:], adminClientPermission(c.Request.Method, c.FullPath()), reqH.RequestId)
+			if errorCode != "" {
+				resJS.Status = false
+				resJS.Message = constants.ErrorCodeMap[errorCode]
+				resJS.ErrorCode = errorCode
+				c.JSON(status, resJS)
+				c.Abort()
+				return
+			}
+			loggerconfig.Info("Admin access granted to admin:", admin.UserId, " role:", admin.Role, " clientId:", reqH.ClientId, " requestId:", reqH.RequestId)
+			reqH.AdminUserId, reqH.AdminRole = admin.UserId, admin.Role
 			c.Set("reqH", reqH)
 			c.Next()
 			return
@@ -405,7 +437,19 @@ func UserAuthentication() gin.HandlerFunc {
 
 		reqH.ClientId = strings.ToUpper(reqH.ClientId)
 
-		key := reqH.ClientId + "_" + reqH.Authorization[7:]
+		// logged out from another device or by a password or 2FA reset, checked
+		// ahead of the cache so a revoked token is never served from it
+		if tradelab.RejectRevokedSession(reqH) {
+			loggerconfig.Info("UserAuthentication revoked session for client:", reqH.ClientId, " requestId:", reqH.RequestId)
+			resJS.Status = false
+			resJS.Message = constants.ErrorCodeMap[constants.SessionRevokedError]
+			resJS.ErrorCode = constants.SessionRevokedError
+			c.JSON(http.StatusUnauthorized, resJS)
+			c.Abort()
+			return
+		}
+
+		key := tradelab.SessionCacheKey(reqH.ClientId, reqH.Authorization[7:])
 
 		exists, err := redisCli.Exists(key).Result()
 		if err != nil {
@@ -414,6 +458,9 @@ func UserAuthentication() gin.HandlerFunc {
 
 		if exists > 0 {
 			loggerconfig.Info("UserAuthentication token found in redis for client:", reqH.ClientId)
+			if tokenHeader, err := helpers.ExtractTokenHeader(reqH.Authorization[7:]); err == nil {
+				tradelab.TouchClientSession(reqH, reqH.Authorization[7:], tokenHeader.Exp)
+			}
 			c.Set("reqH", reqH)
 			c.Next()
 			return
@@ -496,6 +543,7 @@ func UserAuthentication() gin.HandlerFunc {
 			loggerconfig.Error("UserAuthentication Error setting auth from redis:", errRedisSet, " clientId: ", reqH.ClientId)
 		}
 		loggerconfig.Info("UserAuthentication token set in cache for clientid:", reqH.ClientId)
+		tradelab.TouchClientSession(reqH, reqH.Authorization[7:], tokenHeader.Exp)
 
 		c.Set("reqH", reqH)
 		c.Next()
@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"space/business/tradelab"
+	"space/constants"
+	"space/loggerconfig"
+	"space/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddlewareRevokedSession(t *testing.T) {
+	origFetch, origClaim, origRecord := tradelab.CallFetchRevokedSession, tradelab.CallClaimSessionSeen, tradelab.CallRecordClientSession
+	origInfo, origWarn, origError := loggerconfig.Info, loggerconfig.Warn, loggerconfig.Error
+	t.Cleanup(func() {
+		tradelab.CallFetchRevokedSession, tradelab.CallClaimSessionSeen, tradelab.CallRecordClientSession = origFetch, origClaim, origRecord
+		loggerconfig.Info, loggerconfig.Warn, loggerconfig.Error = origInfo, origWarn, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Warn = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+
+	revoked := tradelab.SessionId("revoked-token")
+	tradelab.CallFetchRevokedSession = func(sessionId string) (bool, bool, error) {
+		return sessionId == revoked, sessionId == revoked, nil
+	}
+	tradelab.CallClaimSessionSeen = func(sessionId string) (bool, error) { return true, nil }
+	var recorded []models.MongoClientSession
+	tradelab.CallRecordClientSession = func(session models.MongoClientSession) error {
+		recorded = append(recorded, session)
+		return nil
+	}
+	clientToken := func(clientId string, exp time.Time) string {
+		claims, _ := json.Marshal(models.TokenHeaders{ClientID: clientId, Exp: exp.Unix()})
+		return "e30." + base64.RawURLEncoding.EncodeToString(claims) + ".sig"
+	}
+	active := clientToken("ABC123", time.Now().Add(time.Hour))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+
+	tests := []struct {
+		name       string
+		token      string
+		clientType string
+		code       int
+		recorded   bool
+	}{
+		{"active session", active, "", http.StatusOK, true},
+		{"token of another client", clientToken("XYZ999", time.Now().Add(time.Hour)), "", http.StatusOK, false},
+		{"expired token", clientToken("ABC123", time.Now().Add(-time.Minute)), "", http.StatusOK, false},
+		{"not a jwt", "active-token", "", http.StatusOK, false},
+		{"revoked session", "revoked-token", "", http.StatusUnauthorized, false},
+		{"revoked session claiming admin", "revoked-token", constants.ADMIN, http.StatusUnauthorized, false},
+	}
+	for _, tt := range tests {
+		recorded = nil
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		req.Header.Set("clientId", "abc123")
+		req.Header.Set("P-ClientType", tt.clientType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.code)
+		}
+		if (len(recorded) == 1) != tt.recorded {
+			t.Errorf("%s: recorded sessions = %+v", tt.name, recorded)
+		}
+	}
+}
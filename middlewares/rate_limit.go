@@ -0,0 +1,236 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apihelpers "space/apiHelpers"
+	"space/constants"
+	"space/helpers"
+	"space/helpers/cache"
+	"space/loggerconfig"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitPolicy allows PerClient requests for each client ID and PerIp
+// requests for each IP within any WindowSecs. A zero limit is not enforced.
+// Login routes set ByLoginId to count PerClient on the login ID in the body,
+// as the ClientId header is not checked before there is a session.
+type RateLimitPolicy struct {
+	PerClient  int
+	PerIp      int
+	WindowSecs int
+	ByLoginId  bool
+}
+
+var (
+	defaultRateLimit  = RateLimitPolicy{PerClient: 300, PerIp: 1200, WindowSecs: 60}
+	strictRateLimit   = RateLimitPolicy{PerClient: 10, PerIp: 60, WindowSecs: 60, ByLoginId: true}
+	otpRateLimit      = RateLimitPolicy{PerClient: 5, PerIp: 30, WindowSecs: 300}
+	loginOtpRateLimit = RateLimitPolicy{PerClient: 5, PerIp: 30, WindowSecs: 300, ByLoginId: true}
+	lenientRateLimit  = RateLimitPolicy{PerClient: 1200, PerIp: 3000, WindowSecs: 60}
+	noRateLimit       = RateLimitPolicy{}
+)
+
+// loginIdFields are the body fields the login routes take the user in, in the
+// order they are looked for.
+var loginIdFields = []string{"loginid", "username", "userid", "clientid", "emailid", "email", "id"}
+
+const maxLoginBodyBytes = 64 << 10
+
+// rateLimitPolicies maps router groups, and routes that need something
+// different from their group, to a policy. The entry a request matches is also
+// the group its counters are kept under. Anything unlisted gets the default.
+var rateLimitPolicies = map[string]RateLimitPolicy{
+	"/api/space/health": noRateLimit,
+
+	"/api/space/v1/authapis":                  strictRateLimit,
+	"/api/space/v1/authapis/getAccessToken":   defaultRateLimit,
+	"/api/space/v1/authapis/handleAuthCode":   defaultRateLimit,
+	"/api/space/v1/authapis/fetchApps":        defaultRateLimit,
+	"/api/space/v1/authapis/appActivity":      defaultRateLimit,
+	"/api/space/v1/authapis/validateToken":    defaultRateLimit,
+	"/api/space/v1/authapis/guestUserStatus":  defaultRateLimit,
+	"/api/space/v2/authapis":                  strictRateLimit,
+	"/api/space/v2/authapis/getAccessToken":   defaultRateLimit,
+	"/api/space/v2/authapis/validateLoginOtp": loginOtpRateLimit,
+	"/api/space/v3/authapis":                  strictRateLimit,
+	"/api/space/v3/authapis/validateToken":    defaultRateLimit,
+	"/api/space/v3/authapis/validateLoginOtp": loginOtpRateLimit,
+	"/api/space/v3/authapis/loginByEmailOtp":  loginOtpRateLimit,
+	"/api/space/v1/user/profile/sendAFOtp":    otpRateLimit,
+	"/api/space/v1/user/profile/verifyAFOtp":  otpRateLimit,
+
+	"/api/space/v1/optionchain":     lenientRateLimit,
+	"/api/space/v2/optionchain":     lenientRateLimit,
+	"/api/space/v3/optionchain":     lenientRateLimit,
+	"/api/space/v1/contractdetails": lenientRateLimit,
+	"/api/space/v2/contractdetails": lenientRateLimit,
+	"/api/space/v3/contractdetails": lenientRateLimit,
+	"/api/space/v3/search":          lenientRateLimit,
+	"/api/space/v1/cmots":           lenientRateLimit,
+	"/api/space/v2/cmots":           lenientRateLimit,
+	"/api/space/v3/cmots":           lenientRateLimit,
+	"/api/space/v4/cmots":           lenientRateLimit,
+
+	// gateway callbacks and admin tools have their own authentication, the
+	// admin login in front of them does not
+	"/api/space/v3/funds/webhook":        noRateLimit,
+	"/api/space/v1/adminapis":            noRateLimit,
+	"/api/space/v3/adminapis":            noRateLimit,
+	"/api/space/v1/adminapis/adminLogin": strictRateLimit,
+}
+
+var rateLimitNow = helpers.GetCurrentTimeInIST
+
+// rateLimitPolicy finds the policy of a route and the group it is counted in.
+func rateLimitPolicy(fullPath string) (string, RateLimitPolicy) {
+	path := strings.TrimSuffix(fullPath, "/")
+	for path != "" {
+		if policy, ok := rateLimitPolicies[path]; ok {
+			return path, policy
+		}
+		path = path[:strings.LastIndex(path, "/")]
+	}
+	return "default", defaultRateLimit
+}
+
+// CallCountRateWindow counts a request in the current window of a key and
+// returns that count along with the count of the window before it.
+var CallCountRateWindow = func(key string, window int64, windowSecs int) (int64, int64, error) {
+	redisCli := cache.GetRedisClientObj()
+	currentKey := key + "_" + strconv.FormatInt(window, 10)
+	current, err := redisCli.Incr(currentKey).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	if current == 1 {
+		// kept for two windows as the next one still weighs it
+		if err := redisCli.Expire(currentKey, time.Duration(2*windowSecs/60+1)); err != nil {
+			return current, 0, err
+		}
+	}
+	previous, err := redisCli.GetRedis(key + "_" + strconv.FormatInt(window-1, 10)).Int64()
+	if err != nil {
+		previous = 0
+	}
+	return current, previous, nil
+}
+
+// allowRequest applies a sliding window to one key: the previous window counts
+// for the part of it still inside the last WindowSecs. It returns the seconds
+// to wait when the limit is exceeded. Limits are not enforced while redis is
+// unavailable.
+func allowRequest(key string, limit int, windowSecs int, now time.Time) (bool, int) {
+	if limit <= 0 {
+		return true, 0
+	}
+	window := now.Unix() / int64(windowSecs)
+	elapsed := now.Unix() % int64(windowSecs)
+
+	current, previous, err := CallCountRateWindow(key, window, windowSecs)
+	if err != nil {
+		loggerconfig.Error("RateLimit, redis error:", err, " key:", key)
+		return true, 0
+	}
+	weighted := float64(previous)*float64(int64(windowSecs)-elapsed)/float64(windowSecs) + float64(current)
+	if weighted <= float64(limit) {
+		return true, 0
+	}
+	return false, int(int64(windowSecs) - elapsed)
+}
+
+// rateLimitLoginId reads the login ID out of a JSON body and puts the body
+// back for the handler.
+func rateLimitLoginId(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLoginBodyBytes))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if err != nil {
+		return ""
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+	values := make(map[string]string, len(fields))
+	for name, value := range fields {
+		if str, ok := value.(string); ok {
+			values[strings.ToLower(name)] = str
+		}
+	}
+	for _, name := range loginIdFields {
+		if loginId := strings.TrimSpace(values[name]); loginId != "" {
+			return strings.ToUpper(loginId)
+		}
+	}
+	return ""
+}
+
+func rateLimitAllowed(c *gin.Context) bool {
+	ip := c.ClientIP()
+	for _, allowed := range constants.RateLimitAllowIps {
+		if ip == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+RateLimit throttles every route by client ID and by IP within its router
+group, answering 429 with Retry-After once a limit is reached. Allow-listed
+backoffice IPs are not limited.
+*/
+func RateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !constants.RateLimitEnabled || c.FullPath() == "" || rateLimitAllowed(c) {
+			c.Next()
+			return
+		}
+
+		group, policy := rateLimitPolicy(c.FullPath())
+		now := rateLimitNow()
+
+		var keys []string
+		var limits []int
+		clientId := strings.ToUpper(c.GetHeader("ClientId"))
+		if policy.ByLoginId {
+			clientId = rateLimitLoginId(c)
+		}
+		if clientId != "" {
+			keys = append(keys, constants.RateLimitKey+group+"_client_"+clientId)
+			limits = append(limits, policy.PerClient)
+		}
+		keys = append(keys, constants.RateLimitKey+group+"_ip_"+c.ClientIP())
+		limits = append(limits, policy.PerIp)
+
+		for i, key := range keys {
+			if allowed, retryAfter := allowRequest(key, limits[i], policy.WindowSecs, now); !allowed {
+				loggerconfig.Info("RateLimit, limit reached, key:", key, " limit:", limits[i], " path:", c.FullPath())
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+				var resJS apihelpers.APIRes
+				resJS.Status = false
+				resJS.Message = constants.ErrorCodeMap[constants.TooManyRequests]
+				resJS.ErrorCode = constants.TooManyRequests
+				c.JSON(http.StatusTooManyRequests, resJS)
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,192 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"space/constants"
+	"space/loggerconfig"
+
+	"github.com/gin-gonic/gin"
+)
+
+func rateLimitRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RateLimit())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.POST("/api/space/v2/authapis/login", ok)
+	r.POST("/api/space/v2/authapis/getAccessToken", ok)
+	return r
+}
+
+// rateLimitCall sends clientId both in the ClientId header and as the body's
+// loginId.
+func rateLimitCall(r *gin.Engine, method, path, clientId, ip string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(`{"loginId":"`+clientId+`"}`))
+	req.RemoteAddr = ip + ":5000"
+	if clientId != "" {
+		req.Header.Set("ClientId", clientId)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitPolicy(t *testing.T) {
+	tests := []struct {
+		path   string
+		group  string
+		policy RateLimitPolicy
+	}{
+		{"/api/space/v2/authapis/login", "/api/space/v2/authapis", strictRateLimit},
+		{"/api/space/v2/authapis/getAccessToken", "/api/space/v2/authapis/getAccessToken", defaultRateLimit},
+		{"/api/space/v1/alerts/setAlerts", "default", defaultRateLimit},
+		{"/api/space/v3/funds/webhook/upi/:gateway", "/api/space/v3/funds/webhook", noRateLimit},
+		{"/api/space/v1/adminapis/adminLogin", "/api/space/v1/adminapis/adminLogin", strictRateLimit},
+		{"/api/space/v1/adminapis/fetchAdmins", "/api/space/v1/adminapis", noRateLimit},
+		{"/api/space/v3/authapis/loginByEmailOtp", "/api/space/v3/authapis/loginByEmailOtp", loginOtpRateLimit},
+		{"/api/space/v2/optionchain/fetchOptionChain", "/api/space/v2/optionchain", lenientRateLimit},
+	}
+	for _, tt := range tests {
+		group, policy := rateLimitPolicy(tt.path)
+		if group != tt.group || policy != tt.policy {
+			t.Errorf("rateLimitPolicy(%s) = %s %+v, want %s %+v", tt.path, group, policy, tt.group, tt.policy)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	origNow, origCount, origEnabled, origAllow := rateLimitNow, CallCountRateWindow, constants.RateLimitEnabled, constants.RateLimitAllowIps
+	origInfo, origError := loggerconfig.Info, loggerconfig.Error
+	t.Cleanup(func() {
+		rateLimitNow, CallCountRateWindow, constants.RateLimitEnabled, constants.RateLimitAllowIps = origNow, origCount, origEnabled, origAllow
+		loggerconfig.Info, loggerconfig.Error = origInfo, origError
+	})
+	loggerconfig.Info = func(args ...interface{}) {}
+	loggerconfig.Error = func(args ...interface{}) {}
+
+	var now time.Time
+	var counts map[string]int64
+	constants.RateLimitAllowIps = []string{"10.0.0.9"}
+	rateLimitNow = func() time.Time { return now }
+	CallCountRateWindow = func(key string, window int64, windowSecs int) (int64, int64, error) {
+		counts[key+"_"+strconv.FormatInt(window, 10)]++
+		return counts[key+"_"+strconv.FormatInt(window, 10)], counts[key+"_"+strconv.FormatInt(window-1, 10)], nil
+	}
+	reset := func(at time.Time) {
+		now, counts = at, map[string]int64{}
+		constants.RateLimitEnabled = true
+	}
+
+	t.Run("per client", func(t *testing.T) {
+		reset(time.Date(2026, 10, 19, 10, 0, 20, 0, constants.LocationKolkata))
+		r := rateLimitRouter()
+
+		for i := 0; i < strictRateLimit.PerClient; i++ {
+			if w := rateLimitCall(r, http.MethodPost, "/api/space/v2/authapis/login", "abc123", "1.2.3.4"); w.Code != http.StatusOK {
+				t.Fatalf("login %d = %d", i+1, w.Code)
+			}
+		}
+		w := rateLimitCall(r, http.MethodPost, "/api/space/v2/authapis/login", "ABC123", "1.2.3.5")
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "40" {
+			t.Fatalf("login over limit = %d Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+		}
+
+		// other groups and other clients keep their own counters
+		if w := rateLimitCall(r, http.MethodPost, "/api/space/v2/authapis/getAccessToken", "ABC123", "1.2.3.4"); w.Code != http.StatusOK {
+			t.Errorf("getAccessToken = %d", w.Code)
+		}
+		if w := rateLimitCall(r, http.MethodPost, "/api/space/v2/authapis/login", "XYZ999", "1.2.3.6"); w.Code != http.StatusOK {
+			t.Errorf("another client = %d", w.Code)
+		}
+
+		// allow-listed callers are not limited
+		if w := rateLimitCall(r, http.MethodPost, "/api/space/v2/authapis/login", "ABC123", "10.0.0.9"); w.Code != http.StatusOK {
+			t.Errorf("backoffice ip = %d", w.Code)
+		}
+		// the retired shared admin key is not a way around the limit
+		if w := rateLimitCall(r, http.MethodPost, "/api/space/v2/authapis/login", "ABC123", "1.2.3.4", "P-ClientType", constants.ADMIN, "P-AdminRequestKey", "admin-secret"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("admin key = %d", w.Code)
+		}
+
+		// half way into the next window the 12 calls of the last one, rejected
+		// ones included, still count for 6
+		now = now.Add(70 * time.Second)
+		for i := 0; i < 4; i++ {
+			if w := rateLimitCall(r, http.MethodPost, "/api/space/v2/authapis/login", "ABC123", "1.2.3.4"); w.Code != http.StatusOK {
+				t.Fatalf("next window login %d = %d", i+1, w.Code)
+			}
+		}
+		if w := rateLimitCall(r, http.MethodPost, "/api/space/v2/authapis/login", "ABC123", "1.2.3.4"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("sliding window let through %d", w.Code)
+		}
+	})
+
+	t.Run("per ip", func(t *testing.T) {
+		reset(time.Date(2026, 10, 19, 10, 0, 0, 0, constants.LocationKolkata))
+		r := rateLimitRouter()
+
+		for i := 0; i < strictRateLimit.PerIp; i++ {
+			if w := rateLimitCall(r, http.MethodPost, "/api/space/v2/authapis/login", "C"+strconv.Itoa(i), "1.2.3.4"); w.Code != http.StatusOK {
+				t.Fatalf("login %d = %d", i+1, w.Code)
+			}
+		}
+		if w := rateLimitCall(r, http.MethodPost, "/api/space/v2/authapis/login", "NEW", "1.2.3.4"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("ip over limit = %d", w.Code)
+		}
+
+		constants.RateLimitEnabled = false
+		if w := rateLimitCall(r, http.MethodPost, "/api/space/v2/authapis/login", "NEW", "1.2.3.4"); w.Code != http.StatusOK {
+			t.Errorf("disabled limiter = %d", w.Code)
+		}
+	})
+
+	t.Run("login id from body", func(t *testing.T) {
+		reset(time.Date(2026, 10, 19, 10, 0, 0, 0, constants.LocationKolkata))
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.Use(RateLimit())
+		var bodies []string
+		r.POST("/api/space/v2/authapis/login", func(c *gin.Context) {
+			var req struct {
+				UserName string `json:"userName"`
+			}
+			_ = c.ShouldBindJSON(&req)
+			bodies = append(bodies, req.UserName)
+			c.Status(http.StatusOK)
+		})
+		login := func(userName, headerClientId, ip string) int {
+			req := httptest.NewRequest(http.MethodPost, "/api/space/v2/authapis/login", strings.NewReader(`{"userName":"`+userName+`","password":"x"}`))
+			req.RemoteAddr = ip + ":5000"
+			req.Header.Set("ClientId", headerClientId)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		// a different ClientId header on every try does not reset the count
+		for i := 0; i < strictRateLimit.PerClient; i++ {
+			if code := login("victim1", "H"+strconv.Itoa(i), "1.2.3."+strconv.Itoa(i)); code != http.StatusOK {
+				t.Fatalf("login %d = %d", i+1, code)
+			}
+		}
+		if code := login("VICTIM1", "OTHER", "1.2.4.1"); code != http.StatusTooManyRequests {
+			t.Errorf("login over limit = %d", code)
+		}
+		// and naming the victim in the header does not use up their count
+		if code := login("someone", "VICTIM1", "1.2.4.2"); code != http.StatusOK {
+			t.Errorf("other login id = %d", code)
+		}
+		if len(bodies) != strictRateLimit.PerClient+1 || bodies[0] != "victim1" {
+			t.Errorf("handler saw bodies %v", bodies)
+		}
+	})
+}
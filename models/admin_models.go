@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// MongoAdminAudit is one change an admin made through the admin apis. Entries
+// are only ever inserted, Before is empty for a create and After for a delete.
+type MongoAdminAudit struct {
+	AuditId     string                 `json:"auditId" bson:"auditId"`
+	AdminUserId string                 `json:"adminUserId" bson:"adminUserId"`
+	AdminRole   string                 `json:"adminRole" bson:"adminRole"`
+	Action      string                 `json:"action" bson:"action"`
+	Entity      string                 `json:"entity" bson:"entity"`
+	EntityId    string                 `json:"entityId" bson:"entityId"`
+	Before      map[string]interface{} `json:"before" bson:"before,omitempty"`
+	After       map[string]interface{} `json:"after" bson:"after,omitempty"`
+	Changes     []AdminAuditChange     `json:"changes" bson:"changes"`
+	RequestId   string                 `json:"requestId" bson:"requestId"`
+	Ip          string                 `json:"ip" bson:"ip"`
+	CreatedAt   time.Time              `json:"createdAt" bson:"createdAt"`
+}
+
+type AdminAuditChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+type CreateAdminRequest struct {
+	UserId   string `json:"userId" validate:"required"`
+	Password string `json:"password" validate:"required,min=8" mask:"id"`
+	Name     string `json:"name" validate:"required"`
+	Role     string `json:"role" validate:"required"`
+}
+
+// UpdateAdminRequest changes the fields that are set, leaving the others as
+// they are.
+type UpdateAdminRequest struct {
+	UserId   string `json:"userId" validate:"required"`
+	Password string `json:"password" validate:"omitempty,min=8" mask:"id"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Disabled *bool  `json:"disabled"`
+}
+
+type AdminDetails struct {
+	UserId            string `json:"userId"`
+	Name              string `json:"name"`
+	Role              string `json:"role"`
+	Disabled          bool   `json:"disabled"`
+	CreatedBy         string `json:"createdBy"`
+	CreatedAt         int64  `json:"createdAt"`
+	UpdatedAt         int64  `json:"updatedAt"`
+	PasswordUpdatedAt int64  `json:"passwordUpdatedAt"`
+}
+
+type FetchAdminsResponse struct {
+	Admins []AdminDetails `json:"admins"`
+}
+
+// FetchAdminAuditRequest filters the audit trail, an empty field matches
+// every entry.
+type FetchAdminAuditRequest struct {
+	AdminUserId string `json:"adminUserId"`
+	Entity      string `json:"entity"`
+	EntityId    string `json:"entityId"`
+}
+
+type AdminAuditItem struct {
+	AuditId     string                 `json:"auditId"`
+	AdminUserId string                 `json:"adminUserId"`
+	AdminRole   string                 `json:"adminRole"`
+	Action      string                 `json:"action"`
+	Entity      string                 `json:"entity"`
+	EntityId    string                 `json:"entityId"`
+	Before      map[string]interface{} `json:"before,omitempty"`
+	After       map[string]interface{} `json:"after,omitempty"`
+	Changes     []AdminAuditChange     `json:"changes"`
+	Ip          string                 `json:"ip"`
+	CreatedAt   int64                  `json:"createdAt"`
+}
+
+type FetchAdminAuditResponse struct {
+	Audit []AdminAuditItem `json:"audit"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// AppGrant is what an access token issued to a third-party app is allowed to do.
+type AppGrant struct {
+	AppId       string   `json:"appId"`
+	AppOwner    string   `json:"appOwner"`
+	Scopes      []string `json:"scopes"`
+	QuotaPerMin int      `json:"quotaPerMin"`
+}
+
+type AppActivityRes struct {
+	AppId    string            `json:"appId"`
+	Activity []AppActivityItem `json:"activity"`
+}
+
+type AppActivityItem struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Scope      string `json:"scope"`
+	StatusCode int    `json:"statusCode"`
+	ErrorCode  string `json:"errorCode"`
+	Ip         string `json:"ip"`
+	CreatedAt  int64  `json:"createdAt"`
+}
+
+type MongoAppActivity struct {
+	AppId      string    `bson:"appId"`
+	AppOwner   string    `bson:"appOwner"`
+	ClientId   string    `bson:"clientId"`
+	Method     string    `bson:"method"`
+	Path       string    `bson:"path"`
+	Scope      string    `bson:"scope"`
+	StatusCode int       `bson:"statusCode"`
+	ErrorCode  string    `bson:"errorCode"`
+	Ip         string    `bson:"ip"`
+	CreatedAt  time.Time `bson:"createdAt"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// MongoAuthEvent is one call to a login, 2FA, password or unblock flow. Flows
+// started with an email keep it in EmailId until the client ID is known.
+type MongoAuthEvent struct {
+	ClientId   string    `bson:"clientId"`
+	EmailId    string    `bson:"emailId"`
+	Event      string    `bson:"event"`
+	Outcome    string    `bson:"outcome"`
+	StatusCode int       `bson:"statusCode"`
+	ErrorCode  string    `bson:"errorCode"`
+	Message    string    `bson:"message"`
+	DeviceId   string    `bson:"deviceId"`
+	DeviceType string    `bson:"deviceType"`
+	Platform   string    `bson:"platform"`
+	Ip         string    `bson:"ip"`
+	AppVersion string    `bson:"appVersion"`
+	Rules      []string  `bson:"rules"`
+	CreatedAt  time.Time `bson:"createdAt"`
+}
+
+// MongoAuthSecurity holds the restrictions suspicious logins put on a client.
+// A lock is lifted by unblocking the account with PAN, a step-up by logging in
+// with an email OTP.
+type MongoAuthSecurity struct {
+	ClientId     string    `bson:"clientId"`
+	Locked       bool      `bson:"locked"`
+	LockReason   string    `bson:"lockReason"`
+	LockedAt     time.Time `bson:"lockedAt"`
+	StepUp       bool      `bson:"stepUp"`
+	StepUpReason string    `bson:"stepUpReason"`
+	StepUpAt     time.Time `bson:"stepUpAt"`
+	UpdatedAt    time.Time `bson:"updatedAt"`
+}
+
+// AuthSecurityAlert is published when a rule fires so the client can be told
+// about it.
+type AuthSecurityAlert struct {
+	ClientId   string `json:"clientId"`
+	Rule       string `json:"rule"`
+	Action     string `json:"action"`
+	Event      string `json:"event"`
+	DeviceId   string `json:"deviceId"`
+	DeviceType string `json:"deviceType"`
+	Platform   string `json:"platform"`
+	Ip         string `json:"ip"`
+	PreviousIp string `json:"previousIp"`
+	CreatedAt  int64  `json:"createdAt"`
+}
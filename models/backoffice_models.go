@@ -269,6 +269,7 @@ type DPChargesReq struct {
 	UserID   string `json:"userID"`
 	DFDateFr string `json:"dFDateFr"`
 	DFDateTo string `json:"dFDateTo"`
+	Format   string `json:"format" validate:"omitempty,oneof=xlsx csv pdf"`
 }
 
 type DownloadDPChargesRes struct {
@@ -319,6 +320,7 @@ type TradebookReq struct {
 	UserID   string `json:"userID"`
 	DFDateFr string `json:"dFDateFr"`
 	DFDateTo string `json:"dFDateTo"`
+	Format   string `json:"format" validate:"omitempty,oneof=xlsx csv pdf"`
 }
 
 type DownloadTradebookRes struct {
@@ -359,6 +361,7 @@ type LedgerReq struct {
 	UserID   string `json:"userID"`
 	DFDateFr string `json:"dFDateFr"`
 	DFDateTo string `json:"dFDateTo"`
+	Format   string `json:"format" validate:"omitempty,oneof=xlsx csv pdf"`
 }
 
 type DownloadLedgerRes struct {
@@ -368,6 +371,7 @@ type DownloadLedgerRes struct {
 type OpenPositionReq struct {
 	UserID string `json:"userID"`
 	Dsflag string `json:"dsflag" example:"D,S"  validate:"oneof=D S"`
+	Format string `json:"format" validate:"omitempty,oneof=xlsx csv pdf"`
 }
 
 type OpenPositionRes struct {
@@ -455,6 +459,7 @@ type FnoPnlReq struct {
 	UserID   string `json:"userID"`
 	DFDateFr string `json:"dFDateFr"`
 	DFDateTo string `json:"dFDateTo"`
+	Format   string `json:"format" validate:"omitempty,oneof=xlsx csv pdf"`
 }
 
 type DownloadFnoPnlRes struct {
@@ -463,6 +468,7 @@ type DownloadFnoPnlRes struct {
 
 type GetHoldingFinancialDataReq struct {
 	UserID string `json:"userID"`
+	Format string `json:"format" validate:"omitempty,oneof=xlsx csv pdf"`
 }
 
 type GetHoldingFinancialData struct {
@@ -505,6 +511,7 @@ type CommodityTradebookReq struct {
 	UserID   string `json:"userID"`
 	DFDateFr string `json:"dFDateFr"`
 	DFDateTo string `json:"dFDateTo"`
+	Format   string `json:"format" validate:"omitempty,oneof=xlsx csv pdf"`
 }
 
 type CommodityTransactionData struct {
@@ -560,6 +567,7 @@ type FNOTradebookReq struct {
 	UserID   string `json:"userID"`
 	DFDateFr string `json:"dFDateFr"`
 	DFDateTo string `json:"dFDateTo"`
+	Format   string `json:"format" validate:"omitempty,oneof=xlsx csv pdf"`
 }
 
 type FNOTransactionRes struct {
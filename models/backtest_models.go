@@ -0,0 +1,73 @@
+package models
+
+// BacktestOperand is either an indicator series (sma, rsi, close, ...) with its
+// params, or a constant value when Indicator is empty.
+type BacktestOperand struct {
+	Indicator string  `json:"indicator,omitempty" example:"sma"`
+	Params    []int   `json:"params,omitempty" example:"50"`
+	Value     float64 `json:"value,omitempty" example:"30"`
+}
+
+type BacktestCondition struct {
+	Left     BacktestOperand `json:"left"`
+	Operator string          `json:"operator" validate:"required,oneof=> < >= <= crosses_above crosses_below" example:"crosses_above"`
+	Right    BacktestOperand `json:"right"`
+}
+
+type BacktestStrategy struct {
+	EntryRules  []BacktestCondition `json:"entryRules" validate:"required,min=1,max=10,dive"` // all must hold to enter
+	ExitRules   []BacktestCondition `json:"exitRules" validate:"max=10,dive"`                 // any one exits
+	StopLossPct float64             `json:"stopLossPct" validate:"gte=0,lt=100" example:"5"`
+	TargetPct   float64             `json:"targetPct" validate:"gte=0" example:"10"`
+	Sizing      string              `json:"sizing" validate:"required,oneof=fixed_qty fixed_capital equity_pct" example:"equity_pct"`
+	SizingValue float64             `json:"sizingValue" validate:"gt=0" example:"100"`
+}
+
+type RunBacktestReq struct {
+	Exchange       string           `json:"exchange" validate:"required,oneof=NSE BSE" example:"NSE"`
+	Token          string           `json:"token" validate:"required" example:"11536"`
+	CandleType     string           `json:"candleType" validate:"required" example:"3"`
+	DataDuration   string           `json:"dataDuration" example:"1"`
+	StartTime      string           `json:"startTime" validate:"required" example:"1668623400"`
+	EndTime        string           `json:"endTime" validate:"required" example:"1670484551"`
+	InitialCapital float64          `json:"initialCapital" validate:"gt=0" example:"100000"`
+	SubSegment     string           `json:"subSegment" validate:"omitempty,oneof=delivery intraday" example:"delivery"`
+	Strategy       BacktestStrategy `json:"strategy" validate:"required"`
+}
+
+type BacktestTrade struct {
+	EntryTime  string  `json:"entryTime"`
+	EntryPrice float64 `json:"entryPrice"`
+	ExitTime   string  `json:"exitTime"`
+	ExitPrice  float64 `json:"exitPrice"`
+	Quantity   int     `json:"quantity"`
+	Charges    float64 `json:"charges"`
+	Pnl        float64 `json:"pnl"`
+	PnlPct     float64 `json:"pnlPct"`
+	ExitReason string  `json:"exitReason"`
+}
+
+type BacktestEquityPoint struct {
+	Time   string  `json:"time"`
+	Equity float64 `json:"equity"`
+}
+
+type BacktestSummary struct {
+	TotalTrades    int     `json:"totalTrades"`
+	WinningTrades  int     `json:"winningTrades"`
+	LosingTrades   int     `json:"losingTrades"`
+	WinRate        float64 `json:"winRate"`
+	InitialCapital float64 `json:"initialCapital"`
+	FinalEquity    float64 `json:"finalEquity"`
+	NetPnl         float64 `json:"netPnl"`
+	TotalCharges   float64 `json:"totalCharges"`
+	ReturnPct      float64 `json:"returnPct"`
+	Cagr           float64 `json:"cagr"`
+	MaxDrawdown    float64 `json:"maxDrawdown"`
+}
+
+type RunBacktestRes struct {
+	Summary     BacktestSummary       `json:"summary"`
+	Trades      []BacktestTrade       `json:"trades"`
+	EquityCurve []BacktestEquityPoint `json:"equityCurve"`
+}
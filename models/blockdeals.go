@@ -1,15 +1,54 @@
 package models
 
 type BlockDeal struct {
-	Cocode     int     `json:"cocode"`
-	DealType   string  `json:"dealtype"`
-	ScripCode  string  `json:"scripcode"`
-	Serial     int     `json:"serial"`
-	Date1      string  `json:"date1"`
-	ScripName  string  `json:"scripname"`
-	ClientName string  `json:"clientname"`
-	BuySell    string  `json:"buysell"`
-	QtyShares  float64 `json:"qtyshares"`
-	AvgPrice   float64 `json:"avgprice"`
-	UnixTime   int64   `json:"unixtime"`
+	Cocode     int     `json:"cocode" validate:"required,gt=0"`
+	DealType   string  `json:"dealtype" validate:"required,max=20"`
+	ScripCode  string  `json:"scripcode" validate:"required,max=20"`
+	Serial     int     `json:"serial" validate:"gte=0"`
+	Date1      string  `json:"date1" validate:"required"`
+	ScripName  string  `json:"scripname" validate:"required"`
+	ClientName string  `json:"clientname" validate:"required"`
+	BuySell    string  `json:"buysell" validate:"required,oneof=B S BUY SELL"`
+	QtyShares  float64 `json:"qtyshares" validate:"gt=0"`
+	AvgPrice   float64 `json:"avgprice" validate:"gt=0"`
+	UnixTime   int64   `json:"unixtime" validate:"gt=0"`
+}
+
+// BlockDealFilter narrows getallblockdeals, a zero field matches every deal.
+// Dates are IST days and both ends of the range are included.
+type BlockDealFilter struct {
+	FromDate string  `form:"fromDate" validate:"omitempty,datetime=2006-01-02"`
+	ToDate   string  `form:"toDate" validate:"omitempty,datetime=2006-01-02"`
+	Cocode   int     `form:"cocode" validate:"gte=0"`
+	Client   string  `form:"client"`
+	BuySell  string  `form:"buySell" validate:"omitempty,oneof=B S BUY SELL"`
+	MinValue float64 `form:"minValue" validate:"gte=0"`
+	Page     int     `form:"page" validate:"gte=0"`
+	PageSize int     `form:"pageSize" validate:"gte=0,lte=500"`
+}
+
+type BlockDealsPage struct {
+	Deals    []BlockDeal `json:"deals"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"pageSize"`
+	Total    int         `json:"total"`
+}
+
+// BulkBlockDealsRequest is a csv file with a header row of BlockDeal json
+// names, or a json array of deals.
+type BulkBlockDealsRequest struct {
+	FileName string
+	Content  []byte
+}
+
+type BulkBlockDealsResponse struct {
+	Rows     int                 `json:"rows"`
+	Inserted int                 `json:"inserted"`
+	Updated  int                 `json:"updated"`
+	Errors   []BlockDealRowError `json:"errors,omitempty"`
+}
+
+type BlockDealRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
 }
@@ -710,3 +710,84 @@ type SectorWiseCompanyV2 struct {
 	NSEIndices []Index `json:"nseIndices"`
 	BSEIndices []Index `json:"bseIndices"`
 }
+
+type SectorHeatmapStock struct {
+	Isin         string  `json:"isin"`
+	Symbol       string  `json:"symbol"`
+	CoName       string  `json:"coName"`
+	Token        string  `json:"token"`
+	Ltp          float64 `json:"ltp"`
+	ChangePct    float64 `json:"changePct"`
+	Contribution float64 `json:"contribution"` // % points added to the sector move
+}
+
+type SectorHeatmap struct {
+	SectName        string               `json:"sectName"`
+	SectCode        []string             `json:"sectCode"`
+	ChangePct       float64              `json:"changePct"` // market cap weighted
+	Advances        int                  `json:"advances"`
+	Declines        int                  `json:"declines"`
+	Unchanged       int                  `json:"unchanged"`
+	TopContributors []SectorHeatmapStock `json:"topContributors"`
+	TopLaggards     []SectorHeatmapStock `json:"topLaggards"`
+}
+
+type MarketBreadth struct {
+	Name           string  `json:"name"`
+	Token          string  `json:"token,omitempty"`
+	Total          int     `json:"total"`
+	Advances       int     `json:"advances"`
+	Declines       int     `json:"declines"`
+	Unchanged      int     `json:"unchanged"`
+	AdRatio        float64 `json:"adRatio"`
+	New52WeekHighs int     `json:"new52WeekHighs"`
+	New52WeekLows  int     `json:"new52WeekLows"`
+	AboveDma200    int     `json:"aboveDma200"`
+	AboveDma200Pct float64 `json:"aboveDma200Pct"`
+}
+
+type SectorHeatmapRes struct {
+	Sectors   []SectorHeatmap `json:"sectors"`
+	Breadth   []MarketBreadth `json:"breadth"`
+	UpdatedAt string          `json:"updatedAt"`
+}
+
+type SectorFinancialSeries struct {
+	Isin string `json:"isin"`
+	FetchFinancialsDataV4
+}
+
+type CompareStocksReq struct {
+	IsinList []string `json:"isinList" validate:"required,min=1,max=5,dive,required" example:"INE002A01018,INE467B01029"`
+}
+
+type CompareStockInfo struct {
+	Isin        string `json:"isin"`
+	CompanyName string `json:"companyName"`
+	NseSymbol   string `json:"nseSymbol"`
+	Sector      string `json:"sector"`
+}
+
+type CompareMetricValue struct {
+	Isin             string   `json:"isin"`
+	Value            *float64 `json:"value"`
+	SectorPercentile *float64 `json:"sectorPercentile"` // 100 is best in sector
+	Cagr5Y           *float64 `json:"cagr5Y"`
+}
+
+type CompareMetric struct {
+	Key            string               `json:"key"`
+	Label          string               `json:"label"`
+	Group          string               `json:"group"`
+	HigherIsBetter bool                 `json:"higherIsBetter"`
+	Values         []CompareMetricValue `json:"values"`
+}
+
+type CompareStocksRes struct {
+	Stocks  []CompareStockInfo `json:"stocks"`
+	Metrics []CompareMetric    `json:"metrics"`
+}
+
+type ExportStockComparisonRes struct {
+	DownloadUrl string `json:"downloadUrl"`
+}
@@ -192,3 +192,38 @@ type ScripInfoResponse struct {
 		MtfMargin                float64 `json:"mtf_margin"`
 	} `json:"result"`
 }
+
+// SearchClickReq records which search result a client opened.
+type SearchClickReq struct {
+	Exchange string `json:"exchange" enums:"NSE,BSE,NFO,CDS,MCX,BFO" validate:"required,oneof=NSE BSE NFO CDS MCX BFO"`
+	Token    string `json:"token" validate:"required"`
+	Query    string `json:"query"`
+}
+
+// SearchAffinity is the per client set of instruments search boosts, cached in redis.
+type SearchAffinity struct {
+	HoldingIsins   []string `json:"holdingIsins"`
+	PositionKeys   []string `json:"positionKeys"`
+	WatchlistKeys  []string `json:"watchlistKeys"`
+	WatchlistIsins []string `json:"watchlistIsins"`
+}
+
+// SearchRecent holds a client's recent search queries and result clicks.
+type SearchRecent struct {
+	Queries []SearchRecentEntry `json:"queries"`
+	Clicks  []SearchRecentEntry `json:"clicks"`
+}
+
+type SearchRecentEntry struct {
+	Key    string `json:"key"`
+	Count  int    `json:"count"`
+	LastAt int64  `json:"lastAt"`
+}
+
+// SearchBackendStatus is reported by health, Backend is redisearch or local.
+type SearchBackendStatus struct {
+	Backend            string `json:"backend"`
+	LocalIndexSize     int    `json:"localIndexSize"`
+	LocalIndexBuiltAt  int64  `json:"localIndexBuiltAt"`
+	RediSearchDownTill int64  `json:"rediSearchDownTill,omitempty"`
+}
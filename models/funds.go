@@ -117,3 +117,122 @@ type PayoutQueueDetails struct {
 	IFSC          string `json:"ifsc"`
 	AccountNumber string `json:"accountNumber"`
 }
+
+type PayoutTransitionRequest struct {
+	TransactionId          string `json:"transactionId" validate:"required"`
+	Status                 string `json:"status" validate:"required"`
+	Reason                 string `json:"reason"`
+	UpdatedBy              string `json:"updatedBy" validate:"required"`
+	IdempotencyKey         string `json:"idempotencyKey" validate:"required"`
+	TradelabFundsUpdated   bool   `json:"tradelabFundsUpdated"`
+	BackofficeFundsUpdated bool   `json:"backofficeFundsUpdated"`
+}
+
+type PayoutTransitionResponse struct {
+	TransactionId string `json:"transactionId"`
+	From          string `json:"from"`
+	Status        string `json:"status"`
+	Applied       bool   `json:"applied"`
+}
+
+type FetchPayoutHistoryRequest struct {
+	TransactionId string `json:"transactionId" validate:"required"`
+}
+
+type FetchPayoutHistoryResponse struct {
+	Payout  PayoutDetails         `json:"payout"`
+	History []PayoutStatusHistory `json:"history"`
+}
+
+// PayoutStatusHistory is one row of the payout_status_history audit table.
+type PayoutStatusHistory struct {
+	TransactionId  string    `json:"transactionId"`
+	FromStatus     string    `json:"fromStatus"`
+	ToStatus       string    `json:"toStatus"`
+	Reason         string    `json:"reason"`
+	UpdatedBy      string    `json:"updatedBy"`
+	IdempotencyKey string    `json:"idempotencyKey"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type MongoPayoutMismatch struct {
+	TransactionId string `json:"transactionId" bson:"transactionId"`
+	ClientId      string `json:"clientId" bson:"clientId"`
+	Kind          string `json:"kind" bson:"kind"`
+	Status        string `json:"status" bson:"status"`
+	Amount        int64  `json:"amount" bson:"amount"`
+	FirstSeenAt   int64  `json:"firstSeenAt" bson:"firstSeenAt"`
+	LastSeenAt    int64  `json:"lastSeenAt" bson:"lastSeenAt"`
+}
+
+type UpiCollectRequest struct {
+	ClientID string `json:"clientId" validate:"required"`
+	Amount   string `json:"amount" validate:"required,numeric"`
+	UpiId    string `json:"upiId" mask:"id"`
+}
+
+type UpiIntentRequest struct {
+	ClientID string `json:"clientId" validate:"required"`
+	Amount   string `json:"amount" validate:"required,numeric"`
+}
+
+type UpiPayinResponse struct {
+	TransactionId string `json:"transactionId"`
+	Mode          string `json:"mode"`
+	Status        string `json:"status"`
+	IntentUrl     string `json:"intentUrl,omitempty"`
+	ExpiresAt     int64  `json:"expiresAt"`
+}
+
+type UpiPayinStatusRequest struct {
+	ClientID      string `json:"clientId" validate:"required"`
+	TransactionId string `json:"transactionId" validate:"required"`
+}
+
+type UpiPayinStatusResponse struct {
+	TransactionId string `json:"transactionId"`
+	Mode          string `json:"mode"`
+	Status        string `json:"status"`
+	Amount        int64  `json:"amount"`
+	UpdatedAt     int64  `json:"updatedAt"`
+}
+
+type UpiWebhookRequest struct {
+	Gateway   string
+	Body      []byte
+	Signature string
+}
+
+// UpiPayinOrder is what a gateway is asked to collect. Amounts are in paisa.
+type UpiPayinOrder struct {
+	TransactionId string
+	ClientId      string
+	UpiId         string
+	AmountInPaisa int64
+	ExpiresAt     int64
+}
+
+type UpiGatewayOrder struct {
+	GatewayOrderId string
+	IntentUrl      string
+}
+
+// UpiWebhookEvent is a verified gateway callback with Status already mapped
+// to TransactionSuccess, TransactionFailed or left empty while in progress.
+type UpiWebhookEvent struct {
+	EventId        string
+	TransactionId  string
+	GatewayOrderId string
+	Status         string
+	AmountInPaisa  int64
+	Reason         string
+}
+
+type PayinCreditEvent struct {
+	TransactionId string `json:"transactionId"`
+	ClientId      string `json:"clientId"`
+	AmountInPaisa int64  `json:"amountInPaisa"`
+	UpiId         string `json:"upiId"`
+	Gateway       string `json:"gateway"`
+	CreditedAt    int64  `json:"creditedAt"`
+}
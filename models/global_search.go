@@ -0,0 +1,44 @@
+package models
+
+type GlobalSearchReq struct {
+	Query string   `json:"query"`
+	Types []string `json:"types"`
+	Limit int      `json:"limit"`
+}
+
+type GlobalSearchResult struct {
+	Type     string      `json:"type"`
+	Id       string      `json:"id"`
+	Title    string      `json:"title"`
+	Subtitle string      `json:"subtitle"`
+	Score    float64     `json:"score"`
+	Data     interface{} `json:"data"`
+}
+
+type GlobalSearchGroup struct {
+	Type    string               `json:"type"`
+	Total   int                  `json:"total"`
+	Results []GlobalSearchResult `json:"results"`
+}
+
+type GlobalSearchResponse struct {
+	Query         string               `json:"query"`
+	TopResults    []GlobalSearchResult `json:"topResults"`
+	Groups        []GlobalSearchGroup  `json:"groups"`
+	FailedSources []string             `json:"failedSources"`
+}
+
+type GlobalSearchBond struct {
+	ISIN           string       `json:"isin" bson:"isin"`
+	IssuerName     string       `json:"issuerName" bson:"issuername"`
+	CouponRate     string       `json:"couponRate" bson:"couponrate"`
+	Yield          string       `json:"yield" bson:"yield"`
+	MaturityDate   string       `json:"maturityDate" bson:"maturitydate"`
+	CreditRating   RatingPacket `json:"creditRating" bson:"creditrating"`
+	BusinessSector string       `json:"businessSector" bson:"businesssector"`
+}
+
+type GlobalSearchSector struct {
+	SectName  string   `json:"sectName"`
+	SectCodes []string `json:"sectCode"`
+}
@@ -16,10 +16,14 @@ type ReqHeader struct {
 	FCMToken        string `header:"DeviceToken"`
 	AdminRequestKey string `header:"P-AdminRequestKey"`
 	ClientVersion   string `header:"P-ClientVersion"`
+	// the named admin AdminMiddleware authenticated, never read from headers
+	AdminUserId string `header:"-"`
+	AdminRole   string `header:"-"`
 }
 
 type Pong struct {
-	DT time.Time `json:"time"`
+	DT            time.Time            `json:"time"`
+	SearchBackend *SearchBackendStatus `json:"searchBackend,omitempty"`
 }
 
 type TokenHeaders struct {
@@ -26,6 +26,7 @@ type LoginProvider interface {
 	HandleAuthCode(string, string, ReqHeader) (int, apihelpers.APIRes)
 	GetAccessToken(GetAccessTokenReq, ReqHeader) (int, apihelpers.APIRes)
 	GenerateAccessToken(string, string, ReqHeader) (int, apihelpers.APIRes)
+	FetchAppActivity(string, ReqHeader) (int, apihelpers.APIRes)
 }
 
 type LoginProviderV2 interface {
@@ -103,6 +104,12 @@ type FetchFundsProvider interface {
 type FetchFundsProviderV3 interface {
 	CancelPayout(CancelPayoutReqV3, ReqHeader) (int, apihelpers.APIRes)
 	Payout(AtomPayoutRequest, ReqHeader) (int, apihelpers.APIRes)
+	TransitionPayout(PayoutTransitionRequest, ReqHeader) (int, apihelpers.APIRes)
+	FetchPayoutHistory(FetchPayoutHistoryRequest, ReqHeader) (int, apihelpers.APIRes)
+	UpiCollect(UpiCollectRequest, ReqHeader) (int, apihelpers.APIRes)
+	UpiIntent(UpiIntentRequest, ReqHeader) (int, apihelpers.APIRes)
+	UpiPayinStatus(UpiPayinStatusRequest, ReqHeader) (int, apihelpers.APIRes)
+	UpiPayinWebhook(UpiWebhookRequest, ReqHeader) (int, apihelpers.APIRes)
 }
 
 type ContractDetailsProvider interface {
@@ -117,6 +124,11 @@ type ScripProvider interface {
 type ContractDetailsProviderV2 interface {
 	SearchScrip(string, ReqHeader, int, int, string) (int, apihelpers.APIRes)
 	PerformSearch(string, string, int, int) ([]interface{}, error)
+	RecordSearchClick(SearchClickReq, ReqHeader) (int, apihelpers.APIRes)
+}
+
+type GlobalSearchProvider interface {
+	GlobalSearch(GlobalSearchReq, ReqHeader) (int, apihelpers.APIRes)
 }
 
 type ConditionalOrderProvider interface {
@@ -139,6 +151,8 @@ type IpoProvider interface {
 	FetchIpoData(FetchIpoDataRequest, ReqHeader) (int, apihelpers.APIRes)
 	FetchIpoGmpData(FetchIpoDataRequest, ReqHeader) (int, apihelpers.APIRes)
 	FetchEIpo(FetchEipoReq, ReqHeader) (int, apihelpers.APIRes)
+	FetchIpoHistory(FetchIpoHistoryRequest, ReqHeader) (int, apihelpers.APIRes)
+	FetchIpoSubscriptionHistory(FetchIpoSubscriptionHistoryRequest, ReqHeader) (int, apihelpers.APIRes)
 }
 
 type GainerLoserProvider interface {
@@ -230,6 +244,9 @@ type CMOTSProviderV2 interface {
 	FetchPeersV2(FetchPeersV2Req, ReqHeader) (int, apihelpers.APIRes)
 	FetchSectorListV2(sectorCode string, requestH ReqHeader) (int, apihelpers.APIRes)
 	FetchSectorWiseCompanyV2(FetchSectorWiseCompanyReqV2, ReqHeader) (int, apihelpers.APIRes)
+	FetchSectorHeatmap(ReqHeader) (int, apihelpers.APIRes)
+	CompareStocks(CompareStocksReq, ReqHeader) (int, apihelpers.APIRes)
+	ExportStockComparison(CompareStocksReq, ReqHeader) (int, apihelpers.APIRes)
 }
 
 type UserDetailsProvider interface {
@@ -303,6 +320,9 @@ type OptionChainProviderV2 interface {
 
 type LogoutProvider interface {
 	LogoutSingleDevice(ReqHeader) (int, apihelpers.APIRes)
+	ListSessions(ClientSessionsReq, ReqHeader) (int, apihelpers.APIRes)
+	RevokeSession(RevokeSessionReq, ReqHeader) (int, apihelpers.APIRes)
+	LogoutOtherDevices(ReqHeader) (int, apihelpers.APIRes)
 }
 
 type FinvuProvider interface {
@@ -337,6 +357,7 @@ type ReportsProvider interface {
 	DownloadOpenPosition(OpenPositionReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
 	ViewFnoPnl(FnoPnlReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
 	DownloadFnoPnl(FnoPnlReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
+	SendEmailFnoPnl(FnoPnlReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
 	ViewHoldingFinancial(GetHoldingFinancialDataReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
 	DownloadHoldingFinancial(GetHoldingFinancialDataReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
 	SendEmailHoldingFinancial(GetHoldingFinancialDataReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
@@ -347,6 +368,11 @@ type ReportsProvider interface {
 	ViewFnoTradebook(FNOTradebookReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
 	DownloadFnoTradebook(FNOTradebookReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
 	SendEmailFnoTradebook(FNOTradebookReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
+	SubmitReportJob(SubmitReportJobReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
+	FetchReportJobStatus(ReportJobStatusReq, ReqHeader) (int, apihelpers.APIRes)
+	SubscribeStatement(StatementSubscriptionReq, ReqHeader, ProfileDataResp) (int, apihelpers.APIRes)
+	UnsubscribeStatement(StatementSubscriptionReq, ReqHeader) (int, apihelpers.APIRes)
+	FetchStatementSubscriptions(StatementSubscriptionsReq, ReqHeader) (int, apihelpers.APIRes)
 }
 type ExecutePocketV2 interface {
 	BuyPocketV2(ExecutePocketV2Request, ReqHeader) (int, apihelpers.APIRes)
@@ -368,6 +394,13 @@ type CollectionsProvider interface {
 	FetchAllCollections(ReqHeader) (int, apihelpers.APIRes)
 }
 
+type AdminsProvider interface {
+	CreateAdmin(CreateAdminRequest, ReqHeader) (int, apihelpers.APIRes)
+	UpdateAdmin(UpdateAdminRequest, ReqHeader) (int, apihelpers.APIRes)
+	FetchAdmins(ReqHeader) (int, apihelpers.APIRes)
+	FetchAdminAudit(FetchAdminAuditRequest, ReqHeader) (int, apihelpers.APIRes)
+}
+
 type PinsProvider interface {
 	AddPins(AddPinReq, ReqHeader) (int, apihelpers.APIRes)
 	DeletePins(DeletePins, ReqHeader) (int, apihelpers.APIRes)
@@ -408,6 +441,15 @@ type WatchListProvider interface {
 	PopulateIsinMappingInLocalCache()
 	ArrangeStocksWatchListV3(req ArrangeStocksWatchListV3Request, reqH ReqHeader) (int, apihelpers.APIRes)
 	DeleteStockInWatchListV3Updated(req DeleteWatchListV3UpdatedRequest, reqH ReqHeader) (int, apihelpers.APIRes)
+	ImportWatchList(req ImportWatchListRequest, reqH ReqHeader) (int, apihelpers.APIRes)
+	ExportWatchList(req ExportWatchListRequest, reqH ReqHeader) (int, apihelpers.APIRes)
+	ShareWatchList(req ShareWatchListRequest, reqH ReqHeader) (int, apihelpers.APIRes)
+	FetchSharedWatchList(req FetchSharedWatchListRequest, reqH ReqHeader) (int, apihelpers.APIRes)
+	CloneSharedWatchList(req CloneSharedWatchListRequest, reqH ReqHeader) (int, apihelpers.APIRes)
+	RevokeSharedWatchList(req RevokeSharedWatchListRequest, reqH ReqHeader) (int, apihelpers.APIRes)
+	CreateSmartWatchList(req CreateSmartWatchListRequest, reqH ReqHeader) (int, apihelpers.APIRes)
+	UpdateSmartWatchList(req UpdateSmartWatchListRequest, reqH ReqHeader) (int, apihelpers.APIRes)
+	DeleteSmartWatchList(req DeleteSmartWatchListRequest, reqH ReqHeader) (int, apihelpers.APIRes)
 }
 
 type FreshdeskProvider interface {
@@ -457,12 +499,25 @@ type SipProvider interface {
 	UpdateSipStatus(UpdateSipStatusRequest, ReqHeader) (int, apihelpers.APIRes)
 }
 
+type ScreenerProvider interface {
+	RunScreener(RunScreenerReq, ReqHeader) (int, apihelpers.APIRes)
+	SaveScreener(SaveScreenerReq, ReqHeader) (int, apihelpers.APIRes)
+	FetchScreeners(ReqHeader) (int, apihelpers.APIRes)
+	DeleteScreener(DeleteScreenerReq, ReqHeader) (int, apihelpers.APIRes)
+}
+
+type BacktestProvider interface {
+	RunBacktest(RunBacktestReq, ReqHeader) (int, apihelpers.APIRes)
+}
+
 // blockdeals interface...
 
 type BlockDealService interface {
+	ValidateBlockDeal(blockDeal *BlockDeal) error
 	CreateBlockDeal(c *gin.Context, blockDeal BlockDeal) error
-	GetAllBlockDeals(c *gin.Context) ([]BlockDeal, error)
+	GetAllBlockDeals(c *gin.Context, filter BlockDealFilter) (BlockDealsPage, error)
+	BulkUpsertBlockDeals(c *gin.Context, req BulkBlockDealsRequest) (BulkBlockDealsResponse, error)
 	GetBlockDealByID(c *gin.Context, id int) (BlockDeal, error)
-	UpdateBlockDeal(cocode int, blockDeal BlockDeal) error
+	UpdateBlockDeal(c *gin.Context, cocode int, blockDeal BlockDeal) error
 	DeleteBlockDeal(c *gin.Context, id int) error
 }
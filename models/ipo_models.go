@@ -268,3 +268,88 @@ type FetchNseIpoResponse struct {
 	BidDeatils interface{} `json:"bidDetails"`
 	SubsTimes  string      `json:"subsTimes"` // No of times issue subscribed
 }
+
+type FetchIpoHistoryRequest struct {
+	ClientID string `json:"clientId" validate:"required"`
+}
+
+type FetchIpoHistoryResponse struct {
+	Applications []MongoIpoApplication `json:"applications"`
+}
+
+type IpoApplicationTransition struct {
+	From   string `json:"from" bson:"from"`
+	To     string `json:"to" bson:"to"`
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"`
+	At     int64  `json:"at" bson:"at"`
+}
+
+type MongoIpoApplication struct {
+	ClientId          string                     `json:"clientId" bson:"clientId"`
+	Symbol            string                     `json:"symbol" bson:"symbol"`
+	ApplicationNumber string                     `json:"applicationNumber" bson:"applicationNumber"`
+	Category          string                     `json:"category" bson:"category"`
+	Quantity          int                        `json:"quantity" bson:"quantity"`
+	Amount            int                        `json:"amount" bson:"amount"`
+	AllotmentQuantity int                        `json:"allotmentQuantity" bson:"allotmentQuantity"`
+	Status            string                     `json:"status" bson:"status"`
+	History           []IpoApplicationTransition `json:"history" bson:"history"`
+	CreatedAt         int64                      `json:"createdAt" bson:"createdAt"`
+	UpdatedAt         int64                      `json:"updatedAt" bson:"updatedAt"`
+}
+
+// IpoApplicationEvent is published for every status change of an application.
+type IpoApplicationEvent struct {
+	ClientId          string `json:"clientId"`
+	Symbol            string `json:"symbol"`
+	ApplicationNumber string `json:"applicationNumber"`
+	From              string `json:"from"`
+	To                string `json:"to"`
+	Reason            string `json:"reason"`
+	At                int64  `json:"at"`
+}
+
+type FetchIpoSubscriptionHistoryRequest struct {
+	Symbol string `json:"symbol" validate:"required"`
+}
+
+type FetchIpoSubscriptionHistoryResponse struct {
+	Symbol  string                  `json:"symbol"`
+	Latest  *MongoIpoSubscription   `json:"latest,omitempty"`
+	Series  []IpoSubscriptionSeries `json:"series"`
+	Listing *MongoIpoListing        `json:"listing,omitempty"`
+}
+
+// IpoSubscriptionSeries is one chart line: a subscription category or the GMP.
+type IpoSubscriptionSeries struct {
+	Name   string           `json:"name"`
+	Points []IpoSeriesPoint `json:"points"`
+}
+
+type IpoSeriesPoint struct {
+	At    int64   `json:"at"`
+	Value float64 `json:"value"`
+}
+
+type MongoIpoSubscription struct {
+	Symbol          string             `json:"symbol" bson:"symbol"`
+	Name            string             `json:"name" bson:"name"`
+	Multiples       map[string]float64 `json:"multiples" bson:"multiples"`
+	Gmp             *float64           `json:"gmp,omitempty" bson:"gmp,omitempty"`
+	SourceUpdatedAt string             `json:"sourceUpdatedAt" bson:"sourceUpdatedAt"`
+	CapturedAt      int64              `json:"capturedAt" bson:"capturedAt"`
+}
+
+type MongoIpoListing struct {
+	Symbol          string   `json:"symbol" bson:"symbol"`
+	ListingDate     string   `json:"listingDate" bson:"listingDate"`
+	Exchange        string   `json:"exchange" bson:"exchange"`
+	IssuePrice      float64  `json:"issuePrice" bson:"issuePrice"`
+	ListingPrice    float64  `json:"listingPrice" bson:"listingPrice"`
+	ListingDayClose float64  `json:"listingDayClose" bson:"listingDayClose"`
+	ListingGainPct  float64  `json:"listingGainPct" bson:"listingGainPct"`
+	Gmp             *float64 `json:"gmp,omitempty" bson:"gmp,omitempty"`
+	GmpImpliedPrice *float64 `json:"gmpImpliedPrice,omitempty" bson:"gmpImpliedPrice,omitempty"`
+	GainVsGmpPct    *float64 `json:"gainVsGmpPct,omitempty" bson:"gainVsGmpPct,omitempty"`
+	ComputedAt      int64    `json:"computedAt" bson:"computedAt"`
+}
@@ -0,0 +1,38 @@
+package models
+
+// JwtKeysConfig is the keyset guest and admin tokens are signed with. Tokens
+// are signed with ActiveKid and verified with any key that has not retired,
+// so a new key can be published before it is made active and an old one kept
+// until the tokens it signed have expired. HS512 tokens signed with the
+// legacy secret, which carry no kid, are accepted until LegacyUntil.
+type JwtKeysConfig struct {
+	ActiveKid   string         `json:"activeKid" mapstructure:"activeKid"`
+	LegacyUntil string         `json:"legacyUntil" mapstructure:"legacyUntil"`
+	Keys        []JwtKeyConfig `json:"keys" mapstructure:"keys"`
+}
+
+// JwtKeyConfig is one RS256 or EdDSA key in PEM. A key without PrivateKey
+// can only verify.
+type JwtKeyConfig struct {
+	Kid        string `json:"kid" mapstructure:"kid"`
+	Alg        string `json:"alg" mapstructure:"alg"`
+	PrivateKey string `json:"privateKey" mapstructure:"privateKey"`
+	PublicKey  string `json:"publicKey" mapstructure:"publicKey"`
+	RetireAt   string `json:"retireAt" mapstructure:"retireAt"`
+}
+
+// Jwks is a JSON Web Key Set as defined in RFC 7517.
+type Jwks struct {
+	Keys []Jwk `json:"keys"`
+}
+
+type Jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
@@ -52,10 +52,13 @@ type LocalCreds struct {
 			BucketNamePocket string `json:"BucketNamePocket"`
 			BucketName       string `json:"BucketName"`
 		} `json:"awsS3CredConfig"`
-		FinvuPass       string `json:"FinvuPass"`
-		FinvuRid        string `json:"FinvuRid"`
-		FreshDeskApiKey string `json:"freshDeskApiKey"`
-		FreshDeskPass   string `json:"freshDeskPass"`
-		AuthKeyMsg91    string `json:"authKeyMsg91"`
+		FinvuPass              string        `json:"FinvuPass"`
+		FinvuRid               string        `json:"FinvuRid"`
+		FreshDeskApiKey        string        `json:"freshDeskApiKey"`
+		FreshDeskPass          string        `json:"freshDeskPass"`
+		AuthKeyMsg91           string        `json:"authKeyMsg91"`
+		JwtKeys                JwtKeysConfig `json:"jwtKeys"`
+		FakeUpiSecret          string        `json:"fakeUpiSecret"`
+		TradelabReconcileToken string        `json:"tradelabReconcileToken"`
 	} `json:"local"`
 }
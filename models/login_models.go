@@ -224,6 +224,7 @@ type CreateAppReq struct {
 	Scope        string   `json:"scope"`
 	GrantTypes   []string `json:"grantTypes"`
 	Owner        string   `json:"owner"`
+	QuotaPerMin  int      `json:"quotaPerMin"`
 }
 
 type CreateAppRes struct {
@@ -243,6 +244,7 @@ type AppDetails struct {
 	AuthCode           string   `json:"authCode" bson:"authCode"`
 	AccessToken        string   `json:"accessToken" bson:"accessToken"`
 	ExpiryTime         string   `json:"expiryTime" bson:"expiryTime"`
+	QuotaPerMin        int      `json:"quotaPerMin" bson:"quotaPerMin"`
 }
 
 type GetAccessTokenReq struct {
@@ -1,8 +1,17 @@
 package models
 
+import "time"
+
 type MongoAdmin struct {
-	UserId   string `json:"userId" bson:"userId"`
-	Password string `json:"password" bson:"password"`
+	UserId            string    `json:"userId" bson:"userId"`
+	Password          string    `json:"password" bson:"password"`
+	Name              string    `json:"name" bson:"name"`
+	Role              string    `json:"role" bson:"role"`
+	Disabled          bool      `json:"disabled" bson:"disabled"`
+	CreatedBy         string    `json:"createdBy" bson:"createdBy"`
+	CreatedAt         time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt" bson:"updatedAt"`
+	PasswordUpdatedAt time.Time `json:"passwordUpdatedAt" bson:"passwordUpdatedAt"`
 }
 
 type MongoPocketsMetaData struct {
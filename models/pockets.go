@@ -9,6 +9,7 @@ type AdminLoginRequest struct {
 
 type AdminLoginResponse struct {
 	AuthToken string `json:"authToken"`
+	Role      string `json:"role"`
 }
 
 type PocketsMetaData struct {
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+type SubmitReportJobReq struct {
+	UserID   string `json:"userID"`
+	Report   string `json:"report" validate:"required,oneof=ledger tradebook openPosition fnoPnl holdingFinancial dpCharges commodityTradebook fnoTradebook"`
+	DFDateFr string `json:"dfDateFr" example:"01-04-2026"`
+	DFDateTo string `json:"dfDateTo" example:"30-09-2026"`
+	DsFlag   string `json:"dsFlag" example:"D,S" validate:"omitempty,oneof=D S"`
+	Format   string `json:"format" validate:"omitempty,oneof=xlsx csv pdf"`
+	Delivery string `json:"delivery" validate:"omitempty,oneof=download email"`
+}
+
+type SubmitReportJobRes struct {
+	JobId        string `json:"jobId"`
+	Status       string `json:"status"`
+	Deduplicated bool   `json:"deduplicated"`
+	ExpiresAt    int64  `json:"expiresAt"`
+}
+
+type ReportJobStatusReq struct {
+	UserID string `json:"userID"`
+	JobId  string `json:"jobId" validate:"required"`
+}
+
+type ReportJobStatusRes struct {
+	JobId       string `json:"jobId"`
+	Report      string `json:"report"`
+	Format      string `json:"format"`
+	Delivery    string `json:"delivery"`
+	Status      string `json:"status"`
+	Progress    int    `json:"progress"`
+	DownloadUrl string `json:"downloadUrl,omitempty"`
+	Error       string `json:"error,omitempty"`
+	CreatedAt   int64  `json:"createdAt"`
+	UpdatedAt   int64  `json:"updatedAt"`
+	ExpiresAt   int64  `json:"expiresAt"`
+}
+
+// MongoReportJob is one report generation request. ExpiresAt is what the
+// collection's TTL index removes jobs by.
+type MongoReportJob struct {
+	JobId       string          `bson:"jobId"`
+	DedupKey    string          `bson:"dedupKey"`
+	ClientId    string          `bson:"clientId"`
+	Report      string          `bson:"report"`
+	DateFrom    string          `bson:"dateFrom"`
+	DateTo      string          `bson:"dateTo"`
+	DsFlag      string          `bson:"dsFlag"`
+	Format      string          `bson:"format"`
+	Delivery    string          `bson:"delivery"`
+	UserDetails ProfileDataResp `bson:"userDetails"`
+	Platform    string          `bson:"platform"`
+	DeviceType  string          `bson:"deviceType"`
+	DeviceId    string          `bson:"deviceId"`
+	Status      string          `bson:"status"`
+	Live        bool            `bson:"live"` // can still be returned for its dedup key
+	Progress    int             `bson:"progress"`
+	Attempts    int             `bson:"attempts"`
+	DownloadUrl string          `bson:"downloadUrl"`
+	Error       string          `bson:"error"`
+	CreatedAt   time.Time       `bson:"createdAt"`
+	UpdatedAt   time.Time       `bson:"updatedAt"`
+	ClaimedAt   time.Time       `bson:"claimedAt"`
+	ExpiresAt   time.Time       `bson:"expiresAt"`
+}
@@ -0,0 +1,79 @@
+package models
+
+type ScreenerStock struct {
+	Isin         string  `json:"isin"`
+	CoCode       int     `json:"coCode"`
+	CompanyName  string  `json:"companyName"`
+	NseSymbol    string  `json:"nseSymbol"`
+	Sector       string  `json:"sector"`
+	MarketCap    float64 `json:"marketCap"`
+	PE           float64 `json:"pe"`
+	PB           float64 `json:"pb"`
+	SectorPE     float64 `json:"sectorPe"`
+	ROE          float64 `json:"roe"`
+	EPS          float64 `json:"eps"`
+	DivYield     float64 `json:"divYield"`
+	BookValue    float64 `json:"bookValue"`
+	DebtToEquity float64 `json:"debtToEquity"`
+	Price        float64 `json:"price"`
+	RSI          float64 `json:"rsi"`
+	MACD         float64 `json:"macd"`
+	MACDSignal   float64 `json:"macdSignal"`
+	SMA10        float64 `json:"sma10"`
+	SMA20        float64 `json:"sma20"`
+	SMA50        float64 `json:"sma50"`
+	SMA100       float64 `json:"sma100"`
+	SMA200       float64 `json:"sma200"`
+	EMA10        float64 `json:"ema10"`
+	EMA20        float64 `json:"ema20"`
+	EMA50        float64 `json:"ema50"`
+}
+
+type RunScreenerReq struct {
+	Query      string `json:"query" example:"PE < 20 AND ROE > 15 AND RSI(14) < 30 AND sector = 'IT' AND mcap > 5000cr"`
+	ScreenerId string `json:"screenerId"`
+	Page       int    `json:"page" validate:"gte=0"`
+	PageSize   int    `json:"pageSize" validate:"gte=0,lte=100"`
+	SortBy     string `json:"sortBy" example:"mcap"`
+	SortOrder  string `json:"sortOrder" validate:"omitempty,oneof=asc desc"`
+}
+
+type RunScreenerRes struct {
+	Query    string          `json:"query"`
+	Total    int             `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"pageSize"`
+	Results  []ScreenerStock `json:"results"`
+}
+
+type SaveScreenerReq struct {
+	ScreenerId string `json:"screenerId"`
+	Name       string `json:"name" validate:"required,max=50"`
+	Query      string `json:"query" validate:"required"`
+	SortBy     string `json:"sortBy"`
+	SortOrder  string `json:"sortOrder" validate:"omitempty,oneof=asc desc"`
+}
+
+type DeleteScreenerReq struct {
+	ScreenerId string `json:"screenerId" validate:"required"`
+}
+
+type SavedScreener struct {
+	ScreenerId string `json:"screenerId" bson:"screenerId"`
+	Name       string `json:"name" bson:"name"`
+	Query      string `json:"query" bson:"query"`
+	SortBy     string `json:"sortBy" bson:"sortBy"`
+	SortOrder  string `json:"sortOrder" bson:"sortOrder"`
+	CreatedAt  int64  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt  int64  `json:"updatedAt" bson:"updatedAt"`
+}
+
+type MongoScreeners struct {
+	ClientId  string          `json:"clientId" bson:"clientId"`
+	Screeners []SavedScreener `json:"screeners" bson:"screeners"`
+}
+
+type FetchScreenersRes struct {
+	ClientId  string          `json:"clientId"`
+	Screeners []SavedScreener `json:"screeners"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+type ClientSessionsReq struct {
+	ClientId string `json:"clientId"`
+}
+
+type ClientSessionsRes struct {
+	Sessions []ClientSessionRes `json:"sessions"`
+}
+
+type ClientSessionRes struct {
+	SessionId  string `json:"sessionId"`
+	DeviceId   string `json:"deviceId"`
+	DeviceType string `json:"deviceType"`
+	Platform   string `json:"platform"`
+	Ip         string `json:"ip"`
+	AppVersion string `json:"appVersion"`
+	Current    bool   `json:"current"`
+	CreatedAt  int64  `json:"createdAt"`
+	LastSeenAt int64  `json:"lastSeenAt"`
+}
+
+type RevokeSessionReq struct {
+	ClientId  string `json:"clientId"`
+	SessionId string `json:"sessionId" validate:"required"`
+}
+
+type LogoutOtherDevicesRes struct {
+	Revoked int `json:"revoked"`
+}
+
+// MongoClientSession is one signed in token of a client. SessionId is a hash
+// of the token so the token itself is never stored, and LastSeenAt moves each
+// time the token is revalidated.
+type MongoClientSession struct {
+	SessionId     string    `bson:"sessionId"`
+	ClientId      string    `bson:"clientId"`
+	DeviceId      string    `bson:"deviceId"`
+	DeviceType    string    `bson:"deviceType"`
+	Platform      string    `bson:"platform"`
+	Ip            string    `bson:"ip"`
+	AppVersion    string    `bson:"appVersion"`
+	Status        string    `bson:"status"`
+	RevokedReason string    `bson:"revokedReason"`
+	CreatedAt     time.Time `bson:"createdAt"`
+	LastSeenAt    time.Time `bson:"lastSeenAt"`
+	RevokedAt     time.Time `bson:"revokedAt"`
+	ExpiresAt     time.Time `bson:"expiresAt"`
+}
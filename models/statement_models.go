@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+type StatementSubscriptionReq struct {
+	UserID    string `json:"userID"`
+	Statement string `json:"statement" validate:"required,oneof=monthlyLedger quarterlyHoldings yearlyPnl"`
+}
+
+type StatementSubscriptionsReq struct {
+	UserID string `json:"userID"`
+}
+
+type StatementSubscriptionsRes struct {
+	Subscriptions []StatementSubscriptionRes `json:"subscriptions"`
+	Runs          []StatementRunRes          `json:"runs"`
+}
+
+type StatementSubscriptionRes struct {
+	Statement  string `json:"statement"`
+	Subscribed bool   `json:"subscribed"`
+	Email      string `json:"email,omitempty"`
+	NextPeriod string `json:"nextPeriod"`
+}
+
+type StatementRunRes struct {
+	Statement string `json:"statement"`
+	Period    string `json:"period"`
+	DateFrom  string `json:"dateFrom"`
+	DateTo    string `json:"dateTo"`
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+	Error     string `json:"error,omitempty"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+// MongoStatementSubscription is one client's subscription to one statement.
+// UserDetails is captured when subscribing, as the scheduler has no session
+// to fetch the profile with.
+type MongoStatementSubscription struct {
+	ClientId      string          `bson:"clientId"`
+	Statement     string          `bson:"statement"`
+	Active        bool            `bson:"active"`
+	UserDetails   ProfileDataResp `bson:"userDetails"`
+	Platform      string          `bson:"platform"`
+	DeviceType    string          `bson:"deviceType"`
+	DeviceId      string          `bson:"deviceId"`
+	LastRunPeriod string          `bson:"lastRunPeriod"`
+	CreatedAt     time.Time       `bson:"createdAt"`
+	UpdatedAt     time.Time       `bson:"updatedAt"`
+}
+
+// MongoStatementRun is the delivery of one statement period to one client.
+type MongoStatementRun struct {
+	RunId     string    `bson:"runId"`
+	ClientId  string    `bson:"clientId"`
+	Statement string    `bson:"statement"`
+	Period    string    `bson:"period"`
+	DateFrom  string    `bson:"dateFrom"`
+	DateTo    string    `bson:"dateTo"`
+	Status    string    `bson:"status"`
+	Attempts  int       `bson:"attempts"`
+	Error     string    `bson:"error"`
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
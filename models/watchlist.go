@@ -128,7 +128,11 @@ type WatchList struct {
 }
 
 type FetchWatchListV3Request struct {
-	ClientId string `json:"clientId"`
+	ClientId  string `json:"clientId"`
+	Enrich    bool   `json:"enrich"`
+	SortBy    string `json:"sortBy" validate:"omitempty,oneof=changePct name custom"`
+	SortOrder string `json:"sortOrder" validate:"omitempty,oneof=asc desc"`
+	GroupBy   string `json:"groupBy" validate:"omitempty,oneof=sector"`
 }
 
 type FetchWatchListV3Response struct {
@@ -154,24 +158,54 @@ type StockDetailsWithExpiry struct {
 }
 
 type StockDetailsV2 struct {
-	StockId       string `json:"stockId"`
-	Exchange      string `json:"exchange" example:"NSE,BSE,NFO,BFO,MCX,CDS" validate:"oneof=NSE BSE MCX NFO BFO CDS"`
-	Token         string `json:"token"`
-	Expiry        string `json:"expiry"`
-	Company       string `json:"company"`
-	Symbol        string `json:"symbol"`
-	TradingSymbol string `json:"tradingSymbol"`
-	DisplayName   string `json:"displayName"`
-	Isin          string `json:"isin"`
-	IsTradable    bool   `json:"isTradable"`
-	Segment       string `json:"segment"`
-	Execution     string `json:"execution"`
-	IsinStockId   string `json:"isinStockId"`
+	StockId       string          `json:"stockId"`
+	Exchange      string          `json:"exchange" example:"NSE,BSE,NFO,BFO,MCX,CDS" validate:"oneof=NSE BSE MCX NFO BFO CDS"`
+	Token         string          `json:"token"`
+	Expiry        string          `json:"expiry"`
+	Company       string          `json:"company"`
+	Symbol        string          `json:"symbol"`
+	TradingSymbol string          `json:"tradingSymbol"`
+	DisplayName   string          `json:"displayName"`
+	Isin          string          `json:"isin"`
+	IsTradable    bool            `json:"isTradable"`
+	Segment       string          `json:"segment"`
+	Execution     string          `json:"execution"`
+	IsinStockId   string          `json:"isinStockId"`
+	Quote         *WatchListQuote `json:"quote,omitempty" bson:"-"`
+}
+
+// WatchListQuote is the optional market data FetchWatchListsV3 attaches to a
+// stock. Fields a source could not provide are left empty.
+type WatchListQuote struct {
+	Ltp                 float64                   `json:"ltp"`
+	Change              float64                   `json:"change"`
+	ChangePct           float64                   `json:"changePct"`
+	Week52High          float64                   `json:"week52High"`
+	Week52Low           float64                   `json:"week52Low"`
+	MarketCap           float64                   `json:"marketCap"`
+	Sector              string                    `json:"sector,omitempty"`
+	NextCorporateAction *WatchListCorporateAction `json:"nextCorporateAction,omitempty"`
+}
+
+type WatchListCorporateAction struct {
+	Type        string `json:"type"`
+	Date        string `json:"date"`
+	Description string `json:"description"`
+}
+
+type WatchListGroup struct {
+	Name   string           `json:"name"`
+	Stocks []StockDetailsV2 `json:"stocks"`
 }
 
 type WatchListsDetailsV2 struct {
 	WatchListId string           `json:"watchListId"`
 	Stocks      []StockDetailsV2 `json:"stocks"`
+	IsSmart     bool             `json:"isSmart"`
+	Name        string           `json:"name,omitempty"`
+	Rule        *WatchListRule   `json:"rule,omitempty"`
+	EvaluatedAt int64            `json:"evaluatedAt,omitempty"`
+	Groups      []WatchListGroup `json:"groups,omitempty"`
 }
 
 type ArrangeStocksWatchListV3Request struct {
@@ -184,3 +218,124 @@ type DeleteWatchListV3UpdatedRequest struct {
 	ClientId    string      `json:"clientId"`
 	WatchListId []WatchList `json:"watchListId"`
 }
+
+type ImportWatchListRequest struct {
+	ClientId    string `json:"clientId"`
+	WatchListId string `json:"watchListId" validate:"required,oneof=wl1 wl2 wl3 wl4 wl5"`
+	Replace     bool   `json:"replace"`
+	FileName    string `json:"fileName" validate:"required"`
+	Content     []byte `json:"-"`
+}
+
+type ImportWatchListResponse struct {
+	WatchListId   string               `json:"watchListId"`
+	TotalRows     int                  `json:"totalRows"`
+	Imported      int                  `json:"imported"`
+	Duplicates    int                  `json:"duplicates"`
+	UnmatchedRows []WatchListImportRow `json:"unmatchedRows"`
+}
+
+type WatchListImportRow struct {
+	Row      int    `json:"row"`
+	Symbol   string `json:"symbol"`
+	Isin     string `json:"isin"`
+	Exchange string `json:"exchange"`
+	Reason   string `json:"reason"`
+}
+
+type ExportWatchListRequest struct {
+	ClientId    string `json:"clientId"`
+	WatchListId string `json:"watchListId" validate:"required,oneof=wl1 wl2 wl3 wl4 wl5"`
+	Format      string `json:"format" validate:"required,oneof=csv xlsx"`
+}
+
+type ExportWatchListResponse struct {
+	DownloadUrl string `json:"downloadUrl"`
+}
+
+type ShareWatchListRequest struct {
+	ClientId    string `json:"clientId"`
+	WatchListId string `json:"watchListId" validate:"required,oneof=wl1 wl2 wl3 wl4 wl5"`
+}
+
+type ShareWatchListResponse struct {
+	ShareId   string `json:"shareId"`
+	ShareLink string `json:"shareLink,omitempty"`
+}
+
+type FetchSharedWatchListRequest struct {
+	ShareId string `json:"shareId" validate:"required"`
+}
+
+type FetchSharedWatchListResponse struct {
+	ShareId  string           `json:"shareId"`
+	SharedAt int64            `json:"sharedAt"`
+	Stocks   []StockDetailsV2 `json:"stocks"`
+}
+
+type CloneSharedWatchListRequest struct {
+	ClientId    string `json:"clientId"`
+	ShareId     string `json:"shareId" validate:"required"`
+	WatchListId string `json:"watchListId" validate:"required,oneof=wl1 wl2 wl3 wl4 wl5"`
+	Replace     bool   `json:"replace"`
+}
+
+type CloneSharedWatchListResponse struct {
+	WatchListId string `json:"watchListId"`
+	Added       int    `json:"added"`
+	Duplicates  int    `json:"duplicates"`
+}
+
+type RevokeSharedWatchListRequest struct {
+	ClientId string `json:"clientId"`
+	ShareId  string `json:"shareId" validate:"required"`
+}
+
+// MongoWatchListShare publishes one of a client's watchlists read-only, the
+// stocks are always read live from the owner's watchlistsStocks document.
+type MongoWatchListShare struct {
+	ShareId     string `json:"shareId" bson:"shareId"`
+	ClientId    string `json:"clientId" bson:"clientId"`
+	WatchListId string `json:"watchListId" bson:"watchListId"`
+	Revoked     bool   `json:"revoked" bson:"revoked"`
+	CreatedAt   int64  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   int64  `json:"updatedAt" bson:"updatedAt"`
+}
+
+// WatchListRule decides the members of a smart watchlist. Sector is used by the
+// sector rule, Index by indexGainers and Limit caps every rule.
+type WatchListRule struct {
+	Type   string `json:"type" bson:"type" validate:"required,oneof=holdings sector indexGainers upcomingResults ipoListing"`
+	Sector string `json:"sector,omitempty" bson:"sector,omitempty"`
+	Index  string `json:"index,omitempty" bson:"index,omitempty"`
+	Limit  int    `json:"limit,omitempty" bson:"limit,omitempty" validate:"gte=0,lte=50"`
+}
+
+type CreateSmartWatchListRequest struct {
+	ClientId string        `json:"clientId"`
+	Name     string        `json:"name" validate:"required,max=40"`
+	Rule     WatchListRule `json:"rule"`
+}
+
+type UpdateSmartWatchListRequest struct {
+	ClientId    string        `json:"clientId"`
+	WatchListId string        `json:"watchListId" validate:"required"`
+	Name        string        `json:"name" validate:"required,max=40"`
+	Rule        WatchListRule `json:"rule"`
+}
+
+type DeleteSmartWatchListRequest struct {
+	ClientId    string `json:"clientId"`
+	WatchListId string `json:"watchListId" validate:"required"`
+}
+
+type MongoSmartWatchList struct {
+	ClientId    string           `json:"clientId" bson:"clientId"`
+	WatchListId string           `json:"watchListId" bson:"watchListId"`
+	Name        string           `json:"name" bson:"name"`
+	Rule        WatchListRule    `json:"rule" bson:"rule"`
+	Stocks      []StockDetailsV2 `json:"stocks" bson:"stocks"`
+	EvaluatedAt int64            `json:"evaluatedAt" bson:"evaluatedAt"`
+	CreatedAt   int64            `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   int64            `json:"updatedAt" bson:"updatedAt"`
+}
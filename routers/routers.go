@@ -1,12 +1,14 @@
 package routers
 
 import (
+	"space/constants"
 	healthController "space/controllers/api/health"
 	apiControllerV1 "space/controllers/api/v1"
 	blockDealController "space/controllers/api/v1"
 	apiControllerV2 "space/controllers/api/v2"
 	apiControllerV3 "space/controllers/api/v3"
 	apiControllerV4 "space/controllers/api/v4"
+	"space/loggerconfig"
 	"space/middlewares"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +21,11 @@ import (
 func SetupRouter() *gin.Engine {
 
 	r := gin.Default()
+	// only the load balancers may say who the client is, otherwise
+	// X-Forwarded-For would pick the IP that rate limits are counted on
+	if err := r.SetTrustedProxies(constants.TrustedProxies); err != nil {
+		loggerconfig.Error("Alert Severity:P1-High, SetupRouter invalid trustedProxies:", err)
+	}
 
 	//Giving access to storage folder
 
@@ -48,11 +55,17 @@ func SetupRouter() *gin.Engine {
 		}
 	})
 
+	r.Use(middlewares.RateLimit())
+
+	// keeps third-party app tokens to the routes their scopes cover
+	r.Use(middlewares.AppScopeAuthorization())
+
 	// Health check api route
 	healthCheck := r.Group("api/space")
 	healthCheck.Use()
 	{
 		healthCheck.GET("/health", healthController.GetHealthStatus)
+		healthCheck.GET("/.well-known/jwks.json", apiControllerV1.FetchJwks)
 	}
 
 	//API route for version 1
@@ -98,6 +111,14 @@ func SetupRouter() *gin.Engine {
 		V2Logout.DELETE("/logout", apiControllerV2.Logout)
 	}
 
+	v2Sessions := r.Group("/api/space/v2/user")
+	v2Sessions.Use(middlewares.UserAuthentication())
+	{
+		v2Sessions.GET("/sessions", apiControllerV2.ListSessions)
+		v2Sessions.POST("/revokeSession", apiControllerV2.RevokeSession)
+		v2Sessions.POST("/logoutOtherDevices", apiControllerV2.LogoutOtherDevices)
+	}
+
 	v3Login := r.Group("/api/space/v3/authapis")
 	v3Login.Use(middlewares.Middleware())
 	{
@@ -252,6 +273,22 @@ func SetupRouter() *gin.Engine {
 	{
 		v3funds.PUT("/cancelPayout", apiControllerV3.CancelPayout)
 		v3funds.POST("/payout", apiControllerV3.Payout)
+		v3funds.POST("/upiCollect", apiControllerV3.UpiCollect)
+		v3funds.POST("/upiIntent", apiControllerV3.UpiIntent)
+		v3funds.POST("/upiPayinStatus", apiControllerV3.UpiPayinStatus)
+	}
+
+	// gateway callbacks carry no user token, the provider verifies their signature
+	v3fundsWebhook := r.Group("/api/space/v3/funds/webhook")
+	{
+		v3fundsWebhook.POST("/upi/:gateway", apiControllerV3.UpiPayinWebhook)
+	}
+
+	v3fundsAdmin := r.Group("/api/space/v3/adminapis/funds")
+	v3fundsAdmin.Use(middlewares.AdminMiddleware())
+	{
+		v3fundsAdmin.POST("/transitionPayout", apiControllerV3.TransitionPayout)
+		v3fundsAdmin.POST("/fetchPayoutHistory", apiControllerV3.FetchPayoutHistory)
 	}
 
 	v1Alerts := r.Group("/api/space/v1/alerts/")
@@ -300,6 +337,10 @@ func SetupRouter() *gin.Engine {
 		v1pocketsAdmin.POST("/fetchCollections", apiControllerV1.FetchCollections)
 		v1pocketsAdmin.GET("/fetchAllCollections", apiControllerV1.FetchAllCollections)
 
+		v1pocketsAdmin.POST("/createAdmin", apiControllerV1.CreateAdmin)
+		v1pocketsAdmin.POST("/updateAdmin", apiControllerV1.UpdateAdmin)
+		v1pocketsAdmin.GET("/fetchAdmins", apiControllerV1.FetchAdmins)
+		v1pocketsAdmin.POST("/fetchAdminAudit", apiControllerV1.FetchAdminAudit)
 	}
 
 	v1collections := r.Group("/api/space/v1/collections")
@@ -369,6 +410,7 @@ func SetupRouter() *gin.Engine {
 		v1Ipo.POST("/placeIpoOrder", apiControllerV1.PlaceIpoOrder)
 		v1Ipo.POST("/fetchIpoOrder", apiControllerV1.FetchIpoOrder)
 		v1Ipo.POST("/cancelIpoOrder", apiControllerV1.CancelIpoOrder)
+		v1Ipo.POST("/fetchIpoHistory", apiControllerV1.FetchIpoHistory)
 	}
 
 	v1Ipo.Use(middlewares.AuthCombinedMiddleware())
@@ -377,6 +419,7 @@ func SetupRouter() *gin.Engine {
 		v1Ipo.GET("/getAllIpo", apiControllerV1.GetAllIpo)
 		v1Ipo.POST("/fetchIpoData", apiControllerV1.FetchIpoData)
 		v1Ipo.POST("/fetchIpoGmpData", apiControllerV1.FetchIpoGmpData)
+		v1Ipo.POST("/fetchIpoSubscriptionHistory", apiControllerV1.FetchIpoSubscriptionHistory)
 	}
 
 	v1Screeners := r.Group("api/space/v1/screeners")
@@ -400,6 +443,21 @@ func SetupRouter() *gin.Engine {
 		v2Screeners.GET("/returnOnInvestment", apiControllerV2.ReturnOnInvestment)
 	}
 
+	v2CustomScreeners := r.Group("api/space/v2/customScreeners")
+	v2CustomScreeners.Use(middlewares.UserAuthentication())
+	{
+		v2CustomScreeners.POST("/run", apiControllerV2.RunScreener)
+		v2CustomScreeners.POST("/save", apiControllerV2.SaveScreener)
+		v2CustomScreeners.GET("/fetch", apiControllerV2.FetchScreeners)
+		v2CustomScreeners.POST("/delete", apiControllerV2.DeleteScreener)
+	}
+
+	v2Backtest := r.Group("api/space/v2/backtest")
+	v2Backtest.Use(middlewares.UserAuthentication())
+	{
+		v2Backtest.POST("/run", apiControllerV2.RunBacktest)
+	}
+
 	v1BasketOrder := r.Group("api/space/v1/basket")
 	v1BasketOrder.Use(middlewares.Middleware())
 	{
@@ -451,10 +509,16 @@ func SetupRouter() *gin.Engine {
 	blockDealGroup := r.Group("/api/v1/blockdeals")
 	{
 		blockDealGroup.GET("/getallblockdeals", blockDealController.GetAllBlockDeals)
-		blockDealGroup.POST("/create", blockDealController.CreateBlockDeal)
 		blockDealGroup.GET("/:cocode", blockDealController.GetBlockDealByCocode)
-		blockDealGroup.PUT("/:cocode", blockDealController.UpdateBlockDeal)
-		blockDealGroup.DELETE("/:cocode", blockDealController.DeleteBlockDeal)
+	}
+
+	blockDealAdmin := r.Group("/api/v1/blockdeals")
+	blockDealAdmin.Use(middlewares.AdminMiddleware())
+	{
+		blockDealAdmin.POST("/create", blockDealController.CreateBlockDeal)
+		blockDealAdmin.POST("/bulk", blockDealController.BulkUpsertBlockDeals)
+		blockDealAdmin.PUT("/:cocode", blockDealController.UpdateBlockDeal)
+		blockDealAdmin.DELETE("/:cocode", blockDealController.DeleteBlockDeal)
 	}
 
 	v1LoginWithQR := r.Group("/api/space/v1/qr")
@@ -471,6 +535,9 @@ func SetupRouter() *gin.Engine {
 		v2Cmots.POST("/fetchPeers", apiControllerV2.FetchPeersV2)
 		v2Cmots.GET("/fetchSectorListV2", apiControllerV2.FetchSectorListV2)
 		v2Cmots.POST("/fetchSectorWiseCompanyV2", apiControllerV2.FetchSectorWiseCompanyV2)
+		v2Cmots.GET("/sectorHeatmap", apiControllerV2.FetchSectorHeatmap)
+		v2Cmots.POST("/compareStocks", apiControllerV2.CompareStocks)
+		v2Cmots.POST("/compareStocks/export", apiControllerV2.ExportStockComparison)
 	}
 
 	v1User := r.Group("/api/space/v1/userDetails")
@@ -577,6 +644,14 @@ func SetupRouter() *gin.Engine {
 		v2Group.GET("/searchScrip", apiControllerV2.SearchScript)
 	}
 
+	// same search, ranked with the client's holdings, watchlists and history
+	v2UserSearch := r.Group("/api/space/v2/userSearch")
+	v2UserSearch.Use(middlewares.UserAuthentication())
+	{
+		v2UserSearch.GET("/searchScrip", apiControllerV2.SearchScript)
+		v2UserSearch.POST("/click", apiControllerV2.RecordSearchClick)
+	}
+
 	v1Warning := r.Group("/api/space/v1/warning")
 	v1BondEtf.Use(middlewares.UserAuthentication())
 	{
@@ -658,6 +733,7 @@ func SetupRouter() *gin.Engine {
 		v1Reports.GET("/downloadOpenPosition", apiControllerV1.DownloadOpenPosition)
 		v1Reports.GET("/viewFnoPnl", apiControllerV1.ViewFnoPnl)
 		v1Reports.GET("/downloadFnoPnl", apiControllerV1.DownloadFnoPnl)
+		v1Reports.GET("/sendEmailFnoPnl", apiControllerV1.SendEmailFnoPnl)
 		v1Reports.GET("/viewHoldingFinancial", apiControllerV1.ViewHoldingFinancial)
 		v1Reports.GET("/downloadHoldingFinancial", apiControllerV1.DownloadHoldingFinancial)
 		v1Reports.GET("/sendEmailLedger", apiControllerV1.SendEmailLedger)
@@ -669,6 +745,11 @@ func SetupRouter() *gin.Engine {
 		v1Reports.GET("/sendEmailFnoTradebook", apiControllerV1.SendEmailFnoTradebook)
 		v1Reports.GET("/sendEmailDPCharges", apiControllerV1.SendEmailDPCharges)
 		v1Reports.GET("/sendEmailHoldingFinancial", apiControllerV1.SendEmailHoldingFinancial)
+		v1Reports.POST("/submitReportJob", apiControllerV1.SubmitReportJob)
+		v1Reports.GET("/reportJobStatus", apiControllerV1.ReportJobStatus)
+		v1Reports.GET("/statementSubscriptions", apiControllerV1.StatementSubscriptions)
+		v1Reports.POST("/subscribeStatement", apiControllerV1.SubscribeStatement)
+		v1Reports.POST("/unsubscribeStatement", apiControllerV1.UnsubscribeStatement)
 	}
 
 	v2pockets := r.Group("/api/space/v2/pockets")
@@ -705,6 +786,7 @@ func SetupRouter() *gin.Engine {
 		v1oauth2Login.POST("/createApp", apiControllerV1.CreateApp)
 		v1oauth2Login.GET("/fetchApps/:clientId", apiControllerV1.FetchApps)
 		v1oauth2Login.DELETE("/deleteApp/:appId", apiControllerV1.DeleteApp)
+		v1oauth2Login.GET("/appActivity/:appId", apiControllerV1.FetchAppActivity)
 	}
 
 	v3WatchList := r.Group("/api/space/v3/watchlist")
@@ -715,6 +797,15 @@ func SetupRouter() *gin.Engine {
 		v3WatchList.DELETE("/deleteStockInWatchList", apiControllerV3.DeleteStockInWatchList)
 		v3WatchList.POST("/arrangeStocksWatchList", apiControllerV3.ArrangeStocksWatchList)
 		v3WatchList.DELETE("/deleteStockInWatchListUpdated", apiControllerV3.DeleteStockInWatchListUpdated)
+		v3WatchList.POST("/importWatchList", apiControllerV3.ImportWatchList)
+		v3WatchList.GET("/exportWatchList", apiControllerV3.ExportWatchList)
+		v3WatchList.POST("/shareWatchList", apiControllerV3.ShareWatchList)
+		v3WatchList.GET("/fetchSharedWatchList", apiControllerV3.FetchSharedWatchList)
+		v3WatchList.POST("/cloneSharedWatchList", apiControllerV3.CloneSharedWatchList)
+		v3WatchList.DELETE("/revokeSharedWatchList", apiControllerV3.RevokeSharedWatchList)
+		v3WatchList.POST("/createSmartWatchList", apiControllerV3.CreateSmartWatchList)
+		v3WatchList.POST("/updateSmartWatchList", apiControllerV3.UpdateSmartWatchList)
+		v3WatchList.DELETE("/deleteSmartWatchList", apiControllerV3.DeleteSmartWatchList)
 	}
 
 	freshdesk := r.Group("/api/space/v1/support")
@@ -773,6 +864,13 @@ func SetupRouter() *gin.Engine {
 		v3Search.GET("/searchScrip", apiControllerV3.SearchScrip)
 	}
 
+	// global search across stocks, pockets, collections, IPOs, bonds and sectors
+	v3GlobalSearch := r.Group("/api/space/v3/search")
+	v3GlobalSearch.Use(middlewares.UserAuthentication())
+	{
+		v3GlobalSearch.GET("", apiControllerV3.GlobalSearch)
+	}
+
 	return r
 
 }
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"space/constants"
+	"space/helpers"
 	"space/loggerconfig"
+	"space/models"
 	"strconv"
 )
 
@@ -88,6 +90,9 @@ func InitConfig(env string) {
 	constants.HoldingFinancialS3FolderName = loggerconfig.GetConfig().GetString(normalPath + constants.ReportsFolderName + ".HoldingFinancial")
 	constants.CommodityTradebookS3FolderName = loggerconfig.GetConfig().GetString(normalPath + constants.ReportsFolderName + ".CommodityTradebook")
 	constants.FnoTradebookS3FolderName = loggerconfig.GetConfig().GetString(normalPath + constants.ReportsFolderName + ".FnoTradebook")
+	constants.StockCompareS3FolderName = loggerconfig.GetConfig().GetString(normalPath + constants.ReportsFolderName + ".StockCompare")
+	constants.WatchListS3FolderName = loggerconfig.GetConfig().GetString(normalPath + constants.ReportsFolderName + ".WatchList")
+	constants.WatchListShareBaseUrl = loggerconfig.GetConfig().GetString(normalPath + ".watchListShareBaseUrl")
 
 	constants.LocalCachingCallEnabled = loggerconfig.GetConfig().GetBool(normalPath + ".LocalCachingCallEnabled")
 	constants.CheckDisplayNameFlag = loggerconfig.GetConfig().GetBool(normalPath + ".displayNameCheck")
@@ -149,6 +154,30 @@ func InitConfig(env string) {
 		constants.AdminSecretKey = loggerconfig.LocalCreds.Local.AdminSecretKey
 	}
 
+	constants.AdminSuperUsers = loggerconfig.GetConfig().GetStringSlice(normalPath + ".adminSuperUsers")
+
+	var jwtKeys models.JwtKeysConfig
+	if err := loggerconfig.GetConfig().UnmarshalKey(secretPath+".jwtKeys", &jwtKeys); err != nil {
+		loggerconfig.Error("Alert Severity:P0-Critical, InitConfig error reading jwtKeys:", err)
+	}
+	if env == constants.LocalEnv {
+		jwtKeys = loggerconfig.LocalCreds.Local.JwtKeys
+	}
+	if err := helpers.InitJwtKeys(jwtKeys); err != nil {
+		loggerconfig.Error("Alert Severity:P0-Critical, InitConfig invalid jwtKeys, tokens stay on the legacy key:", err)
+	}
+
+	constants.FakeUpiSecret = loggerconfig.GetConfig().GetString(secretPath + ".fakeUpiSecret")
+	constants.TradelabReconcileToken = loggerconfig.GetConfig().GetString(secretPath + ".tradelabReconcileToken")
+	if env == constants.LocalEnv {
+		constants.FakeUpiSecret = loggerconfig.LocalCreds.Local.FakeUpiSecret
+		constants.TradelabReconcileToken = loggerconfig.LocalCreds.Local.TradelabReconcileToken
+	}
+
+	constants.RateLimitEnabled = loggerconfig.GetConfig().GetBool(normalPath + ".rateLimit.enabled")
+	constants.RateLimitAllowIps = loggerconfig.GetConfig().GetStringSlice(normalPath + ".rateLimit.allowIps")
+	constants.TrustedProxies = loggerconfig.GetConfig().GetStringSlice(normalPath + ".trustedProxies")
+
 	constants.FileLoggingEnabled = loggerconfig.GetConfig().GetBool(normalPath + ".fileLoggingEnabled")
 	constants.LogFilePath = loggerconfig.GetConfig().GetString(normalPath + ".logFilePath")
 